@@ -1,11 +1,13 @@
 package analyzer
 
 import (
+	"context"
+
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	"github.com/shinyvision/vimfony/internal/config"
 	"github.com/shinyvision/vimfony/internal/doctrine"
 	php "github.com/shinyvision/vimfony/internal/php"
-	"github.com/tliron/glsp"
+	"github.com/shinyvision/vimfony/internal/protocol317"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
@@ -14,16 +16,63 @@ type Analyzer interface {
 	Close()
 }
 
+// The providers of the requests a client cancels while typing stop between
+// their lookups once ctx is done, returning its error.
+
 type CompletionProvider interface {
-	OnCompletion(pos protocol.Position) ([]protocol.CompletionItem, error)
+	OnCompletion(ctx context.Context, pos protocol.Position) ([]protocol.CompletionItem, error)
 }
 
 type DefinitionProvider interface {
-	OnDefinition(pos protocol.Position) ([]protocol.Location, error)
+	OnDefinition(ctx context.Context, pos protocol.Position) ([]protocol.Location, error)
 }
 
 type CodeActionProvider interface {
-	OnCodeAction(context *glsp.Context, params *protocol.CodeActionParams) ([]protocol.CodeAction, error)
+	OnCodeAction(ctx context.Context, params *protocol.CodeActionParams) ([]CodeAction, error)
+}
+
+// CodeAction is a code action whose edit is only built by Resolve, so that
+// listing the actions under the cursor stays cheap.
+type CodeAction struct {
+	protocol.CodeAction
+	edit func() *protocol.WorkspaceEdit
+}
+
+func newCodeAction(title string, kind protocol.CodeActionKind, edit func() *protocol.WorkspaceEdit) CodeAction {
+	return CodeAction{CodeAction: protocol.CodeAction{Title: title, Kind: &kind}, edit: edit}
+}
+
+// Resolve returns the code action with its edit
+func (a CodeAction) Resolve() protocol.CodeAction {
+	action := a.CodeAction
+	if a.edit != nil {
+		action.Edit = a.edit()
+	}
+	return action
+}
+
+type DiagnosticsProvider interface {
+	OnDiagnostics() ([]protocol.Diagnostic, error)
+}
+
+type InlayHintProvider interface {
+	OnInlayHints(rng protocol.Range) ([]protocol317.InlayHint, error)
+}
+
+type SignatureHelpProvider interface {
+	OnSignatureHelp(pos protocol.Position) (*protocol.SignatureHelp, error)
+}
+
+type CodeLensProvider interface {
+	OnCodeLens() ([]protocol.CodeLens, error)
+}
+
+type FoldingRangeProvider interface {
+	OnFoldingRanges() ([]protocol.FoldingRange, error)
+}
+
+type DocumentLinkProvider interface {
+	OnDocumentLinks() ([]protocol.DocumentLink, error)
 }
 
 type ContainerAware interface {
@@ -49,3 +98,13 @@ type DocumentPathAware interface {
 type DoctrineAware interface {
 	SetDoctrineRegistry(registry *doctrine.Registry)
 }
+
+type SnippetAware interface {
+	SetSnippetSupport(enabled bool)
+}
+
+// FeaturesAware analyzers leave out the providers of the subsystems the
+// features option turns off
+type FeaturesAware interface {
+	SetFeatures(features config.Features)
+}
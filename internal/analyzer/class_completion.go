@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Completes the classes of the autoload map, replacing the prefix typed
+// before pos. Nothing is offered before the first character, the index also
+// holds every vendor class.
+func classCompletionItems(autoload config.AutoloadMap, pos protocol.Position, prefix, suffix string) []protocol.CompletionItem {
+	prefix = strings.TrimPrefix(prefix, "\\")
+	if prefix == "" {
+		return nil
+	}
+	lowerPrefix := strings.ToLower(prefix)
+	rng := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: pos.Character - utils.Character([]byte(prefix), len(prefix))},
+		End:   pos,
+	}
+
+	kind := protocol.CompletionItemKindClass
+	items := []protocol.CompletionItem{}
+	for short, classes := range autoload.Classes {
+		shortMatch := strings.HasPrefix(strings.ToLower(short), lowerPrefix)
+		for _, class := range classes {
+			if !shortMatch && !strings.HasPrefix(strings.ToLower(class), lowerPrefix) {
+				continue
+			}
+			filter := class
+			if shortMatch {
+				filter = short
+			}
+			items = append(items, protocol.CompletionItem{
+				Label:      class,
+				Kind:       &kind,
+				FilterText: &filter,
+				TextEdit:   protocol.TextEdit{Range: rng, NewText: class + suffix},
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
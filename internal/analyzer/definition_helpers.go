@@ -3,8 +3,10 @@ package analyzer
 import (
 	"strings"
 
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	"github.com/shinyvision/vimfony/internal/config"
 	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/twig"
 	"github.com/shinyvision/vimfony/internal/utils"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
@@ -15,6 +17,16 @@ func normalizeFQN(name string) string {
 	return name
 }
 
+// Resolves a Twig path to its template, preceded by the templates/bundles
+// override of bundle templates
+func templateLocations(twigPath string, container *config.ContainerConfig) ([]protocol.Location, bool) {
+	var locs []protocol.Location
+	for _, target := range twig.ResolveAll(twigPath, container) {
+		locs = append(locs, protocol.Location{URI: protocol.DocumentUri(utils.PathToURI(target))})
+	}
+	return locs, len(locs) > 0
+}
+
 func resolveClassLocations(className string, container *config.ContainerConfig, autoload config.AutoloadMap, store *php.DocumentStore) ([]protocol.Location, bool) {
 	if container == nil || autoload.IsEmpty() || store == nil {
 		return nil, false
@@ -38,11 +50,15 @@ func resolveServiceIDLocations(serviceID string, container *config.ContainerConf
 	if container == nil {
 		return nil, false
 	}
-	className, ok := container.ResolveServiceId(serviceID)
-	if !ok {
-		return nil, false
+	// The class first, then every <service> declaring the id
+	var locations []protocol.Location
+	if className, ok := container.ResolveServiceId(serviceID); ok {
+		if locs, ok := resolveClassLocations(className, container, autoload, store); ok {
+			locations = locs
+		}
 	}
-	return resolveClassLocations(className, container, autoload, store)
+	locations = append(locations, container.ServiceDeclarations(serviceID)...)
+	return locations, len(locations) > 0
 }
 
 func resolveRouteLocations(route config.Route, uri string, doc *php.Document) []protocol.Location {
@@ -55,7 +71,13 @@ func resolveRouteLocations(route config.Route, uri string, doc *php.Document) []
 		method = "__invoke"
 	}
 
-	index := doc.Index()
+	var (
+		index   php.IndexedTree
+		content []byte
+	)
+	doc.Read(func(_ *sitter.Tree, c []byte, idx php.IndexedTree) {
+		index, content = idx, c
+	})
 	if len(index.PublicFunctions) == 0 {
 		return nil
 	}
@@ -71,7 +93,7 @@ func resolveRouteLocations(route config.Route, uri string, doc *php.Document) []
 			if !strings.HasSuffix(publicMethod.Name, target) {
 				continue
 			}
-			if rng, ok := lineColumnRangeToProtocol(publicMethod.Range); ok {
+			if rng, ok := lineColumnRangeToProtocol(publicMethod.Range, content); ok {
 				resultURI := publicMethod.URI
 				if resultURI == "" {
 					resultURI = uri
@@ -128,7 +150,13 @@ func indexedMethodRange(path, className, method string, doc *php.Document, store
 		return protocol.Range{}, false
 	}
 
-	index := analysisDoc.Index()
+	var (
+		index   php.IndexedTree
+		content []byte
+	)
+	analysisDoc.Read(func(_ *sitter.Tree, c []byte, idx php.IndexedTree) {
+		index, content = idx, c
+	})
 	if len(index.PublicFunctions) == 0 {
 		return protocol.Range{}, false
 	}
@@ -138,7 +166,7 @@ func indexedMethodRange(path, className, method string, doc *php.Document, store
 
 	for _, fn := range index.PublicFunctions {
 		if fn.Name == target {
-			if rng, ok := lineColumnRangeToProtocol(fn.Range); ok {
+			if rng, ok := lineColumnRangeToProtocol(fn.Range, content); ok {
 				return rng, true
 			}
 		}
@@ -146,7 +174,7 @@ func indexedMethodRange(path, className, method string, doc *php.Document, store
 
 	for _, fn := range index.PublicFunctions {
 		if strings.HasPrefix(fn.Name, prefix) {
-			if rng, ok := lineColumnRangeToProtocol(fn.Range); ok {
+			if rng, ok := lineColumnRangeToProtocol(fn.Range, content); ok {
 				return rng, true
 			}
 		}
@@ -155,7 +183,9 @@ func indexedMethodRange(path, className, method string, doc *php.Document, store
 	return protocol.Range{}, false
 }
 
-func lineColumnRangeToProtocol(r php.LineColumnRange) (protocol.Range, bool) {
+// lineColumnRangeToProtocol converts r, indexed from content, to the
+// characters the client counts.
+func lineColumnRangeToProtocol(r php.LineColumnRange, content []byte) (protocol.Range, bool) {
 	if r.StartLine <= 0 && r.EndLine <= 0 {
 		return protocol.Range{}, false
 	}
@@ -173,8 +203,8 @@ func lineColumnRangeToProtocol(r php.LineColumnRange) (protocol.Range, bool) {
 		endCol = startCol
 	}
 	return protocol.Range{
-		Start: protocol.Position{Line: uint32(startLine), Character: uint32(startCol)},
-		End:   protocol.Position{Line: uint32(endLine), Character: uint32(endCol)},
+		Start: utils.Position(content, startLine, startCol),
+		End:   utils.Position(content, endLine, endCol),
 	}, true
 }
 
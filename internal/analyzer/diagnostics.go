@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"fmt"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const diagnosticSource = "vimfony"
+
+func (a *twigAnalyzer) OnDiagnostics() ([]protocol.Diagnostic, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.tree == nil {
+		return nil, nil
+	}
+
+	diagnostics := syntaxDiagnostics(a.tree.RootNode(), a.content)
+	diagnostics = append(diagnostics, a.routeDiagnostics()...)
+	return diagnostics, nil
+}
+
+func (a *phpAnalyzer) OnDiagnostics() ([]protocol.Diagnostic, error) {
+	diagnostics := a.routeDiagnostics()
+	diagnostics = append(diagnostics, a.duplicateRouteDiagnostics()...)
+	return diagnostics, nil
+}
+
+func (a *yamlAnalyzer) OnDiagnostics() ([]protocol.Diagnostic, error) {
+	return a.configReferenceDiagnostics(), nil
+}
+
+func newDiagnostic(rng protocol.Range, severity protocol.DiagnosticSeverity, message string) protocol.Diagnostic {
+	source := diagnosticSource
+	return protocol.Diagnostic{
+		Range:    rng,
+		Severity: &severity,
+		Source:   &source,
+		Message:  message,
+	}
+}
+
+// Returns the range of n in content, in the characters the client counts
+func nodeRange(n sitter.Node, content []byte) protocol.Range {
+	sp, ep := n.StartPoint(), n.EndPoint()
+	return protocol.Range{
+		Start: utils.Position(content, int(sp.Row), int(sp.Column)),
+		End:   utils.Position(content, int(ep.Row), int(ep.Column)),
+	}
+}
+
+// Depth-first walk over every named node below (and including) root
+func walkNodes(root sitter.Node, fn func(n sitter.Node)) {
+	if root.IsNull() {
+		return
+	}
+	stack := []sitter.Node{root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		fn(n)
+		for i := int(n.NamedChildCount()) - 1; i >= 0; i-- {
+			stack = append(stack, n.NamedChild(uint32(i)))
+		}
+	}
+}
+
+// Reports tree-sitter ERROR and MISSING nodes. Children of an ERROR node are
+// skipped so a single broken tag does not produce a cascade of diagnostics.
+func syntaxDiagnostics(root sitter.Node, content []byte) []protocol.Diagnostic {
+	if root.IsNull() || !root.HasError() {
+		return nil
+	}
+
+	var diagnostics []protocol.Diagnostic
+	stack := []sitter.Node{root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch {
+		case n.Type() == "ERROR":
+			diagnostics = append(diagnostics, newDiagnostic(nodeRange(n, content), protocol.DiagnosticSeverityError, "Syntax error"))
+			continue
+		case n.IsMissing():
+			diagnostics = append(diagnostics, newDiagnostic(nodeRange(n, content), protocol.DiagnosticSeverityError, fmt.Sprintf("Syntax error: missing '%s'", n.Type())))
+			continue
+		}
+
+		if !n.HasError() {
+			continue
+		}
+		for i := int(n.ChildCount()) - 1; i >= 0; i-- {
+			stack = append(stack, n.Child(uint32(i)))
+		}
+	}
+	return diagnostics
+}
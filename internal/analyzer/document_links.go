@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	php "github.com/shinyvision/vimfony/internal/php"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// A string that may link to a template, service or class
+type linkCandidate struct {
+	value string
+	rng   protocol.Range
+	// Set when the value is a service id without its @
+	service bool
+}
+
+// Returns the file a string links to: the template of a Twig path, the class
+// of an @service reference or the file declaring a class
+func documentLinkTarget(value string, service bool, container *config.ContainerConfig, autoload config.AutoloadMap, store *php.DocumentStore, features config.Features) (protocol.DocumentUri, bool) {
+	var locs []protocol.Location
+	var ok bool
+	switch id, isRef := strings.CutPrefix(value, "@"); {
+	case service:
+		locs, ok = resolveServiceIDLocations(value, container, autoload, store)
+	case strings.HasSuffix(value, ".twig"):
+		if features.Enabled(config.FeatureTemplates) {
+			locs, ok = templateLocations(value, container)
+		}
+	case isRef && !strings.HasPrefix(id, "@"):
+		locs, ok = resolveServiceIDLocations(strings.TrimPrefix(id, "?"), container, autoload, store)
+	case strings.Contains(value, "\\"):
+		// Controller::action strings link to the controller
+		class, _, _ := strings.Cut(value, "::")
+		locs, ok = resolveClassLocations(class, container, autoload, store)
+	}
+	if !ok || len(locs) == 0 {
+		return "", false
+	}
+	return locs[0].URI, true
+}
+
+func documentLinks(candidates []linkCandidate, container *config.ContainerConfig, autoload config.AutoloadMap, store *php.DocumentStore, features config.Features) []protocol.DocumentLink {
+	links := []protocol.DocumentLink{}
+	if container == nil {
+		return links
+	}
+	for _, c := range candidates {
+		if c.value == "" {
+			continue
+		}
+		if target, ok := documentLinkTarget(c.value, c.service, container, autoload, store, features); ok {
+			links = append(links, protocol.DocumentLink{Range: c.rng, Target: &target})
+		}
+	}
+	return links
+}
+
+// Returns the range of a quoted string without its quotes
+func unquotedRange(n sitter.Node, content []byte) protocol.Range {
+	rng := nodeRange(n, content)
+	if rng.Start.Line == rng.End.Line && rng.End.Character-rng.Start.Character >= 2 {
+		rng.Start.Character++
+		rng.End.Character--
+	}
+	return rng
+}
+
+func (a *twigAnalyzer) OnDocumentLinks() ([]protocol.DocumentLink, error) {
+	a.mu.RLock()
+	var candidates []linkCandidate
+	if a.tree != nil {
+		walkNodes(a.tree.RootNode(), func(n sitter.Node) {
+			if n.Type() == "string" {
+				candidates = append(candidates, linkCandidate{value: a.stringContent(n), rng: unquotedRange(n, a.content)})
+			}
+		})
+	}
+	container, autoload, store, features := a.container, a.autoload, a.docStore, a.features
+	a.mu.RUnlock()
+
+	return documentLinks(candidates, container, autoload, store, features), nil
+}
+
+func (a *phpAnalyzer) OnDocumentLinks() ([]protocol.DocumentLink, error) {
+	a.mu.RLock()
+	container, autoload, store, features := a.container, a.autoload, a.docStore, a.features
+	a.mu.RUnlock()
+
+	if a.doc == nil {
+		return nil, nil
+	}
+	var candidates []linkCandidate
+	a.doc.Read(func(tree *sitter.Tree, content []byte, _ php.IndexedTree) {
+		if tree == nil {
+			return
+		}
+		walkNodes(tree.RootNode(), func(n sitter.Node) {
+			if n.Type() != "string" && n.Type() != "encapsed_string" {
+				return
+			}
+			value := phpStringLiteral(n, content)
+			// Interpolated strings are only known at runtime
+			if n.Type() == "encapsed_string" && strings.Contains(value, "$") {
+				return
+			}
+			candidates = append(candidates, linkCandidate{value: value, rng: unquotedRange(n, content)})
+		})
+	})
+
+	return documentLinks(candidates, container, autoload, store, features), nil
+}
+
+func (a *yamlAnalyzer) OnDocumentLinks() ([]protocol.DocumentLink, error) {
+	var candidates []linkCandidate
+	var visit func(n *yamllib.Node)
+	visit = func(n *yamllib.Node) {
+		// Service ids are often class names: App\Service\Mailer: ~
+		if strings.Contains(n.Key, "\\") {
+			candidates = append(candidates, linkCandidate{value: n.Key, rng: n.KeyRange})
+		}
+		if n.Kind == yamllib.Scalar {
+			rng := n.Range
+			if line := int(rng.Start.Line); line < len(a.lines) && rng.Start.Line == rng.End.Line {
+				if start := byteColumn(a.lines[line], rng.Start.Character); start < len(a.lines[line]) && strings.ContainsRune(`'"`, rune(a.lines[line][start])) {
+					rng.Start.Character++
+					rng.End.Character--
+				}
+			}
+			candidates = append(candidates, linkCandidate{value: n.Value, rng: rng})
+		}
+		for _, child := range n.Children {
+			visit(child)
+		}
+	}
+	for _, doc := range a.docs {
+		visit(doc)
+	}
+	return documentLinks(candidates, a.container, a.autoload, a.store, a.features), nil
+}
+
+// Attributes holding a service id, by element
+var xmlServiceReferenceAttributes = map[string][]string{
+	"service":      {"alias", "parent", "decorates"},
+	"factory":      {"service"},
+	"configurator": {"service"},
+}
+
+func (a *xmlAnalyzer) OnDocumentLinks() ([]protocol.DocumentLink, error) {
+	a.mu.RLock()
+	var candidates []linkCandidate
+	if a.tree != nil {
+		walkNodes(a.tree.RootNode(), func(n sitter.Node) {
+			if n.Type() != "STag" && n.Type() != "EmptyElemTag" {
+				return
+			}
+			tagName := a.tagNameFromTagNode(n)
+			attrs := a.tagAttributes(n)
+			services := make(map[string]bool)
+			for _, name := range xmlServiceReferenceAttributes[tagName] {
+				services[name] = true
+			}
+			if typ, ok := attrs["type"]; ok && tagName == "argument" && a.attValue(typ) == "service" {
+				services["id"] = true
+			}
+			for i := uint32(0); i < n.NamedChildCount(); i++ {
+				attr := n.NamedChild(i)
+				if attr.Type() != "Attribute" {
+					continue
+				}
+				name := a.attributeName(attr)
+				if value, ok := attrs[name]; ok {
+					candidates = append(candidates, linkCandidate{value: a.attValue(value), rng: unquotedRange(value, a.content), service: services[name]})
+				}
+			}
+		})
+	}
+	container, autoload, store, features := a.container, a.autoload, a.store, a.features
+	a.mu.RUnlock()
+
+	return documentLinks(candidates, container, autoload, store, features), nil
+}
@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Matches %env(NAME, %env(bool:NAME and env('NAME up to the caret
+var envVarPrefixRe = regexp.MustCompile(`(?:%env\(|\benv\(\s*['"])(?:[\w-]+:)*(\w*)$`)
+
+// Returns the env var name typed so far when the line ends inside an env
+// placeholder or env() call
+func envVarPrefix(linePrefix string) (string, bool) {
+	m := envVarPrefixRe.FindStringSubmatch(linePrefix)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+func envVarCompletionItems(container *config.ContainerConfig, prefix string) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindVariable
+	items := []protocol.CompletionItem{}
+	for name, value := range container.EnvVars {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: name, Kind: &kind}
+		if value != "" {
+			detail := value
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// Matches the processor typed after %env( or after the processors before it
+var envProcessorPrefixRe = regexp.MustCompile(`%env\((?:[\w-]+:)*(\w*)$`)
+
+// Built-in env var processors
+var envProcessors = []struct {
+	name   string
+	detail string
+}{
+	{"base64", "Decodes a base64 value"},
+	{"bool", "Casts to bool"},
+	{"const", "Value of the PHP constant named by the variable"},
+	{"csv", "Decodes a CSV line into an array"},
+	{"default", "Falls back to a parameter when empty, default:param_name:"},
+	{"enum", "Backed enum case, enum:App\\Enum:"},
+	{"file", "Contents of the file the variable points to"},
+	{"float", "Casts to float"},
+	{"int", "Casts to int"},
+	{"json", "Decodes a JSON value"},
+	{"key", "Item of an array, key:name:"},
+	{"not", "Casts to bool and negates"},
+	{"query_string", "Decodes a query string into an array"},
+	{"require", "Returns the value a PHP file returns"},
+	{"resolve", "Resolves the parameters in the value"},
+	{"shuffle", "Shuffles an array"},
+	{"string", "Casts to string"},
+	{"trim", "Trims the value"},
+	{"url", "Parses a URL into its parts"},
+	{"urlencode", "URL-encodes the value"},
+}
+
+// Returns the processor name typed so far inside a %env() placeholder
+func envProcessorPrefix(linePrefix string) (string, bool) {
+	m := envProcessorPrefixRe.FindStringSubmatch(linePrefix)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+func envProcessorCompletionItems(prefix string) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindFunction
+	items := []protocol.CompletionItem{}
+	for _, processor := range envProcessors {
+		if !strings.HasPrefix(processor.name, prefix) {
+			continue
+		}
+		detail := processor.detail
+		insert := processor.name + ":"
+		items = append(items, protocol.CompletionItem{Label: processor.name, Kind: &kind, Detail: &detail, InsertText: &insert})
+	}
+	return items
+}
@@ -1,9 +1,11 @@
 package analyzer
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -24,11 +26,13 @@ type phpAnalyzer struct {
 	servicesRe     *regexp.Regexp
 	container      *config.ContainerConfig
 	routes         config.RoutesMap
+	routeNames     *routeNameCompletions
 	doc            *php.Document
 	docStore       *php.DocumentStore
 	autoload       config.AutoloadMap
 	path           string
 	doctrine       *doctrine.Registry
+	features       config.Features
 }
 
 type phpCallCtx struct {
@@ -236,9 +240,11 @@ func (a *phpAnalyzer) SetRoutes(routes *config.RoutesMap) {
 	defer a.mu.Unlock()
 	if routes == nil {
 		a.routes = nil
+		a.routeNames = nil
 		return
 	}
 	a.routes = *routes
+	a.routeNames = newRouteNameCompletions(a.routes)
 }
 
 func (a *phpAnalyzer) SetAutoloadMap(autoload *config.AutoloadMap) {
@@ -277,7 +283,13 @@ func (a *phpAnalyzer) SetDoctrineRegistry(registry *doctrine.Registry) {
 	a.doctrine = registry
 }
 
-func (a *phpAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.CompletionItem, error) {
+func (a *phpAnalyzer) SetFeatures(features config.Features) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.features = features
+}
+
+func (a *phpAnalyzer) OnCompletion(ctx context.Context, pos protocol.Position) ([]protocol.CompletionItem, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -288,9 +300,23 @@ func (a *phpAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.Completion
 			servicePrefix := strings.TrimPrefix(prefix, "@")
 			items = append(items, a.serviceCompletionItems(servicePrefix)...)
 		}
+		items = append(items, a.autowireCompletionItems(pos)...)
+		items = append(items, a.containerGetCompletionItems(pos)...)
+		items = append(items, a.getParameterCompletionItems(pos)...)
+		items = append(items, a.envPlaceholderCompletionItems(pos)...)
+		items = append(items, a.securityAttributeCompletionItems(pos)...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	items = append(items, a.twigTemplateCompletionItems(pos)...)
+	items = append(items, a.routeAttributeCompletionItems(pos)...)
+	items = append(items, a.eventNameCompletionItems(pos)...)
+	items = append(items, a.formFieldCompletionItems(pos)...)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	if len(a.routes) > 0 {
 		items = append(items, a.phpRouteNameCompletionItems(pos)...)
@@ -299,6 +325,11 @@ func (a *phpAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.Completion
 
 	if a.container != nil {
 		items = append(items, a.translationCompletionItems(pos)...)
+		items = append(items, a.translationDomainCompletionItems(pos)...)
+		items = append(items, a.translationPlaceholderCompletionItems(pos)...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	qbItems := a.queryBuilderCompletionItems(pos)
@@ -313,7 +344,7 @@ func (a *phpAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.Completion
 	return items, nil
 }
 
-func (a *phpAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location, error) {
+func (a *phpAnalyzer) OnDefinition(ctx context.Context, pos protocol.Position) ([]protocol.Location, error) {
 	var content string
 	if a.doc != nil {
 		a.doc.Read(func(_ *sitter.Tree, data []byte, _ php.IndexedTree) {
@@ -324,6 +355,7 @@ func (a *phpAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location,
 	a.mu.RLock()
 	container := a.container
 	autoload := a.autoload
+	features := a.features
 	a.mu.RUnlock()
 
 	if container == nil {
@@ -337,14 +369,13 @@ func (a *phpAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location,
 	if locs, ok := a.queryBuilderDefinition(pos); ok {
 		return locs, nil
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	if twigPath, ok := twig.PathAt(content, pos); ok {
-		if target, ok := twig.Resolve(twigPath, container); ok {
-			loc := protocol.Location{
-				URI:   protocol.DocumentUri(utils.PathToURI(target)),
-				Range: protocol.Range{},
-			}
-			return []protocol.Location{loc}, nil
+	if twigPath, ok := twig.PathAt(content, pos); ok && features.Enabled(config.FeatureTemplates) {
+		if locs, ok := templateLocations(twigPath, container); ok {
+			return locs, nil
 		}
 	}
 
@@ -353,6 +384,9 @@ func (a *phpAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location,
 			return locs, nil
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	if locs, ok := a.resolveServiceDefinition(content, pos, container, autoload); ok {
 		return locs, nil
@@ -412,7 +446,7 @@ func (a *phpAnalyzer) phpRouteNameCompletionItems(pos protocol.Position) []proto
 	if !found {
 		return nil
 	}
-	return makeRouteNameCompletionItems(a.routes, prefix)
+	return a.routeNames.complete(prefix)
 }
 
 func (a *phpAnalyzer) phpRouteParameterCompletionItems(pos protocol.Position) []protocol.CompletionItem {
@@ -424,7 +458,7 @@ func (a *phpAnalyzer) phpRouteParameterCompletionItems(pos protocol.Position) []
 }
 
 func (a *phpAnalyzer) twigTemplateCompletionItems(pos protocol.Position) []protocol.CompletionItem {
-	if a.container == nil {
+	if a.container == nil || !a.features.Enabled(config.FeatureTemplates) {
 		return nil
 	}
 	strNode, ok := a.twigRenderContextAt(pos)
@@ -492,21 +526,10 @@ func (a *phpAnalyzer) isTypingPhpRouteParameter(pos protocol.Position) (bool, st
 	return true, routeName, a.stringPrefix(ctx.strNode, pos)
 }
 
-func (a *phpAnalyzer) phpRouteContextAt(pos protocol.Position) (phpCallCtx, bool) {
-	if a.doc == nil {
-		return phpCallCtx{}, false
-	}
-
-	node, content, index, ok := a.doc.GetNodeAt(pos)
-	if !ok {
-		return phpCallCtx{}, false
-	}
-
-	controllerTarget := strings.ToLower(normalizeFQN(abstractControllerFQN))
-	if controllerTarget == "" {
-		return phpCallCtx{}, false
-	}
-
+// Finds the string literal at node and the nearest argument holding it, be it
+// the string itself or an array around it, along with the call, `new` or
+// attribute that the argument is passed to
+func stringArgumentAt(node sitter.Node) (sitter.Node, sitter.Node, sitter.Node, bool) {
 	var str sitter.Node
 	for cur := node; !cur.IsNull(); cur = cur.Parent() {
 		if str.IsNull() {
@@ -525,230 +548,172 @@ func (a *phpAnalyzer) phpRouteContextAt(pos protocol.Position) (phpCallCtx, bool
 			continue
 		}
 
-		argNode := cur
-		argsNode := argNode.Parent()
-		if argsNode.IsNull() || argsNode.Type() != "arguments" {
-			return phpCallCtx{}, false
-		}
-
-		argIndex := -1
-		for i := uint32(0); i < argsNode.NamedChildCount(); i++ {
-			if argsNode.NamedChild(i).Equal(argNode) {
-				argIndex = int(i)
-				break
-			}
-		}
-		if argIndex < 0 {
-			return phpCallCtx{}, false
-		}
-
-		callNode := argsNode.Parent()
-		for !callNode.IsNull() && callNode.Type() != "member_call_expression" {
-			callNode = callNode.Parent()
-		}
-		if callNode.IsNull() || callNode.Type() != "member_call_expression" {
-			return phpCallCtx{}, false
+		argsNode := cur.Parent()
+		if str.IsNull() || argsNode.IsNull() || argsNode.Type() != "arguments" {
+			break
 		}
+		return str, cur, argsNode.Parent(), true
+	}
+	return sitter.Node{}, sitter.Node{}, sitter.Node{}, false
+}
 
-		nameNode := callNode.ChildByFieldName("name")
-		if nameNode.IsNull() {
-			return phpCallCtx{}, false
+// Returns the position of an argument in its argument list
+func argumentIndex(arg sitter.Node) int {
+	argsNode := arg.Parent()
+	for i := uint32(0); !argsNode.IsNull() && i < argsNode.NamedChildCount(); i++ {
+		if argsNode.NamedChild(i).Equal(arg) {
+			return int(i)
 		}
+	}
+	return -1
+}
 
-		objectNode := callNode.ChildByFieldName("object")
-		if objectNode.IsNull() {
-			return phpCallCtx{}, false
-		}
+func isMemberCall(n sitter.Node) bool {
+	return !n.IsNull() && (n.Type() == "member_call_expression" || n.Type() == "nullsafe_member_call_expression")
+}
 
-		methodName := strings.TrimSpace(nameNode.Content(content))
-		switch methodName {
-		case "generate":
-			callLine := int(callNode.StartPoint().Row) + 1
-			funcName := ""
-			for candidate := callNode; !candidate.IsNull(); candidate = candidate.Parent() {
-				switch candidate.Type() {
-				case "method_declaration", "function_definition", "function_declaration":
-					funcName = functionIdentifierContent(content, candidate)
-				}
-				if funcName != "" {
-					break
-				}
-			}
+func (a *phpAnalyzer) phpRouteContextAt(pos protocol.Position) (phpCallCtx, bool) {
+	if a.doc == nil {
+		return phpCallCtx{}, false
+	}
 
-			propertyName := thisPropertyNameFromMemberAccessContent(content, objectNode)
-			if propertyName != "" {
-				if !propertyHasRouterTypeIndex(index, propertyName) {
-					return phpCallCtx{}, false
-				}
-				if str.IsNull() {
-					return phpCallCtx{}, false
-				}
-				return phpCallCtx{
-					callNode: callNode,
-					argsNode: argsNode,
-					argIndex: argIndex,
-					strNode:  str,
-					property: propertyName,
-				}, true
-			}
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return phpCallCtx{}, false
+	}
 
-			if objectNode.Type() == "variable_name" {
-				varName := php.VariableNameFromNode(objectNode, content)
-				if varName == "" {
-					return phpCallCtx{}, false
-				}
-				if !variableHasRouterTypeIndex(index, funcName, varName, callLine) {
-					return phpCallCtx{}, false
-				}
-				if str.IsNull() {
-					return phpCallCtx{}, false
-				}
-				return phpCallCtx{
-					callNode: callNode,
-					argsNode: argsNode,
-					argIndex: argIndex,
-					strNode:  str,
-					variable: varName,
-				}, true
-			}
-		case "generateUrl", "redirectToRoute":
-			if !isThisVariable(objectNode, content) {
-				return phpCallCtx{}, false
-			}
-			if !classExtendsAbstractControllerIndex(index, callNode, controllerTarget) {
-				return phpCallCtx{}, false
-			}
-			if str.IsNull() {
-				return phpCallCtx{}, false
-			}
-			return phpCallCtx{
-				callNode: callNode,
-				argsNode: argsNode,
-				argIndex: argIndex,
-				strNode:  str,
-			}, true
-		default:
-			return phpCallCtx{}, false
-		}
+	controllerTarget := strings.ToLower(normalizeFQN(abstractControllerFQN))
+	if controllerTarget == "" {
+		return phpCallCtx{}, false
+	}
 
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok || !isMemberCall(callNode) {
 		return phpCallCtx{}, false
 	}
 
-	return phpCallCtx{}, false
+	property, variable, ok := routeCallTarget(a.doc, callNode, content, index, controllerTarget)
+	if !ok {
+		return phpCallCtx{}, false
+	}
+	return phpCallCtx{
+		callNode: callNode,
+		argsNode: arg.Parent(),
+		argIndex: argumentIndex(arg),
+		strNode:  str,
+		property: property,
+		variable: variable,
+	}, true
 }
 
-func (a *phpAnalyzer) twigRenderContextAt(pos protocol.Position) (sitter.Node, bool) {
-	if a.doc == nil {
-		return sitter.Node{}, false
+// Reports whether the call generates a URL from a route name: generate() of
+// a router property or variable or of one a method returns, or generateUrl()
+// and redirectToRoute() of an AbstractController. The property or variable holding the router is
+// returned along.
+func routeCallTarget(doc *php.Document, callNode sitter.Node, content []byte, index php.IndexedTree, controllerTarget string) (string, string, bool) {
+	nameNode := callNode.ChildByFieldName("name")
+	if nameNode.IsNull() {
+		return "", "", false
 	}
 
-	node, content, index, ok := a.doc.GetNodeAt(pos)
-	if !ok {
-		return sitter.Node{}, false
+	objectNode := callNode.ChildByFieldName("object")
+	if objectNode.IsNull() {
+		return "", "", false
 	}
 
-	var str sitter.Node
-	for cur := node; !cur.IsNull(); cur = cur.Parent() {
-		if str.IsNull() {
-			switch cur.Type() {
-			case "string":
-				str = cur
-			case "string_content":
-				parent := cur.Parent()
-				if !parent.IsNull() && parent.Type() == "string" {
-					str = parent
-				}
+	methodName := strings.TrimSpace(nameNode.Content(content))
+	switch methodName {
+	case "generate":
+		callLine := int(callNode.StartPoint().Row) + 1
+		funcName := ""
+		for candidate := callNode; !candidate.IsNull(); candidate = candidate.Parent() {
+			switch candidate.Type() {
+			case "method_declaration", "function_definition", "function_declaration":
+				funcName = functionIdentifierContent(content, candidate)
+			}
+			if funcName != "" {
+				break
 			}
 		}
 
-		if cur.Type() != "argument" {
-			continue
+		propertyName := thisPropertyNameFromMemberAccessContent(content, objectNode)
+		if propertyName != "" {
+			return propertyName, "", propertyHasRouterTypeIndex(index, propertyName)
 		}
 
-		argNode := cur
-		argsNode := argNode.Parent()
-		if argsNode.IsNull() || argsNode.Type() != "arguments" {
-			return sitter.Node{}, false
+		if isThisVariable(objectNode, content) {
+			// A router or URL generator of its own
+			return "", "", classHasTypeIndex(index, callNode, canonicalRouterType)
 		}
 
-		argIndex := -1
-		for i := uint32(0); i < argsNode.NamedChildCount(); i++ {
-			if argsNode.NamedChild(i).Equal(argNode) {
-				argIndex = int(i)
-				break
+		if objectNode.Type() == "variable_name" {
+			varName := php.VariableNameFromNode(objectNode, content)
+			if varName == "" {
+				return "", "", false
 			}
-		}
-		if argIndex != 0 {
-			return sitter.Node{}, false
+			return "", varName, variableHasRouterTypeIndex(index, funcName, varName, callLine)
 		}
 
-		callNode := argsNode.Parent()
-		for !callNode.IsNull() {
-			typ := callNode.Type()
-			if typ == "member_call_expression" || typ == "nullsafe_member_call_expression" {
-				break
-			}
-			callNode = callNode.Parent()
-		}
-		if callNode.IsNull() {
-			return sitter.Node{}, false
+		// A router returned by a getter or a factory
+		return "", "", callHasTypeIndex(doc, objectNode, funcName, canonicalRouterType)
+	case "generateUrl", "redirectToRoute":
+		if !isThisVariable(objectNode, content) {
+			return "", "", false
 		}
+		return "", "", classExtendsAbstractControllerIndex(index, callNode, controllerTarget)
+	}
+	return "", "", false
+}
 
-		nameNode := callNode.ChildByFieldName("name")
-		if nameNode.IsNull() {
-			return sitter.Node{}, false
-		}
-		methodName := strings.TrimSpace(nameNode.Content(content))
-		if methodName != "render" {
-			return sitter.Node{}, false
-		}
+func (a *phpAnalyzer) twigRenderContextAt(pos protocol.Position) (sitter.Node, bool) {
+	if a.doc == nil {
+		return sitter.Node{}, false
+	}
 
-		objectNode := callNode.ChildByFieldName("object")
-		if objectNode.IsNull() || str.IsNull() {
-			return sitter.Node{}, false
-		}
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return sitter.Node{}, false
+	}
 
-		callLine := int(callNode.StartPoint().Row) + 1
-		controllerTarget := strings.ToLower(normalizeFQN(abstractControllerFQN))
-
-		switch objectNode.Type() {
-		case "variable_name":
-			name := strings.TrimSpace(objectNode.Content(content))
-			if name == "$this" {
-				if controllerTarget == "" || !classExtendsAbstractControllerIndex(index, callNode, controllerTarget) {
-					return sitter.Node{}, false
-				}
-				return str, true
-			}
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok || argumentIndex(arg) != 0 || !isMemberCall(callNode) {
+		return sitter.Node{}, false
+	}
+	return str, a.isRenderCall(callNode, content, index)
+}
 
-			varName := php.VariableNameFromNode(objectNode, content)
-			if varName == "" {
-				return sitter.Node{}, false
-			}
-			funcName := a.enclosingFunctionName(callNode)
-			if funcName == "" {
-				return sitter.Node{}, false
-			}
-			if !variableHasTwigEnvironmentTypeIndex(index, funcName, varName, callLine) {
-				return sitter.Node{}, false
-			}
-			return str, true
+// Reports whether the call is render() of an AbstractController or of a Twig
+// environment held by a variable or property
+func (a *phpAnalyzer) isRenderCall(callNode sitter.Node, content []byte, index php.IndexedTree) bool {
+	nameNode := callNode.ChildByFieldName("name")
+	objectNode := callNode.ChildByFieldName("object")
+	if nameNode.IsNull() || objectNode.IsNull() || strings.TrimSpace(nameNode.Content(content)) != "render" {
+		return false
+	}
 
-		case "member_access_expression", "nullsafe_member_access_expression":
-			propertyName := thisPropertyNameFromMemberAccessContent(content, objectNode)
-			if propertyName == "" {
-				return sitter.Node{}, false
-			}
-			if !propertyHasTwigEnvironmentTypeIndex(index, propertyName) {
-				return sitter.Node{}, false
-			}
-			return str, true
+	callLine := int(callNode.StartPoint().Row) + 1
+	controllerTarget := strings.ToLower(normalizeFQN(abstractControllerFQN))
+
+	switch objectNode.Type() {
+	case "variable_name":
+		name := strings.TrimSpace(objectNode.Content(content))
+		if name == "$this" {
+			return controllerTarget != "" && classExtendsAbstractControllerIndex(index, callNode, controllerTarget)
 		}
 
-		return sitter.Node{}, false
+		varName := php.VariableNameFromNode(objectNode, content)
+		if varName == "" {
+			return false
+		}
+		funcName := a.enclosingFunctionName(callNode)
+		return funcName != "" && variableHasTwigEnvironmentTypeIndex(index, funcName, varName, callLine)
+
+	case "member_access_expression", "nullsafe_member_access_expression":
+		propertyName := thisPropertyNameFromMemberAccessContent(content, objectNode)
+		return propertyName != "" && propertyHasTwigEnvironmentTypeIndex(index, propertyName)
 	}
 
-	return sitter.Node{}, false
+	return false
 }
 
 func (a *phpAnalyzer) phpRouteNameFromArgs(args sitter.Node) string {
@@ -1028,6 +993,27 @@ func propertyHasTwigEnvironmentTypeIndex(index php.IndexedTree, name string) boo
 	return propertyHasTypeIndex(index, name, canonicalTwigEnvironmentType)
 }
 
+// Reports whether the class around node extends or implements one of the
+// types canonical knows
+func classHasTypeIndex(index php.IndexedTree, node sitter.Node, canonical func(string) (string, bool)) bool {
+	for cur := node; !cur.IsNull(); cur = cur.Parent() {
+		if cur.Type() != "class_declaration" {
+			continue
+		}
+		info, ok := index.Classes[uint32(cur.StartByte())]
+		if !ok {
+			return false
+		}
+		for _, typ := range slices.Concat(info.Extends, info.Implements) {
+			if _, ok := canonical(typ); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
 func classExtendsAbstractControllerIndex(index php.IndexedTree, node sitter.Node, target string) bool {
 	for cur := node; !cur.IsNull(); cur = cur.Parent() {
 		if cur.Type() != "class_declaration" {
@@ -1073,6 +1059,25 @@ func variableHasTypeIndex(index php.IndexedTree, funcName, varName string, line
 	return false
 }
 
+// Reports whether the call node returns one of the types canonical knows,
+// following the declared return types of the methods it chains
+func callHasTypeIndex(doc *php.Document, node sitter.Node, funcName string, canonical func(string) (string, bool)) bool {
+	switch node.Type() {
+	case "member_call_expression", "nullsafe_member_call_expression", "scoped_call_expression", "function_call_expression":
+	default:
+		return false
+	}
+	if doc == nil {
+		return false
+	}
+	for _, typ := range doc.ExpressionTypeNames(node, funcName) {
+		if _, ok := canonical(typ); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func variableHasRouterTypeIndex(index php.IndexedTree, funcName, varName string, line int) bool {
 	return variableHasTypeIndex(index, funcName, varName, line, canonicalRouterType)
 }
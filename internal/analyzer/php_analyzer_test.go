@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/shinyvision/vimfony/internal/config"
 	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/translations"
 	"github.com/shinyvision/vimfony/internal/utils"
 	"github.com/stretchr/testify/require"
 	protocol "github.com/tliron/glsp/protocol_3_16"
@@ -111,7 +113,7 @@ func TestPHPRouterRouteNameCompletion(t *testing.T) {
 	offset := strings.Index(target, "'a_route'") + 1
 	pos := positionAfter(t, content, target, offset)
 
-	items, err := pa.OnCompletion(pos)
+	items, err := pa.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -124,6 +126,38 @@ func TestPHPRouterRouteNameCompletion(t *testing.T) {
 	require.Contains(t, labels, "another_route")
 }
 
+func TestRouteNameCompletionsAreBuiltOncePerRoutesMap(t *testing.T) {
+	routes := config.RoutesMap{
+		"app_home":     {Name: "app_home"},
+		"app_login":    {Name: "app_login", Parameters: []string{"target"}},
+		"admin_users":  {Name: "admin_users"},
+		"app_homepage": {Name: "app_homepage"},
+	}
+	names := newRouteNameCompletions(routes)
+
+	labels := func(items []protocol.CompletionItem) []string {
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+	require.Equal(t, []string{"app_home", "app_login", "app_homepage"}, labels(names.complete("app_")))
+	require.Equal(t, []string{"admin_users"}, labels(names.complete("adm")))
+	require.Empty(t, names.complete("missing"))
+	require.Equal(t, makeRouteNameCompletionItems(routes, "app_"), names.complete("app_"))
+
+	// Built on the first completion, a route added later shows once the
+	// routes are set again
+	routes["app_logout"] = config.Route{Name: "app_logout"}
+	require.NotContains(t, labels(names.complete("app_")), "app_logout")
+	require.Contains(t, labels(newRouteNameCompletions(routes).complete("app_")), "app_logout")
+
+	var none *routeNameCompletions
+	require.Nil(t, none.complete(""))
+	require.Nil(t, newRouteNameCompletions(nil).complete(""))
+}
+
 func TestPHPRouterRouteCompletionForAssignedVariable(t *testing.T) {
 	content, err := os.ReadFile("../../mock/class_with_router.php")
 	require.NoError(t, err)
@@ -146,7 +180,7 @@ func TestPHPRouterRouteCompletionForAssignedVariable(t *testing.T) {
 	offset := strings.Index(target, "'a_route'") + 1
 	pos := positionAfter(t, content, target, offset)
 
-	items, err := pa.OnCompletion(pos)
+	items, err := pa.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -180,7 +214,7 @@ func TestPHPRouterRouteCompletionForDocblockVariable(t *testing.T) {
 	offset := strings.Index(target, "'a_route'") + 1
 	pos := positionAfter(t, content, target, offset)
 
-	items, err := pa.OnCompletion(pos)
+	items, err := pa.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -212,7 +246,7 @@ func TestPHPDefinitionForClassReference(t *testing.T) {
 			"VendorNamespace\\": {"vendor"},
 		},
 	}
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetAutoloadMap(&autoload)
@@ -223,7 +257,7 @@ func TestPHPDefinitionForClassReference(t *testing.T) {
 	classRef := "VendorNamespace\\TestClass"
 	pos := positionAfter(t, []byte(content), classRef, len(classRef)/2)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -251,7 +285,7 @@ func TestPHPDefinitionForServiceID(t *testing.T) {
 			"VendorNamespace\\": {"vendor"},
 		},
 	}
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetAutoloadMap(&autoload)
@@ -262,7 +296,7 @@ func TestPHPDefinitionForServiceID(t *testing.T) {
 	serviceRef := "test.service"
 	pos := positionAfter(t, []byte(content), serviceRef, len(serviceRef)/2)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -291,7 +325,7 @@ func TestPHPDefinitionForRouteControllerAction(t *testing.T) {
 			"VendorNamespace\\": {"vendor"},
 		},
 	}
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetAutoloadMap(&autoload)
@@ -321,7 +355,7 @@ func TestPHPDefinitionForRouteControllerAction(t *testing.T) {
 	offset := strings.Index(target, "'a_route'") + 1
 	pos := positionAfter(t, content, target, offset)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -355,7 +389,7 @@ func TestPHPDefinitionForRouteControllerInvokeFallback(t *testing.T) {
 			"VendorNamespace\\": {"vendor"},
 		},
 	}
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetAutoloadMap(&autoload)
@@ -383,7 +417,7 @@ func TestPHPDefinitionForRouteControllerInvokeFallback(t *testing.T) {
 	offset := strings.Index(target, "'a_route'") + 1
 	pos := positionAfter(t, content, target, offset)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -394,6 +428,157 @@ func TestPHPDefinitionForRouteControllerInvokeFallback(t *testing.T) {
 	require.Equal(t, invokeRange, locs[0].Range)
 }
 
+func TestPHPDiagnosticsForRouteWithMissingAction(t *testing.T) {
+	content, err := os.ReadFile("../../mock/class_with_router.php")
+	require.NoError(t, err)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	container := &config.ContainerConfig{
+		WorkspaceRoot: mockRoot,
+		ServiceClasses: map[string]string{
+			"test.controller": "VendorNamespace\\TestClass",
+		},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	}
+	an.SetContainerConfig(container)
+	autoload := config.AutoloadMap{
+		PSR4: map[string][]string{
+			"VendorNamespace\\": {"vendor"},
+		},
+	}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	an.SetAutoloadMap(&autoload)
+	an.SetDocumentPath("/tmp/test.php")
+	routes := config.RoutesMap{
+		"a_route": {
+			Name:       "a_route",
+			Controller: "test.controller",
+			Action:     "missingAction",
+		},
+	}
+	an.SetRoutes(&routes)
+	require.NoError(t, an.Changed(content, nil))
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.NotEmpty(t, diagnostics)
+
+	notARouterLine := uint32(strings.Count(string(content[:strings.Index(string(content), "generating_something")]), "\n"))
+	for _, d := range diagnostics {
+		require.Contains(t, d.Message, "test.controller::missingAction")
+		require.NotEqual(t, notARouterLine, d.Range.Start.Line)
+	}
+
+	routes["a_route"] = config.Route{Name: "a_route", Controller: "VendorNamespace\\MissingController", Action: "index"}
+	an.SetRoutes(&routes)
+	diagnostics, err = an.OnDiagnostics()
+	require.NoError(t, err)
+	require.NotEmpty(t, diagnostics)
+	for _, d := range diagnostics {
+		require.Contains(t, d.Message, "missing controller class VendorNamespace\\MissingController")
+	}
+
+	routes["a_route"] = config.Route{Name: "a_route", Controller: "test.controller", Action: "index"}
+	an.SetRoutes(&routes)
+	diagnostics, err = an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Empty(t, diagnostics)
+}
+
+func TestPHPDiagnosticsForDuplicateRouteNames(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use Symfony\Component\Routing\Attribute\Route;
+
+#[Route('/blog', name: 'blog_')]
+class BlogController
+{
+    #[Route('/', name: 'index')]
+    public function index(): void
+    {
+    }
+
+    #[Route('/list', 'index')]
+    public function list(): void
+    {
+    }
+
+    #[Route('/show', name: 'show')]
+    public function show(): void
+    {
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetDocumentPath("/tmp/BlogController.php")
+	require.NoError(t, an.Changed(content, nil))
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 2)
+
+	lines := []uint32{diagnostics[0].Range.Start.Line, diagnostics[1].Range.Start.Line}
+	require.ElementsMatch(t, []uint32{9, 14}, lines)
+	for _, d := range diagnostics {
+		require.Contains(t, d.Message, "blog_index")
+		require.Len(t, d.RelatedInformation, 1)
+		require.NotEqual(t, d.Range.Start.Line, d.RelatedInformation[0].Location.Range.Start.Line)
+	}
+}
+
+func TestPHPDiagnosticsForRouteNameDuplicatedByAnotherController(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use Symfony\Component\Routing\Attribute\Route;
+
+class BlogController
+{
+    #[Route('/', name: 'home')]
+    public function index(): void
+    {
+    }
+}
+`)
+
+	other := protocol.Location{
+		URI: "file:///app/src/Controller/HomeController.php",
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 8, Character: 23},
+			End:   protocol.Position{Line: 8, Character: 29},
+		},
+	}
+	container := config.NewContainerConfig()
+	container.RouteDeclarations["home"] = []config.RouteDeclaration{
+		{Class: "App\\Controller\\BlogController", Action: "index", Location: protocol.Location{URI: "file:///app/src/Controller/BlogController.php"}},
+		{Class: "App\\Controller\\HomeController", Action: "index", Location: other},
+	}
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(container)
+	an.SetDocumentPath("/app/src/Controller/BlogController.php")
+	require.NoError(t, an.Changed(content, nil))
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	require.Contains(t, diagnostics[0].Message, "'home'")
+	require.Len(t, diagnostics[0].RelatedInformation, 1)
+	require.Equal(t, other, diagnostics[0].RelatedInformation[0].Location)
+	require.Contains(t, diagnostics[0].RelatedInformation[0].Message, "HomeController::index")
+}
+
 func TestPHPRouterCompletionForAbstractControllerHelpers(t *testing.T) {
 	content, err := os.ReadFile("../../mock/class_with_router.php")
 	require.NoError(t, err)
@@ -419,7 +604,7 @@ func TestPHPRouterCompletionForAbstractControllerHelpers(t *testing.T) {
 	offsetGenerate := strings.Index(targetGenerate, "'a_route'") + 1
 	posGenerate := positionAfter(t, content, targetGenerate, offsetGenerate)
 
-	itemsGenerate, err := pa.OnCompletion(posGenerate)
+	itemsGenerate, err := pa.OnCompletion(context.Background(), posGenerate)
 	require.NoError(t, err)
 	require.NotEmpty(t, itemsGenerate)
 
@@ -427,7 +612,7 @@ func TestPHPRouterCompletionForAbstractControllerHelpers(t *testing.T) {
 	offsetRedirect := strings.Index(targetRedirect, "'a_route'") + 1
 	posRedirect := positionAfter(t, content, targetRedirect, offsetRedirect)
 
-	itemsRedirect, err := pa.OnCompletion(posRedirect)
+	itemsRedirect, err := pa.OnCompletion(context.Background(), posRedirect)
 	require.NoError(t, err)
 	require.NotEmpty(t, itemsRedirect)
 
@@ -440,6 +625,77 @@ func TestPHPRouterCompletionForAbstractControllerHelpers(t *testing.T) {
 	require.Contains(t, labels, "another_route")
 }
 
+func TestPHPRouterCompletionInAClassImplementingUrlGeneratorInterface(t *testing.T) {
+	content := []byte(`<?php
+namespace App\Routing;
+
+use Symfony\Component\Routing\Generator\UrlGeneratorInterface;
+
+class SiteUrlGenerator implements UrlGeneratorInterface
+{
+    public function home(): string
+    {
+        return $this->generate('a_route');
+    }
+}
+`)
+	analyzer := NewPHPAnalyzer()
+	require.NoError(t, analyzer.Changed(content, nil))
+	pa := analyzer.(*phpAnalyzer)
+	routes := config.RoutesMap{"a_route": {Name: "a_route"}}
+	pa.SetRoutes(&routes)
+
+	target := "$this->generate('a_route')"
+	items, err := pa.OnCompletion(context.Background(), positionAfter(t, content, target, strings.Index(target, "'a_route'")+1))
+	require.NoError(t, err)
+	require.NotEmpty(t, items)
+	require.Equal(t, "a_route", items[0].Label)
+}
+
+func TestPHPRouterCompletionBehindGettersAndFactories(t *testing.T) {
+	content := []byte(`<?php
+namespace App\Routing;
+
+use Symfony\Component\Routing\Generator\UrlGeneratorInterface;
+use Symfony\Component\Routing\RouterInterface;
+
+class RouterFactory
+{
+    public function create(): UrlGeneratorInterface
+    {
+    }
+}
+
+class Links
+{
+    /**
+     * @return RouterInterface
+     */
+    private function getRouter()
+    {
+    }
+
+    public function home(RouterFactory $factory): string
+    {
+        $this->getRouter()->generate('a_route');
+        return $factory->create()->generate('a_route');
+    }
+}
+`)
+	analyzer := NewPHPAnalyzer()
+	require.NoError(t, analyzer.Changed(content, nil))
+	pa := analyzer.(*phpAnalyzer)
+	routes := config.RoutesMap{"a_route": {Name: "a_route"}}
+	pa.SetRoutes(&routes)
+
+	for _, target := range []string{"$this->getRouter()->generate('a_route')", "$factory->create()->generate('a_route')"} {
+		items, err := pa.OnCompletion(context.Background(), positionAfter(t, content, target, strings.Index(target, "'a_route'")+1))
+		require.NoError(t, err)
+		require.NotEmpty(t, items, target)
+		require.Equal(t, "a_route", items[0].Label)
+	}
+}
+
 func TestPHPRouterRouteParameterCompletion(t *testing.T) {
 	content, err := os.ReadFile("../../mock/class_with_router.php")
 	require.NoError(t, err)
@@ -462,7 +718,7 @@ func TestPHPRouterRouteParameterCompletion(t *testing.T) {
 	offset := strings.Index(target, "['some'") + len("['")
 	pos := positionAfter(t, content, target, offset)
 
-	items, err := pa.OnCompletion(pos)
+	items, err := pa.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -496,7 +752,7 @@ func TestPHPRouterRouteParameterCompletionWithoutArrow(t *testing.T) {
 	offset := strings.Index(target, "['unborn_param_name") + len("['")
 	pos := positionAfter(t, content, target, offset)
 
-	items, err := pa.OnCompletion(pos)
+	items, err := pa.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -531,7 +787,7 @@ func TestPHPRouterRouteCompletionNotOfferedForNonRouter(t *testing.T) {
 	offset := strings.Index(target, "('generating_something_that_is_not_a_route") + len("('")
 	pos := positionAfter(t, content, target, offset)
 
-	items, err := pa.OnCompletion(pos)
+	items, err := pa.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.Nil(t, items)
 }
@@ -571,7 +827,7 @@ func TestPHPTwigRenderTemplateCompletion(t *testing.T) {
 
 	for _, tc := range testCases {
 		pos := positionAfter(t, content, tc.needle, tc.offset)
-		items, err := pa.OnCompletion(pos)
+		items, err := pa.OnCompletion(context.Background(), pos)
 		require.NoErrorf(t, err, "completion error for %s context", tc.label)
 		require.NotEmptyf(t, items, "expected completion items for %s context", tc.label)
 
@@ -604,3 +860,710 @@ func positionAfter(t *testing.T, content []byte, needle string, offset int) prot
 		Character: uint32(col),
 	}
 }
+
+func TestPHPRouteAttributeCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use Symfony\Component\HttpFoundation\Request;
+use Symfony\Component\Routing\Attribute\Route;
+
+class BlogPostController
+{
+    #[Route('/blog/{id}/{', name: '', methods: [''])]
+    public function showComments(int $id, string $slug, Request $request)
+    {
+    }
+}
+`)
+
+	analyzer := NewPHPAnalyzer()
+	require.NoError(t, analyzer.Changed(content, nil))
+	pa := analyzer.(*phpAnalyzer)
+
+	labelsAt := func(target string, offset int) []string {
+		items, err := pa.OnCompletion(context.Background(), positionAfter(t, content, target, offset))
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	placeholders := labelsAt("'/blog/{id}/{'", len("'/blog/{id}/{"))
+	require.Equal(t, []string{"slug"}, placeholders)
+
+	names := labelsAt("name: ''", len("name: '"))
+	require.Equal(t, []string{"app_blog_post_show_comments"}, names)
+
+	methods := labelsAt("methods: ['']", len("methods: ['"))
+	require.Contains(t, methods, "GET")
+	require.Contains(t, methods, "POST")
+}
+
+func TestPHPAutowireCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Service;
+
+use Symfony\Component\DependencyInjection\Attribute\Autowire;
+
+class Mailer
+{
+    public function __construct(
+        #[Autowire(service: 'mai')] $transport,
+        #[Autowire('@rou')] $router,
+        #[Autowire(param: 'kernel.')] string $dir,
+        #[Autowire(env: 'bool:APP_')] bool $debug,
+        #[Autowire('%kernel.')] string $env,
+    ) {
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"mailer.transport": "App\\Transport", "router": "App\\Router"},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		Parameters: map[string]string{
+			"kernel.project_dir": "/app",
+			"kernel.environment": "dev",
+			".private.param":     "x",
+		},
+		EnvVars: map[string]string{"APP_DEBUG": "1", "DATABASE_URL": ""},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	labelsAt := func(target string) []string {
+		items, err := an.OnCompletion(context.Background(), positionAfter(t, content, target, len(target)))
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	require.Equal(t, []string{"mailer.transport"}, labelsAt("service: 'mai"))
+	require.Equal(t, []string{"router"}, labelsAt("('@rou"))
+	require.Equal(t, []string{"kernel.environment", "kernel.project_dir"}, labelsAt("param: 'kernel."))
+	require.Equal(t, []string{"APP_DEBUG"}, labelsAt("env: 'bool:APP_"))
+	require.Equal(t, []string{"kernel.environment", "kernel.project_dir"}, labelsAt("('%kernel."))
+}
+
+func TestPHPContainerGetCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Tests;
+
+use Psr\Container\ContainerInterface;
+
+class MailerTest extends KernelTestCase
+{
+    public function testSend(ContainerInterface $locator): void
+    {
+        static::getContainer()->get('mail');
+        $locator->get('rou');
+        $this->other->get('mail');
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"mailer.transport": "App\\Transport", "mailer.mailer": "App\\Mailer", "router": "App\\Router"},
+		ServiceAliases:    map[string]string{"mailer": "mailer.mailer"},
+		ServiceReferences: map[string]int{"mailer.mailer": 3},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	labelsAt := func(target string) []string {
+		items, err := an.OnCompletion(context.Background(), positionAfter(t, content, target, len(target)))
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	labels := labelsAt("getContainer()->get('mail")
+	require.Len(t, labels, 3)
+	require.Equal(t, "mailer.mailer", labels[0])
+	require.ElementsMatch(t, []string{"mailer.mailer", "mailer.transport", "mailer"}, labels)
+
+	require.Equal(t, []string{"router"}, labelsAt("$locator->get('rou"))
+	require.Empty(t, labelsAt("$this->other->get('mail"))
+}
+
+func TestPHPGetParameterCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use Symfony\Bundle\FrameworkBundle\Controller\AbstractController;
+use Symfony\Component\DependencyInjection\ParameterBag\ParameterBagInterface;
+
+class UploadController extends AbstractController
+{
+    public function upload(ParameterBagInterface $params)
+    {
+        $this->getParameter('app.');
+        $params->get('kernel.');
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		Parameters: config.ParametersMap{
+			"kernel.project_dir": "/app",
+			"app.upload_dir":     "%kernel.project_dir%/public/uploads",
+		},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, content, "getParameter('app.", len("getParameter('app.")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "app.upload_dir", items[0].Label)
+	require.NotNil(t, items[0].Detail)
+	require.Equal(t, "/app/public/uploads", *items[0].Detail)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, content, "$params->get('kernel.", len("$params->get('kernel.")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "kernel.project_dir", items[0].Label)
+}
+
+func TestPHPEnvVarCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+use function Symfony\Component\DependencyInjection\Loader\Configurator\env;
+
+return static function (ContainerConfigurator $container): void {
+    $container->parameters()
+        ->set('app.dsn', '%env(MAIL')
+        ->set('app.secret', env('APP_'));
+};
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		EnvVars:           map[string]string{"MAILER_DSN": "null://null", "APP_SECRET": ""},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, content, "'%env(MAIL", len("'%env(MAIL")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "MAILER_DSN", items[0].Label)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, content, "env('APP_", len("env('APP_")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "APP_SECRET", items[0].Label)
+}
+
+func TestPHPSecurityAttributeCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use Symfony\Bundle\FrameworkBundle\Controller\AbstractController;
+use Symfony\Bundle\SecurityBundle\Security;
+use Symfony\Component\Security\Http\Attribute\IsGranted;
+
+class PostController extends AbstractController
+{
+    #[IsGranted('ROLE_')]
+    public function edit(Security $security)
+    {
+        $this->denyAccessUnlessGranted('POST_');
+        $security->isGranted('ROLE_A');
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		SecurityAttributes: map[string]protocol.Location{
+			"ROLE_ADMIN": {URI: "file:///app/config/packages/security.yaml"},
+			"ROLE_USER":  {URI: "file:///app/config/packages/security.yaml"},
+			"POST_EDIT":  {URI: "file:///app/src/Security/PostVoter.php"},
+		},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	labelsAt := func(target string) []string {
+		items, err := an.OnCompletion(context.Background(), positionAfter(t, content, target, len(target)))
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	require.Equal(t, []string{"ROLE_ADMIN", "ROLE_USER"}, labelsAt("IsGranted('ROLE_"))
+	require.Equal(t, []string{"POST_EDIT"}, labelsAt("denyAccessUnlessGranted('POST_"))
+	require.Equal(t, []string{"ROLE_ADMIN"}, labelsAt("isGranted('ROLE_A"))
+}
+
+func TestPHPEventNameCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\EventListener;
+
+use Symfony\Component\EventDispatcher\Attribute\AsEventListener;
+use Symfony\Component\EventDispatcher\EventDispatcherInterface;
+
+#[AsEventListener(event: 'kernel.re')]
+final class LocaleListener
+{
+    public function register(EventDispatcherInterface $dispatcher): void
+    {
+        $dispatcher->addListener('Order', [$this, 'onOrder']);
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	autoload := config.NewAutoloadMap()
+	autoload.Classes["OrderPlacedEvent"] = []string{"App\\Event\\OrderPlacedEvent"}
+	autoload.Classes["OrderRepository"] = []string{"App\\Repository\\OrderRepository"}
+	an.SetAutoloadMap(&autoload)
+	require.NoError(t, an.Changed(content, nil))
+
+	labelsAt := func(target string) []string {
+		items, err := an.OnCompletion(context.Background(), positionAfter(t, content, target, len(target)))
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	require.Equal(t, []string{"kernel.request", "kernel.response"}, labelsAt("event: 'kernel.re"))
+	require.Equal(t, []string{"App\\Event\\OrderPlacedEvent"}, labelsAt("addListener('Order"))
+}
+
+func TestPHPFormFieldCompletion(t *testing.T) {
+	root := t.TempDir()
+	formPath := filepath.Join(root, "src", "Form", "PostType.php")
+	require.NoError(t, os.MkdirAll(filepath.Dir(formPath), 0o755))
+	require.NoError(t, os.WriteFile(formPath, []byte(`<?php
+
+namespace App\Form;
+
+class PostType extends AbstractType
+{
+    public function buildForm(FormBuilderInterface $builder, array $options): void
+    {
+        $builder
+            ->add('title', TextType::class)
+            ->add('teaser')
+            ->add('tags', CollectionType::class);
+        $builder->add('published', CheckboxType::class);
+    }
+}
+`), 0o644))
+
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use App\Form\PostType;
+use Symfony\Bundle\FrameworkBundle\Controller\AbstractController;
+
+class PostController extends AbstractController
+{
+    public function edit()
+    {
+        $form = $this->createForm(PostType::class, $post);
+        $form->get('t');
+        $other->get('t');
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"App\\": {"src"}}}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, root)
+	an.SetDocumentStore(store)
+	an.SetAutoloadMap(&autoload)
+	require.NoError(t, an.Changed(content, nil))
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, content, "$form->get('t", len("$form->get('t")))
+	require.NoError(t, err)
+	var labels []string
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	require.Equal(t, []string{"title", "teaser", "tags"}, labels)
+	require.Equal(t, "TextType", *items[0].Detail)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, content, "$other->get('t", len("$other->get('t")))
+	require.NoError(t, err)
+	require.Empty(t, items)
+}
+
+func TestPHPTranslationDomainCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Service;
+
+use Symfony\Contracts\Translation\TranslatorInterface;
+
+class Greeter
+{
+    public function greet(TranslatorInterface $translator): string
+    {
+        $translator->trans('hello', [], 'val');
+        $translator->trans('hello', domain: 'mes');
+        return $translator->trans('hello', ['%name%' => 'val']);
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		TranslationResources: []string{
+			"/app/translations/messages+intl-icu.en.yaml",
+			"/app/translations/validators.en.xlf",
+		},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	labelsAt := func(target string) []string {
+		items, err := an.OnCompletion(context.Background(), positionAfter(t, content, target, len(target)))
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	require.Equal(t, []string{"validators"}, labelsAt("[], 'val"))
+	require.Equal(t, []string{"messages"}, labelsAt("domain: 'mes"))
+	require.Empty(t, labelsAt("=> 'val"))
+}
+
+func TestPHPTranslationPlaceholderCompletion(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Service;
+
+use Symfony\Contracts\Translation\TranslatorInterface;
+
+class Greeter
+{
+    public function greet(TranslatorInterface $translator): string
+    {
+        $translator->trans('greeting', ['co' => 3]);
+        return $translator->trans('greeting', ['%na' => 'val']);
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		DefaultLocale:     "en",
+		TranslationKeys: translations.TranslationMap{
+			"greeting": {
+				{URI: "file:///app/translations/messages.en.yaml", Message: "Hello %name%, you have {count, plural, one {# message} other {# messages}}"},
+			},
+		},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	labelsAt := func(target string) []string {
+		items, err := an.OnCompletion(context.Background(), positionAfter(t, content, target, len(target)))
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	require.Equal(t, []string{"%name%"}, labelsAt("['%na"))
+	require.Equal(t, []string{"count"}, labelsAt("['co"))
+	require.Empty(t, labelsAt("=> 'val"))
+}
+
+func TestPHPRoutePathInlayHints(t *testing.T) {
+	content, err := os.ReadFile("../../mock/class_with_router.php")
+	require.NoError(t, err)
+
+	analyzer := NewPHPAnalyzer()
+	require.NoError(t, analyzer.Changed(content, nil))
+
+	pa := analyzer.(*phpAnalyzer)
+	routes := config.RoutesMap{
+		"a_route": {
+			Name:       "a_route",
+			Parameters: []string{"some"},
+			Controller: "App\\Controller\\RouteController",
+			Action:     "show",
+			Path:       "/route/{some}",
+		},
+	}
+	pa.SetRoutes(&routes)
+
+	everything := protocol.Range{End: protocol.Position{Line: 1000}}
+	hints, err := pa.OnInlayHints(everything)
+	require.NoError(t, err)
+	require.NotEmpty(t, hints)
+
+	target := "$this->router->generate('a_route'"
+	end := positionAfter(t, content, target, len(target))
+	var found bool
+	for _, hint := range hints {
+		require.Equal(t, "➜ /route/{some}", hint.Label)
+		if hint.Position == end {
+			found = true
+			require.Equal(t, "App\\Controller\\RouteController::show", hint.Tooltip)
+		}
+	}
+	require.True(t, found, "hint after the route name of generate()")
+
+	none, err := pa.OnInlayHints(protocol.Range{})
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
+
+func TestPHPRouteSignatureHelp(t *testing.T) {
+	content, err := os.ReadFile("../../mock/class_with_router.php")
+	require.NoError(t, err)
+
+	analyzer := NewPHPAnalyzer()
+	require.NoError(t, analyzer.Changed(content, nil))
+
+	pa := analyzer.(*phpAnalyzer)
+	routes := config.RoutesMap{
+		"a_route": {
+			Name:       "a_route",
+			Parameters: []string{"some", "page"},
+			Optional:   []string{"page"},
+			Path:       "/route/{some}/{page}",
+		},
+	}
+	pa.SetRoutes(&routes)
+
+	target := "$this->generateUrl('a_route', ['so"
+	help, err := pa.OnSignatureHelp(positionAfter(t, content, target, len(target)))
+	require.NoError(t, err)
+	require.NotNil(t, help)
+	require.Len(t, help.Signatures, 1)
+	signature := help.Signatures[0]
+	require.Equal(t, "generateUrl('a_route', [some, page?])", signature.Label)
+	require.Len(t, signature.Parameters, 2)
+	require.Equal(t, [2]protocol.UInteger{24, 28}, signature.Parameters[0].Label)
+	require.Equal(t, "optional, has a default", signature.Parameters[1].Documentation)
+	require.NotNil(t, help.ActiveParameter)
+	require.Equal(t, protocol.UInteger(0), *help.ActiveParameter)
+
+	// The route name is active, the missing required parameter is highlighted
+	target = "$this->router->generate('a_"
+	help, err = pa.OnSignatureHelp(positionAfter(t, content, target, len(target)))
+	require.NoError(t, err)
+	require.NotNil(t, help)
+	require.Equal(t, "generate('a_route', [some, page?])", help.Signatures[0].Label)
+
+	// Not a router
+	target = "$this->notARouter->generate('gen"
+	help, err = pa.OnSignatureHelp(positionAfter(t, content, target, len(target)))
+	require.NoError(t, err)
+	require.Nil(t, help)
+}
+
+func TestPHPTransSignatureHelp(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Service;
+
+use Symfony\Contracts\Translation\TranslatorInterface;
+
+class Greeter
+{
+    public function greet(TranslatorInterface $translator): string
+    {
+        return $translator->trans('greeting', ['%name%' => 'x'], domain: 'messages');
+    }
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		DefaultLocale:        "en",
+		TranslationResources: []string{"/app/translations/messages.en.yaml"},
+		TranslationKeys: translations.TranslationMap{
+			"greeting": {
+				{URI: "file:///app/translations/messages.en.yaml", Message: "Hello %name%"},
+			},
+		},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	helpAt := func(target string) *protocol.SignatureHelp {
+		help, err := an.OnSignatureHelp(positionAfter(t, content, target, len(target)))
+		require.NoError(t, err)
+		return help
+	}
+
+	help := helpAt("['%na")
+	require.NotNil(t, help)
+	signature := help.Signatures[0]
+	require.Equal(t, "trans(id, parameters, domain, locale)", signature.Label)
+	require.Equal(t, "Hello %name%", signature.Documentation)
+	require.Equal(t, [2]protocol.UInteger{10, 20}, signature.Parameters[1].Label)
+	require.Equal(t, protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: "**Placeholders:**\n- `%name%`\n"}, signature.Parameters[1].Documentation)
+	require.Equal(t, protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: "Known domains: `messages`"}, signature.Parameters[2].Documentation)
+	require.Equal(t, protocol.UInteger(1), *help.ActiveParameter)
+
+	require.Equal(t, protocol.UInteger(0), *helpAt("trans('gr").ActiveParameter)
+	require.Equal(t, protocol.UInteger(2), *helpAt("domain: 'mes").ActiveParameter)
+	require.Nil(t, helpAt("function gr"))
+}
+
+func TestPHPRouteCodeLens(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+class PostController
+{
+    public function show(int $id) {}
+
+    public function helper() {}
+}
+`)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetDocumentPath("/app/src/Controller/PostController.php")
+	usage := protocol.Location{URI: "file:///app/templates/post.html.twig"}
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses: make(map[string]string),
+		ServiceAliases: make(map[string]string),
+		RouteUsages:    map[string][]protocol.Location{"post_show": {usage}},
+	})
+	routes := config.RoutesMap{
+		"post_show":     {Name: "post_show", Controller: "App\\Controller\\PostController", Action: "show"},
+		"post_show_alt": {Name: "post_show_alt", Controller: "App\\Controller\\PostController", Action: "show"},
+		"other":         {Name: "other", Controller: "App\\Controller\\OtherController", Action: "show"},
+	}
+	an.SetRoutes(&routes)
+	require.NoError(t, an.Changed(content, nil))
+
+	lenses, err := an.OnCodeLens()
+	require.NoError(t, err)
+	require.Len(t, lenses, 2)
+
+	show := positionAfter(t, content, "function show", len("function "))
+	require.Equal(t, show, lenses[0].Range.Start)
+	require.NotNil(t, lenses[0].Command)
+	require.Equal(t, "route post_show · 1 reference", lenses[0].Command.Title)
+	require.Equal(t, CommandRouteUsages, lenses[0].Command.Command)
+	require.Equal(t, []any{"post_show", "file:///app/src/Controller/PostController.php"}, lenses[0].Command.Arguments)
+	require.Equal(t, "route post_show_alt · 0 references", lenses[1].Command.Title)
+}
+
+func TestPHPTemplateCodeLens(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use Symfony\Bundle\FrameworkBundle\Controller\AbstractController;
+
+class PostController extends AbstractController
+{
+    public function show()
+    {
+        $this->render('template.html.twig');
+        $this->render('post/missing.html.twig');
+        $this->render("post/{$name}.html.twig");
+        $this->other('template.html.twig');
+    }
+}
+`)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetDocumentPath("/app/src/Controller/PostController.php")
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:  mockRoot,
+		Roots:          []string{"."},
+		ServiceClasses: make(map[string]string),
+		ServiceAliases: make(map[string]string),
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	lenses, err := an.OnCodeLens()
+	require.NoError(t, err)
+	require.Len(t, lenses, 2)
+
+	require.Equal(t, positionAfter(t, content, "$this->render('template", 0), lenses[0].Range.Start)
+	require.Equal(t, "Open template", lenses[0].Command.Title)
+	require.Equal(t, CommandOpenTemplate, lenses[0].Command.Command)
+	uri := "file:///app/src/Controller/PostController.php"
+	require.Equal(t, []any{"template.html.twig", uri}, lenses[0].Command.Arguments)
+
+	require.Equal(t, "Create template", lenses[1].Command.Title)
+	require.Equal(t, CommandCreateTemplate, lenses[1].Command.Command)
+	require.Equal(t, []any{"post/missing.html.twig", uri}, lenses[1].Command.Arguments)
+}
+
+func TestPHPTemplateCodeLensFeatureDisabled(t *testing.T) {
+	content := []byte(`<?php
+
+class PostController extends AbstractController
+{
+    public function show()
+    {
+        $this->render('template.html.twig');
+    }
+}
+`)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewPHPAnalyzer().(*phpAnalyzer)
+	an.SetDocumentPath("/app/src/Controller/PostController.php")
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:  mockRoot,
+		Roots:          []string{"."},
+		ServiceClasses: make(map[string]string),
+		ServiceAliases: make(map[string]string),
+	})
+	an.SetFeatures(config.Features{config.FeatureTemplates: false})
+	require.NoError(t, an.Changed(content, nil))
+
+	lenses, err := an.OnCodeLens()
+	require.NoError(t, err)
+	require.Empty(t, lenses)
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Completes #[Autowire] arguments: service ids for service:, container
+// parameters for param:, env vars for env: and the @service / %param% forms of
+// the value argument.
+func (a *phpAnalyzer) autowireCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	if a.doc == nil || a.container == nil {
+		return nil
+	}
+
+	node, content, _, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return nil
+	}
+
+	var str, arg, attr sitter.Node
+	for cur := node; !cur.IsNull() && attr.IsNull(); cur = cur.Parent() {
+		switch cur.Type() {
+		case "string", "encapsed_string":
+			if str.IsNull() {
+				str = cur
+			}
+		case "argument":
+			if arg.IsNull() {
+				arg = cur
+			}
+		case "attribute":
+			attr = cur
+		case "method_declaration", "class_declaration", "formal_parameters":
+			return nil
+		}
+	}
+	if str.IsNull() || arg.IsNull() || attr.IsNull() || attributeShortName(attr, content) != "Autowire" {
+		return nil
+	}
+
+	caret := lspPosToByteOffset(content, pos)
+	if caret <= int(str.StartByte()) || caret >= int(str.EndByte()) {
+		return nil
+	}
+	prefix := string(content[str.StartByte()+1 : caret])
+
+	switch {
+	case isNamedArgument(arg, content, "service"):
+		return a.serviceCompletionItems(prefix)
+	case isNamedArgument(arg, content, "param"):
+		return a.parameterCompletionItems(prefix)
+	case isNamedArgument(arg, content, "env"):
+		if i := strings.LastIndexByte(prefix, ':'); i >= 0 {
+			prefix = prefix[i+1:]
+		}
+		return envVarCompletionItems(a.container, prefix)
+	case arg.Equal(attributeArgument(attr, content, "value", 0)):
+		if after, ok := strings.CutPrefix(prefix, "@"); ok {
+			return a.serviceCompletionItems(after)
+		}
+		if after, ok := strings.CutPrefix(prefix, "%"); ok && !strings.Contains(after, "%") && !strings.HasPrefix(after, "env(") {
+			return a.parameterCompletionItems(after)
+		}
+	}
+	return nil
+}
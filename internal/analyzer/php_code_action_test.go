@@ -1,13 +1,13 @@
 package analyzer
 
 import (
+	"context"
 	"testing"
 
 	"github.com/shinyvision/vimfony/internal/config"
 	php "github.com/shinyvision/vimfony/internal/php"
 	"github.com/shinyvision/vimfony/internal/utils"
 	"github.com/stretchr/testify/require"
-	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
@@ -27,7 +27,7 @@ class User {
 `)
 
 	analyzer := NewPHPAnalyzer()
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(config.AutoloadMap{}, "")
 
 	pa := analyzer.(*phpAnalyzer)
@@ -41,12 +41,12 @@ class User {
 		Range:        protocol.Range{Start: pos, End: pos},
 	}
 
-	actions, err := pa.OnCodeAction(&glsp.Context{}, params)
+	actions, err := pa.OnCodeAction(context.Background(), params)
 	require.NoError(t, err)
 
-	require.Len(t, actions, 3)
+	require.Len(t, actions, 5)
 
-	findAction := func(title string) *protocol.CodeAction {
+	findAction := func(title string) *CodeAction {
 		for _, a := range actions {
 			if a.Title == title {
 				return &a
@@ -57,7 +57,7 @@ class User {
 
 	gsAction := findAction("Generate getters & setters")
 	require.NotNil(t, gsAction)
-	gsEdit := gsAction.Edit.Changes[protocol.DocumentUri("file:///test.php")][0]
+	gsEdit := gsAction.Resolve().Edit.Changes[protocol.DocumentUri("file:///test.php")][0]
 	gsText := gsEdit.NewText
 
 	require.Contains(t, gsText, "getAge(): ?int")
@@ -72,7 +72,7 @@ class User {
 
 	gAction := findAction("Generate getters")
 	require.NotNil(t, gAction)
-	gText := gAction.Edit.Changes[protocol.DocumentUri("file:///test.php")][0].NewText
+	gText := gAction.Resolve().Edit.Changes[protocol.DocumentUri("file:///test.php")][0].NewText
 	require.Contains(t, gText, "getAge")
 	require.Contains(t, gText, "isActive")
 	require.Contains(t, gText, "getUnknown")
@@ -81,12 +81,19 @@ class User {
 
 	sAction := findAction("Generate setters")
 	require.NotNil(t, sAction)
-	sText := sAction.Edit.Changes[protocol.DocumentUri("file:///test.php")][0].NewText
+	sText := sAction.Resolve().Edit.Changes[protocol.DocumentUri("file:///test.php")][0].NewText
 	require.Contains(t, sText, "setName")
 	require.Contains(t, sText, "setAge")
 	require.Contains(t, sText, "setIsActive")
 	require.Contains(t, sText, "setUnknown")
 	require.NotContains(t, sText, "getName")
+	require.Contains(t, sText, "setName(string $name): void")
+
+	fAction := findAction("Generate fluent setters")
+	require.NotNil(t, fAction)
+	fText := fAction.Resolve().Edit.Changes[protocol.DocumentUri("file:///test.php")][0].NewText
+	require.Contains(t, fText, "setName(string $name): static")
+	require.Contains(t, fText, "        $this->name = $name;\n\n        return $this;\n")
 
 	require.Equal(t, uint32(4), gsEdit.Range.Start.Line)
 }
@@ -100,7 +107,7 @@ class BoolTest {
 }
 `)
 	analyzer := NewPHPAnalyzer()
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(config.AutoloadMap{}, "")
 
 	path := "/bool.php"
@@ -115,12 +122,12 @@ class BoolTest {
 		Range:        protocol.Range{Start: pos, End: pos},
 	}
 
-	actions, err := pa.OnCodeAction(&glsp.Context{}, params)
+	actions, err := pa.OnCodeAction(context.Background(), params)
 	require.NoError(t, err)
 
 	gAction := actions[1]
 	require.Equal(t, "Generate getters", gAction.Title)
-	text := gAction.Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0].NewText
+	text := gAction.Resolve().Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0].NewText
 
 	require.Contains(t, text, "function isValid()")
 	require.Contains(t, text, "function isEnabled()")
@@ -136,7 +143,7 @@ class InsertionTest {
 }
 `)
 	analyzer := NewPHPAnalyzer()
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(config.AutoloadMap{}, "")
 
 	path := "/insertion.php"
@@ -151,12 +158,12 @@ class InsertionTest {
 		Range:        protocol.Range{Start: pos, End: pos},
 	}
 
-	actions, err := pa.OnCodeAction(&glsp.Context{}, params)
+	actions, err := pa.OnCodeAction(context.Background(), params)
 	require.NoError(t, err)
 
 	require.NotEmpty(t, actions)
 	action := actions[0]
-	edit := action.Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0]
+	edit := action.Resolve().Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0]
 
 	require.Equal(t, uint32(4), edit.Range.Start.Line)
 }
@@ -172,7 +179,7 @@ class AlternateTest {
 }
 `)
 	analyzer := NewPHPAnalyzer()
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(config.AutoloadMap{}, "")
 
 	path := "/alternate.php"
@@ -187,12 +194,14 @@ class AlternateTest {
 		Range:        protocol.Range{Start: pos, End: pos},
 	}
 
-	actions, err := pa.OnCodeAction(&glsp.Context{}, params)
+	actions, err := pa.OnCodeAction(context.Background(), params)
 	require.NoError(t, err)
 
-	require.Len(t, actions, 1)
+	require.Len(t, actions, 3)
 	require.Equal(t, "Generate setters", actions[0].Title)
-	require.Contains(t, actions[0].Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0].NewText, "setActive")
+	require.Equal(t, "Generate fluent setters", actions[1].Title)
+	require.Equal(t, "Generate constructor", actions[2].Title)
+	require.Contains(t, actions[0].Resolve().Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0].NewText, "setActive")
 }
 
 func TestOnCodeAction_NamespacedResolution(t *testing.T) {
@@ -213,7 +222,7 @@ class TestEntity {
 `)
 
 	analyzerVar := NewPHPAnalyzer()
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(config.AutoloadMap{}, "/project")
 
 	path := "/project/src/Entity/TestEntity.php"
@@ -228,12 +237,12 @@ class TestEntity {
 		Range:        protocol.Range{Start: pos, End: pos},
 	}
 
-	actions, err := pa.OnCodeAction(&glsp.Context{}, params)
+	actions, err := pa.OnCodeAction(context.Background(), params)
 	require.NoError(t, err)
 
 	require.NotEmpty(t, actions)
 
-	var gettersAction *protocol.CodeAction
+	var gettersAction *CodeAction
 	for _, action := range actions {
 		if action.Title == "Generate getters" {
 			gettersAction = &action
@@ -242,7 +251,7 @@ class TestEntity {
 	}
 	require.NotNil(t, gettersAction)
 
-	newText := gettersAction.Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0].NewText
+	newText := gettersAction.Resolve().Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0].NewText
 
 	require.Contains(t, newText, ": int")
 
@@ -254,3 +263,283 @@ class TestEntity {
 
 	require.Contains(t, newText, "function getOther(): \\Other\\Lib\\Clazz")
 }
+
+func TestOnCodeAction_ImportClass(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use App\Entity\User;
+use Symfony\Component\HttpFoundation\Response;
+
+class HomeController
+{
+    public function index(): Response
+    {
+        return new JsonResponse([]);
+    }
+}
+`)
+
+	analyzer := NewPHPAnalyzer()
+	store := php.NewDocumentStore(0)
+	store.Configure(config.AutoloadMap{}, "")
+
+	pa := analyzer.(*phpAnalyzer)
+	pa.SetDocumentStore(store)
+	pa.SetDocumentPath("/test.php")
+	pa.SetAutoloadMap(&config.AutoloadMap{
+		Classes: config.ClassIndex{
+			"JsonResponse": {"Symfony\\Component\\HttpFoundation\\JsonResponse"},
+		},
+	})
+	require.NoError(t, analyzer.Changed(content, nil))
+
+	pos := protocol.Position{Line: 11, Character: 21}
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.php"},
+		Range:        protocol.Range{Start: pos, End: pos},
+	}
+
+	actions, err := pa.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+
+	var importAction *CodeAction
+	for i := range actions {
+		if actions[i].Title == "Add use Symfony\\Component\\HttpFoundation\\JsonResponse" {
+			importAction = &actions[i]
+		}
+	}
+	require.NotNil(t, importAction)
+
+	edits := importAction.Resolve().Edit.Changes[protocol.DocumentUri("file:///test.php")]
+	require.Len(t, edits, 1)
+	require.Equal(t, uint32(5), edits[0].Range.Start.Line)
+	require.Equal(t, "use Symfony\\Component\\HttpFoundation\\JsonResponse;\n", edits[0].NewText)
+
+	// Already imported classes are left alone
+	pos = protocol.Position{Line: 9, Character: 30}
+	params.Range = protocol.Range{Start: pos, End: pos}
+	actions, err = pa.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+	for _, action := range actions {
+		require.NotContains(t, action.Title, "Add use")
+	}
+}
+
+func TestOnCodeAction_ImportClassSortsOnImportedNames(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use App\Entity\User as Member;
+use Symfony\Component\HttpFoundation\{Request, Response};
+use function Symfony\Component\String\u;
+
+class HomeController
+{
+    public function index(): Response
+    {
+        return new JsonResponse([]);
+    }
+}
+`)
+
+	analyzer := NewPHPAnalyzer()
+	store := php.NewDocumentStore(0)
+	store.Configure(config.AutoloadMap{}, "")
+
+	pa := analyzer.(*phpAnalyzer)
+	pa.SetDocumentStore(store)
+	pa.SetDocumentPath("/test.php")
+	pa.SetAutoloadMap(&config.AutoloadMap{
+		Classes: config.ClassIndex{
+			"JsonResponse": {"Symfony\\Component\\HttpFoundation\\JsonResponse"},
+		},
+	})
+	require.NoError(t, analyzer.Changed(content, nil))
+
+	pos := protocol.Position{Line: 12, Character: 21}
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.php"},
+		Range:        protocol.Range{Start: pos, End: pos},
+	}
+
+	actions, err := pa.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+	require.NotEmpty(t, actions)
+	require.Equal(t, "Add use Symfony\\Component\\HttpFoundation\\JsonResponse", actions[0].Title)
+
+	// The grouped import isn't sorted against, so the new import follows it
+	// rather than the aliased one, and `use function` stays last
+	edits := actions[0].Resolve().Edit.Changes[params.TextDocument.URI]
+	require.Len(t, edits, 1)
+	require.Equal(t, uint32(6), edits[0].Range.Start.Line)
+}
+
+func TestOnCodeAction_ConvertRouteAnnotation(t *testing.T) {
+	content := []byte(`<?php
+
+namespace App\Controller;
+
+use Sensio\Bundle\FrameworkExtraBundle\Configuration\Route;
+
+class BlogController
+{
+    /**
+     * Shows a post.
+     *
+     * @Route("/blog/{id}", name="blog_show", methods={"GET", "HEAD"},
+     *     requirements={"id"="\d+"})
+     */
+    public function show(int $id)
+    {
+    }
+}
+`)
+
+	analyzer := NewPHPAnalyzer()
+	store := php.NewDocumentStore(0)
+	store.Configure(config.AutoloadMap{}, "")
+
+	pa := analyzer.(*phpAnalyzer)
+	pa.SetDocumentStore(store)
+	pa.SetDocumentPath("/test.php")
+	require.NoError(t, analyzer.Changed(content, nil))
+
+	pos := protocol.Position{Line: 14, Character: 22}
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///test.php"},
+		Range:        protocol.Range{Start: pos, End: pos},
+	}
+
+	actions, err := pa.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+
+	var convert *CodeAction
+	for i := range actions {
+		if actions[i].Title == "Convert @Route annotations to attributes" {
+			convert = &actions[i]
+		}
+	}
+	require.NotNil(t, convert)
+
+	edits := convert.Resolve().Edit.Changes[protocol.DocumentUri("file:///test.php")]
+	require.Len(t, edits, 3)
+
+	// The annotation lines are removed from the docblock
+	require.Equal(t, uint32(11), edits[0].Range.Start.Line)
+	require.Equal(t, uint32(13), edits[0].Range.End.Line)
+	require.Equal(t, "", edits[0].NewText)
+
+	require.Equal(t, uint32(14), edits[1].Range.Start.Line)
+	require.Equal(t, "    #[Route('/blog/{id}', name: 'blog_show', methods: ['GET', 'HEAD'], requirements: ['id' => '\\d+'])]\n", edits[1].NewText)
+
+	require.Equal(t, "Symfony\\Component\\Routing\\Annotation\\Route", edits[2].NewText)
+}
+
+func TestOnCodeAction_GenerateConstructor(t *testing.T) {
+	content := []byte(`<?php
+class Mailer {
+    private static int $instances = 0;
+    private readonly string $sender;
+    protected ?int $retries = 3, $timeout;
+
+    public function send(): void {
+    }
+}
+`)
+	analyzer := NewPHPAnalyzer()
+	store := php.NewDocumentStore(0)
+	store.Configure(config.AutoloadMap{}, "")
+
+	path := "/mailer.php"
+	pa := analyzer.(*phpAnalyzer)
+	pa.SetDocumentStore(store)
+	pa.SetDocumentPath(path)
+	require.NoError(t, analyzer.Changed(content, nil))
+
+	pos := protocol.Position{Line: 3, Character: 4}
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri(utils.PathToURI(path))},
+		Range:        protocol.Range{Start: pos, End: pos},
+	}
+
+	actions, err := pa.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+
+	var ctor *CodeAction
+	for i := range actions {
+		if actions[i].Title == "Generate constructor" {
+			ctor = &actions[i]
+		}
+	}
+	require.NotNil(t, ctor)
+
+	edits := ctor.Resolve().Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))]
+	require.Len(t, edits, 2)
+	require.Equal(t, uint32(3), edits[0].Range.Start.Line)
+	require.Equal(t, uint32(4), edits[0].Range.End.Line)
+	require.Equal(t, `    public function __construct(
+        private readonly string $sender,
+        protected ?int $retries = 3,
+        protected ?int $timeout,
+    ) {
+    }
+`, edits[0].NewText)
+	require.Equal(t, uint32(4), edits[1].Range.Start.Line)
+	require.Equal(t, "", edits[1].NewText)
+}
+
+func TestOnCodeAction_FluentSettersByDefault(t *testing.T) {
+	content := []byte(`<?php
+class Fluent {
+    private string $name;
+}
+`)
+	analyzer := NewPHPAnalyzer()
+	store := php.NewDocumentStore(0)
+	store.Configure(config.AutoloadMap{}, "")
+
+	path := "/fluent.php"
+	pa := analyzer.(*phpAnalyzer)
+	pa.SetDocumentStore(store)
+	pa.SetDocumentPath(path)
+	container := config.NewContainerConfig()
+	container.FluentSetters = true
+	pa.SetContainerConfig(container)
+	require.NoError(t, analyzer.Changed(content, nil))
+
+	pos := protocol.Position{Line: 2, Character: 4}
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri(utils.PathToURI(path))},
+		Range:        protocol.Range{Start: pos, End: pos},
+	}
+
+	actions, err := pa.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+
+	texts := make(map[string]string)
+	for _, action := range actions {
+		texts[action.Title] = action.Resolve().Edit.Changes[protocol.DocumentUri(utils.PathToURI(path))][0].NewText
+	}
+	require.Contains(t, texts["Generate setters"], "setName(string $name): static")
+	require.Contains(t, texts["Generate getters & setters"], "return $this;")
+	require.Contains(t, texts["Generate void setters"], "setName(string $name): void")
+}
+
+func TestCodeActionBuildsEditOnResolve(t *testing.T) {
+	built := 0
+	action := newCodeAction("Refactor", protocol.CodeActionKindRefactor, func() *protocol.WorkspaceEdit {
+		built++
+		return &protocol.WorkspaceEdit{}
+	})
+
+	require.Nil(t, action.Edit)
+	require.Equal(t, 0, built)
+
+	resolved := action.Resolve()
+	require.NotNil(t, resolved.Edit)
+	require.Equal(t, "Refactor", resolved.Title)
+	require.Equal(t, 1, built)
+}
@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -8,13 +9,50 @@ import (
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	php "github.com/shinyvision/vimfony/internal/php"
 	"github.com/shinyvision/vimfony/internal/utils"
-	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
-func (a *phpAnalyzer) OnCodeAction(context *glsp.Context, params *protocol.CodeActionParams) ([]protocol.CodeAction, error) {
+func (a *phpAnalyzer) OnCodeAction(ctx context.Context, params *protocol.CodeActionParams) ([]CodeAction, error) {
+	actions := a.translationCodeActions(params.Range.Start)
+	actions = append(actions, a.importClassCodeActions(params)...)
+	actions = append(actions, a.routeAnnotationCodeActions(params)...)
+	// The accessors and the constructor load the class from the store
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	accessors, err := a.accessorCodeActions(params)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, accessors...)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append(actions, a.constructorCodeActions(params)...), nil
+}
+
+func (a *phpAnalyzer) translationCodeActions(pos protocol.Position) []CodeAction {
+	a.mu.RLock()
+	container := a.container
+	ctx, ok := a.translationContextAt(pos)
+	key, domain := "", ""
+	if ok {
+		key = a.stringContent(ctx.strNode)
+		domain = a.translationDomain(ctx)
+	}
+	a.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	return translationKeyCodeActions(container, key, domain)
+}
+
+// Getters and setters for the properties of the class under the cursor
+func (a *phpAnalyzer) accessorCodeActions(params *protocol.CodeActionParams) ([]CodeAction, error) {
 	a.mu.RLock()
 	store := a.docStore
+	fluent := a.container != nil && a.container.FluentSetters
 	a.mu.RUnlock()
 
 	if store == nil {
@@ -191,9 +229,9 @@ func (a *phpAnalyzer) OnCodeAction(context *glsp.Context, params *protocol.CodeA
 		}
 	}
 
-	var actions []protocol.CodeAction
+	var actions []CodeAction
 
-	generateCode := func(props []string, generateGetter, generateSetter bool) string {
+	generateCode := func(props []string, generateGetter, generateSetter, fluentSetter bool) string {
 		var parts []string
 		for _, name := range props {
 			typeStr := formatType(classProperties[name])
@@ -220,9 +258,16 @@ func (a *phpAnalyzer) OnCodeAction(context *glsp.Context, params *protocol.CodeA
 				} else {
 					sb.WriteString("mixed ")
 				}
-				sb.WriteString(fmt.Sprintf("$%s): void\n", name))
+				if fluentSetter {
+					sb.WriteString(fmt.Sprintf("$%s): static\n", name))
+				} else {
+					sb.WriteString(fmt.Sprintf("$%s): void\n", name))
+				}
 				sb.WriteString("    {\n")
 				sb.WriteString(fmt.Sprintf("        $this->%s = $%s;\n", name, name))
+				if fluentSetter {
+					sb.WriteString("\n        return $this;\n")
+				}
 				sb.WriteString("    }")
 				parts = append(parts, sb.String())
 			}
@@ -335,18 +380,30 @@ func (a *phpAnalyzer) OnCodeAction(context *glsp.Context, params *protocol.CodeA
 	}
 
 	if len(bothProps) > 0 {
-		code := prefix + generateCode(bothProps, true, true) + suffix
-		actions = append(actions, createCodeAction("Generate getters & setters", code, params.TextDocument.URI, insertionPos))
+		actions = append(actions, createCodeAction("Generate getters & setters", params.TextDocument.URI, insertionPos, func() string {
+			return prefix + generateCode(bothProps, true, true, fluent) + suffix
+		}))
 	}
 
 	if len(propertiesForGetter) > 0 {
-		code := prefix + generateCode(propertiesForGetter, true, false) + suffix
-		actions = append(actions, createCodeAction("Generate getters", code, params.TextDocument.URI, insertionPos))
+		actions = append(actions, createCodeAction("Generate getters", params.TextDocument.URI, insertionPos, func() string {
+			return prefix + generateCode(propertiesForGetter, true, false, fluent) + suffix
+		}))
 	}
 
 	if len(propertiesForSetter) > 0 {
-		code := prefix + generateCode(propertiesForSetter, false, true) + suffix
-		actions = append(actions, createCodeAction("Generate setters", code, params.TextDocument.URI, insertionPos))
+		actions = append(actions, createCodeAction("Generate setters", params.TextDocument.URI, insertionPos, func() string {
+			return prefix + generateCode(propertiesForSetter, false, true, fluent) + suffix
+		}))
+
+		// The other setter style than the configured one
+		title := "Generate fluent setters"
+		if fluent {
+			title = "Generate void setters"
+		}
+		actions = append(actions, createCodeAction(title, params.TextDocument.URI, insertionPos, func() string {
+			return prefix + generateCode(propertiesForSetter, false, true, !fluent) + suffix
+		}))
 	}
 
 	return actions, nil
@@ -369,12 +426,10 @@ func getSetterName(name string) string {
 	return "set" + php.ToPascalCase(name)
 }
 
-func createCodeAction(title, newText, uri string, pos protocol.Position) protocol.CodeAction {
-	kind := protocol.CodeActionKindRefactor
-	return protocol.CodeAction{
-		Title: title,
-		Kind:  &kind,
-		Edit: &protocol.WorkspaceEdit{
+// Inserts the generated code at pos; the code is only generated on resolve
+func createCodeAction(title, uri string, pos protocol.Position, newText func() string) CodeAction {
+	return newCodeAction(title, protocol.CodeActionKindRefactor, func() *protocol.WorkspaceEdit {
+		return &protocol.WorkspaceEdit{
 			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
 				protocol.DocumentUri(uri): {
 					{
@@ -382,12 +437,12 @@ func createCodeAction(title, newText, uri string, pos protocol.Position) protoco
 							Start: pos,
 							End:   pos,
 						},
-						NewText: newText,
+						NewText: newText(),
 					},
 				},
 			},
-		},
-	}
+		}
+	})
 }
 
 func offsetAt(content []byte, pos protocol.Position) int {
@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/twig"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Commands of the template code lenses, run by the server with the name of
+// the template and the URI of the document as arguments. They return the
+// location of the template.
+const (
+	CommandOpenTemplate   = "vimfony.openTemplate"
+	CommandCreateTemplate = "vimfony.createTemplate"
+)
+
+func (a *phpAnalyzer) OnCodeLens() ([]protocol.CodeLens, error) {
+	lenses := a.routeCodeLenses()
+	lenses = append(lenses, a.templateCodeLenses()...)
+	return lenses, nil
+}
+
+// Puts an "Open template" lens above the render() calls, or "Create
+// template" when the template does not exist yet
+func (a *phpAnalyzer) templateCodeLenses() []protocol.CodeLens {
+	a.mu.RLock()
+	container := a.container
+	uri := utils.PathToURI(a.path)
+	features := a.features
+	a.mu.RUnlock()
+
+	if a.doc == nil || container == nil || !features.Enabled(config.FeatureTemplates) {
+		return nil
+	}
+
+	type renderCall struct {
+		node     sitter.Node
+		template string
+	}
+	var calls []renderCall
+	var content []byte
+	var index php.IndexedTree
+	a.doc.Read(func(tree *sitter.Tree, c []byte, idx php.IndexedTree) {
+		if tree == nil {
+			return
+		}
+		content, index = c, idx
+		walkNodes(tree.RootNode(), func(n sitter.Node) {
+			if n.Type() != "member_call_expression" && n.Type() != "nullsafe_member_call_expression" {
+				return
+			}
+			args := n.ChildByFieldName("arguments")
+			if args.IsNull() || args.NamedChildCount() == 0 {
+				return
+			}
+			str := argumentStringNode(args.NamedChild(0))
+			// Interpolated names are only known at runtime
+			if str.IsNull() || strings.Contains(str.Content(c), "$") {
+				return
+			}
+			calls = append(calls, renderCall{node: n, template: phpStringLiteral(str, c)})
+		})
+	})
+
+	var lenses []protocol.CodeLens
+	for _, call := range calls {
+		if call.template == "" || !a.isRenderCall(call.node, content, index) {
+			continue
+		}
+		rng := nodeRange(call.node, content)
+		if _, ok := twig.Resolve(call.template, container); ok {
+			lenses = append(lenses, protocol.CodeLens{Range: rng, Command: &protocol.Command{
+				Title:     "Open template",
+				Command:   CommandOpenTemplate,
+				Arguments: []any{call.template, uri},
+			}})
+		} else if _, ok := twig.NewTemplatePath(call.template, container); ok {
+			lenses = append(lenses, protocol.CodeLens{Range: rng, Command: &protocol.Command{
+				Title:     "Create template",
+				Command:   CommandCreateTemplate,
+				Arguments: []any{call.template, uri},
+			}})
+		}
+	}
+	return lenses
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// "Generate constructor" moves the selected properties (all of them when
+// nothing is selected) into a constructor as promoted parameters.
+func (a *phpAnalyzer) constructorCodeActions(params *protocol.CodeActionParams) []CodeAction {
+	a.mu.RLock()
+	doc := a.doc
+	a.mu.RUnlock()
+
+	if doc == nil {
+		return nil
+	}
+
+	node, content, _, ok := doc.GetNodeAt(params.Range.Start)
+	if !ok {
+		return nil
+	}
+
+	var body sitter.Node
+	for cur := node; !cur.IsNull(); cur = cur.Parent() {
+		if cur.Type() == "class_declaration" {
+			body = cur.ChildByFieldName("body")
+			break
+		}
+	}
+	if body.IsNull() {
+		return nil
+	}
+
+	var all, selected []sitter.Node
+	for i := uint32(0); i < body.NamedChildCount(); i++ {
+		child := body.NamedChild(i)
+		switch child.Type() {
+		case "method_declaration":
+			if name := child.ChildByFieldName("name"); !name.IsNull() && strings.EqualFold(name.Content(content), "__construct") {
+				return nil
+			}
+		case "property_declaration":
+			if isStaticProperty(child) {
+				continue
+			}
+			all = append(all, child)
+			if uint32(child.EndPoint().Row) >= params.Range.Start.Line && uint32(child.StartPoint().Row) <= params.Range.End.Line {
+				selected = append(selected, child)
+			}
+		}
+	}
+	if params.Range.Start == params.Range.End || len(selected) == 0 {
+		selected = all
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	return []CodeAction{newCodeAction("Generate constructor", protocol.CodeActionKindRefactor, func() *protocol.WorkspaceEdit {
+		indent := lineIndent(content, int(selected[0].StartPoint().Row))
+		var ctor strings.Builder
+		ctor.WriteString(indent + "public function __construct(\n")
+		for _, decl := range selected {
+			for _, param := range promotedParameters(decl, content) {
+				ctor.WriteString(indent + indent + param + ",\n")
+			}
+		}
+		ctor.WriteString(indent + ") {\n")
+		ctor.WriteString(indent + "}\n")
+
+		edits := make([]protocol.TextEdit, 0, len(selected))
+		for i, decl := range selected {
+			text := ""
+			if i == 0 {
+				text = ctor.String()
+			}
+			edits = append(edits, lineRangeEdit(int(decl.StartPoint().Row), int(decl.EndPoint().Row), text))
+		}
+
+		return &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				params.TextDocument.URI: edits,
+			},
+		}
+	})}
+}
+
+func isStaticProperty(decl sitter.Node) bool {
+	for i := uint32(0); i < decl.NamedChildCount(); i++ {
+		if decl.NamedChild(i).Type() == "static_modifier" {
+			return true
+		}
+	}
+	return false
+}
+
+// Turns "private ?int $a = null, $b;" into "private ?int $a = null" and "private ?int $b"
+func promotedParameters(decl sitter.Node, content []byte) []string {
+	var elements []sitter.Node
+	for i := uint32(0); i < decl.NamedChildCount(); i++ {
+		if child := decl.NamedChild(i); child.Type() == "property_element" {
+			elements = append(elements, child)
+		}
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+
+	prefix := strings.Fields(string(content[decl.StartByte():elements[0].StartByte()]))
+	if len(prefix) > 0 && prefix[0] == "var" {
+		prefix[0] = "public"
+	}
+
+	params := make([]string, 0, len(elements))
+	for _, element := range elements {
+		param := strings.Join(append(append([]string{}, prefix...), strings.TrimSpace(element.Content(content))), " ")
+		params = append(params, param)
+	}
+	return params
+}
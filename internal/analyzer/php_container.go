@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	php "github.com/shinyvision/vimfony/internal/php"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	containerInterfaceFQN    = "Symfony\\Component\\DependencyInjection\\ContainerInterface"
+	psrContainerInterfaceFQN = "Psr\\Container\\ContainerInterface"
+)
+
+// Completes service ids in $container->get('...'), $this->container->get('...')
+// and static::getContainer()->get('...')
+func (a *phpAnalyzer) containerGetCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	str, ok := a.containerGetContextAt(pos)
+	if !ok {
+		return nil
+	}
+	return a.serviceCompletionItems(a.stringPrefix(str, pos))
+}
+
+func (a *phpAnalyzer) containerGetContextAt(pos protocol.Position) (sitter.Node, bool) {
+	if a.doc == nil {
+		return sitter.Node{}, false
+	}
+
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return sitter.Node{}, false
+	}
+
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok || argumentIndex(arg) != 0 || !isMemberCall(callNode) {
+		return sitter.Node{}, false
+	}
+
+	nameNode := callNode.ChildByFieldName("name")
+	if nameNode.IsNull() || strings.TrimSpace(nameNode.Content(content)) != "get" {
+		return sitter.Node{}, false
+	}
+	return str, a.isContainerExpression(callNode.ChildByFieldName("object"), callNode, content, index)
+}
+
+func (a *phpAnalyzer) isContainerExpression(object, callNode sitter.Node, content []byte, index php.IndexedTree) bool {
+	if object.IsNull() {
+		return false
+	}
+
+	switch object.Type() {
+	case "variable_name":
+		varName := php.VariableNameFromNode(object, content)
+		if varName == "" {
+			return false
+		}
+		if varName == "container" {
+			return true
+		}
+		funcName := a.enclosingFunctionName(callNode)
+		return funcName != "" && variableHasContainerTypeIndex(index, funcName, varName, int(callNode.StartPoint().Row)+1)
+
+	case "member_access_expression", "nullsafe_member_access_expression":
+		propertyName := thisPropertyNameFromMemberAccessContent(content, object)
+		if propertyName == "" {
+			return false
+		}
+		// AbstractController keeps its service locator in $this->container
+		return propertyName == "container" || propertyHasContainerTypeIndex(index, propertyName)
+
+	case "scoped_property_access_expression":
+		// KernelTestCase::$container in older Symfony versions
+		nameNode := object.ChildByFieldName("name")
+		return !nameNode.IsNull() && strings.TrimSpace(nameNode.Content(content)) == "$container"
+
+	case "member_call_expression", "nullsafe_member_call_expression", "scoped_call_expression":
+		// static::getContainer(), $this->getContainer() and $kernel->getContainer()
+		nameNode := object.ChildByFieldName("name")
+		return !nameNode.IsNull() && strings.TrimSpace(nameNode.Content(content)) == "getContainer"
+	}
+
+	return false
+}
+
+func canonicalContainerType(name string) (string, bool) {
+	normalized := normalizeFQN(name)
+	if normalized == "" {
+		return "", false
+	}
+
+	for _, target := range []string{containerInterfaceFQN, psrContainerInterfaceFQN} {
+		if strings.EqualFold(normalized, target) || strings.EqualFold(shortName(normalized), shortName(target)) {
+			return target, true
+		}
+	}
+	if strings.EqualFold(shortName(normalized), "Container") {
+		return containerInterfaceFQN, true
+	}
+
+	return "", false
+}
+
+func variableHasContainerTypeIndex(index php.IndexedTree, funcName, varName string, line int) bool {
+	return variableHasTypeIndex(index, funcName, varName, line, canonicalContainerType)
+}
+
+func propertyHasContainerTypeIndex(index php.IndexedTree, name string) bool {
+	return propertyHasTypeIndex(index, name, canonicalContainerType)
+}
@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	php "github.com/shinyvision/vimfony/internal/php"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	eventDispatcherInterfaceFQN          = "Symfony\\Component\\EventDispatcher\\EventDispatcherInterface"
+	contractsEventDispatcherInterfaceFQN = "Symfony\\Contracts\\EventDispatcher\\EventDispatcherInterface"
+)
+
+// Event names dispatched by the kernel, the console and Doctrine, with the
+// event class they pass to listeners
+var knownEventNames = map[string]string{
+	"kernel.request":              "Symfony\\Component\\HttpKernel\\Event\\RequestEvent",
+	"kernel.controller":           "Symfony\\Component\\HttpKernel\\Event\\ControllerEvent",
+	"kernel.controller_arguments": "Symfony\\Component\\HttpKernel\\Event\\ControllerArgumentsEvent",
+	"kernel.view":                 "Symfony\\Component\\HttpKernel\\Event\\ViewEvent",
+	"kernel.response":             "Symfony\\Component\\HttpKernel\\Event\\ResponseEvent",
+	"kernel.finish_request":       "Symfony\\Component\\HttpKernel\\Event\\FinishRequestEvent",
+	"kernel.terminate":            "Symfony\\Component\\HttpKernel\\Event\\TerminateEvent",
+	"kernel.exception":            "Symfony\\Component\\HttpKernel\\Event\\ExceptionEvent",
+	"console.command":             "Symfony\\Component\\Console\\Event\\ConsoleCommandEvent",
+	"console.signal":              "Symfony\\Component\\Console\\Event\\ConsoleSignalEvent",
+	"console.error":               "Symfony\\Component\\Console\\Event\\ConsoleErrorEvent",
+	"console.terminate":           "Symfony\\Component\\Console\\Event\\ConsoleTerminateEvent",
+	"prePersist":                  "Doctrine\\ORM\\Event\\PrePersistEventArgs",
+	"postPersist":                 "Doctrine\\ORM\\Event\\PostPersistEventArgs",
+	"preUpdate":                   "Doctrine\\ORM\\Event\\PreUpdateEventArgs",
+	"postUpdate":                  "Doctrine\\ORM\\Event\\PostUpdateEventArgs",
+	"preRemove":                   "Doctrine\\ORM\\Event\\PreRemoveEventArgs",
+	"postRemove":                  "Doctrine\\ORM\\Event\\PostRemoveEventArgs",
+	"postLoad":                    "Doctrine\\ORM\\Event\\PostLoadEventArgs",
+	"preFlush":                    "Doctrine\\ORM\\Event\\PreFlushEventArgs",
+	"onFlush":                     "Doctrine\\ORM\\Event\\OnFlushEventArgs",
+	"postFlush":                   "Doctrine\\ORM\\Event\\PostFlushEventArgs",
+	"onClear":                     "Doctrine\\ORM\\Event\\OnClearEventArgs",
+	"loadClassMetadata":           "Doctrine\\ORM\\Event\\LoadClassMetadataEventArgs",
+}
+
+// Completes event names and event classes in #[AsEventListener(event: '...')]
+// and $dispatcher->addListener('...')
+func (a *phpAnalyzer) eventNameCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	str, ok := a.eventNameContextAt(pos)
+	if !ok {
+		return nil
+	}
+
+	return eventCompletionItems(a.stringPrefix(str, pos), a.autoload)
+}
+
+// Completes the known event names and the event classes of the autoload map
+func eventCompletionItems(prefix string, autoload config.AutoloadMap) []protocol.CompletionItem {
+	eventKind := protocol.CompletionItemKindEvent
+	items := []protocol.CompletionItem{}
+	for name, class := range knownEventNames {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		detail := class
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &eventKind, Detail: &detail})
+	}
+
+	// Listeners can also subscribe to the FQN of an event class
+	classKind := protocol.CompletionItemKindClass
+	lowerPrefix := strings.ToLower(strings.TrimPrefix(prefix, "\\"))
+	for short, classes := range autoload.Classes {
+		if !strings.HasSuffix(short, "Event") {
+			continue
+		}
+		for _, class := range classes {
+			if !strings.Contains(strings.ToLower(class), lowerPrefix) {
+				continue
+			}
+			detail := "event class"
+			items = append(items, protocol.CompletionItem{Label: class, Kind: &classKind, Detail: &detail})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if *items[i].Kind != *items[j].Kind {
+			return *items[i].Kind == eventKind
+		}
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+func (a *phpAnalyzer) eventNameContextAt(pos protocol.Position) (sitter.Node, bool) {
+	if a.doc == nil {
+		return sitter.Node{}, false
+	}
+
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return sitter.Node{}, false
+	}
+
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok {
+		return sitter.Node{}, false
+	}
+
+	if !callNode.IsNull() && callNode.Type() == "attribute" {
+		if attributeShortName(callNode, content) != "AsEventListener" {
+			return sitter.Node{}, false
+		}
+		return str, arg.Equal(attributeArgument(callNode, content, "event", 0))
+	}
+
+	if argumentIndex(arg) != 0 || !isMemberCall(callNode) {
+		return sitter.Node{}, false
+	}
+
+	nameNode := callNode.ChildByFieldName("name")
+	if nameNode.IsNull() || strings.TrimSpace(nameNode.Content(content)) != "addListener" {
+		return sitter.Node{}, false
+	}
+	return str, a.isEventDispatcherExpression(callNode.ChildByFieldName("object"), callNode, content, index)
+}
+
+func (a *phpAnalyzer) isEventDispatcherExpression(object, callNode sitter.Node, content []byte, index php.IndexedTree) bool {
+	if object.IsNull() {
+		return false
+	}
+
+	switch object.Type() {
+	case "variable_name":
+		varName := php.VariableNameFromNode(object, content)
+		funcName := a.enclosingFunctionName(callNode)
+		return varName != "" && funcName != "" &&
+			variableHasTypeIndex(index, funcName, varName, int(callNode.StartPoint().Row)+1, canonicalEventDispatcherType)
+	case "member_access_expression", "nullsafe_member_access_expression":
+		propertyName := thisPropertyNameFromMemberAccessContent(content, object)
+		return propertyName != "" && propertyHasTypeIndex(index, propertyName, canonicalEventDispatcherType)
+	}
+
+	return false
+}
+
+func canonicalEventDispatcherType(name string) (string, bool) {
+	normalized := normalizeFQN(name)
+	if normalized == "" {
+		return "", false
+	}
+
+	for _, target := range []string{eventDispatcherInterfaceFQN, contractsEventDispatcherInterfaceFQN} {
+		if strings.EqualFold(normalized, target) || strings.EqualFold(shortName(normalized), shortName(target)) {
+			return target, true
+		}
+	}
+	if strings.EqualFold(shortName(normalized), "EventDispatcher") {
+		return eventDispatcherInterfaceFQN, true
+	}
+
+	return "", false
+}
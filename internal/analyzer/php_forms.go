@@ -0,0 +1,235 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+type formField struct {
+	name     string
+	typ      string
+	offset   uint
+	location protocol.Location
+}
+
+// Completes $form->get('...') with the fields the form type adds in buildForm(),
+// when $form was created with createForm(SomeType::class)
+func (a *phpAnalyzer) formFieldCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	str, formType, ok := a.formGetContextAt(pos)
+	if !ok {
+		return nil
+	}
+
+	prefix := a.stringPrefix(str, pos)
+	kind := protocol.CompletionItemKindField
+	items := []protocol.CompletionItem{}
+	for i, field := range formTypeFields(a.docStore, formType) {
+		if !strings.HasPrefix(field.name, prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: field.name, Kind: &kind}
+		if field.typ != "" {
+			detail := field.typ
+			item.Detail = &detail
+		}
+		// Keep the order of buildForm()
+		sortText := fmt.Sprintf("%03d", i)
+		item.SortText = &sortText
+		items = append(items, item)
+	}
+	return items
+}
+
+func (a *phpAnalyzer) formGetContextAt(pos protocol.Position) (sitter.Node, string, bool) {
+	if a.doc == nil {
+		return sitter.Node{}, "", false
+	}
+
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return sitter.Node{}, "", false
+	}
+
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok || argumentIndex(arg) != 0 || !isMemberCall(callNode) {
+		return sitter.Node{}, "", false
+	}
+
+	nameNode := callNode.ChildByFieldName("name")
+	if nameNode.IsNull() || strings.TrimSpace(nameNode.Content(content)) != "get" {
+		return sitter.Node{}, "", false
+	}
+
+	formType := formTypeOfExpression(callNode.ChildByFieldName("object"), callNode, content, index)
+	return str, formType, formType != ""
+}
+
+// Looks for the last `$form = $this->createForm(SomeType::class)` before the
+// call, in the enclosing method for variables or in the class for properties
+func formTypeOfExpression(object, callNode sitter.Node, content []byte, index php.IndexedTree) string {
+	if object.IsNull() {
+		return ""
+	}
+
+	scopeType := "method_declaration"
+	switch object.Type() {
+	case "variable_name":
+	case "member_access_expression", "nullsafe_member_access_expression":
+		if thisPropertyNameFromMemberAccessContent(content, object) == "" {
+			return ""
+		}
+		scopeType = "class_declaration"
+	default:
+		return ""
+	}
+
+	var scope sitter.Node
+	for cur := callNode; !cur.IsNull(); cur = cur.Parent() {
+		if cur.Type() == scopeType || (scopeType == "method_declaration" && cur.Type() == "function_definition") {
+			scope = cur
+			break
+		}
+	}
+	if scope.IsNull() {
+		return ""
+	}
+
+	target := strings.ReplaceAll(object.Content(content), "?->", "->")
+	var typeArg sitter.Node
+	walkNodes(scope, func(n sitter.Node) {
+		if n.Type() != "assignment_expression" {
+			return
+		}
+		if scopeType == "method_declaration" && n.StartByte() > callNode.StartByte() {
+			return
+		}
+		left := n.ChildByFieldName("left")
+		right := n.ChildByFieldName("right")
+		if left.IsNull() || right.IsNull() || strings.ReplaceAll(left.Content(content), "?->", "->") != target {
+			return
+		}
+		if right.Type() != "member_call_expression" {
+			return
+		}
+		name := right.ChildByFieldName("name")
+		if name.IsNull() {
+			return
+		}
+		switch strings.TrimSpace(name.Content(content)) {
+		case "createForm", "create":
+		default:
+			return
+		}
+		args := right.ChildByFieldName("arguments")
+		if !args.IsNull() && args.NamedChildCount() > 0 {
+			typeArg = args.NamedChild(0)
+		}
+	})
+	if typeArg.IsNull() {
+		return ""
+	}
+
+	return classConstantFQN(typeArg, content, phpNamespaceAt(callNode, content), index.Uses)
+}
+
+// Resolves `Foo::class` (optionally wrapped in an argument) to a FQN
+func classConstantFQN(node sitter.Node, content []byte, namespace string, uses map[string]string) string {
+	if node.Type() == "argument" && node.NamedChildCount() > 0 {
+		node = node.NamedChild(node.NamedChildCount() - 1)
+	}
+	if node.Type() != "class_constant_access_expression" || node.NamedChildCount() < 2 {
+		return ""
+	}
+	constant := strings.TrimSpace(node.NamedChild(node.NamedChildCount() - 1).Content(content))
+	if !strings.EqualFold(constant, "class") {
+		return ""
+	}
+
+	return resolveClassName(strings.TrimSpace(node.NamedChild(0).Content(content)), namespace, uses)
+}
+
+// Resolves a class name as written in a file to its FQCN through the file's
+// use statements and namespace
+func resolveClassName(name, namespace string, uses map[string]string) string {
+	if strings.HasPrefix(name, "\\") {
+		return strings.TrimPrefix(name, "\\")
+	}
+	first, rest, _ := strings.Cut(name, "\\")
+	if fqn, ok := uses[first]; ok {
+		if rest != "" {
+			return fqn + "\\" + rest
+		}
+		return fqn
+	}
+	if namespace != "" {
+		return namespace + "\\" + name
+	}
+	return name
+}
+
+// Collects the ->add('name', Type::class) calls of the form type's buildForm()
+func formTypeFields(store *php.DocumentStore, formType string) []formField {
+	if store == nil {
+		return nil
+	}
+	path, _, ok := php.Resolve(store, formType)
+	if !ok {
+		return nil
+	}
+	doc, err := store.Get(path)
+	if err != nil || doc == nil {
+		return nil
+	}
+
+	var fields []formField
+	doc.Read(func(tree *sitter.Tree, content []byte, _ php.IndexedTree) {
+		if tree == nil {
+			return
+		}
+		walkNodes(tree.RootNode(), func(n sitter.Node) {
+			if n.Type() != "method_declaration" {
+				return
+			}
+			name := n.ChildByFieldName("name")
+			if name.IsNull() || name.Content(content) != "buildForm" {
+				return
+			}
+			walkNodes(n, func(call sitter.Node) {
+				if call.Type() != "member_call_expression" {
+					return
+				}
+				method := call.ChildByFieldName("name")
+				args := call.ChildByFieldName("arguments")
+				if method.IsNull() || method.Content(content) != "add" || args.IsNull() || args.NamedChildCount() == 0 {
+					return
+				}
+				field := phpStringLiteral(argumentStringNode(args.NamedChild(0)), content)
+				if field == "" {
+					return
+				}
+				typ := ""
+				if args.NamedChildCount() > 1 {
+					if value := args.NamedChild(1); value.NamedChildCount() > 0 {
+						typ = shortName(strings.TrimSuffix(strings.TrimSpace(value.NamedChild(value.NamedChildCount()-1).Content(content)), "::class"))
+					}
+				}
+				fields = append(fields, formField{
+					name:     field,
+					typ:      typ,
+					offset:   args.NamedChild(0).StartByte(),
+					location: protocol.Location{URI: protocol.DocumentUri(utils.PathToURI(path)), Range: nodeRange(args.NamedChild(0), content)},
+				})
+			})
+		})
+	})
+
+	// Outer calls of a fluent chain are visited first, restore the source order
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].offset < fields[j].offset })
+	return fields
+}
@@ -0,0 +1,230 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Parents of a name node in which the name refers to a class
+var classReferenceParents = map[string]bool{
+	"named_type":                        true,
+	"optional_type":                     true,
+	"union_type":                        true,
+	"intersection_type":                 true,
+	"type_list":                         true,
+	"object_creation_expression":        true,
+	"class_constant_access_expression":  true,
+	"scoped_call_expression":            true,
+	"scoped_property_access_expression": true,
+	"attribute":                         true,
+	"base_clause":                       true,
+	"class_interface_clause":            true,
+	"use_declaration":                   true,
+	"binary_expression":                 true,
+}
+
+var reservedClassNames = map[string]bool{
+	"self": true, "static": true, "parent": true,
+}
+
+// Quick fixes that import the class under the cursor when its short name does
+// not resolve in the current file
+func (a *phpAnalyzer) importClassCodeActions(params *protocol.CodeActionParams) []CodeAction {
+	a.mu.RLock()
+	doc := a.doc
+	autoload := a.autoload
+	a.mu.RUnlock()
+
+	if doc == nil || len(autoload.Classes) == 0 {
+		return nil
+	}
+
+	node, content, index, ok := doc.GetNodeAt(params.Range.Start)
+	if !ok || node.Type() != "name" {
+		return nil
+	}
+	parent := node.Parent()
+	if parent.IsNull() || !classReferenceParents[parent.Type()] {
+		return nil
+	}
+	if parent.Type() == "binary_expression" && !isInstanceofOperand(parent, node, content) {
+		return nil
+	}
+
+	short := strings.TrimSpace(node.Content(content))
+	if short == "" || reservedClassNames[strings.ToLower(short)] {
+		return nil
+	}
+	if _, ok := index.Uses[strings.ToLower(short)]; ok {
+		return nil
+	}
+	for _, class := range index.Classes {
+		if class.Name == short {
+			return nil
+		}
+	}
+
+	namespace := phpNamespaceAt(node, content)
+	candidates := autoload.Classes.Lookup(short)
+	for _, fqcn := range candidates {
+		if namespace != "" && fqcn == namespace+"\\"+short {
+			// Resolves through the current namespace
+			return nil
+		}
+	}
+
+	var actions []CodeAction
+	for _, fqcn := range candidates {
+		if namespace == "" && !strings.Contains(fqcn, "\\") {
+			continue
+		}
+		actions = append(actions, newCodeAction(fmt.Sprintf("Add use %s", fqcn), protocol.CodeActionKindQuickFix, func() *protocol.WorkspaceEdit {
+			return &protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+					params.TextDocument.URI: {useStatementEdit(node, content, fqcn)},
+				},
+			}
+		}))
+	}
+	return actions
+}
+
+func isInstanceofOperand(expr, node sitter.Node, content []byte) bool {
+	op := expr.ChildByFieldName("operator")
+	if op.IsNull() || strings.TrimSpace(op.Content(content)) != "instanceof" {
+		return false
+	}
+	right := expr.ChildByFieldName("right")
+	return !right.IsNull() && right.Equal(node)
+}
+
+// Returns the namespace that applies at the given node, for both the statement
+// and the braced form of namespace declarations
+func phpNamespaceAt(node sitter.Node, content []byte) string {
+	root := node
+	for cur := node; !cur.IsNull(); cur = cur.Parent() {
+		if cur.Type() == "namespace_definition" {
+			return namespaceName(cur, content)
+		}
+		root = cur
+	}
+
+	namespace := ""
+	for i := uint32(0); i < root.NamedChildCount(); i++ {
+		child := root.NamedChild(i)
+		if child.StartByte() >= node.StartByte() {
+			break
+		}
+		if child.Type() == "namespace_definition" {
+			namespace = namespaceName(child, content)
+		}
+	}
+	return namespace
+}
+
+func namespaceName(ns sitter.Node, content []byte) string {
+	nameNode := ns.ChildByFieldName("name")
+	if nameNode.IsNull() {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(nameNode.Content(content)), "\\")
+}
+
+// Builds the edit that inserts a use statement, keeping existing use statements
+// sorted alphabetically
+func useStatementEdit(node sitter.Node, content []byte, fqcn string) protocol.TextEdit {
+	// The statements in scope are the children of the braced namespace body, or
+	// of the program itself
+	scope := sitter.Node{}
+	root := node
+	for cur := node; !cur.IsNull(); cur = cur.Parent() {
+		if cur.Type() == "namespace_definition" {
+			if body := cur.ChildByFieldName("body"); !body.IsNull() {
+				scope = body
+				break
+			}
+		}
+		root = cur
+	}
+	if scope.IsNull() {
+		scope = root
+	}
+
+	statement := fmt.Sprintf("use %s;\n", fqcn)
+	insertAt := func(line uint, text string) protocol.TextEdit {
+		pos := protocol.Position{Line: uint32(line)}
+		return protocol.TextEdit{Range: protocol.Range{Start: pos, End: pos}, NewText: text}
+	}
+
+	var lastUse, anchor sitter.Node
+	for i := uint32(0); i < scope.NamedChildCount(); i++ {
+		child := scope.NamedChild(i)
+		switch child.Type() {
+		case "namespace_use_declaration":
+			if !child.ChildByFieldName("type").IsNull() {
+				// use function / use const
+				continue
+			}
+			// Grouped imports keep their place but aren't sorted against
+			if name, ok := importedClassName(child, content); ok && strings.ToLower(name) > strings.ToLower(fqcn) {
+				return insertAt(child.StartPoint().Row, statement)
+			}
+			lastUse = child
+		case "php_tag", "declare_statement", "namespace_definition":
+			if lastUse.IsNull() {
+				anchor = child
+			}
+		}
+		if child.StartByte() >= node.StartByte() {
+			break
+		}
+	}
+
+	if !lastUse.IsNull() {
+		return insertAt(lastUse.EndPoint().Row+1, statement)
+	}
+	if !anchor.IsNull() {
+		return insertAt(anchor.EndPoint().Row+1, "\n"+statement)
+	}
+	if scope.Type() == "compound_statement" {
+		return insertAt(scope.StartPoint().Row+1, statement+"\n")
+	}
+	return insertAt(0, statement)
+}
+
+// Returns the class imported by a use declaration, without its alias. Grouped
+// imports and `use function` / `use const` have none.
+func importedClassName(decl sitter.Node, content []byte) (string, bool) {
+	if !decl.ChildByFieldName("type").IsNull() {
+		return "", false
+	}
+
+	var clause sitter.Node
+	for i := uint32(0); i < decl.NamedChildCount(); i++ {
+		switch child := decl.NamedChild(i); child.Type() {
+		case "namespace_use_group":
+			return "", false
+		case "namespace_use_clause":
+			if clause.IsNull() {
+				clause = child
+			}
+		}
+	}
+	if clause.IsNull() {
+		return "", false
+	}
+
+	for i := uint32(0); i < clause.NamedChildCount(); i++ {
+		if clause.FieldNameForNamedChild(i) == "alias" {
+			continue
+		}
+		switch child := clause.NamedChild(i); child.Type() {
+		case "qualified_name", "name":
+			return strings.TrimLeft(strings.TrimSpace(child.Content(content)), "\\"), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,152 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	php "github.com/shinyvision/vimfony/internal/php"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	parameterBagInterfaceFQN = "Symfony\\Component\\DependencyInjection\\ParameterBag\\ParameterBagInterface"
+	containerBagInterfaceFQN = "Symfony\\Component\\DependencyInjection\\ParameterBag\\ContainerBagInterface"
+)
+
+// Completes parameter names in $this->getParameter('...'),
+// $container->getParameter('...') and $parameterBag->get('...')
+func (a *phpAnalyzer) getParameterCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	str, ok := a.parameterContextAt(pos)
+	if !ok {
+		return nil
+	}
+	return a.parameterCompletionItems(a.stringPrefix(str, pos))
+}
+
+func (a *phpAnalyzer) parameterCompletionItems(prefix string) []protocol.CompletionItem {
+	return parameterCompletionItems(a.container, prefix)
+}
+
+// Completes the parameters of the compiled container, leaving out the
+// private ones and the env() defaults
+func parameterCompletionItems(container *config.ContainerConfig, prefix string) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindConstant
+	items := []protocol.CompletionItem{}
+	for name, value := range container.Parameters {
+		if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "env(") || !strings.Contains(name, prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: name, Kind: &kind}
+		if value != "" {
+			detail := container.Parameters.Resolve(value)
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+func (a *phpAnalyzer) parameterContextAt(pos protocol.Position) (sitter.Node, bool) {
+	if a.doc == nil {
+		return sitter.Node{}, false
+	}
+
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return sitter.Node{}, false
+	}
+
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok || argumentIndex(arg) != 0 || !isMemberCall(callNode) {
+		return sitter.Node{}, false
+	}
+
+	nameNode := callNode.ChildByFieldName("name")
+	if nameNode.IsNull() {
+		return sitter.Node{}, false
+	}
+	objectNode := callNode.ChildByFieldName("object")
+
+	switch strings.TrimSpace(nameNode.Content(content)) {
+	case "getParameter":
+		if isThisVariable(objectNode, content) {
+			target := strings.ToLower(normalizeFQN(abstractControllerFQN))
+			return str, classExtendsAbstractControllerIndex(index, callNode, target)
+		}
+		return str, a.isContainerExpression(objectNode, callNode, content, index)
+	case "get":
+		return str, a.isParameterBagExpression(objectNode, callNode, content, index)
+	}
+	return sitter.Node{}, false
+}
+
+func (a *phpAnalyzer) isParameterBagExpression(object, callNode sitter.Node, content []byte, index php.IndexedTree) bool {
+	if object.IsNull() {
+		return false
+	}
+
+	switch object.Type() {
+	case "variable_name":
+		varName := php.VariableNameFromNode(object, content)
+		funcName := a.enclosingFunctionName(callNode)
+		return varName != "" && funcName != "" &&
+			variableHasTypeIndex(index, funcName, varName, int(callNode.StartPoint().Row)+1, canonicalParameterBagType)
+	case "member_access_expression", "nullsafe_member_access_expression":
+		propertyName := thisPropertyNameFromMemberAccessContent(content, object)
+		return propertyName != "" && propertyHasTypeIndex(index, propertyName, canonicalParameterBagType)
+	}
+
+	return false
+}
+
+func canonicalParameterBagType(name string) (string, bool) {
+	normalized := normalizeFQN(name)
+	if normalized == "" {
+		return "", false
+	}
+
+	for _, target := range []string{parameterBagInterfaceFQN, containerBagInterfaceFQN} {
+		if strings.EqualFold(normalized, target) || strings.EqualFold(shortName(normalized), shortName(target)) {
+			return target, true
+		}
+	}
+
+	return "", false
+}
+
+// Completes env var names in '%env(...)%' strings and env('...') calls, as
+// used in PHP config files
+func (a *phpAnalyzer) envPlaceholderCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	if a.doc == nil {
+		return nil
+	}
+
+	node, content, _, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return nil
+	}
+
+	inString := false
+	for cur := node; !cur.IsNull() && !inString; cur = cur.Parent() {
+		switch cur.Type() {
+		case "string", "encapsed_string":
+			inString = true
+		}
+	}
+	if !inString {
+		return nil
+	}
+
+	point, ok := lspPosToPoint(pos, content)
+	if !ok {
+		return nil
+	}
+	prefix, ok := envVarPrefix(string(linePrefixAtPoint(content, point)))
+	if !ok {
+		return nil
+	}
+	return envVarCompletionItems(a.container, prefix)
+}
@@ -604,7 +604,7 @@ func (a *phpAnalyzer) findPropertyInHierarchy(classFQN, propertyName string, sto
 	}
 
 	var propLine int
-	var propCol int
+	var propRange protocol.Range
 	var extends []string
 	target := "$" + propertyName
 
@@ -617,6 +617,7 @@ func (a *phpAnalyzer) findPropertyInHierarchy(classFQN, propertyName string, sto
 			break
 		}
 		if propLine > 0 {
+			propCol := 0
 			lines := strings.SplitN(string(content), "\n", propLine+1)
 			if propLine <= len(lines) {
 				col := strings.Index(lines[propLine-1], target)
@@ -624,16 +625,17 @@ func (a *phpAnalyzer) findPropertyInHierarchy(classFQN, propertyName string, sto
 					propCol = col
 				}
 			}
+			propRange = protocol.Range{
+				Start: utils.Position(content, propLine-1, propCol),
+				End:   utils.Position(content, propLine-1, propCol+len(target)),
+			}
 		}
 	})
 
 	if propLine > 0 {
 		return protocol.Location{
-			URI: protocol.DocumentUri(utils.PathToURI(path)),
-			Range: protocol.Range{
-				Start: protocol.Position{Line: uint32(propLine - 1), Character: uint32(propCol)},
-				End:   protocol.Position{Line: uint32(propLine - 1), Character: uint32(propCol + len(target))},
-			},
+			URI:   protocol.DocumentUri(utils.PathToURI(path)),
+			Range: propRange,
 		}, true
 	}
 
@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -29,7 +30,7 @@ func prepareQueryBuilderTest(t *testing.T, uri, fileContent string) *phpAnalyzer
 		},
 	}
 
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetAutoloadMap(&autoload)
@@ -76,7 +77,7 @@ func TestQueryBuilderAliasResolutionWithRepositoryAndCreateQueryBuilder(t *testi
 	target := "$qb->where('u."
 	pos := positionAfter(t, content, target, len(target))
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -99,7 +100,7 @@ func TestQueryBuilderAliasResolutionWithJoin(t *testing.T) {
 	target := "$qb->andWhere('a."
 	pos := positionAfter(t, content, target, len(target))
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -125,7 +126,7 @@ func TestQueryBuilderJoinOnCollectionAssociation(t *testing.T) {
 	target := "$qb->andWhere('addr."
 	pos := positionAfter(t, content, target, len(target))
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items, "should resolve Collection-typed association via doctrine targetEntity")
 
@@ -149,7 +150,7 @@ func TestQueryBuilderJoinOnChannelAssociation(t *testing.T) {
 	target := "$qb->andWhere('c."
 	pos := positionAfter(t, content, target, len(target))
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items, "should resolve ManyToMany Channel association across attribute→XML inheritance")
 
@@ -177,7 +178,7 @@ func TestQueryBuilderAliasResolutionWithFromClass(t *testing.T) {
 	target := "$qb->andWhere('aa."
 	pos := positionAfter(t, content, target, len(target)) // using exact target end
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -199,7 +200,7 @@ func TestQueryBuilderAliasResolutionWithGetRepositoryChain(t *testing.T) {
 	target := "->andWhere('o."
 	pos := positionAfter(t, content, target, len(target)) // using exact target end
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -222,7 +223,7 @@ func TestQueryBuilderChainedJoinCompletion(t *testing.T) {
 	target := "->andWhere('c."
 	pos := positionAfter(t, content, target, len(target))
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items, "should resolve join alias in chained method calls")
 
@@ -250,7 +251,7 @@ func TestQueryBuilderPropertyDocumentation(t *testing.T) {
 	target := "->andWhere('o."
 	pos := positionAfter(t, content, target, len(target))
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -317,7 +318,7 @@ class SomeService
 
 	pos := positionAfter(t, []byte(inlineContent), "'cur.", 5)
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items, "should resolve nested join where intermediate association is inherited from XML-mapped parent")
 
@@ -348,7 +349,7 @@ class UserRepository
 
 	pos := positionAfter(t, []byte(inlineContent), "'u.", 3)
 
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -376,7 +377,7 @@ func TestQueryBuilderDefinitionOnOwnField(t *testing.T) {
 	// Cursor on "id" in "$qb->where('u.id = 1')"
 	pos := positionAfter(t, content, "u.id", 3)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -398,7 +399,7 @@ func TestQueryBuilderDefinitionOnJoinedField(t *testing.T) {
 	// Cursor on "street" in "$qb->andWhere('a.street = :street')"
 	pos := positionAfter(t, content, "a.street =", 4)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -431,7 +432,7 @@ class UserRepository
 
 	pos := positionAfter(t, []byte(inlineContent), "c.code", 4)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs, "should resolve inherited field from XML-mapped AbstractChannel")
 
@@ -456,7 +457,7 @@ class UserRepository
 
 	pos := positionAfter(t, []byte(inlineContent), "u.nonexistent", 5)
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.Empty(t, locs, "should return nothing for unmapped field")
 }
@@ -0,0 +1,351 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	sensioRouteFQN        = "Sensio\\Bundle\\FrameworkExtraBundle\\Configuration\\Route"
+	routingAnnotationFQN  = "Symfony\\Component\\Routing\\Annotation\\Route"
+	routeAnnotationMarker = "@Route("
+)
+
+// A refactoring that turns the @Route docblock annotations of the class or
+// method under the cursor into #[Route] attributes
+func (a *phpAnalyzer) routeAnnotationCodeActions(params *protocol.CodeActionParams) []CodeAction {
+	a.mu.RLock()
+	doc := a.doc
+	a.mu.RUnlock()
+
+	if doc == nil {
+		return nil
+	}
+
+	node, content, index, ok := doc.GetNodeAt(params.Range.Start)
+	if !ok {
+		return nil
+	}
+	decl, comment := routeAnnotationTarget(node)
+	if decl.IsNull() || comment.IsNull() {
+		return nil
+	}
+
+	text := comment.Content(content)
+	if !strings.HasPrefix(text, "/**") || !strings.Contains(text, routeAnnotationMarker) {
+		return nil
+	}
+
+	return []CodeAction{newCodeAction("Convert @Route annotations to attributes", protocol.CodeActionKindRefactorRewrite, func() *protocol.WorkspaceEdit {
+		edits := routeAnnotationEdits(decl, comment, content)
+		if len(edits) == 0 {
+			return nil
+		}
+		if index.Uses["route"] == sensioRouteFQN {
+			// The Sensio annotation class cannot be used as an attribute
+			if edit, ok := replaceUseEdit(decl, content, sensioRouteFQN, routingAnnotationFQN); ok {
+				edits = append(edits, edit)
+			}
+		}
+		return &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				params.TextDocument.URI: edits,
+			},
+		}
+	})}
+}
+
+// Finds the class or method declaration at the node together with the docblock
+// that precedes it
+func routeAnnotationTarget(node sitter.Node) (sitter.Node, sitter.Node) {
+	for cur := node; !cur.IsNull(); cur = cur.Parent() {
+		switch cur.Type() {
+		case "comment":
+			next := namedSiblingAt(cur, 1)
+			if !next.IsNull() && (next.Type() == "method_declaration" || next.Type() == "class_declaration") {
+				return next, cur
+			}
+			return sitter.Node{}, sitter.Node{}
+		case "method_declaration", "class_declaration":
+			prev := namedSiblingAt(cur, -1)
+			if !prev.IsNull() && prev.Type() == "comment" {
+				return cur, prev
+			}
+			if cur.Type() == "method_declaration" {
+				// Without a docblock on the method, try the class
+				continue
+			}
+			return sitter.Node{}, sitter.Node{}
+		}
+	}
+	return sitter.Node{}, sitter.Node{}
+}
+
+func namedSiblingAt(node sitter.Node, offset int) sitter.Node {
+	parent := node.Parent()
+	if parent.IsNull() {
+		return sitter.Node{}
+	}
+	for i := uint32(0); i < parent.NamedChildCount(); i++ {
+		if !parent.NamedChild(i).Equal(node) {
+			continue
+		}
+		target := int(i) + offset
+		if target < 0 || target >= int(parent.NamedChildCount()) {
+			return sitter.Node{}
+		}
+		return parent.NamedChild(uint32(target))
+	}
+	return sitter.Node{}
+}
+
+func routeAnnotationEdits(decl, comment sitter.Node, content []byte) []protocol.TextEdit {
+	text := comment.Content(content)
+	if !strings.HasPrefix(text, "/**") {
+		return nil
+	}
+
+	type annotation struct {
+		startLine, endLine int
+		args               string
+	}
+	var annotations []annotation
+	for offset := 0; ; {
+		idx := strings.Index(text[offset:], routeAnnotationMarker)
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+		open := start + len(routeAnnotationMarker) - 1
+		end, ok := matchingParen(text, open)
+		if !ok {
+			return nil
+		}
+		annotations = append(annotations, annotation{
+			startLine: strings.Count(text[:start], "\n"),
+			endLine:   strings.Count(text[:end], "\n"),
+			args:      stripDocblockPrefixes(text[open+1 : end]),
+		})
+		offset = end + 1
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(text, "\n")
+	removed := make(map[int]bool)
+	for _, ann := range annotations {
+		for l := ann.startLine; l <= ann.endLine; l++ {
+			removed[l] = true
+		}
+	}
+
+	commentStart := int(comment.StartPoint().Row)
+	indent := lineIndent(content, int(decl.StartPoint().Row))
+	var attributes strings.Builder
+	for _, ann := range annotations {
+		attributes.WriteString(indent)
+		attributes.WriteString("#[Route(")
+		attributes.WriteString(convertAnnotationArgs(ann.args))
+		attributes.WriteString(")]\n")
+	}
+
+	var edits []protocol.TextEdit
+	if docblockEmptyWithout(lines, removed) {
+		// Drop the whole docblock
+		edits = append(edits, lineRangeEdit(commentStart, commentStart+len(lines)-1, ""))
+	} else {
+		for l := 0; l < len(lines); l++ {
+			if !removed[l] {
+				continue
+			}
+			end := l
+			for end+1 < len(lines) && removed[end+1] {
+				end++
+			}
+			edits = append(edits, lineRangeEdit(commentStart+l, commentStart+end, ""))
+			l = end
+		}
+	}
+
+	declLine := uint32(decl.StartPoint().Row)
+	pos := protocol.Position{Line: declLine}
+	edits = append(edits, protocol.TextEdit{
+		Range:   protocol.Range{Start: pos, End: pos},
+		NewText: attributes.String(),
+	})
+	return edits
+}
+
+// Replaces whole lines, end of line included
+func lineRangeEdit(startLine, endLine int, text string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(startLine)},
+			End:   protocol.Position{Line: uint32(endLine + 1)},
+		},
+		NewText: text,
+	}
+}
+
+func lineIndent(content []byte, line int) string {
+	start := 0
+	for i := 0; i < line; i++ {
+		next := bytes.IndexByte(content[start:], '\n')
+		if next < 0 {
+			return ""
+		}
+		start += next + 1
+	}
+	end := start
+	for end < len(content) && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return string(content[start:end])
+}
+
+// Reports whether the docblock would be left without any text
+func docblockEmptyWithout(lines []string, removed map[int]bool) bool {
+	for i, line := range lines {
+		if removed[i] {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		trimmed = strings.TrimPrefix(trimmed, "/**")
+		trimmed = strings.TrimSuffix(trimmed, "*/")
+		trimmed = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(trimmed), "*"))
+		if trimmed != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func stripDocblockPrefixes(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(strings.Join(lines, " "))
+}
+
+// Returns the index of the parenthesis that closes the one at open, skipping
+// quoted strings
+func matchingParen(s string, open int) (int, bool) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			for i++; i < len(s); i++ {
+				if s[i] == '"' {
+					if i+1 < len(s) && s[i+1] == '"' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Rewrites Doctrine annotation arguments into PHP attribute arguments:
+// name="x" becomes name: 'x', {"a", "b"} becomes ['a', 'b'] and
+// {"key"="value"} becomes ['key' => 'value'].
+func convertAnnotationArgs(args string) string {
+	var out strings.Builder
+	// Named arguments only exist outside of braces
+	depth := 0
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		switch {
+		case c == '"':
+			var value strings.Builder
+			for i++; i < len(args); i++ {
+				if args[i] == '"' {
+					if i+1 < len(args) && args[i+1] == '"' {
+						value.WriteByte('"')
+						i++
+						continue
+					}
+					break
+				}
+				value.WriteByte(args[i])
+			}
+			out.WriteString(phpSingleQuoted(value.String()))
+		case c == ':' && i+1 < len(args) && args[i+1] == ':':
+			out.WriteString("::")
+			i++
+		case c == '=' || c == ':':
+			if depth == 0 {
+				out.WriteString(": ")
+			} else {
+				out.WriteString(" => ")
+			}
+		case c == ',':
+			out.WriteString(", ")
+		case c == '{':
+			depth++
+			out.WriteByte('[')
+		case c == '}':
+			depth--
+			trimmed := strings.TrimSuffix(out.String(), ", ")
+			out.Reset()
+			out.WriteString(trimmed)
+			out.WriteByte(']')
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			// Whitespace is normalized around separators
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return strings.TrimSuffix(out.String(), ", ")
+}
+
+func phpSingleQuoted(s string) string {
+	s = strings.ReplaceAll(s, "'", "\\'")
+	return "'" + s + "'"
+}
+
+// Replaces the class of a use statement in the file of the node
+func replaceUseEdit(node sitter.Node, content []byte, from, to string) (protocol.TextEdit, bool) {
+	root := node
+	for cur := node; !cur.IsNull(); cur = cur.Parent() {
+		root = cur
+	}
+
+	var found protocol.TextEdit
+	ok := false
+	walkNodes(root, func(n sitter.Node) {
+		if ok || n.Type() != "namespace_use_clause" {
+			return
+		}
+		for i := uint32(0); i < n.NamedChildCount(); i++ {
+			child := n.NamedChild(i)
+			if child.Type() != "qualified_name" && child.Type() != "name" {
+				continue
+			}
+			if strings.TrimLeft(strings.TrimSpace(child.Content(content)), "\\") == from {
+				found = protocol.TextEdit{Range: nodeRange(child, content), NewText: to}
+				ok = true
+			}
+			return
+		}
+	})
+	return found, ok
+}
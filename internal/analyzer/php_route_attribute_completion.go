@@ -0,0 +1,189 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var routeHTTPMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// Completes path placeholders, the route name and methods inside #[Route(...)]
+func (a *phpAnalyzer) routeAttributeCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	if a.doc == nil {
+		return nil
+	}
+
+	node, content, _, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return nil
+	}
+
+	var str, arg, attr sitter.Node
+	for cur := node; !cur.IsNull() && attr.IsNull(); cur = cur.Parent() {
+		switch cur.Type() {
+		case "string", "encapsed_string":
+			if str.IsNull() {
+				str = cur
+			}
+		case "argument":
+			if arg.IsNull() {
+				arg = cur
+			}
+		case "attribute":
+			attr = cur
+		case "method_declaration", "class_declaration":
+			return nil
+		}
+	}
+	if str.IsNull() || arg.IsNull() || attr.IsNull() || attributeShortName(attr, content) != "Route" {
+		return nil
+	}
+
+	caret := lspPosToByteOffset(content, pos)
+	if caret <= int(str.StartByte()) || caret >= int(str.EndByte()) {
+		return nil
+	}
+	typed := string(content[str.StartByte()+1 : caret])
+
+	owner := attributeOwner(attr)
+	switch {
+	case arg.Equal(attributeArgument(attr, content, "path", 0)):
+		return routePlaceholderItems(owner, content, typed, phpStringLiteral(str, content))
+	case arg.Equal(attributeArgument(attr, content, "name", 1)):
+		return routeNameItems(owner, content)
+	case isNamedArgument(arg, content, "methods"):
+		kind := protocol.CompletionItemKindEnumMember
+		items := make([]protocol.CompletionItem, 0, len(routeHTTPMethods))
+		for _, method := range routeHTTPMethods {
+			items = append(items, protocol.CompletionItem{Label: method, Kind: &kind})
+		}
+		return items
+	}
+	return nil
+}
+
+func isNamedArgument(arg sitter.Node, content []byte, name string) bool {
+	nameNode := arg.ChildByFieldName("name")
+	return !nameNode.IsNull() && strings.TrimSpace(nameNode.Content(content)) == name
+}
+
+// Suggests the action's parameters as placeholders right after an opening brace
+func routePlaceholderItems(owner sitter.Node, content []byte, typed, path string) []protocol.CompletionItem {
+	open := strings.LastIndexByte(typed, '{')
+	if open < 0 || strings.Contains(typed[open:], "}") || owner.Type() != "method_declaration" {
+		return nil
+	}
+
+	params := owner.ChildByFieldName("parameters")
+	if params.IsNull() {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindVariable
+	var items []protocol.CompletionItem
+	for i := uint32(0); i < params.NamedChildCount(); i++ {
+		param := params.NamedChild(i)
+		nameNode := param.ChildByFieldName("name")
+		if nameNode.IsNull() {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(nameNode.Content(content)), "$")
+		if name == "" || strings.Contains(path, "{"+name+"}") || strings.Contains(path, "{"+name+"<") {
+			continue
+		}
+
+		detail := ""
+		if typeNode := param.ChildByFieldName("type"); !typeNode.IsNull() {
+			detail = strings.TrimSpace(typeNode.Content(content))
+			// Services and the request are not route parameters
+			base := shortName(strings.TrimPrefix(detail, "?"))
+			if base == "Request" || strings.HasSuffix(base, "Interface") {
+				continue
+			}
+		}
+
+		item := protocol.CompletionItem{Label: name, Kind: &kind}
+		if detail != "" {
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Suggests a route name following the app_<controller>_<action> convention, or
+// only the action part when the class already declares a name prefix
+func routeNameItems(owner sitter.Node, content []byte) []protocol.CompletionItem {
+	classNode := owner
+	for !classNode.IsNull() && classNode.Type() != "class_declaration" {
+		classNode = classNode.Parent()
+	}
+	if classNode.IsNull() {
+		return nil
+	}
+
+	className := ""
+	if nameNode := classNode.ChildByFieldName("name"); !nameNode.IsNull() {
+		className = strings.TrimSpace(nameNode.Content(content))
+	}
+	controller := toSnakeCase(strings.TrimSuffix(className, "Controller"))
+	if controller == "" {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindValue
+	if owner.Type() == "class_declaration" {
+		label := fmt.Sprintf("app_%s_", controller)
+		return []protocol.CompletionItem{{Label: label, Kind: &kind}}
+	}
+
+	method := ""
+	if nameNode := owner.ChildByFieldName("name"); !nameNode.IsNull() {
+		method = toSnakeCase(strings.TrimSpace(nameNode.Content(content)))
+	}
+	if method == "" {
+		return nil
+	}
+
+	if prefix := classRouteNamePrefix(classNode, content); prefix != "" {
+		detail := prefix + method
+		return []protocol.CompletionItem{{Label: method, Kind: &kind, Detail: &detail}}
+	}
+	label := fmt.Sprintf("app_%s_%s", controller, method)
+	return []protocol.CompletionItem{{Label: label, Kind: &kind}}
+}
+
+func classRouteNamePrefix(classNode sitter.Node, content []byte) string {
+	prefix := ""
+	walkNodes(classNode, func(n sitter.Node) {
+		if prefix != "" || n.Type() != "attribute" || attributeShortName(n, content) != "Route" {
+			return
+		}
+		if !attributeOwner(n).Equal(classNode) {
+			return
+		}
+		prefix = phpStringLiteral(argumentStringNode(attributeArgument(n, content, "name", 1)), content)
+	})
+	return prefix
+}
+
+// Converts BlogPost or blogPost to blog_post
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
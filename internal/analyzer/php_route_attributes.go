@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	php "github.com/shinyvision/vimfony/internal/php"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// A #[Route] attribute found on a controller class or action
+type routeAttribute struct {
+	name      string
+	nameRange protocol.Range
+	class     string
+	method    string
+}
+
+func attributeShortName(attr sitter.Node, content []byte) string {
+	for i := uint32(0); i < attr.NamedChildCount(); i++ {
+		child := attr.NamedChild(i)
+		switch child.Type() {
+		case "name", "qualified_name":
+			return shortName(strings.TrimSpace(child.Content(content)))
+		}
+	}
+	return ""
+}
+
+// Returns the method_declaration or class_declaration an attribute is attached to
+func attributeOwner(attr sitter.Node) sitter.Node {
+	for cur := attr.Parent(); !cur.IsNull(); cur = cur.Parent() {
+		switch cur.Type() {
+		case "method_declaration", "class_declaration":
+			return cur
+		case "attribute_group", "attribute_list":
+			continue
+		default:
+			return sitter.Node{}
+		}
+	}
+	return sitter.Node{}
+}
+
+// Finds the argument node for a parameter that can be passed either by name or
+// at a fixed position
+func attributeArgument(attr sitter.Node, content []byte, param string, position int) sitter.Node {
+	args := attr.ChildByFieldName("parameters")
+	if args.IsNull() {
+		for i := uint32(0); i < attr.NamedChildCount(); i++ {
+			if attr.NamedChild(i).Type() == "arguments" {
+				args = attr.NamedChild(i)
+				break
+			}
+		}
+	}
+	if args.IsNull() {
+		return sitter.Node{}
+	}
+
+	positional := 0
+	for i := uint32(0); i < args.NamedChildCount(); i++ {
+		arg := args.NamedChild(i)
+		if arg.Type() != "argument" {
+			continue
+		}
+		if nameNode := arg.ChildByFieldName("name"); !nameNode.IsNull() {
+			if strings.TrimSpace(nameNode.Content(content)) == param {
+				return arg
+			}
+			continue
+		}
+		if positional == position {
+			return arg
+		}
+		positional++
+	}
+	return sitter.Node{}
+}
+
+// Returns the string node holding an argument value, if the value is a literal string
+func argumentStringNode(arg sitter.Node) sitter.Node {
+	if arg.IsNull() || arg.NamedChildCount() == 0 {
+		return sitter.Node{}
+	}
+	value := arg.NamedChild(arg.NamedChildCount() - 1)
+	switch value.Type() {
+	case "string", "encapsed_string":
+		return value
+	}
+	return sitter.Node{}
+}
+
+func phpStringLiteral(str sitter.Node, content []byte) string {
+	if str.IsNull() {
+		return ""
+	}
+	raw := str.Content(content)
+	if len(raw) < 2 {
+		return ""
+	}
+	return raw[1 : len(raw)-1]
+}
+
+// Collects every named #[Route] attribute declared on controller actions, with
+// the class-level name prefix applied the way Symfony does.
+func collectRouteAttributes(tree *sitter.Tree, content []byte, index php.IndexedTree) []routeAttribute {
+	if tree == nil {
+		return nil
+	}
+
+	prefixes := make(map[uint32]string)
+	var methodAttrs []routeAttribute
+	var methodClassStarts []uint32
+
+	walkNodes(tree.RootNode(), func(n sitter.Node) {
+		if n.Type() != "attribute" || attributeShortName(n, content) != "Route" {
+			return
+		}
+		owner := attributeOwner(n)
+		if owner.IsNull() {
+			return
+		}
+		str := argumentStringNode(attributeArgument(n, content, "name", 1))
+
+		if owner.Type() == "class_declaration" {
+			if !str.IsNull() {
+				prefixes[uint32(owner.StartByte())] = phpStringLiteral(str, content)
+			}
+			return
+		}
+		if str.IsNull() {
+			return
+		}
+
+		classNode := owner.Parent()
+		for !classNode.IsNull() && classNode.Type() != "class_declaration" {
+			classNode = classNode.Parent()
+		}
+		if classNode.IsNull() {
+			return
+		}
+		classStart := uint32(classNode.StartByte())
+		method := ""
+		if nameNode := owner.ChildByFieldName("name"); !nameNode.IsNull() {
+			method = strings.TrimSpace(nameNode.Content(content))
+		}
+		methodAttrs = append(methodAttrs, routeAttribute{
+			name:      phpStringLiteral(str, content),
+			nameRange: nodeRange(str, content),
+			class:     index.Classes[classStart].FQN,
+			method:    method,
+		})
+		methodClassStarts = append(methodClassStarts, classStart)
+	})
+
+	for i := range methodAttrs {
+		methodAttrs[i].name = prefixes[methodClassStarts[i]] + methodAttrs[i].name
+	}
+	return methodAttrs
+}
@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	securityFQN                      = "Symfony\\Bundle\\SecurityBundle\\Security"
+	legacySecurityFQN                = "Symfony\\Component\\Security\\Core\\Security"
+	authorizationCheckerInterfaceFQN = "Symfony\\Component\\Security\\Core\\Authorization\\AuthorizationCheckerInterface"
+)
+
+// Completes roles and voter attributes in isGranted('...'),
+// denyAccessUnlessGranted('...') and #[IsGranted('...')]
+func (a *phpAnalyzer) securityAttributeCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	str, ok := a.securityAttributeContextAt(pos)
+	if !ok {
+		return nil
+	}
+
+	prefix := a.stringPrefix(str, pos)
+	kind := protocol.CompletionItemKindConstant
+	items := []protocol.CompletionItem{}
+	for name, loc := range a.container.SecurityAttributes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		detail := filepath.Base(utils.UriToPath(string(loc.URI)))
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: &detail})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+func (a *phpAnalyzer) securityAttributeContextAt(pos protocol.Position) (sitter.Node, bool) {
+	if a.doc == nil {
+		return sitter.Node{}, false
+	}
+
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return sitter.Node{}, false
+	}
+
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok {
+		return sitter.Node{}, false
+	}
+
+	if !callNode.IsNull() && callNode.Type() == "attribute" {
+		if attributeShortName(callNode, content) != "IsGranted" {
+			return sitter.Node{}, false
+		}
+		return str, arg.Equal(attributeArgument(callNode, content, "attribute", 0))
+	}
+
+	if argumentIndex(arg) != 0 || !isMemberCall(callNode) {
+		return sitter.Node{}, false
+	}
+
+	nameNode := callNode.ChildByFieldName("name")
+	if nameNode.IsNull() {
+		return sitter.Node{}, false
+	}
+	switch strings.TrimSpace(nameNode.Content(content)) {
+	case "isGranted", "denyAccessUnlessGranted":
+	default:
+		return sitter.Node{}, false
+	}
+
+	objectNode := callNode.ChildByFieldName("object")
+	if isThisVariable(objectNode, content) {
+		target := strings.ToLower(normalizeFQN(abstractControllerFQN))
+		return str, classExtendsAbstractControllerIndex(index, callNode, target)
+	}
+	return str, a.isAuthorizationCheckerExpression(objectNode, callNode, content, index)
+}
+
+func (a *phpAnalyzer) isAuthorizationCheckerExpression(object, callNode sitter.Node, content []byte, index php.IndexedTree) bool {
+	if object.IsNull() {
+		return false
+	}
+
+	switch object.Type() {
+	case "variable_name":
+		varName := php.VariableNameFromNode(object, content)
+		funcName := a.enclosingFunctionName(callNode)
+		return varName != "" && funcName != "" &&
+			variableHasTypeIndex(index, funcName, varName, int(callNode.StartPoint().Row)+1, canonicalAuthorizationCheckerType)
+	case "member_access_expression", "nullsafe_member_access_expression":
+		propertyName := thisPropertyNameFromMemberAccessContent(content, object)
+		return propertyName != "" && propertyHasTypeIndex(index, propertyName, canonicalAuthorizationCheckerType)
+	}
+
+	return false
+}
+
+func canonicalAuthorizationCheckerType(name string) (string, bool) {
+	normalized := normalizeFQN(name)
+	if normalized == "" {
+		return "", false
+	}
+
+	for _, target := range []string{securityFQN, legacySecurityFQN, authorizationCheckerInterfaceFQN} {
+		if strings.EqualFold(normalized, target) || strings.EqualFold(shortName(normalized), shortName(target)) {
+			return target, true
+		}
+	}
+
+	return "", false
+}
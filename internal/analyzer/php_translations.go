@@ -5,6 +5,7 @@ import (
 
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/translations"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
@@ -45,131 +46,157 @@ func (a *phpAnalyzer) isTypingTranslationKey(pos protocol.Position) (bool, strin
 }
 
 func (a *phpAnalyzer) translationContextAt(pos protocol.Position) (phpCallCtx, bool) {
-	if a.doc == nil {
+	ctx, ok := a.transCallContextAt(pos)
+	if !ok || ctx.argIndex != 0 { // Translation key is usually the first argument
 		return phpCallCtx{}, false
 	}
+	return ctx, true
+}
 
-	node, content, index, ok := a.doc.GetNodeAt(pos)
+// Returns the literal domain passed to the trans() call of ctx, by position or
+// as the domain: named argument
+func (a *phpAnalyzer) translationDomain(ctx phpCallCtx) string {
+	var content []byte
+	a.doc.Read(func(_ *sitter.Tree, data []byte, _ php.IndexedTree) {
+		content = data
+	})
+	position := 0
+	for i := uint32(0); i < ctx.argsNode.NamedChildCount(); i++ {
+		arg := ctx.argsNode.NamedChild(i)
+		if arg.Type() != "argument" || arg.NamedChildCount() == 0 {
+			continue
+		}
+		value := arg.NamedChild(arg.NamedChildCount() - 1)
+		if arg.ChildByFieldName("name").IsNull() {
+			if position == 2 {
+				return a.stringContent(value)
+			}
+			position++
+		} else if isNamedArgument(arg, content, "domain") {
+			return a.stringContent(value)
+		}
+	}
+	return ""
+}
+
+// Completes the domain argument of trans()
+func (a *phpAnalyzer) translationDomainCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	ctx, ok := a.transCallContextAt(pos)
 	if !ok {
-		return phpCallCtx{}, false
+		return nil
 	}
 
-	var str sitter.Node
-	for cur := node; !cur.IsNull(); cur = cur.Parent() {
-		if str.IsNull() {
-			switch cur.Type() {
-			case "string":
-				str = cur
-			case "string_content":
-				parent := cur.Parent()
-				if !parent.IsNull() && parent.Type() == "string" {
-					str = parent
-				}
-			}
+	var content []byte
+	a.doc.Read(func(_ *sitter.Tree, data []byte, _ php.IndexedTree) {
+		content = data
+	})
+	arg := ctx.argsNode.NamedChild(uint32(ctx.argIndex))
+	if arg.ChildByFieldName("name").IsNull() {
+		if ctx.argIndex != 2 {
+			return nil
 		}
+	} else if !isNamedArgument(arg, content, "domain") {
+		return nil
+	}
 
-		if cur.Type() != "argument" {
-			continue
+	prefix := a.stringPrefix(ctx.strNode, pos)
+	kind := protocol.CompletionItemKindModule
+	items := []protocol.CompletionItem{}
+	for _, domain := range a.container.TranslationDomains() {
+		if strings.HasPrefix(domain, prefix) {
+			items = append(items, protocol.CompletionItem{Label: domain, Kind: &kind})
 		}
+	}
+	return items
+}
 
-		argNode := cur
-		argsNode := argNode.Parent()
-		if argsNode.IsNull() || argsNode.Type() != "arguments" {
-			continue
-		}
+// Completes the placeholders of the translated message as keys of the
+// parameters array: trans('key', ['%name%' => ...])
+func (a *phpAnalyzer) translationPlaceholderCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	ctx, ok := a.transCallContextAt(pos)
+	if !ok || ctx.argIndex != 1 || !a.isPHPParamKeyContext(ctx.strNode) {
+		return nil
+	}
 
-		argIndex := -1
-		for i := uint32(0); i < argsNode.NamedChildCount(); i++ {
-			if argsNode.NamedChild(i).Equal(argNode) {
-				argIndex = int(i)
-				break
-			}
-		}
-		if argIndex != 0 { // Translation key is usually the first argument
-			return phpCallCtx{}, false
-		}
+	arg := ctx.argsNode.NamedChild(0)
+	if arg.IsNull() || arg.NamedChildCount() == 0 {
+		return nil
+	}
+	key := a.stringContent(arg.NamedChild(arg.NamedChildCount() - 1))
+	message, ok := a.container.TranslationKeys.Message(key, a.container.DefaultLocale)
+	if !ok {
+		return nil
+	}
 
-		callNode := argsNode.Parent()
-		for !callNode.IsNull() && callNode.Type() != "member_call_expression" {
-			callNode = callNode.Parent()
-		}
-		if callNode.IsNull() || callNode.Type() != "member_call_expression" {
-			return phpCallCtx{}, false
-		}
+	return translationPlaceholderItems(message, a.stringPrefix(ctx.strNode, pos))
+}
 
-		nameNode := callNode.ChildByFieldName("name")
-		if nameNode.IsNull() {
-			return phpCallCtx{}, false
+func translationPlaceholderItems(message, prefix string) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindVariable
+	items := []protocol.CompletionItem{}
+	for _, name := range translations.Placeholders(message) {
+		if !strings.HasPrefix(name, prefix) {
+			continue
 		}
+		detail := message
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: &detail})
+	}
+	return items
+}
 
-		objectNode := callNode.ChildByFieldName("object")
-		if objectNode.IsNull() {
-			return phpCallCtx{}, false
-		}
+// Finds the trans() call whose argument holds the string at pos
+func (a *phpAnalyzer) transCallContextAt(pos protocol.Position) (phpCallCtx, bool) {
+	if a.doc == nil {
+		return phpCallCtx{}, false
+	}
 
-		methodName := strings.TrimSpace(nameNode.Content(content))
-		if methodName != "trans" {
-			return phpCallCtx{}, false
-		}
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return phpCallCtx{}, false
+	}
 
-		// Check if object is a translator or $this in controller
-		callLine := int(callNode.StartPoint().Row) + 1
+	str, arg, callNode, ok := stringArgumentAt(node)
+	if !ok || !isMemberCall(callNode) || !a.isTransCall(callNode, content, index) {
+		return phpCallCtx{}, false
+	}
+	return phpCallCtx{
+		callNode: callNode,
+		argsNode: arg.Parent(),
+		argIndex: argumentIndex(arg),
+		strNode:  str,
+	}, true
+}
 
-		if isThisVariable(objectNode, content) {
-			// Check if class extends AbstractController
-			controllerTarget := strings.ToLower(normalizeFQN(abstractControllerFQN))
-			if controllerTarget != "" && classExtendsAbstractControllerIndex(index, callNode, controllerTarget) {
-				if str.IsNull() {
-					return phpCallCtx{}, false
-				}
-				return phpCallCtx{
-					callNode: callNode,
-					argsNode: argsNode,
-					argIndex: argIndex,
-					strNode:  str,
-				}, true
-			}
-		}
+// Reports whether the call is trans() of a translator, held by a variable or
+// property, or of an AbstractController
+func (a *phpAnalyzer) isTransCall(callNode sitter.Node, content []byte, index php.IndexedTree) bool {
+	nameNode := callNode.ChildByFieldName("name")
+	objectNode := callNode.ChildByFieldName("object")
+	if nameNode.IsNull() || objectNode.IsNull() || strings.TrimSpace(nameNode.Content(content)) != "trans" {
+		return false
+	}
 
-		// Check if variable is a translator
-		varName := php.VariableNameFromNode(objectNode, content)
-		if varName != "" {
-			funcName := a.enclosingFunctionName(callNode)
-			if funcName != "" {
-				if variableHasTranslatorTypeIndex(index, funcName, varName, callLine) {
-					if str.IsNull() {
-						return phpCallCtx{}, false
-					}
-					return phpCallCtx{
-						callNode: callNode,
-						argsNode: argsNode,
-						argIndex: argIndex,
-						strNode:  str,
-					}, true
-				}
-			}
+	if isThisVariable(objectNode, content) {
+		controllerTarget := strings.ToLower(normalizeFQN(abstractControllerFQN))
+		if controllerTarget != "" && classExtendsAbstractControllerIndex(index, callNode, controllerTarget) {
+			return true
 		}
+	}
 
-		// Also check property access $this->translator
-		propertyName := thisPropertyNameFromMemberAccessContent(content, objectNode)
-		if propertyName != "" {
-			if propertyHasTranslatorTypeIndex(index, propertyName) {
-				if str.IsNull() {
-					return phpCallCtx{}, false
-				}
-				return phpCallCtx{
-					callNode: callNode,
-					argsNode: argsNode,
-					argIndex: argIndex,
-					strNode:  str,
-				}, true
-			}
+	if varName := php.VariableNameFromNode(objectNode, content); varName != "" {
+		callLine := int(callNode.StartPoint().Row) + 1
+		funcName := a.enclosingFunctionName(callNode)
+		if funcName != "" && variableHasTranslatorTypeIndex(index, funcName, varName, callLine) {
+			return true
 		}
+	}
 
-		return phpCallCtx{}, false
+	if callHasTypeIndex(a.doc, objectNode, a.enclosingFunctionName(callNode), canonicalTranslatorType) {
+		return true
 	}
 
-	return phpCallCtx{}, false
+	propertyName := thisPropertyNameFromMemberAccessContent(content, objectNode)
+	return propertyName != "" && propertyHasTranslatorTypeIndex(index, propertyName)
 }
 
 func (a *phpAnalyzer) resolveTranslationDefinition(pos protocol.Position) ([]protocol.Location, bool) {
@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Command of the route lenses, run by the server with the route name and the
+// URI of the document as arguments. It returns the places that generate the
+// route.
+const CommandRouteUsages = "vimfony.routeUsages"
+
+// Puts a lens with the number of places generating the route above each
+// action that serves one: route app_foo_show · 12 references
+func (a *phpAnalyzer) routeCodeLenses() []protocol.CodeLens {
+	a.mu.RLock()
+	container := a.container
+	routes := a.routes
+	path := a.path
+	a.mu.RUnlock()
+
+	if a.doc == nil || len(routes) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(routes))
+	for name := range routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	uri := utils.PathToURI(path)
+	var lenses []protocol.CodeLens
+	a.doc.Read(func(tree *sitter.Tree, content []byte, index php.IndexedTree) {
+		if tree == nil {
+			return
+		}
+		walkNodes(tree.RootNode(), func(n sitter.Node) {
+			if n.Type() != "method_declaration" {
+				return
+			}
+			nameNode := n.ChildByFieldName("name")
+			classNode := n.Parent()
+			for !classNode.IsNull() && classNode.Type() != "class_declaration" {
+				classNode = classNode.Parent()
+			}
+			if nameNode.IsNull() || classNode.IsNull() {
+				return
+			}
+			class := index.Classes[uint32(classNode.StartByte())].FQN
+			method := strings.TrimSpace(nameNode.Content(content))
+			for _, name := range names {
+				if routeTargets(routes[name], class, method, container) {
+					lenses = append(lenses, routeUsagesCodeLens(name, uri, nodeRange(nameNode, content), container))
+				}
+			}
+		})
+	})
+	return lenses
+}
+
+func routeUsagesCodeLens(name, uri string, rng protocol.Range, container *config.ContainerConfig) protocol.CodeLens {
+	count := 0
+	if container != nil {
+		count = len(container.RouteUsagesOf(name))
+	}
+	noun := "references"
+	if count == 1 {
+		noun = "reference"
+	}
+	return protocol.CodeLens{
+		Range: rng,
+		Command: &protocol.Command{
+			Title:     fmt.Sprintf("route %s · %d %s", name, count, noun),
+			Command:   CommandRouteUsages,
+			Arguments: []any{name, uri},
+		},
+	}
+}
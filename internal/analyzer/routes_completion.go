@@ -4,11 +4,46 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/shinyvision/vimfony/internal/config"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+// routeNameCompletions are the route name completion items of a routes map,
+// built the first time a route name is completed, then only filtered by
+// prefix. A new one is made each time the routes are loaded again.
+type routeNameCompletions struct {
+	once   sync.Once
+	routes config.RoutesMap
+	items  []protocol.CompletionItem
+}
+
+func newRouteNameCompletions(routes config.RoutesMap) *routeNameCompletions {
+	return &routeNameCompletions{routes: routes}
+}
+
+// complete returns the items of the routes starting with prefix, shortest
+// first like makeRouteNameCompletionItems
+func (r *routeNameCompletions) complete(prefix string) []protocol.CompletionItem {
+	if r == nil {
+		return nil
+	}
+	r.once.Do(func() {
+		r.items = makeRouteNameCompletionItems(r.routes, "")
+	})
+	if r.items == nil {
+		return nil
+	}
+	items := make([]protocol.CompletionItem, 0, len(r.items))
+	for _, item := range r.items {
+		if strings.HasPrefix(item.Label, prefix) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func makeRouteNameCompletionItems(routes config.RoutesMap, prefix string) []protocol.CompletionItem {
 	if len(routes) == 0 {
 		return nil
@@ -0,0 +1,268 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Returns the diagnostic of a route whose controller class can't be found, or
+// that lacks the configured action.
+func routeControllerDiagnostic(name string, route config.Route, rng protocol.Range, container *config.ContainerConfig, autoload config.AutoloadMap, store *php.DocumentStore) (protocol.Diagnostic, bool) {
+	if route.Controller == "" {
+		return protocol.Diagnostic{}, false
+	}
+	doc, _, ok := routeDocument(route, container, autoload, store)
+	if !ok {
+		return newDiagnostic(
+			rng,
+			protocol.DiagnosticSeverityWarning,
+			fmt.Sprintf("Route '%s' points to missing controller class %s", name, route.Controller),
+		), true
+	}
+
+	method := route.Action
+	if method == "" {
+		method = "__invoke"
+	}
+
+	target := "::" + method
+	for _, fn := range doc.Index().PublicFunctions {
+		if strings.HasSuffix(fn.Name, target) {
+			return protocol.Diagnostic{}, false
+		}
+	}
+	return newDiagnostic(
+		rng,
+		protocol.DiagnosticSeverityWarning,
+		fmt.Sprintf("Route '%s' points to missing controller action %s::%s", name, route.Controller, method),
+	), true
+}
+
+func (a *twigAnalyzer) routeDiagnostics() []protocol.Diagnostic {
+	if a.tree == nil || a.container == nil || a.autoload.IsEmpty() || len(a.routes) == 0 || a.docStore == nil {
+		return nil
+	}
+
+	var diagnostics []protocol.Diagnostic
+	a.routeNameStrings(func(name string, str sitter.Node) {
+		route, ok := a.routes[name]
+		if !ok {
+			return
+		}
+		if d, ok := routeControllerDiagnostic(name, route, nodeRange(str, a.content), a.container, a.autoload, a.docStore); ok {
+			diagnostics = append(diagnostics, d)
+		}
+	})
+	return diagnostics
+}
+
+// Calls fn with the route name strings passed to path() and url(). The
+// caller holds the lock.
+func (a *twigAnalyzer) routeNameStrings(fn func(name string, str sitter.Node)) {
+	if a.tree == nil {
+		return
+	}
+	walkNodes(a.tree.RootNode(), func(n sitter.Node) {
+		if n.Type() != "function_call" {
+			return
+		}
+		nameNode := n.NamedChild(0)
+		if nameNode.IsNull() {
+			return
+		}
+		fnName := nameNode.Content(a.content)
+		if fnName != "path" && fnName != "url" {
+			return
+		}
+		str := a.firstArgStringNode(n.NamedChild(1))
+		if str.IsNull() {
+			return
+		}
+		fn(a.stringContent(str), str)
+	})
+}
+
+func (a *phpAnalyzer) routeDiagnostics() []protocol.Diagnostic {
+	a.mu.RLock()
+	container := a.container
+	autoload := a.autoload
+	routes := a.routes
+	store := a.docStore
+	a.mu.RUnlock()
+
+	if a.doc == nil || container == nil || autoload.IsEmpty() || len(routes) == 0 || store == nil {
+		return nil
+	}
+
+	var diagnostics []protocol.Diagnostic
+	for _, literal := range a.routeNameLiterals(routes) {
+		if d, ok := routeControllerDiagnostic(literal.route, routes[literal.route], literal.rng, container, autoload, store); ok {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+// A known route name passed as a string to generate(), generateUrl() or
+// redirectToRoute()
+type routeNameLiteral struct {
+	pos   protocol.Position
+	rng   protocol.Range
+	route string
+}
+
+func (a *phpAnalyzer) routeNameLiterals(routes config.RoutesMap) []routeNameLiteral {
+	var candidates []routeNameLiteral
+
+	a.doc.Read(func(tree *sitter.Tree, content []byte, _ php.IndexedTree) {
+		if tree == nil {
+			return
+		}
+		walkNodes(tree.RootNode(), func(n sitter.Node) {
+			if n.Type() != "member_call_expression" {
+				return
+			}
+			nameNode := n.ChildByFieldName("name")
+			if nameNode.IsNull() {
+				return
+			}
+			switch nameNode.Content(content) {
+			case "generate", "generateUrl", "redirectToRoute":
+			default:
+				return
+			}
+			args := n.ChildByFieldName("arguments")
+			if args.IsNull() || args.NamedChildCount() == 0 {
+				return
+			}
+			first := args.NamedChild(0)
+			str := first.NamedChild(0)
+			if str.IsNull() || str.Type() != "string" || str.EndByte()-str.StartByte() < 2 {
+				return
+			}
+			name := string(content[str.StartByte()+1 : str.EndByte()-1])
+			if _, ok := routes[name]; !ok {
+				return
+			}
+			sp := str.StartPoint()
+			candidates = append(candidates, routeNameLiteral{
+				pos:   utils.Position(content, int(sp.Row), int(sp.Column)+1),
+				rng:   nodeRange(str, content),
+				route: name,
+			})
+		})
+	})
+
+	var literals []routeNameLiteral
+	for _, c := range candidates {
+		a.mu.RLock()
+		ctx, ok := a.phpRouteContextAt(c.pos)
+		a.mu.RUnlock()
+		if ok && ctx.argIndex == 0 {
+			literals = append(literals, c)
+		}
+	}
+	return literals
+}
+
+// Reports #[Route] names that are declared more than once, within this
+// document, by the #[Route] attributes of other controllers or by a different
+// controller action known to the router. Symfony silently keeps the last
+// definition, so every location is reported.
+func (a *phpAnalyzer) duplicateRouteDiagnostics() []protocol.Diagnostic {
+	a.mu.RLock()
+	container := a.container
+	autoload := a.autoload
+	routes := a.routes
+	store := a.docStore
+	path := a.path
+	a.mu.RUnlock()
+
+	if a.doc == nil {
+		return nil
+	}
+
+	var attrs []routeAttribute
+	a.doc.Read(func(tree *sitter.Tree, content []byte, index php.IndexedTree) {
+		attrs = collectRouteAttributes(tree, content, index)
+	})
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	uri := protocol.DocumentUri(utils.PathToURI(path))
+	byName := make(map[string][]routeAttribute, len(attrs))
+	for _, attr := range attrs {
+		byName[attr.name] = append(byName[attr.name], attr)
+	}
+
+	var diagnostics []protocol.Diagnostic
+	for _, attr := range attrs {
+		var related []protocol.DiagnosticRelatedInformation
+		for _, other := range byName[attr.name] {
+			if other.nameRange == attr.nameRange {
+				continue
+			}
+			related = append(related, protocol.DiagnosticRelatedInformation{
+				Location: protocol.Location{URI: uri, Range: other.nameRange},
+				Message:  fmt.Sprintf("'%s' also declared on %s::%s", attr.name, other.class, other.method),
+			})
+		}
+		if container != nil {
+			// This document is compared as it is being edited, above
+			for _, other := range container.RouteDeclarationsOf(attr.name) {
+				if other.Location.URI == uri {
+					continue
+				}
+				related = append(related, protocol.DiagnosticRelatedInformation{
+					Location: other.Location,
+					Message:  fmt.Sprintf("'%s' also declared on %s::%s", attr.name, other.Class, other.Action),
+				})
+			}
+		}
+
+		if route, ok := routes[attr.name]; ok && len(related) == 0 && !routeTargets(route, attr.class, attr.method, container) {
+			if doc, routeURI, ok := routeDocument(route, container, autoload, store); ok {
+				for _, loc := range resolveRouteLocations(route, routeURI, doc) {
+					related = append(related, protocol.DiagnosticRelatedInformation{
+						Location: loc,
+						Message:  fmt.Sprintf("'%s' also declared on %s::%s", attr.name, route.Controller, route.Action),
+					})
+				}
+			}
+		}
+
+		if len(related) == 0 {
+			continue
+		}
+		d := newDiagnostic(
+			attr.nameRange,
+			protocol.DiagnosticSeverityWarning,
+			fmt.Sprintf("Duplicate route name '%s'; Symfony only keeps the last definition", attr.name),
+		)
+		d.RelatedInformation = related
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// Checks whether a route is served by the given controller class and method
+func routeTargets(route config.Route, class, method string, container *config.ContainerConfig) bool {
+	controller := route.Controller
+	if container != nil {
+		if resolved, ok := container.ResolveServiceId(controller); ok {
+			controller = resolved
+		}
+	}
+	action := route.Action
+	if action == "" {
+		action = "__invoke"
+	}
+	return strings.EqualFold(normalizeFQN(controller), normalizeFQN(class)) && strings.EqualFold(action, method)
+}
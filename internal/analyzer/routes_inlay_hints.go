@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"fmt"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Shows the path of the route after its name: 'app_order_show' ➜ /orders/{id}
+func routePathInlayHint(route config.Route, pos protocol.Position) (protocol317.InlayHint, bool) {
+	if route.Path == "" {
+		return protocol317.InlayHint{}, false
+	}
+	hint := protocol317.InlayHint{
+		Position:    pos,
+		Label:       "➜ " + route.Path,
+		PaddingLeft: &protocol.True,
+	}
+	if route.Controller != "" {
+		action := route.Action
+		if action == "" {
+			action = "__invoke"
+		}
+		hint.Tooltip = fmt.Sprintf("%s::%s", route.Controller, action)
+	}
+	return hint, true
+}
+
+func rangeContains(rng protocol.Range, pos protocol.Position) bool {
+	if pos.Line < rng.Start.Line || pos.Line > rng.End.Line {
+		return false
+	}
+	if pos.Line == rng.Start.Line && pos.Character < rng.Start.Character {
+		return false
+	}
+	return pos.Line != rng.End.Line || pos.Character <= rng.End.Character
+}
+
+func (a *twigAnalyzer) OnInlayHints(rng protocol.Range) ([]protocol317.InlayHint, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var hints []protocol317.InlayHint
+	a.routeNameStrings(func(name string, str sitter.Node) {
+		end := nodeRange(str, a.content).End
+		if !rangeContains(rng, end) {
+			return
+		}
+		if hint, ok := routePathInlayHint(a.routes[name], end); ok {
+			hints = append(hints, hint)
+		}
+	})
+	return hints, nil
+}
+
+func (a *phpAnalyzer) OnInlayHints(rng protocol.Range) ([]protocol317.InlayHint, error) {
+	a.mu.RLock()
+	routes := a.routes
+	a.mu.RUnlock()
+
+	if a.doc == nil || len(routes) == 0 {
+		return nil, nil
+	}
+
+	var hints []protocol317.InlayHint
+	for _, literal := range a.routeNameLiterals(routes) {
+		if !rangeContains(rng, literal.rng.End) {
+			continue
+		}
+		if hint, ok := routePathInlayHint(routes[literal.route], literal.rng.End); ok {
+			hints = append(hints, hint)
+		}
+	}
+	return hints, nil
+}
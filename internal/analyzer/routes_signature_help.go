@@ -0,0 +1,215 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Matches the keys of a PHP array or Twig hash: 'id' => or id:
+var routeParameterKeyRe = regexp.MustCompile(`(?:^|[\[{,])\s*['"]?(\w+)['"]?\s*(?:=>|:)`)
+
+// Matches the key being typed at the end of the text before the caret
+var routeParameterKeyPrefixRe = regexp.MustCompile(`[\[{,]\s*['"]?(\w*)$`)
+
+// A route generating call around the caret, such as path('app_blog_list',
+// {slug: 'x'}) or $this->generateUrl('app_blog_list', ['slug' => 'x'])
+type routeCall struct {
+	fnName   string
+	argIndex int
+	// Text of the parameters argument, up to the caret when it is in there
+	params      string
+	paramsTyped string
+	// Brackets of the parameters argument: {} in Twig, [] in PHP
+	open, close string
+}
+
+// Shows the parameters of the route as the signature of the call, the
+// required ones plain and the optional ones with a ?:
+// path('app_blog_list', {slug, page?})
+func routeSignatureHelp(call routeCall, route config.Route) *protocol.SignatureHelp {
+	var label strings.Builder
+	fmt.Fprintf(&label, "%s('%s', %s", call.fnName, route.Name, call.open)
+
+	optional := make(map[string]bool, len(route.Optional))
+	for _, name := range route.Optional {
+		optional[name] = true
+	}
+
+	params := make([]protocol.ParameterInformation, 0, len(route.Parameters))
+	for i, name := range route.Parameters {
+		if i > 0 {
+			label.WriteString(", ")
+		}
+		start := protocol.UInteger(label.Len())
+		label.WriteString(name)
+		doc := "required"
+		if optional[name] {
+			label.WriteString("?")
+			doc = "optional, has a default"
+		}
+		params = append(params, protocol.ParameterInformation{
+			Label:         [2]protocol.UInteger{start, protocol.UInteger(label.Len())},
+			Documentation: doc,
+		})
+	}
+	label.WriteString(call.close + ")")
+
+	signature := protocol.SignatureInformation{Label: label.String(), Parameters: params}
+	var doc []string
+	if route.Path != "" {
+		doc = append(doc, "`"+route.Path+"`")
+	}
+	if route.Controller != "" {
+		doc = append(doc, fmt.Sprintf("`%s::%s`", route.Controller, route.Action))
+	}
+	if len(doc) > 0 {
+		signature.Documentation = protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: strings.Join(doc, "\n\n")}
+	}
+
+	activeSignature := protocol.UInteger(0)
+	help := &protocol.SignatureHelp{
+		Signatures:      []protocol.SignatureInformation{signature},
+		ActiveSignature: &activeSignature,
+	}
+	if active, ok := activeRouteParameter(call, route); ok {
+		help.ActiveParameter = &active
+	}
+	return help
+}
+
+// The active parameter is the key typed at the caret, or else the first
+// required parameter still missing from the call
+func activeRouteParameter(call routeCall, route config.Route) (protocol.UInteger, bool) {
+	if call.argIndex == 1 {
+		if m := routeParameterKeyPrefixRe.FindStringSubmatch(call.paramsTyped); m != nil && m[1] != "" {
+			for i, name := range route.Parameters {
+				if strings.HasPrefix(name, m[1]) {
+					return protocol.UInteger(i), true
+				}
+			}
+		}
+	}
+
+	given := make(map[string]bool)
+	for _, m := range routeParameterKeyRe.FindAllStringSubmatch(call.params, -1) {
+		given[m[1]] = true
+	}
+	for i, name := range route.Parameters {
+		if !given[name] && !slices.Contains(route.Optional, name) {
+			return protocol.UInteger(i), true
+		}
+	}
+	return 0, false
+}
+
+// Returns the index of the argument the caret is in, counting the commas of
+// the argument list before it
+func argumentIndexAt(args sitter.Node, caret int) int {
+	index := 0
+	for i := uint32(0); i < args.ChildCount(); i++ {
+		child := args.Child(i)
+		if child.Type() == "," && int(child.EndByte()) <= caret {
+			index++
+		}
+	}
+	return index
+}
+
+// Returns the text of the second argument, and the part of it before the
+// caret
+func routeParamsText(args sitter.Node, content []byte, caret int) (string, string) {
+	if args.NamedChildCount() < 2 {
+		return "", ""
+	}
+	arg := args.NamedChild(1)
+	start, end := int(arg.StartByte()), int(arg.EndByte())
+	text := string(content[start:end])
+	if caret < start || caret > end {
+		return text, ""
+	}
+	return text, string(content[start:caret])
+}
+
+func (a *twigAnalyzer) routeCallSignatureHelp(pos protocol.Position) (*protocol.SignatureHelp, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.tree == nil || len(a.routes) == 0 {
+		return nil, nil
+	}
+	point, ok := lspPosToPoint(pos, a.content)
+	if !ok {
+		return nil, nil
+	}
+	caret := lspPosToByteOffset(a.content, pos)
+
+	for n := a.tree.RootNode().NamedDescendantForPointRange(point, point); !n.IsNull(); n = n.Parent() {
+		if n.Type() != "function_call" {
+			continue
+		}
+		nameNode := n.NamedChild(0)
+		args := n.NamedChild(1)
+		if nameNode.IsNull() || args.IsNull() || args.Type() != "arguments" || caret <= int(args.StartByte()) {
+			continue
+		}
+		fnName := nameNode.Content(a.content)
+		if fnName != "path" && fnName != "url" {
+			continue
+		}
+		route, ok := a.routes[a.firstArgRouteName(args)]
+		if !ok {
+			return nil, nil
+		}
+		call := routeCall{fnName: fnName, argIndex: argumentIndexAt(args, caret), open: "{", close: "}"}
+		call.params, call.paramsTyped = routeParamsText(args, a.content, caret)
+		return routeSignatureHelp(call, route), nil
+	}
+	return nil, nil
+}
+
+func (a *phpAnalyzer) routeCallSignatureHelp(pos protocol.Position) (*protocol.SignatureHelp, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.doc == nil || len(a.routes) == 0 {
+		return nil, nil
+	}
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return nil, nil
+	}
+	caret := lspPosToByteOffset(content, pos)
+	controllerTarget := strings.ToLower(normalizeFQN(abstractControllerFQN))
+
+	for n := node; !n.IsNull(); n = n.Parent() {
+		if n.Type() != "arguments" || caret <= int(n.StartByte()) {
+			continue
+		}
+		callNode := n.Parent()
+		if callNode.IsNull() || callNode.Type() != "member_call_expression" {
+			continue
+		}
+		if _, _, ok := routeCallTarget(a.doc, callNode, content, index, controllerTarget); !ok {
+			continue
+		}
+		route, ok := a.routes[a.phpRouteNameFromArgs(n)]
+		if !ok {
+			return nil, nil
+		}
+		call := routeCall{
+			fnName:   callNode.ChildByFieldName("name").Content(content),
+			argIndex: argumentIndexAt(n, caret),
+			open:     "[",
+			close:    "]",
+		}
+		call.params, call.paramsTyped = routeParamsText(n, content, caret)
+		return routeSignatureHelp(call, route), nil
+	}
+	return nil, nil
+}
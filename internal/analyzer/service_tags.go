@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// What the tags of the framework and the core bundles do
+var coreServiceTags = map[string]string{
+	"auto_alias":                            "Aliases a service to the one whose id is the value of the format parameter",
+	"console.command":                       "Registers a console command",
+	"container.hot_path":                    "Inlines the service in the dumped container, always needed on a request",
+	"container.no_preload":                  "Leaves the class out of the preload script",
+	"container.preload":                     "Adds a class to the preload script",
+	"container.service_locator":             "Marks a service locator",
+	"container.service_subscriber":          "Service subscriber whose getSubscribedServices() are made available",
+	"controller.argument_value_resolver":    "Resolves the value of controller arguments",
+	"controller.service_arguments":          "Controller whose action arguments can be autowired services",
+	"data_collector":                        "Collects data for the web profiler",
+	"doctrine.event_listener":               "Listens to Doctrine events",
+	"doctrine.orm.entity_listener":          "Listens to the lifecycle events of an entity",
+	"doctrine.repository_service":           "Doctrine repository fetched from the container",
+	"form.type":                             "Registers a form type",
+	"form.type_extension":                   "Extends existing form types",
+	"form.type_guesser":                     "Guesses the form type of a property",
+	"kernel.cache_clearer":                  "Clears a cache on cache:clear",
+	"kernel.cache_warmer":                   "Warms up a cache on cache:warmup",
+	"kernel.event_listener":                 "Listens to an event, with the event and method attributes",
+	"kernel.event_subscriber":               "Subscribes to the events of getSubscribedEvents()",
+	"kernel.fragment_renderer":              "Renders fragments such as esi or hinclude",
+	"kernel.locale_aware":                   "Receives the locale of the current request",
+	"kernel.reset":                          "Resets the service between requests, with the method attribute",
+	"messenger.message_handler":             "Handles Messenger messages",
+	"mime.mime_type_guesser":                "Guesses the MIME type of files",
+	"monolog.logger":                        "Injects the logger of the channel attribute",
+	"monolog.processor":                     "Adds extra data to log records",
+	"routing.expression_language_provider":  "Adds functions to routing expressions",
+	"routing.loader":                        "Loads routes from a custom resource",
+	"routing.route_loader":                  "Service whose methods load routes",
+	"security.expression_language_provider": "Adds functions to security expressions",
+	"security.voter":                        "Votes on access decisions",
+	"serializer.encoder":                    "Encodes to and decodes from a format",
+	"serializer.normalizer":                 "Normalizes and denormalizes objects",
+	"translation.dumper":                    "Dumps translations to a format",
+	"translation.extractor":                 "Extracts translation keys from files",
+	"translation.loader":                    "Loads translations from a format",
+	"translation.provider_factory":          "Creates translation providers",
+	"twig.extension":                        "Registers a Twig extension",
+	"twig.loader":                           "Registers a Twig loader",
+	"twig.runtime":                          "Lazy-loaded runtime of a Twig extension",
+	"validator.constraint_validator":        "Validates a custom constraint",
+	"validator.initializer":                 "Initializes objects before validation",
+}
+
+// Completes the tags the container uses, ranked by how many services carry
+// them, with the purpose of the core tags
+func tagCompletionItems(container *config.ContainerConfig, prefix string) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindKeyword
+	items := []protocol.CompletionItem{}
+	for name, count := range container.ServiceTags {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		detail := fmt.Sprintf("%d services", count)
+		item := protocol.CompletionItem{
+			Label:  name,
+			Kind:   &kind,
+			Detail: &detail,
+		}
+		if doc, ok := coreServiceTags[name]; ok {
+			item.Documentation = protocol.MarkupContent{Kind: protocol.MarkupKindPlainText, Value: doc}
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		countI := container.ServiceTags[items[i].Label]
+		countJ := container.ServiceTags[items[j].Label]
+		if countI != countJ {
+			return countI > countJ
+		}
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/translations"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const defaultTranslationDomain = "messages"
+
+// Quick fixes that add a key unknown to its domain to the project catalogs of
+// that domain. The key itself is used as the initial translation.
+func translationKeyCodeActions(container *config.ContainerConfig, key, domain string) []CodeAction {
+	if container == nil || key == "" {
+		return nil
+	}
+	if domain == "" {
+		domain = defaultTranslationDomain
+	}
+	if locs, ok := container.TranslationKeys[key]; ok && len(locationsInDomain(locs, domain)) > 0 {
+		return nil
+	}
+
+	// One catalog per locale, preferring yaml over xlf
+	byLocale := make(map[string]translations.Catalog)
+	var locales []string
+	for _, catalog := range container.TranslationCatalogs() {
+		if catalog.Domain != domain {
+			continue
+		}
+		existing, ok := byLocale[catalog.Locale]
+		if !ok {
+			locales = append(locales, catalog.Locale)
+		}
+		if !ok || (existing.Format != "yaml" && catalog.Format == "yaml") {
+			byLocale[catalog.Locale] = catalog
+		}
+	}
+	if len(locales) == 0 {
+		return nil
+	}
+
+	var actions []CodeAction
+	kind := protocol.CodeActionKindQuickFix
+	if catalog, ok := byLocale[container.DefaultLocale]; ok {
+		title := fmt.Sprintf("Add translation '%s' to %s", key, filepath.Base(catalog.Path))
+		actions = append(actions, newCodeAction(title, kind, func() *protocol.WorkspaceEdit {
+			return catalogKeyEdit([]translations.Catalog{catalog}, key)
+		}))
+	}
+
+	if len(locales) > 1 {
+		catalogs := make([]translations.Catalog, 0, len(locales))
+		for _, locale := range locales {
+			catalogs = append(catalogs, byLocale[locale])
+		}
+		title := fmt.Sprintf("Add translation '%s' to all locales", key)
+		actions = append(actions, newCodeAction(title, kind, func() *protocol.WorkspaceEdit {
+			return catalogKeyEdit(catalogs, key)
+		}))
+	}
+
+	return actions
+}
+
+// Adds the key to each of the catalogs, skipping those that cannot be read
+func catalogKeyEdit(catalogs []translations.Catalog, key string) *protocol.WorkspaceEdit {
+	changes := make(map[protocol.DocumentUri][]protocol.TextEdit)
+	for _, catalog := range catalogs {
+		content, err := os.ReadFile(catalog.Path)
+		if err != nil {
+			continue
+		}
+		edits, ok := translations.AddKeyEdits(catalog.Format, content, key, key)
+		if !ok {
+			continue
+		}
+		changes[protocol.DocumentUri(utils.PathToURI(catalog.Path))] = edits
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return &protocol.WorkspaceEdit{Changes: changes}
+}
@@ -0,0 +1,205 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/translations"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var transParameterNames = []string{"id", "parameters", "domain", "locale"}
+
+// Shows trans(id, parameters, domain, locale) with the placeholders of the
+// message of the key and the known domains as parameter documentation
+func transSignatureHelp(container *config.ContainerConfig, key string, active int) *protocol.SignatureHelp {
+	label := "trans(" + strings.Join(transParameterNames, ", ") + ")"
+
+	message, hasMessage := "", false
+	if key != "" {
+		message, hasMessage = container.TranslationKeys.Message(key, container.DefaultLocale)
+	}
+
+	params := make([]protocol.ParameterInformation, 0, len(transParameterNames))
+	for _, name := range transParameterNames {
+		start := strings.Index(label, name)
+		var doc string
+		switch name {
+		case "id":
+			if hasMessage {
+				doc = fmt.Sprintf("`%s`: %s", key, message)
+			}
+		case "parameters":
+			if hasMessage {
+				doc = transPlaceholdersDocumentation(message)
+			}
+		case "domain":
+			if domains := container.TranslationDomains(); len(domains) > 0 {
+				doc = "Known domains: `" + strings.Join(domains, "`, `") + "`"
+			}
+		case "locale":
+			if container.DefaultLocale != "" {
+				doc = "Defaults to `" + container.DefaultLocale + "`"
+			}
+		}
+		param := protocol.ParameterInformation{
+			Label: [2]protocol.UInteger{protocol.UInteger(start), protocol.UInteger(start + len(name))},
+		}
+		if doc != "" {
+			param.Documentation = protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: doc}
+		}
+		params = append(params, param)
+	}
+
+	signature := protocol.SignatureInformation{Label: label, Parameters: params}
+	if hasMessage {
+		signature.Documentation = message
+	}
+
+	activeSignature := protocol.UInteger(0)
+	help := &protocol.SignatureHelp{
+		Signatures:      []protocol.SignatureInformation{signature},
+		ActiveSignature: &activeSignature,
+	}
+	if active >= 0 && active < len(transParameterNames) {
+		activeParameter := protocol.UInteger(active)
+		help.ActiveParameter = &activeParameter
+	}
+	return help
+}
+
+func transPlaceholdersDocumentation(message string) string {
+	placeholders := translations.Placeholders(message)
+	if len(placeholders) == 0 {
+		return "*No placeholders*"
+	}
+	var b strings.Builder
+	b.WriteString("**Placeholders:**\n")
+	for _, name := range placeholders {
+		b.WriteString("- `")
+		b.WriteString(name)
+		b.WriteString("`\n")
+	}
+	return b.String()
+}
+
+// Returns the index of a trans() parameter given by name: domain: in PHP or
+// domain= in Twig
+func transParameterIndex(name string) int {
+	name = strings.TrimRight(strings.TrimSpace(name), " =:")
+	for i, param := range transParameterNames {
+		if param == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the argument of the list that holds the caret
+func argumentAt(args sitter.Node, caret int) sitter.Node {
+	for i := uint32(0); i < args.NamedChildCount(); i++ {
+		arg := args.NamedChild(i)
+		if int(arg.StartByte()) <= caret && caret <= int(arg.EndByte()) {
+			return arg
+		}
+	}
+	return sitter.Node{}
+}
+
+func (a *twigAnalyzer) OnSignatureHelp(pos protocol.Position) (*protocol.SignatureHelp, error) {
+	if help, err := a.routeCallSignatureHelp(pos); help != nil || err != nil {
+		return help, err
+	}
+	return a.transSignatureHelp(pos)
+}
+
+// Signature help of the trans filter, whose id is the filtered value:
+// {{ 'key'|trans({'%name%': ...}, 'domain') }}
+func (a *twigAnalyzer) transSignatureHelp(pos protocol.Position) (*protocol.SignatureHelp, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.tree == nil || a.container == nil {
+		return nil, nil
+	}
+	point, ok := lspPosToPoint(pos, a.content)
+	if !ok {
+		return nil, nil
+	}
+	caret := lspPosToByteOffset(a.content, pos)
+
+	for n := a.tree.RootNode().NamedDescendantForPointRange(point, point); !n.IsNull(); n = n.Parent() {
+		if n.Type() != "arguments" || caret <= int(n.StartByte()) {
+			continue
+		}
+		filter := n.Parent()
+		if filter.IsNull() || filter.Type() != "filter" {
+			continue
+		}
+		nameNode := filter.NamedChild(0)
+		if nameNode.IsNull() || strings.TrimSpace(nameNode.Content(a.content)) != "trans" {
+			continue
+		}
+
+		key := ""
+		if keyNode := filter.Parent().NamedChild(0); !keyNode.IsNull() && keyNode.Type() == "string" {
+			key = a.stringContent(keyNode)
+		}
+		active := argumentIndexAt(n, caret) + 1
+		if arg := argumentAt(n, caret); !arg.IsNull() {
+			for i := uint32(0); i < arg.NamedChildCount(); i++ {
+				if child := arg.NamedChild(i); child.Type() == "argument_name" {
+					active = transParameterIndex(child.Content(a.content))
+				}
+			}
+		}
+		return transSignatureHelp(a.container, key, active), nil
+	}
+	return nil, nil
+}
+
+func (a *phpAnalyzer) OnSignatureHelp(pos protocol.Position) (*protocol.SignatureHelp, error) {
+	if help, err := a.routeCallSignatureHelp(pos); help != nil || err != nil {
+		return help, err
+	}
+	return a.transSignatureHelp(pos)
+}
+
+func (a *phpAnalyzer) transSignatureHelp(pos protocol.Position) (*protocol.SignatureHelp, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.doc == nil || a.container == nil {
+		return nil, nil
+	}
+	node, content, index, ok := a.doc.GetNodeAt(pos)
+	if !ok {
+		return nil, nil
+	}
+	caret := lspPosToByteOffset(content, pos)
+
+	for n := node; !n.IsNull(); n = n.Parent() {
+		if n.Type() != "arguments" || caret <= int(n.StartByte()) {
+			continue
+		}
+		callNode := n.Parent()
+		if callNode.IsNull() || callNode.Type() != "member_call_expression" || !a.isTransCall(callNode, content, index) {
+			continue
+		}
+
+		key := ""
+		if first := n.NamedChild(0); !first.IsNull() && first.NamedChildCount() > 0 {
+			key = a.stringContent(first.NamedChild(first.NamedChildCount() - 1))
+		}
+		active := argumentIndexAt(n, caret)
+		if arg := argumentAt(n, caret); !arg.IsNull() {
+			if name := arg.ChildByFieldName("name"); !name.IsNull() {
+				active = transParameterIndex(name.Content(content))
+			}
+		}
+		return transSignatureHelp(a.container, key, active), nil
+	}
+	return nil, nil
+}
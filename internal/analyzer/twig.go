@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -13,7 +14,6 @@ import (
 	"github.com/shinyvision/vimfony/internal/config"
 	php "github.com/shinyvision/vimfony/internal/php"
 	twiglib "github.com/shinyvision/vimfony/internal/twig"
-	"github.com/shinyvision/vimfony/internal/utils"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
@@ -27,8 +27,12 @@ type twigAnalyzer struct {
 	assignmentQuery   *sitter.Query
 	container         *config.ContainerConfig
 	routes            config.RoutesMap
+	routeNames        *routeNameCompletions
 	autoload          config.AutoloadMap
 	docStore          *php.DocumentStore
+	path              string
+	snippets          bool
+	features          config.Features
 }
 
 type twigCallCtx struct {
@@ -78,10 +82,13 @@ func (a *twigAnalyzer) Changed(code []byte, change *sitter.InputEdit) error {
 	defer a.mu.Unlock()
 
 	a.content = code
+	// Without an edit the old tree does not describe the new content
+	var oldTree *sitter.Tree
 	if a.tree != nil && change != nil {
 		a.tree.Edit(*change)
+		oldTree = a.tree
 	}
-	newTree, err := a.parser.ParseString(context.Background(), a.tree, code)
+	newTree, err := a.parser.ParseString(context.Background(), oldTree, code)
 	if err != nil {
 		return err
 	}
@@ -256,9 +263,11 @@ func (a *twigAnalyzer) SetRoutes(routes *config.RoutesMap) {
 	defer a.mu.Unlock()
 	if routes == nil {
 		a.routes = nil
+		a.routeNames = nil
 		return
 	}
 	a.routes = *routes
+	a.routeNames = newRouteNameCompletions(a.routes)
 }
 
 func (a *twigAnalyzer) SetAutoloadMap(autoload *config.AutoloadMap) {
@@ -277,31 +286,53 @@ func (a *twigAnalyzer) SetDocumentStore(store *php.DocumentStore) {
 	a.mu.Unlock()
 }
 
-func (a *twigAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location, error) {
-	if locs, ok := a.resolveRouteDefinition(pos); ok {
-		return locs, nil
-	}
+func (a *twigAnalyzer) SetDocumentPath(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.path = path
+}
+
+func (a *twigAnalyzer) SetSnippetSupport(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.snippets = enabled
+}
 
-	if locs, ok := a.resolveTranslationDefinition(pos); ok {
-		return locs, nil
+func (a *twigAnalyzer) SetFeatures(features config.Features) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.features = features
+}
+
+func (a *twigAnalyzer) OnDefinition(ctx context.Context, pos protocol.Position) ([]protocol.Location, error) {
+	resolvers := []func(protocol.Position) ([]protocol.Location, bool){
+		a.resolveRouteDefinition,
+		a.resolveTranslationDefinition,
+		a.resolveComponentDefinition,
+		a.resolveFormFieldDefinition,
+	}
+	for _, resolve := range resolvers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if locs, ok := resolve(pos); ok {
+			return locs, nil
+		}
 	}
 
 	a.mu.RLock()
 	content := string(a.content)
 	container := a.container
+	features := a.features
 	a.mu.RUnlock()
 
 	if container == nil {
 		return nil, nil
 	}
 
-	if twigPath, ok := twiglib.PathAt(content, pos); ok {
-		if target, ok := twiglib.Resolve(twigPath, container); ok {
-			loc := protocol.Location{
-				URI:   protocol.DocumentUri(utils.PathToURI(target)),
-				Range: protocol.Range{},
-			}
-			return []protocol.Location{loc}, nil
+	if twigPath, ok := twiglib.PathAt(content, pos); ok && features.Enabled(config.FeatureTemplates) {
+		if locs, ok := templateLocations(twigPath, container); ok {
+			return locs, nil
 		}
 	}
 
@@ -354,6 +385,12 @@ func (a *twigAnalyzer) resolveRouteDefinition(pos protocol.Position) ([]protocol
 }
 
 func (a *twigAnalyzer) routeContextAt(pos protocol.Position) (twigCallCtx, bool) {
+	return a.callContextAt(pos, "path", "url")
+}
+
+// Finds the call to one of the given functions whose argument holds the
+// string at pos
+func (a *twigAnalyzer) callContextAt(pos protocol.Position, fnNames ...string) (twigCallCtx, bool) {
 	if a.tree == nil {
 		return twigCallCtx{}, false
 	}
@@ -379,7 +416,7 @@ func (a *twigAnalyzer) routeContextAt(pos protocol.Position) (twigCallCtx, bool)
 				return twigCallCtx{}, false
 			}
 			fnName := string(a.content[nameNode.StartByte():nameNode.EndByte()])
-			if fnName != "path" && fnName != "url" {
+			if !slices.Contains(fnNames, fnName) {
 				return twigCallCtx{}, false
 			}
 			args := nn.NamedChild(1)
@@ -449,26 +486,26 @@ func (a *twigAnalyzer) stringContent(str sitter.Node) string {
 }
 
 func (a *twigAnalyzer) firstArgRouteName(args sitter.Node) string {
+	return a.stringContent(a.firstArgStringNode(args))
+}
+
+func (a *twigAnalyzer) firstArgStringNode(args sitter.Node) sitter.Node {
 	if args.IsNull() || args.Type() != "arguments" {
-		return ""
+		return sitter.Node{}
 	}
 	first := args.NamedChild(0)
 	if first.IsNull() {
-		return ""
+		return sitter.Node{}
 	}
 	av := first.NamedChild(0)
 	if av.IsNull() {
-		return ""
+		return sitter.Node{}
 	}
 	str := av.NamedChild(0)
 	if str.IsNull() || str.Type() != "string" {
-		return ""
+		return sitter.Node{}
 	}
-	sb, eb := int(str.StartByte()), int(str.EndByte())
-	if eb-sb < 2 {
-		return ""
-	}
-	return string(a.content[sb+1 : eb-1])
+	return str
 }
 
 func hasAnyHashKey(hashNode sitter.Node) bool {
@@ -524,7 +561,7 @@ func (a *twigAnalyzer) isTypingRouteParameter(pos protocol.Position) (bool, stri
 	return true, routeName, a.stringPrefix(ctx.strNode, pos)
 }
 
-func (a *twigAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.CompletionItem, error) {
+func (a *twigAnalyzer) OnCompletion(ctx context.Context, pos protocol.Position) ([]protocol.CompletionItem, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -534,16 +571,40 @@ func (a *twigAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.Completio
 
 	var items []protocol.CompletionItem
 
-	items = append(items, a.routeNameCompletionItems(pos)...)
-	items = append(items, a.routeParameterCompletionItems(pos)...)
-	items = append(items, a.twigTemplateCompletionItems(pos)...)
-	items = append(items, a.translationCompletionItems(pos)...)
+	completers := []func(protocol.Position) []protocol.CompletionItem{
+		a.routeNameCompletionItems,
+		a.routeParameterCompletionItems,
+		a.twigTemplateCompletionItems,
+		a.translationCompletionItems,
+		a.translationDomainCompletionItems,
+		a.transDefaultDomainCompletionItems,
+		a.translationPlaceholderCompletionItems,
+		a.filterCompletionItems,
+		a.testCompletionItems,
+		a.blockCompletionItems,
+		a.macroCompletionItems,
+		a.formFieldCompletionItems,
+		a.constantCompletionItems,
+		a.assetCompletionItems,
+		a.importMapCompletionItems,
+		a.imagineFilterCompletionItems,
+		a.componentCompletionItems,
+		a.componentPropCompletionItems,
+		a.tagSnippetCompletionItems,
+	}
+	for _, complete := range completers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		items = append(items, complete(pos)...)
+	}
 
 	if foundFunction, functionPrefix := a.isTypingFunction(pos); foundFunction {
 		items = append(items, a.twigFunctionCompletionItems(functionPrefix)...)
 	}
 	if foundVariable, variablePrefix := a.isTypingVariable(pos); foundVariable {
 		items = append(items, a.twigVariableCompletionItems(variablePrefix)...)
+		items = append(items, a.renderVariableCompletionItems(variablePrefix)...)
 	}
 
 	if len(items) == 0 {
@@ -605,12 +666,50 @@ func (a *twigAnalyzer) twigVariableCompletionItems(prefix string) []protocol.Com
 	return items
 }
 
+// Completes the keys of the context arrays that controllers pass to this
+// template with render()
+func (a *twigAnalyzer) renderVariableCompletionItems(prefix string) []protocol.CompletionItem {
+	if a.path == "" || !a.features.Enabled(config.FeatureTemplates) {
+		return nil
+	}
+	name, ok := twiglib.TemplateName(a.path, a.container)
+	if !ok {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindVariable
+	byName := make(map[string][]string)
+	var names []string
+	for _, variable := range a.container.TemplateVariables[name] {
+		if !strings.HasPrefix(variable.Name, prefix) {
+			continue
+		}
+		if _, ok := byName[variable.Name]; !ok {
+			names = append(names, variable.Name)
+		}
+		if !slices.Contains(byName[variable.Name], variable.Controller) {
+			byName[variable.Name] = append(byName[variable.Name], variable.Controller)
+		}
+	}
+
+	items := []protocol.CompletionItem{}
+	for _, variable := range names {
+		detail := strings.Join(byName[variable], ", ")
+		items = append(items, protocol.CompletionItem{
+			Label:  variable,
+			Kind:   &kind,
+			Detail: &detail,
+		})
+	}
+	return items
+}
+
 func (a *twigAnalyzer) routeNameCompletionItems(pos protocol.Position) []protocol.CompletionItem {
 	found, prefix := a.isTypingRouteName(pos)
 	if !found {
 		return nil
 	}
-	return makeRouteNameCompletionItems(a.routes, prefix)
+	return a.routeNames.complete(prefix)
 }
 
 func (a *twigAnalyzer) routeParameterCompletionItems(pos protocol.Position) []protocol.CompletionItem {
@@ -622,7 +721,7 @@ func (a *twigAnalyzer) routeParameterCompletionItems(pos protocol.Position) []pr
 }
 
 func (a *twigAnalyzer) twigTemplateCompletionItems(pos protocol.Position) []protocol.CompletionItem {
-	if a.tree == nil || a.container == nil {
+	if a.tree == nil || a.container == nil || !a.features.Enabled(config.FeatureTemplates) {
 		return nil
 	}
 
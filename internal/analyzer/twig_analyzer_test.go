@@ -1,8 +1,11 @@
 package analyzer
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -191,12 +194,69 @@ func TestTwigDefinitionForIncludePath(t *testing.T) {
 	offset := strings.Index(content, "target.twig") + 3
 	pos := protocol.Position{Line: 0, Character: uint32(offset)}
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 	require.Equal(t, protocol.DocumentUri(utils.PathToURI(targetPath)), locs[0].URI)
 }
 
+func TestTwigDefinitionForBundleOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(rel string) string {
+		path := filepath.Join(tmpDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("{# stub #}"), 0o644))
+		return path
+	}
+	override := write("templates/bundles/TwigBundle/Exception/error.html.twig")
+	original := write("vendor/symfony/twig-bundle/Resources/views/Exception/error.html.twig")
+	bundleOnly := write("vendor/symfony/twig-bundle/Resources/views/layout.html.twig")
+
+	content := "{% extends '@Twig/Exception/error.html.twig' %}\n{% include '@Twig/layout.html.twig' %}\n{% extends '@!Twig/Exception/error.html.twig' %}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	container := &config.ContainerConfig{
+		WorkspaceRoot: tmpDir,
+		Roots:         []string{"templates"},
+		BundleRoots: map[string][]string{
+			"Twig": {filepath.Join(tmpDir, "vendor/symfony/twig-bundle/Resources/views")},
+		},
+	}
+	an.SetContainerConfig(container)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	expected := []protocol.DocumentUri{protocol.DocumentUri(utils.PathToURI(override)), protocol.DocumentUri(utils.PathToURI(original))}
+	uris := func(locs []protocol.Location) []protocol.DocumentUri {
+		var uris []protocol.DocumentUri
+		for _, loc := range locs {
+			uris = append(uris, loc.URI)
+		}
+		return uris
+	}
+
+	locs, err := an.OnDefinition(context.Background(), protocol.Position{Line: 0, Character: 20})
+	require.NoError(t, err)
+	assert.Equal(t, expected, uris(locs))
+
+	locs, err = an.OnDefinition(context.Background(), protocol.Position{Line: 2, Character: 20})
+	require.NoError(t, err)
+	assert.Equal(t, expected[1:], uris(locs))
+
+	// Compiled containers register the override directory as well
+	container.BundleRoots["Twig"] = append([]string{filepath.Join(tmpDir, "templates/bundles/TwigBundle")}, container.BundleRoots["Twig"]...)
+	locs, err = an.OnDefinition(context.Background(), protocol.Position{Line: 0, Character: 20})
+	require.NoError(t, err)
+	assert.Equal(t, expected, uris(locs))
+
+	locs, err = an.OnDefinition(context.Background(), protocol.Position{Line: 2, Character: 20})
+	require.NoError(t, err)
+	assert.Equal(t, expected[1:], uris(locs))
+
+	locs, err = an.OnDefinition(context.Background(), protocol.Position{Line: 1, Character: 20})
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	assert.Equal(t, protocol.DocumentUri(utils.PathToURI(bundleOnly)), locs[0].URI)
+}
+
 func TestTwigDefinitionForRegisteredFunction(t *testing.T) {
 	content := "{{ my_function(variable) }}"
 	an := NewTwigAnalyzer().(*twigAnalyzer)
@@ -215,7 +275,7 @@ func TestTwigDefinitionForRegisteredFunction(t *testing.T) {
 	offset := strings.Index(content, "my_function") + 2
 	pos := protocol.Position{Line: 0, Character: uint32(offset)}
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.Len(t, locs, 1)
 	require.Equal(t, container.TwigFunctions["my_function"], locs[0])
@@ -240,7 +300,7 @@ func TestTwigDefinitionForRouteControllerAction(t *testing.T) {
 			"VendorNamespace\\": {"vendor"},
 		},
 	}
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetAutoloadMap(&autoload)
@@ -266,7 +326,7 @@ func TestTwigDefinitionForRouteControllerAction(t *testing.T) {
 	idx := start + 2
 	pos := protocol.Position{Line: 0, Character: uint32(idx)}
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -298,7 +358,7 @@ func TestTwigDefinitionForRouteControllerInvokeFallback(t *testing.T) {
 			"VendorNamespace\\": {"vendor"},
 		},
 	}
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetAutoloadMap(&autoload)
@@ -324,7 +384,7 @@ func TestTwigDefinitionForRouteControllerInvokeFallback(t *testing.T) {
 	idx := start + 2
 	pos := protocol.Position{Line: 0, Character: uint32(idx)}
 
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, locs)
 
@@ -335,6 +395,149 @@ func TestTwigDefinitionForRouteControllerInvokeFallback(t *testing.T) {
 	require.Equal(t, invokeRange, locs[0].Range)
 }
 
+func TestTwigDiagnosticsForRouteWithMissingAction(t *testing.T) {
+	content := "{{ path('a_route') }}\n{{ url('other_route') }}"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	container := &config.ContainerConfig{
+		WorkspaceRoot:     mockRoot,
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	}
+	an.SetContainerConfig(container)
+	autoload := config.AutoloadMap{
+		PSR4: map[string][]string{
+			"VendorNamespace\\": {"vendor"},
+		},
+	}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	an.SetAutoloadMap(&autoload)
+	routes := config.RoutesMap{
+		"a_route": {
+			Name:       "a_route",
+			Controller: "VendorNamespace\\TestClass",
+			Action:     "missingAction",
+		},
+		"other_route": {
+			Name:       "other_route",
+			Controller: "VendorNamespace\\TestClass",
+			Action:     "index",
+		},
+	}
+	an.SetRoutes(&routes)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, uint32(0), diagnostics[0].Range.Start.Line)
+	require.Equal(t, uint32(8), diagnostics[0].Range.Start.Character)
+	require.Contains(t, diagnostics[0].Message, "missingAction")
+}
+
+func TestTwigDiagnosticsForRouteWithMissingControllerClass(t *testing.T) {
+	content := "{{ path('a_route') }}"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:     mockRoot,
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	autoload := config.AutoloadMap{
+		PSR4: map[string][]string{
+			"VendorNamespace\\": {"vendor"},
+		},
+	}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	an.SetAutoloadMap(&autoload)
+	routes := config.RoutesMap{
+		"a_route": {
+			Name:       "a_route",
+			Controller: "VendorNamespace\\MissingController",
+			Action:     "index",
+		},
+	}
+	an.SetRoutes(&routes)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, uint32(8), diagnostics[0].Range.Start.Character)
+	require.Equal(t, "Route 'a_route' points to missing controller class VendorNamespace\\MissingController", diagnostics[0].Message)
+}
+
+func TestTwigDiagnosticRangeCountsUTF16(t *testing.T) {
+	// é takes 2 bytes and 1 code unit, 😀 takes 4 bytes and 2 code units
+	content := "{# é😀 #} {{ path('a_route') }}"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:     mockRoot,
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	autoload := config.AutoloadMap{
+		PSR4: map[string][]string{
+			"VendorNamespace\\": {"vendor"},
+		},
+	}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	an.SetAutoloadMap(&autoload)
+	routes := config.RoutesMap{
+		"a_route": {
+			Name:       "a_route",
+			Controller: "VendorNamespace\\TestClass",
+			Action:     "missingAction",
+		},
+	}
+	an.SetRoutes(&routes)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, uint32(18), diagnostics[0].Range.Start.Character)
+	require.Equal(t, uint32(27), diagnostics[0].Range.End.Character)
+}
+
+func TestTwigSyntaxDiagnostics(t *testing.T) {
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+
+	require.NoError(t, an.Changed([]byte("{% if a %}\n  {{ b }}\n{% endif %}\n"), nil))
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Empty(t, diagnostics)
+
+	require.NoError(t, an.Changed([]byte("<p>\n  {{ path('a' }}\n</p>\n"), nil))
+	diagnostics, err = an.OnDiagnostics()
+	require.NoError(t, err)
+	require.NotEmpty(t, diagnostics)
+	for _, d := range diagnostics {
+		require.NotNil(t, d.Severity)
+		require.Equal(t, protocol.DiagnosticSeverityError, *d.Severity)
+	}
+}
+
 func TestTwigTemplateCompletion(t *testing.T) {
 	content := `{% include '' %}
 {% embed '' %}
@@ -374,7 +577,7 @@ func TestTwigTemplateCompletion(t *testing.T) {
 
 	for _, tc := range testCases {
 		pos := twigPositionAfter(t, content, tc.needle, tc.offset)
-		items, err := an.OnCompletion(pos)
+		items, err := an.OnCompletion(context.Background(), pos)
 		require.NoErrorf(t, err, "completion error for %s context", tc.label)
 		require.NotEmptyf(t, items, "expected completion items for %s context", tc.label)
 
@@ -388,6 +591,54 @@ func TestTwigTemplateCompletion(t *testing.T) {
 	}
 }
 
+func TestTwigTemplatesFeatureDisabled(t *testing.T) {
+	content := `{% include 'template.html.twig' %}
+{% extends '' %}
+`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:  mockRoot,
+		Roots:          []string{"."},
+		ServiceClasses: make(map[string]string),
+		ServiceAliases: make(map[string]string),
+	})
+	an.SetFeatures(config.Features{config.FeatureTemplates: false})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), twigPositionAfter(t, content, "{% extends '", len("{% extends '")))
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	locs, err := an.OnDefinition(context.Background(), twigPositionAfter(t, content, "template.html", 0))
+	require.NoError(t, err)
+	require.Empty(t, locs)
+
+	links, err := an.OnDocumentLinks()
+	require.NoError(t, err)
+	require.Empty(t, links)
+}
+
+func TestTwigCompletionStopsWhenCancelled(t *testing.T) {
+	content := `{% extends '' %}`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{Roots: []string{"."}})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	items, err := an.OnCompletion(ctx, twigPositionAfter(t, content, "{% extends '", len("{% extends '")))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, items)
+
+	locs, err := an.OnDefinition(ctx, twigPositionAfter(t, content, "{% extends '", len("{% extends '")))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, locs)
+}
+
 func twigPositionAfter(t *testing.T, content, needle string, offset int) protocol.Position {
 	idx := strings.Index(content, needle)
 	require.NotEqualf(t, -1, idx, "needle %q not found", needle)
@@ -407,3 +658,615 @@ func twigPositionAfter(t *testing.T, content, needle string, offset int) protoco
 		Character: uint32(col),
 	}
 }
+
+// Returns the detail of the completion items at pos by label. Given kinds,
+// only the items of those kinds are kept, with or without a detail.
+func completionDetailsAt(t *testing.T, an *twigAnalyzer, pos protocol.Position, kinds ...protocol.CompletionItemKind) map[string]string {
+	items, err := an.OnCompletion(context.Background(), pos)
+	require.NoError(t, err)
+	details := make(map[string]string)
+	for _, item := range items {
+		if len(kinds) > 0 {
+			if item.Kind == nil || !slices.Contains(kinds, *item.Kind) {
+				continue
+			}
+			details[item.Label] = ""
+		}
+		if item.Detail != nil {
+			details[item.Label] = *item.Detail
+		}
+	}
+	return details
+}
+
+// Returns the labels of the completion items at pos
+func completionLabelsAt(t *testing.T, an *twigAnalyzer, pos protocol.Position) []string {
+	items, err := an.OnCompletion(context.Background(), pos)
+	require.NoError(t, err)
+	var labels []string
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func TestTwigExtractToTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatesDir := filepath.Join(tmpDir, "templates", "blog")
+	require.NoError(t, os.MkdirAll(templatesDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templatesDir, "_partial.html.twig"), []byte(""), 0o644))
+	templatePath := filepath.Join(templatesDir, "show.html.twig")
+
+	content := "<div>\n    <h1>{{ post.title }}</h1>\n    <p>{{ post.body }}</p>\n</div>\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: tmpDir,
+		Roots:         []string{"templates"},
+		BundleRoots:   make(map[string][]string),
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	uri := protocol.DocumentUri(utils.PathToURI(templatePath))
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 1, Character: 0},
+			End:   protocol.Position{Line: 3, Character: 0},
+		},
+	}
+
+	actions, err := an.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	require.Equal(t, "Extract to template", actions[0].Title)
+
+	changes := actions[0].Resolve().Edit.DocumentChanges
+	require.Len(t, changes, 3)
+
+	partialURI := protocol.DocumentUri(utils.PathToURI(filepath.Join(templatesDir, "_partial_2.html.twig")))
+	require.Equal(t, protocol.CreateFile{Kind: "create", URI: partialURI}, changes[0])
+
+	partialEdit := changes[1].(protocol.TextDocumentEdit)
+	require.Equal(t, "<h1>{{ post.title }}</h1>\n<p>{{ post.body }}</p>\n", partialEdit.Edits[0].(protocol.TextEdit).NewText)
+
+	includeEdit := changes[2].(protocol.TextDocumentEdit)
+	require.Equal(t, uri, includeEdit.TextDocument.URI)
+	require.Equal(t, "    {{ include('blog/_partial_2.html.twig') }}\n", includeEdit.Edits[0].(protocol.TextEdit).NewText)
+}
+
+func TestNewPartialPathGivesUp(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	// Stat fails with ENOTDIR, which is not a missing file
+	_, ok := newPartialPath(file)
+	require.False(t, ok)
+
+	for i := 1; i <= maxPartialAttempts; i++ {
+		name := "_partial.html.twig"
+		if i > 1 {
+			name = fmt.Sprintf("_partial_%d.html.twig", i)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	_, ok = newPartialPath(dir)
+	require.False(t, ok)
+}
+
+func TestTwigFilterCompletion(t *testing.T) {
+	content := "{{ name|u }}\n{{ 'a|up' }}\n{{ price|pri }}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		TwigFilters: map[string]config.TwigCallable{
+			"price_eur": {Class: "App\\Twig\\PriceExtension"},
+			"upper":     {Class: "App\\Twig\\UpperExtension"},
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	filters := completionDetailsAt(t, an, protocol.Position{Line: 0, Character: 9})
+	require.Equal(t, "App\\Twig\\UpperExtension", filters["upper"])
+	require.Equal(t, "Twig\\Extension\\CoreExtension", filters["url_encode"])
+	require.NotContains(t, filters, "lower")
+
+	require.NotContains(t, completionDetailsAt(t, an, protocol.Position{Line: 1, Character: 9}), "upper")
+
+	require.Equal(t, "App\\Twig\\PriceExtension", completionDetailsAt(t, an, protocol.Position{Line: 2, Character: 12})["price_eur"])
+}
+
+func TestTwigTestCompletion(t *testing.T) {
+	content := "{% if items is e %}\n{% if n is not divisible b %}\n{% if this is %}\n{{ 'a is d' }}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		TwigTests: map[string]config.TwigCallable{
+			"expired": {Class: "App\\Twig\\DateExtension"},
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	tests := completionDetailsAt(t, an, protocol.Position{Line: 0, Character: 16})
+	require.Equal(t, "Twig\\Extension\\CoreExtension", tests["empty"])
+	require.Equal(t, "Twig\\Extension\\CoreExtension", tests["even"])
+	require.Equal(t, "App\\Twig\\DateExtension", tests["expired"])
+	require.NotContains(t, tests, "defined")
+
+	require.Contains(t, completionDetailsAt(t, an, protocol.Position{Line: 1, Character: 26}), "divisible by")
+	require.Empty(t, completionDetailsAt(t, an, protocol.Position{Line: 2, Character: 11}))
+	require.NotContains(t, completionDetailsAt(t, an, protocol.Position{Line: 3, Character: 10}), "defined")
+}
+
+func TestTwigBlockCompletion(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatesDir := filepath.Join(tmpDir, "templates")
+	require.NoError(t, os.MkdirAll(templatesDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templatesDir, "base.html.twig"), []byte(
+		"<title>{% block title %}{% endblock %}</title>\n{% block body %}{% endblock %}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(templatesDir, "layout.html.twig"), []byte(
+		"{% extends 'base.html.twig' %}\n{% block body %}{% block sidebar %}{% endblock %}{% endblock %}\n"), 0o644))
+
+	content := "{% extends 'layout.html.twig' %}\n{% block  %}\n{%- block s %}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: tmpDir,
+		Roots:         []string{"templates"},
+		BundleRoots:   make(map[string][]string),
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	require.Equal(t, map[string]string{
+		"body":    "layout.html.twig",
+		"sidebar": "layout.html.twig",
+		"title":   "base.html.twig",
+	}, completionDetailsAt(t, an, protocol.Position{Line: 1, Character: 9}))
+	require.Equal(t, map[string]string{"sidebar": "layout.html.twig"}, completionDetailsAt(t, an, protocol.Position{Line: 2, Character: 11}))
+}
+
+func TestTwigRenderVariableCompletion(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatePath := filepath.Join(tmpDir, "templates", "post", "show.html.twig")
+
+	content := "{{ po }}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: tmpDir,
+		Roots:         []string{"templates"},
+		BundleRoots:   make(map[string][]string),
+		TemplateVariables: map[string][]config.TemplateVariable{
+			"post/show.html.twig": {
+				{Name: "comments", Controller: "App\\Controller\\PostController::show"},
+				{Name: "post", Controller: "App\\Controller\\PostController::show"},
+				{Name: "post", Controller: "App\\Controller\\AdminController::preview"},
+			},
+		},
+	})
+	an.SetDocumentPath(templatePath)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), protocol.Position{Line: 0, Character: 5})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "post", items[0].Label)
+	require.Equal(t, "App\\Controller\\PostController::show, App\\Controller\\AdminController::preview", *items[0].Detail)
+}
+
+func TestTwigMacroCompletion(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatesDir := filepath.Join(tmpDir, "templates")
+	require.NoError(t, os.MkdirAll(templatesDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templatesDir, "forms.html.twig"), []byte(
+		"{% macro input(name, value,\n    type = \"text\") %}{% endmacro %}\n{%- macro textarea(name) -%}{% endmacro %}\n"), 0o644))
+
+	content := "{% import 'forms.html.twig' as forms %}\n{{ forms.in }}\n{% import _self as self %}{% macro row(label) %}{% endmacro %}\n{{ self. }}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: tmpDir,
+		Roots:         []string{"templates"},
+		BundleRoots:   make(map[string][]string),
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	require.Equal(t, map[string]string{"input": `input(name, value, type = "text")`}, completionDetailsAt(t, an, protocol.Position{Line: 1, Character: 11}))
+	require.Equal(t, map[string]string{"row": "row(label)"}, completionDetailsAt(t, an, protocol.Position{Line: 3, Character: 8}))
+}
+
+func TestTwigConstantCompletion(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("src/Entity/Order.php", `<?php
+
+namespace App\Entity;
+
+class Order
+{
+    public const STATUS_NEW = 'new';
+    public const STATUS_PAID = 'paid', LIMIT = 10;
+    private string $status = self::STATUS_NEW;
+}
+`)
+	write("src/Enum/Suit.php", `<?php
+
+namespace App\Enum;
+
+enum Suit: string
+{
+    case Hearts = 'H';
+    case Spades = 'S';
+}
+`)
+
+	content := `{{ constant('App\\Entity\\Order::STATUS_') }}
+{{ constant('\\App\\Enum\\Suit::') }}
+{{ constant('App\\Entity\\Order') }}
+`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"App\\": {"src"}}}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, root)
+	an.SetDocumentStore(store)
+	an.SetContainerConfig(&config.ContainerConfig{})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	require.Equal(t, map[string]string{"STATUS_NEW": "'new'", "STATUS_PAID": "'paid'"}, completionDetailsAt(t, an, twigPositionAfter(t, content, "STATUS_'", len("STATUS_"))))
+	require.Equal(t, map[string]string{"Hearts": "'H'", "Spades": "'S'"}, completionDetailsAt(t, an, twigPositionAfter(t, content, "Suit::", len("Suit::"))))
+	require.Empty(t, completionDetailsAt(t, an, twigPositionAfter(t, content, "Order')", len("Order"))))
+}
+
+func TestTwigAssetCompletion(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "public", "images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "public", "images", "logo.svg"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "public", "robots.txt"), nil, 0o644))
+
+	content := "<img src=\"{{ asset('images/') }}\">\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{WorkspaceRoot: root})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), protocol.Position{Line: 0, Character: uint32(strings.Index(content, "/'") + 1)})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "images/logo.svg", items[0].Label)
+}
+
+func TestTwigImportMapCompletion(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "importmap.php"), []byte(`<?php
+return [
+    'app' => ['path' => './assets/app.js', 'entrypoint' => true],
+    'admin' => ['path' => './assets/admin.js', 'entrypoint' => true],
+    '@hotwired/stimulus' => ['version' => '3.2.2'],
+];
+`), 0o644))
+
+	content := "{{ importmap('a') }}\n{{ importmap('@') }}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{WorkspaceRoot: root})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	require.Equal(t, map[string]string{"app": "./assets/app.js", "admin": "./assets/admin.js"}, completionDetailsAt(t, an, protocol.Position{Line: 0, Character: 15}))
+	require.Equal(t, map[string]string{"@hotwired/stimulus": "@hotwired/stimulus@3.2.2"}, completionDetailsAt(t, an, protocol.Position{Line: 1, Character: 15}))
+}
+
+func TestTwigComponentCompletion(t *testing.T) {
+	content := "{{ component('Al') }}\n{% component Fo %}{% endcomponent %}\n<twig:\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		TwigComponents: map[string]config.TwigComponent{
+			"Alert":      {Name: "Alert", Class: "App\\Twig\\Components\\Alert", Template: "components/Alert.html.twig"},
+			"Form:Input": {Name: "Form:Input", Template: "components/Form/Input.html.twig"},
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	require.Equal(t, map[string]string{"Alert": "App\\Twig\\Components\\Alert"}, completionDetailsAt(t, an, protocol.Position{Line: 0, Character: 16}))
+	require.Equal(t, map[string]string{"Form:Input": "components/Form/Input.html.twig"}, completionDetailsAt(t, an, protocol.Position{Line: 1, Character: 15}))
+	require.Len(t, completionDetailsAt(t, an, protocol.Position{Line: 2, Character: 6}), 2)
+}
+
+func TestTwigComponentProps(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("src/Twig/Components/Alert.php", `<?php
+
+namespace App\Twig\Components;
+
+#[AsTwigComponent]
+class Alert
+{
+    public string $type = 'success';
+    public string $message;
+    private bool $dismissed = false;
+
+    public function mount(bool $dismissible = false): void
+    {
+    }
+}
+`)
+	write("templates/components/Alert.html.twig", "<div></div>\n")
+	write("templates/components/Button.html.twig", "{% props label, variant = 'primary' %}\n<button>{{ label }}</button>\n")
+
+	content := `<twig:Alert type="info" m
+{% component Alert with { type: 'info', d
+<twig:Button 
+{{ component('Alert') }}
+`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"App\\": {"src"}}}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, root)
+	an.SetDocumentStore(store)
+	classPath := filepath.Join(root, "src/Twig/Components/Alert.php")
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: root,
+		Roots:         []string{"templates"},
+		TwigComponents: map[string]config.TwigComponent{
+			"Alert": {
+				Name:     "Alert",
+				Class:    "App\\Twig\\Components\\Alert",
+				Template: "components/Alert.html.twig",
+				Location: protocol.Location{URI: protocol.DocumentUri(utils.PathToURI(classPath))},
+			},
+			"Button": {Name: "Button", Template: "components/Button.html.twig"},
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	require.Equal(t, []string{"message"}, completionLabelsAt(t, an, protocol.Position{Line: 0, Character: 25}))
+	require.Equal(t, []string{"dismissible"}, completionLabelsAt(t, an, protocol.Position{Line: 1, Character: 41}))
+	require.ElementsMatch(t, []string{"label", "variant"}, completionLabelsAt(t, an, protocol.Position{Line: 2, Character: 13}))
+	require.Empty(t, completionLabelsAt(t, an, protocol.Position{Line: 0, Character: 20}))
+
+	locs, err := an.OnDefinition(context.Background(), protocol.Position{Line: 3, Character: 16})
+	require.NoError(t, err)
+	require.Len(t, locs, 2)
+	assert.Equal(t, utils.PathToURI(classPath), string(locs[0].URI))
+	assert.Equal(t, utils.PathToURI(filepath.Join(root, "templates/components/Alert.html.twig")), string(locs[1].URI))
+
+	locs, err = an.OnDefinition(context.Background(), protocol.Position{Line: 2, Character: 8})
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+}
+
+func TestTwigFormFields(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("src/Form/PostType.php", `<?php
+
+namespace App\Form;
+
+class PostType extends AbstractType
+{
+    public function buildForm(FormBuilderInterface $builder, array $options): void
+    {
+        $builder
+            ->add('title', TextType::class)
+            ->add('body', TextareaType::class)
+            ->add('tags');
+    }
+}
+`)
+
+	content := "{{ form_row(form.t) }}\n{{ form_widget(form. }}\n{{ form_row(post.t) }}\n{{ form_label(form.body) }}\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"App\\": {"src"}}}
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, root)
+	an.SetDocumentStore(store)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: root,
+		Roots:         []string{"templates"},
+		BundleRoots:   make(map[string][]string),
+		TemplateVariables: map[string][]config.TemplateVariable{
+			"post/edit.html.twig": {
+				{Name: "form", Value: "$form", FormType: "App\\Form\\PostType"},
+				{Name: "post", Value: "$post"},
+			},
+		},
+	})
+	an.SetDocumentPath(filepath.Join(root, "templates", "post", "edit.html.twig"))
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	field := protocol.CompletionItemKindField
+	require.Equal(t, map[string]string{"title": "TextType", "tags": ""}, completionDetailsAt(t, an, protocol.Position{Line: 0, Character: 18}, field))
+	require.Len(t, completionDetailsAt(t, an, protocol.Position{Line: 1, Character: 20}, field), 3)
+	require.Empty(t, completionDetailsAt(t, an, protocol.Position{Line: 2, Character: 18}, field))
+
+	locs, err := an.OnDefinition(context.Background(), protocol.Position{Line: 3, Character: 21})
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	assert.Equal(t, utils.PathToURI(filepath.Join(root, "src/Form/PostType.php")), string(locs[0].URI))
+	assert.Equal(t, uint32(10), locs[0].Range.Start.Line)
+	assert.Equal(t, uint32(18), locs[0].Range.Start.Character)
+
+	locs, err = an.OnDefinition(context.Background(), protocol.Position{Line: 2, Character: 17})
+	require.NoError(t, err)
+	require.Empty(t, locs)
+}
+
+func TestTwigImagineFilterCompletion(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "config", "packages"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "packages", "liip_imagine.yaml"), []byte(
+		"liip_imagine:\n    filter_sets:\n        squared_thumbnail:\n            filters:\n                thumbnail: { size: [120, 90] }\n        banner: ~\n"), 0o644))
+
+	content := "<img src=\"{{ asset(image)|imagine_filter('squ') }}\">\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{WorkspaceRoot: root})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), protocol.Position{Line: 0, Character: 45})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "squared_thumbnail", items[0].Label)
+	require.Equal(t, "thumbnail 120x90", *items[0].Detail)
+}
+
+func TestTwigTagSnippetCompletion(t *testing.T) {
+	content := "{% bl %}\n{%- fo\n"
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	snippetsAt := func(line, character uint32) []protocol.CompletionItem {
+		items, err := an.OnCompletion(context.Background(), protocol.Position{Line: line, Character: character})
+		require.NoError(t, err)
+		var snippets []protocol.CompletionItem
+		for _, item := range items {
+			if item.Kind != nil && *item.Kind == protocol.CompletionItemKindSnippet {
+				snippets = append(snippets, item)
+			}
+		}
+		return snippets
+	}
+
+	// Snippets are only offered to clients that support them
+	require.Empty(t, snippetsAt(0, 5))
+	an.SetSnippetSupport(true)
+
+	items := snippetsAt(0, 5)
+	require.Len(t, items, 1)
+	require.Equal(t, "block", items[0].Label)
+	edit := items[0].TextEdit.(protocol.TextEdit)
+	require.Equal(t, protocol.Range{Start: protocol.Position{Line: 0, Character: 0}, End: protocol.Position{Line: 0, Character: 8}}, edit.Range)
+	require.Equal(t, "{% block ${1:name} %}\n\t$0\n{% endblock %}", edit.NewText)
+
+	items = snippetsAt(1, 6)
+	require.Len(t, items, 1)
+	edit = items[0].TextEdit.(protocol.TextEdit)
+	require.Equal(t, protocol.Position{Line: 1, Character: 6}, edit.Range.End)
+	require.Equal(t, "{%- for ${1:item} in ${2:items} %}\n\t$0\n{% endfor %}", edit.NewText)
+	require.Equal(t, "{%- for", *items[0].FilterText)
+}
+
+func TestTwigRoutePathInlayHints(t *testing.T) {
+	content := "<a href=\"{{ path('app_order_show', {id: 1}) }}\">{{ url('unknown') }}</a>\n"
+
+	analyzer := NewTwigAnalyzer().(*twigAnalyzer)
+	routes := config.RoutesMap{
+		"app_order_show": {Name: "app_order_show", Parameters: []string{"id"}, Path: "/orders/{id}"},
+	}
+	analyzer.SetRoutes(&routes)
+	require.NoError(t, analyzer.Changed([]byte(content), nil))
+
+	hints, err := analyzer.OnInlayHints(protocol.Range{End: protocol.Position{Line: 1}})
+	require.NoError(t, err)
+	require.Len(t, hints, 1)
+	assert.Equal(t, "➜ /orders/{id}", hints[0].Label)
+	assert.Equal(t, protocol.Position{Line: 0, Character: uint32(strings.Index(content, "', {id")) + 1}, hints[0].Position)
+	assert.Nil(t, hints[0].Tooltip)
+}
+
+func TestTwigRouteSignatureHelp(t *testing.T) {
+	content := "{{ path('app_blog_list', {slug: 'news', p}) }}\n{{ url('app_blog_list', {slug: 'news'}) }}\n"
+
+	analyzer := NewTwigAnalyzer().(*twigAnalyzer)
+	routes := config.RoutesMap{
+		"app_blog_list": {
+			Name:       "app_blog_list",
+			Parameters: []string{"slug", "page"},
+			Optional:   []string{"page"},
+			Controller: "App\\Controller\\BlogController",
+			Action:     "list",
+			Path:       "/blog/{slug}/{page}",
+		},
+	}
+	analyzer.SetRoutes(&routes)
+	require.NoError(t, analyzer.Changed([]byte(content), nil))
+
+	caret := protocol.Position{Line: 0, Character: uint32(strings.Index(content, "p}") + 1)}
+	help, err := analyzer.OnSignatureHelp(caret)
+	require.NoError(t, err)
+	require.NotNil(t, help)
+	assert.Equal(t, "path('app_blog_list', {slug, page?})", help.Signatures[0].Label)
+	assert.Equal(t, protocol.MarkupContent{
+		Kind:  protocol.MarkupKindMarkdown,
+		Value: "`/blog/{slug}/{page}`\n\n`App\\Controller\\BlogController::list`",
+	}, help.Signatures[0].Documentation)
+	require.NotNil(t, help.ActiveParameter)
+	assert.Equal(t, protocol.UInteger(1), *help.ActiveParameter)
+
+	// Every required parameter is given
+	help, err = analyzer.OnSignatureHelp(protocol.Position{Line: 1, Character: 10})
+	require.NoError(t, err)
+	require.NotNil(t, help)
+	assert.Equal(t, "url('app_blog_list', {slug, page?})", help.Signatures[0].Label)
+	assert.Nil(t, help.ActiveParameter)
+
+	help, err = analyzer.OnSignatureHelp(protocol.Position{Line: 0, Character: 1})
+	require.NoError(t, err)
+	assert.Nil(t, help)
+}
+
+func TestTwigFoldingRanges(t *testing.T) {
+	content := []byte(`
+{# A comment
+   over two lines #}
+{% block title 'Inline' %}
+{% block body %}
+  {% for post in posts %}
+    {% if post.visible %}
+      {{ post.title }}
+    {% else %}
+      Hidden
+    {% endif %}
+  {% endfor %}
+  {% embed 'card.html.twig' %}
+    {% block content %}{% endblock %}
+  {% endembed %}
+{% endblock %}
+`)
+
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	require.NoError(t, an.Changed(content, nil))
+
+	ranges, err := an.OnFoldingRanges()
+	require.NoError(t, err)
+
+	type fold struct{ start, end protocol.UInteger }
+	var folds []fold
+	for _, r := range ranges {
+		folds = append(folds, fold{r.StartLine, r.EndLine})
+	}
+	assert.ElementsMatch(t, []fold{{1, 2}, {6, 9}, {5, 10}, {12, 13}, {4, 14}}, folds)
+	require.NotNil(t, ranges[0].Kind)
+	assert.Equal(t, "comment", *ranges[0].Kind)
+}
+
+func TestTwigDocumentLinks(t *testing.T) {
+	content := []byte(`{% extends 'template.html.twig' %}
+{{ include('missing.html.twig') }}
+{{ 'text' }}
+`)
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: mockRoot,
+		Roots:         []string{"."},
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	links, err := an.OnDocumentLinks()
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 0, Character: 12},
+		End:   protocol.Position{Line: 0, Character: 30},
+	}, links[0].Range)
+	require.Equal(t, protocol.DocumentUri(utils.PathToURI(filepath.Join(mockRoot, "template.html.twig"))), *links[0].Target)
+}
@@ -0,0 +1,32 @@
+package analyzer
+
+import (
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Completes asset('...') with the files of the public directory and the
+// logical paths of the AssetMapper manifest
+func (a *twigAnalyzer) assetCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	ctx, ok := a.callContextAt(pos, "asset")
+	if !ok || ctx.argIndex != 0 {
+		return nil
+	}
+
+	prefix := a.stringPrefix(ctx.strNode, pos)
+	kind := protocol.CompletionItemKindFile
+	items := []protocol.CompletionItem{}
+	for _, asset := range a.container.Assets() {
+		if !strings.HasPrefix(asset.Path, prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: asset.Path, Kind: &kind}
+		if asset.Target != "" {
+			detail := asset.Target
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	twiglib "github.com/shinyvision/vimfony/internal/twig"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var twigBlockPrefixRe = regexp.MustCompile(`\{%-?\s*block\s+([A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Completes {% block ... %} with the blocks declared by the parent templates
+func (a *twigAnalyzer) blockCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return nil
+	}
+	m := twigBlockPrefixRe.FindSubmatch(a.content[:offset])
+	if m == nil {
+		return nil
+	}
+	prefix := string(m[1])
+
+	kind := protocol.CompletionItemKindModule
+	items := []protocol.CompletionItem{}
+	for i, block := range twiglib.InheritedBlocks(string(a.content), a.container) {
+		if !strings.HasPrefix(block.Name, prefix) {
+			continue
+		}
+		detail := block.Template
+		// Closest parents first
+		sortText := fmt.Sprintf("%03d", i)
+		items = append(items, protocol.CompletionItem{Label: block.Name, Kind: &kind, Detail: &detail, SortText: &sortText})
+	}
+	return items
+}
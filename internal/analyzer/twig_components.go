@@ -0,0 +1,272 @@
+package analyzer
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/config"
+	php "github.com/shinyvision/vimfony/internal/php"
+	twiglib "github.com/shinyvision/vimfony/internal/twig"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var (
+	twigComponentTagPrefixRe  = regexp.MustCompile(`\{%-?\s*component\s+([A-Za-z0-9_:]*)$`)
+	twigComponentHTMLPrefixRe = regexp.MustCompile(`<twig:([A-Za-z0-9_:]*)$`)
+)
+
+// Completes component names in component('...'), {% component ... %} and
+// <twig:...> tags
+func (a *twigAnalyzer) componentCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	prefix, ok := a.componentPrefixAt(pos)
+	if !ok {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindClass
+	items := []protocol.CompletionItem{}
+	for name, component := range a.container.TwigComponents {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		detail := component.Class
+		if detail == "" {
+			detail = component.Template
+		}
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: &detail})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+func (a *twigAnalyzer) componentPrefixAt(pos protocol.Position) (string, bool) {
+	if ctx, ok := a.callContextAt(pos, "component"); ok {
+		return a.stringPrefix(ctx.strNode, pos), ctx.argIndex == 0
+	}
+
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return "", false
+	}
+	before := a.content[:offset]
+	if m := twigComponentTagPrefixRe.FindSubmatch(before); m != nil {
+		return string(m[1]), true
+	}
+	if m := twigComponentHTMLPrefixRe.FindSubmatch(before); m != nil {
+		return string(m[1]), true
+	}
+	return "", false
+}
+
+var (
+	twigComponentPropsTagRe   = regexp.MustCompile(`\{%-?\s*props\s+([^%]*)-?%\}`)
+	twigComponentHTMLAttrRe   = regexp.MustCompile(`<twig:([A-Za-z0-9_:]+)((?:\s[^<>]*)?)\s:?([A-Za-z_][A-Za-z0-9_-]*)?$`)
+	twigComponentWithHashRe   = regexp.MustCompile(`\{%-?\s*component\s+['"]?([A-Za-z0-9_:]+)['"]?\s+with\s+\{([^}]*)$`)
+	twigComponentHashKeyRe    = regexp.MustCompile(`(?:^|,)\s*['"]?([A-Za-z_][A-Za-z0-9_]*)?$`)
+	twigComponentAttrNameRe   = regexp.MustCompile(`:?([A-Za-z_][A-Za-z0-9_-]*)\s*=`)
+	twigComponentHashSetKeyRe = regexp.MustCompile(`['"]?([A-Za-z_][A-Za-z0-9_]*)['"]?\s*:`)
+	twigComponentNameRefRes   = []*regexp.Regexp{
+		regexp.MustCompile(`</?twig:([A-Za-z0-9_:]+)`),
+		regexp.MustCompile(`\bcomponent\(\s*['"]([A-Za-z0-9_:]+)['"]`),
+		regexp.MustCompile(`\{%-?\s*component\s+['"]?([A-Za-z0-9_:]+)`),
+	}
+)
+
+type componentProp struct {
+	name   string
+	detail string
+}
+
+// Completes the props of the component in <twig:Alert ...> attributes and in
+// the `with {...}` hash of {% component %}
+func (a *twigAnalyzer) componentPropCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	component, prefix, used, ok := a.componentPropContextAt(pos)
+	if !ok {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindProperty
+	items := []protocol.CompletionItem{}
+	for _, prop := range a.componentProps(component) {
+		if !strings.HasPrefix(prop.name, prefix) || slices.Contains(used, prop.name) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: prop.name, Kind: &kind}
+		if prop.detail != "" {
+			detail := prop.detail
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Returns the component, the partial prop name and the props already set
+func (a *twigAnalyzer) componentPropContextAt(pos protocol.Position) (config.TwigComponent, string, []string, bool) {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return config.TwigComponent{}, "", nil, false
+	}
+	before := a.content[:offset]
+
+	var name, prefix string
+	var used []string
+	if m := twigComponentHTMLAttrRe.FindSubmatch(before); m != nil {
+		attrs := string(m[2])
+		// The caret must not be inside an attribute value
+		if strings.Count(attrs, `"`)%2 != 0 || strings.Count(attrs, "'")%2 != 0 {
+			return config.TwigComponent{}, "", nil, false
+		}
+		name, prefix = string(m[1]), string(m[3])
+		for _, attr := range twigComponentAttrNameRe.FindAllStringSubmatch(attrs, -1) {
+			used = append(used, attr[1])
+		}
+	} else if m := twigComponentWithHashRe.FindSubmatch(before); m != nil {
+		key := twigComponentHashKeyRe.FindSubmatch(m[2])
+		if key == nil {
+			return config.TwigComponent{}, "", nil, false
+		}
+		name, prefix = string(m[1]), string(key[1])
+		for _, k := range twigComponentHashSetKeyRe.FindAllStringSubmatch(string(m[2]), -1) {
+			used = append(used, k[1])
+		}
+	} else {
+		return config.TwigComponent{}, "", nil, false
+	}
+
+	component, ok := a.container.TwigComponents[name]
+	return component, prefix, used, ok
+}
+
+// Collects the public properties and mount() parameters of the component
+// class, or the {% props %} of an anonymous component
+func (a *twigAnalyzer) componentProps(component config.TwigComponent) []componentProp {
+	if component.Class == "" {
+		path, ok := twiglib.Resolve(component.Template, a.container)
+		if !ok {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		var props []componentProp
+		for _, m := range twigComponentPropsTagRe.FindAllStringSubmatch(string(data), -1) {
+			for _, prop := range strings.Split(m[1], ",") {
+				name, value, _ := strings.Cut(prop, "=")
+				if name = strings.TrimSpace(name); name != "" {
+					props = append(props, componentProp{name: name, detail: strings.TrimSpace(value)})
+				}
+			}
+		}
+		return props
+	}
+
+	if a.docStore == nil {
+		return nil
+	}
+	path, _, ok := php.Resolve(a.docStore, component.Class)
+	if !ok {
+		return nil
+	}
+	doc, err := a.docStore.Get(path)
+	if err != nil || doc == nil {
+		return nil
+	}
+
+	var props []componentProp
+	seen := make(map[string]struct{})
+	add := func(name, typ string) {
+		if _, ok := seen[name]; ok || name == "" {
+			return
+		}
+		seen[name] = struct{}{}
+		props = append(props, componentProp{name: name, detail: typ})
+	}
+	typeOf := func(n sitter.Node, content []byte) string {
+		if typ := n.ChildByFieldName("type"); !typ.IsNull() {
+			return typ.Content(content)
+		}
+		return ""
+	}
+
+	doc.Read(func(tree *sitter.Tree, content []byte, _ php.IndexedTree) {
+		if tree == nil {
+			return
+		}
+		walkNodes(tree.RootNode(), func(n sitter.Node) {
+			switch n.Type() {
+			case "property_declaration":
+				if !isPublicMember(n, content) {
+					return
+				}
+				for i := uint32(0); i < n.NamedChildCount(); i++ {
+					if element := n.NamedChild(i); element.Type() == "property_element" {
+						add(strings.TrimPrefix(element.NamedChild(0).Content(content), "$"), typeOf(n, content))
+					}
+				}
+			case "method_declaration":
+				name := n.ChildByFieldName("name")
+				params := n.ChildByFieldName("parameters")
+				if name.IsNull() || params.IsNull() || name.Content(content) != "mount" {
+					return
+				}
+				for i := uint32(0); i < params.NamedChildCount(); i++ {
+					param := params.NamedChild(i)
+					if varName := param.ChildByFieldName("name"); !varName.IsNull() {
+						add(strings.TrimPrefix(varName.Content(content), "$"), typeOf(param, content))
+					}
+				}
+			}
+		})
+	})
+	return props
+}
+
+func isPublicMember(n sitter.Node, content []byte) bool {
+	for i := uint32(0); i < n.NamedChildCount(); i++ {
+		if child := n.NamedChild(i); child.Type() == "visibility_modifier" {
+			return child.Content(content) == "public"
+		}
+	}
+	// Members without a modifier are public
+	return true
+}
+
+// Resolves the component named under the caret to its class and template
+func (a *twigAnalyzer) resolveComponentDefinition(pos protocol.Position) ([]protocol.Location, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.container == nil || int(pos.Line) >= strings.Count(string(a.content), "\n")+1 {
+		return nil, false
+	}
+
+	line := strings.Split(string(a.content), "\n")[pos.Line]
+	caret := lspPosToByteOffset([]byte(line), protocol.Position{Character: pos.Character})
+	name := ""
+	for _, re := range twigComponentNameRefRes {
+		for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+			if caret >= m[2] && caret <= m[3] {
+				name = line[m[2]:m[3]]
+			}
+		}
+	}
+	component, ok := a.container.TwigComponents[name]
+	if !ok {
+		return nil, false
+	}
+
+	var locs []protocol.Location
+	if component.Class != "" {
+		locs = append(locs, component.Location)
+	}
+	if path, ok := twiglib.Resolve(component.Template, a.container); ok {
+		locs = append(locs, protocol.Location{URI: protocol.DocumentUri(utils.PathToURI(path))})
+	}
+	return locs, len(locs) > 0
+}
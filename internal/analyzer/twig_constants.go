@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"strings"
+
+	php "github.com/shinyvision/vimfony/internal/php"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Completes the constants of the referenced class in
+// constant('App\\Entity\\Order::STATUS_')
+func (a *twigAnalyzer) constantCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	ctx, ok := a.callContextAt(pos, "constant")
+	if !ok || ctx.argIndex != 0 || a.docStore == nil {
+		return nil
+	}
+
+	class, prefix, ok := strings.Cut(a.stringPrefix(ctx.strNode, pos), "::")
+	if !ok {
+		return nil
+	}
+	// Twig strings escape the namespace separators
+	class = strings.TrimPrefix(strings.ReplaceAll(class, "\\\\", "\\"), "\\")
+
+	kind := protocol.CompletionItemKindConstant
+	items := []protocol.CompletionItem{}
+	for _, constant := range php.ClassConstants(a.docStore, class) {
+		if !strings.HasPrefix(constant.Name, prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: constant.Name, Kind: &kind}
+		if constant.Value != "" {
+			detail := constant.Value
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	return items
+}
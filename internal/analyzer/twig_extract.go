@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	twiglib "github.com/shinyvision/vimfony/internal/twig"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// "Extract to template" moves the selected markup into a new partial next to
+// the current template and includes it in its place.
+func (a *twigAnalyzer) extractTemplateCodeActions(params *protocol.CodeActionParams) []CodeAction {
+	if params.Range.Start == params.Range.End {
+		return nil
+	}
+
+	a.mu.RLock()
+	container := a.container
+	content := a.content
+	features := a.features
+	a.mu.RUnlock()
+
+	if container == nil || !features.Enabled(config.FeatureTemplates) {
+		return nil
+	}
+
+	start := offsetAt(content, params.Range.Start)
+	end := offsetAt(content, params.Range.End)
+	if start < 0 || end < 0 || start >= end {
+		return nil
+	}
+	selected := string(content[start:end])
+	if strings.TrimSpace(selected) == "" {
+		return nil
+	}
+
+	// Only templates in a twig path can include the partial
+	dir := filepath.Dir(utils.UriToPath(string(params.TextDocument.URI)))
+	if _, ok := twiglib.TemplateName(filepath.Join(dir, "_partial.html.twig"), container); !ok {
+		return nil
+	}
+
+	return []CodeAction{newCodeAction("Extract to template", protocol.CodeActionKindRefactorExtract, func() *protocol.WorkspaceEdit {
+		partialPath, ok := newPartialPath(dir)
+		if !ok {
+			return nil
+		}
+		name, ok := twiglib.TemplateName(partialPath, container)
+		if !ok {
+			return nil
+		}
+
+		// Keep the include on its own line when whole lines are extracted
+		wholeLines := params.Range.Start.Character == 0 && strings.HasSuffix(selected, "\n")
+		partial, indent := dedent(selected, params.Range.Start.Character == 0)
+		include := fmt.Sprintf("{{ include('%s') }}", name)
+		if wholeLines {
+			include = indent + include + "\n"
+		}
+		if !strings.HasSuffix(partial, "\n") {
+			partial += "\n"
+		}
+
+		partialURI := protocol.DocumentUri(utils.PathToURI(partialPath))
+		return &protocol.WorkspaceEdit{
+			DocumentChanges: []any{
+				protocol.CreateFile{Kind: "create", URI: partialURI},
+				protocol.TextDocumentEdit{
+					TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+						TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: partialURI},
+					},
+					Edits: []any{protocol.TextEdit{NewText: partial}},
+				},
+				protocol.TextDocumentEdit{
+					TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+						TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: params.TextDocument.URI},
+					},
+					Edits: []any{protocol.TextEdit{
+						Range:   params.Range,
+						NewText: include,
+					}},
+				},
+			},
+		}
+	})}
+}
+
+// maxPartialAttempts bounds the names tried by newPartialPath
+const maxPartialAttempts = 100
+
+// Picks _partial.html.twig, or _partial_2.html.twig and so on when taken.
+// Gives up when the directory cannot be read or all names are taken.
+func newPartialPath(dir string) (string, bool) {
+	for i := 1; i <= maxPartialAttempts; i++ {
+		name := "_partial.html.twig"
+		if i > 1 {
+			name = fmt.Sprintf("_partial_%d.html.twig", i)
+		}
+		path := filepath.Join(dir, name)
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return path, true
+		}
+		if err != nil {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// Removes the indentation shared by all non-empty lines and returns it. The
+// first line is ignored when the selection starts in the middle of it.
+func dedent(s string, fromLineStart bool) (string, string) {
+	lines := strings.Split(s, "\n")
+	common := ""
+	found := false
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" || (i == 0 && !fromLineStart) {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			common = indent
+			found = true
+			continue
+		}
+		for !strings.HasPrefix(indent, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if common == "" {
+		return s, ""
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, common)
+	}
+	return strings.Join(lines, "\n"), common
+}
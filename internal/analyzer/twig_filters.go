@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	twigCoreExtension        = "Twig\\Extension\\CoreExtension"
+	twigEscaperExtension     = "Twig\\Extension\\EscaperExtension"
+	twigStringLoaderExt      = "Twig\\Extension\\StringLoaderExtension"
+	twigBridgeTranslationExt = "Symfony\\Bridge\\Twig\\Extension\\TranslationExtension"
+	twigBridgeFormExt        = "Symfony\\Bridge\\Twig\\Extension\\FormExtension"
+	twigBridgeCodeExt        = "Symfony\\Bridge\\Twig\\Extension\\CodeExtension"
+	twigBridgeYamlExt        = "Symfony\\Bridge\\Twig\\Extension\\YamlExtension"
+	twigBridgeDumpExt        = "Symfony\\Bridge\\Twig\\Extension\\DumpExtension"
+	twigBridgeSerializerExt  = "Symfony\\Bridge\\Twig\\Extension\\SerializerExtension"
+	twigBridgeHtmlSanitizer  = "Symfony\\Bridge\\Twig\\Extension\\HtmlSanitizerExtension"
+)
+
+// Filters shipped with Twig and the Symfony Twig bridge, with the extension
+// class that provides them
+var builtinTwigFilters = map[string]string{
+	"abs":                   twigCoreExtension,
+	"batch":                 twigCoreExtension,
+	"capitalize":            twigCoreExtension,
+	"column":                twigCoreExtension,
+	"convert_encoding":      twigCoreExtension,
+	"date":                  twigCoreExtension,
+	"date_modify":           twigCoreExtension,
+	"default":               twigCoreExtension,
+	"filter":                twigCoreExtension,
+	"find":                  twigCoreExtension,
+	"first":                 twigCoreExtension,
+	"format":                twigCoreExtension,
+	"join":                  twigCoreExtension,
+	"json_encode":           twigCoreExtension,
+	"keys":                  twigCoreExtension,
+	"last":                  twigCoreExtension,
+	"length":                twigCoreExtension,
+	"lower":                 twigCoreExtension,
+	"map":                   twigCoreExtension,
+	"merge":                 twigCoreExtension,
+	"nl2br":                 twigCoreExtension,
+	"number_format":         twigCoreExtension,
+	"reduce":                twigCoreExtension,
+	"replace":               twigCoreExtension,
+	"reverse":               twigCoreExtension,
+	"round":                 twigCoreExtension,
+	"shuffle":               twigCoreExtension,
+	"slice":                 twigCoreExtension,
+	"sort":                  twigCoreExtension,
+	"spaceless":             twigCoreExtension,
+	"split":                 twigCoreExtension,
+	"striptags":             twigCoreExtension,
+	"title":                 twigCoreExtension,
+	"trim":                  twigCoreExtension,
+	"upper":                 twigCoreExtension,
+	"url_encode":            twigCoreExtension,
+	"e":                     twigEscaperExtension,
+	"escape":                twigEscaperExtension,
+	"raw":                   twigEscaperExtension,
+	"template_from_string":  twigStringLoaderExt,
+	"trans":                 twigBridgeTranslationExt,
+	"humanize":              twigBridgeFormExt,
+	"form_encode_currency":  twigBridgeFormExt,
+	"abbr_class":            twigBridgeCodeExt,
+	"abbr_method":           twigBridgeCodeExt,
+	"file_excerpt":          twigBridgeCodeExt,
+	"file_link":             twigBridgeCodeExt,
+	"file_relative":         twigBridgeCodeExt,
+	"format_args":           twigBridgeCodeExt,
+	"format_args_as_text":   twigBridgeCodeExt,
+	"format_file":           twigBridgeCodeExt,
+	"format_file_from_text": twigBridgeCodeExt,
+	"format_log_message":    twigBridgeCodeExt,
+	"yaml_dump":             twigBridgeYamlExt,
+	"yaml_encode":           twigBridgeYamlExt,
+	"dump":                  twigBridgeDumpExt,
+	"serialize":             twigBridgeSerializerExt,
+	"sanitize_html":         twigBridgeHtmlSanitizer,
+}
+
+var twigFilterPrefixRe = regexp.MustCompile(`\|\s*([A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Completes filter names after `|` in an expression, both the built-in ones
+// and those registered by twig.extension services
+func (a *twigAnalyzer) filterCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	prefix, ok := a.filterPrefixAt(pos)
+	if !ok {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindFunction
+	items := []protocol.CompletionItem{}
+	add := func(name, class string) {
+		if !strings.HasPrefix(name, prefix) {
+			return
+		}
+		detail := class
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: &detail})
+	}
+
+	for name, filter := range a.container.TwigFilters {
+		add(name, filter.Class)
+	}
+	for name, class := range builtinTwigFilters {
+		if _, overridden := a.container.TwigFilters[name]; !overridden {
+			add(name, class)
+		}
+	}
+	return items
+}
+
+// Returns the partial filter name when the caret follows a `|` inside a
+// {{ }} or {% %} block, outside of string literals
+func (a *twigAnalyzer) filterPrefixAt(pos protocol.Position) (string, bool) {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return "", false
+	}
+
+	expr, ok := twigExpressionBefore(a.content[:offset])
+	if !ok {
+		return "", false
+	}
+	m := twigFilterPrefixRe.FindSubmatch(expr)
+	if m == nil {
+		return "", false
+	}
+	return string(m[1]), true
+}
+
+// Returns the text between the innermost open {{ or {% and the end of before,
+// if that text is an unterminated expression outside of a string literal
+func twigExpressionBefore(before []byte) ([]byte, bool) {
+	open := max(bytes.LastIndex(before, []byte("{{")), bytes.LastIndex(before, []byte("{%")))
+	if open < 0 {
+		return nil, false
+	}
+	expr := before[open+2:]
+	if bytes.Contains(expr, []byte("}}")) || bytes.Contains(expr, []byte("%}")) {
+		return nil, false
+	}
+
+	var quote byte
+	for _, c := range expr {
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case c == quote:
+			quote = 0
+		}
+	}
+	return expr, quote == 0
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Matches the tag of a {% ... %} statement and what follows it
+var twigStatementTagRe = regexp.MustCompile(`^\{%[-~]?\s*(\w+)\s*(.*?)\s*[-~]?%\}$`)
+
+// Tags opening a region, by the tag closing it
+var twigFoldingTags = map[string]string{
+	"endblock": "block",
+	"endfor":   "for",
+	"endif":    "if",
+	"endembed": "embed",
+}
+
+// Folds the {% block %}, {% for %}, {% if %} and {% embed %} regions up to
+// the line before their end tag, and the comments spanning several lines.
+// The grammar does not nest statements, so the end tags are paired with the
+// open ones here.
+func (a *twigAnalyzer) OnFoldingRanges() ([]protocol.FoldingRange, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.tree == nil {
+		return nil, nil
+	}
+
+	type openTag struct {
+		tag  string
+		line uint
+	}
+	var open []openTag
+	ranges := []protocol.FoldingRange{}
+	commentKind := string(protocol.FoldingRangeKindComment)
+
+	walkNodes(a.tree.RootNode(), func(n sitter.Node) {
+		switch n.Type() {
+		case "comment":
+			if start, end := twigTagLine(n, a.content, "{#"), n.EndPoint().Row; end > start {
+				ranges = append(ranges, protocol.FoldingRange{
+					StartLine: protocol.UInteger(start),
+					EndLine:   protocol.UInteger(end),
+					Kind:      &commentKind,
+				})
+			}
+		case "statement_directive":
+			text := n.Content(a.content)
+			m := twigStatementTagRe.FindStringSubmatch(strings.TrimSpace(text))
+			if m == nil {
+				return
+			}
+			tag := m[1]
+			line := twigTagLine(n, a.content, "{%")
+			if opener, ok := twigFoldingTags[tag]; ok {
+				for i := len(open) - 1; i >= 0; i-- {
+					if open[i].tag != opener {
+						continue
+					}
+					start, end := open[i].line, line
+					if end > start+1 {
+						ranges = append(ranges, protocol.FoldingRange{
+							StartLine: protocol.UInteger(start),
+							EndLine:   protocol.UInteger(end - 1),
+						})
+					}
+					open = open[:i]
+					break
+				}
+				return
+			}
+			// {% block title 'Title' %} has no end tag
+			if tag == "block" && len(strings.Fields(m[2])) > 1 {
+				return
+			}
+			for _, opener := range twigFoldingTags {
+				if opener == tag {
+					open = append(open, openTag{tag: tag, line: line})
+					break
+				}
+			}
+		}
+	})
+	return ranges, nil
+}
+
+// Returns the line of the delimiter opening the node, which starts with the
+// whitespace before it
+func twigTagLine(n sitter.Node, content []byte, delimiter string) uint {
+	text := n.Content(content)
+	before, _, _ := strings.Cut(text, delimiter)
+	return n.StartPoint().Row + uint(strings.Count(before, "\n"))
+}
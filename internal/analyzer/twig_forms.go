@@ -0,0 +1,103 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	twiglib "github.com/shinyvision/vimfony/internal/twig"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var (
+	twigFormFieldPrefixRe = regexp.MustCompile(`\b(?:form_widget|form_row|form_label|form_errors|form_help|form_rest|field_[a-z_]+)\(\s*([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z_][A-Za-z0-9_]*)?$`)
+	twigMemberAccessRe    = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// Completes form_row(form.field) with the fields of the form type the
+// controller passes as `form` to render()
+func (a *twigAnalyzer) formFieldCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 || a.path == "" {
+		return nil
+	}
+	expr, ok := twigExpressionBefore(a.content[:offset])
+	if !ok {
+		return nil
+	}
+	m := twigFormFieldPrefixRe.FindSubmatch(expr)
+	if m == nil {
+		return nil
+	}
+	formType := a.templateFormType(string(m[1]))
+	if formType == "" {
+		return nil
+	}
+
+	prefix := string(m[2])
+	kind := protocol.CompletionItemKindField
+	items := []protocol.CompletionItem{}
+	for i, field := range formTypeFields(a.docStore, formType) {
+		if !strings.HasPrefix(field.name, prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: field.name, Kind: &kind}
+		if field.typ != "" {
+			detail := field.typ
+			item.Detail = &detail
+		}
+		// Keep the order of buildForm()
+		sortText := fmt.Sprintf("%03d", i)
+		item.SortText = &sortText
+		items = append(items, item)
+	}
+	return items
+}
+
+// Returns the form type of a variable controllers pass to this template
+func (a *twigAnalyzer) templateFormType(variable string) string {
+	name, ok := twiglib.TemplateName(a.path, a.container)
+	if !ok {
+		return ""
+	}
+	for _, v := range a.container.TemplateVariables[name] {
+		if v.Name == variable && v.FormType != "" {
+			return v.FormType
+		}
+	}
+	return ""
+}
+
+// Resolves form.field to the ->add('field', ...) call of the form type
+func (a *twigAnalyzer) resolveFormFieldDefinition(pos protocol.Position) ([]protocol.Location, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.container == nil || a.path == "" {
+		return nil, false
+	}
+
+	lines := strings.Split(string(a.content), "\n")
+	if int(pos.Line) >= len(lines) {
+		return nil, false
+	}
+	line := lines[pos.Line]
+	caret := lspPosToByteOffset([]byte(line), protocol.Position{Character: pos.Character})
+
+	for _, m := range twigMemberAccessRe.FindAllStringSubmatchIndex(line, -1) {
+		if caret < m[4] || caret > m[5] {
+			continue
+		}
+		formType := a.templateFormType(line[m[2]:m[3]])
+		if formType == "" {
+			return nil, false
+		}
+		field := line[m[4]:m[5]]
+		for _, f := range formTypeFields(a.docStore, formType) {
+			if f.name == field {
+				return []protocol.Location{f.location}, true
+			}
+		}
+		return nil, false
+	}
+	return nil, false
+}
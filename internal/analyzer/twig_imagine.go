@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var twigImagineFilterPrefixRe = regexp.MustCompile(`\|\s*imagine_filter\(\s*['"]([A-Za-z0-9_.-]*)$`)
+
+// Completes the filter set of |imagine_filter('...') from the liip_imagine
+// configuration
+func (a *twigAnalyzer) imagineFilterCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return nil
+	}
+	m := twigImagineFilterPrefixRe.FindSubmatch(a.content[:offset])
+	if m == nil {
+		return nil
+	}
+
+	prefix := string(m[1])
+	kind := protocol.CompletionItemKindValue
+	items := []protocol.CompletionItem{}
+	for _, filter := range a.container.ImagineFilters() {
+		if !strings.HasPrefix(filter.Name, prefix) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: filter.Name, Kind: &kind}
+		if filter.Size != "" {
+			detail := filter.Size
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Completes importmap('...') with the entries of importmap.php, entrypoints
+// first since only those can be rendered
+func (a *twigAnalyzer) importMapCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	ctx, ok := a.callContextAt(pos, "importmap")
+	if !ok || ctx.argIndex != 0 {
+		return nil
+	}
+
+	prefix := a.stringPrefix(ctx.strNode, pos)
+	kind := protocol.CompletionItemKindModule
+	items := []protocol.CompletionItem{}
+	for _, entry := range a.container.ImportMapEntries() {
+		if !strings.HasPrefix(entry.Name, prefix) {
+			continue
+		}
+		detail := entry.Path
+		if detail == "" && entry.Version != "" {
+			detail = entry.Name + "@" + entry.Version
+		}
+		sortText := "1" + entry.Name
+		if entry.Entrypoint {
+			sortText = "0" + entry.Name
+		}
+		item := protocol.CompletionItem{Label: entry.Name, Kind: &kind, SortText: &sortText}
+		if detail != "" {
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	twiglib "github.com/shinyvision/vimfony/internal/twig"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var twigMemberPrefixRe = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.])([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Completes the macros of a template imported with
+// {% import 'forms.html.twig' as forms %} after `forms.`
+func (a *twigAnalyzer) macroCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return nil
+	}
+	expr, ok := twigExpressionBefore(a.content[:offset])
+	if !ok {
+		return nil
+	}
+	m := twigMemberPrefixRe.FindSubmatch(expr)
+	if m == nil {
+		return nil
+	}
+	alias, prefix := string(m[1]), string(m[2])
+
+	content := string(a.content)
+	template, ok := twiglib.Imports(content)[alias]
+	if !ok {
+		return nil
+	}
+	if template != twiglib.SelfTemplate {
+		path, ok := twiglib.Resolve(template, a.container)
+		if !ok {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		content = string(data)
+	}
+
+	kind := protocol.CompletionItemKindMethod
+	items := []protocol.CompletionItem{}
+	for _, macro := range twiglib.Macros(content) {
+		if !strings.HasPrefix(macro.Name, prefix) {
+			continue
+		}
+		detail := macro.Signature()
+		items = append(items, protocol.CompletionItem{Label: macro.Name, Kind: &kind, Detail: &detail})
+	}
+	return items
+}
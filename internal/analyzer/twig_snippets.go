@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var (
+	twigTagNamePrefixRe = regexp.MustCompile(`\{%-?[ \t]*([a-z]*)$`)
+	twigTagCloseRe      = regexp.MustCompile(`^[ \t]*-?%\}`)
+)
+
+type twigTagSnippet struct {
+	tag  string
+	body string
+}
+
+// Bodies of the tag snippets, the `{%` and `%}` delimiters are added around them
+var twigTagSnippets = []twigTagSnippet{
+	{"block", "block ${1:name} %}\n\t$0\n{% endblock"},
+	{"for", "for ${1:item} in ${2:items} %}\n\t$0\n{% endfor"},
+	{"if", "if ${1:condition} %}\n\t$0\n{% endif"},
+	{"embed", "embed '${1:template}' %}\n\t$0\n{% endembed"},
+	{"apply", "apply ${1:upper} %}\n\t$0\n{% endapply"},
+	{"macro", "macro ${1:name}($2) %}\n\t$0\n{% endmacro"},
+	{"set", "set ${1:name} %}\n\t$0\n{% endset"},
+	{"with", "with %}\n\t$0\n{% endwith"},
+	{"autoescape", "autoescape %}\n\t$0\n{% endautoescape"},
+	{"verbatim", "verbatim %}\n\t$0\n{% endverbatim"},
+}
+
+// Completes the tag name after {% with snippets that include the closing tag,
+// when the client supports snippets
+func (a *twigAnalyzer) tagSnippetCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	if !a.snippets {
+		return nil
+	}
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return nil
+	}
+	m := twigTagNamePrefixRe.FindSubmatchIndex(a.content[:offset])
+	if m == nil {
+		return nil
+	}
+	typed := string(a.content[m[0]:m[2]])
+	if !strings.HasSuffix(typed, " ") && !strings.HasSuffix(typed, "\t") {
+		typed += " "
+	}
+	prefix := string(a.content[m[2]:m[3]])
+
+	// Replace the {% that is already typed and the %} the editor may have paired
+	start, end := pos, pos
+	start.Character -= utils.Character(a.content[m[0]:offset], offset-m[0])
+	if close := twigTagCloseRe.Find(a.content[offset:]); close != nil {
+		end.Character += utils.Character(close, len(close))
+	}
+
+	kind := protocol.CompletionItemKindSnippet
+	format := protocol.InsertTextFormatSnippet
+	items := []protocol.CompletionItem{}
+	for _, snippet := range twigTagSnippets {
+		if !strings.HasPrefix(snippet.tag, prefix) {
+			continue
+		}
+		detail := "{% " + snippet.tag + " %}…{% end" + snippet.tag + " %}"
+		filter := string(a.content[m[0]:m[2]]) + snippet.tag
+		items = append(items, protocol.CompletionItem{
+			Label:            snippet.tag,
+			Kind:             &kind,
+			Detail:           &detail,
+			FilterText:       &filter,
+			InsertTextFormat: &format,
+			TextEdit: protocol.TextEdit{
+				Range:   protocol.Range{Start: start, End: end},
+				NewText: typed + snippet.body + " %}",
+			},
+		})
+	}
+	return items
+}
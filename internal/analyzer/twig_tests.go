@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Tests shipped with Twig, with the extension class that provides them
+var builtinTwigTests = map[string]string{
+	"constant":     twigCoreExtension,
+	"defined":      twigCoreExtension,
+	"divisible by": twigCoreExtension,
+	"empty":        twigCoreExtension,
+	"even":         twigCoreExtension,
+	"iterable":     twigCoreExtension,
+	"mapping":      twigCoreExtension,
+	"none":         twigCoreExtension,
+	"null":         twigCoreExtension,
+	"odd":          twigCoreExtension,
+	"same as":      twigCoreExtension,
+	"sequence":     twigCoreExtension,
+}
+
+// Tests can be made of two words (`divisible by`, `same as`), so the prefix
+// may hold a space
+var twigTestPrefixRe = regexp.MustCompile(`(?:^|[\s)\]}])is\s+(?:not\s+)?([A-Za-z_][A-Za-z0-9_]*(?: [A-Za-z_]*)?)?$`)
+
+// Completes test names after `is` or `is not` in an expression, both the
+// built-in ones and those registered by twig.extension services
+func (a *twigAnalyzer) testCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	prefix, ok := a.testPrefixAt(pos)
+	if !ok {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindFunction
+	items := []protocol.CompletionItem{}
+	add := func(name, class string) {
+		if !strings.HasPrefix(name, prefix) {
+			return
+		}
+		detail := class
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: &detail})
+	}
+
+	for name, test := range a.container.TwigTests {
+		add(name, test.Class)
+	}
+	for name, class := range builtinTwigTests {
+		if _, overridden := a.container.TwigTests[name]; !overridden {
+			add(name, class)
+		}
+	}
+	return items
+}
+
+func (a *twigAnalyzer) testPrefixAt(pos protocol.Position) (string, bool) {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return "", false
+	}
+
+	expr, ok := twigExpressionBefore(a.content[:offset])
+	if !ok {
+		return "", false
+	}
+	m := twigTestPrefixRe.FindSubmatch(expr)
+	if m == nil {
+		return "", false
+	}
+	return string(m[1]), true
+}
@@ -1,12 +1,19 @@
 package analyzer
 
 import (
+	"context"
+	"regexp"
+	"slices"
 	"strings"
 
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/translations"
+	twiglib "github.com/shinyvision/vimfony/internal/twig"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+var transDefaultDomainPrefixRe = regexp.MustCompile(`\{%-?\s*trans_default_domain\s+["']([^"']*)$`)
+
 func (a *twigAnalyzer) translationCompletionItems(pos protocol.Position) []protocol.CompletionItem {
 	found, prefix := a.isTypingTranslationKey(pos)
 	if !found {
@@ -15,11 +22,15 @@ func (a *twigAnalyzer) translationCompletionItems(pos protocol.Position) []proto
 
 	items := make([]protocol.CompletionItem, 0, len(a.container.TranslationKeys))
 	kind := protocol.CompletionItemKindText
+	domain := twiglib.DefaultDomain(string(a.content))
 
-	for key := range a.container.TranslationKeys {
+	for key, locs := range a.container.TranslationKeys {
 		if prefix != "" && !strings.HasPrefix(key, prefix) {
 			continue
 		}
+		if domain != "" && len(locationsInDomain(locs, domain)) == 0 {
+			continue
+		}
 
 		label := key
 		items = append(items, protocol.CompletionItem{
@@ -56,6 +67,14 @@ func (a *twigAnalyzer) resolveTranslationDefinition(pos protocol.Position) ([]pr
 		return nil, false
 	}
 
+	// Scope to {% trans_default_domain %} unless the key only exists elsewhere
+	a.mu.RLock()
+	domain := twiglib.DefaultDomain(string(a.content))
+	a.mu.RUnlock()
+	if scoped := locationsInDomain(locs, domain); len(scoped) > 0 {
+		locs = scoped
+	}
+
 	// Filter by DefaultLocale if set
 	if container.DefaultLocale != "" {
 		var defaultLocaleLocs []protocol.Location
@@ -164,3 +183,251 @@ func (a *twigAnalyzer) translationContextAt(pos protocol.Position) (twigCallCtx,
 
 	return twigCallCtx{}, false
 }
+
+// Returns the domain the key str is translated in: the domain argument of its
+// trans filter, else the {% trans_default_domain %} of the template
+func (a *twigAnalyzer) translationDomain(str sitter.Node) string {
+	for cur := str.Parent(); !cur.IsNull(); cur = cur.Parent() {
+		if cur.Type() != "output_directive" && cur.Type() != "filter_expression" {
+			continue
+		}
+		for i := uint32(0); i < cur.NamedChildCount(); i++ {
+			filter := cur.NamedChild(i)
+			if filter.Type() != "filter" {
+				continue
+			}
+			name := strings.TrimSpace(filter.NamedChild(0).Content(a.content))
+			if name != "trans" && name != "t" {
+				continue
+			}
+			if domain := a.filterDomainArgument(filter); domain != "" {
+				return domain
+			}
+		}
+		break
+	}
+	return twiglib.DefaultDomain(string(a.content))
+}
+
+// Returns the second argument of a trans filter, or its domain: argument
+func (a *twigAnalyzer) filterDomainArgument(filter sitter.Node) string {
+	for i := uint32(0); i < filter.NamedChildCount(); i++ {
+		args := filter.NamedChild(i)
+		if args.Type() != "arguments" {
+			continue
+		}
+		position := 0
+		for j := uint32(0); j < args.NamedChildCount(); j++ {
+			arg := args.NamedChild(j)
+			if arg.Type() != "argument" {
+				continue
+			}
+			var name string
+			var value sitter.Node
+			for k := uint32(0); k < arg.NamedChildCount(); k++ {
+				switch child := arg.NamedChild(k); child.Type() {
+				case "argument_name":
+					name = strings.TrimRight(child.Content(a.content), " =:")
+				case "argument_value":
+					value = child.NamedChild(0)
+				}
+			}
+			if name == "domain" || (name == "" && position == 1) {
+				if value.IsNull() || value.Type() != "string" {
+					return ""
+				}
+				return a.stringContent(value)
+			}
+			if name == "" {
+				position++
+			}
+		}
+	}
+	return ""
+}
+
+// Keeps the locations of the catalogs of the given domain
+func locationsInDomain(locs []translations.TranslationLocation, domain string) []translations.TranslationLocation {
+	if domain == "" {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(locs), func(loc translations.TranslationLocation) bool {
+		return translations.DomainOf(loc.URI) != domain
+	})
+}
+
+// Completes the domain of {% trans_default_domain '...' %}
+func (a *twigAnalyzer) transDefaultDomainCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	offset := lspPosToByteOffset(a.content, pos)
+	if offset < 0 {
+		return nil
+	}
+	m := transDefaultDomainPrefixRe.FindSubmatch(a.content[:offset])
+	if m == nil {
+		return nil
+	}
+
+	prefix := string(m[1])
+	kind := protocol.CompletionItemKindModule
+	items := []protocol.CompletionItem{}
+	for _, domain := range a.container.TranslationDomains() {
+		if strings.HasPrefix(domain, prefix) {
+			items = append(items, protocol.CompletionItem{Label: domain, Kind: &kind})
+		}
+	}
+	return items
+}
+
+// Completes the domain argument of the trans filter: |trans({}, '...') or
+// |trans(domain='...')
+func (a *twigAnalyzer) translationDomainCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	str, ok := a.translationDomainContextAt(pos)
+	if !ok {
+		return nil
+	}
+
+	prefix := a.stringPrefix(str, pos)
+	kind := protocol.CompletionItemKindModule
+	items := []protocol.CompletionItem{}
+	for _, domain := range a.container.TranslationDomains() {
+		if strings.HasPrefix(domain, prefix) {
+			items = append(items, protocol.CompletionItem{Label: domain, Kind: &kind})
+		}
+	}
+	return items
+}
+
+func (a *twigAnalyzer) translationDomainContextAt(pos protocol.Position) (sitter.Node, bool) {
+	if a.tree == nil {
+		return sitter.Node{}, false
+	}
+
+	point, ok := lspPosToPoint(pos, a.content)
+	if !ok {
+		return sitter.Node{}, false
+	}
+
+	root := a.tree.RootNode()
+	if root.IsNull() {
+		return sitter.Node{}, false
+	}
+
+	str := root.NamedDescendantForPointRange(point, point)
+	if str.IsNull() || str.Type() != "string" {
+		return sitter.Node{}, false
+	}
+
+	value := str.Parent()
+	if value.IsNull() || value.Type() != "argument_value" {
+		return sitter.Node{}, false
+	}
+	arg := value.Parent()
+	if arg.IsNull() || arg.Type() != "argument" {
+		return sitter.Node{}, false
+	}
+	args := arg.Parent()
+	if args.IsNull() || args.Type() != "arguments" {
+		return sitter.Node{}, false
+	}
+	filter := args.Parent()
+	if filter.IsNull() || filter.Type() != "filter" {
+		return sitter.Node{}, false
+	}
+	nameNode := filter.NamedChild(0)
+	if nameNode.IsNull() || strings.TrimSpace(nameNode.Content(a.content)) != "trans" {
+		return sitter.Node{}, false
+	}
+
+	for i := uint32(0); i < arg.NamedChildCount(); i++ {
+		if child := arg.NamedChild(i); child.Type() == "argument_name" {
+			name := strings.TrimRight(child.Content(a.content), " =:")
+			return str, name == "domain"
+		}
+	}
+	return str, args.NamedChildCount() > 1 && args.NamedChild(1).Equal(arg)
+}
+
+// Completes the placeholders of the translated message as keys of the
+// parameters hash: {{ 'key'|trans({'%name%': ...}) }}
+func (a *twigAnalyzer) translationPlaceholderCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	if a.tree == nil {
+		return nil
+	}
+
+	point, ok := lspPosToPoint(pos, a.content)
+	if !ok {
+		return nil
+	}
+
+	root := a.tree.RootNode()
+	if root.IsNull() {
+		return nil
+	}
+
+	str := root.NamedDescendantForPointRange(point, point)
+	if str.IsNull() || str.Type() != "string" {
+		return nil
+	}
+
+	hash := str.Parent()
+	if !hash.IsNull() && hash.Type() == "hash_key" {
+		hash = hash.Parent()
+	}
+	if hash.IsNull() || hash.Type() != "hash" {
+		return nil
+	}
+
+	value := hash.Parent()
+	if value.IsNull() || value.Type() != "argument_value" {
+		return nil
+	}
+	arg := value.Parent()
+	if arg.IsNull() || arg.Type() != "argument" {
+		return nil
+	}
+	args := arg.Parent()
+	if args.IsNull() || args.Type() != "arguments" || !args.NamedChild(0).Equal(arg) {
+		return nil
+	}
+	filter := args.Parent()
+	if filter.IsNull() || filter.Type() != "filter" {
+		return nil
+	}
+	nameNode := filter.NamedChild(0)
+	if nameNode.IsNull() || strings.TrimSpace(nameNode.Content(a.content)) != "trans" {
+		return nil
+	}
+
+	// The translated key is the expression the filter is applied to
+	keyNode := filter.Parent().NamedChild(0)
+	if keyNode.IsNull() || keyNode.Type() != "string" {
+		return nil
+	}
+	message, ok := a.container.TranslationKeys.Message(a.stringContent(keyNode), a.container.DefaultLocale)
+	if !ok {
+		return nil
+	}
+
+	return translationPlaceholderItems(message, a.stringPrefix(str, pos))
+}
+
+func (a *twigAnalyzer) OnCodeAction(ctx context.Context, params *protocol.CodeActionParams) ([]CodeAction, error) {
+	a.mu.RLock()
+	container := a.container
+	call, ok := a.translationContextAt(params.Range.Start)
+	key, domain := "", ""
+	if ok {
+		key = a.stringContent(call.strNode)
+		domain = a.translationDomain(call.strNode)
+	}
+	a.mu.RUnlock()
+
+	var actions []CodeAction
+	if ok {
+		actions = translationKeyCodeActions(container, key, domain)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append(actions, a.extractTemplateCodeActions(params)...), nil
+}
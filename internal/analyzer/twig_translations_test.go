@@ -1,10 +1,15 @@
 package analyzer
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/shinyvision/vimfony/internal/config"
 	"github.com/shinyvision/vimfony/internal/translations"
+	"github.com/shinyvision/vimfony/internal/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	protocol "github.com/tliron/glsp/protocol_3_16"
@@ -29,7 +34,7 @@ func TestTwigTranslationCompletion(t *testing.T) {
 
 	// Test 1: Complete 'hello'
 	pos := protocol.Position{Line: 0, Character: 8} // 'hello|'
-	items, err := an.OnCompletion(pos)
+	items, err := an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -44,7 +49,7 @@ func TestTwigTranslationCompletion(t *testing.T) {
 
 	// Test 2: Complete 'messages.'
 	pos = protocol.Position{Line: 1, Character: 12} // 'messages.|'
-	items, err = an.OnCompletion(pos)
+	items, err = an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 	require.NotEmpty(t, items)
 
@@ -59,7 +64,7 @@ func TestTwigTranslationCompletion(t *testing.T) {
 
 	// Test 3: Complete 'foo' with |t filter
 	pos = protocol.Position{Line: 2, Character: 6} // 'foo|'
-	items, err = an.OnCompletion(pos)
+	items, err = an.OnCompletion(context.Background(), pos)
 	require.NoError(t, err)
 
 	found = false
@@ -92,7 +97,7 @@ func TestTwigTranslationDefinition(t *testing.T) {
 	require.NoError(t, an.Changed([]byte(content), nil))
 
 	pos := protocol.Position{Line: 0, Character: 5} // inside 'hello.world'
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.Len(t, locs, 1)
 	assert.Equal(t, expectedURI, string(locs[0].URI))
@@ -116,14 +121,189 @@ func TestTwigTranslationDefinitionMultipleLocations(t *testing.T) {
 
 	// With DefaultLocale = "en", should return only en location
 	pos := protocol.Position{Line: 0, Character: 5}
-	locs, err := an.OnDefinition(pos)
+	locs, err := an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.Len(t, locs, 1)
 	assert.Equal(t, "file:///tmp/messages.en.yaml", string(locs[0].URI))
 
 	// Without DefaultLocale, should return both
 	container.DefaultLocale = ""
-	locs, err = an.OnDefinition(pos)
+	locs, err = an.OnDefinition(context.Background(), pos)
 	require.NoError(t, err)
 	require.Len(t, locs, 2)
 }
+
+func TestTwigTranslationDomainCompletion(t *testing.T) {
+	content := `{{ 'hello'|trans({}, 'val') }}
+{{ 'hello'|trans(domain='se') }}
+{{ 'hello'|trans({}, 'val')|upper }}
+`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		TranslationResources: []string{
+			"/app/translations/messages+intl-icu.en.yaml",
+			"/app/translations/validators.en.xlf",
+			"/app/vendor/symfony/security-core/Resources/translations/security.nl.xlf",
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	labelsAt := func(line, character uint32) []string {
+		items, err := an.OnCompletion(context.Background(), protocol.Position{Line: line, Character: character})
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	assert.Equal(t, []string{"validators"}, labelsAt(0, 25))
+	assert.Equal(t, []string{"security"}, labelsAt(1, 27))
+	assert.Equal(t, []string{"validators"}, labelsAt(2, 25))
+}
+
+func TestTwigTranslationPlaceholderCompletion(t *testing.T) {
+	content := `{{ 'greeting'|trans({'%na': 'x'}) }}
+`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		DefaultLocale: "en",
+		TranslationKeys: translations.TranslationMap{
+			"greeting": {
+				{URI: "file:///app/translations/messages.nl.yaml", Message: "Hallo %name%"},
+				{URI: "file:///app/translations/messages.en.yaml", Message: "Hello %name%, welcome to %site%"},
+			},
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), protocol.Position{Line: 0, Character: 25})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "%name%", items[0].Label)
+	require.NotNil(t, items[0].Detail)
+	assert.Equal(t, "Hello %name%, welcome to %site%", *items[0].Detail)
+}
+
+func TestTwigTransDefaultDomain(t *testing.T) {
+	content := `{% trans_default_domain 'admin' %}
+{{ 'title'|trans }}
+`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		TranslationResources: []string{
+			"/app/translations/messages.en.yaml",
+			"/app/translations/admin+intl-icu.en.yaml",
+		},
+		TranslationKeys: map[string][]translations.TranslationLocation{
+			"title.page":  {{URI: "file:///app/translations/messages.en.yaml"}, {URI: "file:///app/translations/admin+intl-icu.en.yaml"}},
+			"title.admin": {{URI: "file:///app/translations/admin+intl-icu.en.yaml"}},
+			"title.front": {{URI: "file:///app/translations/messages.en.yaml"}},
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	labelsAt := func(line, character uint32) []string {
+		items, err := an.OnCompletion(context.Background(), protocol.Position{Line: line, Character: character})
+		require.NoError(t, err)
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	assert.Equal(t, []string{"admin"}, labelsAt(0, 27))
+	assert.ElementsMatch(t, []string{"title.page", "title.admin"}, labelsAt(1, 9))
+
+	// The default domain wins when the key exists in several domains
+	require.NoError(t, an.Changed([]byte("{% trans_default_domain 'admin' %}\n{{ 'title.page'|trans }}\n"), nil))
+	locs, err := an.OnDefinition(context.Background(), protocol.Position{Line: 1, Character: 6})
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	assert.Equal(t, "file:///app/translations/admin+intl-icu.en.yaml", string(locs[0].URI))
+}
+
+func TestTwigTranslationCodeActionUsesDomain(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "translations")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	messages := filepath.Join(dir, "messages.en.yaml")
+	admin := filepath.Join(dir, "admin.en.yaml")
+	require.NoError(t, os.WriteFile(messages, []byte("title: Title\n"), 0o644))
+	require.NoError(t, os.WriteFile(admin, []byte("other: Other\n"), 0o644))
+
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:    root,
+		TranslationRoots: []string{"translations"},
+		DefaultLocale:    "en",
+		TranslationKeys: map[string][]translations.TranslationLocation{
+			"title": {{URI: utils.PathToURI(messages)}},
+		},
+	})
+
+	catalogsAt := func(content string, line, character uint32) []string {
+		require.NoError(t, an.Changed([]byte(content), nil))
+		pos := protocol.Position{Line: line, Character: character}
+		actions, err := an.OnCodeAction(context.Background(), &protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///app/templates/page.html.twig"},
+			Range:        protocol.Range{Start: pos, End: pos},
+		})
+		require.NoError(t, err)
+		var uris []string
+		for _, action := range actions {
+			if !strings.HasPrefix(action.Title, "Add translation") {
+				continue
+			}
+			for uri := range action.Resolve().Edit.Changes {
+				uris = append(uris, string(uri))
+			}
+		}
+		return uris
+	}
+
+	assert.Empty(t, catalogsAt("{{ 'title'|trans }}\n", 0, 6))
+	assert.Equal(t, []string{utils.PathToURI(admin)}, catalogsAt("{{ 'title'|trans({}, 'admin') }}\n", 0, 6))
+	assert.Equal(t, []string{utils.PathToURI(admin)}, catalogsAt("{{ 'title'|trans(domain: 'admin') }}\n", 0, 6))
+	assert.Equal(t, []string{utils.PathToURI(admin)}, catalogsAt("{% trans_default_domain 'admin' %}\n{{ 'title'|trans }}\n", 1, 6))
+}
+
+func TestTwigTransSignatureHelp(t *testing.T) {
+	content := `{{ 'greeting'|trans({'%name%': 'x'}, 'messages') }}
+{{ 'greeting'|trans(locale='nl') }}
+`
+	an := NewTwigAnalyzer().(*twigAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		DefaultLocale: "en",
+		TranslationKeys: translations.TranslationMap{
+			"greeting": {
+				{URI: "file:///app/translations/messages.en.yaml", Message: "Hello %name%"},
+			},
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	help, err := an.OnSignatureHelp(protocol.Position{Line: 0, Character: 24})
+	require.NoError(t, err)
+	require.NotNil(t, help)
+	assert.Equal(t, "trans(id, parameters, domain, locale)", help.Signatures[0].Label)
+	assert.Equal(t, protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: "`greeting`: Hello %name%"}, help.Signatures[0].Parameters[0].Documentation)
+	require.NotNil(t, help.ActiveParameter)
+	assert.Equal(t, protocol.UInteger(1), *help.ActiveParameter)
+
+	help, err = an.OnSignatureHelp(protocol.Position{Line: 0, Character: 42})
+	require.NoError(t, err)
+	require.NotNil(t, help)
+	assert.Equal(t, protocol.UInteger(2), *help.ActiveParameter)
+
+	help, err = an.OnSignatureHelp(protocol.Position{Line: 1, Character: 30})
+	require.NoError(t, err)
+	require.NotNil(t, help)
+	assert.Equal(t, protocol.UInteger(3), *help.ActiveParameter)
+
+	help, err = an.OnSignatureHelp(protocol.Position{Line: 0, Character: 5})
+	require.NoError(t, err)
+	assert.Nil(t, help)
+}
@@ -2,9 +2,9 @@ package analyzer
 
 import (
 	"bytes"
-	"unicode/utf8"
 
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/utils"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
@@ -35,42 +35,20 @@ func caretIn(n *sitter.Node, pt sitter.Point) bool {
 	return sp.Row <= pt.Row && pt.Row <= ep.Row
 }
 
-// UTF-16 conversion from bytes to point
+// Converts an LSP position to the tree-sitter point of its byte column
 func lspPosToPoint(pos protocol.Position, content []byte) (sitter.Point, bool) {
-	row := uint(pos.Line)
-
-	var lineStart, i, curRow uint
-	for i = 0; i < uint(len(content)) && curRow < row; i++ {
-		if content[i] == '\n' {
-			curRow++
-			lineStart = i + 1
-		}
-	}
-	if curRow != row {
+	line, _, ok := utils.Line(content, pos.Line)
+	if !ok {
 		return sitter.Point{}, false
 	}
+	column, _ := utils.ByteColumn(bytes.TrimSuffix(line, []byte("\r")), pos.Character)
+	return sitter.Point{Row: uint(pos.Line), Column: uint(column)}, true
+}
 
-	need := pos.Character
-	var colBytes uint
-	for offset := lineStart; offset < uint(len(content)); {
-		b := content[offset]
-		if b == '\n' || b == '\r' {
-			break
-		}
-		rune, size := utf8.DecodeRune(content[offset:])
-		var u16len uint32 = 1
-		if rune > 0xFFFF {
-			u16len = 2
-		}
-		if need < u16len {
-			colBytes = offset - lineStart
-			return sitter.Point{Row: row, Column: colBytes}, true
-		}
-		need -= u16len
-		offset += uint(size)
-		colBytes = offset - lineStart
-	}
-	return sitter.Point{Row: row, Column: colBytes}, true
+// Returns the byte column of line at the character of a position
+func byteColumn(line string, character uint32) int {
+	column, _ := utils.ByteColumn([]byte(line), character)
+	return column
 }
 
 // Getting our line until the caret
@@ -87,26 +65,8 @@ func linePrefixAtPoint(content []byte, point sitter.Point) []byte {
 	return content[start:caret]
 }
 
+// Converts an LSP position to its byte offset in content, -1 when its line is
+// past the end
 func lspPosToByteOffset(content []byte, pos protocol.Position) int {
-	lines := bytes.Split(content, []byte("\n"))
-	if int(pos.Line) >= len(lines) {
-		return -1
-	}
-	offset := 0
-	for i := 0; i < int(pos.Line); i++ {
-		offset += len(lines[i]) + 1 // +1 for the newline
-	}
-
-	char := int(pos.Character)
-	lineLen := len(lines[int(pos.Line)])
-	if char > lineLen {
-		char = lineLen
-	}
-
-	offset += char
-
-	if offset > len(content) {
-		return -1
-	}
-	return offset
+	return utils.ByteOffset(content, pos)
 }
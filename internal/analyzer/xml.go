@@ -3,6 +3,7 @@ package analyzer
 import (
 	"bytes"
 	"context"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
@@ -14,7 +15,6 @@ import (
 	"github.com/shinyvision/vimfony/internal/config"
 	php "github.com/shinyvision/vimfony/internal/php"
 	"github.com/shinyvision/vimfony/internal/twig"
-	"github.com/shinyvision/vimfony/internal/utils"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
@@ -27,6 +27,8 @@ type xmlAnalyzer struct {
 	autoload  config.AutoloadMap
 	store     *php.DocumentStore
 	path      string
+	snippets  bool
+	features  config.Features
 }
 
 func NewXMLAnalyzer() Analyzer {
@@ -91,15 +93,17 @@ func (a *xmlAnalyzer) isInServiceIDAttribute(pos protocol.Position) (bool, strin
 		return false, ""
 	}
 
-	if a.attributeName(attr) != "id" {
-		return false, ""
-	}
-
 	tag := a.ascendToAny(node, "STag", "EmptyElemTag")
 	if tag.IsNull() {
 		return false, ""
 	}
-	if a.tagNameFromTagNode(tag) != "argument" {
+
+	switch tagName, attrName := a.tagNameFromTagNode(tag), a.attributeName(attr); {
+	case tagName == "service" && (attrName == "parent" || attrName == "decorates"),
+		(tagName == "factory" || tagName == "configurator") && attrName == "service":
+		prefix, ok := a.attributeValuePrefixAtCaret(attr, pos)
+		return ok, prefix
+	case tagName != "argument" || attrName != "id":
 		return false, ""
 	}
 
@@ -127,6 +131,54 @@ func (a *xmlAnalyzer) isInServiceIDAttribute(pos protocol.Position) (bool, strin
 	return true, prefix
 }
 
+// Returns the element and attribute names when pos is in an attribute value,
+// along with the typed part of the value. The caller holds the lock.
+func (a *xmlAnalyzer) attributeValueAt(pos protocol.Position) (string, string, string, bool) {
+	if a.tree == nil {
+		return "", "", "", false
+	}
+	point, ok := lspPosToPoint(pos, a.content)
+	if !ok {
+		return "", "", "", false
+	}
+	root := a.tree.RootNode()
+	if root.IsNull() {
+		return "", "", "", false
+	}
+	node := root.NamedDescendantForPointRange(point, point)
+	if node.IsNull() {
+		return "", "", "", false
+	}
+
+	attr := a.ascendToType(node, "Attribute")
+	if attr.IsNull() {
+		return "", "", "", false
+	}
+	tag := a.ascendToAny(node, "STag", "EmptyElemTag")
+	if tag.IsNull() {
+		return "", "", "", false
+	}
+	prefix, ok := a.attributeValuePrefixAtCaret(attr, pos)
+	if !ok {
+		return "", "", "", false
+	}
+	return a.tagNameFromTagNode(tag), a.attributeName(attr), prefix, true
+}
+
+// Returns the typed part of the value when pos is in the class attribute of
+// a service, factory or configurator. The caller holds the lock.
+func (a *xmlAnalyzer) classAttributePrefix(pos protocol.Position) (string, bool) {
+	element, attr, prefix, ok := a.attributeValueAt(pos)
+	if !ok || attr != "class" {
+		return "", false
+	}
+	switch element {
+	case "service", "factory", "configurator":
+		return prefix, true
+	}
+	return "", false
+}
+
 func (a *xmlAnalyzer) ascendToType(n sitter.Node, typ string) sitter.Node {
 	for cur := n; !cur.IsNull(); cur = cur.Parent() {
 		if cur.Type() == typ {
@@ -301,7 +353,19 @@ func (a *xmlAnalyzer) SetDocumentPath(path string) {
 	a.path = path
 }
 
-func (a *xmlAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.CompletionItem, error) {
+func (a *xmlAnalyzer) SetSnippetSupport(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.snippets = enabled
+}
+
+func (a *xmlAnalyzer) SetFeatures(features config.Features) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.features = features
+}
+
+func (a *xmlAnalyzer) OnCompletion(_ context.Context, pos protocol.Position) ([]protocol.CompletionItem, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -309,6 +373,32 @@ func (a *xmlAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.Completion
 		return nil, nil
 	}
 
+	if point, ok := lspPosToPoint(pos, a.content); ok {
+		linePrefix := string(linePrefixAtPoint(a.content, point))
+		if prefix, ok := envVarPrefix(linePrefix); ok {
+			items := envVarCompletionItems(a.container, prefix)
+			if prefix, ok := envProcessorPrefix(linePrefix); ok {
+				items = append(items, envProcessorCompletionItems(prefix)...)
+			}
+			return items, nil
+		}
+		if prefix, ok := xmlParameterPrefix(linePrefix); ok {
+			return parameterCompletionItems(a.container, prefix), nil
+		}
+	}
+
+	if items := a.schemaCompletionItems(pos); len(items) > 0 {
+		return items, nil
+	}
+
+	if prefix, ok := a.classAttributePrefix(pos); ok {
+		return classCompletionItems(a.autoload, pos, prefix, ""), nil
+	}
+
+	if element, attr, prefix, ok := a.attributeValueAt(pos); ok && element == "tag" && attr == "name" {
+		return tagCompletionItems(a.container, prefix), nil
+	}
+
 	found, prefix := a.isInServiceIDAttribute(pos)
 	if !found {
 		return nil, nil
@@ -317,6 +407,23 @@ func (a *xmlAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.Completion
 	return a.serviceCompletionItems(prefix), nil
 }
 
+// Matches the text of an attribute value or of an element up to the caret
+var xmlValuePrefixRe = regexp.MustCompile(`(?:=\s*["']|>)([^"'<>]*)$`)
+
+// Returns the parameter name typed so far when the caret is after an
+// unclosed % in an attribute value or an element text
+func xmlParameterPrefix(linePrefix string) (string, bool) {
+	m := xmlValuePrefixRe.FindStringSubmatch(linePrefix)
+	if m == nil || strings.Count(m[1], "%")%2 == 0 {
+		return "", false
+	}
+	name := m[1][strings.LastIndex(m[1], "%")+1:]
+	if !parameterNameRe.MatchString(name) {
+		return "", false
+	}
+	return name, true
+}
+
 func (a *xmlAnalyzer) serviceCompletionItems(prefix string) []protocol.CompletionItem {
 	items := []protocol.CompletionItem{}
 	seen := make(map[string]bool)
@@ -366,25 +473,22 @@ func (a *xmlAnalyzer) serviceCompletionItems(prefix string) []protocol.Completio
 	return items
 }
 
-func (a *xmlAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location, error) {
+func (a *xmlAnalyzer) OnDefinition(_ context.Context, pos protocol.Position) ([]protocol.Location, error) {
 	a.mu.RLock()
 	content := string(a.content)
 	store := a.store
 	container := a.container
 	autoload := a.autoload
+	features := a.features
 	a.mu.RUnlock()
 
 	if container == nil {
 		return nil, nil
 	}
 
-	if twigPath, ok := twig.PathAt(content, pos); ok {
-		if target, ok := twig.Resolve(twigPath, container); ok {
-			loc := protocol.Location{
-				URI:   protocol.DocumentUri(utils.PathToURI(target)),
-				Range: protocol.Range{},
-			}
-			return []protocol.Location{loc}, nil
+	if twigPath, ok := twig.PathAt(content, pos); ok && features.Enabled(config.FeatureTemplates) {
+		if locs, ok := templateLocations(twigPath, container); ok {
+			return locs, nil
 		}
 	}
 
@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -91,28 +92,384 @@ func TestXMLAnalyzerOnDefinition(t *testing.T) {
 		},
 	}
 	an.SetAutoloadMap(&autoload)
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetDocumentPath("/tmp/test.xml")
 	require.NoError(t, an.Changed([]byte(content), nil))
 
 	servicePos := positionAfter(t, []byte(content), "test.service", len("test"))
-	serviceLocs, err := an.OnDefinition(servicePos)
+	serviceLocs, err := an.OnDefinition(context.Background(), servicePos)
 	require.NoError(t, err)
 	require.NotEmpty(t, serviceLocs)
 	expectedClassPath := filepath.Join(mockRoot, "vendor", "TestClass.php")
 	require.Equal(t, protocol.DocumentUri(utils.PathToURI(expectedClassPath)), serviceLocs[0].URI)
 
 	classPos := positionAfter(t, []byte(content), "VendorNamespace\\TestClass", len("VendorNamespace\\"))
-	classLocs, err := an.OnDefinition(classPos)
+	classLocs, err := an.OnDefinition(context.Background(), classPos)
 	require.NoError(t, err)
 	require.NotEmpty(t, classLocs)
 	require.Equal(t, protocol.DocumentUri(utils.PathToURI(expectedClassPath)), classLocs[0].URI)
 
 	twigPos := positionAfter(t, []byte(content), "template.html.twig", len("template"))
-	twigLocs, err := an.OnDefinition(twigPos)
+	twigLocs, err := an.OnDefinition(context.Background(), twigPos)
 	require.NoError(t, err)
 	require.NotEmpty(t, twigLocs)
 	require.Equal(t, protocol.DocumentUri(utils.PathToURI(filepath.Join(mockRoot, "template.html.twig"))), twigLocs[0].URI)
 }
+
+func TestXMLClassAttributeCompletion(t *testing.T) {
+	content := `<container>
+	<services>
+		<service id="app.foo" class="VendorNamespace\Fo">
+			<factory class="Test" method="create"/>
+			<argument id="Test"/>
+		</service>
+	</services>
+</container>`
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"VendorNamespace\\": {"vendor"}}}
+	autoload.Classes, autoload.Files = config.BuildClassIndex(autoload, mockRoot)
+	an.SetAutoloadMap(&autoload)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "Namespace\\Fo", len("Namespace\\Fo")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "VendorNamespace\\FooClass", items[0].Label)
+	edit := items[0].TextEdit.(protocol.TextEdit)
+	require.Equal(t, "VendorNamespace\\FooClass", edit.NewText)
+	require.Equal(t, uint32(31), edit.Range.Start.Character)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), `class="Test`, len(`class="Test`)))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "VendorNamespace\\TestClass", items[0].Label)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), `id="Test`, len(`id="Test`)))
+	require.NoError(t, err)
+	for _, item := range items {
+		require.NotEqual(t, "VendorNamespace\\TestClass", item.Label)
+	}
+}
+
+func TestXMLServiceReferenceAttributes(t *testing.T) {
+	content := `<container>
+	<services>
+		<service id="app.base" abstract="true"/>
+		<service id="app.foo" parent="app.ba" decorates="app.base">
+			<factory service="app.fact" method="create"/>
+		</service>
+	</services>
+</container>`
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot: mockRoot,
+		ServiceClasses: map[string]string{
+			"app.base":    "VendorNamespace\\TestClass",
+			"app.factory": "VendorNamespace\\FooClass",
+		},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"VendorNamespace\\": {"vendor"}}}
+	an.SetAutoloadMap(&autoload)
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	found, prefix := an.isInServiceIDAttribute(positionAfter(t, []byte(content), `parent="app.ba`, len(`parent="app.ba`)))
+	require.True(t, found)
+	require.Equal(t, "app.ba", prefix)
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, []byte(content), `service="app.fact`, len(`service="app.fact`)))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "app.factory", items[0].Label)
+
+	found, _ = an.isInServiceIDAttribute(positionAfter(t, []byte(content), `method="cr`, len(`method="cr`)))
+	require.False(t, found)
+
+	locs, err := an.OnDefinition(context.Background(), positionAfter(t, []byte(content), `decorates="app.b`, len(`decorates="app.b`)))
+	require.NoError(t, err)
+	require.NotEmpty(t, locs)
+	require.Equal(t, protocol.DocumentUri(utils.PathToURI(filepath.Join(mockRoot, "vendor", "TestClass.php"))), locs[0].URI)
+}
+
+func TestXMLSchemaCompletion(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8" ?>
+<container>
+	<services>
+		<!-- <argument> -->
+		<service id="app.foo" class="App\Foo" a>
+			<argument id="app.bar"/>
+			<ta
+		</service>
+		<s
+	</services>
+</container>`
+
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	labels := func(items []protocol.CompletionItem) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "<ta", 3))
+	require.NoError(t, err)
+	require.Equal(t, []string{"tag"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "\t\t<s\n", 4))
+	require.NoError(t, err)
+	require.Equal(t, []string{"service", "stack"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), `"App\Foo" a`, len(`"App\Foo" a`)))
+	require.NoError(t, err)
+	require.Equal(t, []string{"abstract", "alias", "autowire", "autoconfigure"}, labels(items))
+	require.Equal(t, `abstract=""`, *items[0].InsertText)
+
+	an.SetSnippetSupport(true)
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), `"App\Foo" a`, len(`"App\Foo" a`)))
+	require.NoError(t, err)
+	require.Equal(t, `abstract="$1"`, *items[0].InsertText)
+}
+
+func TestXMLUnknownServiceReferences(t *testing.T) {
+	content := `<container>
+    <services>
+        <service id="app.foo" class="App\Foo">
+            <argument type="service" id="app.missing"/>
+            <argument type="service" id="logger"/>
+            <argument type="service" id="app.local"/>
+            <argument type="service" id="app.optional" on-invalid="null"/>
+        </service>
+        <service id="app.local" class="App\Local"/>
+        <service id="app.alias" alias="app.gone"/>
+    </services>
+</container>`
+
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"logger": "Psr\\Log\\LoggerInterface"},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 2)
+	require.Equal(t, "Unknown service 'app.missing'", diagnostics[0].Message)
+	require.Equal(t, uint32(3), diagnostics[0].Range.Start.Line)
+	require.Equal(t, "Unknown service 'app.gone'", diagnostics[1].Message)
+
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///tmp/services.xml"},
+		Range:        protocol.Range{Start: positionAfter(t, []byte(content), "app.missing", 3)},
+	}
+	actions, err := an.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+
+	require.Equal(t, "Remove reference to 'app.missing'", actions[0].Title)
+	removal := actions[0].Resolve().Edit.Changes[params.TextDocument.URI][0]
+	require.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 3, Character: 0},
+		End:   protocol.Position{Line: 4, Character: 0},
+	}, removal.Range)
+	require.Empty(t, removal.NewText)
+
+	require.Equal(t, "Create alias stub for 'app.missing'", actions[1].Title)
+	stub := actions[1].Resolve().Edit.Changes[params.TextDocument.URI][0]
+	require.Equal(t, protocol.Position{Line: 10, Character: 0}, stub.Range.Start)
+	require.Equal(t, "        <service id=\"app.missing\" alias=\"\"/>\n", stub.NewText)
+
+	params.Range.Start = positionAfter(t, []byte(content), "logger", 2)
+	actions, err = an.OnCodeAction(context.Background(), params)
+	require.NoError(t, err)
+	require.Empty(t, actions)
+}
+
+func TestXMLDefinitionListsServiceDeclarations(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	base := write("config/services.xml", `<container>
+    <services>
+        <service id="app.mailer"/>
+        <service id="app.newsletter">
+            <argument type="service" id="app.mailer"/>
+        </service>
+    </services>
+</container>`)
+	override := write("config/services_test.xml", `<container>
+    <services>
+        <service id="app.mailer"/>
+    </services>
+</container>`)
+
+	content, err := os.ReadFile(base)
+	require.NoError(t, err)
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:     root,
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	require.NoError(t, an.Changed(content, nil))
+
+	locs, err := an.OnDefinition(context.Background(), positionAfter(t, content, `id="app.mailer"/>
+        </service>`, len(`id="app.`)))
+	require.NoError(t, err)
+	require.Len(t, locs, 2)
+	require.Equal(t, protocol.DocumentUri(utils.PathToURI(base)), locs[0].URI)
+	require.Equal(t, uint32(2), locs[0].Range.Start.Line)
+	require.Equal(t, protocol.DocumentUri(utils.PathToURI(override)), locs[1].URI)
+}
+
+func TestXMLTagNameCompletion(t *testing.T) {
+	content := `<container>
+    <services>
+        <service id="app.listener">
+            <tag name="kernel." event="kernel.request"/>
+            <argument id="kernel."/>
+        </service>
+    </services>
+</container>`
+
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		ServiceTags:       map[string]int{"kernel.event_listener": 3, "kernel.reset": 8, "app.custom": 1},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, []byte(content), `name="kernel.`, len(`name="kernel.`)))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "kernel.reset", items[0].Label)
+	require.Equal(t, "8 services", *items[0].Detail)
+	doc := items[0].Documentation.(protocol.MarkupContent)
+	require.Contains(t, doc.Value, "between requests")
+	require.Equal(t, "kernel.event_listener", items[1].Label)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), `id="kernel.`, len(`id="kernel.`)))
+	require.NoError(t, err)
+	for _, item := range items {
+		require.NotEqual(t, "kernel.reset", item.Label)
+	}
+}
+
+func TestXMLParameterCompletion(t *testing.T) {
+	content := `<container>
+    <services>
+        <service id="app.mailer" class="%app.mailer_cl">
+            <argument>%kernel.</argument>
+            <argument>%kernel.debug% and %kern</argument>
+        </service>
+    </services>
+</container>`
+
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		Parameters: config.ParametersMap{
+			"kernel.debug":       "1",
+			"kernel.project_dir": "/app",
+			"app.mailer_class":   "App\\Mailer",
+		},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	labels := func(items []protocol.CompletionItem) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "%kernel.<", len("%kernel.")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"kernel.debug", "kernel.project_dir"}, labels(items))
+	require.Equal(t, "/app", *items[1].Detail)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "%kern<", len("%kern")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"kernel.debug", "kernel.project_dir"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "%app.mailer_cl", len("%app.mailer_cl")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"app.mailer_class"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "%kernel.debug%", len("%kernel.debug%")))
+	require.NoError(t, err)
+	require.Empty(t, items)
+}
+
+func TestXMLEnvCompletion(t *testing.T) {
+	content := `<container>
+    <services>
+        <service id="app.client">
+            <argument>%env(int:APP_</argument>
+            <argument>%env(in</argument>
+        </service>
+    </services>
+</container>`
+
+	an := NewXMLAnalyzer().(*xmlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		EnvVars:           map[string]string{"APP_TIMEOUT": "30", "INSTANCE_ID": ""},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "int:APP_", len("int:APP_")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "APP_TIMEOUT", items[0].Label)
+
+	items, err = an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "%env(in<", len("%env(in")))
+	require.NoError(t, err)
+	labels := make([]string, 0, len(items))
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	require.Equal(t, []string{"int"}, labels)
+	require.Equal(t, "int:", *items[0].InsertText)
+}
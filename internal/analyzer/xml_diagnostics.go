@@ -0,0 +1,207 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// A service id referenced by an argument or an alias that neither the
+// container nor the file defines
+type xmlServiceReference struct {
+	id string
+	// The <argument> or the alias <service> holding the reference
+	element sitter.Node
+	value   sitter.Node
+}
+
+// Returns the AttValue nodes of the attributes of a tag by name
+func (a *xmlAnalyzer) tagAttributes(tag sitter.Node) map[string]sitter.Node {
+	attrs := make(map[string]sitter.Node)
+	for i := uint32(0); i < tag.NamedChildCount(); i++ {
+		attr := tag.NamedChild(i)
+		if attr.IsNull() || attr.Type() != "Attribute" {
+			continue
+		}
+		for j := uint32(0); j < attr.NamedChildCount(); j++ {
+			if value := attr.NamedChild(j); !value.IsNull() && value.Type() == "AttValue" {
+				attrs[a.attributeName(attr)] = value
+			}
+		}
+	}
+	return attrs
+}
+
+func (a *xmlAnalyzer) attValue(value sitter.Node) string {
+	return strings.Trim(value.Content(a.content), `"'`)
+}
+
+// Collects the references to unknown services. The caller holds the lock.
+func (a *xmlAnalyzer) unknownServiceReferences() []xmlServiceReference {
+	if a.tree == nil || a.container == nil || len(a.container.ServiceClasses) == 0 {
+		return nil
+	}
+
+	type candidate struct {
+		element sitter.Node
+		attrs   map[string]sitter.Node
+	}
+	var candidates []candidate
+	local := make(map[string]bool)
+	walkNodes(a.tree.RootNode(), func(n sitter.Node) {
+		if n.Type() != "STag" && n.Type() != "EmptyElemTag" {
+			return
+		}
+		attrs := a.tagAttributes(n)
+		switch a.tagNameFromTagNode(n) {
+		case "service":
+			if id, ok := attrs["id"]; ok {
+				local[a.attValue(id)] = true
+			}
+			if _, ok := attrs["alias"]; ok {
+				candidates = append(candidates, candidate{n.Parent(), attrs})
+			}
+		case "argument":
+			if typ, ok := attrs["type"]; ok && a.attValue(typ) == "service" {
+				candidates = append(candidates, candidate{n.Parent(), attrs})
+			}
+		}
+	})
+
+	var refs []xmlServiceReference
+	for _, c := range candidates {
+		value, ok := c.attrs["alias"]
+		if !ok {
+			value, ok = c.attrs["id"]
+		}
+		if !ok {
+			continue
+		}
+		if onInvalid, ok := c.attrs["on-invalid"]; ok && a.attValue(onInvalid) != "exception" {
+			continue
+		}
+		id := a.attValue(value)
+		if id == "" || local[id] {
+			continue
+		}
+		if _, ok := a.container.ServiceClasses[id]; ok {
+			continue
+		}
+		if _, ok := a.container.ServiceAliases[id]; ok {
+			continue
+		}
+		refs = append(refs, xmlServiceReference{id: id, element: c.element, value: value})
+	}
+	return refs
+}
+
+func (a *xmlAnalyzer) OnDiagnostics() ([]protocol.Diagnostic, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var diagnostics []protocol.Diagnostic
+	for _, ref := range a.unknownServiceReferences() {
+		diagnostics = append(diagnostics, newDiagnostic(
+			nodeRange(ref.value, a.content),
+			protocol.DiagnosticSeverityWarning,
+			fmt.Sprintf("Unknown service '%s'", ref.id),
+		))
+	}
+	return diagnostics, nil
+}
+
+// Quick fixes for the unknown service under the cursor: removing the
+// element that references it, or adding an alias to fill in
+func (a *xmlAnalyzer) OnCodeAction(_ context.Context, params *protocol.CodeActionParams) ([]CodeAction, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	caret := lspPosToByteOffset(a.content, params.Range.Start)
+	if caret < 0 {
+		return nil, nil
+	}
+
+	kind := protocol.CodeActionKindQuickFix
+	uri := params.TextDocument.URI
+	var actions []CodeAction
+	for _, ref := range a.unknownServiceReferences() {
+		if caret < int(ref.value.StartByte()) || caret > int(ref.value.EndByte()) {
+			continue
+		}
+		// Both edits are cheap, and the tree may be gone by the time of resolve
+		removal := a.elementDeletionRange(ref.element)
+		actions = append(actions, newCodeAction(fmt.Sprintf("Remove reference to '%s'", ref.id), kind, func() *protocol.WorkspaceEdit {
+			return &protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+					uri: {{Range: removal, NewText: ""}},
+				},
+			}
+		}))
+		if edit, ok := a.aliasStubEdit(ref.id); ok {
+			actions = append(actions, newCodeAction(fmt.Sprintf("Create alias stub for '%s'", ref.id), kind, func() *protocol.WorkspaceEdit {
+				return &protocol.WorkspaceEdit{
+					Changes: map[protocol.DocumentUri][]protocol.TextEdit{uri: {edit}},
+				}
+			}))
+		}
+	}
+	return actions, nil
+}
+
+// Returns the range of the element, spanning its whole lines when nothing
+// else is on them
+func (a *xmlAnalyzer) elementDeletionRange(el sitter.Node) protocol.Range {
+	rng := nodeRange(el, a.content)
+	start, end := int(el.StartByte()), int(el.EndByte())
+	lineStart := start
+	for lineStart > 0 && (a.content[lineStart-1] == ' ' || a.content[lineStart-1] == '\t') {
+		lineStart--
+	}
+	lineEnd := end
+	for lineEnd < len(a.content) && (a.content[lineEnd] == ' ' || a.content[lineEnd] == '\t' || a.content[lineEnd] == '\r') {
+		lineEnd++
+	}
+	if (lineStart == 0 || a.content[lineStart-1] == '\n') && lineEnd < len(a.content) && a.content[lineEnd] == '\n' {
+		rng.Start.Character = 0
+		rng.End = protocol.Position{Line: rng.End.Line + 1, Character: 0}
+	}
+	return rng
+}
+
+// Inserts `<service id="…" alias=""/>` at the end of the services of the
+// file, indented like the first service
+func (a *xmlAnalyzer) aliasStubEdit(id string) (protocol.TextEdit, bool) {
+	var closing sitter.Node
+	indent := ""
+	walkNodes(a.tree.RootNode(), func(n sitter.Node) {
+		if n.Type() != "element" {
+			return
+		}
+		switch a.elementName(n) {
+		case "services":
+			if closing.IsNull() {
+				closing = n.NamedChild(n.NamedChildCount() - 1)
+			}
+		case "service":
+			if indent == "" {
+				line, _ := lineAt(string(a.content), int(n.StartPoint().Row))
+				indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			}
+		}
+	})
+	if closing.IsNull() || closing.Type() != "ETag" {
+		return protocol.TextEdit{}, false
+	}
+	line, _ := lineAt(string(a.content), int(closing.StartPoint().Row))
+	if strings.TrimSpace(line[:closing.StartPoint().Column]) != "" {
+		return protocol.TextEdit{}, false
+	}
+	pos := protocol.Position{Line: uint32(closing.StartPoint().Row), Character: 0}
+	return protocol.TextEdit{
+		Range:   protocol.Range{Start: pos, End: pos},
+		NewText: fmt.Sprintf("%s<service id=\"%s\" alias=\"\"/>\n", indent, id),
+	}, true
+}
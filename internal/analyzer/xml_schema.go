@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Elements of the Symfony services XSD, along with the elements and the
+// attributes they accept
+type xmlSchemaElement struct {
+	children   []string
+	attributes []string
+}
+
+var xmlServicesSchema = map[string]xmlSchemaElement{
+	"container":  {children: []string{"imports", "parameters", "services", "when"}},
+	"when":       {children: []string{"imports", "parameters", "services"}, attributes: []string{"env"}},
+	"imports":    {children: []string{"import"}},
+	"import":     {attributes: []string{"resource", "type", "ignore-errors"}},
+	"parameters": {children: []string{"parameter"}},
+	"parameter": {
+		children:   []string{"parameter"},
+		attributes: []string{"key", "type", "id", "on-invalid", "trim"},
+	},
+	"services": {children: []string{"service", "prototype", "defaults", "instanceof", "stack"}},
+	"defaults": {
+		children:   []string{"bind", "tag", "resource-tag"},
+		attributes: []string{"public", "autowire", "autoconfigure"},
+	},
+	"instanceof": {
+		children:   []string{"configurator", "call", "tag", "property", "bind"},
+		attributes: []string{"id", "shared", "public", "lazy", "autowire", "autoconfigure", "constructor"},
+	},
+	"service": {
+		children: []string{"file", "argument", "configurator", "factory", "deprecated", "call", "tag", "resource-tag", "property", "bind", "from-callable"},
+		attributes: []string{
+			"id", "class", "shared", "public", "synthetic", "lazy", "abstract", "alias", "parent",
+			"decorates", "decoration-on-invalid", "decoration-inner-name", "decoration-priority",
+			"autowire", "autoconfigure", "constructor",
+		},
+	},
+	"prototype": {
+		children: []string{"argument", "configurator", "factory", "deprecated", "call", "tag", "resource-tag", "property", "bind", "exclude"},
+		attributes: []string{
+			"namespace", "resource", "exclude", "shared", "public", "lazy", "abstract", "parent",
+			"autowire", "autoconfigure", "constructor",
+		},
+	},
+	"stack": {children: []string{"service", "deprecated"}, attributes: []string{"id", "public"}},
+	"argument": {
+		children: []string{"argument"},
+		attributes: []string{
+			"id", "key", "type", "index", "on-invalid", "tag", "index-by",
+			"default-index-method", "default-priority-method", "exclude", "exclude-self",
+		},
+	},
+	"property":      {children: []string{"argument"}, attributes: []string{"name", "id", "type", "on-invalid"}},
+	"bind":          {children: []string{"argument"}, attributes: []string{"key", "type", "id", "on-invalid", "method"}},
+	"call":          {children: []string{"argument"}, attributes: []string{"method", "returns-clone"}},
+	"tag":           {attributes: []string{"name"}},
+	"resource-tag":  {attributes: []string{"name"}},
+	"factory":       {attributes: []string{"class", "service", "method", "expression", "function"}},
+	"configurator":  {attributes: []string{"class", "service", "method", "function"}},
+	"deprecated":    {attributes: []string{"package", "version"}},
+	"from-callable": {children: []string{"service"}},
+}
+
+var (
+	xmlCommentRe       = regexp.MustCompile(`(?s)<!--.*?-->`)
+	xmlTagRe           = regexp.MustCompile(`<(/?)([\w:.-]+)[^<>]*?(/?)>`)
+	xmlElementPrefixRe = regexp.MustCompile(`<([\w:.-]*)$`)
+	xmlAttrPrefixRe    = regexp.MustCompile(`^<([\w:.-]+)((?:\s+[\w:.-]+\s*=\s*(?:"[^"]*"|'[^']*'))*)\s+([\w:.-]*)$`)
+	xmlAttrNameRe      = regexp.MustCompile(`([\w:.-]+)\s*=`)
+)
+
+// Returns the names of the elements left open in text, innermost last
+func xmlOpenElements(text string) []string {
+	var stack []string
+	text = xmlCommentRe.ReplaceAllString(text, "")
+	for _, m := range xmlTagRe.FindAllStringSubmatch(text, -1) {
+		switch {
+		case m[3] == "/":
+		case m[1] == "/":
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == m[2] {
+					stack = stack[:i]
+					break
+				}
+			}
+		default:
+			stack = append(stack, m[2])
+		}
+	}
+	return stack
+}
+
+// Completes the child elements after `<` and the attributes inside a tag
+// from the services schema. The caller holds the lock.
+func (a *xmlAnalyzer) schemaCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	caret := lspPosToByteOffset(a.content, pos)
+	if caret < 0 || caret > len(a.content) {
+		return nil
+	}
+	before := string(a.content[:caret])
+	open := strings.LastIndexByte(before, '<')
+	if open == -1 || strings.ContainsRune(before[open:], '>') || strings.LastIndex(before, "<!--") > strings.LastIndex(before, "-->") {
+		return nil
+	}
+	tag := before[open:]
+
+	if m := xmlElementPrefixRe.FindStringSubmatch(tag); m != nil {
+		stack := xmlOpenElements(before[:open])
+		if len(stack) == 0 {
+			return nil
+		}
+		kind := protocol.CompletionItemKindKeyword
+		items := []protocol.CompletionItem{}
+		for _, name := range xmlServicesSchema[stack[len(stack)-1]].children {
+			if strings.HasPrefix(name, m[1]) {
+				items = append(items, protocol.CompletionItem{Label: name, Kind: &kind})
+			}
+		}
+		return items
+	}
+
+	m := xmlAttrPrefixRe.FindStringSubmatch(tag)
+	if m == nil {
+		return nil
+	}
+	used := make(map[string]bool)
+	for _, attr := range xmlAttrNameRe.FindAllStringSubmatch(m[2], -1) {
+		used[attr[1]] = true
+	}
+
+	kind := protocol.CompletionItemKindProperty
+	items := []protocol.CompletionItem{}
+	for _, name := range xmlServicesSchema[m[1]].attributes {
+		if used[name] || !strings.HasPrefix(name, m[3]) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: name, Kind: &kind}
+		insert := name + `=""`
+		if a.snippets {
+			insert = name + `="$1"`
+			format := protocol.InsertTextFormatSnippet
+			item.InsertTextFormat = &format
+		}
+		item.InsertText = &insert
+		items = append(items, item)
+	}
+	return items
+}
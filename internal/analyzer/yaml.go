@@ -1,40 +1,72 @@
 package analyzer
 
 import (
-	"regexp"
+	"context"
 	"sort"
 	"strings"
+	"sync"
 
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	"github.com/shinyvision/vimfony/internal/config"
 	php "github.com/shinyvision/vimfony/internal/php"
 	"github.com/shinyvision/vimfony/internal/twig"
 	"github.com/shinyvision/vimfony/internal/utils"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 type yamlAnalyzer struct {
+	parser    *sitter.Parser
+	mu        sync.RWMutex
+	tree      *sitter.Tree
 	lines     []string
 	content   string
+	docs      []*yamllib.Node
 	container *config.ContainerConfig
 	autoload  config.AutoloadMap
 	store     *php.DocumentStore
 	path      string
+	features  config.Features
 }
 
 func NewYamlAnalyzer() Analyzer {
-	return &yamlAnalyzer{}
+	return &yamlAnalyzer{parser: yamllib.NewParser()}
 }
 
 func (a *yamlAnalyzer) Changed(code []byte, change *sitter.InputEdit) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.tree != nil && change != nil {
+		a.tree.Edit(*change)
+	}
+	tree, err := a.parser.ParseString(context.Background(), a.tree, code)
+	if err != nil {
+		return err
+	}
+	if a.tree != nil {
+		a.tree.Close()
+	}
+	a.tree = tree
 	a.content = string(code)
 	a.lines = strings.Split(a.content, "\n")
+	caretLine := -1
+	if change != nil {
+		caretLine = int(change.NewEndPoint.Row)
+	}
+	a.docs = yamllib.FromTree(tree, code, caretLine)
 	return nil
 }
 
 func (a *yamlAnalyzer) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.tree != nil {
+		a.tree.Close()
+		a.tree = nil
+	}
 	a.lines = nil
 	a.content = ""
+	a.docs = nil
 }
 
 func (a *yamlAnalyzer) SetContainerConfig(container *config.ContainerConfig) {
@@ -57,34 +89,49 @@ func (a *yamlAnalyzer) SetDocumentPath(path string) {
 	a.path = path
 }
 
-func (a *yamlAnalyzer) hasServicePrefix(pos protocol.Position) (bool, string) {
-	if int(pos.Line) >= len(a.lines) {
-		return false, ""
-	}
+func (a *yamlAnalyzer) SetFeatures(features config.Features) {
+	a.features = features
+}
 
-	line := a.lines[pos.Line]
-	if int(pos.Character) > len(line) {
-		return false, ""
+// Returns the text of the scalar at pos up to the caret, without its opening
+// quote, when the caret is on a value
+func (a *yamlAnalyzer) valuePrefix(pos protocol.Position) (*yamllib.Node, string, bool) {
+	node, onKey := yamllib.NodeAt(a.docs, pos)
+	if node == nil || onKey || node.Kind != yamllib.Scalar || node.Range.Start.Line != pos.Line {
+		return nil, "", false
 	}
-
-	re := regexp.MustCompile(`services\:\s*([a-zA-Z0-9_.-]*)$`)
-	matches := re.FindStringSubmatch(line[:pos.Character])
-	if len(matches) > 1 {
-		return true, matches[1]
+	line, caret, ok := a.caretLine(pos)
+	start := byteColumn(line, node.Range.Start.Character)
+	if !ok || start > caret {
+		return nil, "", false
 	}
+	prefix := line[start:caret]
+	prefix = strings.TrimLeft(prefix, `'"`)
+	return node, prefix, true
+}
 
-	re2 := regexp.MustCompile(`['"]@([a-zA-Z0-9_.-]*)'`)
-	allMatches := re2.FindAllStringSubmatch(line, -1)
-	for _, match := range allMatches {
-		if len(match) > 1 {
-			return true, match[1]
-		}
+// Returns the line of pos and the byte column of its character, false when
+// the character is past the end of the line
+func (a *yamlAnalyzer) caretLine(pos protocol.Position) (string, int, bool) {
+	line, ok := lineAt(a.content, int(pos.Line))
+	if !ok {
+		return "", 0, false
 	}
+	caret, ok := utils.ByteColumn([]byte(line), pos.Character)
+	return line, caret, ok
+}
 
-	return false, ""
+func (a *yamlAnalyzer) hasServicePrefix(pos protocol.Position) (bool, string) {
+	_, prefix, ok := a.valuePrefix(pos)
+	if !ok || !strings.HasPrefix(prefix, "@") {
+		return false, ""
+	}
+	return true, strings.TrimPrefix(strings.TrimPrefix(prefix, "@"), "?")
 }
 
-func (a *yamlAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.CompletionItem, error) {
+func (a *yamlAnalyzer) OnCompletion(_ context.Context, pos protocol.Position) ([]protocol.CompletionItem, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	if a.container == nil {
 		return nil, nil
 	}
@@ -99,6 +146,57 @@ func (a *yamlAnalyzer) OnCompletion(pos protocol.Position) ([]protocol.Completio
 		items = append(items, a.serviceCompletionItems(prefix)...)
 	}
 
+	if attr, prefix, ok := a.listenerTagAttributeAt(pos); ok {
+		if attr.Key == "event" {
+			items = append(items, eventCompletionItems(prefix, a.autoload)...)
+		} else {
+			items = append(items, a.listenerMethodCompletionItems(serviceDefinitionOf(attr), prefix)...)
+		}
+	}
+
+	if prefix, isKey, ok := a.classNameContext(pos); ok {
+		items = append(items, a.classNameCompletionItems(pos, prefix, isKey)...)
+	}
+
+	if node, prefix, ok := a.valuePrefix(pos); ok && isTaggedArgument(node) {
+		items = append(items, tagCompletionItems(a.container, strings.TrimSpace(prefix))...)
+	}
+
+	if prefix, ok := a.tagNamePrefix(pos); ok {
+		items = append(items, tagCompletionItems(a.container, prefix)...)
+	}
+
+	if service, prefix, ok := a.serviceKeyContext(pos); ok {
+		items = append(items, a.serviceKeyCompletionItems(service, pos.Line, prefix)...)
+	}
+
+	if node, prefix, ok := a.valuePrefix(pos); ok && node.Key == "controller" && a.routeOf(node) == node.Parent {
+		items = append(items, a.controllerCompletionItems(pos, prefix)...)
+	}
+
+	if route, section, prefix, ok := a.routePlaceholderKeyContext(pos); ok {
+		items = append(items, routePlaceholderCompletionItems(route, section, pos.Line, prefix)...)
+	}
+
+	if ref, parent, prefix, ok := a.configKeyContext(pos); ok {
+		items = append(items, configKeyCompletionItems(ref, parent, pos.Line, prefix)...)
+	}
+
+	if section, prefix, ok := a.namedArgumentKeyContext(pos); ok {
+		items = append(items, a.namedArgumentCompletionItems(section, pos.Line, prefix)...)
+	}
+	items = append(items, a.namedArgumentValueCompletionItems(pos)...)
+
+	if prefix, ok := a.parameterPrefix(pos); ok {
+		items = append(items, a.parameterCompletionItems(prefix)...)
+	}
+
+	if line, caret, ok := a.caretLine(pos); ok {
+		if prefix, ok := envVarPrefix(line[:caret]); ok {
+			items = append(items, envVarCompletionItems(a.container, prefix)...)
+		}
+	}
+
 	if len(items) == 0 {
 		return nil, nil
 	}
@@ -140,6 +238,24 @@ func (a *yamlAnalyzer) serviceCompletionItems(prefix string) []protocol.Completi
 		}
 	}
 
+	// Services defined in this file, in when@env sections too
+	for _, def := range a.localDefinitions("services") {
+		if strings.HasPrefix(def.name, ".") || !strings.Contains(def.name, prefix) {
+			continue
+		}
+		if seen[def.name] {
+			a.addEnvDetail(items, def)
+			continue
+		}
+		detail := def.detail(serviceDefinitionClass(def.node))
+		items = append(items, protocol.CompletionItem{
+			Label:  def.name,
+			Kind:   &kind,
+			Detail: &detail,
+		})
+		seen[def.name] = true
+	}
+
 	sort.Slice(items, func(i, j int) bool {
 		idI := items[i].Label
 		idJ := items[j].Label
@@ -156,47 +272,15 @@ func (a *yamlAnalyzer) serviceCompletionItems(prefix string) []protocol.Completi
 }
 
 func (a *yamlAnalyzer) templatePrefix(pos protocol.Position) (bool, string) {
-	lineIdx := int(pos.Line)
-	if lineIdx < 0 || lineIdx >= len(a.lines) {
-		return false, ""
-	}
-
-	line := a.lines[lineIdx]
-	colonIdx := strings.Index(line, ":")
-	if colonIdx < 0 {
-		return false, ""
-	}
-
-	key := strings.TrimSpace(line[:colonIdx])
-	if key != "template" {
+	node, prefix, ok := a.valuePrefix(pos)
+	if !ok || node.Key != "template" {
 		return false, ""
 	}
-
-	charIdx := int(pos.Character)
-	if charIdx < 0 {
-		charIdx = 0
-	}
-	if charIdx > len(line) {
-		charIdx = len(line)
-	}
-	if charIdx <= colonIdx {
-		return false, ""
-	}
-
-	valueSegment := line[colonIdx+1 : charIdx]
-	valueSegment = strings.TrimLeft(valueSegment, " \t")
-	prefix := valueSegment
-	if len(prefix) > 0 && (prefix[0] == '\'' || prefix[0] == '"') {
-		prefix = prefix[1:]
-	}
-	prefix = strings.TrimSuffix(prefix, "'")
-	prefix = strings.TrimSuffix(prefix, "\"")
-	prefix = strings.TrimSpace(prefix)
-	return true, prefix
+	return true, strings.TrimSpace(prefix)
 }
 
 func (a *yamlAnalyzer) templateCompletionItems(prefix string) []protocol.CompletionItem {
-	if a.container == nil {
+	if a.container == nil || !a.features.Enabled(config.FeatureTemplates) {
 		return nil
 	}
 
@@ -236,39 +320,78 @@ func (a *yamlAnalyzer) templateCompletionItems(prefix string) []protocol.Complet
 	return items
 }
 
-func (a *yamlAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location, error) {
+func (a *yamlAnalyzer) OnDefinition(_ context.Context, pos protocol.Position) ([]protocol.Location, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	if a.container == nil {
 		return nil, nil
 	}
 
-	if twigPath, ok := twig.PathAt(a.content, pos); ok {
-		if target, ok := twig.Resolve(twigPath, a.container); ok {
-			loc := protocol.Location{
-				URI:   protocol.DocumentUri(utils.PathToURI(target)),
-				Range: protocol.Range{},
-			}
-			return []protocol.Location{loc}, nil
+	if twigPath, ok := twig.PathAt(a.content, pos); ok && a.features.Enabled(config.FeatureTemplates) {
+		if locs, ok := templateLocations(twigPath, a.container); ok {
+			return locs, nil
 		}
 	}
 
-	line, ok := lineAt(a.content, int(pos.Line))
-	if !ok || line == "" {
-		return nil, nil
+	node, onKey := yamllib.NodeAt(a.docs, pos)
+	if node != nil && !onKey && node.Alias != "" {
+		if anchor := yamllib.FindAnchor(node.Document(), node.Alias); anchor != nil && a.path != "" {
+			return []protocol.Location{{
+				URI:   a.documentURI(),
+				Range: anchor.AnchorRange,
+			}}, nil
+		}
 	}
 
-	token, _, _, ok := extractIdentifier(line, int(pos.Character), isServiceIdentifierWithAtRune)
-	if !ok {
-		return nil, nil
+	if node != nil {
+		if locs, ok := a.routeDefinition(node, onKey, pos); ok {
+			return locs, nil
+		}
+	}
+
+	if name, ok := a.parameterNameAt(pos); ok {
+		if def, ok := a.localDefinition("parameters", name); ok {
+			return []protocol.Location{a.definitionLocation(def)}, nil
+		}
+	}
+
+	if node != nil && !onKey && isTaggedArgument(node) {
+		return a.taggedServiceLocations(node.Value), nil
 	}
-	token = trimQuotes(strings.TrimSpace(token))
+
+	token := ""
+	switch {
+	case node != nil && onKey:
+		token = node.Key
+	case node != nil && node.Kind == yamllib.Scalar:
+		token = node.Value
+	default:
+		line, ok := lineAt(a.content, int(pos.Line))
+		if !ok || line == "" {
+			return nil, nil
+		}
+		token, _, _, ok = extractIdentifier(line, byteColumn(line, pos.Character), isServiceIdentifierWithAtRune)
+		if !ok {
+			return nil, nil
+		}
+	}
+
+	return a.resolveToken(trimQuotes(strings.TrimSpace(token))), nil
+}
+
+func (a *yamlAnalyzer) resolveToken(token string) []protocol.Location {
 	if token == "" {
-		return nil, nil
+		return nil
 	}
 
 	if strings.HasPrefix(token, "@") {
-		serviceID := strings.TrimPrefix(token, "@")
+		serviceID := strings.TrimPrefix(strings.TrimPrefix(token, "@"), "?")
 		if locs, ok := resolveServiceIDLocations(serviceID, a.container, a.autoload, a.store); ok {
-			return locs, nil
+			return locs
+		}
+		// Services the compiled container doesn't know, e.g. of another environment
+		if def, ok := a.localDefinition("services", serviceID); ok {
+			return []protocol.Location{a.definitionLocation(def)}
 		}
 		// fall through to consider remainder for classes or aliases without '@'
 		token = serviceID
@@ -276,13 +399,13 @@ func (a *yamlAnalyzer) OnDefinition(pos protocol.Position) ([]protocol.Location,
 
 	if strings.Contains(token, "\\") {
 		if locs, ok := resolveClassLocations(token, a.container, a.autoload, a.store); ok {
-			return locs, nil
+			return locs
 		}
 	}
 
 	if locs, ok := resolveServiceIDLocations(token, a.container, a.autoload, a.store); ok {
-		return locs, nil
+		return locs
 	}
 
-	return nil, nil
+	return nil
 }
@@ -1,9 +1,12 @@
 package analyzer
 
 import (
+	"context"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shinyvision/vimfony/internal/config"
 	php "github.com/shinyvision/vimfony/internal/php"
@@ -40,27 +43,27 @@ func TestYAMLAnalyzerOnDefinition(t *testing.T) {
 		},
 	}
 	an.SetAutoloadMap(&autoload)
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	an.SetDocumentStore(store)
 	an.SetDocumentPath("/tmp/test.yaml")
 	require.NoError(t, an.Changed([]byte(content), nil))
 
 	servicePos := positionAfter(t, []byte(content), "@test.service", len("@test"))
-	serviceLocs, err := an.OnDefinition(servicePos)
+	serviceLocs, err := an.OnDefinition(context.Background(), servicePos)
 	require.NoError(t, err)
 	require.NotEmpty(t, serviceLocs)
 	expectedClassPath := filepath.Join(mockRoot, "vendor", "TestClass.php")
 	require.Equal(t, protocol.DocumentUri(utils.PathToURI(expectedClassPath)), serviceLocs[0].URI)
 
 	classPos := positionAfter(t, []byte(content), "VendorNamespace\\TestClass", len("VendorNamespace\\"))
-	classLocs, err := an.OnDefinition(classPos)
+	classLocs, err := an.OnDefinition(context.Background(), classPos)
 	require.NoError(t, err)
 	require.NotEmpty(t, classLocs)
 	require.Equal(t, protocol.DocumentUri(utils.PathToURI(expectedClassPath)), classLocs[0].URI)
 
 	twigPos := positionAfter(t, []byte(content), "template.html.twig", len("template"))
-	twigLocs, err := an.OnDefinition(twigPos)
+	twigLocs, err := an.OnDefinition(context.Background(), twigPos)
 	require.NoError(t, err)
 	require.NotEmpty(t, twigLocs)
 	expectedTwig := filepath.Join(mockRoot, "template.html.twig")
@@ -97,7 +100,7 @@ func TestYAMLTemplateCompletion(t *testing.T) {
 
 	for _, tc := range testCases {
 		pos := yamlPositionAfter(t, content, tc.needle, tc.offset)
-		items, err := an.OnCompletion(pos)
+		items, err := an.OnCompletion(context.Background(), pos)
 		require.NoErrorf(t, err, "completion error for %s context", tc.label)
 		require.NotEmptyf(t, items, "expected completion items for %s context", tc.label)
 
@@ -130,3 +133,722 @@ func yamlPositionAfter(t *testing.T, content, needle string, offset int) protoco
 		Character: uint32(col),
 	}
 }
+
+func TestYAMLEnvVarCompletion(t *testing.T) {
+	content := `doctrine:
+  dbal:
+    url: '%env(resolve:DATA'
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		EnvVars:           map[string]string{"DATABASE_URL": "mysql://db", "APP_SECRET": ""},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), positionAfter(t, []byte(content), "resolve:DATA", len("resolve:DATA")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "DATABASE_URL", items[0].Label)
+	require.Equal(t, "mysql://db", *items[0].Detail)
+}
+
+func TestYAMLEnvVarCompletionCountsUTF16(t *testing.T) {
+	content := `doctrine:
+  dbal:
+    url: 'é😀%env(`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		EnvVars:           map[string]string{"DATABASE_URL": "mysql://db", "APP_SECRET": ""},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	// The caret is after `%env(`, counted in UTF-16 code units
+	items, err := an.OnCompletion(context.Background(), protocol.Position{Line: 2, Character: 18})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+}
+
+func TestYAMLServiceCompletionInCollections(t *testing.T) {
+	content := `services:
+    App\Foo:
+        arguments: { $logger: '@log' }
+---
+services:
+    App\Bar:
+        calls:
+            - [setLogger, ['@lo']]
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"logger": "Psr\\Log\\LoggerInterface", "router": "Router"},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	for _, needle := range []string{"'@log'", "'@lo'"} {
+		items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, needle, len(needle)-1))
+		require.NoError(t, err)
+		require.Len(t, items, 1, needle)
+		require.Equal(t, "logger", items[0].Label)
+	}
+}
+
+func TestYAMLAnalyzerDefinitionInCollections(t *testing.T) {
+	content := `parameters:
+    defaults: &defaults
+        class: VendorNamespace\TestClass
+services:
+    app.foo:
+        <<: *defaults
+        arguments:
+            - { inner: '@test.service' }
+`
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:     mockRoot,
+		Roots:             []string{"."},
+		BundleRoots:       make(map[string][]string),
+		ServiceClasses:    map[string]string{"test.service": "VendorNamespace\\TestClass"},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"VendorNamespace\\": {"vendor"}}}
+	an.SetAutoloadMap(&autoload)
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	an.SetDocumentPath("/tmp/services.yaml")
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	expectedClass := protocol.DocumentUri(utils.PathToURI(filepath.Join(mockRoot, "vendor", "TestClass.php")))
+
+	locs, err := an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "@test.service", 3))
+	require.NoError(t, err)
+	require.NotEmpty(t, locs)
+	require.Equal(t, expectedClass, locs[0].URI)
+
+	locs, err = an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "TestClass", 2))
+	require.NoError(t, err)
+	require.NotEmpty(t, locs)
+	require.Equal(t, expectedClass, locs[0].URI)
+
+	locs, err = an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "*defaults", 3))
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	require.Equal(t, protocol.DocumentUri(utils.PathToURI("/tmp/services.yaml")), locs[0].URI)
+	require.Equal(t, yamlPositionAfter(t, content, "&defaults", 0), locs[0].Range.Start)
+}
+
+func TestYAMLServiceKeyCompletion(t *testing.T) {
+	content := `services:
+    app.foo:
+        class: App\Foo
+        arg
+    app.bar:
+        tags: [app.tag]
+        
+    app.baz: ~
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "arg", 3))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "arguments", items[0].Label)
+	require.Equal(t, "arguments: ", *items[0].InsertText)
+
+	items, err = an.OnCompletion(context.Background(), protocol.Position{Line: 6, Character: 8})
+	require.NoError(t, err)
+	labels := make([]string, 0, len(items))
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	require.Contains(t, labels, "class")
+	require.NotContains(t, labels, "tags")
+
+	items, err = an.OnCompletion(context.Background(), protocol.Position{Line: 6, Character: 4})
+	require.NoError(t, err)
+	require.Empty(t, items)
+}
+
+func TestYAMLTagNameCompletion(t *testing.T) {
+	content := `services:
+    App\Listener:
+        tags:
+            - { name: kernel.ev }
+            - kernel.
+    App\Other:
+        tags:
+            - name: 
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		ServiceTags:       map[string]int{"kernel.event_listener": 3, "kernel.reset": 8, "twig.extension": 5},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "kernel.ev", len("kernel.ev")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "kernel.event_listener", items[0].Label)
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "- kernel.\n", len("- kernel.")))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "kernel.reset", items[0].Label)
+	require.Equal(t, "kernel.event_listener", items[1].Label)
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "- name: ", len("- name: ")))
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.Equal(t, "8 services", *items[0].Detail)
+}
+
+func TestYAMLEventListenerTagCompletion(t *testing.T) {
+	content := `services:
+    app.listener:
+        class: VendorNamespace\TestClass
+        tags:
+            - { name: kernel.event_listener, event: kernel.re, method: ind }
+    VendorNamespace\TestClass:
+        tags:
+            - kernel.event_listener: { event: console., method:  }
+`
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"VendorNamespace\\": {"vendor"}}}
+	an.SetAutoloadMap(&autoload)
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	labels := func(items []protocol.CompletionItem) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "kernel.re", len("kernel.re")))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"kernel.request", "kernel.response"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "method: ind", len("method: ind")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"index"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "console.", len("console.")))
+	require.NoError(t, err)
+	require.Contains(t, labels(items), "console.command")
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "method:  }", len("method: ")))
+	require.NoError(t, err)
+	require.Contains(t, labels(items), "index")
+	require.NotContains(t, labels(items), "__invoke")
+}
+
+func TestYAMLClassNameCompletion(t *testing.T) {
+	content := `services:
+    app.foo:
+        class: VendorNamespace\Fo
+    Test
+`
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    make(map[string]string),
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"VendorNamespace\\": {"vendor"}}}
+	autoload.Classes, autoload.Files = config.BuildClassIndex(autoload, mockRoot)
+	an.SetAutoloadMap(&autoload)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "Namespace\\Fo", len("Namespace\\Fo")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "VendorNamespace\\FooClass", items[0].Label)
+	edit := items[0].TextEdit.(protocol.TextEdit)
+	require.Equal(t, "VendorNamespace\\FooClass", edit.NewText)
+	require.Equal(t, uint32(15), edit.Range.Start.Character)
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "    Test", len("    Test")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "VendorNamespace\\TestClass", items[0].Label)
+	edit = items[0].TextEdit.(protocol.TextEdit)
+	require.Equal(t, "VendorNamespace\\TestClass:", edit.NewText)
+	require.Equal(t, uint32(4), edit.Range.Start.Character)
+}
+
+func TestYAMLTaggedIteratorArguments(t *testing.T) {
+	content := `services:
+    App\HandlerChain:
+        arguments:
+            - !tagged_iterator app.handler
+            - !tagged_locator { tag: app.h, index_by: key }
+`
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"app.test_handler": "VendorNamespace\\TestClass"},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		ServiceTags:       map[string]int{"app.handler": 1, "kernel.reset": 4},
+		TaggedServices:    map[string][]string{"app.handler": {"app.test_handler"}},
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"VendorNamespace\\": {"vendor"}}}
+	an.SetAutoloadMap(&autoload)
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "tag: app.h", len("tag: app.h")))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "app.handler", items[0].Label)
+
+	locs, err := an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "app.handler", 3))
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	require.Equal(t, protocol.DocumentUri(utils.PathToURI(filepath.Join(mockRoot, "vendor", "TestClass.php"))), locs[0].URI)
+}
+
+func TestYAMLWhenEnvSections(t *testing.T) {
+	content := `parameters:
+    app.name: shop
+services:
+    app.mailer:
+        class: App\Mailer
+
+when@dev:
+    parameters:
+        app.debug_dir: '%kernel.project_dir%/var/debug'
+    services:
+        app.profiler_mailer:
+            class: App\ProfilerMailer
+            arguments: ['@app.mailer', '%app.debug_dir%', '%app.']
+            aut
+
+        app.dev_only:
+            arguments: ['@app.']
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"app.mailer": "App\\Mailer"},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		Parameters:        config.ParametersMap{"app.name": "shop"},
+	})
+	an.SetDocumentPath("/tmp/services.yaml")
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	details := func(items []protocol.CompletionItem) map[string]string {
+		out := make(map[string]string)
+		for _, item := range items {
+			out[item.Label] = ""
+			if item.Detail != nil {
+				out[item.Label] = *item.Detail
+			}
+		}
+		return out
+	}
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "'@app.'", len("'@app.")))
+	require.NoError(t, err)
+	services := details(items)
+	require.Equal(t, "App\\Mailer", services["app.mailer"])
+	require.Equal(t, "App\\ProfilerMailer (when@dev)", services["app.profiler_mailer"])
+	require.Equal(t, "when@dev", services["app.dev_only"])
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "'%app.'", len("'%app.")))
+	require.NoError(t, err)
+	parameters := details(items)
+	require.Equal(t, "shop", parameters["app.name"])
+	require.Equal(t, "%kernel.project_dir%/var/debug (when@dev)", parameters["app.debug_dir"])
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "aut", len("aut")))
+	require.NoError(t, err)
+	require.Contains(t, details(items), "autowire")
+
+	locs, err := an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "%app.debug_dir%", 3))
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	require.Equal(t, yamlPositionAfter(t, content, "app.debug_dir:", 0), locs[0].Range.Start)
+}
+
+func TestYAMLRoutesEditing(t *testing.T) {
+	tmpDir := t.TempDir()
+	controllerPath := filepath.Join(tmpDir, "src", "Controller", "BlogController.php")
+	require.NoError(t, os.MkdirAll(filepath.Dir(controllerPath), 0o755))
+	require.NoError(t, os.WriteFile(controllerPath, []byte(`<?php
+
+namespace App\Controller;
+
+class BlogController
+{
+    public function __construct()
+    {
+    }
+
+    public function show(int $id): void
+    {
+    }
+
+    public function list(): void
+    {
+    }
+}
+`), 0o644))
+
+	content := `blog_show:
+    path: /blog/{id}/{slug}
+    controller: App\Controller\BlogController::show
+    requirements:
+        id: '\d+'
+        
+
+blog_new:
+    controller: Blog
+
+when@dev:
+    blog_list:
+        controller: App\Controller\BlogController::li
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"app.blog_feed": "App\\Feed"},
+		ServiceAliases:    make(map[string]string),
+		ServiceReferences: make(map[string]int),
+		TaggedServices:    map[string][]string{"controller.service_arguments": {"app.blog_feed"}},
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"App\\": {"src"}}}
+	autoload.Classes, autoload.Files = config.BuildClassIndex(autoload, tmpDir)
+	an.SetAutoloadMap(&autoload)
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, tmpDir)
+	an.SetDocumentStore(store)
+	an.SetDocumentPath(filepath.Join(tmpDir, "config", "routes.yaml"))
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	labels := func(items []protocol.CompletionItem) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "controller: Blog", len("controller: Blog")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"App\\Controller\\BlogController", "app.blog_feed"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "::li", len("::li")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"list"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), protocol.Position{Line: 5, Character: 8})
+	require.NoError(t, err)
+	require.Equal(t, []string{"slug"}, labels(items))
+
+	controllerURI := protocol.DocumentUri(utils.PathToURI(controllerPath))
+	locs, err := an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "::show", 3))
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	require.Equal(t, controllerURI, locs[0].URI)
+	require.Equal(t, uint32(10), locs[0].Range.Start.Line)
+
+	locs, err = an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "BlogController::show", 2))
+	require.NoError(t, err)
+	require.NotEmpty(t, locs)
+	require.Equal(t, controllerURI, locs[0].URI)
+
+	locs, err = an.OnDefinition(context.Background(), yamlPositionAfter(t, content, "id: '", 1))
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+	require.Equal(t, yamlPositionAfter(t, content, "{id}", 1), locs[0].Range.Start)
+}
+
+func TestYAMLNamedArgumentCompletion(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(tmpDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("src/Mailer.php", `<?php
+
+namespace App;
+
+use Psr\Log\LoggerInterface;
+
+class Mailer
+{
+    public function __construct(private LoggerInterface $logger, string $senderAddress)
+    {
+    }
+}
+`)
+	write("src/Notifier.php", `<?php
+
+namespace App;
+
+class Notifier
+{
+    public function __construct(Mailer $mailer)
+    {
+    }
+}
+`)
+
+	content := `services:
+    _defaults:
+        bind:
+            $
+    App\Mailer:
+        arguments:
+            $logger: 
+            $sen
+    App\Notifier: ~
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses:    map[string]string{"logger": "Monolog\\Logger", "monolog.logger.mail": "Monolog\\Logger"},
+		ServiceAliases:    map[string]string{"Psr\\Log\\LoggerInterface": "logger"},
+		ServiceReferences: make(map[string]int),
+		Parameters:        config.ParametersMap{"app.sender_address": "noreply@example.com"},
+	})
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"App\\": {"src"}}}
+	an.SetAutoloadMap(&autoload)
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, tmpDir)
+	an.SetDocumentStore(store)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	labels := func(items []protocol.CompletionItem) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "$sen", len("$sen")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"$senderAddress"}, labels(items))
+	require.Equal(t, "string", *items[0].Detail)
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "            $\n", len("            $")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"$logger", "$mailer", "$senderAddress"}, labels(items))
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "$logger: ", len("$logger: ")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"@Psr\\Log\\LoggerInterface"}, labels(items))
+}
+
+func TestYAMLBundleConfigKeys(t *testing.T) {
+	root := t.TempDir()
+	script := filepath.Join(root, "php")
+	dump := `twig:
+    # The default path used to load templates.
+    default_path:         '%kernel.project_dir%/templates'
+    globals:
+        # Prototype
+        key:
+            id:                   ~
+            value:                ~
+    strict_variables:     ~
+`
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ncat <<'EOF'\n"+dump+"EOF\n"), 0o755))
+
+	container := &config.ContainerConfig{WorkspaceRoot: root}
+	container.EnableConfigReference(script)
+	require.Nil(t, container.ConfigReference("twig"))
+	require.Eventually(t, func() bool { return container.ConfigReference("twig") != nil }, 5*time.Second, 10*time.Millisecond)
+
+	content := `twig:
+    default_path: templates
+    str
+    globals:
+        app_name:
+            valeu: Vimfony
+    paths: ~
+when@dev:
+    twig:
+        debug: true
+`
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(container)
+	an.SetDocumentPath(filepath.Join(root, "config", "packages", "twig.yaml"))
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	items, err := an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "str", 3))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "strict_variables", items[0].Label)
+	require.Equal(t, "strict_variables: ", *items[0].InsertText)
+
+	items, err = an.OnCompletion(context.Background(), yamlPositionAfter(t, content, "valeu", 3))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "value", items[0].Label)
+
+	diagnostics, err := an.OnDiagnostics()
+	require.NoError(t, err)
+	messages := make([]string, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		messages = append(messages, d.Message)
+	}
+	require.ElementsMatch(t, []string{
+		"Unknown option 'valeu' under 'twig.globals.app_name'",
+		"Unknown option 'paths' under 'twig'",
+		"Unknown option 'debug' under 'twig'",
+	}, messages)
+
+	an.SetDocumentPath(filepath.Join(root, "config", "services.yaml"))
+	diagnostics, err = an.OnDiagnostics()
+	require.NoError(t, err)
+	require.Empty(t, diagnostics)
+}
+
+func TestYAMLArgumentInlayHints(t *testing.T) {
+	content := `parameters:
+  app.admin_email: 'admin@example.com'
+
+services:
+  App\Mailer:
+    arguments:
+      - '@mailer'
+      - '%app.admin_email%'
+      - '@?app.local'
+      - '%kernel.project_dir%/var'
+      - '@@literal'
+      - '%unknown%'
+  app.local:
+    class: App\Local
+`
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		ServiceClasses: map[string]string{"mailer.mailer": "Symfony\\Component\\Mailer\\Mailer"},
+		ServiceAliases: map[string]string{"mailer": "mailer.mailer"},
+		Parameters:     config.ParametersMap{"kernel.project_dir": "/app"},
+	})
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	hints, err := an.OnInlayHints(protocol.Range{End: protocol.Position{Line: 100}})
+	require.NoError(t, err)
+
+	labels := map[uint32]any{}
+	for _, hint := range hints {
+		labels[hint.Position.Line] = hint.Label
+	}
+	require.Equal(t, map[uint32]any{
+		6: ": Symfony\\Component\\Mailer\\Mailer",
+		7: "= admin@example.com",
+		8: ": App\\Local",
+		9: "= /app/var",
+	}, labels)
+	require.Equal(t, protocol.Position{Line: 6, Character: 17}, hints[0].Position)
+
+	hints, err = an.OnInlayHints(protocol.Range{Start: protocol.Position{Line: 8}, End: protocol.Position{Line: 8, Character: 100}})
+	require.NoError(t, err)
+	require.Len(t, hints, 1)
+}
+
+func TestYAMLDocumentLinks(t *testing.T) {
+	content := `services:
+  VendorNamespace\TestClass: ~
+  app.foo:
+    class: App\Missing
+    arguments:
+      - '@test.service'
+      - '@@literal'
+      - "template.html.twig"
+`
+
+	mockRoot, err := filepath.Abs("../../mock")
+	require.NoError(t, err)
+
+	an := NewYamlAnalyzer().(*yamlAnalyzer)
+	an.SetContainerConfig(&config.ContainerConfig{
+		WorkspaceRoot:  mockRoot,
+		Roots:          []string{"."},
+		BundleRoots:    make(map[string][]string),
+		ServiceClasses: map[string]string{"test.service": "VendorNamespace\\TestClass"},
+		ServiceAliases: make(map[string]string),
+	})
+	autoload := config.AutoloadMap{
+		PSR4: map[string][]string{
+			"VendorNamespace\\": {"vendor"},
+		},
+	}
+	an.SetAutoloadMap(&autoload)
+	store := php.NewDocumentStore(0)
+	store.Configure(autoload, mockRoot)
+	an.SetDocumentStore(store)
+	require.NoError(t, an.Changed([]byte(content), nil))
+
+	links, err := an.OnDocumentLinks()
+	require.NoError(t, err)
+	require.Len(t, links, 3)
+
+	classURI := protocol.DocumentUri(utils.PathToURI(filepath.Join(mockRoot, "vendor", "TestClass.php")))
+	twigURI := protocol.DocumentUri(utils.PathToURI(filepath.Join(mockRoot, "template.html.twig")))
+	require.Equal(t, positionAfter(t, []byte(content), "VendorNamespace", 0), links[0].Range.Start)
+	require.Equal(t, classURI, *links[0].Target)
+	require.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 5, Character: 9},
+		End:   protocol.Position{Line: 5, Character: 22},
+	}, links[1].Range)
+	require.Equal(t, classURI, *links[1].Target)
+	require.Equal(t, 7, int(links[2].Range.Start.Line))
+	require.Equal(t, twigURI, *links[2].Target)
+}
@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	php "github.com/shinyvision/vimfony/internal/php"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var yamlArgumentKeyPrefixRe = regexp.MustCompile(`^([ \t]*)(\$?[A-Za-z0-9_]*)$`)
+
+var phpBuiltinTypes = map[string]bool{
+	"int": true, "float": true, "string": true, "bool": true, "array": true, "iterable": true,
+	"callable": true, "mixed": true, "object": true, "self": true, "static": true,
+	"null": true, "false": true, "true": true,
+}
+
+type constructorParameter struct {
+	name string
+	// Type as written, and resolved to a FQCN when it is a single class
+	typ   string
+	class string
+}
+
+// Collects the parameters of the class constructor
+func constructorParameters(store *php.DocumentStore, class string) []constructorParameter {
+	if store == nil || class == "" {
+		return nil
+	}
+	path, _, ok := php.Resolve(store, class)
+	if !ok {
+		return nil
+	}
+	doc, err := store.Get(path)
+	if err != nil || doc == nil {
+		return nil
+	}
+
+	var params []constructorParameter
+	doc.Read(func(tree *sitter.Tree, content []byte, index php.IndexedTree) {
+		if tree == nil {
+			return
+		}
+		walkNodes(tree.RootNode(), func(n sitter.Node) {
+			if n.Type() != "method_declaration" {
+				return
+			}
+			name := n.ChildByFieldName("name")
+			list := n.ChildByFieldName("parameters")
+			if name.IsNull() || list.IsNull() || !strings.EqualFold(name.Content(content), "__construct") {
+				return
+			}
+			namespace := phpNamespaceAt(n, content)
+			for i := uint32(0); i < list.NamedChildCount(); i++ {
+				param := list.NamedChild(i)
+				varName := param.ChildByFieldName("name")
+				if varName.IsNull() {
+					continue
+				}
+				p := constructorParameter{name: varName.Content(content)}
+				if typ := param.ChildByFieldName("type"); !typ.IsNull() {
+					p.typ = typ.Content(content)
+					single := strings.TrimPrefix(p.typ, "?")
+					if !strings.ContainsAny(single, "|&") && !phpBuiltinTypes[strings.ToLower(single)] {
+						p.class = resolveClassName(single, namespace, index.Uses)
+					}
+				}
+				params = append(params, p)
+			}
+		})
+	})
+	return params
+}
+
+// Returns the classes whose constructor arguments the `arguments:` or
+// `bind:` mapping at section configures: the service's class, or the classes
+// of every service of the file for `_defaults`
+func (a *yamlAnalyzer) argumentClasses(section *yamllib.Node) []string {
+	if section == nil || !section.IsEntry() || (section.Key != "arguments" && section.Key != "bind") {
+		return nil
+	}
+	service := section.Parent
+	path, _ := configPath(service)
+	if len(path) != 2 || path[0] != "services" {
+		return nil
+	}
+	if service.Key != "_defaults" {
+		if class := serviceDefinitionClass(service); class != "" {
+			return []string{class}
+		}
+		return nil
+	}
+	if section.Key != "bind" {
+		return nil
+	}
+	var classes []string
+	for _, def := range a.localDefinitions("services") {
+		if class := serviceDefinitionClass(def.node); class != "" {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// Returns the `arguments:` or `bind:` mapping a `$name` key is typed in at
+// pos, along with the typed part
+func (a *yamlAnalyzer) namedArgumentKeyContext(pos protocol.Position) (*yamllib.Node, string, bool) {
+	line, caret, ok := a.caretLine(pos)
+	if !ok {
+		return nil, "", false
+	}
+
+	var section *yamllib.Node
+	prefix := ""
+	if node, onKey := yamllib.NodeAt(a.docs, pos); node != nil && onKey {
+		section = node.Parent
+		prefix = line[byteColumn(line, node.KeyRange.Start.Character):caret]
+	} else if m := yamlArgumentKeyPrefixRe.FindStringSubmatch(line[:caret]); m != nil {
+		section = yamllib.ParentAt(a.docs, protocol.Position{Line: pos.Line, Character: uint32(len(m[1]))})
+		prefix = m[2]
+	}
+	if section == nil || !section.IsEntry() || section.Kind == yamllib.Sequence || (section.Key != "arguments" && section.Key != "bind") {
+		return nil, "", false
+	}
+	return section, prefix, true
+}
+
+func (a *yamlAnalyzer) namedArgumentCompletionItems(section *yamllib.Node, line uint32, prefix string) []protocol.CompletionItem {
+	used := make(map[string]bool)
+	for _, child := range section.Children {
+		if child.KeyRange.Start.Line != line {
+			used[child.Key] = true
+		}
+	}
+
+	kind := protocol.CompletionItemKindVariable
+	items := []protocol.CompletionItem{}
+	for _, class := range a.argumentClasses(section) {
+		for _, param := range constructorParameters(a.store, class) {
+			if used[param.name] || !strings.HasPrefix(param.name, prefix) {
+				continue
+			}
+			used[param.name] = true
+			insert := param.name + ": "
+			item := protocol.CompletionItem{Label: param.name, Kind: &kind, InsertText: &insert}
+			if param.typ != "" {
+				detail := param.typ
+				item.Detail = &detail
+			}
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// Completes the empty value of a `$name` argument with the services of its
+// type and the parameters named after it
+func (a *yamlAnalyzer) namedArgumentValueCompletionItems(pos protocol.Position) []protocol.CompletionItem {
+	node, prefix, ok := a.valuePrefix(pos)
+	if !ok || strings.TrimSpace(prefix) != "" || !node.IsEntry() || !strings.HasPrefix(node.Key, "$") {
+		return nil
+	}
+
+	var param *constructorParameter
+	for _, class := range a.argumentClasses(node.Parent) {
+		for _, p := range constructorParameters(a.store, class) {
+			if p.name == node.Key {
+				param = &p
+				break
+			}
+		}
+		if param != nil {
+			break
+		}
+	}
+	if param == nil {
+		return nil
+	}
+
+	items := []protocol.CompletionItem{}
+	if param.class != "" {
+		kind := protocol.CompletionItemKindReference
+		ids := make([]string, 0, len(a.container.ServiceClasses)+len(a.container.ServiceAliases))
+		for id := range a.container.ServiceClasses {
+			ids = append(ids, id)
+		}
+		for id := range a.container.ServiceAliases {
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			// Autowiring aliases are named after the type
+			class, _ := a.container.ResolveServiceId(id)
+			if strings.HasPrefix(id, ".") || !strings.EqualFold(class, param.class) && !strings.EqualFold(id, param.class) {
+				continue
+			}
+			detail := class
+			items = append(items, protocol.CompletionItem{Label: "@" + id, Kind: &kind, Detail: &detail})
+		}
+	}
+
+	snake := toSnakeCase(strings.TrimPrefix(param.name, "$"))
+	kind := protocol.CompletionItemKindConstant
+	for name, value := range a.container.Parameters {
+		if strings.HasPrefix(name, ".") || !strings.Contains(name, snake) {
+			continue
+		}
+		detail := a.container.Parameters.Resolve(value)
+		items = append(items, protocol.CompletionItem{Label: "%" + name + "%", Kind: &kind, Detail: &detail})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var (
+	parameterNameRe    = regexp.MustCompile(`^[A-Za-z0-9_.\-]*$`)
+	yamlParameterRefRe = regexp.MustCompile(`%([A-Za-z0-9_.\-]+)%`)
+)
+
+// A service or parameter defined in the open YAML file
+type yamlDefinition struct {
+	name string
+	// Environment of the when@env section holding the definition
+	env  string
+	node *yamllib.Node
+}
+
+// Returns the path of the node with a leading when@env section stripped,
+// along with that environment
+func configPath(n *yamllib.Node) ([]string, string) {
+	path := n.Path()
+	if len(path) > 0 {
+		if env, ok := strings.CutPrefix(path[0], "when@"); ok {
+			return path[1:], env
+		}
+	}
+	return path, ""
+}
+
+// Returns the entries of a top-level section such as services or
+// parameters, including the ones inside when@env sections
+func (a *yamlAnalyzer) localDefinitions(section string) []yamlDefinition {
+	var defs []yamlDefinition
+	add := func(block *yamllib.Node, env string) {
+		if block == nil || block.Kind != yamllib.Mapping {
+			return
+		}
+		for _, entry := range block.Children {
+			// Skip _defaults, _instanceof and resource namespaces
+			if entry.Key == "" || strings.HasPrefix(entry.Key, "_") || strings.HasSuffix(entry.Key, "\\") {
+				continue
+			}
+			defs = append(defs, yamlDefinition{name: entry.Key, env: env, node: entry})
+		}
+	}
+
+	for _, doc := range a.docs {
+		add(doc.Get(section), "")
+		if doc.Kind != yamllib.Mapping {
+			continue
+		}
+		for _, top := range doc.Children {
+			if env, ok := strings.CutPrefix(top.Key, "when@"); ok {
+				add(top.Get(section), env)
+			}
+		}
+	}
+	return defs
+}
+
+func (d yamlDefinition) detail(base string) string {
+	if d.env == "" {
+		return base
+	}
+	if base == "" {
+		return "when@" + d.env
+	}
+	return base + " (when@" + d.env + ")"
+}
+
+func (a *yamlAnalyzer) localDefinition(section, name string) (yamlDefinition, bool) {
+	for _, def := range a.localDefinitions(section) {
+		if def.name == name {
+			return def, true
+		}
+	}
+	return yamlDefinition{}, false
+}
+
+func (a *yamlAnalyzer) definitionLocation(def yamlDefinition) protocol.Location {
+	return protocol.Location{URI: a.documentURI(), Range: def.node.KeyRange}
+}
+
+func (a *yamlAnalyzer) documentURI() protocol.DocumentUri {
+	return protocol.DocumentUri(utils.PathToURI(a.path))
+}
+
+// Appends the environment of a definition to the detail of its completion item
+func (a *yamlAnalyzer) addEnvDetail(items []protocol.CompletionItem, def yamlDefinition) {
+	if def.env == "" {
+		return
+	}
+	for i := range items {
+		if items[i].Label != def.name {
+			continue
+		}
+		base := ""
+		if items[i].Detail != nil {
+			base = *items[i].Detail
+		}
+		detail := def.detail(base)
+		items[i].Detail = &detail
+	}
+}
+
+// Returns the typed part of a parameter name after an opening %
+func (a *yamlAnalyzer) parameterPrefix(pos protocol.Position) (string, bool) {
+	_, prefix, ok := a.valuePrefix(pos)
+	if !ok || strings.Count(prefix, "%")%2 == 0 {
+		return "", false
+	}
+	name := prefix[strings.LastIndex(prefix, "%")+1:]
+	if !parameterNameRe.MatchString(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// Returns the name of the %parameter% under the caret
+func (a *yamlAnalyzer) parameterNameAt(pos protocol.Position) (string, bool) {
+	line, caret, ok := a.caretLine(pos)
+	if !ok {
+		return "", false
+	}
+	for _, m := range yamlParameterRefRe.FindAllStringSubmatchIndex(line, -1) {
+		if m[0] <= caret && caret <= m[1] {
+			return line[m[2]:m[3]], true
+		}
+	}
+	return "", false
+}
+
+func (a *yamlAnalyzer) parameterCompletionItems(prefix string) []protocol.CompletionItem {
+	items := parameterCompletionItems(a.container, prefix)
+	kind := protocol.CompletionItemKindConstant
+	for _, def := range a.localDefinitions("parameters") {
+		if !strings.Contains(def.name, prefix) {
+			continue
+		}
+		if _, ok := a.container.Parameters[def.name]; ok {
+			a.addEnvDetail(items, def)
+			continue
+		}
+		item := protocol.CompletionItem{Label: def.name, Kind: &kind}
+		if detail := def.detail(def.node.Value); detail != "" {
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
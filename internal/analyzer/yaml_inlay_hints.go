@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Longest parameter value shown in a hint, longer ones are cut
+const maxParameterHintLength = 60
+
+// Shows what the arguments of the service definitions resolve to: the class
+// of an @service reference and the value of a %parameter%
+func (a *yamlAnalyzer) OnInlayHints(rng protocol.Range) ([]protocol317.InlayHint, error) {
+	if a.container == nil {
+		return nil, nil
+	}
+
+	parameters := config.ParametersMap{}
+	for _, def := range a.localDefinitions("parameters") {
+		if def.node.Kind == yamllib.Scalar {
+			parameters[def.name] = def.node.Value
+		}
+	}
+	for name, value := range a.container.Parameters {
+		parameters[name] = value
+	}
+
+	var hints []protocol317.InlayHint
+	var visit func(n *yamllib.Node)
+	visit = func(n *yamllib.Node) {
+		if n.Kind != yamllib.Scalar {
+			for _, child := range n.Children {
+				visit(child)
+			}
+			return
+		}
+		end := n.Range.End
+		if !rangeContains(rng, end) {
+			return
+		}
+		if hint, ok := a.argumentInlayHint(n.Value, parameters); ok {
+			hint.Position = end
+			hints = append(hints, hint)
+		}
+	}
+	for _, def := range a.localDefinitions("services") {
+		if arguments := def.node.Get("arguments"); arguments != nil {
+			visit(arguments)
+		}
+	}
+	return hints, nil
+}
+
+func (a *yamlAnalyzer) argumentInlayHint(value string, parameters config.ParametersMap) (protocol317.InlayHint, bool) {
+	if id, ok := strings.CutPrefix(value, "@"); ok {
+		// @@ escapes a string starting with @
+		if strings.HasPrefix(id, "@") {
+			return protocol317.InlayHint{}, false
+		}
+		id = strings.TrimPrefix(id, "?")
+		class, ok := a.container.ResolveServiceId(id)
+		if !ok {
+			if def, found := a.localDefinition("services", id); found {
+				class = serviceDefinitionClass(def.node)
+			}
+		}
+		if class == "" || class == id {
+			return protocol317.InlayHint{}, false
+		}
+		kind := protocol317.InlayHintKindType
+		return protocol317.InlayHint{Label: ": " + class, Kind: &kind}, true
+	}
+
+	if !strings.Contains(value, "%") {
+		return protocol317.InlayHint{}, false
+	}
+	resolved := parameters.Resolve(value)
+	if resolved == value || resolved == "" {
+		return protocol317.InlayHint{}, false
+	}
+	label := resolved
+	if len(label) > maxParameterHintLength {
+		label = label[:maxParameterHintLength] + "…"
+	}
+	return protocol317.InlayHint{
+		Label:       "= " + label,
+		PaddingLeft: &protocol.True,
+		Tooltip:     resolved,
+	}, true
+}
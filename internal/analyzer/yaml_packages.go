@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Top-level keys of a package config file that are not bundle extensions
+var nonExtensionKeys = map[string]bool{
+	"imports":    true,
+	"parameters": true,
+	"services":   true,
+}
+
+func (a *yamlAnalyzer) isPackageConfigFile() bool {
+	return strings.Contains(filepath.ToSlash(a.path), "/config/packages/")
+}
+
+// Returns the reference of the configuration the node sets, when the file
+// configures bundles and the reference of its extension could be dumped
+func (a *yamlAnalyzer) configReferenceOf(n *yamllib.Node) *config.ConfigReference {
+	if a.container == nil || !a.isPackageConfigFile() {
+		return nil
+	}
+	path, _ := configPath(n)
+	if len(path) == 0 || nonExtensionKeys[path[0]] {
+		return nil
+	}
+	return a.container.ConfigReference(path[0]).Lookup(path[1:])
+}
+
+// Returns the reference of the mapping a key typed at pos belongs to, along
+// with that mapping and the typed part of the key
+func (a *yamlAnalyzer) configKeyContext(pos protocol.Position) (*config.ConfigReference, *yamllib.Node, string, bool) {
+	if !a.isPackageConfigFile() {
+		return nil, nil, "", false
+	}
+	line, caret, ok := a.caretLine(pos)
+	if !ok {
+		return nil, nil, "", false
+	}
+	m := yamlKeyPrefixRe.FindStringSubmatch(line[:caret])
+	if m == nil {
+		return nil, nil, "", false
+	}
+
+	parent := yamllib.ParentAt(a.docs, protocol.Position{Line: pos.Line, Character: uint32(len(m[1]))})
+	if parent == nil {
+		return nil, nil, "", false
+	}
+	ref := a.configReferenceOf(parent)
+	if ref == nil || len(ref.Children) == 0 {
+		return nil, nil, "", false
+	}
+	return ref, parent, m[2], true
+}
+
+func configKeyCompletionItems(ref *config.ConfigReference, parent *yamllib.Node, line uint32, prefix string) []protocol.CompletionItem {
+	used := make(map[string]bool)
+	for _, child := range parent.Children {
+		if child.KeyRange.Start.Line != line {
+			used[child.Key] = true
+		}
+	}
+
+	kind := protocol.CompletionItemKindProperty
+	items := []protocol.CompletionItem{}
+	for _, child := range ref.Children {
+		if used[child.Name] || !strings.HasPrefix(child.Name, prefix) {
+			continue
+		}
+		insert := child.Name + ": "
+		item := protocol.CompletionItem{Label: child.Name, Kind: &kind, InsertText: &insert}
+		if child.Default != "" && child.Default != "~" {
+			detail := child.Default
+			item.Detail = &detail
+		}
+		if child.Doc != "" {
+			item.Documentation = protocol.MarkupContent{Kind: protocol.MarkupKindPlainText, Value: child.Doc}
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// Reports the keys of bundle configurations their extension does not know
+func (a *yamlAnalyzer) configReferenceDiagnostics() []protocol.Diagnostic {
+	if a.container == nil || !a.isPackageConfigFile() {
+		return nil
+	}
+
+	var diagnostics []protocol.Diagnostic
+	var check func(n *yamllib.Node, ref *config.ConfigReference, name string)
+	check = func(n *yamllib.Node, ref *config.ConfigReference, name string) {
+		switch n.Kind {
+		case yamllib.Sequence:
+			if ref.Prototype != nil {
+				for _, item := range n.Children {
+					check(item, ref.Prototype, name)
+				}
+			}
+		case yamllib.Mapping:
+			for _, child := range n.Children {
+				if child.Key == "" || child.Key == "<<" || child.Incomplete {
+					continue
+				}
+				childRef := ref.Child(child.Key)
+				if childRef == nil {
+					// Nodes without fixed keys accept anything
+					if len(ref.Children) > 0 {
+						diagnostics = append(diagnostics, newDiagnostic(
+							child.KeyRange,
+							protocol.DiagnosticSeverityWarning,
+							fmt.Sprintf("Unknown option '%s' under '%s'", child.Key, name),
+						))
+					}
+					continue
+				}
+				check(child, childRef, name+"."+child.Key)
+			}
+		}
+	}
+
+	for _, doc := range a.docs {
+		if doc.Kind != yamllib.Mapping {
+			continue
+		}
+		for _, top := range doc.Children {
+			blocks := []*yamllib.Node{top}
+			if strings.HasPrefix(top.Key, "when@") {
+				blocks = top.Children
+			}
+			for _, block := range blocks {
+				if ref := a.configReferenceOf(block); ref != nil {
+					check(block, ref, block.Key)
+				}
+			}
+		}
+	}
+	return diagnostics
+}
@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/utils"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var routePlaceholderRe = regexp.MustCompile(`\{!?([A-Za-z0-9_]+)`)
+
+func (a *yamlAnalyzer) isRoutesFile() bool {
+	path := filepath.ToSlash(a.path)
+	return strings.HasPrefix(filepath.Base(path), "routes") || strings.Contains(path, "/routes/")
+}
+
+// Returns the route the node belongs to in a routes file
+func (a *yamlAnalyzer) routeOf(n *yamllib.Node) *yamllib.Node {
+	if !a.isRoutesFile() {
+		return nil
+	}
+	for cur := n; cur != nil; cur = cur.Parent {
+		if path, _ := configPath(cur); len(path) == 1 {
+			return cur
+		}
+	}
+	return nil
+}
+
+// Completes `controller:` values with controller classes and controller
+// services, and with the public methods after `Class::`
+func (a *yamlAnalyzer) controllerCompletionItems(pos protocol.Position, prefix string) []protocol.CompletionItem {
+	if class, method, ok := strings.Cut(prefix, "::"); ok {
+		if resolved, ok := a.container.ResolveServiceId(class); ok {
+			class = resolved
+		}
+		return a.publicMethodCompletionItems(normalizeFQN(class), method)
+	}
+
+	lowerPrefix := strings.ToLower(strings.TrimPrefix(prefix, "\\"))
+	rng := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: pos.Character - utils.Character([]byte(prefix), len(prefix))},
+		End:   pos,
+	}
+	items := []protocol.CompletionItem{}
+	seen := make(map[string]bool)
+	add := func(label, detail string, kind protocol.CompletionItemKind) {
+		if seen[label] || !strings.Contains(strings.ToLower(label), lowerPrefix) {
+			return
+		}
+		seen[label] = true
+		item := protocol.CompletionItem{
+			Label:    label,
+			Kind:     &kind,
+			TextEdit: protocol.TextEdit{Range: rng, NewText: label},
+		}
+		if detail != "" {
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+
+	for short, classes := range a.autoload.Classes {
+		if !strings.HasSuffix(short, "Controller") {
+			continue
+		}
+		for _, class := range classes {
+			add(class, "", protocol.CompletionItemKindClass)
+		}
+	}
+	for _, id := range a.container.TaggedServices["controller.service_arguments"] {
+		add(id, a.container.ServiceClasses[id], protocol.CompletionItemKindReference)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// Returns the placeholders of the route's path, which may be localized
+func routePlaceholders(route *yamllib.Node) []string {
+	path := route.Get("path")
+	if path == nil {
+		return nil
+	}
+	values := []*yamllib.Node{path}
+	if path.Kind == yamllib.Mapping {
+		values = path.Children
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, m := range routePlaceholderRe.FindAllStringSubmatch(value.Value, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+// Returns the route and the `requirements:` or `defaults:` mapping when a
+// key of it is typed at pos, along with the typed part
+func (a *yamlAnalyzer) routePlaceholderKeyContext(pos protocol.Position) (*yamllib.Node, *yamllib.Node, string, bool) {
+	line, caret, ok := a.caretLine(pos)
+	if !ok {
+		return nil, nil, "", false
+	}
+
+	var section *yamllib.Node
+	prefix := ""
+	if node, onKey := yamllib.NodeAt(a.docs, pos); node != nil && onKey {
+		section = node.Parent
+		prefix = line[byteColumn(line, node.KeyRange.Start.Character):caret]
+	} else if m := yamlKeyPrefixRe.FindStringSubmatch(line[:caret]); m != nil {
+		section = yamllib.ParentAt(a.docs, protocol.Position{Line: pos.Line, Character: uint32(len(m[1]))})
+		prefix = m[2]
+	}
+	if section == nil || !section.IsEntry() || (section.Key != "requirements" && section.Key != "defaults") {
+		return nil, nil, "", false
+	}
+	route := a.routeOf(section)
+	if route == nil || route != section.Parent {
+		return nil, nil, "", false
+	}
+	return route, section, prefix, true
+}
+
+func routePlaceholderCompletionItems(route, section *yamllib.Node, line uint32, prefix string) []protocol.CompletionItem {
+	used := make(map[string]bool)
+	for _, child := range section.Children {
+		if child.KeyRange.Start.Line != line {
+			used[child.Key] = true
+		}
+	}
+
+	kind := protocol.CompletionItemKindVariable
+	detail := "route placeholder"
+	items := []protocol.CompletionItem{}
+	for _, name := range routePlaceholders(route) {
+		if used[name] || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: &detail})
+	}
+	return items
+}
+
+// Resolves `controller:` values to the controller class or action, and the
+// keys of `requirements:` and `defaults:` to their placeholder in the path
+func (a *yamlAnalyzer) routeDefinition(node *yamllib.Node, onKey bool, pos protocol.Position) ([]protocol.Location, bool) {
+	route := a.routeOf(node)
+	if route == nil {
+		return nil, false
+	}
+
+	if !onKey && node.Key == "controller" && node.Parent == route {
+		class, method, hasMethod := strings.Cut(node.Value, "::")
+		line, caret, _ := a.caretLine(pos)
+		onMethod := hasMethod && strings.Contains(line, "::") && caret > strings.Index(line, "::")
+		if !onMethod {
+			if locs, ok := resolveServiceIDLocations(class, a.container, a.autoload, a.store); ok {
+				return locs, true
+			}
+			return resolveClassLocations(normalizeFQN(class), a.container, a.autoload, a.store)
+		}
+		controller := config.Route{Controller: class, Action: method}
+		doc, uri, ok := routeDocument(controller, a.container, a.autoload, a.store)
+		if !ok {
+			return nil, false
+		}
+		locs := resolveRouteLocations(controller, uri, doc)
+		return locs, len(locs) > 0
+	}
+
+	section := node.Parent
+	if onKey && section != nil && section.Parent == route && (section.Key == "requirements" || section.Key == "defaults") {
+		path := route.Get("path")
+		if path == nil {
+			return nil, false
+		}
+		values := []*yamllib.Node{path}
+		if path.Kind == yamllib.Mapping {
+			values = path.Children
+		}
+		for _, value := range values {
+			line, ok := lineAt(a.content, int(value.Range.Start.Line))
+			if !ok {
+				continue
+			}
+			for _, m := range routePlaceholderRe.FindAllStringSubmatchIndex(line, -1) {
+				if line[m[2]:m[3]] != node.Key || m[0] < byteColumn(line, value.Range.Start.Character) {
+					continue
+				}
+				return []protocol.Location{{
+					URI: a.documentURI(),
+					Range: protocol.Range{
+						Start: protocol.Position{Line: value.Range.Start.Line, Character: utils.Character([]byte(line), m[2])},
+						End:   protocol.Position{Line: value.Range.Start.Line, Character: utils.Character([]byte(line), m[3])},
+					},
+				}}, true
+			}
+		}
+	}
+	return nil, false
+}
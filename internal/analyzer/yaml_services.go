@@ -0,0 +1,254 @@
+package analyzer
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	php "github.com/shinyvision/vimfony/internal/php"
+	yamllib "github.com/shinyvision/vimfony/internal/yaml"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var yamlKeyPrefixRe = regexp.MustCompile(`^([ \t]*)([A-Za-z_]*)$`)
+
+type serviceDefinitionKey struct {
+	name   string
+	detail string
+}
+
+var serviceDefinitionKeys = []serviceDefinitionKey{
+	{"class", "Class of the service"},
+	{"arguments", "Constructor arguments"},
+	{"tags", "Tags of the service"},
+	{"calls", "Setter calls after construction"},
+	{"decorates", "Service this one decorates"},
+	{"factory", "Factory creating the service"},
+	{"autowire", "Autowire the arguments"},
+	{"autoconfigure", "Tag the service from its type"},
+	{"bind", "Arguments bound by name or type"},
+	{"public", "Fetchable from the container"},
+	{"alias", "Service this id is an alias for"},
+	{"parent", "Parent definition to inherit from"},
+	{"abstract", "Template for child definitions"},
+	{"lazy", "Wrap the service in a lazy proxy"},
+	{"shared", "Share one instance"},
+	{"resource", "Directories to register as services"},
+	{"exclude", "Paths left out of the resource"},
+}
+
+// Returns the service definition mapping a key typed at pos belongs to,
+// along with the typed part of the key
+func (a *yamlAnalyzer) serviceKeyContext(pos protocol.Position) (*yamllib.Node, string, bool) {
+	line, caret, ok := a.caretLine(pos)
+	if !ok {
+		return nil, "", false
+	}
+	m := yamlKeyPrefixRe.FindStringSubmatch(line[:caret])
+	if m == nil {
+		return nil, "", false
+	}
+
+	keyPos := protocol.Position{Line: pos.Line, Character: uint32(len(m[1]))}
+	parent := yamllib.ParentAt(a.docs, keyPos)
+	if parent == nil {
+		return nil, "", false
+	}
+	path, _ := configPath(parent)
+	if len(path) != 2 || path[0] != "services" || path[1] == "_instanceof" {
+		return nil, "", false
+	}
+	return parent, m[2], true
+}
+
+func (a *yamlAnalyzer) serviceKeyCompletionItems(service *yamllib.Node, line uint32, prefix string) []protocol.CompletionItem {
+	used := make(map[string]bool)
+	for _, child := range service.Children {
+		if child.KeyRange.Start.Line != line {
+			used[child.Key] = true
+		}
+	}
+
+	kind := protocol.CompletionItemKindProperty
+	items := []protocol.CompletionItem{}
+	for _, key := range serviceDefinitionKeys {
+		if used[key.name] || !strings.HasPrefix(key.name, prefix) {
+			continue
+		}
+		detail := key.detail
+		insert := key.name + ": "
+		items = append(items, protocol.CompletionItem{
+			Label:      key.name,
+			Kind:       &kind,
+			Detail:     &detail,
+			InsertText: &insert,
+		})
+	}
+	return items
+}
+
+// Returns the typed part of a tag name when pos is on the name of a tag of a
+// service, in the short (`- kernel.reset`) or the long (`- name: …`) form
+func (a *yamlAnalyzer) tagNamePrefix(pos protocol.Position) (string, bool) {
+	node, prefix, ok := a.valuePrefix(pos)
+	if !ok {
+		return "", false
+	}
+	path, _ := configPath(node)
+	if len(path) < 4 || path[0] != "services" {
+		return "", false
+	}
+	rest := path[len(path)-2:]
+	if rest[1] == "name" {
+		path = path[:len(path)-1]
+		rest = path[len(path)-2:]
+	}
+	if rest[0] != "tags" || rest[1] != "-" || len(path) < 4 {
+		return "", false
+	}
+	return strings.TrimSpace(prefix), true
+}
+
+// Returns the service definition the node belongs to
+func serviceDefinitionOf(n *yamllib.Node) *yamllib.Node {
+	for cur := n; cur != nil; cur = cur.Parent {
+		if path, _ := configPath(cur); len(path) == 2 && path[0] == "services" {
+			return cur
+		}
+	}
+	return nil
+}
+
+// Returns the class of a service definition, which defaults to its id
+func serviceDefinitionClass(service *yamllib.Node) string {
+	if class := service.Get("class"); class != nil && class.Value != "" {
+		return strings.TrimPrefix(class.Value, "\\")
+	}
+	if strings.Contains(service.Key, "\\") {
+		return strings.TrimPrefix(service.Key, "\\")
+	}
+	return ""
+}
+
+// Returns the attribute and its typed value when pos is on the value of an
+// attribute of a kernel.event_listener tag, either in the `name:` form or
+// keyed by the tag name
+func (a *yamlAnalyzer) listenerTagAttributeAt(pos protocol.Position) (*yamllib.Node, string, bool) {
+	node, prefix, ok := a.valuePrefix(pos)
+	if !ok || !node.IsEntry() || (node.Key != "event" && node.Key != "method") {
+		return nil, "", false
+	}
+	tag := node.Parent
+	isListener := tag.Get("name") != nil && tag.Get("name").Value == "kernel.event_listener"
+	if tag.IsEntry() && tag.Key == "kernel.event_listener" {
+		isListener = true
+		tag = tag.Parent
+	}
+	path, _ := configPath(tag)
+	if !isListener || len(path) < 4 || path[len(path)-2] != "tags" || path[len(path)-1] != "-" {
+		return nil, "", false
+	}
+	return node, strings.TrimSpace(prefix), true
+}
+
+func (a *yamlAnalyzer) listenerMethodCompletionItems(service *yamllib.Node, prefix string) []protocol.CompletionItem {
+	if service == nil || a.store == nil {
+		return nil
+	}
+	return a.publicMethodCompletionItems(serviceDefinitionClass(service), prefix)
+}
+
+// Completes the public methods of a class, leaving out the magic ones
+func (a *yamlAnalyzer) publicMethodCompletionItems(class, prefix string) []protocol.CompletionItem {
+	if class == "" || a.store == nil {
+		return nil
+	}
+	path, _, ok := php.Resolve(a.store, class)
+	if !ok {
+		return nil
+	}
+	doc, err := a.store.Get(path)
+	if err != nil || doc == nil {
+		return nil
+	}
+
+	kind := protocol.CompletionItemKindMethod
+	items := []protocol.CompletionItem{}
+	seen := make(map[string]bool)
+	for _, fn := range doc.Index().PublicFunctions {
+		_, name, _ := strings.Cut(fn.Name, "::")
+		if name == "" || strings.HasPrefix(name, "__") || seen[name] || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		seen[name] = true
+		detail := class
+		items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: &detail})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+var yamlClassKeyPrefixRe = regexp.MustCompile(`^([ \t]*)['"]?([A-Za-z0-9_\\]*)$`)
+
+// Returns the typed part of a class name when pos is on the id of a service
+// being defined or on its `class:` value, and whether it is the id
+func (a *yamlAnalyzer) classNameContext(pos protocol.Position) (string, bool, bool) {
+	if node, prefix, ok := a.valuePrefix(pos); ok {
+		if node.Key != "class" || serviceDefinitionOf(node) != node.Parent {
+			return "", false, false
+		}
+		return prefix, false, true
+	}
+
+	line, caret, ok := a.caretLine(pos)
+	if !ok {
+		return "", false, false
+	}
+	m := yamlClassKeyPrefixRe.FindStringSubmatch(line[:caret])
+	if m == nil {
+		return "", false, false
+	}
+	keyPos := protocol.Position{Line: pos.Line, Character: uint32(len(m[1]))}
+	parent := yamllib.ParentAt(a.docs, keyPos)
+	if parent == nil {
+		return "", false, false
+	}
+	if path, _ := configPath(parent); !slices.Equal(path, []string{"services"}) {
+		return "", false, false
+	}
+	return m[2], true, true
+}
+
+func (a *yamlAnalyzer) classNameCompletionItems(pos protocol.Position, prefix string, isKey bool) []protocol.CompletionItem {
+	suffix := ""
+	if line, caret, ok := a.caretLine(pos); isKey && ok && !strings.Contains(line[caret:], ":") {
+		suffix = ":"
+	}
+	return classCompletionItems(a.autoload, pos, prefix, suffix)
+}
+
+// Reports whether the node holds the tag of a !tagged_iterator or
+// !tagged_locator argument, in the short or the `{ tag: … }` form
+func isTaggedArgument(n *yamllib.Node) bool {
+	isTagged := func(tag string) bool {
+		return tag == "!tagged_iterator" || tag == "!tagged_locator" || tag == "!tagged"
+	}
+	if n.Kind != yamllib.Scalar {
+		return false
+	}
+	if isTagged(n.Tag) {
+		return true
+	}
+	return n.IsEntry() && n.Key == "tag" && isTagged(n.Parent.Tag)
+}
+
+func (a *yamlAnalyzer) taggedServiceLocations(tag string) []protocol.Location {
+	var locations []protocol.Location
+	for _, id := range a.container.TaggedServices[tag] {
+		if locs, ok := resolveServiceIDLocations(id, a.container, a.autoload, a.store); ok {
+			locations = append(locations, locs...)
+		}
+	}
+	return locations
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// FindAppRoot returns the directory of the Symfony app holding path in a
+// repository with several of them: the closest one above it with a
+// composer.json and a bin/console or var/cache, up to the workspace root.
+// Files of no app belong to the workspace root.
+func FindAppRoot(path, workspaceRoot string) string {
+	root := filepath.Clean(workspaceRoot)
+	found := ""
+	for dir := filepath.Dir(filepath.Clean(path)); dir != root; dir = filepath.Dir(dir) {
+		if filepath.Dir(dir) == dir {
+			// path is outside of the workspace
+			return root
+		}
+		switch {
+		case filepath.Base(dir) == "vendor":
+			// The packages of an app are not apps
+			found = ""
+		case found == "" && isAppRoot(dir):
+			found = dir
+		}
+	}
+	if found == "" {
+		return root
+	}
+	return found
+}
+
+func isAppRoot(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "composer.json")); err != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bin", "console")); err == nil {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(dir, "var", "cache"))
+	return err == nil && info.IsDir()
+}
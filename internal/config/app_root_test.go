@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAppRoot(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0o644))
+	}
+
+	write("composer.json")
+	write("apps/admin/composer.json")
+	write("apps/admin/bin/console")
+	write("apps/shop/composer.json")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "apps/shop/var/cache"), 0o755))
+	write("apps/shop/vendor/acme/tool/composer.json")
+	write("apps/shop/vendor/acme/tool/bin/console")
+	write("packages/lib/composer.json")
+
+	admin := filepath.Join(root, "apps/admin")
+	shop := filepath.Join(root, "apps/shop")
+	assert.Equal(t, admin, FindAppRoot(filepath.Join(admin, "src/Controller/HomeController.php"), root))
+	assert.Equal(t, admin, FindAppRoot(filepath.Join(admin, "composer.json"), root))
+	assert.Equal(t, shop, FindAppRoot(filepath.Join(shop, "templates/base.html.twig"), root))
+	assert.Equal(t, shop, FindAppRoot(filepath.Join(shop, "vendor/acme/tool/src/Tool.php"), root))
+	assert.Equal(t, root, FindAppRoot(filepath.Join(root, "packages/lib/src/Lib.php"), root))
+	assert.Equal(t, root, FindAppRoot(filepath.Join(root, "config/services.yaml"), root))
+	assert.Equal(t, root, FindAppRoot("/elsewhere/file.php", root))
+}
@@ -12,12 +12,18 @@ import (
 type AutoloadMap struct {
 	PSR4     map[string][]string
 	Classmap map[string]string
+	Classes  ClassIndex
+	// Files locates the classes of Classes without probing the PSR-4
+	// directories
+	Files ClassFiles
 }
 
 func NewAutoloadMap() AutoloadMap {
 	return AutoloadMap{
 		PSR4:     make(map[string][]string),
 		Classmap: make(map[string]string),
+		Classes:  make(ClassIndex),
+		Files:    make(ClassFiles),
 	}
 }
 
@@ -46,7 +52,11 @@ func GetAutoloadMap(psr4File, classmapFile, phpPath string) (AutoloadMap, error)
 func loadAutoloadSection(autoloadFile, phpPath string, target any) error {
 	data, err := executeAutoloadPHP(autoloadFile, phpPath)
 	if err != nil {
-		return err
+		// PHP may not be installed, the generated file can be read natively
+		if nativeErr := parseAutoloadFile(autoloadFile, target); nativeErr != nil {
+			return fmt.Errorf("%w, and natively: %v", err, nativeErr)
+		}
+		return nil
 	}
 	if err := json.Unmarshal(data, target); err != nil {
 		return fmt.Errorf("could not unmarshal json: %w", err)
@@ -69,7 +79,12 @@ func executeAutoloadPHP(autoloadFile, phpPath string) ([]byte, error) {
 	return out, nil
 }
 
+// AutoloadResolve returns the file defining className. The classes indexed are
+// found without touching the filesystem, the others the way Composer would.
 func AutoloadResolve(className string, autoloadMap AutoloadMap, workspaceRoot string) (string, bool) {
+	if path, ok := autoloadMap.Files[className]; ok {
+		return path, true
+	}
 	if path, ok := autoloadMap.Classmap[className]; ok {
 		if resolved, ok := resolveClassmapPath(path, workspaceRoot); ok {
 			return resolved, true
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	phpforest "github.com/alexaandru/go-sitter-forest/php"
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+)
+
+// Reads a Composer autoload_*.php file without PHP. These files only assign
+// paths built from __DIR__ and dirname() and return an array of them, which
+// is evaluated here and decoded into target like the output of PHP would be.
+func parseAutoloadFile(autoloadFile string, target any) error {
+	content, err := os.ReadFile(autoloadFile)
+	if err != nil {
+		return err
+	}
+
+	parser := sitter.NewParser()
+	_ = parser.SetLanguage(sitter.NewLanguage(phpforest.GetLanguage()))
+	tree, err := parser.ParseString(context.Background(), nil, content)
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", autoloadFile, err)
+	}
+	defer tree.Close()
+
+	eval := autoloadEvaluator{
+		content: content,
+		dir:     filepath.Dir(autoloadFile),
+		vars:    make(map[string]any),
+	}
+	root := tree.RootNode()
+	var result any
+	for i := uint32(0); i < root.NamedChildCount(); i++ {
+		stmt := root.NamedChild(i)
+		switch stmt.Type() {
+		case "expression_statement":
+			if stmt.NamedChildCount() == 0 || stmt.NamedChild(0).Type() != "assignment_expression" {
+				continue
+			}
+			assign := stmt.NamedChild(0)
+			left := assign.ChildByFieldName("left")
+			if value, ok := eval.value(assign.ChildByFieldName("right")); ok && left.Type() == "variable_name" {
+				eval.vars[left.Content(content)] = value
+			}
+		case "return_statement":
+			if stmt.NamedChildCount() > 0 {
+				result, _ = eval.value(stmt.NamedChild(0))
+			}
+		}
+	}
+	if result == nil {
+		return fmt.Errorf("no array returned by %s", autoloadFile)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("could not unmarshal json: %w", err)
+	}
+	return nil
+}
+
+type autoloadEvaluator struct {
+	content []byte
+	dir     string
+	vars    map[string]any
+}
+
+// Evaluates the expressions Composer generates: strings, __DIR__, variables,
+// concatenations, dirname() calls and arrays
+func (e *autoloadEvaluator) value(n sitter.Node) (any, bool) {
+	if n.IsNull() {
+		return nil, false
+	}
+	switch n.Type() {
+	case "string", "encapsed_string":
+		s, ok := phpLiteralString(n, e.content)
+		return unescapePHPString(s), ok
+	case "name":
+		if n.Content(e.content) == "__DIR__" {
+			return e.dir, true
+		}
+	case "variable_name":
+		v, ok := e.vars[n.Content(e.content)]
+		return v, ok
+	case "parenthesized_expression":
+		if n.NamedChildCount() > 0 {
+			return e.value(n.NamedChild(0))
+		}
+	case "binary_expression":
+		if op := n.ChildByFieldName("operator"); op.IsNull() || op.Content(e.content) != "." {
+			return nil, false
+		}
+		left, ok := e.value(n.ChildByFieldName("left"))
+		if !ok {
+			return nil, false
+		}
+		right, ok := e.value(n.ChildByFieldName("right"))
+		if !ok {
+			return nil, false
+		}
+		l, lok := left.(string)
+		r, rok := right.(string)
+		return l + r, lok && rok
+	case "function_call_expression":
+		return e.dirname(n)
+	case "array_creation_expression":
+		return e.array(n)
+	}
+	return nil, false
+}
+
+func (e *autoloadEvaluator) dirname(call sitter.Node) (any, bool) {
+	name := strings.TrimPrefix(call.ChildByFieldName("function").Content(e.content), "\\")
+	args := call.ChildByFieldName("arguments")
+	if name != "dirname" || args.IsNull() || args.NamedChildCount() == 0 {
+		return nil, false
+	}
+	arg, ok := e.value(args.NamedChild(0).NamedChild(0))
+	path, isString := arg.(string)
+	if !ok || !isString {
+		return nil, false
+	}
+	levels := 1
+	if args.NamedChildCount() > 1 {
+		if _, err := fmt.Sscanf(args.NamedChild(1).Content(e.content), "%d", &levels); err != nil {
+			return nil, false
+		}
+	}
+	for i := 0; i < levels; i++ {
+		path = filepath.Dir(path)
+	}
+	return path, true
+}
+
+// Keyed and empty arrays become maps and lists become slices; entries that
+// cannot be evaluated are skipped
+func (e *autoloadEvaluator) array(n sitter.Node) (any, bool) {
+	keyed := make(map[string]any)
+	var list []any
+	for i := uint32(0); i < n.NamedChildCount(); i++ {
+		item := n.NamedChild(i)
+		if item.Type() != "array_element_initializer" || item.NamedChildCount() == 0 {
+			continue
+		}
+		value, ok := e.value(item.NamedChild(item.NamedChildCount() - 1))
+		if !ok {
+			continue
+		}
+		if item.NamedChildCount() == 1 {
+			list = append(list, value)
+			continue
+		}
+		key, ok := e.value(item.NamedChild(0))
+		if k, isString := key.(string); ok && isString {
+			keyed[k] = value
+		}
+	}
+	if len(list) == 0 {
+		return keyed, true
+	}
+	return list, true
+}
+
+// Reads the psr-4 sections of composer.json, for projects whose
+// dependencies are not installed
+func composerJSONAutoload(composerFile string) (AutoloadMap, error) {
+	data, err := os.ReadFile(composerFile)
+	if err != nil {
+		return AutoloadMap{}, err
+	}
+	type autoloadSection struct {
+		PSR4 map[string]any `json:"psr-4"`
+	}
+	var composer struct {
+		Autoload    autoloadSection `json:"autoload"`
+		AutoloadDev autoloadSection `json:"autoload-dev"`
+	}
+	if err := json.Unmarshal(data, &composer); err != nil {
+		return AutoloadMap{}, fmt.Errorf("could not unmarshal %s: %w", composerFile, err)
+	}
+
+	result := NewAutoloadMap()
+	root := filepath.Dir(composerFile)
+	for _, section := range []autoloadSection{composer.Autoload, composer.AutoloadDev} {
+		for namespace, paths := range section.PSR4 {
+			var dirs []string
+			switch v := paths.(type) {
+			case string:
+				dirs = []string{v}
+			case []any:
+				for _, p := range v {
+					if s, ok := p.(string); ok {
+						dirs = append(dirs, s)
+					}
+				}
+			}
+			for _, dir := range dirs {
+				result.PSR4[namespace] = append(result.PSR4[namespace], filepath.Join(root, dir))
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAutoloadFile(t *testing.T) {
+	root := t.TempDir()
+	composerDir := filepath.Join(root, "vendor", "composer")
+	require.NoError(t, os.MkdirAll(composerDir, 0o755))
+
+	psr4 := `<?php
+
+// autoload_psr4.php @generated by Composer
+
+$vendorDir = dirname(__DIR__);
+$baseDir = dirname($vendorDir);
+
+return array(
+    'Symfony\\Component\\Routing\\' => array($vendorDir . '/symfony/routing'),
+    'App\\Tests\\' => array($baseDir . '/tests'),
+    'App\\' => array($baseDir . '/src', $baseDir . '/lib'),
+);
+`
+	classmap := `<?php
+
+$vendorDir = \dirname(__DIR__, 1);
+$baseDir = dirname(__DIR__, 2);
+
+return array(
+    'Composer\\InstalledVersions' => $vendorDir . '/composer/InstalledVersions.php',
+    'App\\Kernel' => $baseDir . '/src/Kernel.php',
+);
+`
+	require.NoError(t, os.WriteFile(filepath.Join(composerDir, "autoload_psr4.php"), []byte(psr4), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(composerDir, "autoload_classmap.php"), []byte(classmap), 0o644))
+
+	var psr4Map map[string][]string
+	require.NoError(t, parseAutoloadFile(filepath.Join(composerDir, "autoload_psr4.php"), &psr4Map))
+	assert.Equal(t, map[string][]string{
+		`Symfony\Component\Routing\`: {filepath.Join(root, "vendor", "symfony", "routing")},
+		`App\Tests\`:                 {filepath.Join(root, "tests")},
+		`App\`:                       {filepath.Join(root, "src"), filepath.Join(root, "lib")},
+	}, psr4Map)
+
+	var classmapMap map[string]string
+	require.NoError(t, parseAutoloadFile(filepath.Join(composerDir, "autoload_classmap.php"), &classmapMap))
+	assert.Equal(t, map[string]string{
+		`Composer\InstalledVersions`: filepath.Join(root, "vendor", "composer", "InstalledVersions.php"),
+		`App\Kernel`:                 filepath.Join(root, "src", "Kernel.php"),
+	}, classmapMap)
+}
+
+func TestComposerJSONAutoload(t *testing.T) {
+	root := t.TempDir()
+	composer := `{
+    "autoload": {"psr-4": {"App\\": "src/", "Lib\\": ["lib/", "legacy/"]}},
+    "autoload-dev": {"psr-4": {"App\\Tests\\": "tests/"}}
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(root, "composer.json"), []byte(composer), 0o644))
+
+	autoloadMap, err := composerJSONAutoload(filepath.Join(root, "composer.json"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		`App\`:       {filepath.Join(root, "src")},
+		`Lib\`:       {filepath.Join(root, "lib"), filepath.Join(root, "legacy")},
+		`App\Tests\`: {filepath.Join(root, "tests")},
+	}, autoloadMap.PSR4)
+}
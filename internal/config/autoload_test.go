@@ -32,3 +32,55 @@ func TestGetAutoloadMap(t *testing.T) {
 	assert.Equal(t, expected.PSR4, autoloadMap.PSR4)
 	assert.Equal(t, expected.Classmap, autoloadMap.Classmap)
 }
+
+func TestBuildClassIndex(t *testing.T) {
+	mockDir, err := filepath.Abs("../../mock")
+	assert.NoError(t, err)
+
+	autoloadMap := AutoloadMap{
+		PSR4: map[string][]string{
+			"BaseNamespace\\": {filepath.Join(mockDir, "base")},
+		},
+		Classmap: map[string]string{
+			"VendorNamespace\\QuxClass": filepath.Join(filepath.Dir(mockDir), "QuxClass.php"),
+		},
+	}
+
+	index, files := BuildClassIndex(autoloadMap, mockDir)
+	assert.Equal(t, []string{"BaseNamespace\\TestClass"}, index.Lookup("TestClass"))
+	assert.Equal(t, []string{"VendorNamespace\\QuxClass"}, index.Lookup("QuxClass"))
+	assert.Empty(t, index.Lookup("Missing"))
+	assert.Equal(t, filepath.Join(mockDir, "base", "TestClass.php"), files["BaseNamespace\\TestClass"])
+	assert.Equal(t, filepath.Join(filepath.Dir(mockDir), "QuxClass.php"), files["VendorNamespace\\QuxClass"])
+}
+
+func TestBuildProjectClassIndexSkipsVendor(t *testing.T) {
+	mockDir, err := filepath.Abs("../../mock")
+	assert.NoError(t, err)
+
+	autoloadMap := AutoloadMap{
+		PSR4: map[string][]string{
+			"BaseNamespace\\":   {"base"},
+			"VendorNamespace\\": {"vendor"},
+		},
+		Classmap: map[string]string{
+			"VendorNamespace\\QuxClass": filepath.Join(mockDir, "vendor", "QuxClass.php"),
+		},
+	}
+
+	index, _ := BuildProjectClassIndex(autoloadMap, mockDir, "vendor")
+	assert.Equal(t, []string{"BaseNamespace\\TestClass"}, index.Lookup("TestClass"))
+	assert.Equal(t, []string{"VendorNamespace\\QuxClass"}, index.Lookup("QuxClass"))
+	assert.Empty(t, index.Lookup("FooClass"))
+}
+
+func TestAutoloadResolveUsesTheIndexedFiles(t *testing.T) {
+	// Indexed, the file is not looked for
+	autoloadMap := AutoloadMap{Files: ClassFiles{"App\\Indexed": "/nowhere/Indexed.php"}}
+	path, ok := AutoloadResolve("App\\Indexed", autoloadMap, t.TempDir())
+	assert.True(t, ok)
+	assert.Equal(t, "/nowhere/Indexed.php", path)
+
+	_, ok = AutoloadResolve("App\\Missing", autoloadMap, t.TempDir())
+	assert.False(t, ok)
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ClassIndex maps short class names to every fully qualified class name known
+// to the autoloader.
+type ClassIndex map[string][]string
+
+// ClassFiles maps fully qualified class names to the file defining them.
+type ClassFiles map[string]string
+
+// BuildClassIndex collects all classes from the classmap and from the files
+// found in the PSR-4 directories, along with the file of each.
+func BuildClassIndex(autoload AutoloadMap, workspaceRoot string) (ClassIndex, ClassFiles) {
+	files := make(ClassFiles)
+	index := make(ClassIndex)
+	add := func(fqcn, path string) {
+		fqcn = strings.TrimPrefix(fqcn, "\\")
+		if fqcn == "" {
+			return
+		}
+		if _, ok := files[fqcn]; ok {
+			return
+		}
+		files[fqcn] = path
+		short := fqcn[strings.LastIndex(fqcn, "\\")+1:]
+		index[short] = append(index[short], fqcn)
+	}
+
+	for fqcn, path := range autoload.Classmap {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workspaceRoot, path)
+		}
+		add(fqcn, path)
+	}
+
+	for namespace, paths := range autoload.PSR4 {
+		for _, path := range paths {
+			root := path
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(workspaceRoot, root)
+			}
+			_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				name := d.Name()
+				if d.IsDir() {
+					if p != root && strings.HasPrefix(name, ".") {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !strings.HasSuffix(name, ".php") || !unicode.IsUpper([]rune(name)[0]) {
+					return nil
+				}
+				rel, err := filepath.Rel(root, strings.TrimSuffix(p, ".php"))
+				if err != nil {
+					return nil
+				}
+				add(namespace+strings.ReplaceAll(rel, string(filepath.Separator), "\\"), p)
+				return nil
+			})
+		}
+	}
+
+	for short := range index {
+		sort.Strings(index[short])
+	}
+	return index, files
+}
+
+// BuildProjectClassIndex is BuildClassIndex without walking the PSR-4
+// directories under vendorDir, which would make startup grow with the
+// dependencies. The classes of the dependencies come from the classmap.
+func BuildProjectClassIndex(autoload AutoloadMap, workspaceRoot, vendorDir string) (ClassIndex, ClassFiles) {
+	if vendorDir != "" && !filepath.IsAbs(vendorDir) {
+		vendorDir = filepath.Join(workspaceRoot, vendorDir)
+	}
+	project := AutoloadMap{PSR4: make(map[string][]string), Classmap: autoload.Classmap}
+	for namespace, paths := range autoload.PSR4 {
+		for _, path := range paths {
+			root := path
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(workspaceRoot, root)
+			}
+			if vendorDir != "" && isWithin(root, vendorDir) {
+				continue
+			}
+			project.PSR4[namespace] = append(project.PSR4[namespace], path)
+		}
+	}
+	return BuildClassIndex(project, workspaceRoot)
+}
+
+// Reports whether path is dir or inside of it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Lookup returns the fully qualified names of the classes with the given short name.
+func (idx ClassIndex) Lookup(short string) []string {
+	return idx[short]
+}
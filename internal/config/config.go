@@ -2,8 +2,11 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/tliron/commonlog"
 )
@@ -14,14 +17,33 @@ type Config struct {
 	Routes    RoutesMap
 	VendorDir string
 	PhpPath   string
+	// RoutesCommand prints the routes as JSON, run in addition to reading
+	// the compiled routes files
+	RoutesCommand []string
+	// DiagnosticsDebounce delays publishing diagnostics after a change
+	DiagnosticsDebounce time.Duration
+	// WatchInterval is how often the container, routes, autoload and
+	// translation files are checked for changes, 0 disables it
+	WatchInterval time.Duration
+	// DocumentMemory is the bytes the parsed PHP documents may retain before
+	// the least recently used are evicted, the store's default when 0
+	DocumentMemory int64
+	// Features turns subsystems off by their Feature name
+	Features Features
+	// LogLevel and LogFile send the logs elsewhere than stderr at info level
+	LogLevel string
+	LogFile  string
 }
 
 func NewConfig() *Config {
 	return &Config{
-		Container: NewContainerConfig(),
-		Autoload:  NewAutoloadMap(),
-		Routes:    make(RoutesMap),
-		PhpPath:   "php",
+		Container:           NewContainerConfig(),
+		Autoload:            NewAutoloadMap(),
+		Routes:              make(RoutesMap),
+		PhpPath:             "php",
+		DiagnosticsDebounce: 300 * time.Millisecond,
+		WatchInterval:       2 * time.Second,
+		Features:            make(Features),
 	}
 }
 
@@ -43,25 +65,30 @@ func (c *Config) LoadAutoloadMap() {
 
 	autoloadMap, err := GetAutoloadMap(psr4File, classmapFile, c.PhpPath)
 	if err != nil {
-		logger.Warningf("could not load autoload map: %v", err)
-		return
+		c.Container.reportProblem(Problem{
+			Message: fmt.Sprintf("could not load the autoload map of '%s': %v", c.VendorDir, err),
+			Hint:    fmt.Sprintf("Run composer install, or check vendor_dir and that php_path '%s' runs PHP", c.PhpPath),
+		})
+		// Without vendor/ the namespaces of the project itself are still known
+		autoloadMap, err = composerJSONAutoload(filepath.Join(c.Container.WorkspaceRoot, "composer.json"))
+		if err != nil {
+			return
+		}
 	}
 
+	autoloadMap.Classes, autoloadMap.Files = BuildProjectClassIndex(autoloadMap, c.Container.WorkspaceRoot, c.VendorDir)
 	c.Autoload = autoloadMap
 	logger.Infof(
-		"loaded %d psr-4 mappings and %d classmap entries",
+		"loaded %d psr-4 mappings, %d classmap entries and %d class names",
 		len(c.Autoload.PSR4),
 		len(c.Autoload.Classmap),
+		len(c.Autoload.Classes),
 	)
 }
 
 func (c *Config) LoadRoutesMap() {
 	logger := commonlog.GetLoggerf("vimfony.config")
-	if len(c.Container.ContainerXMLPaths) == 0 {
-		return
-	}
-
-	c.Routes = make(RoutesMap)
+	routes := make(RoutesMap)
 
 	loaded := 0
 	for idx, containerPath := range c.Container.ContainerXMLPaths {
@@ -85,18 +112,49 @@ func (c *Config) LoadRoutesMap() {
 
 		routesMap, err := GetRoutesMap(routesFile, c.PhpPath)
 		if err != nil {
-			logger.Warningf("could not load routes map from '%s': %v", routesFile, err)
+			c.Container.reportProblem(Problem{
+				Message: fmt.Sprintf("could not load routes map from '%s': %v", routesFile, err),
+				Hint:    fmt.Sprintf("Check that php_path '%s' runs PHP", c.PhpPath),
+			})
 			continue
 		}
 
 		for name, route := range routesMap {
-			c.Routes[name] = route
+			routes[name] = route
 		}
 
 		loaded++
 	}
 
 	if loaded > 0 {
-		logger.Infof("loaded %d routes from %d route files", len(c.Routes), loaded)
+		logger.Infof("loaded %d routes from %d route files", len(routes), loaded)
 	}
+
+	// The command also sees the routes of loaders that are not compiled yet
+	if len(c.RoutesCommand) > 0 {
+		routesMap, err := GetRoutesMapFromCommand(c.RoutesCommand, c.Container.WorkspaceRoot)
+		if err != nil {
+			c.Container.reportProblem(Problem{
+				Message: fmt.Sprintf("could not load routes from '%s': %v", strings.Join(c.RoutesCommand, " "), err),
+				Hint:    "Check routes_command, it must print the routes like bin/console debug:router --format=json",
+			})
+		} else {
+			for name, route := range routesMap {
+				routes[name] = route
+			}
+			logger.Infof("loaded %d routes from '%s'", len(routesMap), strings.Join(c.RoutesCommand, " "))
+			loaded++
+		}
+	}
+
+	// Without PHP at hand, the #[Route] attributes of the controllers still
+	// give most routes
+	if loaded == 0 {
+		routes = ScanRouteAttributes(c.Container.WorkspaceRoot)
+		if len(routes) > 0 {
+			logger.Infof("loaded %d routes from #[Route] attributes", len(routes))
+		}
+	}
+
+	c.Routes = routes
 }
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configReferenceTimeout = 30 * time.Second
+
+var extensionAliasRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ConfigReference is a node of the configuration tree of a bundle
+// extension, as printed by bin/console config:dump-reference
+type ConfigReference struct {
+	Name string
+	// Default value as printed by the dump, "~" when there is none
+	Default string
+	Doc     string
+	// Children of an array node with fixed keys
+	Children []*ConfigReference
+	// Shape of the entries of a prototyped array, whose keys are free
+	Prototype *ConfigReference
+}
+
+// Child returns the node configured by key, following the prototype of
+// arrays with free keys and "-" for list items
+func (r *ConfigReference) Child(key string) *ConfigReference {
+	if r == nil {
+		return nil
+	}
+	if key != "-" {
+		for _, child := range r.Children {
+			if child.Name == key {
+				return child
+			}
+		}
+	}
+	return r.Prototype
+}
+
+// Lookup follows the path of keys down from r
+func (r *ConfigReference) Lookup(path []string) *ConfigReference {
+	cur := r
+	for _, key := range path {
+		if cur = cur.Child(key); cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+type configReferenceCache struct {
+	phpPath string
+	mu      sync.Mutex
+	refs    map[string]*ConfigReference
+	// The aliases whose dump is running
+	pending map[string]bool
+	// Bumped by a reset, so that the dumps running since are dropped
+	generation int
+	loaded     func()
+}
+
+// EnableConfigReference lets ConfigReference run bin/console of the
+// workspace with the given php binary
+func (c *ContainerConfig) EnableConfigReference(phpPath string) {
+	c.configReference = &configReferenceCache{
+		phpPath: phpPath,
+		refs:    make(map[string]*ConfigReference),
+		pending: make(map[string]bool),
+	}
+}
+
+// OnConfigReferenceLoaded sets a function to call when a dump started by
+// ConfigReference finished
+func (c *ContainerConfig) OnConfigReferenceLoaded(loaded func()) {
+	if cache := c.configReference; cache != nil {
+		cache.mu.Lock()
+		cache.loaded = loaded
+		cache.mu.Unlock()
+	}
+}
+
+// ResetConfigReference forgets the dumps, so that they run again with the
+// bundles of a reloaded container
+func (c *ContainerConfig) ResetConfigReference() {
+	if cache := c.configReference; cache != nil {
+		cache.mu.Lock()
+		cache.refs = make(map[string]*ConfigReference)
+		cache.pending = make(map[string]bool)
+		cache.generation++
+		cache.mu.Unlock()
+	}
+}
+
+// ConfigReference returns the configuration tree of the extension with the
+// given alias, such as framework or twig. The dump is slow, so the first call
+// for an alias starts it in the background and returns nil like a disabled or
+// failed dump does; later calls return its result.
+func (c *ContainerConfig) ConfigReference(alias string) *ConfigReference {
+	cache := c.configReference
+	if cache == nil || c.WorkspaceRoot == "" || !extensionAliasRe.MatchString(alias) {
+		return nil
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if ref, ok := cache.refs[alias]; ok {
+		return ref
+	}
+	if !cache.pending[alias] {
+		cache.pending[alias] = true
+		go cache.dump(c.WorkspaceRoot, alias, cache.generation)
+	}
+	return nil
+}
+
+func (cache *configReferenceCache) dump(root, alias string, generation int) {
+	ctx, cancel := context.WithTimeout(context.Background(), configReferenceTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, cache.phpPath, "bin/console", "config:dump-reference", alias, "--format=yaml", "--no-ansi")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	var ref *ConfigReference
+	if err == nil {
+		ref = ParseConfigReference(out, alias)
+	}
+
+	cache.mu.Lock()
+	if generation != cache.generation {
+		cache.mu.Unlock()
+		return
+	}
+	delete(cache.pending, alias)
+	// Failures are kept as well until the next reset
+	cache.refs[alias] = ref
+	loaded := cache.loaded
+	cache.mu.Unlock()
+
+	if loaded != nil {
+		loaded()
+	}
+}
+
+// ParseConfigReference reads the YAML printed by config:dump-reference for
+// the extension with the given alias
+func ParseConfigReference(data []byte, alias string) *ConfigReference {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == alias {
+			return configReferenceNode(root.Content[i], root.Content[i+1])
+		}
+	}
+	return nil
+}
+
+func configReferenceNode(key, value *yaml.Node) *ConfigReference {
+	ref := &ConfigReference{Name: key.Value, Doc: configReferenceDoc(key.HeadComment)}
+	switch value.Kind {
+	case yaml.ScalarNode:
+		ref.Default = value.Value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			child := configReferenceNode(value.Content[i], value.Content[i+1])
+			if strings.Contains(value.Content[i].HeadComment, "# Prototype") {
+				ref.Prototype = child
+				continue
+			}
+			ref.Children = append(ref.Children, child)
+		}
+	case yaml.SequenceNode:
+		if len(value.Content) > 0 {
+			ref.Prototype = configReferenceNode(&yaml.Node{Value: "-"}, value.Content[0])
+		}
+	}
+	return ref
+}
+
+// Keeps the description of a node, leaving out the markers and the examples
+// the dump prints along with it
+func configReferenceDoc(comment string) string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+		if strings.HasPrefix(line, "Example") {
+			break
+		}
+		if line == "" || line == "Prototype" || line == "Required" || strings.HasPrefix(line, "Default configuration for") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const twigReferenceDump = `# Default configuration for extension with alias: "twig"
+twig:
+
+    # The default path used to load templates.
+    default_path:         '%kernel.project_dir%/templates'
+    form_themes:
+
+        # Default:
+        - form_div_layout.html.twig
+    globals:
+
+        # Example:
+        # foo:                 '@bar'
+
+        # Prototype
+        key:
+            id:                   ~
+            value:                ~
+    strict_variables:     '%kernel.debug%'
+`
+
+func TestParseConfigReference(t *testing.T) {
+	ref := ParseConfigReference([]byte(twigReferenceDump), "twig")
+	require.NotNil(t, ref)
+	assert.Equal(t, "twig", ref.Name)
+
+	names := make([]string, 0, len(ref.Children))
+	for _, child := range ref.Children {
+		names = append(names, child.Name)
+	}
+	assert.Equal(t, []string{"default_path", "form_themes", "globals", "strict_variables"}, names)
+
+	defaultPath := ref.Child("default_path")
+	require.NotNil(t, defaultPath)
+	assert.Equal(t, "%kernel.project_dir%/templates", defaultPath.Default)
+	assert.Equal(t, "The default path used to load templates.", defaultPath.Doc)
+
+	// Globals take any name
+	global := ref.Lookup([]string{"globals", "app_name"})
+	require.NotNil(t, global)
+	assert.NotNil(t, global.Child("id"))
+	assert.Empty(t, ref.Child("globals").Children)
+
+	assert.NotNil(t, ref.Lookup([]string{"form_themes", "-"}))
+	assert.Nil(t, ref.Lookup([]string{"default_path", "nested"}))
+	assert.Nil(t, ParseConfigReference([]byte(twigReferenceDump), "framework"))
+}
+
+func TestConfigReferenceRunsConsoleOnce(t *testing.T) {
+	root := t.TempDir()
+	counter := filepath.Join(root, "runs")
+	script := filepath.Join(root, "php")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho run >> "+counter+"\ncat <<'EOF'\n"+twigReferenceDump+"EOF\n"), 0o755))
+
+	c := &ContainerConfig{WorkspaceRoot: root}
+	assert.Nil(t, c.ConfigReference("twig"), "disabled unless enabled")
+
+	c.EnableConfigReference(script)
+	loaded := make(chan struct{}, 1)
+	c.OnConfigReferenceLoaded(func() { loaded <- struct{}{} })
+	assert.Nil(t, c.ConfigReference("twig"), "dumped in the background")
+	assert.Nil(t, c.ConfigReference("twig"))
+	<-loaded
+	require.NotNil(t, c.ConfigReference("twig"))
+	assert.Nil(t, c.ConfigReference("when@dev"))
+
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(runs))
+
+	c.ResetConfigReference()
+	assert.Nil(t, c.ConfigReference("twig"), "dumped again after a reset")
+	<-loaded
+	require.NotNil(t, c.ConfigReference("twig"))
+	runs, err = os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "run\nrun\n", string(runs))
+}
@@ -3,14 +3,16 @@ package config
 import (
 	"bufio"
 	"encoding/xml"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
-	"unicode/utf8"
+	"time"
 
 	"github.com/shinyvision/vimfony/internal/translations"
 	"github.com/shinyvision/vimfony/internal/utils"
@@ -21,42 +23,90 @@ import (
 type ContainerConfig struct {
 	WorkspaceRoot         string
 	ContainerXMLPaths     []string
+	Environment           string
 	Roots                 []string
+	PublicDir             string
 	BundleRoots           map[string][]string
 	ServiceClasses        map[string]string
 	ServiceAliases        map[string]string
 	TwigFunctions         map[string]protocol.Location
+	TwigFilters           map[string]TwigCallable
+	TwigTests             map[string]TwigCallable
+	SecurityAttributes    map[string]protocol.Location
+	TemplateVariables     map[string][]TemplateVariable
+	RouteUsages           map[string][]protocol.Location // where the app generates each route
+	RouteDeclarations     map[string][]RouteDeclaration  // the #[Route] attributes declaring each route
+	TwigComponents        map[string]TwigComponent
 	ServiceReferences     map[string]int
+	ServiceTags           map[string]int
+	TaggedServices        map[string][]string
+	Parameters            ParametersMap
+	EnvVars               map[string]string
 	TranslationRoots      []string
 	TranslationKeys       translations.TranslationMap
+	TranslationResources  []string
 	DefaultLocale         string
+	FluentSetters         bool
+	TwigIndexLimit        int64
 	DoctrineDrivers       []DoctrineDriverMapping
 	ResolveTargetEntities map[string]string
+	twigTemplateFiles     map[string][]string
 	twigTemplates         []string
 	twigTemplateSig       string
 	twigMu                sync.Mutex
+	assets                []Asset
+	assetsMu              sync.Mutex
+	routeUsagesMu         sync.RWMutex
+	routeDeclarationsMu   sync.RWMutex
+	configReference       *configReferenceCache
+	xmlServices           xmlServiceIndex
+	environmentXMLPaths   map[string][]string
+	onProblem             func(Problem)
+	// servicesFromYAML tells the services were read from services.yaml
+	servicesFromYAML bool
 }
 
 const targetServiceID = "twig.loader.native_filesystem"
 
+const containerHint = "Run bin/console cache:warmup to compile the container, or set container_xml_path to the container of var/cache"
+
 type containerLoadStats struct {
 	addedBare      int
 	addedBundle    int
 	bundlesTouched map[string]struct{}
 	foundService   bool
+	size           int64
+	services       int
+	references     int
+	// The classes of the twig.extension and security.voter services, whose
+	// files are read once the XML is
+	twigExtensions []string
+	voters         []string
 }
 
 func NewContainerConfig() *ContainerConfig {
 	return &ContainerConfig{
 		Roots:                 []string{"templates"},
-		TranslationRoots:     []string{"translations"},
-		BundleRoots:          make(map[string][]string),
-		ServiceClasses:       make(map[string]string),
-		ServiceAliases:       make(map[string]string),
-		TwigFunctions:        make(map[string]protocol.Location),
-		ServiceReferences:    make(map[string]int),
-		TranslationKeys:      make(translations.TranslationMap),
-		DefaultLocale:        "en",
+		TranslationRoots:      []string{"translations"},
+		PublicDir:             "public",
+		BundleRoots:           make(map[string][]string),
+		ServiceClasses:        make(map[string]string),
+		ServiceAliases:        make(map[string]string),
+		TwigFunctions:         make(map[string]protocol.Location),
+		TwigFilters:           make(map[string]TwigCallable),
+		TwigTests:             make(map[string]TwigCallable),
+		SecurityAttributes:    make(map[string]protocol.Location),
+		TemplateVariables:     make(map[string][]TemplateVariable),
+		RouteUsages:           make(map[string][]protocol.Location),
+		RouteDeclarations:     make(map[string][]RouteDeclaration),
+		TwigComponents:        make(map[string]TwigComponent),
+		ServiceReferences:     make(map[string]int),
+		ServiceTags:           make(map[string]int),
+		TaggedServices:        make(map[string][]string),
+		Parameters:            make(ParametersMap),
+		EnvVars:               make(map[string]string),
+		TranslationKeys:       make(translations.TranslationMap),
+		DefaultLocale:         "en",
 		ResolveTargetEntities: make(map[string]string),
 	}
 }
@@ -87,11 +137,20 @@ func (c *ContainerConfig) LoadFromXML(autoloadMap AutoloadMap) {
 
 	c.ServiceClasses = make(map[string]string)
 	c.ServiceAliases = make(map[string]string)
+	c.servicesFromYAML = false
 	c.ServiceReferences = make(map[string]int)
+	c.ServiceTags = make(map[string]int)
+	c.TaggedServices = make(map[string][]string)
+	c.Parameters = make(ParametersMap)
+	c.EnvVars = make(map[string]string)
 	c.TwigFunctions = make(map[string]protocol.Location)
+	c.TwigFilters = make(map[string]TwigCallable)
+	c.TwigTests = make(map[string]TwigCallable)
+	c.SecurityAttributes = make(map[string]protocol.Location)
 	c.DoctrineDrivers = nil
 	c.ResolveTargetEntities = make(map[string]string)
 	c.twigMu.Lock()
+	c.twigTemplateFiles = nil
 	c.twigTemplates = nil
 	c.twigTemplateSig = ""
 	c.twigMu.Unlock()
@@ -113,11 +172,34 @@ func (c *ContainerConfig) LoadFromXML(autoloadMap AutoloadMap) {
 			absPath = filepath.Join(c.WorkspaceRoot, absPath)
 		}
 
+		if phpPath, ok := containerPHPDumpPath(absPath); ok {
+			found, err := c.loadContainerPHP(phpPath)
+			if err != nil {
+				c.reportProblem(Problem{
+					Message: fmt.Sprintf("cannot read container_xml_path[%d] '%s': %v", idx, phpPath, err),
+					Hint:    containerHint,
+				})
+				continue
+			}
+			logger.Infof("container_xml_path[%d]: loaded %d services from the PHP dump '%s'", idx, found, phpPath)
+			processed++
+			continue
+		}
+
+		start := time.Now()
 		stats, err := c.loadContainerXML(absPath, autoloadMap, dc)
 		if err != nil {
-			logger.Warningf("cannot read container_xml_path[%d] '%s': %v", idx, relPath, err)
+			c.reportProblem(Problem{
+				Message: fmt.Sprintf("cannot read container_xml_path[%d] '%s': %v", idx, relPath, err),
+				Hint:    containerHint,
+			})
 			continue
 		}
+		c.indexServiceClasses(idx, stats, autoloadMap)
+		logger.Infof(
+			"container_xml_path[%d]: read %d services and %d service references from %.1f MB in %v",
+			idx, stats.services, stats.references, float64(stats.size)/(1<<20), time.Since(start).Round(time.Millisecond),
+		)
 
 		processed++
 		totalBare += stats.addedBare
@@ -154,6 +236,10 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 		return stats, err
 	}
 	defer f.Close()
+	if info, err := f.Stat(); err == nil {
+		stats.size = info.Size()
+	}
+	indexed := make(map[string]struct{})
 
 	dec := xml.NewDecoder(f)
 	dec.Strict = false
@@ -173,7 +259,9 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 	var serviceClass string
 
 	inParameter := false
+	parameterDepth := 0
 	parameterKey := ""
+	parameterType := ""
 	var paramBuf strings.Builder
 
 	// Doctrine state: tracks nested context for doctrine-relevant services.
@@ -216,16 +304,23 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 			local := t.Name.Local
 
 			if local == "parameter" {
-				for _, a := range t.Attr {
-					if a.Name.Local == "key" {
-						parameterKey = a.Value
-						break
+				// Nested parameters are collection items of the top-level one
+				if parameterDepth == 0 {
+					parameterKey, parameterType = "", ""
+					for _, a := range t.Attr {
+						switch a.Name.Local {
+						case "key":
+							parameterKey = a.Value
+						case "type":
+							parameterType = a.Value
+						}
+					}
+					if parameterKey != "" {
+						inParameter = true
+						paramBuf.Reset()
 					}
 				}
-				if parameterKey == "kernel.default_locale" {
-					inParameter = true
-					paramBuf.Reset()
-				}
+				parameterDepth++
 			} else if local == "service" {
 				if serviceDepth == 0 {
 					id := ""
@@ -248,6 +343,7 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 					serviceID = ""
 					serviceClass = ""
 					if !isAbstract && id != "" && !strings.Contains(id, " ") {
+						stats.services++
 						serviceID = id
 						if class != "" {
 							if _, exists := c.ServiceClasses[id]; !exists {
@@ -309,8 +405,21 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 						innerID = a.Value
 					}
 				}
-				if name == "twig.extension" && serviceID != "" && serviceClass != "" {
-					c.indexTwigFunctions(serviceClass, autoloadMap)
+				if name != "" {
+					c.ServiceTags[name]++
+					if ids := c.TaggedServices[name]; serviceID != "" && (len(ids) == 0 || ids[len(ids)-1] != serviceID) {
+						c.TaggedServices[name] = append(ids, serviceID)
+					}
+				}
+				if (name == "twig.extension" || name == "security.voter") && serviceID != "" && serviceClass != "" {
+					if _, ok := indexed[name+" "+serviceClass]; !ok {
+						indexed[name+" "+serviceClass] = struct{}{}
+						if name == "twig.extension" {
+							stats.twigExtensions = append(stats.twigExtensions, serviceClass)
+						} else {
+							stats.voters = append(stats.voters, serviceClass)
+						}
+					}
 				}
 				if name == "container.decorator" && len(docServiceStack) > 0 {
 					svcFrame := docServiceStack[len(docServiceStack)-1]
@@ -331,6 +440,7 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 				}
 				if isServiceArg && serviceIDRef != "" {
 					c.ServiceReferences[serviceIDRef]++
+					stats.references++
 				}
 			} else if serviceDepth > 0 && local == "call" {
 				method := ""
@@ -445,6 +555,7 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 			if inParameter {
 				paramBuf.Write(t)
 			}
+			c.collectEnvVars(string(t))
 			if docInCall && docInArg {
 				docCallArgBuf.Write(t)
 			}
@@ -455,10 +566,22 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 		case xml.EndElement:
 			local := t.Name.Local
 
-			if local == "parameter" {
-				if inParameter {
-					c.DefaultLocale = strings.TrimSpace(paramBuf.String())
-					logger.Infof("Found kernel.default_locale: %s", c.DefaultLocale)
+			if local == "parameter" && parameterDepth > 0 {
+				parameterDepth--
+				if parameterDepth == 0 && inParameter {
+					value := strings.TrimSpace(paramBuf.String())
+					if parameterType == "collection" {
+						value = ""
+					}
+					c.Parameters[parameterKey] = value
+					if name, ok := strings.CutPrefix(parameterKey, "env("); ok {
+						// env(NAME) parameters hold the default value of NAME
+						c.addEnvVar(strings.TrimSuffix(name, ")"), value)
+					}
+					if parameterKey == "kernel.default_locale" {
+						c.DefaultLocale = value
+						logger.Infof("Found kernel.default_locale: %s", c.DefaultLocale)
+					}
 					inParameter = false
 				}
 			}
@@ -601,6 +724,30 @@ func (c *ContainerConfig) loadContainerXML(absPath string, autoloadMap AutoloadM
 	return stats, nil
 }
 
+// Reads the classes of the twig.extension and security.voter services of the
+// container XML at container_xml_path[idx]. The Twig callables are skipped
+// when the XML is larger than TwigIndexLimit. The service references need no
+// such pass, they are counted from the XML alone while it is streamed.
+func (c *ContainerConfig) indexServiceClasses(idx int, stats containerLoadStats, autoloadMap AutoloadMap) {
+	if c.TwigIndexLimit > 0 && stats.size > c.TwigIndexLimit {
+		commonlog.GetLoggerf("vimfony.config").Infof(
+			"container_xml_path[%d]: not indexing the Twig functions of %d extensions, the XML is larger than twig_index_limit_mb",
+			idx, len(stats.twigExtensions),
+		)
+	} else {
+		for _, class := range stats.twigExtensions {
+			c.indexTwigFunctions(class, autoloadMap)
+			c.indexTwigCallables(class, autoloadMap, "getFilters", "TwigFilter", c.TwigFilters)
+			c.indexTwigCallables(class, autoloadMap, "getTests", "TwigTest", c.TwigTests)
+		}
+	}
+	for _, class := range stats.voters {
+		c.indexVoterAttributes(class, autoloadMap)
+	}
+}
+
+var twigFunctionRe = regexp.MustCompile(`new\s+TwigFunction\s*\(\s*['"]([^'"]+)['"]`)
+
 func (c *ContainerConfig) indexTwigFunctions(class string, autoloadMap AutoloadMap) {
 	logger := commonlog.GetLoggerf("vimfony.config")
 	path, ok := AutoloadResolve(class, autoloadMap, c.WorkspaceRoot)
@@ -641,13 +788,12 @@ func (c *ContainerConfig) indexTwigFunctions(class string, autoloadMap AutoloadM
 			if braceLevel <= 0 {
 				return
 			}
-			re := regexp.MustCompile(`new\s+TwigFunction\s*\(\s*['"]([^'"]+)['"]`)
-			matches := re.FindAllStringSubmatchIndex(line, -1)
+			matches := twigFunctionRe.FindAllStringSubmatchIndex(line, -1)
 			for _, match := range matches {
 				if len(match) >= 4 {
 					functionName := line[match[2]:match[3]]
-					startCol := utf8.RuneCountInString(line[:match[2]])
-					endCol := startCol + utf8.RuneCountInString(functionName)
+					startCol := characters(line[:match[2]])
+					endCol := startCol + characters(functionName)
 					locRange := protocol.Range{
 						Start: protocol.Position{Line: uint32(lineNumber), Character: uint32(startCol)},
 						End:   protocol.Position{Line: uint32(lineNumber), Character: uint32(endCol)},
@@ -681,20 +827,96 @@ func (c *ContainerConfig) ResolveServiceId(serviceID string) (string, bool) {
 	return "", false
 }
 
-// TwigTemplates returns the set of twig template identifiers discovered from configured roots.
+// TwigTemplates returns the set of twig template identifiers discovered from
+// configured roots. They are collected once per set of roots, then follow the
+// templates reported with TwigTemplateCreated and TwigTemplateDeleted.
 func (c *ContainerConfig) TwigTemplates() []string {
 	c.twigMu.Lock()
 	defer c.twigMu.Unlock()
 
-	sig := c.twigTemplateSignature()
-	if sig == c.twigTemplateSig && c.twigTemplates != nil {
-		return append([]string(nil), c.twigTemplates...)
+	if sig := c.twigTemplateSignature(); sig != c.twigTemplateSig || c.twigTemplateFiles == nil {
+		c.twigTemplateFiles = c.collectTwigTemplates()
+		c.twigTemplateSig = sig
+		c.twigTemplates = nil
+	}
+	if c.twigTemplates == nil {
+		c.twigTemplates = make([]string, 0, len(c.twigTemplateFiles))
+		for name := range c.twigTemplateFiles {
+			c.twigTemplates = append(c.twigTemplates, name)
+		}
+		sort.Strings(c.twigTemplates)
+	}
+	return append([]string(nil), c.twigTemplates...)
+}
+
+// TwigTemplateCreated adds the template at path to the list, under the names
+// its roots give it
+func (c *ContainerConfig) TwigTemplateCreated(path string) {
+	c.twigMu.Lock()
+	defer c.twigMu.Unlock()
+	if c.twigTemplateFiles == nil {
+		return
+	}
+	for _, name := range c.twigTemplateNames(path) {
+		if files := c.twigTemplateFiles[name]; !slices.Contains(files, path) {
+			c.twigTemplateFiles[name] = append(files, path)
+			c.twigTemplates = nil
+		}
+	}
+}
+
+// TwigTemplateDeleted removes the template at path from the list, keeping
+// the names other roots still give
+func (c *ContainerConfig) TwigTemplateDeleted(path string) {
+	c.twigMu.Lock()
+	defer c.twigMu.Unlock()
+	if c.twigTemplateFiles == nil {
+		return
+	}
+	for _, name := range c.twigTemplateNames(path) {
+		files := slices.DeleteFunc(c.twigTemplateFiles[name], func(file string) bool { return file == path })
+		if len(files) == 0 {
+			delete(c.twigTemplateFiles, name)
+		} else {
+			c.twigTemplateFiles[name] = files
+		}
+		c.twigTemplates = nil
+	}
+}
+
+// Returns the names of the template at path, one per root it is under
+func (c *ContainerConfig) twigTemplateNames(path string) []string {
+	if !strings.HasSuffix(strings.ToLower(path), ".twig") {
+		return nil
+	}
+	under := func(base string) (string, bool) {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(c.WorkspaceRoot, base)
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+		return filepath.ToSlash(rel), true
 	}
 
-	templates := c.collectTwigTemplates()
-	c.twigTemplates = templates
-	c.twigTemplateSig = sig
-	return append([]string(nil), templates...)
+	var names []string
+	for _, root := range c.Roots {
+		if rel, ok := under(root); ok {
+			names = append(names, rel)
+		}
+	}
+	for bundle, bases := range c.BundleRoots {
+		if bundle == "" {
+			continue
+		}
+		for _, base := range bases {
+			if rel, ok := under(base); ok {
+				names = append(names, "@"+bundle+"/"+rel)
+			}
+		}
+	}
+	return names
 }
 
 func (c *ContainerConfig) twigTemplateSignature() string {
@@ -720,19 +942,17 @@ func (c *ContainerConfig) twigTemplateSignature() string {
 	return strings.Join(parts, ";")
 }
 
-func (c *ContainerConfig) collectTwigTemplates() []string {
-	seen := make(map[string]struct{})
-	add := func(value string) {
+// Returns the template files of the roots by the names they give
+func (c *ContainerConfig) collectTwigTemplates() map[string][]string {
+	templates := make(map[string][]string)
+	add := func(value, path string) {
 		value = strings.TrimSpace(value)
 		if value == "" {
 			return
 		}
 		value = strings.ReplaceAll(value, "\\", "/")
 		value = strings.TrimPrefix(value, "./")
-		if _, ok := seen[value]; ok {
-			return
-		}
-		seen[value] = struct{}{}
+		templates[value] = append(templates[value], path)
 	}
 
 	for _, root := range c.Roots {
@@ -745,7 +965,7 @@ func (c *ContainerConfig) collectTwigTemplates() []string {
 			if err != nil {
 				return
 			}
-			add(filepath.ToSlash(rel))
+			add(filepath.ToSlash(rel), path)
 		})
 	}
 
@@ -763,16 +983,10 @@ func (c *ContainerConfig) collectTwigTemplates() []string {
 				if err != nil {
 					return
 				}
-				add("@" + bundle + "/" + filepath.ToSlash(rel))
+				add("@"+bundle+"/"+filepath.ToSlash(rel), path)
 			})
 		}
 	}
-
-	templates := make([]string, 0, len(seen))
-	for value := range seen {
-		templates = append(templates, value)
-	}
-	sort.Strings(templates)
 	return templates
 }
 
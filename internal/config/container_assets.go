@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Asset is a path asset() accepts. Target is the public path an AssetMapper
+// manifest entry is compiled to, empty for files of the public directory.
+type Asset struct {
+	Path   string
+	Target string
+}
+
+func (c *ContainerConfig) publicDir() string {
+	dir := c.PublicDir
+	if dir == "" {
+		dir = "public"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(c.WorkspaceRoot, dir)
+	}
+	return dir
+}
+
+// Assets lists the AssetMapper manifest entries and the files of the public
+// directory, sorted by path. The list is built once and kept until
+// LoadAssets.
+func (c *ContainerConfig) Assets() []Asset {
+	c.assetsMu.Lock()
+	defer c.assetsMu.Unlock()
+	if c.assets == nil {
+		c.assets = c.collectAssets()
+	}
+	return c.assets
+}
+
+// LoadAssets rebuilds the list of Assets, e.g. after the public directory or
+// the manifest changed.
+func (c *ContainerConfig) LoadAssets() {
+	assets := c.collectAssets()
+	c.assetsMu.Lock()
+	c.assets = assets
+	c.assetsMu.Unlock()
+}
+
+func (c *ContainerConfig) collectAssets() []Asset {
+	public := c.publicDir()
+	seen := make(map[string]struct{})
+	var assets []Asset
+
+	// Compiled files have a hashed name, their logical path is what asset() wants
+	compiledDir := ""
+	manifestPath := filepath.Join(public, "assets", "manifest.json")
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		var manifest map[string]string
+		if json.Unmarshal(data, &manifest) == nil {
+			compiledDir = filepath.Dir(manifestPath)
+			for logical, target := range manifest {
+				seen[logical] = struct{}{}
+				assets = append(assets, Asset{Path: logical, Target: target})
+			}
+		}
+	}
+
+	_ = filepath.WalkDir(public, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != public && (strings.HasPrefix(name, ".") || p == compiledDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".php") {
+			return nil
+		}
+		rel, err := filepath.Rel(public, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := seen[rel]; ok {
+			return nil
+		}
+		seen[rel] = struct{}{}
+		assets = append(assets, Asset{Path: rel})
+		return nil
+	})
+
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Path < assets[j].Path })
+	if assets == nil {
+		// Not nil, so that an empty directory is not walked again
+		assets = []Asset{}
+	}
+	return assets
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerAssets(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("web/index.php", "<?php")
+	write("web/.htaccess", "")
+	write("web/favicon.ico", "")
+	write("web/images/logo.svg", "")
+	write("web/assets/app-3f2a1b.js", "")
+	write("web/assets/manifest.json", `{"app.js": "/assets/app-3f2a1b.js", "images/logo.svg": "/assets/images/logo-9c8d.svg"}`)
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.PublicDir = "web"
+
+	assert.Equal(t, []Asset{
+		{Path: "app.js", Target: "/assets/app-3f2a1b.js"},
+		{Path: "favicon.ico"},
+		{Path: "images/logo.svg", Target: "/assets/images/logo-9c8d.svg"},
+	}, c.Assets())
+}
+
+func TestContainerAssetsAreKeptUntilLoad(t *testing.T) {
+	root := t.TempDir()
+	public := filepath.Join(root, "public")
+	require.NoError(t, os.MkdirAll(public, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(public, "app.css"), nil, 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	assert.Equal(t, []Asset{{Path: "app.css"}}, c.Assets())
+
+	require.NoError(t, os.WriteFile(filepath.Join(public, "app.js"), nil, 0o644))
+	assert.Equal(t, []Asset{{Path: "app.css"}}, c.Assets())
+
+	c.LoadAssets()
+	assert.Equal(t, []Asset{{Path: "app.css"}, {Path: "app.js"}}, c.Assets())
+	assert.Equal(t, []string{public}, c.AssetArtifacts())
+}
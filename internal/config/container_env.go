@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tliron/commonlog"
+)
+
+var envPlaceholderRe = regexp.MustCompile(`%env\(([^)%]+)\)%`)
+var dotenvLineRe = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+// Records the env vars referenced as %env(...)% in container values. Processors
+// such as %env(bool:resolve:FOO)% are stripped.
+func (c *ContainerConfig) collectEnvVars(text string) {
+	if !strings.Contains(text, "%env(") {
+		return
+	}
+	for _, m := range envPlaceholderRe.FindAllStringSubmatch(text, -1) {
+		name := m[1]
+		if i := strings.LastIndexByte(name, ':'); i >= 0 {
+			name = name[i+1:]
+		}
+		c.addEnvVar(name, "")
+	}
+}
+
+func (c *ContainerConfig) addEnvVar(name, value string) {
+	if name == "" {
+		return
+	}
+	if c.EnvVars == nil {
+		c.EnvVars = make(map[string]string)
+	}
+	if existing, ok := c.EnvVars[name]; ok && value == "" {
+		value = existing
+	}
+	c.EnvVars[name] = value
+}
+
+// LoadEnvFiles reads the .env files in the workspace root. Later files such as
+// .env.local override the values of .env, like the Dotenv component does.
+func (c *ContainerConfig) LoadEnvFiles() {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	if c.WorkspaceRoot == "" {
+		return
+	}
+	if c.EnvVars == nil {
+		c.EnvVars = make(map[string]string)
+	}
+
+	files, err := filepath.Glob(filepath.Join(c.WorkspaceRoot, ".env*"))
+	if err != nil {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return envFileRank(files[i]) < envFileRank(files[j])
+	})
+
+	loaded := 0
+	for _, path := range files {
+		name := filepath.Base(path)
+		if name == ".env.dist" || strings.HasSuffix(name, ".php") {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		if c.loadEnvFile(path) {
+			loaded++
+		}
+	}
+	logger.Infof("loaded %d env vars from %d env files", len(c.EnvVars), loaded)
+}
+
+// .env < .env.local < .env.<env> < .env.<env>.local
+func envFileRank(path string) string {
+	name := filepath.Base(path)
+	switch {
+	case name == ".env":
+		return "0"
+	case name == ".env.local":
+		return "1"
+	case strings.HasSuffix(name, ".local"):
+		return "3" + name
+	}
+	return "2" + name
+}
+
+func (c *ContainerConfig) loadEnvFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		m := dotenvLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := strings.TrimSpace(m[2])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		c.EnvVars[m[1]] = value
+	}
+	return true
+}
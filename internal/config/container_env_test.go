@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerEnvVars(t *testing.T) {
+	root := t.TempDir()
+
+	containerXML := `<?xml version="1.0" encoding="utf-8"?>
+<container xmlns="http://symfony.com/schema/dic/services">
+  <parameters>
+    <parameter key="env(MAILER_DSN)">null://null</parameter>
+  </parameters>
+  <services>
+    <service id="app.client" class="App\Client">
+      <argument>%env(bool:resolve:APP_DEBUG)%</argument>
+    </service>
+  </services>
+</container>
+`
+	containerPath := filepath.Join(root, "container.xml")
+	require.NoError(t, os.WriteFile(containerPath, []byte(containerXML), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("# comment\nAPP_SECRET=abc\nDATABASE_URL=\"mysql://db\"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env.local"), []byte("APP_SECRET=local\n"), 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.SetContainerXMLPaths([]string{containerPath})
+	c.LoadFromXML(NewAutoloadMap())
+	c.LoadEnvFiles()
+
+	assert.Equal(t, "null://null", c.EnvVars["MAILER_DSN"])
+	assert.Contains(t, c.EnvVars, "APP_DEBUG")
+	assert.Equal(t, "local", c.EnvVars["APP_SECRET"])
+	assert.Equal(t, "mysql://db", c.EnvVars["DATABASE_URL"])
+}
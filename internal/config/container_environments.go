@@ -0,0 +1,47 @@
+package config
+
+import "sort"
+
+const defaultEnvironment = "dev"
+
+// SetEnvironmentContainerXMLPaths configures the container XML paths of each
+// kernel environment, such as dev and test. The dev environment is active
+// unless SetEnvironment selects another one.
+func (c *ContainerConfig) SetEnvironmentContainerXMLPaths(paths map[string][]string) {
+	c.environmentXMLPaths = paths
+	env := c.Environment
+	if _, ok := paths[env]; !ok {
+		env = defaultEnvironment
+	}
+	if _, ok := paths[env]; !ok {
+		env = ""
+		if envs := c.Environments(); len(envs) > 0 {
+			env = envs[0]
+		}
+	}
+	c.SetEnvironment(env)
+}
+
+// Environments lists the environments that have container XML paths.
+func (c *ContainerConfig) Environments() []string {
+	envs := make([]string, 0, len(c.environmentXMLPaths))
+	for env := range c.environmentXMLPaths {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+// SetEnvironment makes the container XML paths of env the active ones. The
+// bundle roots of the previous environment are dropped; the caller reloads
+// the container. Reports whether env has paths.
+func (c *ContainerConfig) SetEnvironment(env string) bool {
+	paths, ok := c.environmentXMLPaths[env]
+	if !ok {
+		return false
+	}
+	c.Environment = env
+	c.SetContainerXMLPaths(paths)
+	c.BundleRoots = make(map[string][]string)
+	return true
+}
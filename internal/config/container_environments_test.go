@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerEnvironments(t *testing.T) {
+	c := NewContainerConfig()
+	c.SetEnvironmentContainerXMLPaths(map[string][]string{
+		"dev":  {"var/cache/dev/App_KernelDevDebugContainer.xml"},
+		"test": {"var/cache/test/App_KernelTestDebugContainer.xml"},
+	})
+
+	assert.Equal(t, "dev", c.Environment)
+	assert.Equal(t, []string{"var/cache/dev/App_KernelDevDebugContainer.xml"}, c.ContainerXMLPaths)
+	assert.Equal(t, []string{"dev", "test"}, c.Environments())
+
+	c.BundleRoots["App"] = []string{"templates"}
+	assert.True(t, c.SetEnvironment("test"))
+	assert.Equal(t, []string{"var/cache/test/App_KernelTestDebugContainer.xml"}, c.ContainerXMLPaths)
+	assert.Empty(t, c.BundleRoots)
+
+	assert.False(t, c.SetEnvironment("prod"))
+	assert.Equal(t, "test", c.Environment)
+}
+
+func TestContainerEnvironmentsKeepConfiguredEnvironment(t *testing.T) {
+	c := NewContainerConfig()
+	c.Environment = "test"
+	c.SetEnvironmentContainerXMLPaths(map[string][]string{
+		"dev":  {"dev.xml"},
+		"test": {"test.xml"},
+	})
+	assert.Equal(t, []string{"test.xml"}, c.ContainerXMLPaths)
+
+	c = NewContainerConfig()
+	c.SetEnvironmentContainerXMLPaths(map[string][]string{"staging": {"staging.xml"}, "test": {"test.xml"}})
+	assert.Equal(t, "staging", c.Environment)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ParametersMap holds the container parameters by name. Collections are kept
+// with an empty value.
+type ParametersMap map[string]string
+
+var parameterReferenceRe = regexp.MustCompile(`%([^%\s]+)%`)
+
+// Resolve replaces the %name% references in value with the parameters they
+// point to. Env placeholders and unknown parameters are left untouched.
+func (p ParametersMap) Resolve(value string) string {
+	for range 10 {
+		if !strings.Contains(value, "%") {
+			return value
+		}
+		resolved := parameterReferenceRe.ReplaceAllStringFunc(value, func(ref string) string {
+			name := ref[1 : len(ref)-1]
+			if strings.HasPrefix(name, "env(") {
+				return ref
+			}
+			if v, ok := p[name]; ok && v != "" {
+				return v
+			}
+			return ref
+		})
+		if resolved == value {
+			break
+		}
+		value = resolved
+	}
+	return strings.ReplaceAll(value, "%%", "%")
+}
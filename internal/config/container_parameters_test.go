@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParametersMapResolve(t *testing.T) {
+	params := ParametersMap{
+		"kernel.project_dir": "/app",
+		"app.upload_dir":     "%kernel.project_dir%/public/uploads",
+		"app.thumbs_dir":     "%app.upload_dir%/thumbs",
+		"app.locales":        "",
+	}
+
+	assert.Equal(t, "/app/public/uploads/thumbs", params.Resolve("%app.thumbs_dir%"))
+	assert.Equal(t, "%env(DATABASE_URL)%", params.Resolve("%env(DATABASE_URL)%"))
+	assert.Equal(t, "%unknown%", params.Resolve("%unknown%"))
+	assert.Equal(t, "100%", params.Resolve("100%%"))
+}
+
+func TestContainerParameters(t *testing.T) {
+	root := t.TempDir()
+
+	containerXML := `<?xml version="1.0" encoding="utf-8"?>
+<container xmlns="http://symfony.com/schema/dic/services">
+  <parameters>
+    <parameter key="kernel.project_dir">/app</parameter>
+    <parameter key="kernel.default_locale">nl</parameter>
+    <parameter key="app.locales" type="collection">
+      <parameter>en</parameter>
+      <parameter>nl</parameter>
+    </parameter>
+  </parameters>
+</container>
+`
+	containerPath := filepath.Join(root, "container.xml")
+	require.NoError(t, os.WriteFile(containerPath, []byte(containerXML), 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.SetContainerXMLPaths([]string{containerPath})
+	c.LoadFromXML(NewAutoloadMap())
+
+	assert.Equal(t, "/app", c.Parameters["kernel.project_dir"])
+	assert.Equal(t, "nl", c.DefaultLocale)
+	assert.Contains(t, c.Parameters, "app.locales")
+	assert.NotContains(t, c.Parameters, "en")
+}
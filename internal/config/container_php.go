@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	phpforest "github.com/alexaandru/go-sitter-forest/php"
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+)
+
+var (
+	phpDumpServiceIDRe   = regexp.MustCompile(`Gets the (?:public|private) '([^']+)'`)
+	phpDumpReturnClassRe = regexp.MustCompile(`@return\s+(\S+)`)
+)
+
+// Returns the PHP container dump to read for a configured path: the path
+// itself when it is a .php file, or the dump next to a missing XML file
+func containerPHPDumpPath(absPath string) (string, bool) {
+	switch filepath.Ext(absPath) {
+	case ".php":
+		return absPath, true
+	case ".xml":
+		if _, err := os.Stat(absPath); err == nil {
+			return "", false
+		}
+		phpPath := strings.TrimSuffix(absPath, ".xml") + ".php"
+		if _, err := os.Stat(phpPath); err == nil {
+			return phpPath, true
+		}
+	}
+	return "", false
+}
+
+// Reads the services and aliases of a PHP container dump such as
+// var/cache/dev/App_KernelDevDebugContainer.php. The entry file includes the
+// container class from its Container<hash>/ directory, where each service
+// getter is documented with its id and class. Returns the number of services
+// found.
+func (c *ContainerConfig) loadContainerPHP(absPath string) (int, error) {
+	parser := sitter.NewParser()
+	_ = parser.SetLanguage(sitter.NewLanguage(phpforest.GetLanguage()))
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return 0, err
+	}
+	tree, err := parser.ParseString(context.Background(), nil, content)
+	if err != nil {
+		return 0, err
+	}
+	defer tree.Close()
+
+	classPath, classContent := absPath, content
+	if !hasSitterNodeOfType(tree.RootNode(), "class_declaration") {
+		classPath = includedContainerClass(tree.RootNode(), content, filepath.Dir(absPath))
+		if classPath == "" {
+			return 0, nil
+		}
+		if classContent, err = os.ReadFile(classPath); err != nil {
+			return 0, err
+		}
+	}
+
+	found := c.addPHPDumpServices(parser, classContent)
+	// Services dumped as separate files (getFooService.php) are lazily loaded
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(classPath), "get*.php"))
+	for _, path := range files {
+		if fileContent, err := os.ReadFile(path); err == nil {
+			found += c.addPHPDumpServices(parser, fileContent)
+		}
+	}
+	return found, nil
+}
+
+// Returns the file included through `__DIR__.'/Container…/….php'`
+func includedContainerClass(root sitter.Node, content []byte, dir string) string {
+	path := ""
+	walkSitterNodes(root, func(n sitter.Node) {
+		if path != "" || n.Type() != "binary_expression" || n.NamedChildCount() != 2 {
+			return
+		}
+		if n.NamedChild(0).Content(content) != "__DIR__" {
+			return
+		}
+		rel, ok := phpLiteralString(n.NamedChild(1), content)
+		if !ok || filepath.Ext(rel) != ".php" {
+			return
+		}
+		candidate := filepath.Join(dir, rel)
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+		}
+	})
+	return path
+}
+
+func (c *ContainerConfig) addPHPDumpServices(parser *sitter.Parser, content []byte) int {
+	tree, err := parser.ParseString(context.Background(), nil, content)
+	if err != nil {
+		return 0
+	}
+	defer tree.Close()
+
+	found := 0
+	walkSitterNodes(tree.RootNode(), func(n sitter.Node) {
+		switch n.Type() {
+		case "method_declaration":
+			comment := n.PrevNamedSibling()
+			if comment.IsNull() || comment.Type() != "comment" {
+				return
+			}
+			id, class := phpDumpServiceDoc(comment.Content(content))
+			if id == "" || class == "" {
+				return
+			}
+			if _, exists := c.ServiceClasses[id]; !exists {
+				c.ServiceClasses[id] = class
+				found++
+			}
+		case "assignment_expression":
+			left := n.ChildByFieldName("left")
+			if left.IsNull() || left.Type() != "member_access_expression" {
+				return
+			}
+			if name := left.ChildByFieldName("name"); name.IsNull() || name.Content(content) != "aliases" {
+				return
+			}
+			c.addPHPDumpAliases(n.ChildByFieldName("right"), content)
+		}
+	})
+	return found
+}
+
+// Reads `$this->aliases = ['alias' => 'id', …]`
+func (c *ContainerConfig) addPHPDumpAliases(array sitter.Node, content []byte) {
+	if array.IsNull() || array.Type() != "array_creation_expression" {
+		return
+	}
+	for i := uint32(0); i < array.NamedChildCount(); i++ {
+		item := array.NamedChild(i)
+		if item.Type() != "array_element_initializer" || item.NamedChildCount() != 2 {
+			continue
+		}
+		alias, ok := phpLiteralString(item.NamedChild(0), content)
+		if !ok {
+			continue
+		}
+		target, ok := phpLiteralString(item.NamedChild(1), content)
+		if !ok {
+			continue
+		}
+		alias, target = unescapePHPString(alias), unescapePHPString(target)
+		if _, exists := c.ServiceClasses[alias]; exists {
+			continue
+		}
+		if _, exists := c.ServiceAliases[alias]; !exists {
+			c.ServiceAliases[alias] = target
+		}
+	}
+}
+
+// Reads the id and the class from the doc comment of a service getter:
+//
+//	Gets the public 'foo' shared autowired service.
+//
+//	@return \App\Foo
+func phpDumpServiceDoc(comment string) (string, string) {
+	id := phpDumpServiceIDRe.FindStringSubmatch(comment)
+	ret := phpDumpReturnClassRe.FindStringSubmatch(comment)
+	if id == nil || ret == nil {
+		return "", ""
+	}
+	// Lazy services are documented as `\App\Foo|\Proxy…`
+	class := strings.Split(ret[1], "|")[0]
+	if class == "object" || class == "void" {
+		return "", ""
+	}
+	return id[1], strings.TrimPrefix(class, "\\")
+}
+
+// Single quoted strings of the dump escape the namespace separators
+func unescapePHPString(s string) string {
+	return strings.ReplaceAll(s, `\\`, `\`)
+}
+
+func hasSitterNodeOfType(root sitter.Node, typ string) bool {
+	found := false
+	walkSitterNodes(root, func(n sitter.Node) {
+		if n.Type() == typ {
+			found = true
+		}
+	})
+	return found
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromPHPContainerDump(t *testing.T) {
+	root := t.TempDir()
+
+	entry := `<?php
+
+// This file has been auto-generated by the Symfony Dependency Injection Component for internal use.
+
+if (\class_exists(\ContainerAbc123\App_KernelDevDebugContainer::class, false)) {
+    // no-op
+} elseif (!include __DIR__.'/ContainerAbc123/App_KernelDevDebugContainer.php') {
+    touch(__DIR__.'/ContainerAbc123.legacy');
+
+    return;
+}
+
+return new \ContainerAbc123\App_KernelDevDebugContainer([
+    'container.build_hash' => 'Abc123',
+], __DIR__.\DIRECTORY_SEPARATOR.'ContainerAbc123');
+`
+	class := `<?php
+
+namespace ContainerAbc123;
+
+class App_KernelDevDebugContainer extends Container
+{
+    public function __construct(private array $buildParameters = [], protected string $containerDir = __DIR__)
+    {
+        $this->services = $this->privates = [];
+        $this->methodMap = [
+            'event_dispatcher' => 'getEventDispatcherService',
+        ];
+        $this->fileMap = [
+            'App\\Controller\\PostController' => 'getPostControllerService',
+        ];
+        $this->aliases = [
+            'App\\Kernel' => 'kernel',
+            'Psr\\EventDispatcher\\EventDispatcherInterface' => 'event_dispatcher',
+        ];
+    }
+
+    /**
+     * Gets the public 'event_dispatcher' shared service.
+     *
+     * @return \Symfony\Component\EventDispatcher\EventDispatcher
+     */
+    protected static function getEventDispatcherService($container)
+    {
+        return $container->services['event_dispatcher'] = new \Symfony\Component\EventDispatcher\EventDispatcher();
+    }
+
+    protected function getDefaultParameters(): array
+    {
+        return [];
+    }
+}
+`
+	getter := `<?php
+
+namespace ContainerAbc123;
+
+class getPostControllerService extends App_KernelDevDebugContainer
+{
+    /**
+     * Gets the public 'App\Controller\PostController' shared autowired service.
+     *
+     * @return \App\Controller\PostController
+     */
+    public static function do($container, $lazyLoad = true)
+    {
+        return $container->services['App\\Controller\\PostController'] = new \App\Controller\PostController();
+    }
+}
+`
+	files := map[string]string{
+		"var/cache/dev/App_KernelDevDebugContainer.php":                 entry,
+		"var/cache/dev/ContainerAbc123/App_KernelDevDebugContainer.php": class,
+		"var/cache/dev/ContainerAbc123/getPostControllerService.php":    getter,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	// The XML dump is missing, the PHP one next to it is used instead
+	c.SetContainerXMLPaths([]string{"var/cache/dev/App_KernelDevDebugContainer.xml"})
+	c.LoadFromXML(NewAutoloadMap())
+
+	assert.Equal(t, map[string]string{
+		"event_dispatcher":              `Symfony\Component\EventDispatcher\EventDispatcher`,
+		`App\Controller\PostController`: `App\Controller\PostController`,
+	}, c.ServiceClasses)
+	assert.Equal(t, map[string]string{
+		`App\Kernel`: "kernel",
+		`Psr\EventDispatcher\EventDispatcherInterface`: "event_dispatcher",
+	}, c.ServiceAliases)
+}
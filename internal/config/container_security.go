@@ -0,0 +1,253 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	voterConstantRe  = regexp.MustCompile(`const\s+(?:\w+\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=\s*['"]([^'"]+)['"]`)
+	voterConstRefRe  = regexp.MustCompile(`(?:self|static)::([A-Za-z_][A-Za-z0-9_]*)`)
+	voterStringRe    = regexp.MustCompile(`['"]([A-Za-z][A-Za-z0-9_.:-]*)['"]`)
+	voterSupportsRe  = regexp.MustCompile(`function\s+supports(?:Attribute)?\s*\((?:\s*[\w\\?|]*\s*\$(\w+))?`)
+	voterInArrayRe   = regexp.MustCompile(`in_array\s*\(\s*\$(\w+)\s*,\s*\[`)
+	voterCompareRe   = regexp.MustCompile(`\$(\w+)\s*===?\s*['"]([A-Za-z][A-Za-z0-9_.:-]*)['"]|['"]([A-Za-z][A-Za-z0-9_.:-]*)['"]\s*===?\s*\$(\w+)`)
+	securityRoleName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// LoadSecurityRoles collects the roles used in role_hierarchy and access_control
+// of the security.yaml files.
+func (c *ContainerConfig) LoadSecurityRoles() {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	if c.WorkspaceRoot == "" {
+		return
+	}
+	if c.SecurityAttributes == nil {
+		c.SecurityAttributes = make(map[string]protocol.Location)
+	}
+
+	var files []string
+	for _, pattern := range []string{"config/packages/security.y*ml", "config/packages/*/security.y*ml"} {
+		matches, _ := filepath.Glob(filepath.Join(c.WorkspaceRoot, pattern))
+		files = append(files, matches...)
+	}
+
+	for _, path := range files {
+		c.loadSecurityFile(path)
+	}
+	logger.Infof("indexed %d security attributes", len(c.SecurityAttributes))
+}
+
+func (c *ContainerConfig) loadSecurityFile(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return
+	}
+
+	uri := utils.PathToURI(path)
+	add := func(node *yaml.Node) {
+		if node.Kind == yaml.ScalarNode && securityRoleName.MatchString(node.Value) {
+			pos := utils.RunePosition(data, node.Line, node.Column)
+			c.addSecurityAttribute(node.Value, uri, int(pos.Line), int(pos.Character))
+		}
+	}
+	addAll := func(node *yaml.Node) {
+		if node == nil {
+			return
+		}
+		if node.Kind == yaml.SequenceNode {
+			for _, item := range node.Content {
+				add(item)
+			}
+			return
+		}
+		add(node)
+	}
+
+	security := yamlMapValue(doc.Content[0], "security")
+	if security == nil {
+		return
+	}
+
+	if hierarchy := yamlMapValue(security, "role_hierarchy"); hierarchy != nil && hierarchy.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(hierarchy.Content); i += 2 {
+			add(hierarchy.Content[i])
+			addAll(hierarchy.Content[i+1])
+		}
+	}
+
+	if rules := yamlMapValue(security, "access_control"); rules != nil && rules.Kind == yaml.SequenceNode {
+		for _, rule := range rules.Content {
+			addAll(yamlMapValue(rule, "roles"))
+		}
+	}
+}
+
+func yamlMapValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func (c *ContainerConfig) addSecurityAttribute(name, uri string, line, col int) {
+	if _, exists := c.SecurityAttributes[name]; exists {
+		return
+	}
+	c.SecurityAttributes[name] = protocol.Location{
+		URI: protocol.DocumentUri(uri),
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(line), Character: uint32(col)},
+			End:   protocol.Position{Line: uint32(line), Character: uint32(col + characters(name))},
+		},
+	}
+}
+
+// Indexes the attributes a voter supports: the constants its supports()
+// method references, and the strings it compares the attribute against with
+// in_array($attribute, [...]) or $attribute === '...'.
+func (c *ContainerConfig) indexVoterAttributes(class string, autoloadMap AutoloadMap) {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	path, ok := AutoloadResolve(class, autoloadMap, c.WorkspaceRoot)
+	if !ok {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	type constant struct {
+		value string
+		line  int
+		col   int
+	}
+	constants := make(map[string]constant)
+	type reference struct {
+		name  string
+		value bool
+		line  int
+		col   int
+	}
+	var refs []reference
+
+	addValue := func(line string, lineNumber, start, end int) {
+		refs = append(refs, reference{
+			name:  line[start:end],
+			value: true,
+			line:  lineNumber,
+			col:   characters(line[:start]),
+		})
+	}
+	// Adds the strings of an in_array() list from offset on, and reports
+	// whether the list goes on past the line
+	addList := func(line string, lineNumber, offset int) bool {
+		depth := 0
+		for i := offset; i < len(line); i++ {
+			switch line[i] {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			if depth < 0 {
+				for _, m := range voterStringRe.FindAllStringSubmatchIndex(line[offset:i], -1) {
+					addValue(line, lineNumber, offset+m[2], offset+m[3])
+				}
+				return false
+			}
+		}
+		for _, m := range voterStringRe.FindAllStringSubmatchIndex(line[offset:], -1) {
+			addValue(line, lineNumber, offset+m[2], offset+m[3])
+		}
+		return true
+	}
+
+	inSupports := false
+	inList := false
+	attribute := ""
+	braceLevel := 0
+	lineNumber := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := voterConstantRe.FindStringSubmatchIndex(line); m != nil {
+			constants[line[m[2]:m[3]]] = constant{
+				value: line[m[4]:m[5]],
+				line:  lineNumber,
+				col:   characters(line[:m[4]]),
+			}
+		}
+
+		if !inSupports {
+			if m := voterSupportsRe.FindStringSubmatch(line); m != nil {
+				inSupports = true
+				inList = false
+				braceLevel = 0
+				attribute = m[1]
+				if attribute == "" {
+					attribute = "attribute"
+				}
+			}
+		}
+		if inSupports {
+			for _, m := range voterConstRefRe.FindAllStringSubmatch(line, -1) {
+				refs = append(refs, reference{name: m[1]})
+			}
+			if inList {
+				inList = addList(line, lineNumber, 0)
+			}
+			for _, m := range voterInArrayRe.FindAllStringSubmatchIndex(line, -1) {
+				if line[m[2]:m[3]] == attribute {
+					inList = addList(line, lineNumber, m[1])
+				}
+			}
+			for _, m := range voterCompareRe.FindAllStringSubmatchIndex(line, -1) {
+				if m[2] >= 0 && line[m[2]:m[3]] == attribute {
+					addValue(line, lineNumber, m[4], m[5])
+				} else if m[8] >= 0 && line[m[8]:m[9]] == attribute {
+					addValue(line, lineNumber, m[6], m[7])
+				}
+			}
+			braceLevel += strings.Count(line, "{")
+			braceLevel -= strings.Count(line, "}")
+			if braceLevel <= 0 && strings.Contains(line, "}") {
+				inSupports = false
+			}
+		}
+		lineNumber++
+	}
+
+	uri := utils.PathToURI(path)
+	for _, ref := range refs {
+		if ref.value {
+			c.addSecurityAttribute(ref.name, uri, ref.line, ref.col)
+			continue
+		}
+		if constant, ok := constants[ref.name]; ok {
+			c.addSecurityAttribute(constant.value, uri, constant.line, constant.col)
+		}
+	}
+	logger.Debugf("indexed voter attributes of '%s'", class)
+}
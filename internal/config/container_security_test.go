@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityAttributes(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(rel, content string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	write("src/Security/PostVoter.php", `<?php
+
+namespace App\Security;
+
+class PostVoter extends Voter
+{
+    public const EDIT = 'POST_EDIT';
+    public const VIEW = 'POST_VIEW';
+    private const string UNUSED = 'POST_UNUSED';
+
+    protected function supports(string $attribute, mixed $subject): bool
+    {
+        if ($subject instanceof Post && $subject->getStatus() === 'ARCHIVED') {
+            return false;
+        }
+
+        return in_array($attribute, [
+            self::EDIT,
+            static::VIEW,
+            'POST_SHARE',
+        ], true) || $attribute === 'POST_DELETE';
+    }
+
+    protected function voteOnAttribute(string $attribute, mixed $subject, TokenInterface $token): bool
+    {
+        return 'POST_IGNORED' === $attribute;
+    }
+}
+`)
+	containerPath := write("var/cache/dev/container.xml", `<?xml version="1.0" encoding="utf-8"?>
+<container xmlns="http://symfony.com/schema/dic/services">
+  <services>
+    <service id="App\Security\PostVoter" class="App\Security\PostVoter">
+      <tag name="security.voter"/>
+    </service>
+  </services>
+</container>
+`)
+	write("config/packages/security.yaml", `security:
+    role_hierarchy:
+        ROLE_ADMIN: ROLE_USER
+        ROLE_SUPER_ADMIN: [ROLE_ADMIN, ROLE_ALLOWED_TO_SWITCH]
+    access_control:
+        - { path: ^/admin, roles: ROLE_ADMIN }
+        - { path: ^/profile, roles: [IS_AUTHENTICATED_FULLY, ROLE_EDITOR] }
+`)
+
+	autoload := NewAutoloadMap()
+	autoload.PSR4["App\\"] = []string{"src"}
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.SetContainerXMLPaths([]string{containerPath})
+	c.LoadFromXML(autoload)
+	c.LoadSecurityRoles()
+
+	for _, name := range []string{
+		"POST_EDIT", "POST_VIEW", "POST_SHARE", "POST_DELETE",
+		"ROLE_ADMIN", "ROLE_USER", "ROLE_SUPER_ADMIN", "ROLE_ALLOWED_TO_SWITCH",
+		"IS_AUTHENTICATED_FULLY", "ROLE_EDITOR",
+	} {
+		assert.Contains(t, c.SecurityAttributes, name)
+	}
+	assert.NotContains(t, c.SecurityAttributes, "POST_UNUSED")
+	assert.NotContains(t, c.SecurityAttributes, "POST_IGNORED")
+	assert.NotContains(t, c.SecurityAttributes, "ARCHIVED")
+
+	edit := c.SecurityAttributes["POST_EDIT"]
+	assert.Equal(t, uint32(6), edit.Range.Start.Line)
+	share := c.SecurityAttributes["POST_SHARE"]
+	assert.Equal(t, uint32(19), share.Range.Start.Line)
+	assert.Equal(t, uint32(13), share.Range.Start.Character)
+}
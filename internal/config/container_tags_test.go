@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerServiceTags(t *testing.T) {
+	root := t.TempDir()
+
+	containerXML := `<?xml version="1.0" encoding="utf-8"?>
+<container xmlns="http://symfony.com/schema/dic/services">
+  <services>
+    <service id="app.listener" class="App\Listener">
+      <tag name="kernel.event_listener" event="kernel.request"/>
+      <tag name="kernel.reset" method="reset"/>
+    </service>
+    <service id="app.other_listener" class="App\OtherListener">
+      <tag name="kernel.event_listener" event="kernel.response"/>
+    </service>
+  </services>
+</container>
+`
+	containerPath := filepath.Join(root, "container.xml")
+	require.NoError(t, os.WriteFile(containerPath, []byte(containerXML), 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.SetContainerXMLPaths([]string{containerPath})
+	c.LoadFromXML(NewAutoloadMap())
+
+	assert.Equal(t, map[string]int{"kernel.event_listener": 2, "kernel.reset": 1}, c.ServiceTags)
+	assert.Equal(t, []string{"app.listener", "app.other_listener"}, c.TaggedServices["kernel.event_listener"])
+}
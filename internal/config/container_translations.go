@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/shinyvision/vimfony/internal/translations"
@@ -47,10 +48,46 @@ func (c *ContainerConfig) LoadTranslations() {
 		}
 	}
 
+	c.TranslationResources = resources
 	c.TranslationKeys = translations.Parse(resources)
 	logger.Infof("loaded %d translation keys from %d resources", len(c.TranslationKeys), len(resources))
 }
 
+// TranslationCatalogs returns the project's own translation catalogs: the compiled
+// resources that live inside the workspace (vendor excluded) and every catalog
+// found in the configured translation roots.
+func (c *ContainerConfig) TranslationCatalogs() []translations.Catalog {
+	var dirs []string
+	seenDirs := make(map[string]struct{})
+	addDir := func(dir string) {
+		if _, ok := seenDirs[dir]; ok {
+			return
+		}
+		seenDirs[dir] = struct{}{}
+		dirs = append(dirs, dir)
+	}
+
+	for _, root := range c.TranslationRoots {
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(c.WorkspaceRoot, root)
+		}
+		addDir(filepath.Clean(root))
+	}
+
+	for _, res := range c.TranslationResources {
+		if c.WorkspaceRoot == "" || strings.Contains(res, string(filepath.Separator)+"vendor"+string(filepath.Separator)) {
+			continue
+		}
+		rel, err := filepath.Rel(c.WorkspaceRoot, res)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		addDir(filepath.Dir(res))
+	}
+
+	return translations.FindCatalogs(dirs)
+}
+
 func (c *ContainerConfig) parseMetaJson(path string) []string {
 	logger := commonlog.GetLoggerf("vimfony.config")
 	file, err := os.Open(path)
@@ -107,3 +144,32 @@ func (c *ContainerConfig) parseMetaJson(path string) []string {
 	}
 	return resources
 }
+
+// TranslationDomains returns the sorted domain names of all translation
+// resources, e.g. messages, validators and security.
+func (c *ContainerConfig) TranslationDomains() []string {
+	seen := make(map[string]struct{})
+	var domains []string
+	add := func(path string) {
+		parts := strings.Split(filepath.Base(path), ".")
+		if len(parts) < 3 {
+			return
+		}
+		domain := strings.TrimSuffix(strings.Join(parts[:len(parts)-2], "."), "+intl-icu")
+		if _, ok := seen[domain]; ok || domain == "" {
+			return
+		}
+		seen[domain] = struct{}{}
+		domains = append(domains, domain)
+	}
+
+	for _, res := range c.TranslationResources {
+		add(res)
+	}
+	for _, catalog := range c.TranslationCatalogs() {
+		add(catalog.Path)
+	}
+
+	sort.Strings(domains)
+	return domains
+}
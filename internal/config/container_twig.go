@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// TwigCallable is a filter or test registered by a Twig extension
+type TwigCallable struct {
+	Class    string
+	Location protocol.Location
+}
+
+// Indexes the `new TwigFilter('name', ...)` style callables returned by the
+// given method of a twig.extension service.
+func (c *ContainerConfig) indexTwigCallables(class string, autoloadMap AutoloadMap, method, callable string, target map[string]TwigCallable) {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	path, ok := AutoloadResolve(class, autoloadMap, c.WorkspaceRoot)
+	if !ok {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	methodRe := regexp.MustCompile(`function\s+` + regexp.QuoteMeta(method) + `\s*\(`)
+	callableRe := regexp.MustCompile(`new\s+\\?(?:[A-Za-z_][A-Za-z0-9_]*\\)*` + regexp.QuoteMeta(callable) + `\s*\(\s*['"]([^'"]+)['"]`)
+
+	uri := protocol.DocumentUri(utils.PathToURI(path))
+	inMethod := false
+	braceLevel := 0
+	lineNumber := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !inMethod && methodRe.MatchString(line) {
+			inMethod = true
+			braceLevel = 0
+		}
+		if inMethod {
+			for _, m := range callableRe.FindAllStringSubmatchIndex(line, -1) {
+				name := line[m[2]:m[3]]
+				startCol := characters(line[:m[2]])
+				target[name] = TwigCallable{
+					Class: class,
+					Location: protocol.Location{
+						URI: uri,
+						Range: protocol.Range{
+							Start: protocol.Position{Line: uint32(lineNumber), Character: uint32(startCol)},
+							End:   protocol.Position{Line: uint32(lineNumber), Character: uint32(startCol + characters(name))},
+						},
+					},
+				}
+				logger.Debugf("indexed twig %s '%s' at %s:%d", strings.ToLower(strings.TrimPrefix(callable, "Twig")), name, path, lineNumber+1)
+			}
+			braceLevel += strings.Count(line, "{")
+			braceLevel -= strings.Count(line, "}")
+			if braceLevel <= 0 && strings.Contains(line, "}") {
+				return
+			}
+		}
+		lineNumber++
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwigExtensionCallables(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(rel, content string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	write("src/Twig/PriceExtension.php", `<?php
+
+namespace App\Twig;
+
+use Twig\Extension\AbstractExtension;
+use Twig\TwigFilter;
+use Twig\TwigFunction;
+use Twig\TwigTest;
+
+class PriceExtension extends AbstractExtension
+{
+    public function getFilters(): array
+    {
+        return [
+            new TwigFilter('price', [$this, 'formatPrice']),
+            new \Twig\TwigFilter('price_eur', [$this, 'formatEuro']),
+        ];
+    }
+
+    public function getTests(): array
+    {
+        return [new TwigTest('cheap', [$this, 'isCheap'])];
+    }
+
+    public function getFunctions(): array
+    {
+        return [
+            new TwigFunction('price_total', [$this, 'total']),
+        ];
+    }
+}
+`)
+	containerPath := write("var/cache/dev/container.xml", `<?xml version="1.0" encoding="utf-8"?>
+<container xmlns="http://symfony.com/schema/dic/services">
+  <services>
+    <service id="App\Twig\PriceExtension" class="App\Twig\PriceExtension">
+      <tag name="twig.extension"/>
+    </service>
+  </services>
+</container>
+`)
+
+	autoload := NewAutoloadMap()
+	autoload.PSR4["App\\"] = []string{"src"}
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.SetContainerXMLPaths([]string{containerPath})
+	c.LoadFromXML(autoload)
+
+	require.Contains(t, c.TwigFilters, "price")
+	require.Contains(t, c.TwigFilters, "price_eur")
+	assert.NotContains(t, c.TwigFilters, "price_total")
+	assert.Contains(t, c.TwigFunctions, "price_total")
+	assert.Contains(t, c.TwigTests, "cheap")
+	assert.NotContains(t, c.TwigTests, "price")
+
+	price := c.TwigFilters["price"]
+	assert.Equal(t, "App\\Twig\\PriceExtension", price.Class)
+	assert.Equal(t, uint32(14), price.Location.Range.Start.Line)
+	assert.Equal(t, uint32(28), price.Location.Range.Start.Character)
+
+	// Past the limit the services are still read, the extensions are not
+	c.TwigIndexLimit = 64
+	c.LoadFromXML(autoload)
+	assert.Equal(t, "App\\Twig\\PriceExtension", c.ServiceClasses["App\\Twig\\PriceExtension"])
+	assert.Empty(t, c.TwigFunctions)
+	assert.Empty(t, c.TwigFilters)
+	assert.Empty(t, c.TwigTests)
+}
+
+func TestTwigTemplatesFollowTheCreatedAndDeletedFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		return path
+	}
+	write("templates/base.html.twig")
+	write("vendor/acme/shop/templates/base.html.twig")
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.Roots = []string{"templates", "vendor/acme/shop/templates"}
+	c.BundleRoots = map[string][]string{"AcmeShop": {"vendor/acme/shop/templates"}}
+	require.Equal(t, []string{"@AcmeShop/base.html.twig", "base.html.twig"}, c.TwigTemplates())
+
+	post := write("templates/post/show.html.twig")
+	c.TwigTemplateCreated(post)
+	c.TwigTemplateCreated(post)
+	c.TwigTemplateCreated(write("templates/notes.txt"))
+	assert.Equal(t, []string{"@AcmeShop/base.html.twig", "base.html.twig", "post/show.html.twig"}, c.TwigTemplates())
+
+	c.TwigTemplateDeleted(post)
+	assert.Equal(t, []string{"@AcmeShop/base.html.twig", "base.html.twig"}, c.TwigTemplates())
+
+	// The other root still gives the name
+	c.TwigTemplateDeleted(filepath.Join(root, "templates/base.html.twig"))
+	assert.Equal(t, []string{"@AcmeShop/base.html.twig", "base.html.twig"}, c.TwigTemplates())
+	c.TwigTemplateDeleted(filepath.Join(root, "vendor/acme/shop/templates/base.html.twig"))
+	assert.Empty(t, c.TwigTemplates())
+}
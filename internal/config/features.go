@@ -0,0 +1,57 @@
+package config
+
+// Subsystems the features init option can turn off, e.g. to leave the
+// providers that overlap with Intelephense to it
+const (
+	FeatureCompletion     = "completion"
+	FeatureDefinition     = "definition"
+	FeatureCodeActions    = "code_actions"
+	FeatureDiagnostics    = "diagnostics"
+	FeatureInlayHints     = "inlay_hints"
+	FeatureSignatureHelp  = "signature_help"
+	FeatureCodeLens       = "code_lens"
+	FeatureFoldingRanges  = "folding_ranges"
+	FeatureDocumentLinks  = "document_links"
+	FeatureRoutes         = "routes"
+	FeatureTranslations   = "translations"
+	FeatureTemplates      = "templates"
+	FeatureTwigComponents = "twig_components"
+	FeatureWatch          = "watch"
+)
+
+var knownFeatures = map[string]bool{
+	FeatureCompletion:     true,
+	FeatureDefinition:     true,
+	FeatureCodeActions:    true,
+	FeatureDiagnostics:    true,
+	FeatureInlayHints:     true,
+	FeatureSignatureHelp:  true,
+	FeatureCodeLens:       true,
+	FeatureFoldingRanges:  true,
+	FeatureDocumentLinks:  true,
+	FeatureRoutes:         true,
+	FeatureTranslations:   true,
+	FeatureTemplates:      true,
+	FeatureTwigComponents: true,
+	FeatureWatch:          true,
+}
+
+// IsKnownFeature reports whether name is one of the Feature constants.
+func IsKnownFeature(name string) bool {
+	return knownFeatures[name]
+}
+
+// Features maps Feature names to whether they are on
+type Features map[string]bool
+
+// Enabled reports whether a subsystem is on. Everything is on unless the
+// features option turns it off.
+func (f Features) Enabled(name string) bool {
+	enabled, ok := f[name]
+	return !ok || enabled
+}
+
+// FeatureEnabled reports whether a subsystem is on.
+func (c *Config) FeatureEnabled(name string) bool {
+	return c.Features.Enabled(name)
+}
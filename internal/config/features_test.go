@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureEnabled(t *testing.T) {
+	c := NewConfig()
+	assert.True(t, c.FeatureEnabled(FeatureRoutes))
+
+	c.Features[FeatureRoutes] = false
+	c.Features[FeatureDiagnostics] = true
+	assert.False(t, c.FeatureEnabled(FeatureRoutes))
+	assert.True(t, c.FeatureEnabled(FeatureDiagnostics))
+
+	var none Features
+	assert.True(t, none.Enabled(FeatureTemplates))
+
+	assert.True(t, IsKnownFeature("code_actions"))
+	assert.False(t, IsKnownFeature("hover"))
+}
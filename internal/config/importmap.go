@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var importMapReturnRe = regexp.MustCompile(`\breturn\s*(\[|array\s*\()`)
+
+// ImportMapEntry is an entry of the AssetMapper importmap.php file
+type ImportMapEntry struct {
+	Name       string
+	Path       string
+	Version    string
+	Entrypoint bool
+}
+
+// ImportMapEntries parses the importmap.php file of the workspace.
+func (c *ContainerConfig) ImportMapEntries() []ImportMapEntry {
+	if c.WorkspaceRoot == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(c.WorkspaceRoot, "importmap.php"))
+	if err != nil {
+		return nil
+	}
+	content := string(data)
+
+	m := importMapReturnRe.FindStringSubmatchIndex(content)
+	if m == nil {
+		return nil
+	}
+
+	var entries []ImportMapEntry
+	for _, pair := range phpArrayPairs(content, m[3]-1) {
+		entry := ImportMapEntry{Name: pair.name}
+		if open := strings.IndexAny(pair.value, "[("); open >= 0 {
+			for _, option := range phpArrayPairs(pair.value, open) {
+				value := strings.Trim(option.value, `'"`)
+				switch option.name {
+				case "path":
+					entry.Path = value
+				case "version":
+					entry.Version = value
+				case "entrypoint":
+					entry.Entrypoint = strings.EqualFold(value, "true")
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportMapEntries(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "importmap.php"), []byte(`<?php
+
+/**
+ * Returns the importmap for this application.
+ */
+return [
+    'app' => [
+        'path' => './assets/app.js',
+        'entrypoint' => true,
+    ],
+    '@hotwired/stimulus' => [
+        'version' => '3.2.2',
+    ],
+    'bootstrap/dist/css/bootstrap.min.css' => ['version' => '5.3.3', 'type' => 'css'],
+];
+`), 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+
+	assert.Equal(t, []ImportMapEntry{
+		{Name: "@hotwired/stimulus", Version: "3.2.2"},
+		{Name: "app", Path: "./assets/app.js", Entrypoint: true},
+		{Name: "bootstrap/dist/css/bootstrap.min.css", Version: "5.3.3"},
+	}, c.ImportMapEntries())
+}
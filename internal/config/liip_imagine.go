@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ImagineFilter is a LiipImagine filter set, Size describes its dimensions
+// such as "thumbnail 120x90"
+type ImagineFilter struct {
+	Name string
+	Size string
+}
+
+// ImagineFilters lists the filter_sets of the liip_imagine configuration,
+// sorted by name.
+func (c *ContainerConfig) ImagineFilters() []ImagineFilter {
+	if c.WorkspaceRoot == "" {
+		return nil
+	}
+
+	var files []string
+	for _, pattern := range []string{"config/packages/liip_imagine.y*ml", "config/packages/*/liip_imagine.y*ml"} {
+		matches, _ := filepath.Glob(filepath.Join(c.WorkspaceRoot, pattern))
+		files = append(files, matches...)
+	}
+
+	seen := make(map[string]struct{})
+	var filters []ImagineFilter
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+			continue
+		}
+		sets := yamlMapValue(yamlMapValue(doc.Content[0], "liip_imagine"), "filter_sets")
+		if sets == nil || sets.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(sets.Content); i += 2 {
+			key := sets.Content[i]
+			// Later environments override the filter, the first one is enough
+			if _, ok := seen[key.Value]; ok || key.Value == "cache" {
+				continue
+			}
+			seen[key.Value] = struct{}{}
+			filters = append(filters, ImagineFilter{
+				Name: key.Value,
+				Size: imagineFilterSize(yamlMapValue(sets.Content[i+1], "filters")),
+			})
+		}
+	}
+
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Name < filters[j].Name })
+	return filters
+}
+
+// Describes the first filter of the set that has dimensions, e.g.
+// thumbnail: { size: [120, 90] } or fixed: { width: 120, height: 90 }
+func imagineFilterSize(filters *yaml.Node) string {
+	if filters == nil || filters.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(filters.Content); i += 2 {
+		name, options := filters.Content[i].Value, filters.Content[i+1]
+		for _, key := range []string{"size", "dim"} {
+			if dim := yamlMapValue(options, key); dim != nil && dim.Kind == yaml.SequenceNode && len(dim.Content) == 2 {
+				return fmt.Sprintf("%s %sx%s", name, dim.Content[0].Value, dim.Content[1].Value)
+			}
+		}
+		width, height := yamlMapValue(options, "width"), yamlMapValue(options, "height")
+		if width != nil && height != nil {
+			return fmt.Sprintf("%s %sx%s", name, width.Value, height.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImagineFilters(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("config/packages/liip_imagine.yaml", `liip_imagine:
+    driver: "gd"
+    filter_sets:
+        cache: ~
+        squared_thumbnail:
+            quality: 75
+            filters:
+                strip: ~
+                thumbnail: { size: [120, 90], mode: outbound }
+        banner:
+            filters:
+                fixed:
+                    width: 1200
+                    height: 300
+        raw: ~
+`)
+	write("config/packages/dev/liip_imagine.yaml", `liip_imagine:
+    filter_sets:
+        debug:
+            filters:
+                relative_resize: { heighten: 60 }
+`)
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+
+	assert.Equal(t, []ImagineFilter{
+		{Name: "banner", Size: "fixed 1200x300"},
+		{Name: "debug"},
+		{Name: "raw"},
+		{Name: "squared_thumbnail", Size: "thumbnail 120x90"},
+	}, c.ImagineFilters())
+}
@@ -0,0 +1,62 @@
+package config
+
+import "strings"
+
+type phpArrayPair struct {
+	name   string
+	value  string
+	offset int
+}
+
+// Collects the 'key' => value pairs of the array literal that opens at open
+func phpArrayPairs(content string, open int) []phpArrayPair {
+	var keys []phpArrayPair
+	depth := 0
+	strStart, strEnd := -1, -1
+	valueStart := -1
+
+	endValue := func(end int) {
+		if valueStart >= 0 && len(keys) > 0 {
+			keys[len(keys)-1].value = strings.TrimSpace(content[valueStart:end])
+		}
+		valueStart = -1
+	}
+
+	for i := open; i < len(content); i++ {
+		switch ch := content[i]; ch {
+		case '\'', '"':
+			j := i + 1
+			for j < len(content) && content[j] != ch {
+				if content[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if depth == 1 && valueStart < 0 {
+				strStart, strEnd = i+1, min(j, len(content))
+			}
+			i = j
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+			if depth == 0 {
+				endValue(i)
+				return keys
+			}
+		case ',':
+			if depth == 1 {
+				endValue(i)
+				strStart, strEnd = -1, -1
+			}
+		case '=':
+			if depth == 1 && valueStart < 0 && i+1 < len(content) && content[i+1] == '>' && strEnd >= 0 &&
+				strings.TrimSpace(content[strEnd+1:i]) == "" {
+				keys = append(keys, phpArrayPair{name: content[strStart:strEnd], offset: strStart})
+				valueStart = i + 2
+				i++
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,29 @@
+package config
+
+import "github.com/tliron/commonlog"
+
+// A Problem is a file or command of the project that could not be loaded, in
+// a way the user can fix, e.g. a container_xml_path that does not exist
+type Problem struct {
+	// Message names what failed with its path or command
+	Message string
+	// Hint tells how to fix it
+	Hint string
+}
+
+func (p Problem) String() string {
+	return p.Message + ". " + p.Hint
+}
+
+// OnProblem sets a function to call with the problems met while loading, in
+// addition to logging them
+func (c *ContainerConfig) OnProblem(report func(Problem)) {
+	c.onProblem = report
+}
+
+func (c *ContainerConfig) reportProblem(problem Problem) {
+	commonlog.GetLoggerf("vimfony.config").Warningf("%s", problem.Message)
+	if c.onProblem != nil {
+		c.onProblem(problem)
+	}
+}
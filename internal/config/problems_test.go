@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadingReportsTheFilesThatCannotBeRead(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Container.WorkspaceRoot = t.TempDir()
+	cfg.Container.SetContainerXMLPaths([]string{"var/cache/dev/App_KernelDevDebugContainer.xml"})
+	cfg.RoutesCommand = []string{"./bin/missing-console", "debug:router"}
+	var problems []Problem
+	cfg.Container.OnProblem(func(problem Problem) { problems = append(problems, problem) })
+
+	cfg.Container.LoadFromXML(cfg.Autoload)
+	cfg.LoadRoutesMap()
+
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0].Message, "cannot read container_xml_path[0] 'var/cache/dev/App_KernelDevDebugContainer.xml'")
+	assert.Equal(t, containerHint, problems[0].Hint)
+	assert.Contains(t, problems[1].Message, "could not load routes from './bin/missing-console debug:router'")
+	assert.Contains(t, problems[1].String(), ". Check routes_command")
+}
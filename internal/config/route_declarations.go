@@ -0,0 +1,82 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+
+	phpforest "github.com/alexaandru/go-sitter-forest/php"
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RouteDeclaration is a #[Route] attribute of a controller action, at the
+// name it declares
+type RouteDeclaration struct {
+	Class    string
+	Action   string
+	Location protocol.Location
+}
+
+// LoadRouteDeclarations indexes the #[Route] attributes of the controllers
+// by route name. Unlike the routes map, which holds the definition Symfony
+// keeps, every declaration of a name is kept.
+func (c *ContainerConfig) LoadRouteDeclarations() {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	declarations := make(map[string][]RouteDeclaration)
+	walkControllers(c.WorkspaceRoot, func(path string, routes []attributeRoute) {
+		indexRouteDeclarations(declarations, protocol.DocumentUri(utils.PathToURI(path)), routes)
+	})
+
+	c.routeDeclarationsMu.Lock()
+	c.RouteDeclarations = declarations
+	c.routeDeclarationsMu.Unlock()
+	logger.Infof("indexed the #[Route] declarations of %d routes", len(declarations))
+}
+
+// RouteDeclarationsOf returns the #[Route] attributes that declare the route.
+func (c *ContainerConfig) RouteDeclarationsOf(name string) []RouteDeclaration {
+	c.routeDeclarationsMu.RLock()
+	defer c.routeDeclarationsMu.RUnlock()
+	return append([]RouteDeclaration{}, c.RouteDeclarations[name]...)
+}
+
+// UpdateRouteDeclarations reindexes the #[Route] attributes of a controller
+// from its unsaved content, so that they follow the edits.
+func (c *ContainerConfig) UpdateRouteDeclarations(path, content string) {
+	if c.WorkspaceRoot == "" || !isControllerFile(path) || !isWithin(path, filepath.Join(c.WorkspaceRoot, "src")) {
+		return
+	}
+
+	parser := sitter.NewParser()
+	defer parser.Close()
+	_ = parser.SetLanguage(sitter.NewLanguage(phpforest.GetLanguage()))
+	routes := parseRouteAttributes(parser, []byte(content))
+
+	uri := protocol.DocumentUri(utils.PathToURI(path))
+	c.routeDeclarationsMu.Lock()
+	defer c.routeDeclarationsMu.Unlock()
+	if c.RouteDeclarations == nil {
+		c.RouteDeclarations = make(map[string][]RouteDeclaration)
+	}
+	for name, declarations := range c.RouteDeclarations {
+		kept := slices.DeleteFunc(slices.Clone(declarations), func(d RouteDeclaration) bool { return d.Location.URI == uri })
+		if len(kept) == 0 {
+			delete(c.RouteDeclarations, name)
+		} else if len(kept) != len(declarations) {
+			c.RouteDeclarations[name] = kept
+		}
+	}
+	indexRouteDeclarations(c.RouteDeclarations, uri, routes)
+}
+
+func indexRouteDeclarations(declarations map[string][]RouteDeclaration, uri protocol.DocumentUri, routes []attributeRoute) {
+	for _, route := range routes {
+		declarations[route.Name] = append(declarations[route.Name], RouteDeclaration{
+			Class:    route.Controller,
+			Action:   route.Action,
+			Location: protocol.Location{URI: uri, Range: route.nameRange},
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestLoadRouteDeclarations(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	blog := write("src/Controller/BlogController.php", `<?php
+namespace App\Controller;
+
+class BlogController
+{
+    #[Route('/', name: 'home')]
+    public function index() {}
+}
+`)
+	write("src/Controller/HomeController.php", `<?php
+namespace App\Controller;
+
+class HomeController
+{
+    #[Route('/home', name: 'home')]
+    public function index() {}
+}
+`)
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.LoadRouteDeclarations()
+
+	declarations := c.RouteDeclarationsOf("home")
+	require.Len(t, declarations, 2)
+	classes := []string{declarations[0].Class, declarations[1].Class}
+	assert.ElementsMatch(t, []string{"App\\Controller\\BlogController", "App\\Controller\\HomeController"}, classes)
+
+	blogURI := protocol.DocumentUri(utils.PathToURI(blog))
+	for _, d := range declarations {
+		if d.Location.URI == blogURI {
+			assert.Equal(t, protocol.Range{
+				Start: protocol.Position{Line: 5, Character: 23},
+				End:   protocol.Position{Line: 5, Character: 29},
+			}, d.Location.Range)
+		}
+	}
+
+	// Renaming the route in the editor moves the declaration
+	c.UpdateRouteDeclarations(blog, `<?php
+namespace App\Controller;
+
+class BlogController
+{
+    #[Route('/', name: 'blog')]
+    public function index() {}
+}
+`)
+	require.Len(t, c.RouteDeclarationsOf("home"), 1)
+	require.Len(t, c.RouteDeclarationsOf("blog"), 1)
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var (
+	phpRouteUsageRe  = regexp.MustCompile(`->(?:generate|generateUrl|redirectToRoute)\s*\(\s*['"]([^'"$]+)['"]`)
+	twigRouteUsageRe = regexp.MustCompile(`\b(?:path|url)\s*\(\s*['"]([^'"]+)['"]`)
+)
+
+// LoadRouteUsages indexes the route names the application generates URLs
+// for: generate(), generateUrl() and redirectToRoute() in its classes, and
+// path() and url() in its templates.
+func (c *ContainerConfig) LoadRouteUsages(autoload AutoloadMap) {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	usages := make(map[string][]protocol.Location)
+	defer func() {
+		c.routeUsagesMu.Lock()
+		c.RouteUsages = usages
+		c.routeUsagesMu.Unlock()
+	}()
+	if c.WorkspaceRoot == "" {
+		return
+	}
+
+	c.WalkAppSources(autoload, func(path string) {
+		indexRouteUsageFile(usages, path, phpRouteUsageRe)
+	})
+	for _, root := range c.Roots {
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(c.WorkspaceRoot, root)
+		}
+		// Only the templates of the application, not the ones of bundles
+		rel, err := filepath.Rel(c.WorkspaceRoot, root)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if first, _, _ := strings.Cut(filepath.ToSlash(rel), "/"); first == "vendor" {
+			continue
+		}
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.HasSuffix(p, ".twig") {
+				indexRouteUsageFile(usages, p, twigRouteUsageRe)
+			}
+			return nil
+		})
+	}
+	logger.Infof("indexed the usages of %d routes", len(usages))
+}
+
+// RouteUsagesOf returns the places that generate the route.
+func (c *ContainerConfig) RouteUsagesOf(name string) []protocol.Location {
+	c.routeUsagesMu.RLock()
+	defer c.routeUsagesMu.RUnlock()
+	return append([]protocol.Location{}, c.RouteUsages[name]...)
+}
+
+// UpdateRouteUsages reindexes the usages of a source file or template of the
+// application from its unsaved content, so that they follow the edits.
+func (c *ContainerConfig) UpdateRouteUsages(path, content string) {
+	var re *regexp.Regexp
+	switch {
+	case strings.HasSuffix(path, ".php"):
+		re = phpRouteUsageRe
+	case strings.HasSuffix(path, ".twig"):
+		re = twigRouteUsageRe
+	default:
+		return
+	}
+	if c.WorkspaceRoot == "" || !isWithin(path, c.WorkspaceRoot) || isWithin(path, filepath.Join(c.WorkspaceRoot, "vendor")) {
+		return
+	}
+
+	uri := protocol.DocumentUri(utils.PathToURI(path))
+	c.routeUsagesMu.Lock()
+	defer c.routeUsagesMu.Unlock()
+	if c.RouteUsages == nil {
+		c.RouteUsages = make(map[string][]protocol.Location)
+	}
+	for name, locs := range c.RouteUsages {
+		kept := slices.DeleteFunc(slices.Clone(locs), func(loc protocol.Location) bool { return loc.URI == uri })
+		if len(kept) == 0 {
+			delete(c.RouteUsages, name)
+		} else if len(kept) != len(locs) {
+			c.RouteUsages[name] = kept
+		}
+	}
+	indexRouteUsages(c.RouteUsages, uri, content, re)
+}
+
+func indexRouteUsageFile(usages map[string][]protocol.Location, path string, re *regexp.Regexp) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	indexRouteUsages(usages, protocol.DocumentUri(utils.PathToURI(path)), string(data), re)
+}
+
+func indexRouteUsages(usages map[string][]protocol.Location, uri protocol.DocumentUri, content string, re *regexp.Regexp) {
+	matches := re.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return
+	}
+
+	lineStarts := lineStartOffsets(content)
+	for _, m := range matches {
+		name := content[m[2]:m[3]]
+		line, col := offsetToPosition(content, lineStarts, m[2])
+		usages[name] = append(usages[name], protocol.Location{
+			URI: uri,
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(line), Character: uint32(col)},
+				End:   protocol.Position{Line: uint32(line), Character: uint32(col + characters(name))},
+			},
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestLoadRouteUsages(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	write("src/Controller/PostController.php", `<?php
+class PostController extends AbstractController
+{
+    public function edit(): Response
+    {
+        $url = $this->router->generate('post_show', ['id' => 1]);
+        return $this->redirectToRoute( "post_show" );
+    }
+
+    public function dynamic(string $name): Response
+    {
+        return $this->redirectToRoute($name);
+    }
+}
+`)
+	write("templates/post/list.html.twig", `<a href="{{ path('post_show', {id: post.id}) }}">{{ url('post_list') }}</a>
+`)
+	write("vendor/acme/templates/post.html.twig", `{{ path('post_show') }}`)
+
+	autoload := NewAutoloadMap()
+	autoload.PSR4["App\\"] = []string{"src"}
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.Roots = append(c.Roots, filepath.Join(root, "vendor/acme/templates"))
+	c.LoadRouteUsages(autoload)
+
+	require.Len(t, c.RouteUsages["post_show"], 3)
+	require.Len(t, c.RouteUsages["post_list"], 1)
+	assert.NotContains(t, c.RouteUsages, "$name")
+
+	template := protocol.DocumentUri(utils.PathToURI(filepath.Join(root, "templates/post/list.html.twig")))
+	assert.Equal(t, protocol.Location{
+		URI: template,
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 0, Character: 18},
+			End:   protocol.Position{Line: 0, Character: 27},
+		},
+	}, c.RouteUsages["post_show"][2])
+}
+
+func TestUpdateRouteUsages(t *testing.T) {
+	root := t.TempDir()
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	controller := filepath.Join(root, "src/Controller/PostController.php")
+	template := filepath.Join(root, "templates/post/list.html.twig")
+
+	c.UpdateRouteUsages(controller, `<?php $this->redirectToRoute('post_show');`)
+	c.UpdateRouteUsages(template, `{{ path('post_show') }} {{ path('post_list') }}`)
+	require.Len(t, c.RouteUsagesOf("post_show"), 2)
+	require.Len(t, c.RouteUsagesOf("post_list"), 1)
+
+	c.UpdateRouteUsages(template, `{{ path('post_show') }}`)
+	assert.Len(t, c.RouteUsagesOf("post_show"), 2)
+	assert.Empty(t, c.RouteUsagesOf("post_list"))
+	assert.NotContains(t, c.RouteUsages, "post_list")
+
+	c.UpdateRouteUsages(filepath.Join(root, "vendor/acme/Controller.php"), `<?php $this->redirectToRoute('post_show');`)
+	assert.Len(t, c.RouteUsagesOf("post_show"), 2)
+}
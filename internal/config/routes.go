@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -13,6 +14,10 @@ type Route struct {
 	Parameters []string
 	Controller string
 	Action     string
+	// Path is the URL pattern, such as /orders/{id}
+	Path string
+	// Optional are the parameters with a default value, which can be left out
+	Optional []string
 }
 
 type RoutesMap map[string]Route
@@ -71,31 +76,75 @@ func GetRoutesMap(routesFile, phpPath string) (RoutesMap, error) {
 			Parameters: params,
 			Controller: controller,
 			Action:     action,
+			Path:       routePathFromTokens(routeData),
+			Optional:   optionalRouteParameters(params, routeDefaults(routeData)),
 		}
 	}
 
 	return routesMap, nil
 }
 
+// Rebuilds the path from the compiled tokens, which are stored last segment
+// first: ['text', '/orders'] or ['variable', '/', '[^/]++', 'id', true]
+func routePathFromTokens(routeData []any) string {
+	if len(routeData) < 4 {
+		return ""
+	}
+	tokens, ok := routeData[3].([]any)
+	if !ok {
+		return ""
+	}
+	path := ""
+	for _, t := range tokens {
+		token, ok := t.([]any)
+		if !ok || len(token) < 2 {
+			continue
+		}
+		kind, _ := token[0].(string)
+		prefix, _ := token[1].(string)
+		switch kind {
+		case "text":
+			path = prefix + path
+		case "variable":
+			if len(token) < 4 {
+				continue
+			}
+			name, _ := token[3].(string)
+			path = prefix + "{" + name + "}" + path
+		}
+	}
+	if path == "" {
+		path = "/"
+	}
+	return path
+}
+
 func extractController(routeData []any) (string, string) {
+	if controller, ok := routeDefaults(routeData)["_controller"].(string); ok {
+		return parseController(controller)
+	}
+	return "", ""
+}
+
+// Returns the defaults of a compiled route, which json_encode turns into an
+// empty list when there are none
+func routeDefaults(routeData []any) map[string]any {
 	if len(routeData) < 2 {
-		return "", ""
+		return nil
 	}
+	defaults, _ := routeData[1].(map[string]any)
+	return defaults
+}
 
-	defaultsRaw := routeData[1]
-	switch defaults := defaultsRaw.(type) {
-	case map[string]any:
-		if controllerRaw, ok := defaults["_controller"]; ok {
-			if controllerStr, ok := controllerRaw.(string); ok {
-				return parseController(controllerStr)
-			}
-		}
-	case map[string]string:
-		if controllerStr, ok := defaults["_controller"]; ok {
-			return parseController(controllerStr)
+// Returns the parameters that have a default
+func optionalRouteParameters(params []string, defaults map[string]any) []string {
+	var optional []string
+	for _, param := range params {
+		if _, ok := defaults[param]; ok {
+			optional = append(optional, param)
 		}
 	}
-	return "", ""
+	return optional
 }
 
 func parseController(raw string) (string, string) {
@@ -118,3 +167,70 @@ func parseController(raw string) (string, string) {
 
 	return controller, action
 }
+
+// Matches the placeholders of a route path or host: {id}, {!id},
+// {id<\d+>} and {id?1}, capturing the inline default
+var routePlaceholderRe = regexp.MustCompile(`\{!?(\w+)(?:<[^>]*>)?(\?[^}]*)?\}`)
+
+// Strips the inline requirements and defaults of the placeholders of a path,
+// /post/{id<\d+>}/{page?1} becomes /post/{id}/{page}
+func normalizeRoutePath(path string) string {
+	return routePlaceholderRe.ReplaceAllString(path, "{$1}")
+}
+
+// GetRoutesMapFromCommand runs a command printing the routes like
+// `bin/console debug:router --format=json` does
+func GetRoutesMapFromCommand(command []string, dir string) (RoutesMap, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("empty routes command")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not execute routes command: %w", err)
+	}
+	return parseDebugRouterJSON(out)
+}
+
+func parseDebugRouterJSON(data []byte) (RoutesMap, error) {
+	var rawRoutes map[string]struct {
+		Path     string         `json:"path"`
+		Host     string         `json:"host"`
+		Defaults map[string]any `json:"defaults"`
+	}
+	if err := json.Unmarshal(data, &rawRoutes); err != nil {
+		return nil, fmt.Errorf("could not unmarshal json: %w", err)
+	}
+
+	routesMap := make(RoutesMap)
+	for name, raw := range rawRoutes {
+		if strings.Contains(name, "\\") {
+			continue
+		}
+
+		// Host placeholders come first, like in the compiled routes
+		params := []string{}
+		seen := make(map[string]bool)
+		for _, pattern := range []string{raw.Host, raw.Path} {
+			for _, m := range routePlaceholderRe.FindAllStringSubmatch(pattern, -1) {
+				if !seen[m[1]] {
+					seen[m[1]] = true
+					params = append(params, m[1])
+				}
+			}
+		}
+
+		route := Route{
+			Name:       name,
+			Parameters: params,
+			Path:       normalizeRoutePath(raw.Path),
+			Optional:   optionalRouteParameters(params, raw.Defaults),
+		}
+		if controller, ok := raw.Defaults["_controller"].(string); ok {
+			route.Controller, route.Action = parseController(controller)
+		}
+		routesMap[name] = route
+	}
+	return routesMap, nil
+}
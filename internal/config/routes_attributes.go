@@ -0,0 +1,307 @@
+package config
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	phpforest "github.com/alexaandru/go-sitter-forest/php"
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var defaultRouteNameRe = regexp.MustCompile(`(bundle|controller)_`)
+
+// ScanRouteAttributes builds the routes of the #[Route] attributes of the
+// controllers under src/, without running PHP. Routes loaded from YAML, XML
+// or custom loaders are not known this way.
+func ScanRouteAttributes(workspaceRoot string) RoutesMap {
+	routes := make(RoutesMap)
+	walkControllers(workspaceRoot, func(_ string, declared []attributeRoute) {
+		for _, route := range declared {
+			routes[route.Name] = route.Route
+		}
+	})
+	return routes
+}
+
+// Calls fn with the #[Route] attributes of every controller under src/
+func walkControllers(workspaceRoot string, fn func(path string, routes []attributeRoute)) {
+	if workspaceRoot == "" {
+		return
+	}
+
+	parser := sitter.NewParser()
+	defer parser.Close()
+	_ = parser.SetLanguage(sitter.NewLanguage(phpforest.GetLanguage()))
+
+	_ = filepath.WalkDir(filepath.Join(workspaceRoot, "src"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isControllerFile(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		fn(path, parseRouteAttributes(parser, content))
+		return nil
+	})
+}
+
+func isControllerFile(path string) bool {
+	return filepath.Ext(path) == ".php" && strings.Contains(filepath.ToSlash(path), "/Controller/")
+}
+
+func parseRouteAttributes(parser *sitter.Parser, content []byte) []attributeRoute {
+	tree, err := parser.ParseString(context.Background(), nil, content)
+	if err != nil {
+		return nil
+	}
+	defer tree.Close()
+	return routeAttributesOf(tree.RootNode(), content)
+}
+
+// A #[Route] attribute with its literal path and name
+type routeAttributeArgs struct {
+	path    string
+	name    string
+	hasName bool
+	// The name literal, or the attribute when the name is generated
+	nameRange protocol.Range
+}
+
+// A route declared by a #[Route] attribute, along with the range of its name
+type attributeRoute struct {
+	Route
+	nameRange protocol.Range
+}
+
+func routeAttributesOf(root sitter.Node, content []byte) []attributeRoute {
+	var routes []attributeRoute
+	namespace := ""
+	for i := uint32(0); i < root.NamedChildCount(); i++ {
+		child := root.NamedChild(i)
+		switch child.Type() {
+		case "namespace_definition":
+			if name := child.ChildByFieldName("name"); !name.IsNull() {
+				namespace = name.Content(content)
+			}
+		case "class_declaration":
+			routes = append(routes, classRoutes(child, namespace, content)...)
+		}
+	}
+	return routes
+}
+
+func classRoutes(class sitter.Node, namespace string, content []byte) []attributeRoute {
+	nameNode := class.ChildByFieldName("name")
+	if nameNode.IsNull() {
+		return nil
+	}
+	fqcn := nameNode.Content(content)
+	if namespace != "" {
+		fqcn = namespace + "\\" + fqcn
+	}
+
+	prefixes := routeAttributesOn(class, content)
+	prefix := routeAttributeArgs{}
+	if len(prefixes) > 0 {
+		prefix = prefixes[0]
+	}
+
+	var routes []attributeRoute
+	var invoke sitter.Node
+	body := class.ChildByFieldName("body")
+	for i := uint32(0); !body.IsNull() && i < body.NamedChildCount(); i++ {
+		method := body.NamedChild(i)
+		if method.Type() != "method_declaration" {
+			continue
+		}
+		methodName := method.ChildByFieldName("name")
+		if methodName.IsNull() {
+			continue
+		}
+		action := methodName.Content(content)
+		if action == "__invoke" {
+			invoke = method
+		}
+		defaults := actionParameterDefaults(method, content)
+		for _, attr := range routeAttributesOn(method, content) {
+			routes = append(routes, newAttributeRoute(fqcn, action, prefix, attr, defaults))
+		}
+	}
+
+	// Invokable controllers may carry the route on the class
+	if len(routes) == 0 && !invoke.IsNull() {
+		defaults := actionParameterDefaults(invoke, content)
+		for _, attr := range prefixes {
+			routes = append(routes, newAttributeRoute(fqcn, "__invoke", routeAttributeArgs{}, attr, defaults))
+		}
+	}
+	return routes
+}
+
+// Placeholders are optional with an inline default, {page?1}, or when the
+// action argument of the same name has a default value
+func newAttributeRoute(class, action string, prefix, attr routeAttributeArgs, defaults map[string]bool) attributeRoute {
+	name := attr.name
+	if !attr.hasName {
+		name = defaultRouteName(class, action)
+	}
+	name = prefix.name + name
+	params := []string{}
+	var optional []string
+	for _, m := range routePlaceholderRe.FindAllStringSubmatch(prefix.path+attr.path, -1) {
+		params = append(params, m[1])
+		if m[2] != "" || defaults[m[1]] {
+			optional = append(optional, m[1])
+		}
+	}
+	return attributeRoute{
+		Route: Route{
+			Name:       name,
+			Parameters: params,
+			Controller: class,
+			Action:     action,
+			Path:       normalizeRoutePath(prefix.path + attr.path),
+			Optional:   optional,
+		},
+		nameRange: attr.nameRange,
+	}
+}
+
+// Returns the names of the arguments of an action that have a default value
+func actionParameterDefaults(method sitter.Node, content []byte) map[string]bool {
+	defaults := make(map[string]bool)
+	params := method.ChildByFieldName("parameters")
+	for i := uint32(0); !params.IsNull() && i < params.NamedChildCount(); i++ {
+		param := params.NamedChild(i)
+		name := param.ChildByFieldName("name")
+		if name.IsNull() || param.ChildByFieldName("default_value").IsNull() {
+			continue
+		}
+		defaults[strings.TrimPrefix(name.Content(content), "$")] = true
+	}
+	return defaults
+}
+
+// Mirrors the name Symfony gives to routes declared without one
+func defaultRouteName(class, action string) string {
+	name := strings.ToLower(strings.ReplaceAll(class, "\\", "_") + "_" + action)
+	name = defaultRouteNameRe.ReplaceAllString(name, "_")
+	return strings.ReplaceAll(name, "__", "_")
+}
+
+// Returns the #[Route] attributes of a class or method declaration
+func routeAttributesOn(decl sitter.Node, content []byte) []routeAttributeArgs {
+	var attrs []routeAttributeArgs
+	for i := uint32(0); i < decl.NamedChildCount(); i++ {
+		list := decl.NamedChild(i)
+		if list.Type() != "attribute_list" {
+			continue
+		}
+		walkSitterNodes(list, func(n sitter.Node) {
+			if n.Type() != "attribute" || routeAttributeName(n, content) != "Route" {
+				return
+			}
+			attrs = append(attrs, parseRouteAttribute(n, content))
+		})
+	}
+	return attrs
+}
+
+func routeAttributeName(attr sitter.Node, content []byte) string {
+	for i := uint32(0); i < attr.NamedChildCount(); i++ {
+		child := attr.NamedChild(i)
+		switch child.Type() {
+		case "name", "qualified_name":
+			name := child.Content(content)
+			return name[strings.LastIndex(name, "\\")+1:]
+		}
+	}
+	return ""
+}
+
+func parseRouteAttribute(attr sitter.Node, content []byte) routeAttributeArgs {
+	var args sitter.Node
+	for i := uint32(0); i < attr.NamedChildCount(); i++ {
+		if attr.NamedChild(i).Type() == "arguments" {
+			args = attr.NamedChild(i)
+		}
+	}
+
+	result := routeAttributeArgs{nameRange: sitterRange(attr, content)}
+	positional := 0
+	for i := uint32(0); !args.IsNull() && i < args.NamedChildCount(); i++ {
+		arg := args.NamedChild(i)
+		if arg.Type() != "argument" || arg.NamedChildCount() == 0 {
+			continue
+		}
+		param := ""
+		if name := arg.ChildByFieldName("name"); !name.IsNull() {
+			param = name.Content(content)
+		} else {
+			if positional < 2 {
+				param = []string{"path", "name"}[positional]
+			}
+			positional++
+		}
+		literal := arg.NamedChild(arg.NamedChildCount() - 1)
+		value, ok := phpLiteralString(literal, content)
+		if !ok {
+			continue
+		}
+		switch param {
+		case "path":
+			result.path = value
+		case "name":
+			result.name = value
+			result.hasName = true
+			result.nameRange = sitterRange(literal, content)
+		}
+	}
+	return result
+}
+
+// Returns the value of a string literal, or of the first one of an array
+// such as the paths of a localized route
+func phpLiteralString(n sitter.Node, content []byte) (string, bool) {
+	switch n.Type() {
+	case "string", "encapsed_string":
+		raw := n.Content(content)
+		if len(raw) < 2 {
+			return "", false
+		}
+		return raw[1 : len(raw)-1], true
+	case "array_creation_expression":
+		for i := uint32(0); i < n.NamedChildCount(); i++ {
+			item := n.NamedChild(i)
+			if item.NamedChildCount() > 0 {
+				return phpLiteralString(item.NamedChild(item.NamedChildCount()-1), content)
+			}
+		}
+	}
+	return "", false
+}
+
+func sitterRange(n sitter.Node, content []byte) protocol.Range {
+	start, end := n.StartPoint(), n.EndPoint()
+	return protocol.Range{
+		Start: utils.Position(content, int(start.Row), int(start.Column)),
+		End:   utils.Position(content, int(end.Row), int(end.Column)),
+	}
+}
+
+func walkSitterNodes(n sitter.Node, fn func(sitter.Node)) {
+	if n.IsNull() {
+		return
+	}
+	fn(n)
+	for i := uint32(0); i < n.NamedChildCount(); i++ {
+		walkSitterNodes(n.NamedChild(i), fn)
+	}
+}
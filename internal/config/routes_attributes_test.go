@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanRouteAttributes(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("src/Controller/Admin/PostController.php", `<?php
+namespace App\Controller\Admin;
+
+use Symfony\Component\Routing\Attribute\Route;
+
+#[Route('/admin/{_locale}', name: 'admin_')]
+class PostController
+{
+    #[Route('/post/{id<\d+>}', name: 'post_show', methods: ['GET'])]
+    public function show(int $id) {}
+
+    #[Route(path: ['en' => '/posts/{page?1}', 'fr' => '/articles/{page?1}'])]
+    public function list(string $_locale = 'en') {}
+
+    public function helper() {}
+}
+`)
+	write("src/Controller/HomeController.php", `<?php
+namespace App\Controller;
+
+use Symfony\Component\Routing\Annotation\Route;
+
+#[Route('/', name: 'home')]
+final class HomeController
+{
+    public function __invoke() {}
+}
+`)
+	write("src/Service/NotAController.php", `<?php
+namespace App\Service;
+
+class NotAController
+{
+    #[Route('/nope', name: 'nope')]
+    public function nope() {}
+}
+`)
+
+	routes := ScanRouteAttributes(root)
+	assert.Equal(t, RoutesMap{
+		"admin_post_show": {
+			Name:       "admin_post_show",
+			Parameters: []string{"_locale", "id"},
+			Controller: "App\\Controller\\Admin\\PostController",
+			Action:     "show",
+			Path:       "/admin/{_locale}/post/{id}",
+		},
+		"admin_app_admin_post_list": {
+			Name:       "admin_app_admin_post_list",
+			Parameters: []string{"_locale", "page"},
+			Controller: "App\\Controller\\Admin\\PostController",
+			Action:     "list",
+			Path:       "/admin/{_locale}/posts/{page}",
+			Optional:   []string{"_locale", "page"},
+		},
+		"home": {
+			Name:       "home",
+			Parameters: []string{},
+			Controller: "App\\Controller\\HomeController",
+			Action:     "__invoke",
+			Path:       "/",
+		},
+	}, routes)
+}
+
+func TestLoadRoutesMapFallsBackToAttributes(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "src", "Controller", "HomeController.php")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(`<?php
+namespace App\Controller;
+
+class HomeController
+{
+    #[Route('/', name: 'home')]
+    public function index() {}
+}
+`), 0o644))
+
+	cfg := NewConfig()
+	cfg.Container.WorkspaceRoot = root
+	cfg.RoutesCommand = []string{"false"}
+	cfg.LoadRoutesMap()
+
+	require.Contains(t, cfg.Routes, "home")
+	assert.Equal(t, "index", cfg.Routes["home"].Action)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const debugRouterJSON = `{
+    "app_post_show": {
+        "path": "\/{_locale}\/post\/{slug<[a-z-]+>}\/{page?1}",
+        "pathRegex": "{^\/(?P<_locale>[^\/]++)\/post\/(?P<slug>[a-z-]+)(?:\/(?P<page>[^\/]++))?$}sDu",
+        "host": "{subdomain}.example.com",
+        "defaults": {
+            "_controller": "App\\Controller\\PostController::show"
+        },
+        "options": {}
+    },
+    "app_home": {
+        "path": "\/",
+        "host": "ANY",
+        "defaults": {
+            "_controller": "App\\Controller\\HomeController"
+        }
+    },
+    "_preview_error": {
+        "path": "\/_error\/{code}.{_format}",
+        "defaults": {
+            "_controller": "error_controller::preview",
+            "_format": "html"
+        }
+    }
+}`
+
+func TestGetRoutesMapFromCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "routes.json"), []byte(debugRouterJSON), 0o644))
+
+	routes, err := GetRoutesMapFromCommand([]string{"cat", "routes.json"}, dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, RoutesMap{
+		"app_post_show": {
+			Name:       "app_post_show",
+			Parameters: []string{"subdomain", "_locale", "slug", "page"},
+			Controller: "App\\Controller\\PostController",
+			Action:     "show",
+			Path:       "/{_locale}/post/{slug}/{page}",
+		},
+		"app_home": {
+			Name:       "app_home",
+			Parameters: []string{},
+			Controller: "App\\Controller\\HomeController",
+			Action:     "__invoke",
+			Path:       "/",
+		},
+		"_preview_error": {
+			Name:       "_preview_error",
+			Parameters: []string{"code", "_format"},
+			Controller: "error_controller",
+			Action:     "preview",
+			Path:       "/_error/{code}.{_format}",
+			Optional:   []string{"_format"},
+		},
+	}, routes)
+
+	_, err = GetRoutesMapFromCommand([]string{"false"}, dir)
+	assert.Error(t, err)
+}
+
+func TestLoadRoutesMapFromCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "routes.json"), []byte(debugRouterJSON), 0o644))
+
+	cfg := NewConfig()
+	cfg.Container.WorkspaceRoot = dir
+	cfg.RoutesCommand = []string{"cat", "routes.json"}
+	cfg.LoadRoutesMap()
+
+	assert.Len(t, cfg.Routes, 3)
+	assert.Contains(t, cfg.Routes, "app_home")
+}
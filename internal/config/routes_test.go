@@ -20,14 +20,38 @@ func TestGetRoutesMap(t *testing.T) {
 			Parameters: []string{"token"},
 			Controller: "web_profiler.controller.profiler",
 			Action:     "toolbarAction",
+			Path:       "/_wdt/{token}",
 		},
 		"app_foo_bar": Route{
 			Name:       "app_foo_bar",
 			Parameters: []string{"id"},
 			Controller: "App\\Foo\\BarController",
 			Action:     "index",
+			Path:       "/foo/bar/{id}",
 		},
 	}
 
 	assert.Equal(t, expected, routesMap)
 }
+
+func TestRoutePathFromTokens(t *testing.T) {
+	routeData := []any{
+		[]any{"id"}, map[string]any{}, map[string]any{},
+		[]any{
+			[]any{"text", "/edit"},
+			[]any{"variable", "/", "[^/]++", "id", true},
+			[]any{"text", "/orders"},
+		},
+	}
+	assert.Equal(t, "/orders/{id}/edit", routePathFromTokens(routeData))
+	assert.Equal(t, "/", routePathFromTokens([]any{[]any{}, nil, nil, []any{}}))
+	assert.Equal(t, "", routePathFromTokens([]any{[]any{}}))
+}
+
+func TestOptionalRouteParameters(t *testing.T) {
+	routeData := []any{[]any{"slug", "page"}, map[string]any{"_controller": "App\\Controller\\BlogController::list", "page": float64(1)}}
+	assert.Equal(t, []string{"page"}, optionalRouteParameters([]string{"slug", "page"}, routeDefaults(routeData)))
+
+	// json_encode turns empty defaults into a list
+	assert.Nil(t, optionalRouteParameters([]string{"slug"}, routeDefaults([]any{[]any{"slug"}, []any{}})))
+}
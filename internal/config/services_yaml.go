@@ -0,0 +1,210 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tliron/commonlog"
+	"gopkg.in/yaml.v3"
+)
+
+var globBracesRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// LoadServicesFromYAML fills the services and parameters from
+// config/services.yaml when no compiled container provided any, e.g. on a
+// fresh clone. Resource namespaces are discovered from the files under their
+// directory the way autoconfiguration does, which is a best-effort guess: the
+// compiler passes and the bundles are unknown. Loading again replaces the
+// services read before.
+func (c *ContainerConfig) LoadServicesFromYAML() {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	if c.WorkspaceRoot == "" {
+		return
+	}
+	if c.servicesFromYAML {
+		c.ServiceClasses = make(map[string]string)
+		c.ServiceAliases = make(map[string]string)
+		c.Parameters = make(ParametersMap)
+		c.servicesFromYAML = false
+	}
+	if len(c.ServiceClasses) > 0 {
+		return
+	}
+
+	files, _ := filepath.Glob(filepath.Join(c.WorkspaceRoot, "config", "services*.y*ml"))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+			logger.Warningf("could not parse '%s': %v", path, err)
+			continue
+		}
+		root := doc.Content[0]
+		c.addYAMLServices(yamlMapValue(root, "services"), filepath.Dir(path))
+		c.addYAMLParameters(yamlMapValue(root, "parameters"))
+		// when@env sections apply on top, the first environment is enough
+		for i := 0; root.Kind == yaml.MappingNode && i+1 < len(root.Content); i += 2 {
+			if strings.HasPrefix(root.Content[i].Value, "when@") {
+				c.addYAMLServices(yamlMapValue(root.Content[i+1], "services"), filepath.Dir(path))
+				c.addYAMLParameters(yamlMapValue(root.Content[i+1], "parameters"))
+			}
+		}
+	}
+
+	if len(c.ServiceClasses) > 0 {
+		c.servicesFromYAML = true
+		logger.Infof("loaded %d services from services.yaml, no container XML was found", len(c.ServiceClasses))
+	}
+}
+
+func (c *ContainerConfig) addYAMLServices(services *yaml.Node, dir string) {
+	if services == nil || services.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		id, def := services.Content[i].Value, services.Content[i+1]
+		if id == "" || strings.HasPrefix(id, "_") {
+			continue
+		}
+
+		// Short alias syntax: `App\FooInterface: '@App\Foo'`
+		if def.Kind == yaml.ScalarNode && strings.HasPrefix(def.Value, "@") {
+			c.ServiceAliases[id] = strings.TrimPrefix(def.Value, "@")
+			continue
+		}
+		if alias := yamlMapValue(def, "alias"); alias != nil && alias.Value != "" {
+			c.ServiceAliases[id] = alias.Value
+			continue
+		}
+
+		if resource := yamlMapValue(def, "resource"); resource != nil && strings.HasSuffix(id, "\\") {
+			for _, class := range discoverResourceClasses(id, resource.Value, yamlStringList(yamlMapValue(def, "exclude")), dir) {
+				if _, ok := c.ServiceClasses[class]; !ok {
+					c.ServiceClasses[class] = class
+				}
+			}
+			continue
+		}
+
+		class := ""
+		if classNode := yamlMapValue(def, "class"); classNode != nil {
+			class = classNode.Value
+		} else if strings.Contains(id, "\\") {
+			class = id
+		}
+		if class != "" {
+			c.ServiceClasses[id] = strings.TrimPrefix(class, "\\")
+		}
+	}
+}
+
+func (c *ContainerConfig) addYAMLParameters(parameters *yaml.Node) {
+	if parameters == nil || parameters.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(parameters.Content); i += 2 {
+		name, value := parameters.Content[i].Value, parameters.Content[i+1]
+		if _, ok := c.Parameters[name]; ok || value.Kind != yaml.ScalarNode {
+			continue
+		}
+		c.Parameters[name] = value.Value
+	}
+}
+
+// Accepts a single string or a list of strings
+func yamlStringList(node *yaml.Node) []string {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+	var values []string
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode {
+			values = append(values, item.Value)
+		}
+	}
+	return values
+}
+
+// Lists the classes a resource namespace registers: every PHP file under the
+// resource directory that no exclude pattern matches
+func discoverResourceClasses(namespace, resource string, excludes []string, dir string) []string {
+	var excluded []string
+	for _, pattern := range excludes {
+		for _, expanded := range expandGlobBraces(pattern) {
+			excluded = append(excluded, filepath.Clean(filepath.Join(dir, expanded)))
+		}
+	}
+	isExcluded := func(path string) bool {
+		for _, pattern := range excluded {
+			if path == pattern || strings.HasPrefix(path, pattern+string(filepath.Separator)) {
+				return true
+			}
+			if ok, _ := filepath.Match(pattern, path); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	var classes []string
+	for _, base := range expandGlobBraces(resource) {
+		pattern := filepath.Join(dir, base)
+		// The namespace maps to the directory before the first wildcard
+		prefix := pattern
+		if i := strings.IndexAny(pattern, "*?["); i != -1 {
+			prefix = filepath.Dir(pattern[:i+1])
+		}
+		roots, _ := filepath.Glob(pattern)
+		for _, root := range roots {
+			classes = append(classes, discoverClassesUnder(namespace, filepath.Clean(prefix), root, isExcluded)...)
+		}
+	}
+	return classes
+}
+
+func discoverClassesUnder(namespace, prefix, root string, isExcluded func(string) bool) []string {
+	var classes []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if isExcluded(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || filepath.Ext(path) != ".php" {
+			return nil
+		}
+		rel, err := filepath.Rel(prefix, strings.TrimSuffix(path, ".php"))
+		if err != nil {
+			return nil
+		}
+		classes = append(classes, namespace+strings.ReplaceAll(rel, string(filepath.Separator), "\\"))
+		return nil
+	})
+	return classes
+}
+
+// Expands the {a,b} alternatives of a glob pattern
+func expandGlobBraces(pattern string) []string {
+	loc := globBracesRe.FindStringSubmatchIndex(pattern)
+	if loc == nil {
+		return []string{pattern}
+	}
+	var patterns []string
+	for _, alt := range strings.Split(pattern[loc[2]:loc[3]], ",") {
+		patterns = append(patterns, expandGlobBraces(pattern[:loc[0]]+alt+pattern[loc[1]:])...)
+	}
+	return patterns
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadServicesFromYAML(t *testing.T) {
+	root := t.TempDir()
+
+	servicesYAML := `parameters:
+  app.locale: 'en'
+  app.limits: [1, 2]
+
+services:
+  _defaults:
+    autowire: true
+
+  App\:
+    resource: '../src/'
+    exclude:
+      - '../src/DependencyInjection/'
+      - '../src/{Entity,Kernel.php}'
+
+  app.mailer:
+    class: App\Service\Mailer
+
+  App\Service\MailerInterface: '@app.mailer'
+
+  mailer.alias:
+    alias: app.mailer
+
+when@dev:
+  services:
+    App\Dev\Profiler: ~
+`
+	files := map[string]string{
+		"config/services.yaml":                     servicesYAML,
+		"src/Kernel.php":                           "<?php",
+		"src/Entity/Post.php":                      "<?php",
+		"src/DependencyInjection/AppExtension.php": "<?php",
+		"src/Controller/PostController.php":        "<?php",
+		"src/Service/Mailer.php":                   "<?php",
+		"src/Service/README.md":                    "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.LoadServicesFromYAML()
+
+	assert.Equal(t, map[string]string{
+		`App\Controller\PostController`: `App\Controller\PostController`,
+		`App\Service\Mailer`:            `App\Service\Mailer`,
+		"app.mailer":                    `App\Service\Mailer`,
+		`App\Dev\Profiler`:              `App\Dev\Profiler`,
+	}, c.ServiceClasses)
+	assert.Equal(t, map[string]string{
+		`App\Service\MailerInterface`: "app.mailer",
+		"mailer.alias":                "app.mailer",
+	}, c.ServiceAliases)
+	assert.Equal(t, "en", c.Parameters["app.locale"])
+	assert.NotContains(t, c.Parameters, "app.limits")
+}
+
+func TestLoadServicesFromYAMLKeepsContainerServices(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "config", "services.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("services:\n  app.other:\n    class: App\\Other\n"), 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.ServiceClasses["app.compiled"] = `App\Compiled`
+	c.LoadServicesFromYAML()
+
+	assert.Equal(t, map[string]string{"app.compiled": `App\Compiled`}, c.ServiceClasses)
+}
+
+func TestLoadServicesFromYAMLAgainReplacesTheServices(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "config", "services.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("services:\n  app.mailer:\n    class: App\\Mailer\n"), 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.LoadServicesFromYAML()
+	require.Equal(t, map[string]string{"app.mailer": `App\Mailer`}, c.ServiceClasses)
+
+	require.NoError(t, os.WriteFile(path, []byte("services:\n  app.notifier:\n    class: App\\Notifier\n"), 0o644))
+	c.LoadServicesFromYAML()
+	assert.Equal(t, map[string]string{"app.notifier": `App\Notifier`}, c.ServiceClasses)
+}
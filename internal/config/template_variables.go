@@ -0,0 +1,235 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var (
+	renderCallRe      = regexp.MustCompile(`->(?:render|renderView|renderForm|renderBlock|renderBlockView|stream)\s*\(\s*['"]([^'"]+\.twig)['"]\s*,\s*(\[|array\s*\()`)
+	phpNamespaceRe    = regexp.MustCompile(`(?m)^\s*namespace\s+([^;\s]+)\s*;`)
+	phpClassRe        = regexp.MustCompile(`\bclass\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	phpFunctionNameRe = regexp.MustCompile(`\bfunction\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	phpUseRe          = regexp.MustCompile(`(?m)^\s*use\s+\\?([A-Za-z0-9_\\]+)(?:\s+as\s+([A-Za-z_][A-Za-z0-9_]*))?\s*;`)
+	formVariableRe    = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)(?:->createView\(\))?$`)
+	createFormCallRe  = regexp.MustCompile(`^\$this->(?:createForm|[A-Za-z_][A-Za-z0-9_]*->create)\(\s*([\\A-Za-z0-9_]+)::class`)
+)
+
+// TemplateVariable is a key of the context array a controller passes to a template
+type TemplateVariable struct {
+	Name string
+	// Value is the PHP expression assigned to the key
+	Value string
+	// FormType is the FQN of the form type when the value is a form view
+	FormType string
+	// Controller is the method that renders the template, as Class::method
+	Controller string
+	Location   protocol.Location
+}
+
+// LoadTemplateVariables indexes the render('template.html.twig', [...]) calls
+// of the application's classes, so templates know the variables they receive.
+func (c *ContainerConfig) LoadTemplateVariables(autoload AutoloadMap) {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	c.TemplateVariables = make(map[string][]TemplateVariable)
+	if c.WorkspaceRoot == "" {
+		return
+	}
+
+	c.WalkAppSources(autoload, c.indexRenderCalls)
+
+	for template := range c.TemplateVariables {
+		vars := c.TemplateVariables[template]
+		sort.SliceStable(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	}
+	logger.Infof("indexed render() variables of %d templates", len(c.TemplateVariables))
+}
+
+func (c *ContainerConfig) indexRenderCalls(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	content := string(data)
+
+	matches := renderCallRe.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return
+	}
+
+	namespace := ""
+	if m := phpNamespaceRe.FindStringSubmatch(content); m != nil {
+		namespace = m[1]
+	}
+	uses := phpUses(content)
+	uri := protocol.DocumentUri(utils.PathToURI(path))
+	lineStarts := lineStartOffsets(content)
+
+	for _, m := range matches {
+		template := content[m[2]:m[3]]
+		controller := enclosingPHPMethod(content[:m[0]], namespace)
+		body := content[:m[0]]
+		if fns := phpFunctionNameRe.FindAllStringIndex(body, -1); len(fns) > 0 {
+			body = body[fns[len(fns)-1][0]:]
+		}
+		for _, key := range phpArrayPairs(content, m[5]-1) {
+			line, col := offsetToPosition(content, lineStarts, key.offset)
+			formType := ""
+			if class := formTypeOfValue(key.value, body); class != "" {
+				formType = resolvePHPClass(class, namespace, uses)
+			}
+			c.TemplateVariables[template] = append(c.TemplateVariables[template], TemplateVariable{
+				Name:       key.name,
+				Value:      key.value,
+				FormType:   formType,
+				Controller: controller,
+				Location: protocol.Location{
+					URI: uri,
+					Range: protocol.Range{
+						Start: protocol.Position{Line: uint32(line), Character: uint32(col)},
+						End:   protocol.Position{Line: uint32(line), Character: uint32(col + characters(key.name))},
+					},
+				},
+			})
+		}
+	}
+}
+
+// Returns Namespace\Class::method for the last class and function declared
+// before the call
+func enclosingPHPMethod(before, namespace string) string {
+	class := ""
+	if m := phpClassRe.FindAllStringSubmatch(before, -1); len(m) > 0 {
+		class = m[len(m)-1][1]
+	}
+	method := ""
+	if m := phpFunctionNameRe.FindAllStringSubmatch(before, -1); len(m) > 0 {
+		method = m[len(m)-1][1]
+	}
+	if namespace != "" && class != "" {
+		class = namespace + "\\" + class
+	}
+	if method == "" {
+		return class
+	}
+	return class + "::" + method
+}
+
+// Returns the form type class a render() value is created from, either
+// directly with $this->createForm(Type::class)->createView() or through the
+// last assignment of the variable in the method body
+func formTypeOfValue(value, body string) string {
+	if m := createFormCallRe.FindStringSubmatch(value); m != nil {
+		return m[1]
+	}
+	m := formVariableRe.FindStringSubmatch(value)
+	if m == nil {
+		return ""
+	}
+	assignRe := regexp.MustCompile(`\$` + m[1] + `\s*=\s*(\$this->[^;]*)`)
+	class := ""
+	for _, assign := range assignRe.FindAllStringSubmatch(body, -1) {
+		if call := createFormCallRe.FindStringSubmatch(assign[1]); call != nil {
+			class = call[1]
+		}
+	}
+	return class
+}
+
+// Maps the aliases of the use statements of a PHP file to their FQN
+func phpUses(content string) map[string]string {
+	uses := make(map[string]string)
+	for _, m := range phpUseRe.FindAllStringSubmatch(content, -1) {
+		alias := m[2]
+		if alias == "" {
+			alias = m[1][strings.LastIndex(m[1], "\\")+1:]
+		}
+		uses[alias] = m[1]
+	}
+	return uses
+}
+
+// Resolves a class name as written in a PHP file to its FQN
+func resolvePHPClass(name, namespace string, uses map[string]string) string {
+	if strings.HasPrefix(name, "\\") {
+		return strings.TrimPrefix(name, "\\")
+	}
+	first, rest, _ := strings.Cut(name, "\\")
+	if fqn, ok := uses[first]; ok {
+		if rest != "" {
+			return fqn + "\\" + rest
+		}
+		return fqn
+	}
+	if namespace != "" {
+		return namespace + "\\" + name
+	}
+	return name
+}
+
+func lineStartOffsets(content string) []int {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+func offsetToPosition(content string, lineStarts []int, offset int) (int, int) {
+	line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+	return line, characters(content[lineStarts[line]:offset])
+}
+
+// Returns the characters the client counts in text
+func characters(text string) int {
+	return int(utils.Character([]byte(text), len(text)))
+}
+
+// WalkAppSources calls fn for every PHP file of the application's PSR-4
+// directories, leaving out the vendor directory
+func (c *ContainerConfig) WalkAppSources(autoload AutoloadMap, fn func(path string)) {
+	roots := make(map[string]struct{})
+	for _, paths := range autoload.PSR4 {
+		for _, path := range paths {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(c.WorkspaceRoot, path)
+			}
+			rel, err := filepath.Rel(c.WorkspaceRoot, path)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			if first, _, _ := strings.Cut(filepath.ToSlash(rel), "/"); first == "vendor" {
+				continue
+			}
+			roots[filepath.Clean(path)] = struct{}{}
+		}
+	}
+
+	for root := range roots {
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if p != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(p, ".php") {
+				fn(p)
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplateVariables(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	write("src/Controller/PostController.php", `<?php
+
+namespace App\Controller;
+
+use App\Form\PostType;
+
+class PostController extends AbstractController
+{
+    public function show(Post $post): Response
+    {
+        return $this->render('post/show.html.twig', [
+            'post' => $post,
+            'comments' => $this->repository->findBy(['post' => $post], ['id' => 'DESC']),
+            "title" => 'Post => ' . $post->getTitle(),
+        ]);
+    }
+
+    public function edit(Post $post): Response
+    {
+        $form = $this->createForm(PostType::class, $post);
+
+        return $this->render('post/edit.html.twig', array('form' => $form, 'post' => $post));
+    }
+}
+`)
+	write("vendor/acme/lib/src/Renderer.php", `<?php
+namespace Acme;
+class Renderer { function run() { $this->render('post/show.html.twig', ['vendor' => 1]); } }
+`)
+
+	autoload := NewAutoloadMap()
+	autoload.PSR4["App\\"] = []string{"src"}
+	autoload.PSR4["Acme\\"] = []string{filepath.Join(root, "vendor/acme/lib/src")}
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.LoadTemplateVariables(autoload)
+
+	show := c.TemplateVariables["post/show.html.twig"]
+	require.Len(t, show, 3)
+	assert.Equal(t, "comments", show[0].Name)
+	assert.Equal(t, "$this->repository->findBy(['post' => $post], ['id' => 'DESC'])", show[0].Value)
+	assert.Equal(t, "post", show[1].Name)
+	assert.Equal(t, "$post", show[1].Value)
+	assert.Equal(t, "App\\Controller\\PostController::show", show[1].Controller)
+	assert.Equal(t, uint32(11), show[1].Location.Range.Start.Line)
+	assert.Equal(t, uint32(13), show[1].Location.Range.Start.Character)
+	assert.Equal(t, "title", show[2].Name)
+
+	edit := c.TemplateVariables["post/edit.html.twig"]
+	require.Len(t, edit, 2)
+	assert.Equal(t, "form", edit[0].Name)
+	assert.Equal(t, "$form", edit[0].Value)
+	assert.Equal(t, "App\\Controller\\PostController::edit", edit[0].Controller)
+	assert.Equal(t, "App\\Form\\PostType", edit[0].FormType)
+	assert.Empty(t, edit[1].FormType)
+}
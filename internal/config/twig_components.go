@@ -0,0 +1,137 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Namespace of the twig_component.defaults entry of the Symfony recipe
+const twigComponentsNamespace = "App\\Twig\\Components\\"
+
+var (
+	asTwigComponentRe   = regexp.MustCompile(`#\[\s*(?:\\?Symfony\\UX\\TwigComponent\\Attribute\\)?AsTwigComponent\s*(?:\(([^)]*)\))?\s*\]`)
+	componentClassRe    = regexp.MustCompile(`\bclass\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	componentPositional = regexp.MustCompile(`^\s*['"]([^'"]+)['"]`)
+	componentNamedArgRe = regexp.MustCompile(`\b(name|template)\s*:\s*['"]([^'"]+)['"]`)
+)
+
+// TwigComponent is a Symfony UX component, backed by a class or anonymous
+type TwigComponent struct {
+	Name string
+	// Class is empty for anonymous components
+	Class    string
+	Template string
+	Location protocol.Location
+}
+
+// LoadTwigComponents indexes the #[AsTwigComponent] classes of the application
+// and the anonymous components of the templates/components directories.
+func (c *ContainerConfig) LoadTwigComponents(autoload AutoloadMap) {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	c.TwigComponents = make(map[string]TwigComponent)
+	if c.WorkspaceRoot == "" {
+		return
+	}
+
+	c.WalkAppSources(autoload, c.indexTwigComponentClass)
+
+	for _, root := range c.Roots {
+		base := root
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(c.WorkspaceRoot, base)
+		}
+		components := filepath.Join(base, "components")
+		_ = filepath.WalkDir(components, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(p, ".html.twig") {
+				return nil
+			}
+			rel, err := filepath.Rel(components, p)
+			if err != nil {
+				return nil
+			}
+			rel = strings.TrimSuffix(filepath.ToSlash(rel), ".html.twig")
+			name := strings.ReplaceAll(rel, "/", ":")
+			if _, exists := c.TwigComponents[name]; exists {
+				return nil
+			}
+			c.TwigComponents[name] = TwigComponent{
+				Name:     name,
+				Template: "components/" + rel + ".html.twig",
+				Location: protocol.Location{URI: protocol.DocumentUri(utils.PathToURI(p))},
+			}
+			return nil
+		})
+	}
+	logger.Infof("indexed %d twig components", len(c.TwigComponents))
+}
+
+func (c *ContainerConfig) indexTwigComponentClass(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	content := string(data)
+
+	m := asTwigComponentRe.FindStringSubmatchIndex(content)
+	if m == nil {
+		return
+	}
+	args := ""
+	if m[2] >= 0 {
+		args = content[m[2]:m[3]]
+	}
+	classMatch := componentClassRe.FindStringSubmatchIndex(content[m[1]:])
+	if classMatch == nil {
+		return
+	}
+	classStart := m[1] + classMatch[2]
+	short := content[classStart : m[1]+classMatch[3]]
+
+	class := short
+	if ns := phpNamespaceRe.FindStringSubmatch(content); ns != nil {
+		class = ns[1] + "\\" + short
+	}
+
+	name := ""
+	template := ""
+	if pm := componentPositional.FindStringSubmatch(args); pm != nil {
+		name = pm[1]
+	}
+	for _, nm := range componentNamedArgRe.FindAllStringSubmatch(args, -1) {
+		if nm[1] == "name" {
+			name = nm[2]
+		} else {
+			template = nm[2]
+		}
+	}
+	if name == "" {
+		name = short
+		if rest, ok := strings.CutPrefix(class, twigComponentsNamespace); ok {
+			name = strings.ReplaceAll(rest, "\\", ":")
+		}
+	}
+	if template == "" {
+		template = "components/" + strings.ReplaceAll(name, ":", "/") + ".html.twig"
+	}
+
+	line, col := offsetToPosition(content, lineStartOffsets(content), classStart)
+	c.TwigComponents[name] = TwigComponent{
+		Name:     name,
+		Class:    class,
+		Template: template,
+		Location: protocol.Location{
+			URI: protocol.DocumentUri(utils.PathToURI(path)),
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(line), Character: uint32(col)},
+				End:   protocol.Position{Line: uint32(line), Character: uint32(col + characters(short))},
+			},
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTwigComponents(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	write("src/Twig/Components/Alert.php", `<?php
+
+namespace App\Twig\Components;
+
+use Symfony\UX\TwigComponent\Attribute\AsTwigComponent;
+
+#[AsTwigComponent]
+final class Alert
+{
+    public string $type = 'success';
+}
+`)
+	write("src/Twig/Components/Form/Input.php", `<?php
+
+namespace App\Twig\Components\Form;
+
+#[AsTwigComponent]
+class Input {}
+`)
+	write("src/Component/Card.php", `<?php
+
+namespace App\Component;
+
+#[AsTwigComponent('product_card', template: 'cards/product.html.twig')]
+class Card {}
+`)
+	write("src/Service/Mailer.php", `<?php
+
+namespace App\Service;
+
+class Mailer {}
+`)
+	write("templates/components/Button.html.twig", "<button>{% block content %}{% endblock %}</button>")
+	write("templates/components/Form/Label.html.twig", "<label></label>")
+	write("templates/components/Alert.html.twig", "<div></div>")
+
+	autoload := NewAutoloadMap()
+	autoload.PSR4["App\\"] = []string{"src"}
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.LoadTwigComponents(autoload)
+
+	require.Len(t, c.TwigComponents, 5)
+
+	alert := c.TwigComponents["Alert"]
+	assert.Equal(t, "App\\Twig\\Components\\Alert", alert.Class)
+	assert.Equal(t, "components/Alert.html.twig", alert.Template)
+	assert.Equal(t, uint32(7), alert.Location.Range.Start.Line)
+	assert.Equal(t, uint32(12), alert.Location.Range.Start.Character)
+
+	assert.Equal(t, "App\\Twig\\Components\\Form\\Input", c.TwigComponents["Form:Input"].Class)
+	assert.Equal(t, "components/Form/Input.html.twig", c.TwigComponents["Form:Input"].Template)
+
+	card := c.TwigComponents["product_card"]
+	assert.Equal(t, "App\\Component\\Card", card.Class)
+	assert.Equal(t, "cards/product.html.twig", card.Template)
+
+	assert.Empty(t, c.TwigComponents["Button"].Class)
+	assert.Equal(t, "components/Form/Label.html.twig", c.TwigComponents["Form:Label"].Template)
+}
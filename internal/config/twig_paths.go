@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/commonlog"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadTwigPaths adds the twig.paths of the twig.yaml configuration: paths
+// mapped to a namespace become @namespace roots, the others bare roots.
+func (c *ContainerConfig) LoadTwigPaths() {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	if c.WorkspaceRoot == "" {
+		return
+	}
+
+	var files []string
+	for _, pattern := range []string{"config/packages/twig.y*ml", "config/packages/*/twig.y*ml"} {
+		matches, _ := filepath.Glob(filepath.Join(c.WorkspaceRoot, pattern))
+		files = append(files, matches...)
+	}
+
+	added := 0
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+			continue
+		}
+		paths := yamlMapValue(yamlMapValue(doc.Content[0], "twig"), "paths")
+		if paths == nil || paths.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(paths.Content); i += 2 {
+			base := paths.Content[i].Value
+			if rest, ok := strings.CutPrefix(base, "%kernel.project_dir%"); ok {
+				base = filepath.Join(c.WorkspaceRoot, rest)
+			} else if !filepath.IsAbs(base) {
+				base = filepath.Join(c.WorkspaceRoot, base)
+			}
+			// Other parameters can't be resolved without the container
+			if strings.Contains(base, "%") {
+				continue
+			}
+
+			namespace := strings.TrimPrefix(paths.Content[i+1].Value, "@")
+			if paths.Content[i+1].Tag == "!!null" || namespace == "" {
+				before := len(c.Roots)
+				c.Roots = utils.AppendUnique(c.Roots, base)
+				added += len(c.Roots) - before
+				continue
+			}
+			if strings.HasPrefix(namespace, "!") {
+				continue
+			}
+			if c.BundleRoots == nil {
+				c.BundleRoots = make(map[string][]string)
+			}
+			before := len(c.BundleRoots[namespace])
+			c.BundleRoots[namespace] = utils.AppendUnique(c.BundleRoots[namespace], base)
+			added += len(c.BundleRoots[namespace]) - before
+		}
+	}
+	logger.Infof("added %d twig paths from twig.yaml", added)
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTwigPaths(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "config", "packages"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "packages", "twig.yaml"), []byte(`twig:
+    default_path: '%kernel.project_dir%/templates'
+    paths:
+        '%kernel.project_dir%/templates/admin': admin
+        'lib/emails': '@emails'
+        'lib/shared': ~
+        '%shared_dir%/twig': shared
+        'templates/bundles/AcmeBundle': '!Acme'
+`), 0o644))
+
+	c := NewContainerConfig()
+	c.WorkspaceRoot = root
+	c.Roots = []string{"templates"}
+	c.LoadTwigPaths()
+
+	assert.Equal(t, []string{"templates", filepath.Join(root, "lib/shared")}, c.Roots)
+	assert.Equal(t, map[string][]string{
+		"admin":  {filepath.Join(root, "templates/admin")},
+		"emails": {filepath.Join(root, "lib/emails")},
+	}, c.BundleRoots)
+}
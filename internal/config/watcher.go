@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tliron/commonlog"
+)
+
+// ArtifactWatcher polls the files the indexes are built from, such as the
+// container XML or the Composer autoload files, and rebuilds an index once
+// its files changed and settled. Polling keeps it free of platform specific
+// notifications; the artifacts are few and rewritten as a whole by
+// cache:warmup or composer dump-autoload.
+type ArtifactWatcher struct {
+	interval  time.Duration
+	mu        sync.Mutex
+	artifacts []*watchedArtifact
+	stop      chan struct{}
+}
+
+type watchedArtifact struct {
+	name      string
+	paths     func() []string
+	reload    func()
+	signature string
+	// The changed signature waiting for the next poll to confirm it
+	pending string
+}
+
+func NewArtifactWatcher(interval time.Duration) *ArtifactWatcher {
+	return &ArtifactWatcher{interval: interval}
+}
+
+// Watch calls reload when the files or directories returned by paths change.
+// The paths are asked again on every poll, as a reload may change them.
+func (w *ArtifactWatcher) Watch(name string, paths func() []string, reload func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.artifacts = append(w.artifacts, &watchedArtifact{
+		name:      name,
+		paths:     paths,
+		reload:    reload,
+		signature: artifactSignature(paths()),
+	})
+}
+
+// Start polls in the background until Stop.
+func (w *ArtifactWatcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil || w.interval <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.poll()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (w *ArtifactWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+func (w *ArtifactWatcher) poll() {
+	logger := commonlog.GetLoggerf("vimfony.config")
+	w.mu.Lock()
+	artifacts := append([]*watchedArtifact(nil), w.artifacts...)
+	w.mu.Unlock()
+
+	for _, a := range artifacts {
+		signature := artifactSignature(a.paths())
+		switch {
+		case signature == a.signature:
+			a.pending = ""
+		case signature != a.pending:
+			// Still being written, wait for it to settle
+			a.pending = signature
+		default:
+			logger.Infof("%s changed, reloading", a.name)
+			a.reload()
+			a.signature = artifactSignature(a.paths())
+			a.pending = ""
+		}
+	}
+}
+
+// Sums up the size and modification time of the files, walking directories
+func artifactSignature(paths []string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				fmt.Fprintf(&b, "%s:-;", p)
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(&b, "%s:%d:%d;", p, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return b.String()
+}
+
+func (c *ContainerConfig) absContainerPaths() []string {
+	var paths []string
+	for _, p := range c.ContainerXMLPaths {
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(c.WorkspaceRoot, p)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// ContainerArtifacts lists the container dumps, XML or PHP.
+func (c *ContainerConfig) ContainerArtifacts() []string {
+	var paths []string
+	for _, p := range c.absContainerPaths() {
+		paths = append(paths, p)
+		if filepath.Ext(p) == ".xml" {
+			paths = append(paths, strings.TrimSuffix(p, ".xml")+".php")
+		}
+	}
+	return paths
+}
+
+// TranslationArtifacts lists the compiled translation metadata next to the
+// containers and the translation roots.
+func (c *ContainerConfig) TranslationArtifacts() []string {
+	var paths []string
+	for _, p := range c.absContainerPaths() {
+		paths = append(paths, filepath.Join(filepath.Dir(p), "translations"))
+	}
+	for _, root := range c.TranslationRoots {
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(c.WorkspaceRoot, root)
+		}
+		paths = append(paths, root)
+	}
+	return paths
+}
+
+// AssetArtifacts lists the public directory, which holds the AssetMapper
+// manifest.
+func (c *ContainerConfig) AssetArtifacts() []string {
+	return []string{c.publicDir()}
+}
+
+// RoutesArtifacts lists the compiled routes files next to the containers.
+func (c *Config) RoutesArtifacts() []string {
+	var paths []string
+	for _, p := range c.Container.absContainerPaths() {
+		paths = append(paths, filepath.Join(filepath.Dir(p), "url_generating_routes.php"))
+	}
+	return paths
+}
+
+// AutoloadArtifacts lists the Composer files the autoload map comes from.
+func (c *Config) AutoloadArtifacts() []string {
+	if c.VendorDir == "" {
+		return nil
+	}
+	paths := []string{
+		filepath.Join(c.VendorDir, "composer", "autoload_psr4.php"),
+		filepath.Join(c.VendorDir, "composer", "autoload_classmap.php"),
+		"composer.json",
+	}
+	for i, p := range paths {
+		if !filepath.IsAbs(p) {
+			paths[i] = filepath.Join(c.Container.WorkspaceRoot, p)
+		}
+	}
+	return paths
+}
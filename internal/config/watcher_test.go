@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactWatcherReloadsSettledChanges(t *testing.T) {
+	root := t.TempDir()
+	containerPath := filepath.Join(root, "container.xml")
+	require.NoError(t, os.WriteFile(containerPath, []byte("<container/>"), 0o644))
+
+	reloads := 0
+	w := NewArtifactWatcher(time.Second)
+	w.Watch("container", func() []string { return []string{containerPath} }, func() { reloads++ })
+
+	w.poll()
+	assert.Equal(t, 0, reloads)
+
+	require.NoError(t, os.WriteFile(containerPath, []byte("<container><services/></container>"), 0o644))
+	w.poll()
+	assert.Equal(t, 0, reloads, "waits for the change to settle")
+	w.poll()
+	assert.Equal(t, 1, reloads)
+	w.poll()
+	assert.Equal(t, 1, reloads)
+
+	// Files showing up in a watched directory count as a change too
+	translationsDir := filepath.Join(root, "translations")
+	w.Watch("translations", func() []string { return []string{translationsDir} }, func() { reloads++ })
+	require.NoError(t, os.MkdirAll(translationsDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(translationsDir, "messages.en.yaml"), []byte("a: b"), 0o644))
+	w.poll()
+	w.poll()
+	assert.Equal(t, 2, reloads)
+}
+
+func TestConfigArtifacts(t *testing.T) {
+	c := NewConfig()
+	c.Container.WorkspaceRoot = "/app"
+	c.VendorDir = "vendor"
+	c.Container.SetContainerXMLPaths([]string{"var/cache/dev/App_KernelDevDebugContainer.xml"})
+
+	assert.Equal(t, []string{
+		"/app/var/cache/dev/App_KernelDevDebugContainer.xml",
+		"/app/var/cache/dev/App_KernelDevDebugContainer.php",
+	}, c.Container.ContainerArtifacts())
+	assert.Equal(t, []string{"/app/var/cache/dev/url_generating_routes.php"}, c.RoutesArtifacts())
+	assert.Equal(t, []string{"/app/var/cache/dev/translations", "/app/translations"}, c.Container.TranslationArtifacts())
+	assert.Equal(t, []string{
+		"/app/vendor/composer/autoload_psr4.php",
+		"/app/vendor/composer/autoload_classmap.php",
+		"/app/composer.json",
+	}, c.AutoloadArtifacts())
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Index of the <service> elements of the XML files under config/, refreshed
+// for the files that changed since they were read
+type xmlServiceIndex struct {
+	mu    sync.Mutex
+	files map[string]xmlServiceFile
+}
+
+type xmlServiceFile struct {
+	modTime  time.Time
+	services map[string][]protocol.Range
+}
+
+// ServiceDeclarations returns the <service> elements declaring the id in the
+// XML service files of the workspace, in path order
+func (c *ContainerConfig) ServiceDeclarations(id string) []protocol.Location {
+	if c.WorkspaceRoot == "" || id == "" {
+		return nil
+	}
+	root := filepath.Join(c.WorkspaceRoot, "config")
+
+	idx := &c.xmlServices
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var locations []protocol.Location
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".xml") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		seen[path] = true
+		file, ok := idx.files[path]
+		if !ok || !file.modTime.Equal(info.ModTime()) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			file = xmlServiceFile{modTime: info.ModTime(), services: parseXMLServiceDeclarations(data)}
+			if idx.files == nil {
+				idx.files = make(map[string]xmlServiceFile)
+			}
+			idx.files[path] = file
+		}
+		for _, rng := range file.services[id] {
+			locations = append(locations, protocol.Location{URI: protocol.DocumentUri(utils.PathToURI(path)), Range: rng})
+		}
+		return nil
+	})
+
+	for path := range idx.files {
+		if !seen[path] {
+			delete(idx.files, path)
+		}
+	}
+	return locations
+}
+
+// Returns the ranges of the <service> start tags of a services file by id.
+// Files whose root is not <container> declare nothing.
+func parseXMLServiceDeclarations(data []byte) map[string][]protocol.Range {
+	services := make(map[string][]protocol.Range)
+	content := string(data)
+	lineStarts := lineStartOffsets(content)
+	position := func(offset int64) protocol.Position {
+		line, col := offsetToPosition(content, lineStarts, int(offset))
+		return protocol.Position{Line: uint32(line), Character: uint32(col)}
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	depth := 0
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 && el.Name.Local != "container" {
+				return services
+			}
+			if el.Name.Local != "service" {
+				continue
+			}
+			for _, attr := range el.Attr {
+				if attr.Name.Local == "id" && attr.Value != "" {
+					services[attr.Value] = append(services[attr.Value], protocol.Range{
+						Start: position(start),
+						End:   position(dec.InputOffset()),
+					})
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return services
+}
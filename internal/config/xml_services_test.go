@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestServiceDeclarations(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	services := write("config/services.xml", `<?xml version="1.0" encoding="UTF-8" ?>
+<container>
+    <services>
+        <service id="app.mailer"
+                 class="App\Mailer">
+            <argument>smtp</argument>
+        </service>
+    </services>
+</container>
+`)
+	override := write("config/packages/test/services.xml", `<container><services><service id="app.mailer" class="App\FakeMailer"/></services></container>`)
+	write("config/other.xml", `<routes><service id="app.mailer"/></routes>`)
+
+	c := &ContainerConfig{WorkspaceRoot: root}
+	locations := c.ServiceDeclarations("app.mailer")
+	require.Len(t, locations, 2)
+	assert.Equal(t, protocol.DocumentUri(utils.PathToURI(override)), locations[0].URI)
+	assert.Equal(t, protocol.Position{Line: 0, Character: 21}, locations[0].Range.Start)
+	assert.Equal(t, protocol.DocumentUri(utils.PathToURI(services)), locations[1].URI)
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 3, Character: 8},
+		End:   protocol.Position{Line: 4, Character: 36},
+	}, locations[1].Range)
+	assert.Empty(t, c.ServiceDeclarations("app.unknown"))
+
+	// Changed files are read again
+	require.NoError(t, os.Remove(override))
+	write("config/services.xml", `<container><services><service id="app.renamed"/></services></container>`)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(services, future, future))
+	assert.Empty(t, c.ServiceDeclarations("app.mailer"))
+	assert.Len(t, c.ServiceDeclarations("app.renamed"), 1)
+}
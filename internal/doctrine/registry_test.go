@@ -22,7 +22,7 @@ func setupMockRegistry(t *testing.T) *Registry {
 		},
 	}
 
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 
 	entityDir := filepath.Join(mockRoot, "Entity")
@@ -209,7 +209,7 @@ func TestRegistry_ResolveTargetEntity(t *testing.T) {
 		},
 	}
 
-	store := php.NewDocumentStore(10)
+	store := php.NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 
 	entityDir := filepath.Join(mockRoot, "Entity")
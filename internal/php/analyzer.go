@@ -67,9 +67,10 @@ func (a *StaticAnalyzer) Update(content *[]byte, tree *sitter.Tree, dirty []Byte
 	}
 
 	if !a.built || len(dirty) == 0 {
+		classes := ctx.collectClassInfo()
 		props := ctx.collectPropertyTypes()
+		ctx.mergeTraitProperties(props, classes)
 		vars := ctx.collectFunctionVariableTypes(props)
-		classes := ctx.collectClassInfo()
 		priv, prot, pub := ctx.collectFunctionInfos(classes)
 		uses := ctx.collectNamespaceUses(tree.RootNode())
 		a.index = IndexedTree{
@@ -77,6 +78,7 @@ func (a *StaticAnalyzer) Update(content *[]byte, tree *sitter.Tree, dirty []Byte
 			Variables:          vars,
 			Types:              computeTypeReferences(props, vars),
 			Classes:            classes,
+			Constants:          ctx.collectConstants(),
 			Uses:               uses,
 			PrivateFunctions:   priv,
 			ProtectedFunctions: prot,
@@ -92,8 +94,9 @@ func (a *StaticAnalyzer) Update(content *[]byte, tree *sitter.Tree, dirty []Byte
 	classes := cloneClassIndex(a.index.Classes)
 
 	index := ctx.updateIndex(props, vars, classes, dirty)
-	// Refresh uses for the whole file
+	// Refresh uses and constants for the whole file
 	index.Uses = ctx.collectNamespaceUses(tree.RootNode())
+	index.Constants = ctx.collectConstants()
 
 	priv, prot, pub := ctx.collectFunctionInfos(index.Classes)
 	index.PrivateFunctions = priv
@@ -157,13 +160,18 @@ func cloneClassIndex(in map[uint32]ClassInfo) map[uint32]ClassInfo {
 		extends := make([]string, len(v.Extends))
 		copy(extends, v.Extends)
 		out[k] = ClassInfo{
-			Name:      v.Name,
-			Namespace: v.Namespace,
-			FQN:       v.FQN,
-			Extends:   extends,
-			StartLine: v.StartLine,
-			EndLine:   v.EndLine,
-			StartByte: v.StartByte,
+			Name:       v.Name,
+			Namespace:  v.Namespace,
+			FQN:        v.FQN,
+			Extends:    extends,
+			Implements: cloneStrings(v.Implements),
+			Traits:     cloneStrings(v.Traits),
+			Trait:      v.Trait,
+			Interface:  v.Interface,
+			StartLine:  v.StartLine,
+			EndLine:    v.EndLine,
+			StartByte:  v.StartByte,
+			traitLine:  v.traitLine,
 		}
 	}
 	return out
@@ -1,6 +1,7 @@
 package php
 
 import (
+	"slices"
 	"strings"
 
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
@@ -18,7 +19,7 @@ func (ctx *analysisContext) collectClassInfo() map[uint32]ClassInfo {
 		node := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 
-		if node.Type() == "class_declaration" {
+		if isClassLikeDeclaration(node) {
 			if info, ok := ctx.classInfoFromNode(node); ok {
 				result[info.StartByte] = info
 			}
@@ -33,8 +34,17 @@ func (ctx *analysisContext) collectClassInfo() map[uint32]ClassInfo {
 	return result
 }
 
+// Reports whether node declares a class, a trait or an interface
+func isClassLikeDeclaration(node sitter.Node) bool {
+	switch node.Type() {
+	case "class_declaration", "trait_declaration", "interface_declaration":
+		return true
+	}
+	return false
+}
+
 func (ctx *analysisContext) classInfoFromNode(node sitter.Node) (ClassInfo, bool) {
-	if node.IsNull() || node.Type() != "class_declaration" {
+	if node.IsNull() || !isClassLikeDeclaration(node) {
 		return ClassInfo{}, false
 	}
 
@@ -54,19 +64,32 @@ func (ctx *analysisContext) classInfoFromNode(node sitter.Node) (ClassInfo, bool
 	endLine := int(node.EndPoint().Row) + 1
 	startByte := uint32(node.StartByte())
 	extends := ctx.classExtendsFromNode(node, namespace)
+	traits, traitLine := ctx.classTraitsFromNode(node, namespace)
 
 	return ClassInfo{
 		Name:      name,
 		Namespace: namespace,
 		FQN:       fqn,
 		Extends:   extends,
-		StartLine: startLine,
-		EndLine:   endLine,
-		StartByte: startByte,
+		// Interfaces extend others in their base_clause
+		Implements: ctx.classClauseNames(node, namespace, "class_interface_clause"),
+		Traits:     traits,
+		Trait:      node.Type() == "trait_declaration",
+		Interface:  node.Type() == "interface_declaration",
+		StartLine:  startLine,
+		EndLine:    endLine,
+		StartByte:  startByte,
+		traitLine:  traitLine,
 	}, true
 }
 
 func (ctx *analysisContext) classExtendsFromNode(node sitter.Node, namespace string) []string {
+	return ctx.classClauseNames(node, namespace, "base_clause")
+}
+
+// Returns the classes named by the clauses of type clause of a class
+// declaration, its base_clause or class_interface_clause
+func (ctx *analysisContext) classClauseNames(node sitter.Node, namespace, clause string) []string {
 	content := ctx.bytes()
 	uses := ctx.uses
 	seen := make(map[string]struct{})
@@ -74,7 +97,7 @@ func (ctx *analysisContext) classExtendsFromNode(node sitter.Node, namespace str
 
 	for i := uint32(0); i < node.NamedChildCount(); i++ {
 		child := node.NamedChild(i)
-		if child.Type() != "base_clause" {
+		if child.Type() != clause {
 			continue
 		}
 		for j := uint32(0); j < child.NamedChildCount(); j++ {
@@ -99,6 +122,39 @@ func (ctx *analysisContext) classExtendsFromNode(node sitter.Node, namespace str
 	return result
 }
 
+// Returns the traits of the `use` declarations in the body of a class, and
+// the line of the first
+func (ctx *analysisContext) classTraitsFromNode(node sitter.Node, namespace string) ([]string, int) {
+	body := node.ChildByFieldName("body")
+	if body.IsNull() {
+		return nil, 0
+	}
+	content := ctx.bytes()
+	var result []string
+	line := 0
+	for i := uint32(0); i < body.NamedChildCount(); i++ {
+		child := body.NamedChild(i)
+		if child.Type() != "use_declaration" {
+			continue
+		}
+		if line == 0 {
+			line = int(child.StartPoint().Row) + 1
+		}
+		// A declaration may use several traits
+		for j := uint32(0); j < child.NamedChildCount(); j++ {
+			nameNode := child.NamedChild(j)
+			if nameNode.Type() != "name" && nameNode.Type() != "qualified_name" {
+				continue
+			}
+			resolved := ctx.qualifyClassName(strings.TrimSpace(nameNode.Content(content)), namespace, ctx.uses)
+			if resolved != "" && !slices.Contains(result, resolved) {
+				result = append(result, resolved)
+			}
+		}
+	}
+	return result, line
+}
+
 func (ctx *analysisContext) refreshClassDeclaration(node sitter.Node, classes map[uint32]ClassInfo) {
 	if info, ok := ctx.classInfoFromNode(node); ok {
 		classes[info.StartByte] = info
@@ -164,12 +220,23 @@ func (ctx *analysisContext) expandClassExtends(classes map[uint32]ClassInfo) {
 		return
 	}
 	direct := make(map[string][]string, len(classes))
+	implements := make(map[string][]string, len(classes))
 	for _, info := range classes {
 		if info.FQN == "" {
 			continue
 		}
 		direct[strings.ToLower(info.FQN)] = cloneStrings(info.Extends)
+		implements[strings.ToLower(info.FQN)] = cloneStrings(info.Implements)
+	}
+	var external []string
+	for _, info := range classes {
+		for _, parent := range slices.Concat(info.Extends, info.Implements) {
+			if _, ok := direct[strings.ToLower(normalizeFQN(parent))]; !ok {
+				external = append(external, parent)
+			}
+		}
 	}
+	ctx.prefetchExternalClasses(external)
 	for key, info := range classes {
 		info.Extends = ctx.collectAllAncestors(info.Extends, direct)
 		if info.FQN != "" {
@@ -177,6 +244,22 @@ func (ctx *analysisContext) expandClassExtends(classes map[uint32]ClassInfo) {
 		}
 		classes[key] = info
 	}
+	// The interfaces of the ancestors and the ones they extend count too
+	for key, info := range classes {
+		if info.Interface {
+			continue
+		}
+		interfaces := cloneStrings(info.Implements)
+		for _, ancestor := range info.Extends {
+			if own, ok := implements[strings.ToLower(normalizeFQN(ancestor))]; ok {
+				interfaces = append(interfaces, own...)
+			} else {
+				interfaces = append(interfaces, ctx.externalImplementsFor(ancestor)...)
+			}
+		}
+		info.Implements = ctx.collectAllAncestors(interfaces, direct)
+		classes[key] = info
+	}
 }
 
 func (ctx *analysisContext) collectAllAncestors(initial []string, direct map[string][]string) []string {
@@ -206,6 +289,7 @@ func (ctx *analysisContext) collectAllAncestors(initial []string, direct map[str
 			continue
 		}
 		if next := ctx.externalExtendsFor(cur); len(next) > 0 {
+			ctx.prefetchExternalClasses(next)
 			queue = append(queue, next...)
 		}
 	}
@@ -0,0 +1,88 @@
+package php
+
+import (
+	"sort"
+	"strings"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+)
+
+// Collects the constants and enum cases the declarations of the file hold, in
+// the order of the file
+func (ctx *analysisContext) collectConstants() []ConstantInfo {
+	root := ctx.rootNode()
+	if root.IsNull() {
+		return nil
+	}
+
+	var result []ConstantInfo
+	stack := []sitter.Node{root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch node.Type() {
+		case "class_declaration", "interface_declaration", "trait_declaration", "enum_declaration":
+			result = append(result, ctx.constantsFromDeclaration(node)...)
+			continue
+		}
+		for i := uint32(0); i < node.NamedChildCount(); i++ {
+			stack = append(stack, node.NamedChild(i))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Range.StartLine != result[j].Range.StartLine {
+			return result[i].Range.StartLine < result[j].Range.StartLine
+		}
+		return result[i].Range.StartColumn < result[j].Range.StartColumn
+	})
+	return result
+}
+
+// Returns the constants and enum cases in the body of a class-like declaration
+func (ctx *analysisContext) constantsFromDeclaration(node sitter.Node) []ConstantInfo {
+	content := ctx.bytes()
+	nameNode := node.ChildByFieldName("name")
+	body := node.ChildByFieldName("body")
+	if nameNode.IsNull() || body.IsNull() {
+		return nil
+	}
+	class := strings.TrimSpace(nameNode.Content(content))
+	if namespace := ctx.namespaceForNode(node); namespace != "" {
+		class = namespace + "\\" + class
+	}
+
+	var result []ConstantInfo
+	add := func(nameNode, valueNode sitter.Node, enumCase bool) {
+		if nameNode.IsNull() {
+			return
+		}
+		value := ""
+		if !valueNode.IsNull() && !valueNode.Equal(nameNode) {
+			value = valueNode.Content(content)
+		}
+		result = append(result, ConstantInfo{
+			Class:    class,
+			Name:     nameNode.Content(content),
+			Value:    value,
+			EnumCase: enumCase,
+			Range:    rangeFromNode(nameNode),
+		})
+	}
+	for i := uint32(0); i < body.NamedChildCount(); i++ {
+		member := body.NamedChild(i)
+		switch member.Type() {
+		case "const_declaration":
+			for j := uint32(0); j < member.NamedChildCount(); j++ {
+				element := member.NamedChild(j)
+				if element.Type() == "const_element" && element.NamedChildCount() > 0 {
+					add(element.NamedChild(0), element.NamedChild(element.NamedChildCount()-1), false)
+				}
+			}
+		case "enum_case":
+			add(member.ChildByFieldName("name"), member.ChildByFieldName("value"), true)
+		}
+	}
+	return result
+}
@@ -17,6 +17,10 @@ type analysisContext struct {
 	root     string
 	loaded   map[string]externalClassData
 	store    *DocumentStore
+	// The return types of the methods and functions of the file, and the
+	// parents of its classes by lowercase FQN, read on demand
+	returns map[string][]string
+	classes map[string][]string
 }
 
 func newAnalysisContext(content *[]byte, tree *sitter.Tree, uri string, autoload config.AutoloadMap, workspaceRoot string, store *DocumentStore) *analysisContext {
@@ -75,9 +79,10 @@ func (ctx *analysisContext) updateIndex(props map[string][]TypeOccurrence, vars
 		node := root.NamedDescendantForByteRange(uint32(start), uint32(end))
 		if ctx.refreshForNode(node, visited, props, vars, classes) {
 			// Fallback to full rebuild when incremental update is insufficient.
+			freshClasses := ctx.collectClassInfo()
 			freshProps := ctx.collectPropertyTypes()
+			ctx.mergeTraitProperties(freshProps, freshClasses)
 			freshVars := ctx.collectFunctionVariableTypes(freshProps)
-			freshClasses := ctx.collectClassInfo()
 			return IndexedTree{
 				Properties: freshProps,
 				Variables:  freshVars,
@@ -102,7 +107,7 @@ func (ctx *analysisContext) refreshForNode(node sitter.Node, visited map[string]
 		switch typeName {
 		case "program":
 			return false
-		case "namespace_use_declaration", "namespace_use_clause", "namespace_use_group":
+		case "namespace_use_declaration", "namespace_use_clause", "namespace_use_group", "use_declaration":
 			return true
 		}
 
@@ -119,7 +124,7 @@ func (ctx *analysisContext) refreshForNode(node sitter.Node, visited map[string]
 			return true
 		case "method_declaration", "function_definition", "function_declaration":
 			ctx.refreshFunctionScope(cur, props, vars)
-		case "class_declaration":
+		case "class_declaration", "trait_declaration":
 			ctx.refreshClassDeclaration(cur, classes)
 		}
 	}
@@ -237,7 +242,7 @@ func (ctx *analysisContext) buildMethodMetadata(classes map[uint32]ClassInfo) (m
 		node := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 
-		if node.Type() == "class_declaration" {
+		if isClassLikeDeclaration(node) {
 			info, ok := startToClass[uint32(node.StartByte())]
 			if ok && info.Name != "" {
 				methods := classMethods[info.Name]
@@ -256,6 +261,7 @@ func (ctx *analysisContext) buildMethodMetadata(classes map[uint32]ClassInfo) (m
 						if !okMethod {
 							continue
 						}
+						fn.ReturnTypes = ctx.functionReturnTypes(child, info.FQN)
 						switch visibility {
 						case "private":
 							methods.private = append(methods.private, fn)
@@ -274,6 +280,7 @@ func (ctx *analysisContext) buildMethodMetadata(classes map[uint32]ClassInfo) (m
 		}
 	}
 
+	ctx.mergeTraitMethods(classMethods, classes)
 	return classMethods, extendsMap, fullNames
 }
 
@@ -9,22 +9,26 @@ import (
 	phpforest "github.com/alexaandru/go-sitter-forest/php"
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/utils"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 // Document maintains a parsed PHP syntax tree together with its static analysis index.
 // It owns the tree-sitter parser and decides when static analysis should be re-run.
 type Document struct {
-	parser          *sitter.Parser
-	mu              sync.RWMutex
-	tree            *sitter.Tree
-	content         []byte
-	docURI          string
-	workspaceRoot   string
-	autoload        config.AutoloadMap
-	analyzer        *StaticAnalyzer
-	index           IndexedTree
+	parser        *sitter.Parser
+	mu            sync.RWMutex
+	tree          *sitter.Tree
+	content       []byte
+	docURI        string
+	workspaceRoot string
+	autoload      config.AutoloadMap
+	analyzer      *StaticAnalyzer
+	index         IndexedTree
+	// dirtyRanges are the bytes edited since the last analysis, which
+	// analysisTimer runs once typing pauses
 	dirtyRanges     []ByteRange
+	store           *DocumentStore
 	analysisTimer   *time.Timer
 	analysisVersion int64
 	lastAnalyzed    int64
@@ -75,7 +79,12 @@ func (d *Document) setContext(uri, root string, autoload config.AutoloadMap) {
 	}
 }
 
-// Update refreshes the document's content and AST.
+// How long typing must pause before the edits are analyzed
+const analysisDelay = 150 * time.Millisecond
+
+// Update refreshes the document's content and AST. A full parse is analyzed
+// right away; an edit is parsed incrementally right away but analyzed once
+// typing pauses, or when the index is read before.
 func (d *Document) Update(content []byte, change *sitter.InputEdit, store *DocumentStore) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -94,7 +103,10 @@ func (d *Document) Update(content []byte, change *sitter.InputEdit, store *Docum
 		}
 		d.tree = tree
 		d.content = content
-		d.index = d.analyzer.Update(&d.content, d.tree, nil, store)
+		d.store = store
+		d.recordDirtyRangeLocked(nil)
+		d.analysisVersion++
+		d.analyzeLocked()
 		return nil
 	}
 
@@ -110,12 +122,67 @@ func (d *Document) Update(content []byte, change *sitter.InputEdit, store *Docum
 	d.tree.Close()
 	d.tree = newTree
 	d.content = content
-
-	dirty := []ByteRange{{Start: uint32(change.StartIndex), End: uint32(change.NewEndIndex)}}
-	d.index = d.analyzer.Update(&d.content, d.tree, dirty, store)
+	d.store = store
+	d.recordDirtyRangeLocked(change)
+	d.analysisVersion++
+	d.scheduleAnalysisLocked()
 	return nil
 }
 
+// Analyzes the edits after analysisDelay, unless another one comes first
+func (d *Document) scheduleAnalysisLocked() {
+	if d.analysisTimer != nil {
+		d.analysisTimer.Stop()
+	}
+	version := d.analysisVersion
+	d.analysisTimer = time.AfterFunc(analysisDelay, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.analysisVersion == version {
+			d.analyzeLocked()
+		}
+	})
+}
+
+// Rebuilds the index from the edits since the last analysis, all of it when
+// the document was parsed again in full
+func (d *Document) analyzeLocked() {
+	if d.analysisTimer != nil {
+		d.analysisTimer.Stop()
+		d.analysisTimer = nil
+	}
+	if d.lastAnalyzed == d.analysisVersion || d.tree == nil {
+		return
+	}
+	d.index = d.analyzer.Update(&d.content, d.tree, d.dirtyRanges, d.store)
+	d.dirtyRanges = nil
+	d.lastAnalyzed = d.analysisVersion
+}
+
+// Analyzes the edits waiting for typing to pause, for a reader of the index
+func (d *Document) flushAnalysis() {
+	d.mu.RLock()
+	pending := d.lastAnalyzed != d.analysisVersion
+	d.mu.RUnlock()
+	if pending {
+		d.mu.Lock()
+		d.analyzeLocked()
+		d.mu.Unlock()
+	}
+}
+
+// The bytes a syntax tree retains per byte of source, about one node of a few
+// dozen bytes every few bytes
+const treeBytesPerSourceByte = 8
+
+// RetainedBytes approximates the memory the document retains: its content
+// and its syntax tree.
+func (d *Document) RetainedBytes() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return int64(len(d.content)) * (1 + treeBytesPerSourceByte)
+}
+
 // Close releases resources owned by the document.
 func (d *Document) Close() {
 	d.mu.Lock()
@@ -134,6 +201,7 @@ func (d *Document) Close() {
 // Read executes the provided function while holding a read lock on the document.
 // The callback must not store the tree, content, or index beyond its scope.
 func (d *Document) Read(fn func(tree *sitter.Tree, content []byte, index IndexedTree)) {
+	d.flushAnalysis()
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 	fn(d.tree, d.content, d.index)
@@ -141,15 +209,34 @@ func (d *Document) Read(fn func(tree *sitter.Tree, content []byte, index Indexed
 
 // Index returns the most recently computed static analysis index.
 func (d *Document) Index() IndexedTree {
+	d.flushAnalysis()
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 	return d.index
 }
 
+// ExpressionTypeNames returns the types the expression node of the document
+// evaluates to, following the return types of the methods it calls into the
+// files of their classes. funcName is the function around node, as the index
+// names it.
+func (d *Document) ExpressionTypeNames(node sitter.Node, funcName string) []string {
+	d.flushAnalysis()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	ctx := newAnalysisContext(&d.content, d.tree, d.docURI, d.autoload, d.workspaceRoot, d.store)
+	if ctx == nil || node.IsNull() {
+		return nil
+	}
+	line := int(node.StartPoint().Row) + 1
+	return ctx.inferExpressionTypeNames(node, d.index.Variables[funcName].Variables, d.index.Properties, line)
+}
+
 // GetNodeAt returns the syntax node that spans the provided LSP position together with
 // the current file content and static analysis index. The returned content is a copy,
 // ensuring callers cannot mutate the underlying buffer.
 func (d *Document) GetNodeAt(pos protocol.Position) (sitter.Node, []byte, IndexedTree, bool) {
+	d.flushAnalysis()
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
@@ -181,6 +268,19 @@ func (d *Document) recordDirtyRangeLocked(edit *sitter.InputEdit) {
 		d.dirtyRanges = nil
 		return
 	}
+	// The ranges of the edits before are in the content before this one
+	shift := func(offset uint32) uint32 {
+		switch {
+		case offset >= uint32(edit.OldEndIndex):
+			return uint32(int64(offset) + int64(edit.NewEndIndex) - int64(edit.OldEndIndex))
+		case offset > uint32(edit.NewEndIndex):
+			return uint32(edit.NewEndIndex)
+		}
+		return offset
+	}
+	for i, r := range d.dirtyRanges {
+		d.dirtyRanges[i] = ByteRange{Start: shift(r.Start), End: shift(r.End)}
+	}
 	rangeStart := uint32(edit.StartIndex)
 	rangeEnd := uint32(edit.NewEndIndex)
 	if edit.OldEndIndex > edit.NewEndIndex {
@@ -224,34 +324,13 @@ func appendByteRange(ranges []ByteRange, rng ByteRange) []ByteRange {
 }
 
 func positionToPoint(pos protocol.Position, content []byte) (sitter.Point, bool) {
-	line := int(pos.Line)
-	column := int(pos.Character)
-	if line < 0 || column < 0 {
-		return sitter.Point{}, false
-	}
-
-	currentLine := 0
-	offset := 0
-	for offset < len(content) && currentLine < line {
-		if content[offset] == '\n' {
-			currentLine++
-		}
-		offset++
-	}
-
-	if currentLine != line {
+	line, _, ok := utils.Line(content, pos.Line)
+	if !ok {
 		return sitter.Point{}, false
 	}
-
-	byteColumn := 0
-	for offset < len(content) && content[offset] != '\n' && byteColumn < column {
-		offset++
-		byteColumn++
-	}
-
-	if byteColumn < column {
+	column, ok := utils.ByteColumn(line, pos.Character)
+	if !ok {
 		return sitter.Point{}, false
 	}
-
-	return sitter.Point{Row: uint(line), Column: uint(column)}, true
+	return sitter.Point{Row: uint(pos.Line), Column: uint(column)}, true
 }
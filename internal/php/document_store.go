@@ -1,9 +1,11 @@
 package php
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sync"
 
 	"github.com/shinyvision/vimfony/internal/config"
@@ -14,16 +16,27 @@ type storedDocument struct {
 	path   string
 	doc    *Document
 	isOpen bool
+	// size is the memory the document retained when it was stored
+	size int64
 }
 
-// DocumentStore maintains a bounded set of parsed PHP documents.
+// DefaultMemoryBudget is the memory the documents of a store retain before
+// the least recently used are evicted, unless it is given another
+const DefaultMemoryBudget = 256 << 20
+
+// DocumentStore maintains a set of parsed PHP documents retaining about as
+// much memory as its budget.
 type DocumentStore struct {
-	mu       sync.Mutex
-	max      int
+	mu sync.Mutex
+	// budget is the bytes the documents may retain, size the bytes they do
+	budget   int64
+	size     int64
 	entries  []*storedDocument
 	index    map[string]*storedDocument
 	autoload config.AutoloadMap
 	root     string
+	// workers holds a slot per parse running in the background
+	workers chan struct{}
 }
 
 func (s *DocumentStore) Config() (config.AutoloadMap, string) {
@@ -38,18 +51,31 @@ func (s *DocumentStore) contextSnapshot() (config.AutoloadMap, string) {
 	return s.autoload, s.root
 }
 
-// NewDocumentStore constructs a store with the provided maximum size.
-func NewDocumentStore(max int) *DocumentStore {
-	if max <= 0 {
-		max = 1000
+// NewDocumentStore constructs a store evicting documents past budget bytes,
+// DefaultMemoryBudget when it is not positive.
+func NewDocumentStore(budget int64) *DocumentStore {
+	if budget <= 0 {
+		budget = DefaultMemoryBudget
 	}
 	return &DocumentStore{
-		max:     max,
-		entries: make([]*storedDocument, 0, max),
+		budget:  budget,
 		index:   make(map[string]*storedDocument),
+		workers: make(chan struct{}, runtime.GOMAXPROCS(0)),
 	}
 }
 
+// SetMemoryBudget changes the bytes the documents may retain, evicting the
+// least recently used past it. A budget that is not positive is the default.
+func (s *DocumentStore) SetMemoryBudget(budget int64) {
+	if budget <= 0 {
+		budget = DefaultMemoryBudget
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budget = budget
+	s.ensureCapacityLocked()
+}
+
 // Configure updates the shared context injected into any stored document.
 func (s *DocumentStore) Configure(autoload config.AutoloadMap, workspaceRoot string) {
 	s.mu.Lock()
@@ -78,6 +104,7 @@ func (s *DocumentStore) RegisterOpen(path string, doc *Document) {
 
 	autoload, root := s.contextSnapshot()
 	configureDocumentContext(doc, path, autoload, root)
+	size := doc.RetainedBytes()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -85,7 +112,9 @@ func (s *DocumentStore) RegisterOpen(path string, doc *Document) {
 	if entry, ok := s.index[path]; ok {
 		entry.doc = doc
 		entry.isOpen = true
+		s.resizeLocked(entry, size)
 		s.moveToEndLocked(entry)
+		s.ensureCapacityLocked()
 		return
 	}
 
@@ -94,9 +123,7 @@ func (s *DocumentStore) RegisterOpen(path string, doc *Document) {
 		doc:    doc,
 		isOpen: true,
 	}
-	s.entries = append(s.entries, entry)
-	s.index[path] = entry
-	s.ensureCapacityLocked()
+	s.addLocked(entry, size)
 }
 
 // Close marks a document as no longer open. It becomes eligible for eviction.
@@ -106,13 +133,34 @@ func (s *DocumentStore) Close(path string) {
 		return
 	}
 
+	s.mu.Lock()
+	entry, ok := s.index[path]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	entry.isOpen = false
+	doc := entry.doc
+	s.mu.Unlock()
+
+	// The document changed while it was open
+	size := doc.RetainedBytes()
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if entry, ok := s.index[path]; ok {
-		entry.isOpen = false
+	if s.index[path] == entry && entry.doc == doc {
+		s.resizeLocked(entry, size)
+		s.ensureCapacityLocked()
 	}
 }
 
+// Contains reports whether the document at path is parsed and cached.
+func (s *DocumentStore) Contains(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.index[normalizePath(path)]
+	return ok && entry.doc != nil
+}
+
 // Retrieves or loads (and caches) a document for the given path.
 func (s *DocumentStore) Get(path string) (*Document, error) {
 	path = normalizePath(path)
@@ -142,6 +190,7 @@ func (s *DocumentStore) Get(path string) (*Document, error) {
 	if err := doc.Update(data, nil, s); err != nil {
 		return nil, err
 	}
+	size := doc.RetainedBytes()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -154,16 +203,46 @@ func (s *DocumentStore) Get(path string) (*Document, error) {
 		return entry.doc, nil
 	}
 
-	entry := &storedDocument{
-		path: path,
-		doc:  doc,
-	}
-	s.entries = append(s.entries, entry)
-	s.index[path] = entry
-	s.ensureCapacityLocked()
+	s.addLocked(&storedDocument{path: path, doc: doc}, size)
 	return doc, nil
 }
 
+// Prefetch parses and caches the documents at paths, as many at once as the
+// store has workers plus the calling goroutine, which parses the ones no
+// worker is free for. It calls parsed, one call at a time, as each is done,
+// and returns once all are, or once the started ones are when ctx is
+// cancelled. Parses never wait for a worker, so a document whose analysis
+// prefetches the classes it extends cannot block the pool.
+func (s *DocumentStore) Prefetch(ctx context.Context, paths []string, parsed func(path string, err error)) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	parse := func(path string) {
+		_, err := s.Get(path)
+		if parsed != nil {
+			mu.Lock()
+			parsed(path, err)
+			mu.Unlock()
+		}
+	}
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case s.workers <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-s.workers }()
+				parse(path)
+			}()
+		default:
+			parse(path)
+		}
+	}
+	wg.Wait()
+}
+
 func (s *DocumentStore) moveToEndLocked(entry *storedDocument) {
 	if len(s.entries) == 0 {
 		return
@@ -182,23 +261,33 @@ func (s *DocumentStore) moveToEndLocked(entry *storedDocument) {
 	s.entries = append(s.entries, entry)
 }
 
+func (s *DocumentStore) addLocked(entry *storedDocument, size int64) {
+	s.entries = append(s.entries, entry)
+	s.index[entry.path] = entry
+	s.resizeLocked(entry, size)
+	s.ensureCapacityLocked()
+}
+
+func (s *DocumentStore) resizeLocked(entry *storedDocument, size int64) {
+	s.size += size - entry.size
+	entry.size = size
+}
+
+// Evicts the least recently used documents that are not open until the others
+// fit in the budget. The most recent one stays, however big, as its caller is
+// about to use it.
 func (s *DocumentStore) ensureCapacityLocked() {
-	for len(s.entries) > s.max {
-		evicted := false
-		for i, entry := range s.entries {
-			if entry.isOpen {
-				continue
-			}
-			s.entries = append(s.entries[:i], s.entries[i+1:]...)
-			delete(s.index, entry.path)
-			if entry.doc != nil {
-				entry.doc.Close()
-			}
-			evicted = true
-			break
+	for i := 0; s.size > s.budget && i < len(s.entries)-1; {
+		entry := s.entries[i]
+		if entry.isOpen {
+			i++
+			continue
 		}
-		if !evicted {
-			break
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		delete(s.index, entry.path)
+		s.size -= entry.size
+		if entry.doc != nil {
+			entry.doc.Close()
 		}
 	}
 }
@@ -0,0 +1,96 @@
+package php
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchParsesEveryPath(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"A.php", "B.php", "C.php", "D.php", "E.php", "F.php"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("<?php\nclass "+name[:1]+" {}\n"), 0o644))
+		paths = append(paths, path)
+	}
+	missing := filepath.Join(dir, "Missing.php")
+
+	store := NewDocumentStore(0)
+	parsed := make(map[string]error)
+	store.Prefetch(context.Background(), append(paths, missing), func(path string, err error) {
+		parsed[path] = err
+	})
+
+	require.Len(t, parsed, len(paths)+1)
+	for _, path := range paths {
+		assert.NoError(t, parsed[path])
+		assert.True(t, store.Contains(path), path)
+	}
+	assert.Error(t, parsed[missing])
+	assert.False(t, store.Contains(missing))
+}
+
+func TestPrefetchStopsWhenCancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "A.php")
+	require.NoError(t, os.WriteFile(path, []byte("<?php\nclass A {}\n"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := NewDocumentStore(0)
+	store.Prefetch(ctx, []string{path}, func(string, error) { t.Fatal("nothing is parsed once cancelled") })
+	assert.False(t, store.Contains(path))
+}
+
+func TestStoreEvictsTheLeastRecentlyUsedPastItsMemoryBudget(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		content := "<?php\n" + strings.Repeat("/", size-6)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	small, big, other := write("Small.php", 100), write("Big.php", 1000), write("Other.php", 100)
+	docSize := func(size int64) int64 { return size * (1 + treeBytesPerSourceByte) }
+
+	store := NewDocumentStore(docSize(1150))
+	_, err := store.Get(small)
+	require.NoError(t, err)
+	_, err = store.Get(big)
+	require.NoError(t, err)
+	assert.True(t, store.Contains(small))
+
+	// The small one was used last, the big one goes
+	_, err = store.Get(small)
+	require.NoError(t, err)
+	_, err = store.Get(other)
+	require.NoError(t, err)
+	assert.False(t, store.Contains(big))
+	assert.True(t, store.Contains(small))
+	assert.True(t, store.Contains(other))
+
+	// Open documents stay, the document just loaded too however big
+	doc, err := store.Get(small)
+	require.NoError(t, err)
+	store.RegisterOpen(small, doc)
+	store.SetMemoryBudget(docSize(10))
+	assert.True(t, store.Contains(small))
+	assert.False(t, store.Contains(other))
+	_, err = store.Get(big)
+	require.NoError(t, err)
+	assert.True(t, store.Contains(small))
+	assert.True(t, store.Contains(big))
+
+	// Closed, it goes first
+	store.Close(small)
+	assert.False(t, store.Contains(small))
+	_, err = store.Get(other)
+	require.NoError(t, err)
+	assert.False(t, store.Contains(big))
+	assert.True(t, store.Contains(other))
+}
@@ -0,0 +1,66 @@
+package php
+
+import (
+	"testing"
+	"time"
+
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (d *Document) analysisPending() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.lastAnalyzed != d.analysisVersion
+}
+
+// Returns the edit inserting text at offset of a single line
+func insertEdit(offset int, text string) *sitter.InputEdit {
+	return &sitter.InputEdit{
+		StartIndex:  uint(offset),
+		OldEndIndex: uint(offset),
+		NewEndIndex: uint(offset + len(text)),
+		StartPoint:  sitter.Point{Column: uint(offset)},
+		OldEndPoint: sitter.Point{Column: uint(offset)},
+		NewEndPoint: sitter.Point{Column: uint(offset + len(text))},
+	}
+}
+
+func TestEditsAreAnalyzedOnceTypingPauses(t *testing.T) {
+	doc := NewDocument()
+	defer doc.Close()
+	content := "<?php $a = 1;"
+	require.NoError(t, doc.Update([]byte(content), nil, nil))
+	assert.False(t, doc.analysisPending(), "a full parse is analyzed right away")
+
+	for i, text := range []string{"$", "b", " = 2;"} {
+		offset := len(content)
+		content += text
+		require.NoError(t, doc.Update([]byte(content), insertEdit(offset, text), nil))
+		assert.True(t, doc.analysisPending(), "edit %d", i)
+	}
+	assert.Equal(t, []ByteRange{{Start: 13, End: 19}}, doc.dirtyRanges)
+
+	assert.Eventually(t, func() bool { return !doc.analysisPending() }, time.Second, 10*time.Millisecond)
+	assert.Nil(t, doc.dirtyRanges)
+}
+
+func TestReadingTheIndexAnalyzesTheEditsFirst(t *testing.T) {
+	doc := NewDocument()
+	defer doc.Close()
+	require.NoError(t, doc.Update([]byte("<?php "), nil, nil))
+	require.NoError(t, doc.Update([]byte("<?php $a;"), insertEdit(6, "$a;"), nil))
+	require.True(t, doc.analysisPending())
+
+	doc.Index()
+	assert.False(t, doc.analysisPending())
+}
+
+func TestDirtyRangesFollowTheEditsAfterThem(t *testing.T) {
+	doc := NewDocument()
+	doc.recordDirtyRangeLocked(&sitter.InputEdit{StartIndex: 30, OldEndIndex: 30, NewEndIndex: 35})
+	// Deleting 10 bytes before it moves it back
+	doc.recordDirtyRangeLocked(&sitter.InputEdit{StartIndex: 0, OldEndIndex: 10, NewEndIndex: 0})
+	assert.Equal(t, []ByteRange{{Start: 0, End: 10}, {Start: 20, End: 25}}, doc.dirtyRanges)
+}
@@ -1,6 +1,7 @@
 package php
 
 import (
+	"context"
 	"strings"
 
 	"github.com/shinyvision/vimfony/internal/config"
@@ -24,6 +25,11 @@ func (ctx *analysisContext) externalExtendsFor(fqcn string) []string {
 	return cloneStrings(data.extends)
 }
 
+func (ctx *analysisContext) externalImplementsFor(fqcn string) []string {
+	data := ctx.ensureExternalClassLoaded(fqcn)
+	return cloneStrings(data.implements)
+}
+
 func (ctx *analysisContext) ensureExternalClassLoaded(fqcn string) externalClassData {
 	fqcn = normalizeFQN(fqcn)
 	if fqcn == "" || ctx.autoload.IsEmpty() {
@@ -75,8 +81,12 @@ func (ctx *analysisContext) ensureExternalClassLoaded(fqcn string) externalClass
 			continue
 		}
 		entry := externalClassData{
-			methods: extMethods[info.Name],
-			extends: cloneStrings(info.Extends),
+			methods:    extMethods[info.Name],
+			extends:    cloneStrings(info.Extends),
+			implements: cloneStrings(info.Implements),
+		}
+		if info.Trait {
+			entry.properties = propertiesInLines(index.Properties, info.StartLine, info.EndLine)
 		}
 		ctx.loaded[full] = entry
 	}
@@ -88,6 +98,28 @@ func (ctx *analysisContext) ensureExternalClassLoaded(fqcn string) externalClass
 	return ctx.loaded[fqcn]
 }
 
+// Parses the files of the external classes among fqcns in parallel, ahead of
+// loading them one by one
+func (ctx *analysisContext) prefetchExternalClasses(fqcns []string) {
+	if ctx.store == nil || ctx.autoload.IsEmpty() {
+		return
+	}
+	var paths []string
+	for _, fqcn := range fqcns {
+		fqcn = normalizeFQN(fqcn)
+		if _, ok := ctx.loaded[fqcn]; ok || fqcn == "" {
+			continue
+		}
+		if path, ok := config.AutoloadResolve(fqcn, ctx.autoload, ctx.root); ok {
+			paths = append(paths, path)
+		}
+	}
+	// A single class is loaded right after anyway
+	if len(paths) > 1 {
+		ctx.store.Prefetch(context.Background(), paths, nil)
+	}
+}
+
 func addClassMethod(methods map[string]*methodSet, fn FunctionInfo, visibility string) {
 	// Name is "ClassName::MethodName"
 	parts := strings.SplitN(fn.Name, "::", 2)
@@ -5,6 +5,7 @@ import (
 
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/utils"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
@@ -26,7 +27,9 @@ func PathAt(store *DocumentStore, path string, pos protocol.Position) (string, b
 		if root.IsNull() {
 			return
 		}
-		point := sitter.Point{Row: uint(pos.Line), Column: uint(pos.Character)}
+		line, _, _ := utils.Line(content, pos.Line)
+		column, _ := utils.ByteColumn(line, pos.Character)
+		point := sitter.Point{Row: uint(pos.Line), Column: uint(column)}
 		node := root.NamedDescendantForPointRange(point, point)
 
 		var candidate sitter.Node
@@ -96,11 +99,7 @@ func Resolve(store *DocumentStore, className string) (string, protocol.Range, bo
 		if !foundNode.IsNull() {
 			nameNode := foundNode.ChildByFieldName("name")
 			if !nameNode.IsNull() {
-				r := rangeFromNode(nameNode)
-				rng = protocol.Range{
-					Start: protocol.Position{Line: uint32(r.StartLine - 1), Character: uint32(r.StartColumn)},
-					End:   protocol.Position{Line: uint32(r.EndLine - 1), Character: uint32(r.EndColumn)},
-				}
+				rng = protocolRange(rangeFromNode(nameNode), content)
 				found = true
 			}
 		}
@@ -145,14 +144,58 @@ func FindMethodRange(store *DocumentStore, path, methodName string) (protocol.Ra
 		findMethod(root)
 
 		if !foundNode.IsNull() {
-			r := rangeFromNode(foundNode)
-			rng = protocol.Range{
-				Start: protocol.Position{Line: uint32(r.StartLine - 1), Character: uint32(r.StartColumn)},
-				End:   protocol.Position{Line: uint32(r.EndLine - 1), Character: uint32(r.EndColumn)},
-			}
+			rng = protocolRange(rangeFromNode(foundNode), content)
 			found = true
 		}
 	})
 
 	return rng, found
 }
+
+// ClassConstant is a constant or an enum case declared by a class.
+type ClassConstant struct {
+	Name  string
+	Value string
+	Range protocol.Range
+}
+
+// ClassConstants returns the constants and enum cases declared in the body of the given class.
+func ClassConstants(store *DocumentStore, className string) []ClassConstant {
+	if store == nil {
+		return nil
+	}
+	autoloadMap, workspaceRoot := store.Config()
+	path, ok := config.AutoloadResolve(className, autoloadMap, workspaceRoot)
+	if !ok {
+		return nil
+	}
+	doc, err := store.Get(path)
+	if err != nil {
+		return nil
+	}
+
+	var constants []ClassConstant
+	target := normalizeFQN(className)
+	doc.Read(func(_ *sitter.Tree, content []byte, index IndexedTree) {
+		for _, constant := range index.Constants {
+			if !strings.EqualFold(constant.Class, target) {
+				continue
+			}
+			constants = append(constants, ClassConstant{
+				Name:  constant.Name,
+				Value: constant.Value,
+				Range: protocolRange(constant.Range, content),
+			})
+		}
+	})
+	return constants
+}
+
+// protocolRange converts r, indexed from content, to the characters the
+// client counts.
+func protocolRange(r LineColumnRange, content []byte) protocol.Range {
+	return protocol.Range{
+		Start: utils.Position(content, r.StartLine-1, r.StartColumn),
+		End:   utils.Position(content, r.EndLine-1, r.EndColumn),
+	}
+}
@@ -19,7 +19,7 @@ func TestResolve(t *testing.T) {
 	}
 	workspaceRoot := "../../"
 
-	store := NewDocumentStore(10)
+	store := NewDocumentStore(0)
 	store.Configure(autoloadMap, workspaceRoot)
 
 	// Test resolving a class
@@ -41,7 +41,7 @@ func TestFindMethodRange(t *testing.T) {
 	}
 	workspaceRoot := "../../"
 
-	store := NewDocumentStore(10)
+	store := NewDocumentStore(0)
 	store.Configure(autoloadMap, workspaceRoot)
 
 	path, _, ok := Resolve(store, "VendorNamespace\\TestClass")
@@ -73,7 +73,7 @@ class MyClass {
 	}
 }
 `
-	store := NewDocumentStore(10)
+	store := NewDocumentStore(0)
 	dummyPath := "/tmp/dummy.php"
 	doc := NewDocument()
 	doc.Update([]byte(content), nil, store)
@@ -20,7 +20,7 @@ func TestStaticAnalyzerIndexesInheritedMethods(t *testing.T) {
 		},
 	}
 
-	store := NewDocumentStore(10)
+	store := NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 
 	doc := NewDocument()
@@ -82,7 +82,7 @@ class One extends BarClass {}
 			"VendorNamespace\\": {"vendor"},
 		},
 	}
-	store := NewDocumentStore(10)
+	store := NewDocumentStore(0)
 	store.Configure(autoload, mockRoot)
 	doc.SetURI("test.php")
 	doc.SetAutoloadMap(autoload)
@@ -111,3 +111,283 @@ class One extends BarClass {}
 	}
 	require.True(t, found, "expected Derived class metadata to be collected")
 }
+
+func TestStaticAnalyzerMergesTraits(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	traitPath := write("vendor/acme/Timestampable.php", `<?php
+namespace Acme;
+
+use Acme\Clock;
+
+trait Timestampable
+{
+    use Loggable;
+
+    private Clock $clock;
+
+    public function touch(): void {}
+
+    protected function now(): Clock { return $this->clock; }
+}
+`)
+	loggablePath := write("vendor/acme/Loggable.php", `<?php
+namespace Acme;
+
+trait Loggable
+{
+    public function log(): void {}
+}
+`)
+
+	autoload := config.AutoloadMap{PSR4: map[string][]string{"Acme\\": {"vendor/acme"}}}
+	store := NewDocumentStore(0)
+	store.Configure(autoload, root)
+	doc := NewDocument()
+	doc.SetURI("test.php")
+	doc.SetAutoloadMap(autoload)
+	doc.SetWorkspaceRoot(root)
+	require.NoError(t, doc.Update([]byte(`<?php
+namespace App;
+
+use Acme\Timestampable;
+
+trait Named
+{
+    private string $name;
+    public function getName(): string { return $this->name; }
+}
+
+class Post
+{
+    use Timestampable, Named;
+
+    public function getName(): string { return 'post'; }
+}
+`), nil, store))
+	index := doc.Index()
+
+	for _, info := range index.Classes {
+		if info.Name == "Post" {
+			require.Equal(t, []string{"Acme\\Timestampable", "App\\Named"}, info.Traits)
+			require.False(t, info.Trait)
+		}
+		if info.Name == "Named" {
+			require.True(t, info.Trait)
+		}
+	}
+
+	uris := func(fns []FunctionInfo) map[string][]string {
+		result := make(map[string][]string)
+		for _, fn := range fns {
+			result[fn.Name] = append(result[fn.Name], fn.URI)
+		}
+		return result
+	}
+	public := uris(index.PublicFunctions)
+	require.Equal(t, []string{utils.PathToURI(traitPath)}, public["Post::touch"])
+	require.Equal(t, []string{utils.PathToURI(loggablePath)}, public["Post::log"])
+	// The method of the class wins over the one of its trait
+	require.Equal(t, []string{"test.php"}, public["Post::getName"])
+	require.Contains(t, uris(index.ProtectedFunctions), "Post::now")
+
+	// The properties of the traits are declared at their use
+	require.Contains(t, index.Properties["clock"], TypeOccurrence{Type: "Acme\\Clock", Line: 14})
+	require.Contains(t, index.Properties["name"], TypeOccurrence{Type: "string", Line: 14})
+}
+
+func TestClassInfoCollectsInheritedInterfaces(t *testing.T) {
+	code := []byte(`<?php
+namespace Example;
+
+use Symfony\Component\Routing\RouterInterface;
+
+interface Named {}
+interface Titled extends Named {}
+
+class Base implements RouterInterface {}
+class Page extends Base implements Titled {}
+`)
+
+	doc := NewDocument()
+	doc.SetURI("test.php")
+	require.NoError(t, doc.Update(code, nil, NewDocumentStore(0)))
+
+	var found bool
+	for _, info := range doc.Index().Classes {
+		switch info.Name {
+		case "Page":
+			require.ElementsMatch(t, []string{
+				"Example\\Titled",
+				"Example\\Named",
+				"Symfony\\Component\\Routing\\RouterInterface",
+			}, info.Implements)
+			found = true
+		case "Titled":
+			require.True(t, info.Interface)
+			require.Equal(t, []string{"Example\\Named"}, info.Extends)
+		}
+	}
+	require.True(t, found, "expected Page class metadata to be collected")
+}
+
+func TestStaticAnalyzerReadsMethodDocblocks(t *testing.T) {
+	code := []byte(`<?php
+namespace Example;
+
+use Symfony\Component\HttpFoundation\Request;
+use Doctrine\ORM\EntityManagerInterface;
+
+class LegacyController
+{
+    /**
+     * @param Request $request
+     * @param int|null $page
+     */
+    public function listAction($request, $page = null)
+    {
+        $em = $this->getManager();
+        $self = $this->setPage($page);
+        $other = static::create();
+    }
+
+    /**
+     * @return EntityManagerInterface
+     */
+    private function getManager()
+    {
+    }
+
+    public function setPage($page): static
+    {
+    }
+
+    /** @return self */
+    public static function create()
+    {
+    }
+}
+`)
+
+	doc := NewDocument()
+	doc.SetURI("test.php")
+	require.NoError(t, doc.Update(code, nil, NewDocumentStore(0)))
+
+	vars := doc.Index().Variables["listAction"].Variables
+	require.Equal(t, []string{"Symfony\\Component\\HttpFoundation\\Request"}, TypeNamesFromOccurrences(vars["request"]))
+	require.ElementsMatch(t, []string{"int", "null"}, TypeNamesFromOccurrences(vars["page"]))
+	require.Equal(t, []string{"Doctrine\\ORM\\EntityManagerInterface"}, TypeNamesFromOccurrences(vars["em"]))
+	require.Equal(t, []string{"Example\\LegacyController"}, TypeNamesFromOccurrences(vars["self"]))
+	require.Equal(t, []string{"Example\\LegacyController"}, TypeNamesFromOccurrences(vars["other"]))
+}
+
+func TestStaticAnalyzerNarrowsInstanceofChecks(t *testing.T) {
+	code := []byte(`<?php
+namespace Example;
+
+use Symfony\Component\Routing\RouterInterface;
+
+class Links
+{
+    public function home(object $router, $other)
+    {
+        if ($router instanceof RouterInterface && $other instanceof \Countable) {
+            $router->generate('home');
+        }
+        $router->generate('home');
+    }
+}
+`)
+
+	doc := NewDocument()
+	doc.SetURI("test.php")
+	require.NoError(t, doc.Update(code, nil, NewDocumentStore(0)))
+
+	vars := doc.Index().Variables["home"].Variables
+	require.Equal(t, []string{"Symfony\\Component\\Routing\\RouterInterface"}, TypeNamesAtOrBefore(vars["router"], 11))
+	require.Equal(t, []string{"Countable"}, TypeNamesAtOrBefore(vars["other"], 11))
+	require.Equal(t, []string{"object"}, TypeNamesAtOrBefore(vars["router"], 13))
+	require.Empty(t, TypeNamesAtOrBefore(vars["other"], 13))
+}
+
+func TestStaticAnalyzerInfersTheUnionOfTernariesAndMatches(t *testing.T) {
+	code := []byte(`<?php
+namespace Example;
+
+use Symfony\Component\Routing\RouterInterface;
+use Symfony\Component\Routing\Generator\UrlGeneratorInterface;
+
+class Links
+{
+    private RouterInterface $router;
+    private UrlGeneratorInterface $generator;
+
+    public function home(bool $admin, string $kind)
+    {
+        $router = $admin ? $this->router : $this->generator;
+        $fallback = $this->router ?: null;
+        $matched = match ($kind) {
+            'router' => $this->router,
+            default => $this->generator,
+        };
+    }
+}
+`)
+
+	doc := NewDocument()
+	doc.SetURI("test.php")
+	require.NoError(t, doc.Update(code, nil, NewDocumentStore(0)))
+
+	both := []string{
+		"Symfony\\Component\\Routing\\RouterInterface",
+		"Symfony\\Component\\Routing\\Generator\\UrlGeneratorInterface",
+	}
+	vars := doc.Index().Variables["home"].Variables
+	require.ElementsMatch(t, both, TypeNamesFromOccurrences(vars["router"]))
+	require.ElementsMatch(t, []string{"Symfony\\Component\\Routing\\RouterInterface", "null"}, TypeNamesFromOccurrences(vars["fallback"]))
+	require.ElementsMatch(t, both, TypeNamesFromOccurrences(vars["matched"]))
+}
+
+func TestStaticAnalyzerIndexesConstantsAndEnumCases(t *testing.T) {
+	code := []byte(`<?php
+namespace Example;
+
+class Order
+{
+    public const STATUS_NEW = 'new', STATUS_PAID = 'paid';
+}
+
+enum Suit: string
+{
+    case Hearts = 'H';
+    case Spades = 'S';
+}
+`)
+
+	doc := NewDocument()
+	doc.SetURI("test.php")
+	require.NoError(t, doc.Update(code, nil, NewDocumentStore(0)))
+
+	constants := doc.Index().Constants
+	require.Len(t, constants, 4)
+	require.Equal(t, ConstantInfo{
+		Class: "Example\\Order",
+		Name:  "STATUS_NEW",
+		Value: "'new'",
+		Range: LineColumnRange{StartLine: 6, StartColumn: 17, EndLine: 6, EndColumn: 27},
+	}, constants[0])
+	require.Equal(t, "STATUS_PAID", constants[1].Name)
+	require.Equal(t, ConstantInfo{
+		Class:    "Example\\Suit",
+		Name:     "Hearts",
+		Value:    "'H'",
+		EnumCase: true,
+		Range:    LineColumnRange{StartLine: 11, StartColumn: 9, EndLine: 11, EndColumn: 15},
+	}, constants[2])
+	require.Equal(t, "Spades", constants[3].Name)
+}
@@ -0,0 +1,140 @@
+package php
+
+import (
+	"strings"
+)
+
+// Declares the properties of the traits of each class at the line of its
+// first trait use, unless the class declares them itself
+func (ctx *analysisContext) mergeTraitProperties(props map[string][]TypeOccurrence, classes map[uint32]ClassInfo) {
+	for _, info := range classes {
+		if len(info.Traits) == 0 {
+			continue
+		}
+		own := propertiesInLines(props, info.StartLine, info.EndLine)
+		visited := make(map[string]struct{})
+		for name, occs := range ctx.traitProperties(info.Traits, props, classes, visited) {
+			if _, ok := own[name]; ok {
+				continue
+			}
+			moved := make([]TypeOccurrence, 0, len(occs))
+			for _, occ := range occs {
+				moved = append(moved, TypeOccurrence{Type: occ.Type, Line: info.traitLine})
+			}
+			props[name] = mergeTypeOccurrences(props[name], moved)
+		}
+	}
+}
+
+// Returns the properties of traits, the ones of the traits they use included.
+// The traits of the file are read from props, the others from their file.
+func (ctx *analysisContext) traitProperties(traits []string, props map[string][]TypeOccurrence, classes map[uint32]ClassInfo, visited map[string]struct{}) map[string][]TypeOccurrence {
+	result := make(map[string][]TypeOccurrence)
+	for _, trait := range traits {
+		key := strings.ToLower(normalizeFQN(trait))
+		if _, ok := visited[key]; ok || key == "" {
+			continue
+		}
+		visited[key] = struct{}{}
+
+		if info, ok := classByFQN(classes, key); ok {
+			for name, occs := range propertiesInLines(props, info.StartLine, info.EndLine) {
+				result[name] = mergeTypeOccurrences(result[name], occs)
+			}
+			for name, occs := range ctx.traitProperties(info.Traits, props, classes, visited) {
+				if _, ok := result[name]; !ok {
+					result[name] = occs
+				}
+			}
+			continue
+		}
+		for name, occs := range ctx.ensureExternalClassLoaded(trait).properties {
+			if _, ok := result[name]; !ok {
+				result[name] = occs
+			}
+		}
+	}
+	return result
+}
+
+// Adds the methods of the traits of each class of the file to its own, unless
+// it declares them itself
+func (ctx *analysisContext) mergeTraitMethods(classMethods map[string]*methodSet, classes map[uint32]ClassInfo) {
+	// The methods the classes declare, before any trait is merged
+	declared := make(map[string]methodSet, len(classMethods))
+	for name, methods := range classMethods {
+		if methods != nil {
+			declared[name] = *methods
+		}
+	}
+	for _, info := range classes {
+		methods := classMethods[info.Name]
+		if len(info.Traits) == 0 || methods == nil {
+			continue
+		}
+		names := make(map[string]struct{})
+		for _, fns := range [][]FunctionInfo{methods.private, methods.protected, methods.public} {
+			for _, fn := range fns {
+				names[strings.ToLower(fn.Name)] = struct{}{}
+			}
+		}
+		visited := make(map[string]struct{})
+		ctx.addTraitMethods(methods, names, info.Traits, classes, declared, visited)
+	}
+}
+
+func (ctx *analysisContext) addTraitMethods(methods *methodSet, names map[string]struct{}, traits []string, classes map[uint32]ClassInfo, declared map[string]methodSet, visited map[string]struct{}) {
+	add := func(target *[]FunctionInfo, fns []FunctionInfo) {
+		for _, fn := range fns {
+			if _, ok := names[strings.ToLower(fn.Name)]; ok {
+				continue
+			}
+			names[strings.ToLower(fn.Name)] = struct{}{}
+			*target = append(*target, fn)
+		}
+	}
+	for _, trait := range traits {
+		key := strings.ToLower(normalizeFQN(trait))
+		if _, ok := visited[key]; ok || key == "" {
+			continue
+		}
+		visited[key] = struct{}{}
+
+		var traitMethods methodSet
+		var nested []string
+		if info, ok := classByFQN(classes, key); ok {
+			traitMethods = declared[info.Name]
+			nested = info.Traits
+		} else if data := ctx.ensureExternalClassLoaded(trait); data.methods != nil {
+			// Merged with the traits it uses already
+			traitMethods = *data.methods
+		}
+		add(&methods.private, traitMethods.private)
+		add(&methods.protected, traitMethods.protected)
+		add(&methods.public, traitMethods.public)
+		ctx.addTraitMethods(methods, names, nested, classes, declared, visited)
+	}
+}
+
+// Returns the class or trait of the file whose lowercase name is key
+func classByFQN(classes map[uint32]ClassInfo, key string) (ClassInfo, bool) {
+	for _, info := range classes {
+		if strings.ToLower(normalizeFQN(info.FQN)) == key {
+			return info, true
+		}
+	}
+	return ClassInfo{}, false
+}
+
+// Returns the occurrences of props between the lines startLine and endLine
+func propertiesInLines(props map[string][]TypeOccurrence, startLine, endLine int) map[string][]TypeOccurrence {
+	result := make(map[string][]TypeOccurrence)
+	for name, occs := range props {
+		for _, occ := range occs {
+			if occ.Line >= startLine && occ.Line <= endLine {
+				result[name] = append(result[name], occ)
+			}
+		}
+	}
+	return result
+}
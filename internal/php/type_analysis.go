@@ -283,12 +283,12 @@ func mergeTypeOccurrences(existing, additions []TypeOccurrence) []TypeOccurrence
 	}
 	seen := make(map[string]struct{}, len(existing))
 	for _, occ := range existing {
-		key := strings.ToLower(occ.Type) + "#" + strconv.Itoa(occ.Line)
+		key := strings.ToLower(occ.Type) + "#" + strconv.Itoa(occ.Line) + "-" + strconv.Itoa(occ.EndLine)
 		seen[key] = struct{}{}
 	}
 	for _, add := range additions {
 		// We allow empty type for untyped properties
-		key := strings.ToLower(add.Type) + "#" + strconv.Itoa(add.Line)
+		key := strings.ToLower(add.Type) + "#" + strconv.Itoa(add.Line) + "-" + strconv.Itoa(add.EndLine)
 		if _, ok := seen[key]; ok {
 			continue
 		}
@@ -316,14 +316,18 @@ func TypeNamesFromOccurrences(entries []TypeOccurrence) []string {
 	return types
 }
 
-// TypeNamesAtOrBefore collapses the occurrences observed up to the requested line.
+// TypeNamesAtOrBefore collapses the occurrences observed up to the requested line,
+// skipping the narrowed ones whose block ended before it.
 func TypeNamesAtOrBefore(entries []TypeOccurrence, line int) []string {
 	if len(entries) == 0 {
 		return nil
 	}
+	expired := func(occ TypeOccurrence) bool {
+		return line >= 0 && occ.EndLine > 0 && line > occ.EndLine
+	}
 	maxLine := -1
 	for _, occ := range entries {
-		if line >= 0 && occ.Line > line {
+		if line >= 0 && occ.Line > line || expired(occ) {
 			continue
 		}
 		if occ.Line > maxLine {
@@ -336,7 +340,7 @@ func TypeNamesAtOrBefore(entries []TypeOccurrence, line int) []string {
 	seen := make(map[string]struct{})
 	var result []string
 	for _, occ := range entries {
-		if occ.Line != maxLine {
+		if occ.Line != maxLine || expired(occ) {
 			continue
 		}
 		key := strings.ToLower(occ.Type)
@@ -14,6 +14,9 @@ const (
 type TypeOccurrence struct {
 	Type string
 	Line int
+	// EndLine bounds the occurrence of a type an instanceof check narrows to
+	// the last line of the block it guards, 0 when it holds until the next one
+	EndLine int
 }
 
 // TypeReference ties a type name to the symbol (property or variable) where it was observed.
@@ -45,6 +48,9 @@ type FunctionInfo struct {
 	Range      LineColumnRange
 	Parameters LineColumnRange
 	Body       LineColumnRange
+	// ReturnTypes are the types the method declares it returns, natively or
+	// in its docblock
+	ReturnTypes []string
 }
 
 type methodSet struct {
@@ -54,19 +60,48 @@ type methodSet struct {
 }
 
 type externalClassData struct {
-	methods *methodSet
-	extends []string
+	methods    *methodSet
+	extends    []string
+	implements []string
+	// properties are the ones declared in the class, for the classes using
+	// it as a trait
+	properties map[string][]TypeOccurrence
 }
 
-// ClassInfo describes a class declaration discovered in the file.
+// ClassInfo describes a class, trait or interface declaration discovered in
+// the file.
 type ClassInfo struct {
 	Name      string
 	Namespace string
 	FQN       string
-	Extends   []string
+	// Extends are all the ancestors of the class, or the interfaces an
+	// interface extends
+	Extends []string
+	// Implements are all the interfaces of a class, the ones of its ancestors
+	// and the ones they extend included
+	Implements []string
+	// Traits are the traits the class uses, whose methods and properties
+	// count as its own
+	Traits    []string
+	Trait     bool
+	Interface bool
 	StartLine int
 	EndLine   int
 	StartByte uint32
+	// traitLine is the line of the first use of a trait, where the index
+	// declares the properties of the traits
+	traitLine int
+}
+
+// ConstantInfo describes a constant or an enum case declared by a class,
+// interface, trait or enum of the file.
+type ConstantInfo struct {
+	// Class is the FQN of the declaration holding the constant
+	Class    string
+	Name     string
+	Value    string
+	EnumCase bool
+	Range    LineColumnRange
 }
 
 // IndexedTree contains lightweight static analysis metadata for a PHP source file.
@@ -77,6 +112,7 @@ type IndexedTree struct {
 	Variables          map[string]FunctionScope
 	Types              map[string][]TypeReference
 	Classes            map[uint32]ClassInfo
+	Constants          []ConstantInfo
 	Uses               map[string]string
 	PrivateFunctions   []FunctionInfo
 	ProtectedFunctions []FunctionInfo
@@ -8,7 +8,11 @@ import (
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 )
 
-var docblockVarRe = regexp.MustCompile(`@var\s+([^\s]+)\s+\$([A-Za-z_][A-Za-z0-9_]*)`)
+var (
+	docblockVarRe    = regexp.MustCompile(`@var\s+([^\s]+)\s+\$([A-Za-z_][A-Za-z0-9_]*)`)
+	docblockParamRe  = regexp.MustCompile(`@param\s+([^\s$]+)\s+(?:&\s*)?(?:\.\.\.)?\$([A-Za-z_][A-Za-z0-9_]*)`)
+	docblockReturnRe = regexp.MustCompile(`@return\s+([^\s*]+)`)
+)
 
 func (ctx *analysisContext) collectFunctionVariableTypes(properties map[string][]TypeOccurrence) map[string]FunctionScope {
 	result := make(map[string]FunctionScope)
@@ -49,6 +53,7 @@ func (ctx *analysisContext) collectVariableTypesForFunction(node sitter.Node, us
 
 	params := node.ChildByFieldName("parameters")
 	if !params.IsNull() {
+		docParams := ctx.parseDocblockParams(node, uses)
 		for i := uint32(0); i < params.NamedChildCount(); i++ {
 			param := params.NamedChild(i)
 			nameNode := param.ChildByFieldName("name")
@@ -57,6 +62,10 @@ func (ctx *analysisContext) collectVariableTypesForFunction(node sitter.Node, us
 				continue
 			}
 			typeNames := CollectTypeNames(param.ChildByFieldName("type"), content, uses)
+			if len(typeNames) == 0 {
+				// Legacy code types its parameters in the docblock only
+				typeNames = docParams[name]
+			}
 			if len(typeNames) == 0 {
 				continue
 			}
@@ -95,7 +104,7 @@ func (ctx *analysisContext) collectVariableTypesForFunction(node sitter.Node, us
 			}
 			line := int(expr.StartPoint().Row) + 1
 			right := expr.ChildByFieldName("right")
-			inferred := InferExpressionTypeNames(right, content, uses, types, properties, line-1)
+			inferred := ctx.inferExpressionTypeNames(right, types, properties, line-1)
 			docs := pendingDoc[varName]
 			combined := mergeTypeNameLists(docs, inferred)
 			if len(combined) > 0 {
@@ -103,6 +112,8 @@ func (ctx *analysisContext) collectVariableTypesForFunction(node sitter.Node, us
 				types[varName] = mergeTypeOccurrences(types[varName], occ)
 			}
 			delete(pendingDoc, varName)
+		case "if_statement":
+			ctx.collectInstanceofNarrowing(stmt, types)
 		default:
 			pendingDoc = make(map[string][]string)
 			continue
@@ -113,6 +124,70 @@ func (ctx *analysisContext) collectVariableTypesForFunction(node sitter.Node, us
 	return types
 }
 
+// Records the types the instanceof checks of an if statement narrow variables
+// to, for the lines of the block they guard. The ifs nested in the block are
+// followed too.
+func (ctx *analysisContext) collectInstanceofNarrowing(stmt sitter.Node, types map[string][]TypeOccurrence) {
+	if stmt.Type() != "if_statement" {
+		return
+	}
+	body := stmt.ChildByFieldName("body")
+	if body.IsNull() {
+		return
+	}
+	start, end := int(body.StartPoint().Row)+1, int(body.EndPoint().Row)+1
+	checks := make(map[string][]string)
+	ctx.instanceofChecks(stmt.ChildByFieldName("condition"), checks)
+	for name, typeNames := range checks {
+		for _, typ := range typeNames {
+			types[name] = mergeTypeOccurrences(types[name], []TypeOccurrence{{Type: typ, Line: start, EndLine: end}})
+		}
+	}
+	for i := uint32(0); i < body.NamedChildCount(); i++ {
+		ctx.collectInstanceofNarrowing(body.NamedChild(i), types)
+	}
+}
+
+// Collects the classes condition checks variables are instances of, in the
+// checks all of which must hold
+func (ctx *analysisContext) instanceofChecks(condition sitter.Node, checks map[string][]string) {
+	if condition.IsNull() {
+		return
+	}
+	content := ctx.bytes()
+	switch condition.Type() {
+	case "parenthesized_expression":
+		if condition.NamedChildCount() > 0 {
+			ctx.instanceofChecks(condition.NamedChild(0), checks)
+		}
+	case "binary_expression":
+		operator := condition.ChildByFieldName("operator")
+		if operator.IsNull() {
+			return
+		}
+		left, right := condition.ChildByFieldName("left"), condition.ChildByFieldName("right")
+		switch strings.ToLower(strings.TrimSpace(operator.Content(content))) {
+		case "&&", "and":
+			ctx.instanceofChecks(left, checks)
+			ctx.instanceofChecks(right, checks)
+		case "instanceof":
+			name := VariableNameFromNode(left, content)
+			if name == "" || right.IsNull() {
+				return
+			}
+			switch right.Type() {
+			case "name", "qualified_name", "relative_name":
+				candidate := strings.TrimSpace(right.Content(content))
+				resolved := ResolveRawTypeName(candidate, ctx.uses)
+				if resolved == "" {
+					resolved = candidate
+				}
+				checks[name] = mergeTypeNameLists(checks[name], []string{resolved})
+			}
+		}
+	}
+}
+
 func (ctx *analysisContext) functionBodyNode(node sitter.Node) sitter.Node {
 	if body := node.ChildByFieldName("body"); !body.IsNull() {
 		return body
@@ -170,8 +245,164 @@ func (ctx *analysisContext) parseDocblockVar(node sitter.Node, uses map[string]s
 	if len(matches) < 3 {
 		return "", nil
 	}
-	typeExpr := matches[1]
-	varName := matches[2]
+	return matches[2], docblockTypeNames(matches[1], uses)
+}
+
+// Returns the docblock right above a method or function declaration, or ""
+func (ctx *analysisContext) functionDocblock(node sitter.Node) string {
+	comment := node.PrevNamedSibling()
+	if comment.IsNull() || comment.Type() != "comment" {
+		return ""
+	}
+	text := comment.Content(ctx.bytes())
+	if !strings.HasPrefix(text, "/**") {
+		return ""
+	}
+	return text
+}
+
+// Returns the types of the parameters of a function by name, read from the
+// @param tags of its docblock
+func (ctx *analysisContext) parseDocblockParams(node sitter.Node, uses map[string]string) map[string][]string {
+	doc := ctx.functionDocblock(node)
+	if doc == "" {
+		return nil
+	}
+	result := make(map[string][]string)
+	for _, matches := range docblockParamRe.FindAllStringSubmatch(doc, -1) {
+		if types := docblockTypeNames(matches[1], uses); len(types) > 0 {
+			result[matches[2]] = types
+		}
+	}
+	return result
+}
+
+// Returns the types a function returns, from its native return type or else
+// the @return tag of its docblock. self and static are the class of class.
+func (ctx *analysisContext) functionReturnTypes(node sitter.Node, class string) []string {
+	uses := ctx.uses
+	types := CollectTypeNames(node.ChildByFieldName("return_type"), ctx.bytes(), uses)
+	if len(types) == 0 {
+		if matches := docblockReturnRe.FindStringSubmatch(ctx.functionDocblock(node)); len(matches) == 2 {
+			types = docblockTypeNames(matches[1], uses)
+		}
+	}
+	for i, typ := range types {
+		switch strings.ToLower(typ) {
+		case "self", "static", "$this":
+			if class != "" {
+				types[i] = class
+			}
+		}
+	}
+	return types
+}
+
+// Returns the return types of the methods and functions declared in the
+// file, by lowercase name
+func (ctx *analysisContext) declaredReturnTypes() map[string][]string {
+	if ctx.returns != nil {
+		return ctx.returns
+	}
+	ctx.returns = make(map[string][]string)
+	ctx.classes = make(map[string][]string)
+	root := ctx.rootNode()
+	if root.IsNull() {
+		return ctx.returns
+	}
+	type frame struct {
+		node  sitter.Node
+		class string
+	}
+	stack := []frame{{node: root}}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		class := cur.class
+		switch cur.node.Type() {
+		case "class_declaration", "trait_declaration", "interface_declaration":
+			if info, ok := ctx.classInfoFromNode(cur.node); ok {
+				class = info.FQN
+				ctx.classes[strings.ToLower(normalizeFQN(info.FQN))] = info.Extends
+			}
+		case "method_declaration", "function_definition":
+			name := strings.ToLower(ctx.functionNameFromNode(cur.node))
+			if types := ctx.functionReturnTypes(cur.node, class); name != "" && len(types) > 0 {
+				if _, ok := ctx.returns[name]; !ok {
+					ctx.returns[name] = types
+				}
+			}
+			continue
+		}
+		for i := uint32(0); i < cur.node.NamedChildCount(); i++ {
+			stack = append(stack, frame{node: cur.node.NamedChild(i), class: class})
+		}
+	}
+	return ctx.returns
+}
+
+// Returns the types the method of class fqcn returns, read from the file of
+// the class, the inherited methods included
+func (ctx *analysisContext) methodReturnTypes(fqcn, method string) []string {
+	if _, ok := ctx.declaredClasses()[strings.ToLower(normalizeFQN(fqcn))]; ok {
+		return ctx.fileMethodReturnTypes(method)
+	}
+	if !isClassTypeName(fqcn) {
+		return nil
+	}
+	methods := ctx.ensureExternalClassLoaded(fqcn).methods
+	if methods == nil {
+		return nil
+	}
+	for _, fns := range [][]FunctionInfo{methods.public, methods.protected, methods.private} {
+		for _, fn := range fns {
+			if strings.EqualFold(fn.Name, method) {
+				return fn.ReturnTypes
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the types a method of the classes of the file returns, the methods
+// they inherit from the classes of other files included
+func (ctx *analysisContext) fileMethodReturnTypes(method string) []string {
+	if types := ctx.declaredReturnTypes()[strings.ToLower(method)]; len(types) > 0 {
+		return types
+	}
+	classes := ctx.declaredClasses()
+	for _, parents := range classes {
+		for _, parent := range parents {
+			if _, ok := classes[strings.ToLower(normalizeFQN(parent))]; ok {
+				continue
+			}
+			if types := ctx.methodReturnTypes(parent, method); len(types) > 0 {
+				return types
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the parents of the classes declared in the file by lowercase FQN
+func (ctx *analysisContext) declaredClasses() map[string][]string {
+	ctx.declaredReturnTypes()
+	return ctx.classes
+}
+
+// Reports whether typ names a class rather than a builtin type
+func isClassTypeName(typ string) bool {
+	switch strings.ToLower(normalizeFQN(typ)) {
+	case "", "array", "bool", "callable", "false", "float", "int", "iterable", "mixed",
+		"never", "null", "object", "resource", "self", "static", "string", "true", "void":
+		return false
+	}
+	return true
+}
+
+// Returns the types named by the type of a docblock tag, like ?Foo or Foo[]|null
+func docblockTypeNames(typeExpr string, uses map[string]string) []string {
 	parts := strings.Split(typeExpr, "|")
 	types := make([]string, 0, len(parts))
 	for _, part := range parts {
@@ -202,20 +433,29 @@ func (ctx *analysisContext) parseDocblockVar(node sitter.Node, uses map[string]s
 		}
 		types = mergeTypeNameLists(types, []string{resolved})
 	}
-	return varName, types
+	return types
 }
 
-// InferExpressionTypeNames evaluates an expression node and returns the inferred types based on known mappings.
-func InferExpressionTypeNames(expr sitter.Node, content []byte, uses map[string]string, current map[string][]TypeOccurrence, properties map[string][]TypeOccurrence, line int) []string {
+// inferExpressionTypeNames evaluates an expression node and returns the inferred types based on known mappings
+// and the return types of the methods and functions of the file.
+func (ctx *analysisContext) inferExpressionTypeNames(expr sitter.Node, current map[string][]TypeOccurrence, properties map[string][]TypeOccurrence, line int) []string {
 	if expr.IsNull() {
 		return nil
 	}
+	content := ctx.bytes()
+	uses := ctx.uses
 
 	switch expr.Type() {
-	case "member_call_expression":
+	case "member_call_expression", "nullsafe_member_call_expression":
 		nameNode := expr.ChildByFieldName("name")
 		if !nameNode.IsNull() {
 			methodName := strings.TrimSpace(nameNode.Content(content))
+			objNode := expr.ChildByFieldName("object")
+			if VariableNameFromNode(objNode, content) == "this" {
+				if types := ctx.fileMethodReturnTypes(methodName); len(types) > 0 {
+					return types
+				}
+			}
 			if methodName == "getRepository" {
 				return []string{"\\Doctrine\\ORM\\EntityRepository"}
 			}
@@ -227,12 +467,33 @@ func InferExpressionTypeNames(expr sitter.Node, content []byte, uses map[string]
 			}
 
 			// If it's a known fluid method of QueryBuilder or similar, fallback to object type
-			objNode := expr.ChildByFieldName("object")
 			if !objNode.IsNull() {
-				// We don't have full reflection, so we pass through the object's type as a heuristic
-				return InferExpressionTypeNames(objNode, content, uses, current, properties, line)
+				objectTypes := ctx.inferExpressionTypeNames(objNode, current, properties, line)
+				var returned []string
+				for _, typ := range objectTypes {
+					returned = mergeTypeNameLists(returned, ctx.methodReturnTypes(typ, methodName))
+				}
+				if len(returned) > 0 {
+					return returned
+				}
+				// Without a declared return type, pass through the object's type as a heuristic
+				return objectTypes
 			}
 		}
+	case "scoped_call_expression":
+		scopeNode, nameNode := expr.ChildByFieldName("scope"), expr.ChildByFieldName("name")
+		if scopeNode.IsNull() || nameNode.IsNull() {
+			return nil
+		}
+		switch strings.ToLower(strings.TrimSpace(scopeNode.Content(content))) {
+		case "self", "static":
+			return ctx.fileMethodReturnTypes(strings.TrimSpace(nameNode.Content(content)))
+		}
+	case "function_call_expression":
+		fnNode := expr.ChildByFieldName("function")
+		if !fnNode.IsNull() {
+			return ctx.declaredReturnTypes()[strings.ToLower(strings.TrimSpace(fnNode.Content(content)))]
+		}
 	case "member_access_expression", "nullsafe_member_access_expression":
 		if name := memberAccessPropertyName(expr, content); name != "" {
 			return TypeNamesFromOccurrences(properties[name])
@@ -280,12 +541,37 @@ func InferExpressionTypeNames(expr sitter.Node, content []byte, uses map[string]
 		if !typeNode.IsNull() {
 			return CollectTypeNames(typeNode, content, uses)
 		}
+	case "conditional_expression":
+		// The short ternary returns the condition when it holds
+		body := expr.ChildByFieldName("body")
+		if body.IsNull() {
+			body = expr.ChildByFieldName("condition")
+		}
+		return mergeTypeNameLists(
+			ctx.inferExpressionTypeNames(body, current, properties, line),
+			ctx.inferExpressionTypeNames(expr.ChildByFieldName("alternative"), current, properties, line),
+		)
+	case "match_expression":
+		block := expr.ChildByFieldName("body")
+		if block.IsNull() {
+			return nil
+		}
+		var types []string
+		for i := uint32(0); i < block.NamedChildCount(); i++ {
+			arm := block.NamedChild(i)
+			switch arm.Type() {
+			case "match_conditional_expression", "match_default_expression":
+				returned := ctx.inferExpressionTypeNames(arm.ChildByFieldName("return_expression"), current, properties, line)
+				types = mergeTypeNameLists(types, returned)
+			}
+		}
+		return types
 	case "parenthesized_expression":
 		inner := expr.ChildByFieldName("expression")
 		if inner.IsNull() && expr.NamedChildCount() > 0 {
 			inner = expr.NamedChild(0)
 		}
-		return InferExpressionTypeNames(inner, content, uses, current, properties, line)
+		return ctx.inferExpressionTypeNames(inner, current, properties, line)
 	}
 	return nil
 }
@@ -0,0 +1,179 @@
+// Package protocol317 is the LSP 3.17 protocol surface on top of glsp's
+// protocol_3_16: the 3.17 capabilities and the pull diagnostics, inlay hint,
+// type hierarchy and inline value requests. Everything else is the 3.16
+// protocol, which is embedded unchanged.
+package protocol317
+
+import (
+	"encoding/json"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#initializeParams
+type InitializeParams struct {
+	protocol.InitializeParams
+
+	// Replaces the 3.16 capabilities of the embedded params
+	Capabilities ClientCapabilities `json:"capabilities"`
+
+	// Replaces the token of the embedded params, which glsp does not decode
+	WorkDoneToken *ProgressToken `json:"workDoneToken,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#progress
+//
+// The integer or string of glsp's ProgressToken, which loses its value when
+// decoded
+type ProgressToken protocol.ProgressToken
+
+func (t ProgressToken) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Value)
+}
+
+func (t *ProgressToken) UnmarshalJSON(data []byte) error {
+	var integer protocol.Integer
+	if err := json.Unmarshal(data, &integer); err == nil {
+		t.Value = integer
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	t.Value = str
+	return nil
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#clientCapabilities
+type ClientCapabilities struct {
+	protocol.ClientCapabilities
+
+	Workspace    *WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+	TextDocument *TextDocumentClientCapabilities `json:"textDocument,omitempty"`
+	General      *GeneralClientCapabilities      `json:"general,omitempty"`
+}
+
+// The general capabilities of https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#clientCapabilities
+// that the server reads, replacing the 3.16 ones of the embedded capabilities
+type GeneralClientCapabilities struct {
+	/**
+	 * The position encodings supported by the client, in the order of its
+	 * preference. Without it the client only supports UTF-16.
+	 *
+	 * @since 3.17.0
+	 */
+	PositionEncodings []PositionEncodingKind `json:"positionEncodings,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#positionEncodingKind
+type PositionEncodingKind string
+
+const (
+	PositionEncodingKindUTF8  = PositionEncodingKind("utf-8")
+	PositionEncodingKindUTF16 = PositionEncodingKind("utf-16")
+	PositionEncodingKindUTF32 = PositionEncodingKind("utf-32")
+)
+
+// The workspace capabilities of https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#clientCapabilities
+// that the server reads, replacing the 3.16 ones of the embedded capabilities
+type WorkspaceClientCapabilities struct {
+	ApplyEdit             *bool                                             `json:"applyEdit,omitempty"`
+	WorkspaceEdit         *protocol.WorkspaceEditClientCapabilities         `json:"workspaceEdit,omitempty"`
+	DidChangeWatchedFiles *protocol.DidChangeWatchedFilesClientCapabilities `json:"didChangeWatchedFiles,omitempty"`
+	ExecuteCommand        *protocol.ExecuteCommandClientCapabilities        `json:"executeCommand,omitempty"`
+	WorkspaceFolders      *bool                                             `json:"workspaceFolders,omitempty"`
+	Configuration         *bool                                             `json:"configuration,omitempty"`
+	CodeLens              *protocol.CodeLensWorkspaceClientCapabilities     `json:"codeLens,omitempty"`
+
+	/**
+	 * Client workspace capabilities specific to diagnostics.
+	 *
+	 * @since 3.17.0
+	 */
+	Diagnostics *DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocumentClientCapabilities
+type TextDocumentClientCapabilities struct {
+	protocol.TextDocumentClientCapabilities
+
+	/**
+	 * Capabilities specific to the `textDocument/typeHierarchy` request.
+	 *
+	 * @since 3.17.0
+	 */
+	TypeHierarchy *TypeHierarchyClientCapabilities `json:"typeHierarchy,omitempty"`
+
+	/**
+	 * Capabilities specific to the `textDocument/inlineValue` request.
+	 *
+	 * @since 3.17.0
+	 */
+	InlineValue *InlineValueClientCapabilities `json:"inlineValue,omitempty"`
+
+	/**
+	 * Capabilities specific to the `textDocument/inlayHint` request.
+	 *
+	 * @since 3.17.0
+	 */
+	InlayHint *InlayHintClientCapabilities `json:"inlayHint,omitempty"`
+
+	/**
+	 * Capabilities specific to the diagnostic pull model.
+	 *
+	 * @since 3.17.0
+	 */
+	Diagnostic *DiagnosticClientCapabilities `json:"diagnostic,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#serverCapabilities
+type ServerCapabilities struct {
+	protocol.ServerCapabilities
+
+	/**
+	 * The position encoding the server picked from the encodings offered by
+	 * the client, UTF-16 when left out.
+	 *
+	 * @since 3.17.0
+	 */
+	PositionEncoding *PositionEncodingKind `json:"positionEncoding,omitempty"`
+
+	/**
+	 * The server provides type hierarchy support.
+	 *
+	 * @since 3.17.0
+	 */
+	TypeHierarchyProvider any `json:"typeHierarchyProvider,omitempty"` // bool | TypeHierarchyOptions
+
+	/**
+	 * The server provides inline values.
+	 *
+	 * @since 3.17.0
+	 */
+	InlineValueProvider any `json:"inlineValueProvider,omitempty"` // bool | InlineValueOptions
+
+	/**
+	 * The server provides inlay hints.
+	 *
+	 * @since 3.17.0
+	 */
+	InlayHintProvider any `json:"inlayHintProvider,omitempty"` // bool | InlayHintOptions
+
+	/**
+	 * The server has support for pull model diagnostics.
+	 *
+	 * @since 3.17.0
+	 */
+	DiagnosticProvider *DiagnosticOptions `json:"diagnosticProvider,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#initializeResult
+type InitializeResult struct {
+	Capabilities ServerCapabilities                   `json:"capabilities"`
+	ServerInfo   *protocol.InitializeResultServerInfo `json:"serverInfo,omitempty"`
+}
+
+// Returns: InitializeResult
+type InitializeFunc func(context *glsp.Context, params *InitializeParams) (any, error)
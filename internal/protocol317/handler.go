@@ -0,0 +1,169 @@
+package protocol317
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Handler dispatches the 3.17 requests and hands everything else to the
+// embedded 3.16 handler. Initialize replaces the one of the 3.16 handler so
+// that it receives the 3.17 client capabilities, and
+// WindowWorkDoneProgressCancel so that it receives the token.
+type Handler struct {
+	protocol.Handler
+
+	Initialize                   InitializeFunc
+	WindowWorkDoneProgressCancel WindowWorkDoneProgressCancelFunc
+
+	TextDocumentDiagnostic           TextDocumentDiagnosticFunc
+	TextDocumentInlayHint            TextDocumentInlayHintFunc
+	InlayHintResolve                 InlayHintResolveFunc
+	TextDocumentPrepareTypeHierarchy TextDocumentPrepareTypeHierarchyFunc
+	TypeHierarchySupertypes          TypeHierarchySupertypesFunc
+	TypeHierarchySubtypes            TypeHierarchySubtypesFunc
+	TextDocumentInlineValue          TextDocumentInlineValueFunc
+}
+
+// glsp.Handler interface
+func (self *Handler) Handle(context *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	if context.Method == protocol.MethodInitialize && self.Initialize != nil {
+		validMethod = true
+		var params InitializeParams
+		if err = json.Unmarshal(context.Params, &params); err == nil {
+			validParams = true
+			if r, err = self.Initialize(context, &params); err == nil {
+				self.SetInitialized(true)
+			}
+		}
+		return
+	}
+
+	if context.Method == protocol.MethodWindowWorkDoneProgressCancel && self.WindowWorkDoneProgressCancel != nil {
+		if !self.IsInitialized() {
+			return nil, true, true, errors.New("server not initialized")
+		}
+		validMethod = true
+		var params WorkDoneProgressCancelParams
+		if err = json.Unmarshal(context.Params, &params); err == nil {
+			validParams = true
+			err = self.WindowWorkDoneProgressCancel(context, &params)
+		}
+		return
+	}
+
+	switch context.Method {
+	case MethodTextDocumentDiagnostic, MethodTextDocumentInlayHint, MethodInlayHintResolve,
+		MethodTextDocumentPrepareTypeHierarchy, MethodTypeHierarchySupertypes, MethodTypeHierarchySubtypes,
+		MethodTextDocumentInlineValue:
+		if !self.IsInitialized() {
+			return nil, true, true, errors.New("server not initialized")
+		}
+	default:
+		return self.Handler.Handle(context)
+	}
+
+	switch context.Method {
+	case MethodTextDocumentDiagnostic:
+		if self.TextDocumentDiagnostic != nil {
+			validMethod = true
+			var params DocumentDiagnosticParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = self.TextDocumentDiagnostic(context, &params)
+			}
+		}
+
+	case MethodTextDocumentInlayHint:
+		if self.TextDocumentInlayHint != nil {
+			validMethod = true
+			var params InlayHintParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = self.TextDocumentInlayHint(context, &params)
+			}
+		}
+
+	case MethodInlayHintResolve:
+		if self.InlayHintResolve != nil {
+			validMethod = true
+			var params InlayHint
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = self.InlayHintResolve(context, &params)
+			}
+		}
+
+	case MethodTextDocumentPrepareTypeHierarchy:
+		if self.TextDocumentPrepareTypeHierarchy != nil {
+			validMethod = true
+			var params TypeHierarchyPrepareParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = self.TextDocumentPrepareTypeHierarchy(context, &params)
+			}
+		}
+
+	case MethodTypeHierarchySupertypes:
+		if self.TypeHierarchySupertypes != nil {
+			validMethod = true
+			var params TypeHierarchySupertypesParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = self.TypeHierarchySupertypes(context, &params)
+			}
+		}
+
+	case MethodTypeHierarchySubtypes:
+		if self.TypeHierarchySubtypes != nil {
+			validMethod = true
+			var params TypeHierarchySubtypesParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = self.TypeHierarchySubtypes(context, &params)
+			}
+		}
+
+	case MethodTextDocumentInlineValue:
+		if self.TextDocumentInlineValue != nil {
+			validMethod = true
+			var params InlineValueParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = self.TextDocumentInlineValue(context, &params)
+			}
+		}
+	}
+
+	return
+}
+
+// CreateServerCapabilities adds the 3.17 providers of the handler to the
+// capabilities of the 3.16 one.
+func (self *Handler) CreateServerCapabilities() ServerCapabilities {
+	capabilities := ServerCapabilities{ServerCapabilities: self.Handler.CreateServerCapabilities()}
+
+	if self.TextDocumentDiagnostic != nil {
+		capabilities.DiagnosticProvider = &DiagnosticOptions{InterFileDependencies: true}
+	}
+
+	if self.TextDocumentInlayHint != nil {
+		if self.InlayHintResolve != nil {
+			capabilities.InlayHintProvider = &InlayHintOptions{ResolveProvider: &protocol.True}
+		} else {
+			capabilities.InlayHintProvider = true
+		}
+	}
+
+	if self.TextDocumentPrepareTypeHierarchy != nil {
+		capabilities.TypeHierarchyProvider = true
+	}
+
+	if self.TextDocumentInlineValue != nil {
+		capabilities.InlineValueProvider = true
+	}
+
+	return capabilities
+}
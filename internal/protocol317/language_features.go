@@ -0,0 +1,207 @@
+package protocol317
+
+import (
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	MethodTextDocumentDiagnostic           = protocol.Method("textDocument/diagnostic")
+	MethodTextDocumentInlayHint            = protocol.Method("textDocument/inlayHint")
+	MethodInlayHintResolve                 = protocol.Method("inlayHint/resolve")
+	MethodTextDocumentPrepareTypeHierarchy = protocol.Method("textDocument/prepareTypeHierarchy")
+	MethodTypeHierarchySupertypes          = protocol.Method("typeHierarchy/supertypes")
+	MethodTypeHierarchySubtypes            = protocol.Method("typeHierarchy/subtypes")
+	MethodTextDocumentInlineValue          = protocol.Method("textDocument/inlineValue")
+	MethodWorkspaceDiagnosticRefresh       = protocol.Method("workspace/diagnostic/refresh")
+)
+
+// Pull diagnostics
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#diagnosticClientCapabilities
+type DiagnosticClientCapabilities struct {
+	DynamicRegistration    *bool `json:"dynamicRegistration,omitempty"`
+	RelatedDocumentSupport *bool `json:"relatedDocumentSupport,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#diagnosticWorkspaceClientCapabilities
+type DiagnosticWorkspaceClientCapabilities struct {
+	RefreshSupport *bool `json:"refreshSupport,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#diagnosticOptions
+type DiagnosticOptions struct {
+	protocol.WorkDoneProgressOptions
+	Identifier            *string `json:"identifier,omitempty"`
+	InterFileDependencies bool    `json:"interFileDependencies"`
+	WorkspaceDiagnostics  bool    `json:"workspaceDiagnostics"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#documentDiagnosticParams
+type DocumentDiagnosticParams struct {
+	protocol.WorkDoneProgressParams
+	protocol.PartialResultParams
+	TextDocument     protocol.TextDocumentIdentifier `json:"textDocument"`
+	Identifier       *string                         `json:"identifier,omitempty"`
+	PreviousResultID *string                         `json:"previousResultId,omitempty"`
+}
+
+type DocumentDiagnosticReportKind string
+
+const (
+	DocumentDiagnosticReportKindFull      = DocumentDiagnosticReportKind("full")
+	DocumentDiagnosticReportKindUnchanged = DocumentDiagnosticReportKind("unchanged")
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#fullDocumentDiagnosticReport
+type FullDocumentDiagnosticReport struct {
+	Kind     DocumentDiagnosticReportKind `json:"kind"`
+	ResultID *string                      `json:"resultId,omitempty"`
+	Items    []protocol.Diagnostic        `json:"items"`
+}
+
+// Returns: FullDocumentDiagnosticReport
+type TextDocumentDiagnosticFunc func(context *glsp.Context, params *DocumentDiagnosticParams) (any, error)
+
+// Inlay hints
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlayHintClientCapabilities
+type InlayHintClientCapabilities struct {
+	DynamicRegistration *bool `json:"dynamicRegistration,omitempty"`
+	ResolveSupport      *struct {
+		Properties []string `json:"properties"`
+	} `json:"resolveSupport,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlayHintOptions
+type InlayHintOptions struct {
+	protocol.WorkDoneProgressOptions
+	ResolveProvider *bool `json:"resolveProvider,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlayHintParams
+type InlayHintParams struct {
+	protocol.WorkDoneProgressParams
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	Range        protocol.Range                  `json:"range"`
+}
+
+type InlayHintKind protocol.UInteger
+
+const (
+	InlayHintKindType      = InlayHintKind(1)
+	InlayHintKindParameter = InlayHintKind(2)
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlayHint
+type InlayHint struct {
+	Position     protocol.Position   `json:"position"`
+	Label        any                 `json:"label"` // string | []InlayHintLabelPart
+	Kind         *InlayHintKind      `json:"kind,omitempty"`
+	TextEdits    []protocol.TextEdit `json:"textEdits,omitempty"`
+	Tooltip      any                 `json:"tooltip,omitempty"` // string | MarkupContent
+	PaddingLeft  *bool               `json:"paddingLeft,omitempty"`
+	PaddingRight *bool               `json:"paddingRight,omitempty"`
+	Data         any                 `json:"data,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlayHintLabelPart
+type InlayHintLabelPart struct {
+	Value    string             `json:"value"`
+	Tooltip  any                `json:"tooltip,omitempty"` // string | MarkupContent
+	Location *protocol.Location `json:"location,omitempty"`
+	Command  *protocol.Command  `json:"command,omitempty"`
+}
+
+// Returns: []InlayHint | nil
+type TextDocumentInlayHintFunc func(context *glsp.Context, params *InlayHintParams) ([]InlayHint, error)
+
+type InlayHintResolveFunc func(context *glsp.Context, params *InlayHint) (*InlayHint, error)
+
+// Type hierarchy
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#typeHierarchyClientCapabilities
+type TypeHierarchyClientCapabilities struct {
+	DynamicRegistration *bool `json:"dynamicRegistration,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#typeHierarchyPrepareParams
+type TypeHierarchyPrepareParams struct {
+	protocol.TextDocumentPositionParams
+	protocol.WorkDoneProgressParams
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#typeHierarchyItem
+type TypeHierarchyItem struct {
+	Name           string               `json:"name"`
+	Kind           protocol.SymbolKind  `json:"kind"`
+	Tags           []protocol.SymbolTag `json:"tags,omitempty"`
+	Detail         *string              `json:"detail,omitempty"`
+	URI            protocol.DocumentUri `json:"uri"`
+	Range          protocol.Range       `json:"range"`
+	SelectionRange protocol.Range       `json:"selectionRange"`
+	Data           any                  `json:"data,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#typeHierarchySupertypesParams
+type TypeHierarchySupertypesParams struct {
+	protocol.WorkDoneProgressParams
+	protocol.PartialResultParams
+	Item TypeHierarchyItem `json:"item"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#typeHierarchySubtypesParams
+type TypeHierarchySubtypesParams struct {
+	protocol.WorkDoneProgressParams
+	protocol.PartialResultParams
+	Item TypeHierarchyItem `json:"item"`
+}
+
+type TextDocumentPrepareTypeHierarchyFunc func(context *glsp.Context, params *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, error)
+
+type TypeHierarchySupertypesFunc func(context *glsp.Context, params *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, error)
+
+type TypeHierarchySubtypesFunc func(context *glsp.Context, params *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, error)
+
+// Inline values
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlineValueClientCapabilities
+type InlineValueClientCapabilities struct {
+	DynamicRegistration *bool `json:"dynamicRegistration,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlineValueParams
+type InlineValueParams struct {
+	protocol.WorkDoneProgressParams
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	Range        protocol.Range                  `json:"range"`
+	Context      InlineValueContext              `json:"context"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlineValueContext
+type InlineValueContext struct {
+	FrameID         protocol.Integer `json:"frameId"`
+	StoppedLocation protocol.Range   `json:"stoppedLocation"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlineValueText
+type InlineValueText struct {
+	Range protocol.Range `json:"range"`
+	Text  string         `json:"text"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlineValueVariableLookup
+type InlineValueVariableLookup struct {
+	Range               protocol.Range `json:"range"`
+	VariableName        *string        `json:"variableName,omitempty"`
+	CaseSensitiveLookup bool           `json:"caseSensitiveLookup"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#inlineValueEvaluatableExpression
+type InlineValueEvaluatableExpression struct {
+	Range      protocol.Range `json:"range"`
+	Expression *string        `json:"expression,omitempty"`
+}
+
+// Returns: []InlineValueText | []InlineValueVariableLookup | []InlineValueEvaluatableExpression | nil
+type TextDocumentInlineValueFunc func(context *glsp.Context, params *InlineValueParams) ([]any, error)
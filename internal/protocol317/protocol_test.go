@@ -0,0 +1,113 @@
+package protocol317
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestInitializeParamsKeep316Capabilities(t *testing.T) {
+	raw := `{
+		"processId": 1,
+		"rootUri": "file:///app",
+		"capabilities": {
+			"textDocument": {
+				"completion": {"completionItem": {"snippetSupport": true}},
+				"diagnostic": {"relatedDocumentSupport": false},
+				"inlayHint": {"resolveSupport": {"properties": ["tooltip"]}}
+			}
+		}
+	}`
+	var params InitializeParams
+	require.NoError(t, json.Unmarshal([]byte(raw), &params))
+
+	require.NotNil(t, params.RootURI)
+	assert.Equal(t, "file:///app", *params.RootURI)
+	require.NotNil(t, params.Capabilities.TextDocument)
+	assert.True(t, *params.Capabilities.TextDocument.Completion.CompletionItem.SnippetSupport)
+	assert.NotNil(t, params.Capabilities.TextDocument.Diagnostic)
+	assert.Equal(t, []string{"tooltip"}, params.Capabilities.TextDocument.InlayHint.ResolveSupport.Properties)
+}
+
+func TestInitializeParamsDecodeThePositionEncodings(t *testing.T) {
+	var params InitializeParams
+	require.NoError(t, json.Unmarshal([]byte(`{"capabilities": {"general": {"positionEncodings": ["utf-8", "utf-16"]}}}`), &params))
+	require.NotNil(t, params.Capabilities.General)
+	assert.Equal(t, []PositionEncodingKind{PositionEncodingKindUTF8, PositionEncodingKindUTF16}, params.Capabilities.General.PositionEncodings)
+}
+
+func TestInitializeParamsDecodeTheWorkDoneToken(t *testing.T) {
+	var params InitializeParams
+	require.NoError(t, json.Unmarshal([]byte(`{"workDoneToken": "init"}`), &params))
+	require.NotNil(t, params.WorkDoneToken)
+	assert.Equal(t, "init", params.WorkDoneToken.Value)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"workDoneToken": 7}`), &params))
+	assert.Equal(t, protocol.Integer(7), params.WorkDoneToken.Value)
+
+	data, err := json.Marshal(params.WorkDoneToken)
+	require.NoError(t, err)
+	assert.Equal(t, "7", string(data))
+}
+
+func TestHandlerDispatches317Requests(t *testing.T) {
+	var hintParams *InlayHintParams
+	h := &Handler{
+		Initialize: func(_ *glsp.Context, _ *InitializeParams) (any, error) { return nil, nil },
+		TextDocumentInlayHint: func(_ *glsp.Context, params *InlayHintParams) ([]InlayHint, error) {
+			hintParams = params
+			return []InlayHint{{Label: "App\\Foo"}}, nil
+		},
+	}
+
+	_, _, _, err := h.Handle(&glsp.Context{Method: MethodTextDocumentInlayHint, Params: json.RawMessage(`{}`)})
+	assert.EqualError(t, err, "server not initialized")
+
+	_, validMethod, _, err := h.Handle(&glsp.Context{Method: protocol.MethodInitialize, Params: json.RawMessage(`{}`)})
+	require.NoError(t, err)
+	assert.True(t, validMethod)
+
+	r, validMethod, validParams, err := h.Handle(&glsp.Context{
+		Method: MethodTextDocumentInlayHint,
+		Params: json.RawMessage(`{"textDocument": {"uri": "file:///app/config/services.yaml"}, "range": {"start": {"line": 0, "character": 0}, "end": {"line": 9, "character": 0}}}`),
+	})
+	require.NoError(t, err)
+	assert.True(t, validMethod)
+	assert.True(t, validParams)
+	assert.Equal(t, "file:///app/config/services.yaml", hintParams.TextDocument.URI)
+	assert.Equal(t, []InlayHint{{Label: "App\\Foo"}}, r)
+
+	_, validMethod, _, _ = h.Handle(&glsp.Context{Method: MethodTextDocumentInlineValue, Params: json.RawMessage(`{}`)})
+	assert.False(t, validMethod)
+
+	caps := h.CreateServerCapabilities()
+	assert.Equal(t, true, caps.InlayHintProvider)
+	assert.Nil(t, caps.DiagnosticProvider)
+	assert.Nil(t, caps.TypeHierarchyProvider)
+}
+
+func TestHandlerDecodesTheCancelledProgressToken(t *testing.T) {
+	var token any
+	h := &Handler{
+		Initialize: func(_ *glsp.Context, _ *InitializeParams) (any, error) { return nil, nil },
+		WindowWorkDoneProgressCancel: func(_ *glsp.Context, params *WorkDoneProgressCancelParams) error {
+			token = params.Token.Value
+			return nil
+		},
+	}
+	_, _, _, err := h.Handle(&glsp.Context{Method: protocol.MethodInitialize, Params: json.RawMessage(`{}`)})
+	require.NoError(t, err)
+
+	_, validMethod, validParams, err := h.Handle(&glsp.Context{
+		Method: protocol.MethodWindowWorkDoneProgressCancel,
+		Params: json.RawMessage(`{"token": "vimfony.indexing"}`),
+	})
+	require.NoError(t, err)
+	assert.True(t, validMethod)
+	assert.True(t, validParams)
+	assert.Equal(t, "vimfony.indexing", token)
+}
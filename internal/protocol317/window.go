@@ -0,0 +1,17 @@
+package protocol317
+
+import (
+	"github.com/tliron/glsp"
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#window_workDoneProgress_cancel
+//
+// Replaces the params of glsp, whose token loses its value when decoded
+type WorkDoneProgressCancelParams struct {
+	/**
+	 * The token to be used to report progress.
+	 */
+	Token ProgressToken `json:"token"`
+}
+
+type WindowWorkDoneProgressCancelFunc func(context *glsp.Context, params *WorkDoneProgressCancelParams) error
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/doctrine"
+	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// A Symfony app of the workspace. Repositories with several apps, each with
+// its own composer.json and var/cache, get one per app with the container,
+// routes, templates and autoload map of that app.
+type app struct {
+	config   *config.Config
+	docStore *php.DocumentStore
+	doctrine *doctrine.Registry
+	watcher  *config.ArtifactWatcher
+	// loaded is closed once an app found after initialize is loaded
+	loaded chan struct{}
+}
+
+func newApp(cfg *config.Config) *app {
+	return &app{
+		config:   cfg,
+		docStore: php.NewDocumentStore(cfg.DocumentMemory),
+		doctrine: doctrine.NewRegistry(),
+	}
+}
+
+// Returns the app of the file at path, loading it the first time one of its
+// files is opened. The loading holds no lock, the other callers asking for
+// the same app wait for it.
+func (s *Server) appFor(path string) *app {
+	root := s.appRoot(path)
+
+	s.appsMu.Lock()
+	a, ok := s.apps[root]
+	if !ok && len(s.apps) == 0 {
+		// Not initialized yet
+		s.appsMu.Unlock()
+		return s.root
+	}
+	if !ok {
+		a = newApp(config.NewConfig())
+		a.loaded = make(chan struct{})
+		s.apps[root] = a
+	}
+	initOptions := s.initOptions
+	s.appsMu.Unlock()
+
+	if ok {
+		if a.loaded != nil {
+			<-a.loaded
+		}
+		return a
+	}
+	configureApp(a.config, root, initOptions)
+	// The capabilities follow the features of the workspace
+	a.config.Features = s.config.Features
+	s.loadApp(a, "app "+root, nil)
+	close(a.loaded)
+	return a
+}
+
+// Returns the app of the file at path if it is loaded, without loading it
+func (s *Server) loadedAppFor(path string) *app {
+	root := s.appRoot(path)
+	s.appsMu.Lock()
+	defer s.appsMu.Unlock()
+	a := s.apps[root]
+	if a == nil || a.loaded == nil {
+		return a
+	}
+	select {
+	case <-a.loaded:
+		return a
+	default:
+		return nil
+	}
+}
+
+// Returns the root of the app of the file at path, looking it up once per
+// directory
+func (s *Server) appRoot(path string) string {
+	dir := filepath.Dir(filepath.Clean(path))
+	s.appsMu.Lock()
+	root, ok := s.appRoots[dir]
+	workspaceRoot := s.config.Container.WorkspaceRoot
+	s.appsMu.Unlock()
+	if ok {
+		return root
+	}
+
+	root = config.FindAppRoot(path, workspaceRoot)
+	s.appsMu.Lock()
+	s.appRoots[dir] = root
+	s.appsMu.Unlock()
+	return root
+}
+
+// Returns the apps loaded so far, the one of the workspace root first
+func (s *Server) loadedApps() []*app {
+	s.appsMu.Lock()
+	defer s.appsMu.Unlock()
+	roots := make([]string, 0, len(s.apps))
+	for root := range s.apps {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	apps := []*app{s.root}
+	for _, root := range roots {
+		if a := s.apps[root]; a != s.root {
+			apps = append(apps, a)
+		}
+	}
+	return apps
+}
+
+func (s *Server) loadApp(a *app, context string, progress *progress) {
+	// Bundle config diagnostics wait for their dump
+	a.config.Container.OnConfigReferenceLoaded(s.refreshDiagnostics)
+	a.config.Container.OnProblem(s.showProblem)
+	progress.next("autoload map")
+	a.config.LoadAutoloadMap()
+	s.loadContainer(a, progress)
+	progress.done()
+	logPathStats(a.config, context)
+	s.watchArtifacts(a)
+}
+
+// Loads everything read from the container and the files it points to,
+// reporting its steps to progress
+func (s *Server) loadContainer(a *app, progress *progress) {
+	cfg := a.config
+	progress.next("container")
+	cfg.Container.ResetConfigReference()
+	cfg.Container.LoadFromXML(cfg.Autoload)
+	cfg.Container.LoadServicesFromYAML()
+	if cfg.FeatureEnabled(config.FeatureTemplates) {
+		cfg.Container.LoadTwigPaths()
+		cfg.Container.LoadAssets()
+	}
+	if cfg.FeatureEnabled(config.FeatureRoutes) {
+		progress.next("routes")
+		cfg.LoadRoutesMap()
+		cfg.Container.LoadRouteUsages(cfg.Autoload)
+		cfg.Container.LoadRouteDeclarations()
+	}
+	if cfg.FeatureEnabled(config.FeatureTranslations) {
+		progress.next("translations")
+		cfg.LoadTranslations()
+	}
+	cfg.Container.LoadEnvFiles()
+	cfg.Container.LoadSecurityRoles()
+	cfg.Container.LoadTemplateVariables(cfg.Autoload)
+	if cfg.FeatureEnabled(config.FeatureTwigComponents) {
+		cfg.Container.LoadTwigComponents(cfg.Autoload)
+	}
+	a.docStore.Configure(cfg.Autoload, cfg.Container.WorkspaceRoot)
+	a.docStore.SetMemoryBudget(cfg.DocumentMemory)
+	a.doctrine.Configure(
+		cfg.Container.DoctrineDrivers,
+		cfg.Autoload,
+		cfg.Container.WorkspaceRoot,
+		a.docStore,
+		cfg.Container.ResolveTargetEntities,
+	)
+}
+
+// Rebuilds the indexes whose files change, e.g. after cache:warmup
+func (s *Server) watchArtifacts(a *app) {
+	cfg := a.config
+	// The client watching the files spares polling them
+	if cfg.WatchInterval <= 0 || !cfg.FeatureEnabled(config.FeatureWatch) || s.watchFiles {
+		return
+	}
+	a.watcher = config.NewArtifactWatcher(cfg.WatchInterval)
+	a.watcher.Watch("container", cfg.Container.ContainerArtifacts, s.reloadWith(func() { s.loadContainer(a, nil) }))
+	if cfg.FeatureEnabled(config.FeatureRoutes) {
+		a.watcher.Watch("routes", cfg.RoutesArtifacts, s.reloadWith(cfg.LoadRoutesMap))
+	}
+	a.watcher.Watch("autoload", cfg.AutoloadArtifacts, s.reloadWith(func() {
+		cfg.LoadAutoloadMap()
+		s.loadContainer(a, nil)
+		s.reindex(a)
+	}))
+	if cfg.FeatureEnabled(config.FeatureTemplates) {
+		a.watcher.Watch("assets", cfg.Container.AssetArtifacts, s.reloadWith(cfg.Container.LoadAssets))
+	}
+	if cfg.FeatureEnabled(config.FeatureTranslations) {
+		a.watcher.Watch("translations", cfg.Container.TranslationArtifacts, s.reloadWith(cfg.LoadTranslations))
+	}
+	a.watcher.Start()
+}
+
+// Shows a file or command that could not be loaded to the user, who would
+// miss it in the logs
+func (s *Server) showProblem(problem config.Problem) {
+	s.diagnosticsMu.Lock()
+	client := s.client
+	s.diagnosticsMu.Unlock()
+	if client == nil || client.Notify == nil {
+		return
+	}
+	client.Notify(protocol.ServerWindowShowMessage, protocol.ShowMessageParams{
+		Type:    protocol.MessageTypeWarning,
+		Message: lsName + ": " + problem.String(),
+	})
+}
+
+func (s *Server) reloadWith(load func()) func() {
+	return func() {
+		s.indexMu.Lock()
+		load()
+		s.indexMu.Unlock()
+		s.refreshDiagnostics()
+	}
+}
+
+// Rebuilds with load in the background: the request or notification being
+// handled holds the index read lock, which the rebuild waits for
+func (s *Server) reloadLater(load func()) {
+	s.reloads.Add(1)
+	go func() {
+		defer s.reloads.Done()
+		s.reloadWith(load)()
+	}()
+}
+
+// Applies the options of the editor, merged over the .vimfony.json of the
+// app, to the config of the app at root
+func configureApp(cfg *config.Config, root string, editorOptions any) {
+	cfg.Container.WorkspaceRoot = root
+
+	configReference := false
+	var environmentXMLPaths map[string][]string
+	if m := initializationOptions(root, editorOptions); len(m) > 0 {
+		if r, ok := m["roots"]; ok {
+			if arr, ok := r.([]any); ok {
+				var roots []string
+				for _, v := range arr {
+					if str, ok := v.(string); ok && str != "" {
+						roots = append(roots, str)
+					}
+				}
+				if len(roots) > 0 {
+					cfg.Container.Roots = roots
+				}
+			}
+		}
+		if cxp, ok := m["container_xml_path"]; ok {
+			if byEnv, ok := cxp.(map[string]any); ok {
+				environmentXMLPaths = make(map[string][]string)
+				for env, v := range byEnv {
+					if paths := toStringSlice(v); len(paths) > 0 {
+						environmentXMLPaths[env] = paths
+					}
+				}
+			} else if paths := toStringSlice(cxp); len(paths) > 0 {
+				cfg.Container.SetContainerXMLPaths(paths)
+			}
+		}
+		if env, ok := m["environment"]; ok {
+			if str, ok := env.(string); ok && str != "" {
+				cfg.Container.Environment = str
+			}
+		}
+		if pd, ok := m["public_dir"]; ok {
+			if str, ok := pd.(string); ok && str != "" {
+				cfg.Container.PublicDir = str
+			}
+		}
+		if phpp, ok := m["php_path"]; ok {
+			if str, ok := phpp.(string); ok && str != "" {
+				cfg.PhpPath = str
+			}
+		}
+		if vdp, ok := m["vendor_dir"]; ok {
+			if str, ok := vdp.(string); ok && str != "" {
+				cfg.VendorDir = str
+			}
+		}
+		if fs, ok := m["fluent_setters"]; ok {
+			if b, ok := fs.(bool); ok {
+				cfg.Container.FluentSetters = b
+			}
+		}
+		if rc, ok := m["routes_command"]; ok {
+			command := toStringSlice(rc)
+			if len(command) == 1 {
+				command = strings.Fields(command[0])
+			}
+			cfg.RoutesCommand = command
+		}
+		if cr, ok := m["config_reference"]; ok {
+			if b, ok := cr.(bool); ok && b {
+				configReference = true
+			}
+		}
+		if ddb, ok := m["diagnostics_debounce_ms"]; ok {
+			if ms, ok := ddb.(float64); ok && ms >= 0 {
+				cfg.DiagnosticsDebounce = time.Duration(ms) * time.Millisecond
+			}
+		}
+		if f, ok := m["features"]; ok {
+			if features, ok := f.(map[string]any); ok {
+				for name, v := range features {
+					enabled, ok := v.(bool)
+					if !ok || !config.IsKnownFeature(name) {
+						commonlog.GetLoggerf("vimfony.server").Warningf("ignoring feature '%s': %v", name, v)
+						continue
+					}
+					cfg.Features[name] = enabled
+				}
+			}
+		}
+		if ll, ok := m["log_level"]; ok {
+			if str, ok := ll.(string); ok {
+				cfg.LogLevel = str
+			}
+		}
+		if lf, ok := m["log_file"]; ok {
+			if str, ok := lf.(string); ok && str != "" {
+				if !filepath.IsAbs(str) {
+					str = filepath.Join(root, str)
+				}
+				cfg.LogFile = str
+			}
+		}
+		if dm, ok := m["document_memory_mb"]; ok {
+			if mb, ok := dm.(float64); ok && mb > 0 {
+				cfg.DocumentMemory = int64(mb * (1 << 20))
+			}
+		}
+		if tl, ok := m["twig_index_limit_mb"]; ok {
+			if mb, ok := tl.(float64); ok && mb >= 0 {
+				cfg.Container.TwigIndexLimit = int64(mb * (1 << 20))
+			}
+		}
+		if wi, ok := m["watch_interval_ms"]; ok {
+			if ms, ok := wi.(float64); ok && ms >= 0 {
+				cfg.WatchInterval = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if configReference {
+		cfg.Container.EnableConfigReference(cfg.PhpPath)
+	}
+	if len(environmentXMLPaths) > 0 {
+		cfg.Container.SetEnvironmentContainerXMLPaths(environmentXMLPaths)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Makes a workspace with an app at its root and another one under apps/admin
+func multiAppServer(t *testing.T) (*Server, string) {
+	root := t.TempDir()
+	for _, rel := range []string{"composer.json", "bin/console", "apps/admin/composer.json", "apps/admin/bin/console"} {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0o644))
+	}
+
+	s := NewServer()
+	s.config.Container.WorkspaceRoot = root
+	s.initOptions = map[string]any{"watch_interval_ms": float64(0)}
+	s.apps = map[string]*app{root: s.root}
+	return s, root
+}
+
+func TestAppForRoutesFilesToTheirApp(t *testing.T) {
+	s, root := multiAppServer(t)
+
+	assert.Same(t, s.root, s.appFor(filepath.Join(root, "src/Controller/HomeController.php")))
+
+	admin := s.appFor(filepath.Join(root, "apps/admin/src/Controller/UserController.php"))
+	require.NotSame(t, s.root, admin)
+	assert.Equal(t, filepath.Join(root, "apps/admin"), admin.config.Container.WorkspaceRoot)
+	assert.Same(t, admin, s.appFor(filepath.Join(root, "apps/admin/templates/user.html.twig")))
+	assert.Equal(t, []*app{s.root, admin}, s.loadedApps())
+
+	// Vendor packages belong to the app that installed them
+	assert.Same(t, admin, s.appFor(filepath.Join(root, "apps/admin/vendor/acme/bundle/composer.json")))
+}
+
+func TestAppRootIsLookedUpOncePerDirectory(t *testing.T) {
+	s, root := multiAppServer(t)
+	path := filepath.Join(root, "apps/admin/src/Kernel.php")
+	require.Equal(t, filepath.Join(root, "apps/admin"), s.appRoot(path))
+
+	// The cached root survives the app markers going away
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "apps/admin")))
+	assert.Equal(t, filepath.Join(root, "apps/admin"), s.appRoot(path))
+	assert.Equal(t, root, s.appRoot(filepath.Join(root, "apps/admin/src/Other/Kernel.php")))
+}
+
+func TestOpeningADocumentOfAnotherAppDoesNotHoldTheState(t *testing.T) {
+	s, root := multiAppServer(t)
+	opened := protocol.DocumentUri(utils.PathToURI(filepath.Join(root, "templates/base.html.twig")))
+	s.state.SetDocument(opened, "", "plaintext")
+
+	// The document store of a new document is picked while its app loads
+	s.state.SetDocumentStoreFunc(func(path string) *php.DocumentStore {
+		_, ok := s.state.GetDocument(opened)
+		assert.True(t, ok)
+		return s.appFor(path).docStore
+	})
+	uri := protocol.DocumentUri(utils.PathToURI(filepath.Join(root, "apps/admin/src/Kernel.php")))
+	s.state.SetDocument(uri, "<?php\n", "php")
+
+	_, ok := s.state.GetDocument(uri)
+	assert.True(t, ok)
+	assert.Len(t, s.loadedApps(), 2)
+}
+
+func TestInitializeShowsTheFilesThatCannotBeLoaded(t *testing.T) {
+	s := NewServer()
+	var mu sync.Mutex
+	var shown []protocol.ShowMessageParams
+	client := connectServer(t, s, func(request *jsonrpc2.Request) {
+		if request.Method != string(protocol.ServerWindowShowMessage) {
+			return
+		}
+		var params protocol.ShowMessageParams
+		require.NoError(t, json.Unmarshal(*request.Params, &params))
+		mu.Lock()
+		shown = append(shown, params)
+		mu.Unlock()
+	})
+
+	var result map[string]any
+	require.NoError(t, client.Call(context.Background(), protocol.MethodInitialize, map[string]any{
+		"rootUri":               protocol.DocumentUri(utils.PathToURI(t.TempDir())),
+		"capabilities":          map[string]any{},
+		"initializationOptions": map[string]any{"container_xml_path": "var/cache/dev/App_KernelDevDebugContainer.xml"},
+	}, &result))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, shown, 1)
+	assert.Equal(t, protocol.MessageTypeWarning, shown[0].Type)
+	assert.Contains(t, shown[0].Message, "vimfony: cannot read container_xml_path[0] 'var/cache/dev/App_KernelDevDebugContainer.xml'")
+	assert.Contains(t, shown[0].Message, "cache:warmup")
+}
@@ -1,28 +1,104 @@
 package server
 
 import (
+	"encoding/json"
+
 	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+// When the client resolves edits lazily, code actions are sent without their
+// edit and carry this data instead so the edit can be built on resolve.
+type codeActionData struct {
+	URI   protocol.DocumentUri `json:"uri"`
+	Range protocol.Range       `json:"range"`
+}
+
 func (s *Server) onCodeAction(context *glsp.Context, params *protocol.CodeActionParams) (any, error) {
-	doc, ok := s.state.GetDocument(params.TextDocument.URI)
-	if !ok {
+	codeActions, err := s.collectCodeActions(context, params)
+	if err != nil || len(codeActions) == 0 {
+		return nil, err
+	}
+
+	result := make([]protocol.CodeAction, 0, len(codeActions))
+	for _, codeAction := range codeActions {
+		if s.resolveCodeActions {
+			action := codeAction.CodeAction
+			action.Data = codeActionData{URI: params.TextDocument.URI, Range: params.Range}
+			result = append(result, action)
+			continue
+		}
+		// Without resolve the edit is built now, and an action that turns
+		// out to have none is left out rather than offered as a no-op
+		if action := codeAction.Resolve(); action.Edit != nil {
+			result = append(result, action)
+		}
+	}
+	if len(result) == 0 {
 		return nil, nil
 	}
+	return result, nil
+}
+
+func (s *Server) onCodeActionResolve(context *glsp.Context, action *protocol.CodeAction) (*protocol.CodeAction, error) {
+	if action.Data == nil || action.Edit != nil {
+		return action, nil
+	}
+
+	raw, err := json.Marshal(action.Data)
+	if err != nil {
+		return action, nil
+	}
+	var data codeActionData
+	if err := json.Unmarshal(raw, &data); err != nil || data.URI == "" {
+		return action, nil
+	}
 
-	if doc.Analyzer != nil {
-		if provider, ok := doc.Analyzer.(analyzer.CodeActionProvider); ok {
-			codeActions, err := provider.OnCodeAction(context, params)
-			if err != nil {
-				return nil, err
-			}
-			if len(codeActions) > 0 {
-				return codeActions, nil
-			}
+	codeActions, err := s.collectCodeActions(context, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: data.URI},
+		Range:        data.Range,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range codeActions {
+		if candidate.Title == action.Title {
+			// Only the chosen action builds its edit
+			action.Edit = candidate.Resolve().Edit
+			break
 		}
 	}
+	return action, nil
+}
+
+func (s *Server) collectCodeActions(context *glsp.Context, params *protocol.CodeActionParams) ([]analyzer.CodeAction, error) {
+	doc, ok := s.state.GetDocument(params.TextDocument.URI)
+	if !ok || doc.Analyzer == nil || !s.config.FeatureEnabled(config.FeatureCodeActions) {
+		return nil, nil
+	}
 
+	if provider, ok := doc.Analyzer.(analyzer.CodeActionProvider); ok {
+		return provider.OnCodeAction(s.requestContext(context), params)
+	}
 	return nil, nil
 }
+
+// Reports whether the client can resolve the edit of a code action lazily
+func clientResolvesCodeActionEdits(capabilities protocol317.ClientCapabilities) bool {
+	if capabilities.TextDocument == nil || capabilities.TextDocument.CodeAction == nil {
+		return false
+	}
+	codeAction := capabilities.TextDocument.CodeAction
+	if codeAction.ResolveSupport == nil || codeAction.DataSupport == nil || !*codeAction.DataSupport {
+		return false
+	}
+	for _, property := range codeAction.ResolveSupport.Properties {
+		if property == "edit" {
+			return true
+		}
+	}
+	return false
+}
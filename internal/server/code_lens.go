@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/twig"
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) onCodeLens(_ *glsp.Context, params *protocol.CodeLensParams) ([]protocol.CodeLens, error) {
+	doc, ok := s.state.GetDocument(params.TextDocument.URI)
+	if !ok || doc.Analyzer == nil || !s.config.FeatureEnabled(config.FeatureCodeLens) {
+		return nil, nil
+	}
+
+	if provider, ok := doc.Analyzer.(analyzer.CodeLensProvider); ok {
+		return provider.OnCodeLens()
+	}
+	return nil, nil
+}
+
+// Returns the places that generate the route of a code lens
+func routeUsageLocations(arguments []any, cfg *config.ContainerConfig) ([]protocol.Location, error) {
+	name := ""
+	if len(arguments) > 0 {
+		name, _ = arguments[0].(string)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("missing route name")
+	}
+	return cfg.RouteUsagesOf(name), nil
+}
+
+// Returns the location of the template of a code lens, creating an empty file
+// for it when create is set and the template does not exist yet
+func templateCommandLocation(arguments []any, cfg *config.ContainerConfig, create bool) (any, error) {
+	name := ""
+	if len(arguments) > 0 {
+		name, _ = arguments[0].(string)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("missing template name")
+	}
+
+	path, ok := twig.Resolve(name, cfg)
+	if !ok {
+		if !create {
+			return nil, fmt.Errorf("template '%s' not found", name)
+		}
+		if path, ok = twig.NewTemplatePath(name, cfg); !ok {
+			return nil, fmt.Errorf("cannot create template '%s' outside of the Twig paths", name)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil && !os.IsExist(err) {
+			return nil, err
+		}
+		if f != nil {
+			f.Close()
+			cfg.TwigTemplateCreated(path)
+		}
+	}
+	return protocol.Location{URI: utils.PathToURI(path)}, nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Executes a command the way the handler does, holding the index read lock
+func executeCommand(s *Server, command string, arguments ...any) error {
+	_, err := executeCommandResult(s, command, arguments...)
+	return err
+}
+
+func executeCommandResult(s *Server, command string, arguments ...any) (any, error) {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	return s.onExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: command, Arguments: arguments})
+}
+
+func environment(s *Server) string {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	return s.config.Container.Environment
+}
+
+func TestSwitchEnvironmentReloadsAfterTheRequest(t *testing.T) {
+	s := NewServer()
+	s.config.Container.WorkspaceRoot = t.TempDir()
+	s.config.Container.SetEnvironmentContainerXMLPaths(map[string][]string{
+		"dev":  {"var/cache/dev/App_KernelDevDebugContainer.xml"},
+		"prod": {"var/cache/prod/App_KernelProdContainer.xml"},
+	})
+	require.Equal(t, "dev", environment(s))
+
+	done := make(chan error, 1)
+	go func() { done <- executeCommand(s, commandSwitchEnvironment, "prod") }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the command waits for the index lock it holds")
+	}
+
+	require.Eventually(t, func() bool { return environment(s) == "prod" }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"var/cache/prod/App_KernelProdContainer.xml"}, s.config.Container.ContainerXMLPaths)
+}
+
+func TestSwitchEnvironmentRejectsUnknownEnvironments(t *testing.T) {
+	s := NewServer()
+	s.config.Container.SetEnvironmentContainerXMLPaths(map[string][]string{
+		"dev": {"var/cache/dev/App_KernelDevDebugContainer.xml"},
+	})
+
+	err := executeCommand(s, commandSwitchEnvironment, "staging")
+	require.Error(t, err)
+	assert.Equal(t, "dev", environment(s))
+}
+
+func TestReloadRoutesDoesNotBlockTheRequest(t *testing.T) {
+	s := NewServer()
+	s.config.Container.WorkspaceRoot = t.TempDir()
+
+	done := make(chan error, 1)
+	go func() { done <- executeCommand(s, commandReloadRoutes) }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the command waits for the index lock it holds")
+	}
+	s.reloads.Wait()
+}
+
+func TestRouteUsagesFollowEdits(t *testing.T) {
+	s := NewServer()
+	root := t.TempDir()
+	s.config.Container.WorkspaceRoot = root
+	uri := protocol.DocumentUri(utils.PathToURI(filepath.Join(root, "templates/post.html.twig")))
+	lensURI := utils.PathToURI(filepath.Join(root, "src/Controller/PostController.php"))
+
+	require.NoError(t, s.didOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, LanguageID: "plaintext", Text: ""},
+	}))
+	require.NoError(t, s.didChange(nil, &protocol.DidChangeTextDocumentParams{
+		TextDocument:   protocol.VersionedTextDocumentIdentifier{TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri}},
+		ContentChanges: []any{&protocol.TextDocumentContentChangeEventWhole{Text: "{{ path('post_show') }}"}},
+	}))
+
+	result, err := executeCommandResult(s, analyzer.CommandRouteUsages, "post_show", lensURI)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, uri, result.([]protocol.Location)[0].URI)
+
+	result, err = executeCommandResult(s, analyzer.CommandRouteUsages, "post_list", lensURI)
+	require.NoError(t, err)
+	assert.Equal(t, []protocol.Location{}, result)
+
+	_, err = executeCommandResult(s, analyzer.CommandRouteUsages)
+	assert.Error(t, err)
+}
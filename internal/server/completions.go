@@ -2,19 +2,21 @@ package server
 
 import (
 	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
-func (s *Server) onCompletion(_ *glsp.Context, p *protocol.CompletionParams) (any, error) {
+func (s *Server) onCompletion(context *glsp.Context, p *protocol.CompletionParams) (any, error) {
 	doc, ok := s.state.GetDocument(p.TextDocument.URI)
-	if !ok {
+	if !ok || !s.config.FeatureEnabled(config.FeatureCompletion) {
 		return nil, nil
 	}
 
 	if doc.Analyzer != nil {
 		if cp, ok := doc.Analyzer.(analyzer.CompletionProvider); ok {
-			completions, err := cp.OnCompletion(p.Position)
+			completions, err := cp.OnCompletion(s.requestContext(context), p.Position)
 			if err != nil {
 				return nil, err
 			}
@@ -26,3 +28,12 @@ func (s *Server) onCompletion(_ *glsp.Context, p *protocol.CompletionParams) (an
 
 	return nil, nil
 }
+
+// Reports whether the client accepts completion items with snippet syntax
+func clientSupportsSnippets(capabilities protocol317.ClientCapabilities) bool {
+	if capabilities.TextDocument == nil || capabilities.TextDocument.Completion == nil {
+		return false
+	}
+	item := capabilities.TextDocument.Completion.CompletionItem
+	return item != nil && item.SnippetSupport != nil && *item.SnippetSupport
+}
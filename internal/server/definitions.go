@@ -2,19 +2,20 @@ package server
 
 import (
 	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
-func (s *Server) onDefinition(_ *glsp.Context, params *protocol.DefinitionParams) (any, error) {
+func (s *Server) onDefinition(context *glsp.Context, params *protocol.DefinitionParams) (any, error) {
 	doc, ok := s.state.GetDocument(params.TextDocument.URI)
-	if !ok {
+	if !ok || !s.config.FeatureEnabled(config.FeatureDefinition) {
 		return nil, nil
 	}
 
 	if doc.Analyzer != nil {
 		if provider, ok := doc.Analyzer.(analyzer.DefinitionProvider); ok {
-			locations, err := provider.OnDefinition(params.Position)
+			locations, err := provider.OnDefinition(s.requestContext(context), params.Position)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) onDiagnostic(_ *glsp.Context, params *protocol317.DocumentDiagnosticParams) (any, error) {
+	return protocol317.FullDocumentDiagnosticReport{
+		Kind:  protocol317.DocumentDiagnosticReportKindFull,
+		Items: s.collectDiagnostics(params.TextDocument.URI),
+	}, nil
+}
+
+func (s *Server) collectDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic {
+	diagnostics := []protocol.Diagnostic{}
+	doc, ok := s.state.GetDocument(uri)
+	if !ok || doc.Analyzer == nil || !s.config.FeatureEnabled(config.FeatureDiagnostics) {
+		return diagnostics
+	}
+	if provider, ok := doc.Analyzer.(analyzer.DiagnosticsProvider); ok {
+		found, err := provider.OnDiagnostics()
+		if err == nil && len(found) > 0 {
+			diagnostics = found
+		}
+	}
+	return diagnostics
+}
+
+// scheduleDiagnostics publishes diagnostics once the document has been quiet for
+// the configured debounce window. Clients that pull diagnostics are left alone.
+func (s *Server) scheduleDiagnostics(context *glsp.Context, uri protocol.DocumentUri) {
+	if s.pullDiagnostics {
+		return
+	}
+
+	delay := s.config.DiagnosticsDebounce
+	if delay <= 0 {
+		s.publishDiagnostics(context, uri)
+		return
+	}
+
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+	if timer, ok := s.diagnosticTimers[uri]; ok {
+		timer.Stop()
+	}
+	s.diagnosticTimers[uri] = time.AfterFunc(delay, func() {
+		s.diagnosticsMu.Lock()
+		delete(s.diagnosticTimers, uri)
+		s.diagnosticsMu.Unlock()
+		s.indexMu.RLock()
+		defer s.indexMu.RUnlock()
+		s.publishDiagnostics(context, uri)
+	})
+}
+
+func (s *Server) cancelDiagnostics(uri protocol.DocumentUri) {
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+	if timer, ok := s.diagnosticTimers[uri]; ok {
+		timer.Stop()
+		delete(s.diagnosticTimers, uri)
+	}
+}
+
+// refreshDiagnostics brings the diagnostics of the open documents up to date
+// after an index was rebuilt. Clients that pull diagnostics are asked to pull
+// them again.
+func (s *Server) refreshDiagnostics() {
+	s.diagnosticsMu.Lock()
+	client := s.client
+	s.diagnosticsMu.Unlock()
+	if client == nil {
+		return
+	}
+
+	if s.pullDiagnostics {
+		if s.diagnosticsRefresh && client.Call != nil {
+			// The client answers once it has pulled again, which may be after
+			// requests that wait for the index lock
+			go func() {
+				var result any
+				client.Call(string(protocol317.MethodWorkspaceDiagnosticRefresh), nil, &result)
+			}()
+		}
+		return
+	}
+
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	for _, uri := range s.state.URIs() {
+		s.publishDiagnostics(client, uri)
+	}
+}
+
+func (s *Server) publishDiagnostics(context *glsp.Context, uri protocol.DocumentUri) {
+	if s.pullDiagnostics || context == nil || context.Notify == nil {
+		return
+	}
+
+	context.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: s.collectDiagnostics(uri),
+	})
+}
+
+// Reports whether the client pulls diagnostics again when asked to
+func clientRefreshesDiagnostics(capabilities protocol317.ClientCapabilities) bool {
+	if capabilities.Workspace == nil || capabilities.Workspace.Diagnostics == nil {
+		return false
+	}
+	refresh := capabilities.Workspace.Diagnostics.RefreshSupport
+	return refresh != nil && *refresh
+}
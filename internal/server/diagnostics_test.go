@@ -0,0 +1,142 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// recordingClient is a glsp context that records what the server sends
+type recordingClient struct {
+	mu       sync.Mutex
+	notified []protocol.PublishDiagnosticsParams
+	called   []string
+}
+
+func (c *recordingClient) context() *glsp.Context {
+	return &glsp.Context{
+		Notify: func(method string, params any) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if p, ok := params.(protocol.PublishDiagnosticsParams); ok && method == protocol.ServerTextDocumentPublishDiagnostics {
+				c.notified = append(c.notified, p)
+			}
+		},
+		Call: func(method string, params any, result any) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.called = append(c.called, method)
+		},
+	}
+}
+
+func (c *recordingClient) published() []protocol.PublishDiagnosticsParams {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]protocol.PublishDiagnosticsParams(nil), c.notified...)
+}
+
+func (c *recordingClient) calls() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.called...)
+}
+
+const diagnosticsURI = protocol.DocumentUri("file:///app/templates/base.html.twig")
+
+func TestScheduleDiagnosticsDebouncesChanges(t *testing.T) {
+	s := NewServer()
+	s.config.DiagnosticsDebounce = 50 * time.Millisecond
+	client := &recordingClient{}
+	ctx := client.context()
+
+	for range 3 {
+		s.scheduleDiagnostics(ctx, diagnosticsURI)
+	}
+	assert.Empty(t, client.published())
+
+	require.Eventually(t, func() bool { return len(client.published()) > 0 }, time.Second, 5*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	published := client.published()
+	require.Len(t, published, 1)
+	assert.Equal(t, diagnosticsURI, published[0].URI)
+}
+
+func TestScheduleDiagnosticsWithoutDebouncePublishesAtOnce(t *testing.T) {
+	s := NewServer()
+	s.config.DiagnosticsDebounce = 0
+	client := &recordingClient{}
+
+	s.scheduleDiagnostics(client.context(), diagnosticsURI)
+
+	assert.Len(t, client.published(), 1)
+}
+
+func TestCancelDiagnosticsStopsPendingPublish(t *testing.T) {
+	s := NewServer()
+	s.config.DiagnosticsDebounce = 20 * time.Millisecond
+	client := &recordingClient{}
+
+	s.scheduleDiagnostics(client.context(), diagnosticsURI)
+	s.cancelDiagnostics(diagnosticsURI)
+	time.Sleep(60 * time.Millisecond)
+
+	assert.Empty(t, client.published())
+}
+
+func TestPullClientsAreNotPushedDiagnostics(t *testing.T) {
+	s := NewServer()
+	s.pullDiagnostics = true
+	s.config.DiagnosticsDebounce = 0
+	client := &recordingClient{}
+
+	s.scheduleDiagnostics(client.context(), diagnosticsURI)
+	s.publishDiagnostics(client.context(), diagnosticsURI)
+
+	assert.Empty(t, client.published())
+}
+
+func TestReloadRefreshesPullClients(t *testing.T) {
+	s := NewServer()
+	s.pullDiagnostics = true
+	s.diagnosticsRefresh = true
+	client := &recordingClient{}
+	s.client = client.context()
+
+	s.reloadWith(func() {})()
+
+	require.Eventually(t, func() bool { return len(client.calls()) > 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{string(protocol317.MethodWorkspaceDiagnosticRefresh)}, client.calls())
+	assert.Empty(t, client.published())
+}
+
+func TestReloadRepublishesOpenDocuments(t *testing.T) {
+	s := NewServer()
+	client := &recordingClient{}
+	s.client = client.context()
+	s.state.SetDocument(diagnosticsURI, "", "plaintext")
+
+	s.reloadWith(func() {})()
+
+	published := client.published()
+	require.Len(t, published, 1)
+	assert.Equal(t, diagnosticsURI, published[0].URI)
+	assert.Empty(t, client.calls())
+}
+
+func TestClientRefreshesDiagnostics(t *testing.T) {
+	refresh := true
+	capabilities := protocol317.ClientCapabilities{
+		Workspace: &protocol317.WorkspaceClientCapabilities{
+			Diagnostics: &protocol317.DiagnosticWorkspaceClientCapabilities{RefreshSupport: &refresh},
+		},
+	}
+	assert.True(t, clientRefreshesDiagnostics(capabilities))
+	assert.False(t, clientRefreshesDiagnostics(protocol317.ClientCapabilities{}))
+}
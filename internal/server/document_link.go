@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) onDocumentLink(_ *glsp.Context, params *protocol.DocumentLinkParams) ([]protocol.DocumentLink, error) {
+	doc, ok := s.state.GetDocument(params.TextDocument.URI)
+	if !ok || doc.Analyzer == nil || !s.config.FeatureEnabled(config.FeatureDocumentLinks) {
+		return nil, nil
+	}
+
+	if provider, ok := doc.Analyzer.(analyzer.DocumentLinkProvider); ok {
+		return provider.OnDocumentLinks()
+	}
+	return nil, nil
+}
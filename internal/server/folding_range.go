@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) onFoldingRange(_ *glsp.Context, params *protocol.FoldingRangeParams) ([]protocol.FoldingRange, error) {
+	doc, ok := s.state.GetDocument(params.TextDocument.URI)
+	if !ok || doc.Analyzer == nil || !s.config.FeatureEnabled(config.FeatureFoldingRanges) {
+		return nil, nil
+	}
+
+	if provider, ok := doc.Analyzer.(analyzer.FoldingRangeProvider); ok {
+		return provider.OnFoldingRanges()
+	}
+	return nil, nil
+}
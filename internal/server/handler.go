@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/tliron/glsp"
+)
+
+// handler serves the 3.17 protocol, keeping requests out while an index is
+// being rebuilt.
+type handler struct {
+	protocol317.Handler
+	server *Server
+}
+
+func (h *handler) Handle(context *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	h.server.indexMu.RLock()
+	defer h.server.indexMu.RUnlock()
+
+	return h.Handler.Handle(context)
+}
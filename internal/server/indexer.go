@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/tliron/commonlog"
+	"github.com/tliron/glsp"
+)
+
+const indexingToken = "vimfony.indexing"
+
+// The most sources parsed ahead, so that on large projects they leave most of
+// the memory of the document store to the documents parsed on demand
+const maxIndexedSources = 500
+
+// startIndexing indexes the classes of every PSR-4 directory of app a, then
+// parses its controllers, form types and twig extensions in the background,
+// so that the first requests about them do not wait for their parse. It stops
+// the indexing started before.
+func (s *Server) startIndexing(client *glsp.Context, a *app) {
+	s.stopIndexing()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.indexerMu.Lock()
+	s.cancelIndexing = cancel
+	s.indexerMu.Unlock()
+
+	s.indexer.Add(1)
+	go func() {
+		defer s.indexer.Done()
+		defer cancel()
+		s.indexClasses(ctx, a)
+		s.indexSources(ctx, client, a)
+	}()
+}
+
+// stopIndexing cancels the background indexing, which stops after the file
+// it parses. It does not wait for it: the indexing may wait for an answer of
+// the client read by the caller.
+func (s *Server) stopIndexing() {
+	s.indexerMu.Lock()
+	if s.cancelIndexing != nil {
+		s.cancelIndexing()
+		s.cancelIndexing = nil
+	}
+	s.indexerMu.Unlock()
+}
+
+// reindex restarts the indexing of app a after its autoload map was reloaded
+// without the classes of the dependencies. Only the root app is indexed.
+func (s *Server) reindex(a *app) {
+	if a == s.root {
+		s.startIndexing(s.client, a)
+	}
+}
+
+// cancelWorkDoneProgress stops the indexing when the user cancels its progress
+func (s *Server) cancelWorkDoneProgress(_ *glsp.Context, p *protocol317.WorkDoneProgressCancelParams) error {
+	if token, ok := p.Token.Value.(string); ok && token == indexingToken {
+		s.stopIndexing()
+	}
+	return nil
+}
+
+// Reports whether the client shows the progress the server creates
+func clientShowsWorkDoneProgress(capabilities protocol317.ClientCapabilities) bool {
+	window := capabilities.Window
+	return window != nil && window.WorkDoneProgress != nil && *window.WorkDoneProgress
+}
+
+// Replaces the class index of app a, which loading the autoload map limits
+// to the classmap and the sources of the project, with the one of every PSR-4
+// directory. Completion, the use imports and the class resolution then skip
+// the filesystem for the classes of the dependencies too.
+func (s *Server) indexClasses(ctx context.Context, a *app) {
+	s.indexMu.RLock()
+	autoload, root := a.config.Autoload, a.config.Container.WorkspaceRoot
+	s.indexMu.RUnlock()
+	if autoload.IsEmpty() {
+		return
+	}
+	classes, files := config.BuildClassIndex(autoload, root)
+
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	// Cancelled by a reload of the autoload map, the index would be stale
+	if ctx.Err() != nil {
+		return
+	}
+	a.config.Autoload.Classes = classes
+	a.config.Autoload.Files = files
+	a.docStore.Configure(a.config.Autoload, root)
+	commonlog.GetLoggerf("vimfony.server").Infof("indexed %d classes", len(files))
+}
+
+// Parses the indexed sources of app a into its document store until ctx is
+// cancelled, reporting on a progress created on the client when it shows them
+func (s *Server) indexSources(ctx context.Context, client *glsp.Context, a *app) {
+	s.indexMu.RLock()
+	paths := indexedSources(a.config)
+	s.indexMu.RUnlock()
+	if len(paths) == 0 {
+		return
+	}
+
+	var progress *progress
+	if s.workDoneProgress {
+		progress = createProgress(client, indexingToken, len(paths))
+	}
+	defer progress.done()
+
+	done, reported := 0, -1
+	progress.report(fmt.Sprintf("indexing sources (0/%d)", len(paths)), 0)
+	a.docStore.Prefetch(ctx, paths, func(path string, err error) {
+		if err != nil {
+			commonlog.GetLoggerf("vimfony.server").Debugf("cannot index %s: %v", path, err)
+		}
+		done++
+		// Once per percent, the client does not need more
+		if percent := 100 * done / len(paths); percent != reported && done < len(paths) {
+			reported = percent
+			progress.report(fmt.Sprintf("indexing sources (%d/%d)", done, len(paths)), done)
+		}
+	})
+	if done < len(paths) {
+		commonlog.GetLoggerf("vimfony.server").Infof("indexing cancelled after %d of %d files", done, len(paths))
+	}
+}
+
+// Returns the PHP files of the app the first requests usually need, up to
+// maxIndexedSources
+func indexedSources(cfg *config.Config) []string {
+	var paths []string
+	cfg.Container.WalkAppSources(cfg.Autoload, func(path string) {
+		if len(paths) >= maxIndexedSources {
+			return
+		}
+		if rel, err := filepath.Rel(cfg.Container.WorkspaceRoot, path); err == nil && isIndexedSource(filepath.ToSlash(rel)) {
+			paths = append(paths, path)
+		}
+	})
+	return paths
+}
+
+// Reports whether the PHP file at rel, relative to the app root, is a
+// controller, a form type or a twig extension
+func isIndexedSource(rel string) bool {
+	dir, name := filepath.Split("/" + rel)
+	switch {
+	case strings.Contains(dir, "/Controller/"):
+		return true
+	case strings.Contains(dir, "/Form/"):
+		return strings.HasSuffix(name, "Type.php")
+	case strings.Contains(dir, "/Twig/"):
+		return true
+	}
+	return strings.HasSuffix(name, "TwigExtension.php")
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Returns a server whose root app has a controller, a form type, a twig
+// extension and an entity under src/
+func indexedServer(t *testing.T) (*Server, string) {
+	s, root := multiAppServer(t)
+	for _, rel := range []string{
+		"src/Controller/HomeController.php",
+		"src/Form/UserType.php",
+		"src/Twig/AppExtension.php",
+		"src/Entity/User.php",
+		"vendor/acme/bundle/src/Controller/AcmeController.php",
+	} {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("<?php\n"), 0o644))
+	}
+	s.config.Autoload.PSR4 = map[string][]string{
+		"App\\":  {"src"},
+		"Acme\\": {"vendor/acme/bundle/src"},
+	}
+	return s, root
+}
+
+// Returns a client context recording the progress it is sent
+func progressClient(t *testing.T, onCreate func()) (*glsp.Context, func() []any) {
+	var mu sync.Mutex
+	var kinds []any
+	return &glsp.Context{
+		Call: func(method string, _ any, _ any) {
+			if method == protocol.ServerWindowWorkDoneProgressCreate && onCreate != nil {
+				onCreate()
+			}
+		},
+		Notify: func(method string, params any) {
+			if p, ok := params.(protocol.ProgressParams); ok && method == protocol.MethodProgress {
+				assert.Equal(t, indexingToken, p.Token.Value)
+				mu.Lock()
+				switch value := p.Value.(type) {
+				case protocol.WorkDoneProgressBegin:
+					kinds = append(kinds, value.Kind)
+				case protocol.WorkDoneProgressReport:
+					kinds = append(kinds, *value.Message)
+				case protocol.WorkDoneProgressEnd:
+					kinds = append(kinds, value.Kind)
+				}
+				mu.Unlock()
+			}
+		},
+	}, func() []any {
+		mu.Lock()
+		defer mu.Unlock()
+		return kinds
+	}
+}
+
+func TestIndexingParsesControllersFormTypesAndTwigExtensions(t *testing.T) {
+	s, root := indexedServer(t)
+	s.workDoneProgress = true
+	client, reported := progressClient(t, nil)
+
+	s.startIndexing(client, s.root)
+	s.indexer.Wait()
+
+	for _, rel := range []string{"src/Controller/HomeController.php", "src/Form/UserType.php", "src/Twig/AppExtension.php"} {
+		assert.True(t, s.root.docStore.Contains(filepath.Join(root, rel)), rel)
+	}
+	assert.False(t, s.root.docStore.Contains(filepath.Join(root, "src/Entity/User.php")))
+	assert.False(t, s.root.docStore.Contains(filepath.Join(root, "vendor/acme/bundle/src/Controller/AcmeController.php")))
+	assert.Equal(t, []any{
+		"begin",
+		"indexing sources (0/3)",
+		"indexing sources (1/3)",
+		"indexing sources (2/3)",
+		"end",
+	}, reported())
+}
+
+func TestIndexingIndexesTheClassesOfTheDependencies(t *testing.T) {
+	s, root := indexedServer(t)
+
+	s.startIndexing(nil, s.root)
+	s.indexer.Wait()
+
+	autoload := s.config.Autoload
+	assert.Equal(t, []string{"Acme\\Controller\\AcmeController"}, autoload.Classes.Lookup("AcmeController"))
+	assert.Equal(t, []string{"App\\Entity\\User"}, autoload.Classes.Lookup("User"))
+	assert.Equal(t, filepath.Join(root, "vendor/acme/bundle/src/Controller/AcmeController.php"), autoload.Files["Acme\\Controller\\AcmeController"])
+	storeAutoload, _ := s.root.docStore.Config()
+	assert.Equal(t, autoload.Files, storeAutoload.Files)
+}
+
+func TestCancellingTheIndexingProgressStopsIt(t *testing.T) {
+	s, root := indexedServer(t)
+	s.workDoneProgress = true
+	client, reported := progressClient(t, func() {
+		require.NoError(t, s.cancelWorkDoneProgress(nil, &protocol317.WorkDoneProgressCancelParams{
+			Token: protocol317.ProgressToken{Value: indexingToken},
+		}))
+	})
+
+	s.startIndexing(client, s.root)
+	s.indexer.Wait()
+
+	assert.False(t, s.root.docStore.Contains(filepath.Join(root, "src/Controller/HomeController.php")))
+	assert.Equal(t, []any{"begin", "indexing sources (0/3)", "end"}, reported())
+}
+
+func TestIsIndexedSource(t *testing.T) {
+	for rel, indexed := range map[string]bool{
+		"src/Controller/HomeController.php":        true,
+		"src/Controller/Admin/UserController.php":  true,
+		"Controller/HomeController.php":            true,
+		"src/Form/UserType.php":                    true,
+		"src/Form/DataTransformer/Transformer.php": false,
+		"src/Twig/Components/Alert.php":            true,
+		"src/Extension/MarkdownTwigExtension.php":  true,
+		"src/Entity/User.php":                      false,
+	} {
+		assert.Equal(t, indexed, isIndexedSource(rel), rel)
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/tliron/glsp"
+)
+
+func (s *Server) onInlayHint(_ *glsp.Context, params *protocol317.InlayHintParams) ([]protocol317.InlayHint, error) {
+	doc, ok := s.state.GetDocument(params.TextDocument.URI)
+	if !ok || doc.Analyzer == nil || !s.config.FeatureEnabled(config.FeatureInlayHints) {
+		return nil, nil
+	}
+
+	if provider, ok := doc.Analyzer.(analyzer.InlayHintProvider); ok {
+		return provider.OnInlayHints(params.Range)
+	}
+	return nil, nil
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/tliron/commonlog"
+	"github.com/tliron/glsp"
+)
+
+// RunTCP serves the editors connecting to address, e.g. 0.0.0.0:9257 for a
+// server in a container, instead of stdio.
+func (s *Server) RunTCP(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.serveListener(listener)
+}
+
+// RunSocket serves the editors connecting to the Unix socket at path, e.g. in
+// a directory shared with a container, instead of stdio.
+func (s *Server) RunSocket(path string) error {
+	// A socket left behind by a previous run
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return s.serveListener(listener)
+}
+
+// Serves one client at a time, as the server holds the state of one editor
+func (s *Server) serveListener(listener net.Listener) error {
+	defer listener.Close()
+	logger := commonlog.GetLoggerf("vimfony.server")
+	logger.Infof("listening on %s", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		logger.Infof("client connected from %s", conn.RemoteAddr())
+		stream := jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{})
+		<-jsonrpc2.NewConn(context.Background(), stream, s.newRPCHandler()).DisconnectNotify()
+		logger.Infof("client disconnected")
+		s.reset()
+	}
+}
+
+// Forgets the editor of a connection that ended: its documents, apps and
+// watchers. The next one starts over with initialize, as with a new server.
+func (s *Server) reset() {
+	s.stopIndexing()
+	s.reloads.Wait()
+	// A reload of the autoload map restarts it
+	s.stopIndexing()
+	s.indexer.Wait()
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	for _, a := range s.loadedApps() {
+		if a.watcher != nil {
+			a.watcher.Stop()
+		}
+	}
+	for _, uri := range s.state.URIs() {
+		s.cancelDiagnostics(uri)
+		s.state.DeleteDocument(uri)
+	}
+
+	root := newApp(config.NewConfig())
+	s.appsMu.Lock()
+	s.root = root
+	s.config = root.config
+	s.apps = make(map[string]*app)
+	s.appRoots = make(map[string]string)
+	s.initOptions = nil
+	s.appsMu.Unlock()
+
+	s.diagnosticsMu.Lock()
+	s.client = nil
+	s.diagnosticsMu.Unlock()
+	s.h.SetInitialized(false)
+}
+
+// Hands a request of a connection to the protocol handler, the way the glsp
+// server does for stdio
+func (s *Server) handleRPC(ctx context.Context, conn *jsonrpc2.Conn, request *jsonrpc2.Request) (any, error) {
+	logger := commonlog.GetLoggerf("vimfony.server")
+	glspContext := glsp.Context{
+		Method: request.Method,
+		Notify: func(method string, params any) {
+			if err := conn.Notify(ctx, method, params); err != nil {
+				logger.Errorf("%s", err.Error())
+			}
+		},
+		Call: func(method string, params any, result any) {
+			if err := conn.Call(ctx, method, params, result); err != nil {
+				logger.Errorf("%s", err.Error())
+			}
+		},
+	}
+	if request.Params != nil {
+		glspContext.Params = *request.Params
+	}
+	s.requests.Store(&glspContext, ctx)
+	defer s.requests.Delete(&glspContext)
+
+	if request.Method == "exit" {
+		// The next client starts over with initialize
+		s.h.Handle(&glspContext)
+		return nil, conn.Close()
+	}
+
+	r, validMethod, validParams, err := s.h.Handle(&glspContext)
+	switch {
+	case ctx.Err() != nil && err != nil:
+		return nil, &jsonrpc2.Error{Code: codeRequestCancelled, Message: "request cancelled"}
+	case !validMethod:
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", request.Method)}
+	case !validParams:
+		rpcErr := &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+		if err != nil {
+			rpcErr.Message = err.Error()
+		}
+		return nil, rpcErr
+	case err != nil:
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: err.Error()}
+	}
+	return r, nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Connects an editor to the server listening on listener
+func dialServer(t *testing.T, listener net.Listener) *jsonrpc2.Conn {
+	conn, err := net.Dial(listener.Addr().Network(), listener.Addr().String())
+	require.NoError(t, err)
+	stream := jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{})
+	return jsonrpc2.NewConn(context.Background(), stream, jsonrpc2.HandlerWithError(func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (any, error) {
+		return nil, nil
+	}))
+}
+
+// Connects an editor to s, which receives the notifications of the server
+func connectServer(t *testing.T, s *Server, notified func(request *jsonrpc2.Request)) *jsonrpc2.Conn {
+	serverSide, clientSide := net.Pipe()
+	jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), s.newRPCHandler())
+	client := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(func(_ context.Context, _ *jsonrpc2.Conn, request *jsonrpc2.Request) (any, error) {
+		notified(request)
+		return nil, nil
+	}))
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func initializeServer(t *testing.T, client *jsonrpc2.Conn, root string) {
+	rootURI := protocol.DocumentUri(utils.PathToURI(root))
+	var result map[string]any
+	require.NoError(t, client.Call(context.Background(), protocol.MethodInitialize, map[string]any{
+		"rootUri":               rootURI,
+		"capabilities":          map[string]any{},
+		"initializationOptions": map[string]any{"watch_interval_ms": 50},
+	}, &result))
+	require.Contains(t, result, "capabilities")
+}
+
+func TestListenerResetsTheServerBetweenClients(t *testing.T) {
+	s := NewServer()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.serveListener(listener)
+	t.Cleanup(func() { listener.Close() })
+
+	root := t.TempDir()
+	client := dialServer(t, listener)
+	initializeServer(t, client, root)
+	watcher := s.root.watcher
+	require.NotNil(t, watcher)
+	uri := protocol.DocumentUri(utils.PathToURI(root + "/templates/base.html.twig"))
+	require.NoError(t, client.Notify(context.Background(), protocol.MethodTextDocumentDidOpen, protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, LanguageID: "plaintext", Text: ""},
+	}))
+	require.Eventually(t, func() bool { return len(s.state.URIs()) == 1 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, client.Close())
+
+	require.Eventually(t, func() bool {
+		s.indexMu.RLock()
+		defer s.indexMu.RUnlock()
+		return s.root.watcher == nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, s.state.URIs())
+	assert.False(t, s.h.IsInitialized())
+
+	// The next client starts over
+	client = dialServer(t, listener)
+	defer client.Close()
+	var result any
+	err = client.Call(context.Background(), protocol.MethodShutdown, nil, &result)
+	require.ErrorContains(t, err, "server not initialized")
+	initializeServer(t, client, root)
+	assert.NotSame(t, watcher, s.root.watcher)
+	assert.Len(t, s.loadedApps(), 1)
+}
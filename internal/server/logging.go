@@ -0,0 +1,181 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tliron/commonlog"
+	"github.com/tliron/commonlog/simple"
+)
+
+// The log file is moved to <log_file>.1 when it grows past this size
+const maxLogFileSize = 10 * 1024 * 1024
+
+// Levels of the log_level option
+var logLevels = map[string]commonlog.Level{
+	"none":     commonlog.None,
+	"critical": commonlog.Critical,
+	"error":    commonlog.Error,
+	"warning":  commonlog.Warning,
+	"notice":   commonlog.Notice,
+	"info":     commonlog.Info,
+	"debug":    commonlog.Debug,
+}
+
+// The log level and file of the command line, which the log_level and
+// log_file options of the clients override, and the ones in use
+var logs struct {
+	mu           sync.Mutex
+	defaultLevel string
+	defaultPath  string
+	level        string
+	path         string
+	file         *rotatingFile
+}
+
+// ConfigureLogging applies the log level and file of the command line. The
+// logs go to stderr at info level unless they say otherwise.
+func ConfigureLogging(level, path string) error {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+	if err := applyLogging(level, path); err != nil {
+		return err
+	}
+	logs.defaultLevel, logs.defaultPath = level, path
+	return nil
+}
+
+// Applies the log_level and log_file options of a client over the command
+// line. A client reconnecting with the same options keeps the log as it is.
+func configureLogging(level, path string) error {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+	if level == "" {
+		level = logs.defaultLevel
+	}
+	if path == "" {
+		path = logs.defaultPath
+	}
+	if strings.EqualFold(level, logs.level) && path == logs.path {
+		return nil
+	}
+	return applyLogging(level, path)
+}
+
+func applyLogging(level, path string) error {
+	maxLevel := commonlog.Info
+	if level != "" {
+		l, ok := logLevels[strings.ToLower(level)]
+		if !ok {
+			return fmt.Errorf("unknown log_level '%s'", level)
+		}
+		maxLevel = l
+	}
+
+	backend := simple.NewBackend()
+	backend.Buffered = false
+	var file *rotatingFile
+	if path == "" {
+		backend.Configure(1, nil)
+	} else {
+		var err error
+		if file, err = openRotatingFile(path, maxLogFileSize); err != nil {
+			return err
+		}
+		backend.Configure(1, nil)
+		backend.Writer = file
+		// No colors in files, even when stderr is a terminal
+		backend.Format = func(message *commonlog.LinearMessage, name []string, level commonlog.Level, _ bool) string {
+			return simple.DefaultFormat(message, name, level, false)
+		}
+	}
+	backend.SetMaxLevel(maxLevel)
+	commonlog.SetBackend(backend)
+
+	if logs.file != nil {
+		logs.file.Close()
+	}
+	logs.level, logs.path, logs.file = level, path, file
+	return nil
+}
+
+// A log file that is moved aside to path.1 when it grows past maxSize,
+// replacing the previous one
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+	closed  bool
+}
+
+func openRotatingFile(path string, maxSize int64) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f := &rotatingFile{path: path, maxSize: maxSize}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file != nil && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		f.file.Close()
+		f.file = nil
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			// Go on at the end of the file, the next attempt waits for
+			// another maxSize
+			if err := f.open(); err != nil {
+				return 0, err
+			}
+			f.size = 0
+		}
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file, the writes that follow fail.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
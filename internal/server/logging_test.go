@@ -0,0 +1,91 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/commonlog"
+)
+
+func TestRotatingFileMovesTheLogAside(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "vimfony.log")
+	f, err := openRotatingFile(path, 10)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(rotated))
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(current))
+}
+
+func TestRotatingFileKeepsWritingWhenTheRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vimfony.log")
+	// A directory that is not empty cannot be replaced by the log
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "keep"), 0o755))
+	f, err := openRotatingFile(path, 10)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\nthird\n", string(current))
+}
+
+func TestRotatingFileFailsOnceClosed(t *testing.T) {
+	f, err := openRotatingFile(filepath.Join(t.TempDir(), "vimfony.log"), 10)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = f.Write([]byte("late\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestConfigureLogging(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, ConfigureLogging("", "")) })
+	require.NoError(t, ConfigureLogging("warning", ""))
+
+	// Without options the command line applies
+	require.NoError(t, configureLogging("", ""))
+	assert.Equal(t, commonlog.Warning, commonlog.GetMaxLevel())
+
+	require.Error(t, configureLogging("loud", ""))
+	assert.Equal(t, commonlog.Warning, commonlog.GetMaxLevel())
+
+	path := filepath.Join(t.TempDir(), "vimfony.log")
+	require.NoError(t, configureLogging("debug", path))
+	assert.Equal(t, commonlog.Debug, commonlog.GetMaxLevel())
+	file := logs.file
+	require.NotNil(t, file)
+	commonlog.GetLogger("vimfony.test").Debug("to the file")
+
+	// A reconnecting client keeps the file open
+	require.NoError(t, configureLogging("debug", path))
+	assert.Same(t, file, logs.file)
+
+	// and a client without options goes back to the command line, closing it
+	require.NoError(t, configureLogging("", ""))
+	assert.Equal(t, commonlog.Warning, commonlog.GetMaxLevel())
+	assert.Nil(t, logs.file)
+	_, err := file.Write([]byte("late\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "to the file")
+}
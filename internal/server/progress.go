@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// progress reports the steps of a load as work done progress, on the token
+// the client sent with its request or one the server created. Without a token
+// it reports nothing, so a nil progress is valid.
+type progress struct {
+	context *glsp.Context
+	token   protocol.ProgressToken
+	steps   int
+	step    int
+}
+
+func newProgress(context *glsp.Context, token *protocol317.ProgressToken, steps int) *progress {
+	if context == nil || context.Notify == nil || token == nil {
+		return nil
+	}
+	p := &progress{context: context, token: protocol.ProgressToken(*token), steps: steps}
+	p.notify(protocol.WorkDoneProgressBegin{Kind: "begin", Title: lsName})
+	return p
+}
+
+// createProgress asks the client to show a progress of the server with token,
+// which the user can cancel. It calls the client, so it must not run on the
+// goroutine reading its answers.
+func createProgress(context *glsp.Context, token string, steps int) *progress {
+	if context == nil || context.Call == nil || context.Notify == nil {
+		return nil
+	}
+	var result any
+	context.Call(protocol.ServerWindowWorkDoneProgressCreate, protocol.WorkDoneProgressCreateParams{
+		Token: protocol.ProgressToken{Value: token},
+	}, &result)
+	p := &progress{context: context, token: protocol.ProgressToken{Value: token}, steps: steps}
+	cancellable := true
+	p.notify(protocol.WorkDoneProgressBegin{Kind: "begin", Title: lsName, Cancellable: &cancellable})
+	return p
+}
+
+// Returns the number of steps loading an app reports
+func indexingSteps(cfg *config.Config) int {
+	steps := 2 // the autoload map and the container
+	if cfg.FeatureEnabled(config.FeatureRoutes) {
+		steps++
+	}
+	if cfg.FeatureEnabled(config.FeatureTranslations) {
+		steps++
+	}
+	return steps
+}
+
+// next reports the step about to start, e.g. "indexing container (2/4)"
+func (p *progress) next(what string) {
+	if p == nil {
+		return
+	}
+	p.step++
+	p.report(fmt.Sprintf("indexing %s (%d/%d)", what, p.step, p.steps), p.step-1)
+}
+
+// report reports message with done of the steps done
+func (p *progress) report(message string, done int) {
+	if p == nil {
+		return
+	}
+	percentage := protocol.UInteger(100 * done / p.steps)
+	p.notify(protocol.WorkDoneProgressReport{Kind: "report", Message: &message, Percentage: &percentage})
+}
+
+func (p *progress) done() {
+	if p == nil {
+		return
+	}
+	p.notify(protocol.WorkDoneProgressEnd{Kind: "end"})
+}
+
+func (p *progress) notify(value any) {
+	p.context.Notify(protocol.MethodProgress, protocol.ProgressParams{Token: p.token, Value: value})
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestInitializeReportsIndexingProgress(t *testing.T) {
+	s := NewServer()
+	var mu sync.Mutex
+	var reported []map[string]any
+	client := connectServer(t, s, func(request *jsonrpc2.Request) {
+		if request.Method != string(protocol.MethodProgress) {
+			return
+		}
+		var params struct {
+			Token string         `json:"token"`
+			Value map[string]any `json:"value"`
+		}
+		require.NoError(t, json.Unmarshal(*request.Params, &params))
+		assert.Equal(t, "init", params.Token)
+		mu.Lock()
+		reported = append(reported, params.Value)
+		mu.Unlock()
+	})
+
+	var result map[string]any
+	require.NoError(t, client.Call(context.Background(), protocol.MethodInitialize, map[string]any{
+		"rootUri":       protocol.DocumentUri(utils.PathToURI(t.TempDir())),
+		"capabilities":  map[string]any{},
+		"workDoneToken": "init",
+	}, &result))
+
+	mu.Lock()
+	defer mu.Unlock()
+	var kinds, messages []any
+	for _, value := range reported {
+		kinds = append(kinds, value["kind"])
+		if message, ok := value["message"]; ok {
+			messages = append(messages, message)
+		}
+	}
+	assert.Equal(t, []any{"begin", "report", "report", "report", "report", "end"}, kinds)
+	assert.Equal(t, []any{
+		"indexing autoload map (1/4)",
+		"indexing container (2/4)",
+		"indexing routes (3/4)",
+		"indexing translations (4/4)",
+	}, messages)
+	assert.Equal(t, "vimfony", reported[0]["title"])
+}
+
+func TestProgressWithoutTokenReportsNothing(t *testing.T) {
+	var p *progress
+	assert.Nil(t, newProgress(nil, nil, 4))
+	p.next("container")
+	p.done()
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/tliron/commonlog"
+)
+
+// projectConfigFile holds the initializationOptions a team commits with the
+// project, so that editor configs only need what differs per developer.
+const projectConfigFile = ".vimfony.json"
+
+// initializationOptions merges the options of the editor over the ones of
+// the project config file.
+func initializationOptions(workspaceRoot string, editorOptions any) map[string]any {
+	logger := commonlog.GetLoggerf("vimfony.server")
+	options := make(map[string]any)
+
+	path := filepath.Join(workspaceRoot, projectConfigFile)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &options); err != nil {
+			logger.Warningf("could not parse '%s': %v", path, err)
+			options = make(map[string]any)
+		} else {
+			logger.Infof("loaded options from '%s'", path)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		logger.Warningf("could not read '%s': %v", path, err)
+	}
+
+	if m, ok := editorOptions.(map[string]any); ok {
+		for key, value := range m {
+			options[key] = value
+		}
+	}
+	return options
+}
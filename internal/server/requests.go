@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// The requests a client cancels while typing. They run on their own
+// goroutine, so that the $/cancelRequest following them can be read.
+var cancellableMethods = map[string]bool{
+	protocol.MethodTextDocumentCompletion: true,
+	protocol.MethodTextDocumentDefinition: true,
+	protocol.MethodTextDocumentCodeAction: true,
+	protocol.MethodCodeActionResolve:      true,
+}
+
+// The LSP error of a cancelled request
+const codeRequestCancelled = -32800
+
+// rpcHandler serves the messages of a connection in order, but for the
+// cancellable requests
+type rpcHandler struct {
+	server  *Server
+	serve   jsonrpc2.Handler
+	mu      sync.Mutex
+	pending map[jsonrpc2.ID]context.CancelFunc
+}
+
+func (s *Server) newRPCHandler() *rpcHandler {
+	return &rpcHandler{
+		server:  s,
+		serve:   jsonrpc2.HandlerWithError(s.handleRPC),
+		pending: make(map[jsonrpc2.ID]context.CancelFunc),
+	}
+}
+
+func (h *rpcHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, request *jsonrpc2.Request) {
+	switch {
+	case request.Method == string(protocol.MethodCancelRequest):
+		h.cancel(request)
+	case !request.Notif && cancellableMethods[request.Method]:
+		ctx, cancel := context.WithCancel(ctx)
+		h.mu.Lock()
+		h.pending[request.ID] = cancel
+		h.mu.Unlock()
+		go func() {
+			defer func() {
+				h.mu.Lock()
+				delete(h.pending, request.ID)
+				h.mu.Unlock()
+				cancel()
+			}()
+			h.serve.Handle(ctx, conn, request)
+		}()
+	default:
+		h.serve.Handle(ctx, conn, request)
+	}
+}
+
+// Cancels the request of a $/cancelRequest, if it still runs
+func (h *rpcHandler) cancel(request *jsonrpc2.Request) {
+	if request.Params == nil {
+		return
+	}
+	var params struct {
+		ID jsonrpc2.ID `json:"id"`
+	}
+	if err := json.Unmarshal(*request.Params, &params); err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if cancel, ok := h.pending[params.ID]; ok {
+		cancel()
+	}
+}
+
+// requestContext returns the context of the request being handled with c,
+// which is done once the client cancels it.
+func (s *Server) requestContext(c *glsp.Context) context.Context {
+	if c != nil {
+		if ctx, ok := s.requests.Load(c); ok {
+			return ctx.(context.Context)
+		}
+	}
+	return context.Background()
+}
+
+// The stdin and stdout of the process as the stream of the client
+type stdio struct{}
+
+func (stdio) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdio) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+
+func (stdio) Close() error {
+	if err := os.Stdin.Close(); err != nil {
+		return err
+	}
+	return os.Stdout.Close()
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestCancelRequestStopsTheRequest(t *testing.T) {
+	s := NewServer()
+	h := s.newRPCHandler()
+	started := make(chan struct{})
+	h.serve = jsonrpc2.HandlerWithError(func(ctx context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, &jsonrpc2.Error{Code: codeRequestCancelled, Message: "request cancelled"}
+	})
+
+	serverSide, clientSide := net.Pipe()
+	jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), h)
+	client := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (any, error) {
+		return nil, nil
+	}))
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		var result any
+		done <- client.Call(context.Background(), protocol.MethodTextDocumentCompletion, map[string]any{}, &result, jsonrpc2.PickID(jsonrpc2.ID{Num: 7}))
+	}()
+	<-started
+	require.NoError(t, client.Notify(context.Background(), string(protocol.MethodCancelRequest), map[string]any{"id": 7}))
+
+	select {
+	case err := <-done:
+		var rpcErr *jsonrpc2.Error
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, int64(codeRequestCancelled), rpcErr.Code)
+	case <-time.After(time.Second):
+		t.Fatal("the request was not cancelled")
+	}
+	// The request is forgotten once its reply is sent
+	assert.Eventually(t, func() bool {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return len(h.pending) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRequestContextDefaultsToBackground(t *testing.T) {
+	s := NewServer()
+	assert.Equal(t, context.Background(), s.requestContext(nil))
+}
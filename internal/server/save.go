@@ -0,0 +1,112 @@
+package server
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// didSave refreshes the indexes read from the saved file, so that completion
+// follows services.yaml, the routes, the translation catalogs and the
+// controllers without waiting for the cache to be rebuilt
+func (s *Server) didSave(_ *glsp.Context, p *protocol.DidSaveTextDocumentParams) error {
+	path := utils.UriToPath(string(p.TextDocument.URI))
+	a := s.appFor(path)
+	if filepath.Ext(path) == ".twig" && a.config.FeatureEnabled(config.FeatureTemplates) {
+		// A template written the first time, for clients not watching files
+		a.config.Container.TwigTemplateCreated(path)
+	}
+	if load := s.savedFileReload(a, path); load != nil {
+		s.reloadLater(load)
+	}
+	return nil
+}
+
+// Returns the reload of the indexes of app a read from the file at path, or
+// nil when none is
+func (s *Server) savedFileReload(a *app, path string) func() {
+	cfg := a.config
+	rel, err := filepath.Rel(cfg.Container.WorkspaceRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	if strings.HasPrefix(rel, "vendor/") {
+		return nil
+	}
+
+	var loads []func()
+	routes := cfg.FeatureEnabled(config.FeatureRoutes)
+	switch {
+	case isServicesFile(rel):
+		// Only read without a compiled container
+		loads = append(loads, cfg.Container.LoadServicesFromYAML)
+	case isRoutesFile(rel):
+		if routes {
+			loads = append(loads, cfg.LoadRoutesMap)
+		}
+	case isTranslationCatalog(cfg.Container, path):
+		if cfg.FeatureEnabled(config.FeatureTranslations) {
+			loads = append(loads, cfg.LoadTranslations)
+		}
+	case strings.HasSuffix(rel, ".php"):
+		if routes && strings.Contains(rel, "/Controller/") {
+			loads = append(loads, cfg.LoadRoutesMap)
+		}
+		if cfg.FeatureEnabled(config.FeatureTemplates) {
+			loads = append(loads, func() { cfg.Container.LoadTemplateVariables(cfg.Autoload) })
+		}
+		if cfg.FeatureEnabled(config.FeatureTwigComponents) {
+			loads = append(loads, func() { cfg.Container.LoadTwigComponents(cfg.Autoload) })
+		}
+	}
+	if len(loads) == 0 {
+		return nil
+	}
+	return func() {
+		for _, load := range loads {
+			load()
+		}
+	}
+}
+
+// Reports whether rel is config/services.yaml or one of its variants
+func isServicesFile(rel string) bool {
+	dir, name := filepath.Split(rel)
+	return dir == "config/" && strings.HasPrefix(name, "services") && isYAMLFile(name)
+}
+
+// Reports whether rel is config/routes.yaml or a file under config/routes/
+func isRoutesFile(rel string) bool {
+	if rel == "config/routes.yaml" || rel == "config/routes.yml" || rel == "config/routes.php" {
+		return true
+	}
+	return strings.HasPrefix(rel, "config/routes/")
+}
+
+// Reports whether path is a translation catalog the container reads, or a
+// file under the translation roots
+func isTranslationCatalog(container *config.ContainerConfig, path string) bool {
+	if slices.Contains(container.TranslationResources, path) {
+		return true
+	}
+	for _, root := range container.TranslationRoots {
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(container.WorkspaceRoot, root)
+		}
+		if strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isYAMLFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
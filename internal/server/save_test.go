@@ -0,0 +1,65 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestSavedFileReloadPicksTheIndexesOfTheFile(t *testing.T) {
+	s, root := multiAppServer(t)
+	for rel, reloads := range map[string]bool{
+		"config/services.yaml":               true,
+		"config/services_test.yaml":          true,
+		"config/packages/twig.yaml":          false,
+		"config/routes.yaml":                 true,
+		"config/routes/api.yaml":             true,
+		"translations/messages.en.yaml":      true,
+		"src/Controller/HomeController.php":  true,
+		"src/Entity/Post.php":                true,
+		"templates/base.html.twig":           false,
+		"vendor/acme/bundle/src/Service.php": false,
+	} {
+		path := filepath.Join(root, rel)
+		assert.Equal(t, reloads, s.savedFileReload(s.root, path) != nil, rel)
+	}
+	assert.Nil(t, s.savedFileReload(s.root, filepath.Join(filepath.Dir(root), "other/config/services.yaml")))
+}
+
+func TestSavingServicesYAMLReloadsTheServices(t *testing.T) {
+	s, root := multiAppServer(t)
+	services := filepath.Join(root, "config/services.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(services), 0o755))
+	require.NoError(t, os.WriteFile(services, []byte("services:\n  app.mailer:\n    class: App\\Mailer\n"), 0o644))
+	s.loadContainer(s.root, nil)
+	require.Equal(t, "App\\Mailer", s.config.Container.ServiceClasses["app.mailer"])
+
+	require.NoError(t, os.WriteFile(services, []byte("services:\n  app.notifier:\n    class: App\\Notifier\n"), 0o644))
+	s.indexMu.RLock()
+	err := s.didSave(nil, &protocol.DidSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri(utils.PathToURI(services))},
+	})
+	s.indexMu.RUnlock()
+	require.NoError(t, err)
+
+	s.reloads.Wait()
+	assert.Equal(t, map[string]string{"app.notifier": "App\\Notifier"}, s.config.Container.ServiceClasses)
+}
+
+func TestSavingANewTemplateAddsItToTheList(t *testing.T) {
+	s, root := multiAppServer(t)
+	require.Empty(t, s.config.Container.TwigTemplates())
+
+	path := filepath.Join(root, "templates/post/show.html.twig")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	require.NoError(t, s.didSave(nil, &protocol.DidSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri(utils.PathToURI(path))},
+	}))
+	assert.Equal(t, []string{"post/show.html.twig"}, s.config.Container.TwigTemplates())
+}
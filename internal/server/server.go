@@ -1,17 +1,24 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
 	sitter "github.com/alexaandru/go-tree-sitter-bare"
 	"github.com/shinyvision/vimfony/internal/analyzer"
 	"github.com/shinyvision/vimfony/internal/config"
-	"github.com/shinyvision/vimfony/internal/doctrine"
 	php "github.com/shinyvision/vimfony/internal/php"
+	"github.com/shinyvision/vimfony/internal/protocol317"
 	"github.com/shinyvision/vimfony/internal/state"
 	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/sourcegraph/jsonrpc2"
 	"github.com/tliron/commonlog"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
-	glspserver "github.com/tliron/glsp/server"
 )
 
 const lsName = "vimfony"
@@ -19,112 +26,182 @@ const lsName = "vimfony"
 var version = "0.1.0"
 
 type Server struct {
-	config   *config.Config
-	state    *state.State
-	docStore *php.DocumentStore
-	doctrine *doctrine.Registry
-	h        protocol.Handler
+	// config is the one of the app at the workspace root, whose features and
+	// client settings apply to all apps
+	config             *config.Config
+	root               *app
+	state              *state.State
+	h                  handler
+	pullDiagnostics    bool
+	diagnosticsRefresh bool
+	resolveCodeActions bool
+	snippetSupport     bool
+	watchFiles         bool
+	workDoneProgress   bool
+	diagnosticsMu      sync.Mutex
+	diagnosticTimers   map[protocol.DocumentUri]*time.Timer
+	// client is the context of initialize, to reach the client outside of
+	// requests
+	client *glsp.Context
+	// indexMu keeps requests out while a changed index is rebuilt
+	indexMu sync.RWMutex
+	// reloads are the rebuilds waiting for the request that started them
+	reloads sync.WaitGroup
+	// indexer is the background parse of the sources, which cancelIndexing
+	// stops
+	indexer        sync.WaitGroup
+	cancelIndexing context.CancelFunc
+	indexerMu      sync.Mutex
+	// apps are the Symfony apps of the workspace by root directory, loaded
+	// when a file of theirs is opened
+	// requests are the contexts of the requests being handled, by their glsp
+	// context
+	requests sync.Map
+	apps     map[string]*app
+	// appRoots caches the app root of the directories of the documents
+	appRoots    map[string]string
+	appsMu      sync.Mutex
+	initOptions any
 }
 
 func NewServer() *Server {
-	store := php.NewDocumentStore(1000)
+	root := newApp(config.NewConfig())
 	s := &Server{
-		config:   config.NewConfig(),
-		state:    state.NewState(store),
-		docStore: store,
-		doctrine: doctrine.NewRegistry(),
-	}
-	s.h = protocol.Handler{
-		Initialize:             s.initialize,
-		Initialized:            s.initialized,
-		Shutdown:               s.shutdown,
-		SetTrace:               s.setTrace,
-		TextDocumentDidOpen:    s.didOpen,
-		TextDocumentDidChange:  s.didChange,
-		TextDocumentDidClose:   s.didClose,
-		TextDocumentDefinition: s.onDefinition,
-		TextDocumentCompletion: s.onCompletion,
-		TextDocumentCodeAction: s.onCodeAction,
+		config:           root.config,
+		root:             root,
+		state:            state.NewState(root.docStore),
+		diagnosticTimers: make(map[protocol.DocumentUri]*time.Timer),
+		apps:             make(map[string]*app),
+		appRoots:         make(map[string]string),
+	}
+	s.state.SetDocumentStoreFunc(func(path string) *php.DocumentStore {
+		return s.appFor(path).docStore
+	})
+	s.h.server = s
+	s.h.Handler = protocol317.Handler{
+		Handler: protocol.Handler{
+			Initialized:                    s.initialized,
+			Shutdown:                       s.shutdown,
+			SetTrace:                       s.setTrace,
+			TextDocumentDidOpen:            s.didOpen,
+			TextDocumentDidChange:          s.didChange,
+			TextDocumentDidClose:           s.didClose,
+			TextDocumentDidSave:            s.didSave,
+			TextDocumentDefinition:         s.onDefinition,
+			TextDocumentCompletion:         s.onCompletion,
+			TextDocumentCodeAction:         s.onCodeAction,
+			TextDocumentSignatureHelp:      s.onSignatureHelp,
+			TextDocumentCodeLens:           s.onCodeLens,
+			TextDocumentFoldingRange:       s.onFoldingRange,
+			TextDocumentDocumentLink:       s.onDocumentLink,
+			CodeActionResolve:              s.onCodeActionResolve,
+			WorkspaceExecuteCommand:        s.onExecuteCommand,
+			WorkspaceDidChangeWatchedFiles: s.didChangeWatchedFiles,
+		},
+		Initialize:                   s.initialize,
+		WindowWorkDoneProgressCancel: s.cancelWorkDoneProgress,
+		TextDocumentDiagnostic:       s.onDiagnostic,
+		TextDocumentInlayHint:        s.onInlayHint,
 	}
 	return s
 }
 
+// Run serves the editor on stdin and stdout.
 func (s *Server) Run() {
-	server := glspserver.NewServer(&s.h, lsName, false)
-	server.RunStdio()
+	stream := jsonrpc2.NewBufferedStream(stdio{}, jsonrpc2.VSCodeObjectCodec{})
+	<-jsonrpc2.NewConn(context.Background(), stream, s.newRPCHandler()).DisconnectNotify()
 }
 
-func (s *Server) initialize(_ *glsp.Context, params *protocol.InitializeParams) (any, error) {
+func (s *Server) initialize(context *glsp.Context, params *protocol317.InitializeParams) (any, error) {
+	s.pullDiagnostics = params.Capabilities.TextDocument != nil && params.Capabilities.TextDocument.Diagnostic != nil
+	s.diagnosticsRefresh = clientRefreshesDiagnostics(params.Capabilities)
+	s.diagnosticsMu.Lock()
+	s.client = context
+	s.diagnosticsMu.Unlock()
 	caps := s.h.CreateServerCapabilities()
+	encoding := negotiatePositionEncoding(params.Capabilities)
+	utils.SetUTF8Positions(encoding == protocol317.PositionEncodingKindUTF8)
+	caps.PositionEncoding = &encoding
 	openClose := true
 	change := protocol.TextDocumentSyncKindIncremental
 	caps.TextDocumentSync = &protocol.TextDocumentSyncOptions{
 		OpenClose: &openClose,
 		Change:    &change,
+		Save:      true,
+	}
+	caps.ExecuteCommandProvider = &protocol.ExecuteCommandOptions{
+		Commands: []string{commandReloadRoutes, commandSwitchEnvironment, analyzer.CommandOpenTemplate, analyzer.CommandCreateTemplate, analyzer.CommandRouteUsages},
 	}
 	defProvider := true
 	caps.DefinitionProvider = defProvider
 	caps.CompletionProvider = &protocol.CompletionOptions{
 		TriggerCharacters: []string{"@"},
 	}
+	s.resolveCodeActions = clientResolvesCodeActionEdits(params.Capabilities)
+	s.snippetSupport = clientSupportsSnippets(params.Capabilities)
+	caps.SignatureHelpProvider = &protocol.SignatureHelpOptions{
+		TriggerCharacters:   []string{"(", ","},
+		RetriggerCharacters: []string{"{", "["},
+	}
+	if s.resolveCodeActions {
+		resolveProvider := true
+		caps.CodeActionProvider = protocol.CodeActionOptions{ResolveProvider: &resolveProvider}
+	}
+	if !s.pullDiagnostics {
+		caps.DiagnosticProvider = nil
+	}
 
+	workspaceRoot := "."
 	if params.RootURI != nil {
-		s.config.Container.WorkspaceRoot = utils.UriToPath(*params.RootURI)
+		workspaceRoot = utils.UriToPath(*params.RootURI)
 	} else if len(params.WorkspaceFolders) > 0 {
-		s.config.Container.WorkspaceRoot = utils.UriToPath(params.WorkspaceFolders[0].URI)
-	} else {
-		s.config.Container.WorkspaceRoot = "."
-	}
-
-	if params.InitializationOptions != nil {
-		if m, ok := params.InitializationOptions.(map[string]any); ok {
-			if r, ok := m["roots"]; ok {
-				if arr, ok := r.([]any); ok {
-					var roots []string
-					for _, v := range arr {
-						if str, ok := v.(string); ok && str != "" {
-							roots = append(roots, str)
-						}
-					}
-					if len(roots) > 0 {
-						s.config.Container.Roots = roots
-					}
-				}
-			}
-			if cxp, ok := m["container_xml_path"]; ok {
-				if paths := toStringSlice(cxp); len(paths) > 0 {
-					s.config.Container.SetContainerXMLPaths(paths)
-				}
-			}
-			if phpp, ok := m["php_path"]; ok {
-				if str, ok := phpp.(string); ok && str != "" {
-					s.config.PhpPath = str
-				}
-			}
-			if vdp, ok := m["vendor_dir"]; ok {
-				if str, ok := vdp.(string); ok && str != "" {
-					s.config.VendorDir = str
-				}
-			}
-		}
+		workspaceRoot = utils.UriToPath(params.WorkspaceFolders[0].URI)
+	}
+	s.initOptions = params.InitializationOptions
+	configureApp(s.config, workspaceRoot, s.initOptions)
+	if err := configureLogging(s.config.LogLevel, s.config.LogFile); err != nil {
+		commonlog.GetLoggerf("vimfony.server").Warningf("could not configure logging: %v", err)
 	}
 
-	s.config.LoadAutoloadMap()
-	s.config.Container.LoadFromXML(s.config.Autoload)
-	s.config.LoadRoutesMap()
-	s.config.LoadTranslations()
-	s.docStore.Configure(s.config.Autoload, s.config.Container.WorkspaceRoot)
-	s.doctrine.Configure(
-		s.config.Container.DoctrineDrivers,
-		s.config.Autoload,
-		s.config.Container.WorkspaceRoot,
-		s.docStore,
-		s.config.Container.ResolveTargetEntities,
-	)
+	s.watchFiles = clientWatchesFiles(params.Capabilities) && s.config.FeatureEnabled(config.FeatureWatch)
+	s.workDoneProgress = clientShowsWorkDoneProgress(params.Capabilities)
+
+	if !s.config.FeatureEnabled(config.FeatureCompletion) {
+		caps.CompletionProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureDefinition) {
+		caps.DefinitionProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureCodeActions) {
+		caps.CodeActionProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureDiagnostics) {
+		caps.DiagnosticProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureInlayHints) {
+		caps.InlayHintProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureSignatureHelp) {
+		caps.SignatureHelpProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureCodeLens) {
+		caps.CodeLensProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureFoldingRanges) {
+		caps.FoldingRangeProvider = nil
+	}
+	if !s.config.FeatureEnabled(config.FeatureDocumentLinks) {
+		caps.DocumentLinkProvider = nil
+	}
 
-	logPathStats(s.config, "initialize")
+	s.appsMu.Lock()
+	// A client reconnecting to a listening server starts over
+	s.apps = map[string]*app{filepath.Clean(workspaceRoot): s.root}
+	s.appRoots = make(map[string]string)
+	s.appsMu.Unlock()
+	s.loadApp(s.root, "initialize", newProgress(context, params.WorkDoneToken, indexingSteps(s.config)))
 
-	return protocol.InitializeResult{
+	return protocol317.InitializeResult{
 		Capabilities: caps,
 		ServerInfo: &protocol.InitializeResultServerInfo{
 			Name:    lsName,
@@ -133,40 +210,128 @@ func (s *Server) initialize(_ *glsp.Context, params *protocol.InitializeParams)
 	}, nil
 }
 
-func (s *Server) initialized(_ *glsp.Context, _ *protocol.InitializedParams) error { return nil }
-func (s *Server) shutdown(_ *glsp.Context) error                                   { return nil }
+// Picks UTF-8 positions when the client offers them, which are the byte
+// columns of tree-sitter, and the UTF-16 of the spec otherwise
+func negotiatePositionEncoding(capabilities protocol317.ClientCapabilities) protocol317.PositionEncodingKind {
+	if capabilities.General != nil && slices.Contains(capabilities.General.PositionEncodings, protocol317.PositionEncodingKindUTF8) {
+		return protocol317.PositionEncodingKindUTF8
+	}
+	return protocol317.PositionEncodingKindUTF16
+}
+
+const (
+	commandReloadRoutes      = "vimfony.reloadRoutes"
+	commandSwitchEnvironment = "vimfony.switchEnvironment"
+)
+
+func (s *Server) onExecuteCommand(_ *glsp.Context, params *protocol.ExecuteCommandParams) (any, error) {
+	switch params.Command {
+	case commandReloadRoutes:
+		if s.config.FeatureEnabled(config.FeatureRoutes) {
+			s.reloadLater(func() {
+				for _, a := range s.loadedApps() {
+					a.config.LoadRoutesMap()
+					a.config.Container.LoadRouteUsages(a.config.Autoload)
+					a.config.Container.LoadRouteDeclarations()
+				}
+			})
+		}
+	case commandSwitchEnvironment:
+		env := ""
+		if len(params.Arguments) > 0 {
+			env, _ = params.Arguments[0].(string)
+		}
+		apps := s.loadedApps()
+		known := false
+		for _, a := range apps {
+			known = known || slices.Contains(a.config.Container.Environments(), env)
+		}
+		if !known {
+			return nil, fmt.Errorf("no container_xml_path for environment '%s', expected one of %v", env, s.config.Container.Environments())
+		}
+		s.reloadLater(func() {
+			for _, a := range apps {
+				if a.config.Container.SetEnvironment(env) {
+					s.loadContainer(a, nil)
+					logPathStats(a.config, "environment "+env)
+				}
+			}
+		})
+	case analyzer.CommandOpenTemplate, analyzer.CommandCreateTemplate:
+		// The lenses pass the URI of their document after the template name
+		a := s.root
+		if len(params.Arguments) > 1 {
+			if uri, ok := params.Arguments[1].(string); ok {
+				a = s.appFor(utils.UriToPath(uri))
+			}
+		}
+		return templateCommandLocation(params.Arguments, a.config.Container, params.Command == analyzer.CommandCreateTemplate)
+	case analyzer.CommandRouteUsages:
+		a := s.root
+		if len(params.Arguments) > 1 {
+			if uri, ok := params.Arguments[1].(string); ok {
+				a = s.appFor(utils.UriToPath(uri))
+			}
+		}
+		return routeUsageLocations(params.Arguments, a.config.Container)
+	}
+	return nil, nil
+}
+
+func (s *Server) initialized(context *glsp.Context, _ *protocol.InitializedParams) error {
+	s.registerWatchedFiles(context)
+	s.startIndexing(context, s.root)
+	return nil
+}
+func (s *Server) shutdown(_ *glsp.Context) error {
+	s.stopIndexing()
+	for _, a := range s.loadedApps() {
+		if a.watcher != nil {
+			a.watcher.Stop()
+		}
+	}
+	return nil
+}
 func (s *Server) setTrace(_ *glsp.Context, p *protocol.SetTraceParams) error {
 	protocol.SetTraceValue(p.Value)
 	return nil
 }
 
-func (s *Server) didOpen(_ *glsp.Context, p *protocol.DidOpenTextDocumentParams) error {
+func (s *Server) didOpen(context *glsp.Context, p *protocol.DidOpenTextDocumentParams) error {
 	s.state.SetDocument(p.TextDocument.URI, p.TextDocument.Text, p.TextDocument.LanguageID)
 
 	if doc, ok := s.state.GetDocument(p.TextDocument.URI); ok {
 		if doc.Analyzer != nil {
+			a := s.appFor(utils.UriToPath(string(p.TextDocument.URI)))
 			if ca, ok := doc.Analyzer.(analyzer.ContainerAware); ok {
-				ca.SetContainerConfig(s.config.Container)
+				ca.SetContainerConfig(a.config.Container)
 			}
 			if pa, ok := doc.Analyzer.(analyzer.AutoloadAware); ok {
-				pa.SetAutoloadMap(&s.config.Autoload)
+				pa.SetAutoloadMap(&a.config.Autoload)
 			}
 			if ra, ok := doc.Analyzer.(analyzer.RoutesAware); ok {
-				ra.SetRoutes(&s.config.Routes)
+				ra.SetRoutes(&a.config.Routes)
 			}
 			if da, ok := doc.Analyzer.(analyzer.DocumentStoreAware); ok {
-				da.SetDocumentStore(s.docStore)
+				da.SetDocumentStore(a.docStore)
 			}
 			if da, ok := doc.Analyzer.(analyzer.DoctrineAware); ok {
-				da.SetDoctrineRegistry(s.doctrine)
+				da.SetDoctrineRegistry(a.doctrine)
+			}
+			if sa, ok := doc.Analyzer.(analyzer.SnippetAware); ok {
+				sa.SetSnippetSupport(s.snippetSupport)
+			}
+			if fa, ok := doc.Analyzer.(analyzer.FeaturesAware); ok {
+				fa.SetFeatures(a.config.Features)
 			}
 		}
 	}
 
+	s.publishDiagnostics(context, p.TextDocument.URI)
 	return nil
 }
 
-func (s *Server) didChange(_ *glsp.Context, p *protocol.DidChangeTextDocumentParams) error {
+func (s *Server) didChange(context *glsp.Context, p *protocol.DidChangeTextDocumentParams) error {
 	doc, ok := s.state.GetDocument(p.TextDocument.URI)
 	if !ok {
 		return nil
@@ -212,6 +377,7 @@ func (s *Server) didChange(_ *glsp.Context, p *protocol.DidChangeTextDocumentPar
 		return sitter.Point{Row: row, Column: uint(col)}
 	}
 
+	changes := make([]state.Change, 0, len(p.ContentChanges))
 	for _, raw := range p.ContentChanges {
 		if wholePage, ok := raw.(*protocol.TextDocumentContentChangeEventWhole); ok {
 			newText := wholePage.Text
@@ -225,7 +391,7 @@ func (s *Server) didChange(_ *glsp.Context, p *protocol.DidChangeTextDocumentPar
 				OldEndPoint: pointOfEnd(old),
 				NewEndPoint: pointOfEnd(newBytes),
 			}
-			s.state.ChangeDocument(uri, newText, &change)
+			changes = append(changes, state.Change{Text: newText, Edit: &change})
 
 			text, old = newText, newBytes
 			continue
@@ -241,8 +407,8 @@ func (s *Server) didChange(_ *glsp.Context, p *protocol.DidChangeTextDocumentPar
 			continue
 		}
 
-		start := changeEvent.Range.Start.IndexIn(text)
-		end := changeEvent.Range.End.IndexIn(text)
+		start := utils.ByteOffset(old, changeEvent.Range.Start)
+		end := utils.ByteOffset(old, changeEvent.Range.End)
 		if start < 0 || end < start || end > len(text) {
 			continue
 		}
@@ -270,19 +436,30 @@ func (s *Server) didChange(_ *glsp.Context, p *protocol.DidChangeTextDocumentPar
 		}
 
 		newText := text[:start] + changeEvent.Text + text[end:]
-		s.state.ChangeDocument(uri, newText, &change)
+		changes = append(changes, state.Change{Text: newText, Edit: &change})
 
 		text = newText
 		old = []byte(newText)
 	}
 
-	// TODO: optimize for incremental changes
-	s.state.SetDocument(uri, text, doc.LanguageID)
+	if len(changes) == 0 {
+		return nil
+	}
+	s.state.ChangeDocument(uri, changes)
+	if s.config.FeatureEnabled(config.FeatureRoutes) {
+		path := utils.UriToPath(string(uri))
+		container := s.appFor(path).config.Container
+		container.UpdateRouteUsages(path, text)
+		container.UpdateRouteDeclarations(path, text)
+	}
+	s.scheduleDiagnostics(context, uri)
 	return nil
 }
 
-func (s *Server) didClose(_ *glsp.Context, p *protocol.DidCloseTextDocumentParams) error {
+func (s *Server) didClose(context *glsp.Context, p *protocol.DidCloseTextDocumentParams) error {
 	s.state.DeleteDocument(p.TextDocument.URI)
+	s.cancelDiagnostics(p.TextDocument.URI)
+	s.publishDiagnostics(context, p.TextDocument.URI)
 	return nil
 }
 
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestInitializePicksUTF8PositionsWhenOffered(t *testing.T) {
+	t.Cleanup(func() { utils.SetUTF8Positions(false) })
+	for _, tc := range []struct {
+		offered  []string
+		expected string
+	}{
+		{offered: []string{"utf-32", "utf-8", "utf-16"}, expected: "utf-8"},
+		{offered: []string{"utf-32"}, expected: "utf-16"},
+		{offered: nil, expected: "utf-16"},
+	} {
+		s := NewServer()
+		client := connectServer(t, s, func(*jsonrpc2.Request) {})
+		var result struct {
+			Capabilities map[string]any `json:"capabilities"`
+		}
+		require.NoError(t, client.Call(context.Background(), protocol.MethodInitialize, map[string]any{
+			"rootUri":      protocol.DocumentUri(utils.PathToURI(t.TempDir())),
+			"capabilities": map[string]any{"general": map[string]any{"positionEncodings": tc.offered}},
+		}, &result))
+		assert.Equal(t, tc.expected, result.Capabilities["positionEncoding"], "offered %v", tc.offered)
+	}
+}
+
+func TestDidChangeCountsUTF16CodeUnits(t *testing.T) {
+	s := NewServer()
+	uri := protocol.DocumentUri(utils.PathToURI(filepath.Join(t.TempDir(), "notes.txt")))
+	require.NoError(t, s.didOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, LanguageID: "plaintext", Text: "café 😀 x"},
+	}))
+
+	// x is the 8th code unit, after the 2 of the emoji
+	require.NoError(t, s.didChange(nil, &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri}},
+		ContentChanges: []any{protocol.TextDocumentContentChangeEvent{
+			Range: &protocol.Range{Start: protocol.Position{Character: 8}, End: protocol.Position{Character: 9}},
+			Text:  "y",
+		}},
+	}))
+
+	doc, ok := s.state.GetDocument(uri)
+	require.True(t, ok)
+	assert.Equal(t, "café 😀 y", doc.Text)
+}
+
+func TestDidChangeAppliesTheChangesInOrder(t *testing.T) {
+	s := NewServer()
+	uri := protocol.DocumentUri(utils.PathToURI(filepath.Join(t.TempDir(), "notes.txt")))
+	require.NoError(t, s.didOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, LanguageID: "plaintext", Text: "one\ntwo"},
+	}))
+
+	// The second range is read in the text the first one left
+	require.NoError(t, s.didChange(nil, &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri}},
+		ContentChanges: []any{
+			protocol.TextDocumentContentChangeEvent{
+				Range: &protocol.Range{Start: protocol.Position{Line: 0, Character: 3}, End: protocol.Position{Line: 1, Character: 0}},
+				Text:  " ",
+			},
+			protocol.TextDocumentContentChangeEvent{
+				Range: &protocol.Range{Start: protocol.Position{Character: 4}, End: protocol.Position{Character: 7}},
+				Text:  "three",
+			},
+		},
+	}))
+
+	doc, ok := s.state.GetDocument(uri)
+	require.True(t, ok)
+	assert.Equal(t, "one three", doc.Text)
+	line, ok := doc.GetLine(0)
+	require.True(t, ok)
+	assert.Equal(t, "one three", line)
+}
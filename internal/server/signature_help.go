@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/shinyvision/vimfony/internal/analyzer"
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) onSignatureHelp(_ *glsp.Context, params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
+	doc, ok := s.state.GetDocument(params.TextDocument.URI)
+	if !ok || doc.Analyzer == nil || !s.config.FeatureEnabled(config.FeatureSignatureHelp) {
+		return nil, nil
+	}
+
+	if provider, ok := doc.Analyzer.(analyzer.SignatureHelpProvider); ok {
+		return provider.OnSignatureHelp(params.Position)
+	}
+	return nil, nil
+}
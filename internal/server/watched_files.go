@@ -0,0 +1,146 @@
+package server
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/config"
+	"github.com/shinyvision/vimfony/internal/protocol317"
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// The files the client watches for the server, which then needs no polling
+var watchedFilePatterns = []string{
+	"**/*.twig",
+	"**/var/cache/*/App_Kernel*Container.xml",
+	"**/var/cache/*/App_Kernel*Container.php",
+	"**/var/cache/*/url_generating_routes.php",
+	"**/translations/**",
+	"**/composer.json",
+	"**/vendor/composer/autoload_*.php",
+}
+
+const watchedFilesRegistrationID = "vimfony.watchedFiles"
+
+// Reports whether the client watches the files the server asks it to
+func clientWatchesFiles(capabilities protocol317.ClientCapabilities) bool {
+	if capabilities.Workspace == nil || capabilities.Workspace.DidChangeWatchedFiles == nil {
+		return false
+	}
+	dynamic := capabilities.Workspace.DidChangeWatchedFiles.DynamicRegistration
+	return dynamic != nil && *dynamic
+}
+
+// Asks the client to watch the files the indexes are built from
+func (s *Server) registerWatchedFiles(context *glsp.Context) {
+	if !s.watchFiles || context == nil || context.Call == nil {
+		return
+	}
+	watchers := make([]protocol.FileSystemWatcher, 0, len(watchedFilePatterns))
+	for _, pattern := range watchedFilePatterns {
+		watchers = append(watchers, protocol.FileSystemWatcher{GlobPattern: pattern})
+	}
+	params := protocol.RegistrationParams{Registrations: []protocol.Registration{{
+		ID:              watchedFilesRegistrationID,
+		Method:          string(protocol.MethodWorkspaceDidChangeWatchedFiles),
+		RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{Watchers: watchers},
+	}}}
+	// The answer is read by the goroutine handling this notification
+	go func() {
+		var result any
+		context.Call(protocol.ServerClientRegisterCapability, params, &result)
+	}()
+}
+
+// didChangeWatchedFiles rebuilds the indexes of the files the client saw
+// change, once per app and index
+func (s *Server) didChangeWatchedFiles(_ *glsp.Context, p *protocol.DidChangeWatchedFilesParams) error {
+	type reload struct {
+		app   *app
+		index string
+	}
+	var reloads []reload
+	for _, change := range p.Changes {
+		path := utils.UriToPath(string(change.URI))
+		a := s.loadedAppFor(path)
+		if a == nil {
+			continue
+		}
+		index := watchedFileIndex(a.config, path)
+		switch {
+		case index == "":
+			continue
+		case index == "templates":
+			// The list follows the files, no rebuild needed
+			switch change.Type {
+			case protocol.FileChangeTypeCreated:
+				a.config.Container.TwigTemplateCreated(path)
+			case protocol.FileChangeTypeDeleted:
+				a.config.Container.TwigTemplateDeleted(path)
+			}
+			continue
+		}
+		if r := (reload{a, index}); !slices.Contains(reloads, r) {
+			reloads = append(reloads, r)
+		}
+	}
+	if len(reloads) == 0 {
+		return nil
+	}
+
+	s.reloadLater(func() {
+		for _, r := range reloads {
+			cfg := r.app.config
+			switch r.index {
+			case "container":
+				s.loadContainer(r.app, nil)
+			case "autoload":
+				cfg.LoadAutoloadMap()
+				s.loadContainer(r.app, nil)
+				s.reindex(r.app)
+			case "routes":
+				cfg.LoadRoutesMap()
+			case "translations":
+				cfg.LoadTranslations()
+			}
+		}
+	})
+	return nil
+}
+
+// Returns the index built from the file at path, the way the artifact watcher
+// names them, or "" when none is
+func watchedFileIndex(cfg *config.Config, path string) string {
+	switch {
+	case filepath.Ext(path) == ".twig":
+		if cfg.FeatureEnabled(config.FeatureTemplates) {
+			return "templates"
+		}
+	case slices.Contains(cfg.Container.ContainerArtifacts(), path):
+		return "container"
+	case slices.Contains(cfg.AutoloadArtifacts(), path):
+		return "autoload"
+	case slices.Contains(cfg.RoutesArtifacts(), path):
+		if cfg.FeatureEnabled(config.FeatureRoutes) {
+			return "routes"
+		}
+	case underAny(cfg.Container.TranslationArtifacts(), path):
+		if cfg.FeatureEnabled(config.FeatureTranslations) {
+			return "translations"
+		}
+	}
+	return ""
+}
+
+// Reports whether path is inside one of the directories
+func underAny(dirs []string, path string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestInitializedRegistersTheWatchedFiles(t *testing.T) {
+	s := NewServer()
+	var mu sync.Mutex
+	var registered *protocol.RegistrationParams
+	client := connectServer(t, s, func(request *jsonrpc2.Request) {
+		if request.Method != string(protocol.ServerClientRegisterCapability) {
+			return
+		}
+		var params protocol.RegistrationParams
+		require.NoError(t, json.Unmarshal(*request.Params, &params))
+		mu.Lock()
+		registered = &params
+		mu.Unlock()
+	})
+
+	var result map[string]any
+	require.NoError(t, client.Call(context.Background(), protocol.MethodInitialize, map[string]any{
+		"rootUri": protocol.DocumentUri(utils.PathToURI(t.TempDir())),
+		"capabilities": map[string]any{
+			"workspace": map[string]any{"didChangeWatchedFiles": map[string]any{"dynamicRegistration": true}},
+		},
+		"initializationOptions": map[string]any{"watch_interval_ms": 50},
+	}, &result))
+	// The client watching the files spares polling them
+	assert.Nil(t, s.root.watcher)
+
+	require.NoError(t, client.Notify(context.Background(), protocol.MethodInitialized, map[string]any{}))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return registered != nil
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, registered.Registrations, 1)
+	assert.Equal(t, string(protocol.MethodWorkspaceDidChangeWatchedFiles), registered.Registrations[0].Method)
+	assert.Contains(t, registered.Registrations[0].RegisterOptions, "watchers")
+}
+
+func TestWatchedFileIndex(t *testing.T) {
+	s, root := multiAppServer(t)
+	cfg := s.config
+	cfg.VendorDir = "vendor"
+	cfg.Container.SetContainerXMLPaths([]string{"var/cache/dev/App_KernelDevDebugContainer.xml"})
+
+	for rel, index := range map[string]string{
+		"templates/post/show.html.twig":                 "templates",
+		"var/cache/dev/App_KernelDevDebugContainer.xml": "container",
+		"var/cache/dev/App_KernelDevDebugContainer.php": "container",
+		"var/cache/dev/url_generating_routes.php":       "routes",
+		"var/cache/dev/translations/catalogue.en.php":   "translations",
+		"translations/messages.en.yaml":                 "translations",
+		"composer.json":                                 "autoload",
+		"vendor/composer/autoload_psr4.php":             "autoload",
+		"var/cache/prod/App_KernelProdContainer.xml":    "",
+		"src/Controller/PostController.php":             "",
+	} {
+		assert.Equal(t, index, watchedFileIndex(cfg, filepath.Join(root, rel)), rel)
+	}
+}
+
+func TestWatchedTemplateChangesRefreshTheTemplateList(t *testing.T) {
+	s, root := multiAppServer(t)
+	write := func(rel string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		return path
+	}
+	write("templates/base.html.twig")
+	require.Equal(t, []string{"base.html.twig"}, s.config.Container.TwigTemplates())
+
+	created := write("templates/post.html.twig")
+	require.NoError(t, s.didChangeWatchedFiles(nil, &protocol.DidChangeWatchedFilesParams{Changes: []protocol.FileEvent{
+		{URI: protocol.DocumentUri(utils.PathToURI(created)), Type: protocol.FileChangeTypeCreated},
+		// Files of apps nobody opened yet leave them alone
+		{URI: protocol.DocumentUri(utils.PathToURI(write("apps/admin/templates/user.html.twig"))), Type: protocol.FileChangeTypeCreated},
+	}}))
+	assert.ElementsMatch(t, []string{"base.html.twig", "post.html.twig"}, s.config.Container.TwigTemplates())
+	assert.Len(t, s.loadedApps(), 1)
+
+	require.NoError(t, os.Remove(created))
+	require.NoError(t, s.didChangeWatchedFiles(nil, &protocol.DidChangeWatchedFilesParams{Changes: []protocol.FileEvent{
+		{URI: protocol.DocumentUri(utils.PathToURI(created)), Type: protocol.FileChangeTypeDeleted},
+	}}))
+	assert.Equal(t, []string{"base.html.twig"}, s.config.Container.TwigTemplates())
+}
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"slices"
 	"strings"
 	"sync"
 
@@ -17,6 +18,9 @@ type State struct {
 	mu       sync.RWMutex
 	docs     map[protocol.DocumentUri]*Document
 	docStore *php.DocumentStore
+	// storeFor picks the document store of a path when set, in workspaces
+	// with several apps
+	storeFor func(path string) *php.DocumentStore
 }
 
 func NewState(store *php.DocumentStore) *State {
@@ -26,6 +30,14 @@ func NewState(store *php.DocumentStore) *State {
 	}
 }
 
+// SetDocumentStoreFunc makes the documents use the store fn returns for
+// their path instead of the one given to NewState.
+func (s *State) SetDocumentStoreFunc(fn func(path string) *php.DocumentStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.storeFor = fn
+}
+
 // GetDocument retrieves a document from the state.
 func (s *State) GetDocument(uri protocol.DocumentUri) (*Document, bool) {
 	s.mu.RLock()
@@ -34,42 +46,96 @@ func (s *State) GetDocument(uri protocol.DocumentUri) (*Document, bool) {
 	return doc, ok
 }
 
-// SetDocument adds or updates a document in the state.
+// URIs returns the URIs of the open documents.
+func (s *State) URIs() []protocol.DocumentUri {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	uris := make([]protocol.DocumentUri, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	slices.Sort(uris)
+	return uris
+}
+
+// SetDocument adds or updates a document in the state. A new document is
+// set up and parsed without the lock: picking its store may load an app.
 func (s *State) SetDocument(uri protocol.DocumentUri, text string, languageID string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if existingDoc, ok := s.docs[uri]; ok {
-		// We probably never set the document if it already exists, but this doesn't hurt
-		existingDoc.Text = text
-		existingDoc.lines = strings.Split(text, "\n")
+	if s.updateDocument(uri, text) {
 		return
 	}
+
+	s.mu.RLock()
+	store, storeFor := s.docStore, s.storeFor
+	s.mu.RUnlock()
+
 	doc := NewDocument(languageID, text)
 	path := utils.UriToPath(string(uri))
 	if doc.Analyzer != nil {
 		if dsa, ok := doc.Analyzer.(analyzer.DocumentStoreAware); ok {
-			dsa.SetDocumentStore(s.docStore)
+			if storeFor != nil {
+				store = storeFor(path)
+			}
+			dsa.SetDocumentStore(store)
 		}
 		if dpa, ok := doc.Analyzer.(analyzer.DocumentPathAware); ok {
 			dpa.SetDocumentPath(path)
 		}
-	}
-	s.docs[uri] = doc
-	if doc.Analyzer != nil {
 		doc.Analyzer.Changed([]byte(text), nil)
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.docs[uri]; ok {
+		// Opened twice at once, keep the first
+		if doc.Analyzer != nil {
+			doc.Analyzer.Close()
+		}
+		return
+	}
+	s.docs[uri] = doc
 }
 
-func (s *State) ChangeDocument(uri protocol.DocumentUri, text string, change *sitter.InputEdit) {
+// Updates the text of a document already in the state
+func (s *State) updateDocument(uri protocol.DocumentUri, text string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if existingDoc, ok := s.docs[uri]; ok {
+	existingDoc, ok := s.docs[uri]
+	if ok {
+		// We probably never set the document if it already exists, but this doesn't hurt
 		existingDoc.Text = text
 		existingDoc.lines = strings.Split(text, "\n")
-		if existingDoc.Analyzer != nil {
-			existingDoc.Analyzer.Changed([]byte(text), change)
+	}
+	return ok
+}
+
+// Change is one edit of a document: its text after the edit, and the edit
+// the analyzer applies to its tree.
+type Change struct {
+	Text string
+	Edit *sitter.InputEdit
+}
+
+// ChangeDocument hands the edits of a document to its analyzer in order, then
+// keeps the text after the last one.
+func (s *State) ChangeDocument(uri protocol.DocumentUri, changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existingDoc, ok := s.docs[uri]
+	if !ok {
+		return
+	}
+	if existingDoc.Analyzer != nil {
+		for _, change := range changes {
+			existingDoc.Analyzer.Changed([]byte(change.Text), change.Edit)
 		}
 	}
+	text := changes[len(changes)-1].Text
+	existingDoc.Text = text
+	existingDoc.lines = strings.Split(text, "\n")
 }
 
 // DeleteDocument removes a document from the state.
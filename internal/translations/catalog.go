@@ -0,0 +1,77 @@
+package translations
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Catalog describes a translation resource file such as messages+intl-icu.en.yaml
+type Catalog struct {
+	Path   string
+	Domain string
+	Locale string
+	Format string
+}
+
+// ParseCatalogPath extracts domain, locale and format from a catalog filename.
+func ParseCatalogPath(path string) (Catalog, bool) {
+	parts := strings.Split(filepath.Base(path), ".")
+	if len(parts) < 3 {
+		return Catalog{}, false
+	}
+
+	format := strings.ToLower(parts[len(parts)-1])
+	switch format {
+	case "yaml", "yml":
+		format = "yaml"
+	case "xlf", "xliff":
+		format = "xlf"
+	default:
+		return Catalog{}, false
+	}
+
+	domain := strings.Join(parts[:len(parts)-2], ".")
+	domain = strings.TrimSuffix(domain, "+intl-icu")
+	if domain == "" {
+		return Catalog{}, false
+	}
+
+	return Catalog{
+		Path:   path,
+		Domain: domain,
+		Locale: parts[len(parts)-2],
+		Format: format,
+	}, true
+}
+
+// FindCatalogs returns every catalog that lives directly inside the given directories.
+func FindCatalogs(dirs []string) []Catalog {
+	seen := make(map[string]struct{})
+	var catalogs []Catalog
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if _, ok := seen[path]; ok {
+				continue
+			}
+			if catalog, ok := ParseCatalogPath(path); ok {
+				seen[path] = struct{}{}
+				catalogs = append(catalogs, catalog)
+			}
+		}
+	}
+
+	sort.Slice(catalogs, func(i, j int) bool {
+		return catalogs[i].Path < catalogs[j].Path
+	})
+	return catalogs
+}
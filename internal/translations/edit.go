@@ -0,0 +1,206 @@
+package translations
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultIndent = 4
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// AddKeyEdits builds the text edits that add a translation key to the content of a catalog.
+// It returns false when the key is already present or the catalog cannot be edited safely.
+func AddKeyEdits(format string, content []byte, key, value string) ([]protocol.TextEdit, bool) {
+	if key == "" {
+		return nil, false
+	}
+	switch format {
+	case "yaml":
+		return yamlAddKeyEdits(content, key, value)
+	case "xlf":
+		return xlfAddKeyEdits(content, key, value)
+	}
+	return nil, false
+}
+
+func yamlAddKeyEdits(content []byte, key, value string) ([]protocol.TextEdit, bool) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(content)) > 0 {
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, false
+		}
+	}
+
+	var mapping *yaml.Node
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		mapping = doc.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			return nil, false
+		}
+	}
+
+	segments := strings.Split(key, ".")
+	if mapping != nil && yamlKeyExists(mapping, segments) {
+		return nil, false
+	}
+
+	// Descend into the nested mappings that already exist for the key
+	var parentKey *yaml.Node
+	indent, step := 0, defaultIndent
+	nested := mapping != nil && !yamlHasDottedKeys(mapping)
+	for mapping != nil && nested && len(segments) > 1 {
+		k, v := yamlMappingEntry(mapping, segments[0])
+		if k == nil || v.Kind != yaml.MappingNode || v.Style&yaml.FlowStyle != 0 || len(v.Content) == 0 {
+			break
+		}
+		if diff := v.Content[0].Column - k.Column; diff > 0 {
+			step = diff
+		}
+		parentKey = k
+		indent = v.Content[0].Column - 1
+		mapping = v
+		segments = segments[1:]
+	}
+	if !nested {
+		segments = []string{strings.Join(segments, ".")}
+	}
+
+	var b strings.Builder
+	for i, segment := range segments {
+		b.WriteString(strings.Repeat(" ", indent+i*step))
+		b.WriteString(yamlScalar(segment))
+		b.WriteString(":")
+		if i == len(segments)-1 {
+			b.WriteString(" ")
+			b.WriteString(yamlScalar(value))
+		}
+		b.WriteString("\n")
+	}
+
+	if parentKey != nil {
+		pos := protocol.Position{Line: uint32(parentKey.Line), Character: 0}
+		return []protocol.TextEdit{{Range: protocol.Range{Start: pos, End: pos}, NewText: b.String()}}, true
+	}
+
+	pos := endPosition(content)
+	text := b.String()
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		text = "\n" + text
+	}
+	return []protocol.TextEdit{{Range: protocol.Range{Start: pos, End: pos}, NewText: text}}, true
+}
+
+func yamlMappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func yamlKeyExists(mapping *yaml.Node, segments []string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		for n := len(segments); n > 0; n-- {
+			if k.Value != strings.Join(segments[:n], ".") {
+				continue
+			}
+			if n == len(segments) {
+				return true
+			}
+			if v.Kind == yaml.MappingNode && yamlKeyExists(v, segments[n:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func yamlHasDottedKeys(mapping *yaml.Node) bool {
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if strings.Contains(mapping.Content[i].Value, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func yamlScalar(value string) string {
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%q", value)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+func xlfAddKeyEdits(content []byte, key, value string) ([]protocol.TextEdit, bool) {
+	text := string(content)
+	escKey := xmlEscaper.Replace(key)
+	if strings.Contains(text, `resname="`+escKey+`"`) || strings.Contains(text, `id="`+escKey+`"`) {
+		return nil, false
+	}
+
+	isV2 := strings.Contains(text, "urn:oasis:names:tc:xliff:document:2.0")
+	closing := "</body>"
+	if isV2 {
+		closing = "</file>"
+	}
+	idx := strings.LastIndex(text, closing)
+	if idx < 0 {
+		return nil, false
+	}
+
+	lineStart := strings.LastIndexByte(text[:idx], '\n') + 1
+	baseIndent := text[lineStart:idx]
+	if strings.TrimSpace(baseIndent) != "" {
+		// The closing tag shares its line with other markup
+		return nil, false
+	}
+	unit := strings.Repeat(" ", defaultIndent)
+	if strings.Contains(baseIndent, "\t") {
+		unit = "\t"
+	}
+	in := baseIndent + unit
+	// Follow the indentation of the last unit in the file when there is one
+	if lineStart > 0 {
+		prevStart := strings.LastIndexByte(text[:lineStart-1], '\n') + 1
+		prev := text[prevStart : lineStart-1]
+		prevIndent := prev[:len(prev)-len(strings.TrimLeft(prev, " \t"))]
+		if strings.TrimSpace(prev) != "" && len(prevIndent) > len(baseIndent) && strings.HasPrefix(prevIndent, baseIndent) {
+			in = prevIndent
+			unit = prevIndent[len(baseIndent):]
+		}
+	}
+	escValue := xmlEscaper.Replace(value)
+
+	var b strings.Builder
+	if isV2 {
+		fmt.Fprintf(&b, "%s<unit id=\"%s\">\n", in, escKey)
+		fmt.Fprintf(&b, "%s%s<segment>\n", in, unit)
+		fmt.Fprintf(&b, "%s%s%s<source>%s</source>\n", in, unit, unit, escKey)
+		fmt.Fprintf(&b, "%s%s%s<target>%s</target>\n", in, unit, unit, escValue)
+		fmt.Fprintf(&b, "%s%s</segment>\n", in, unit)
+		fmt.Fprintf(&b, "%s</unit>\n", in)
+	} else {
+		fmt.Fprintf(&b, "%s<trans-unit id=\"%s\" resname=\"%s\">\n", in, escKey, escKey)
+		fmt.Fprintf(&b, "%s%s<source>%s</source>\n", in, unit, escKey)
+		fmt.Fprintf(&b, "%s%s<target>%s</target>\n", in, unit, escValue)
+		fmt.Fprintf(&b, "%s</trans-unit>\n", in)
+	}
+
+	pos := protocol.Position{Line: uint32(strings.Count(text[:lineStart], "\n")), Character: 0}
+	return []protocol.TextEdit{{Range: protocol.Range{Start: pos, End: pos}, NewText: b.String()}}, true
+}
+
+func endPosition(content []byte) protocol.Position {
+	line := bytes.Count(content, []byte("\n"))
+	lastLine := content[bytes.LastIndexByte(content, '\n')+1:]
+	return protocol.Position{Line: uint32(line), Character: utils.Character(lastLine, len(lastLine))}
+}
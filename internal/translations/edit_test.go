@@ -0,0 +1,97 @@
+package translations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCatalogPath(t *testing.T) {
+	catalog, ok := ParseCatalogPath("/app/translations/messages+intl-icu.nl.yml")
+	if !ok {
+		t.Fatal("Expected catalog path to be parsed")
+	}
+	if catalog.Domain != "messages" || catalog.Locale != "nl" || catalog.Format != "yaml" {
+		t.Errorf("Unexpected catalog: %+v", catalog)
+	}
+
+	if _, ok := ParseCatalogPath("/app/translations/README.md"); ok {
+		t.Errorf("Expected README.md not to be a catalog")
+	}
+}
+
+func TestAddKeyEditsNestedYaml(t *testing.T) {
+	content := "app:\n  title: Title\nother: Other\n"
+
+	edits, ok := AddKeyEdits("yaml", []byte(content), "app.menu.home", "app.menu.home")
+	if !ok || len(edits) != 1 {
+		t.Fatalf("Expected one edit, got %v", edits)
+	}
+	if edits[0].Range.Start.Line != 1 || edits[0].Range.Start.Character != 0 {
+		t.Errorf("Expected insertion after 'app:', got %+v", edits[0].Range.Start)
+	}
+	expected := "  menu:\n    home: app.menu.home\n"
+	if edits[0].NewText != expected {
+		t.Errorf("Expected %q, got %q", expected, edits[0].NewText)
+	}
+
+	if _, ok := AddKeyEdits("yaml", []byte(content), "app.title", "app.title"); ok {
+		t.Errorf("Expected existing key not to be added again")
+	}
+}
+
+func TestAddKeyEditsFlatYaml(t *testing.T) {
+	content := "app.title: Title"
+
+	edits, ok := AddKeyEdits("yaml", []byte(content), "app.subtitle", "app.subtitle")
+	if !ok || len(edits) != 1 {
+		t.Fatalf("Expected one edit, got %v", edits)
+	}
+	if edits[0].Range.Start.Line != 0 || edits[0].Range.Start.Character != uint32(len(content)) {
+		t.Errorf("Expected insertion at end of file, got %+v", edits[0].Range.Start)
+	}
+	if edits[0].NewText != "\napp.subtitle: app.subtitle\n" {
+		t.Errorf("Unexpected text %q", edits[0].NewText)
+	}
+}
+
+func TestAddKeyEditsFlatYamlCountsUTF16(t *testing.T) {
+	content := "app.title: Café 😀"
+
+	edits, ok := AddKeyEdits("yaml", []byte(content), "app.subtitle", "app.subtitle")
+	if !ok || len(edits) != 1 {
+		t.Fatalf("Expected one edit, got %v", edits)
+	}
+	if edits[0].Range.Start.Line != 0 || edits[0].Range.Start.Character != 18 {
+		t.Errorf("Expected insertion at UTF-16 column 18, got %+v", edits[0].Range.Start)
+	}
+}
+
+func TestAddKeyEditsXliff(t *testing.T) {
+	content := `<?xml version="1.0" encoding="utf-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">
+  <file source-language="en" target-language="en" datatype="plaintext" original="file.ext">
+    <body>
+      <trans-unit id="app.title" resname="app.title">
+        <source>app.title</source>
+        <target>Title</target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+
+	edits, ok := AddKeyEdits("xlf", []byte(content), "app.subtitle", "app.subtitle")
+	if !ok || len(edits) != 1 {
+		t.Fatalf("Expected one edit, got %v", edits)
+	}
+	if edits[0].Range.Start.Line != 8 {
+		t.Errorf("Expected insertion before </body>, got line %d", edits[0].Range.Start.Line)
+	}
+	if !strings.HasPrefix(edits[0].NewText, `      <trans-unit id="app.subtitle" resname="app.subtitle">`) {
+		t.Errorf("Unexpected text %q", edits[0].NewText)
+	}
+
+	if _, ok := AddKeyEdits("xlf", []byte(content), "app.title", "app.title"); ok {
+		t.Errorf("Expected existing key not to be added again")
+	}
+}
@@ -0,0 +1,80 @@
+package translations
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	percentPlaceholderRe = regexp.MustCompile(`%[A-Za-z0-9_.-]+%`)
+	icuArgumentRe        = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*[,}]`)
+)
+
+// Message returns the text of key in the given locale, falling back to any
+// locale that defines it.
+func (m TranslationMap) Message(key, locale string) (string, bool) {
+	locs, ok := m[key]
+	if !ok || len(locs) == 0 {
+		return "", false
+	}
+	for _, loc := range locs {
+		if LocaleOf(loc.URI) == locale {
+			return loc.Message, true
+		}
+	}
+	return locs[0].Message, true
+}
+
+// LocaleOf extracts the locale of a catalog such as messages.en.yaml
+func LocaleOf(uri string) string {
+	parts := strings.Split(uri, ".")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+// Placeholders returns the parameters a message expects, in order of
+// appearance: %name% placeholders and ICU {name} arguments.
+func Placeholders(message string) []string {
+	seen := make(map[string]struct{})
+	var names []string
+	add := func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	for _, m := range percentPlaceholderRe.FindAllString(message, -1) {
+		add(m)
+	}
+	// Braces at an even depth open an argument, at an odd depth they open the
+	// sub-message of a plural or select option
+	depth := 0
+	for i := 0; i < len(message); i++ {
+		switch message[i] {
+		case '{':
+			if depth%2 == 0 {
+				if m := icuArgumentRe.FindStringSubmatch(message[i+1:]); m != nil {
+					add(m[1])
+				}
+			}
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	return names
+}
+
+// DomainOf extracts the domain of a catalog such as admin+intl-icu.en.yaml
+func DomainOf(uri string) string {
+	if catalog, ok := ParseCatalogPath(uri); ok {
+		return catalog.Domain
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package translations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	cases := map[string][]string{
+		"Hello %name%, you have %count% messages from %name%":                {"%name%", "%count%"},
+		"{count, plural, =0 {none} one {# item} other {# items}} for {user}": {"count", "user"},
+		"Nothing to replace": nil,
+	}
+	for message, want := range cases {
+		if got := Placeholders(message); !reflect.DeepEqual(got, want) {
+			t.Errorf("Placeholders(%q) = %v, want %v", message, got, want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	m := TranslationMap{
+		"greeting": {
+			{URI: "file:///app/translations/messages.nl.yaml", Message: "Hallo %name%"},
+			{URI: "file:///app/translations/messages.en.yaml", Message: "Hello %name%"},
+		},
+	}
+
+	if got, _ := m.Message("greeting", "en"); got != "Hello %name%" {
+		t.Errorf("expected English message, got %q", got)
+	}
+	if got, _ := m.Message("greeting", "de"); got != "Hallo %name%" {
+		t.Errorf("expected fallback message, got %q", got)
+	}
+	if _, ok := m.Message("missing", "en"); ok {
+		t.Error("expected missing key to be reported")
+	}
+}
@@ -5,14 +5,16 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/shinyvision/vimfony/internal/utils"
 	"github.com/tliron/commonlog"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 	"gopkg.in/yaml.v3"
 )
 
 type TranslationLocation struct {
-	URI   string
-	Range protocol.Range
+	URI     string
+	Range   protocol.Range
+	Message string
 }
 
 type TranslationMap map[string][]TranslationLocation
@@ -43,25 +45,23 @@ func Parse(resources []string) TranslationMap {
 }
 
 func parseYamlFile(path string, translations TranslationMap) {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return
 	}
-	defer file.Close()
 
 	var node yaml.Node
-	decoder := yaml.NewDecoder(file)
-	if err := decoder.Decode(&node); err != nil {
+	if err := yaml.Unmarshal(data, &node); err != nil {
 		return
 	}
 
-	traverseYamlNode(&node, "", path, translations)
+	traverseYamlNode(&node, "", path, data, translations)
 }
 
-func traverseYamlNode(node *yaml.Node, prefix string, path string, translations TranslationMap) {
+func traverseYamlNode(node *yaml.Node, prefix string, path string, data []byte, translations TranslationMap) {
 	if node.Kind == yaml.DocumentNode {
 		for _, child := range node.Content {
-			traverseYamlNode(child, prefix, path, translations)
+			traverseYamlNode(child, prefix, path, data, translations)
 		}
 		return
 	}
@@ -79,20 +79,18 @@ func traverseYamlNode(node *yaml.Node, prefix string, path string, translations
 
 			switch valueNode.Kind {
 			case yaml.ScalarNode:
-				// Corrects for 1-based line / column
-				line := uint32(keyNode.Line - 1)
-				col := uint32(keyNode.Column - 1)
+				start := utils.RunePosition(data, keyNode.Line, keyNode.Column)
+				end := start
+				end.Character += utils.Character([]byte(key), len(key))
 
 				loc := TranslationLocation{
-					URI: "file://" + path,
-					Range: protocol.Range{
-						Start: protocol.Position{Line: line, Character: col},
-						End:   protocol.Position{Line: line, Character: col + uint32(len(key))},
-					},
+					URI:     "file://" + path,
+					Range:   protocol.Range{Start: start, End: end},
+					Message: valueNode.Value,
 				}
 				translations[fullKey] = append(translations[fullKey], loc)
 			case yaml.MappingNode:
-				traverseYamlNode(valueNode, fullKey, path, translations)
+				traverseYamlNode(valueNode, fullKey, path, data, translations)
 			}
 		}
 	}
@@ -71,3 +71,24 @@ nextline.key:
 		t.Errorf("Expected key 'nextline.key' to be found")
 	}
 }
+
+func TestParseKeyRangeCountsUTF16(t *testing.T) {
+	tmpDir := t.TempDir()
+	// ü takes 2 bytes and 1 code unit, 😀 takes 4 bytes and 2 code units
+	filename := filepath.Join(tmpDir, "messages.en.yaml")
+	err := os.WriteFile(filename, []byte("{ grüße: Hallo, 😀: Smile, after: Done }\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	translations := Parse([]string{filename})
+
+	locations := translations["after"]
+	if len(locations) != 1 {
+		t.Fatalf("Expected key 'after' to be found once, got %d", len(locations))
+	}
+	rng := locations[0].Range
+	if rng.Start.Character != 27 || rng.End.Character != 32 {
+		t.Errorf("Expected 'after' at characters 27-32, got %d-%d", rng.Start.Character, rng.End.Character)
+	}
+}
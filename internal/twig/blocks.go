@@ -0,0 +1,80 @@
+package twig
+
+import (
+	"os"
+	"regexp"
+
+	"github.com/shinyvision/vimfony/internal/config"
+)
+
+var (
+	extendsRe = regexp.MustCompile(`\{%-?\s*extends\s+["']([^"']+)["']`)
+	blockRe   = regexp.MustCompile(`\{%-?\s*block\s+([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// Block is a block declared by a template of the inheritance chain
+type Block struct {
+	Name     string
+	Template string
+}
+
+// Extends returns the parent template named by the {% extends %} tag.
+func Extends(content string) (string, bool) {
+	m := extendsRe.FindStringSubmatch(content)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Blocks returns the names of the blocks declared in content, in order.
+func Blocks(content string) []string {
+	seen := make(map[string]struct{})
+	var names []string
+	for _, m := range blockRe.FindAllStringSubmatch(content, -1) {
+		if _, ok := seen[m[1]]; ok {
+			continue
+		}
+		seen[m[1]] = struct{}{}
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// InheritedBlocks follows the {% extends %} chain of content and returns the
+// blocks of every parent, the closest declaration of a name first.
+func InheritedBlocks(content string, cfg *config.ContainerConfig) []Block {
+	var blocks []Block
+	seenBlocks := make(map[string]struct{})
+	seenTemplates := make(map[string]struct{})
+
+	for range 10 { // Limit the depth in case of an inheritance loop
+		parent, ok := Extends(content)
+		if !ok {
+			break
+		}
+		path, ok := Resolve(parent, cfg)
+		if !ok {
+			break
+		}
+		if _, ok := seenTemplates[path]; ok {
+			break
+		}
+		seenTemplates[path] = struct{}{}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			break
+		}
+		content = string(data)
+
+		for _, name := range Blocks(content) {
+			if _, ok := seenBlocks[name]; ok {
+				continue
+			}
+			seenBlocks[name] = struct{}{}
+			blocks = append(blocks, Block{Name: name, Template: parent})
+		}
+	}
+	return blocks
+}
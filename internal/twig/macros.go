@@ -0,0 +1,54 @@
+package twig
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	importRe = regexp.MustCompile(`\{%-?\s*import\s+(?:["']([^"']+)["']|(_self))\s+as\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	macroRe  = regexp.MustCompile(`\{%-?\s*macro\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(([^)]*)\)`)
+)
+
+// SelfTemplate is the name {% import _self as ... %} imports from
+const SelfTemplate = "_self"
+
+// Macro is a {% macro %} declared in a template
+type Macro struct {
+	Name   string
+	Params []string
+}
+
+// Signature renders the macro as name(param, other = "default")
+func (m Macro) Signature() string {
+	return m.Name + "(" + strings.Join(m.Params, ", ") + ")"
+}
+
+// Imports maps the aliases of {% import 'template' as alias %} tags to the
+// imported template.
+func Imports(content string) map[string]string {
+	imports := make(map[string]string)
+	for _, m := range importRe.FindAllStringSubmatch(content, -1) {
+		template := m[1]
+		if template == "" {
+			template = m[2]
+		}
+		imports[m[3]] = template
+	}
+	return imports
+}
+
+// Macros returns the macros declared in content, in order.
+func Macros(content string) []Macro {
+	var macros []Macro
+	for _, m := range macroRe.FindAllStringSubmatch(content, -1) {
+		var params []string
+		for _, param := range strings.Split(m[2], ",") {
+			if param = strings.Join(strings.Fields(param), " "); param != "" {
+				params = append(params, param)
+			}
+		}
+		macros = append(macros, Macro{Name: m[1], Params: params})
+	}
+	return macros
+}
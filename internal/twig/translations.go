@@ -0,0 +1,14 @@
+package twig
+
+import "regexp"
+
+var transDefaultDomainRe = regexp.MustCompile(`\{%-?\s*trans_default_domain\s+["']([^"']+)["']`)
+
+// DefaultDomain returns the domain set by {% trans_default_domain 'admin' %},
+// or an empty string when the template doesn't set one.
+func DefaultDomain(content string) string {
+	if m := transDefaultDomainRe.FindStringSubmatch(content); m != nil {
+		return m[1]
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/shinyvision/vimfony/internal/config"
@@ -18,7 +19,7 @@ var twigFuncRe = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*\(`)
 
 // PathAt returns the Twig path at a given position in the content.
 func PathAt(content string, pos protocol.Position) (string, bool) {
-	offset := pos.IndexIn(content) // LSP UTF-16 -> byte offset
+	offset := utils.ByteOffset([]byte(content), pos)
 
 	// helper: search with a regex whose capture group 1 is the path
 	findWith := func(re *regexp.Regexp) (string, bool) {
@@ -46,7 +47,7 @@ func PathAt(content string, pos protocol.Position) (string, bool) {
 }
 
 func FunctionAt(content string, pos protocol.Position) (string, bool) {
-	offset := pos.IndexIn(content)
+	offset := utils.ByteOffset([]byte(content), pos)
 
 	idxs := twigFuncRe.FindAllStringSubmatchIndex(content, -1)
 	for _, m := range idxs {
@@ -70,38 +71,82 @@ func normalize(p string) string {
 
 // Resolve resolves a Twig path to an absolute file path.
 func Resolve(rel string, cfg *config.ContainerConfig) (string, bool) {
+	paths := ResolveAll(rel, cfg)
+	if len(paths) == 0 {
+		return "", false
+	}
+	return paths[0], true
+}
+
+// ResolveAll resolves a Twig path to every file it may refer to: for
+// @Bundle/ paths the override in templates/bundles/<Name>Bundle comes first,
+// followed by the bundle's own template.
+func ResolveAll(rel string, cfg *config.ContainerConfig) []string {
 	orig := rel
 	rel = normalize(rel)
 
+	var found []string
 	candidatesTried := make([]string, 0, 8)
+	try := func(cand string) {
+		candidatesTried = append(candidatesTried, cand)
+		if slices.Contains(found, cand) {
+			return
+		}
+		if info, err := os.Stat(cand); err == nil && !info.IsDir() {
+			found = append(found, cand)
+		}
+	}
+	rootBase := func(root string) string {
+		if filepath.IsAbs(root) {
+			return root
+		}
+		return filepath.Join(cfg.WorkspaceRoot, root)
+	}
 
 	// Try bundle resolution first: "<Bundle>/path/to/file.twig"
 	parts := strings.SplitN(rel, string(filepath.Separator), 2)
 	if len(parts) == 2 {
 		bundle, remainder := parts[0], parts[1]
-		if bases, ok := cfg.BundleRoots[bundle]; ok {
-			for _, base := range bases {
-				cand := filepath.Join(base, remainder)
-				candidatesTried = append(candidatesTried, cand)
-				if info, err := os.Stat(cand); err == nil && !info.IsDir() {
-					return cand, true
-				}
+		// @!Bundle/ refers to the bundle's own template, skipping the override
+		original := strings.HasPrefix(bundle, "!")
+		bundle = strings.TrimPrefix(bundle, "!")
+
+		dir := bundle
+		if !strings.HasSuffix(dir, "Bundle") {
+			dir += "Bundle"
+		}
+		var overrides []string
+		if strings.HasPrefix(orig, "@") {
+			for _, root := range cfg.Roots {
+				overrides = append(overrides, filepath.Join(rootBase(root), "bundles", dir))
 			}
 		}
+		if !original {
+			for _, override := range overrides {
+				try(filepath.Join(override, remainder))
+			}
+		}
+
+		bases, ok := cfg.BundleRoots[bundle]
+		if !ok {
+			// Namespaces are registered without the Bundle suffix
+			bases = cfg.BundleRoots[strings.TrimSuffix(bundle, "Bundle")]
+		}
+		for _, base := range bases {
+			if original && slices.Contains(overrides, filepath.Clean(base)) {
+				continue
+			}
+			try(filepath.Join(base, remainder))
+		}
+		if len(found) > 0 {
+			return found
+		}
 	}
 
 	// Fall back to bare roots
 	for _, root := range cfg.Roots {
-		var base string
-		if filepath.IsAbs(root) {
-			base = root
-		} else {
-			base = filepath.Join(cfg.WorkspaceRoot, root)
-		}
-		cand := filepath.Join(base, rel)
-		candidatesTried = append(candidatesTried, cand)
-		if info, err := os.Stat(cand); err == nil && !info.IsDir() {
-			return cand, true
+		if try(filepath.Join(rootBase(root), rel)); len(found) > 0 {
+			return found
 		}
 	}
 
@@ -118,9 +163,56 @@ func Resolve(rel string, cfg *config.ContainerConfig) (string, bool) {
 		}
 	}
 
+	return nil
+}
+
+// TemplateName returns the name under which Twig knows the template at path,
+// preferring the bare roots over bundle namespaces.
+func TemplateName(path string, cfg *config.ContainerConfig) (string, bool) {
+	relTo := func(base string) (string, bool) {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(cfg.WorkspaceRoot, base)
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+		return filepath.ToSlash(rel), true
+	}
+
+	for _, root := range cfg.Roots {
+		if rel, ok := relTo(root); ok {
+			return rel, true
+		}
+	}
+	for bundle, bases := range cfg.BundleRoots {
+		for _, base := range bases {
+			if rel, ok := relTo(base); ok {
+				return "@" + bundle + "/" + rel, true
+			}
+		}
+	}
 	return "", false
 }
 
+// NewTemplatePath returns where a template of the application that does not
+// exist yet goes: under the first bare root. Bundle templates have no such
+// place.
+func NewTemplatePath(name string, cfg *config.ContainerConfig) (string, bool) {
+	if strings.HasPrefix(name, "@") || strings.Contains(name, ":") || len(cfg.Roots) == 0 {
+		return "", false
+	}
+	base := cfg.Roots[0]
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(cfg.WorkspaceRoot, base)
+	}
+	path := filepath.Join(base, normalize(name))
+	if rel, err := filepath.Rel(base, path); err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return path, true
+}
+
 func ResolveFunction(functionName string, cfg *config.Config) (string, protocol.Range, bool) {
 	if location, ok := cfg.Container.TwigFunctions[functionName]; ok {
 		return utils.UriToPath(location.URI), location.Range, true
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bytes"
+	"sync/atomic"
+	"unicode/utf8"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Whether the client agreed on counting the characters of positions in bytes
+// instead of the UTF-16 code units of the spec
+var utf8Positions atomic.Bool
+
+// SetUTF8Positions sets whether the client agreed on the utf-8 position
+// encoding
+func SetUTF8Positions(enabled bool) {
+	utf8Positions.Store(enabled)
+}
+
+// ByteOffset returns the byte offset in content of pos, or -1 when its line is
+// past the end. A character past the end of the line is the end of the line.
+func ByteOffset(content []byte, pos protocol.Position) int {
+	line, start, ok := Line(content, pos.Line)
+	if !ok {
+		return -1
+	}
+	column, _ := ByteColumn(line, pos.Character)
+	return start + column
+}
+
+// Line returns a line of content without its newline and the byte offset it
+// starts at, or false when content has fewer lines
+func Line(content []byte, line uint32) ([]byte, int, bool) {
+	start := 0
+	for ; line > 0; line-- {
+		next := bytes.IndexByte(content[start:], '\n')
+		if next < 0 {
+			return nil, 0, false
+		}
+		start += next + 1
+	}
+	text := content[start:]
+	if end := bytes.IndexByte(text, '\n'); end >= 0 {
+		text = text[:end]
+	}
+	return text, start, true
+}
+
+// ByteColumn returns the byte offset in line of the character of a position,
+// and false when the character is past the end of the line, where it stops.
+// A character in the middle of a surrogate pair is the start of its rune.
+func ByteColumn(line []byte, character uint32) (int, bool) {
+	if utf8Positions.Load() {
+		if int(character) > len(line) {
+			return len(line), false
+		}
+		return int(character), true
+	}
+
+	column := 0
+	for units := uint32(0); units < character; {
+		if column >= len(line) {
+			return len(line), false
+		}
+		r, size := utf8.DecodeRune(line[column:])
+		width := uint32(1)
+		if r >= 0x10000 {
+			width = 2
+		}
+		if units+width > character {
+			break
+		}
+		units += width
+		column += size
+	}
+	return column, true
+}
+
+// Character returns the character of a position at the byte column of line:
+// the UTF-16 code units before it, or the column itself when the client agreed
+// on utf-8. A column past the end of the line is the end of the line.
+func Character(line []byte, column int) uint32 {
+	if column > len(line) {
+		column = len(line)
+	}
+	if utf8Positions.Load() {
+		return uint32(column)
+	}
+	units := uint32(0)
+	for i := 0; i < column; {
+		r, size := utf8.DecodeRune(line[i:column])
+		units++
+		if r >= 0x10000 {
+			units++
+		}
+		i += size
+	}
+	return units
+}
+
+// Position returns the position of the byte column of a line of content, the
+// way tree-sitter points and regexp matches count them
+func Position(content []byte, line, column int) protocol.Position {
+	text, _, ok := Line(content, uint32(line))
+	if !ok {
+		return protocol.Position{Line: uint32(line), Character: uint32(column)}
+	}
+	return protocol.Position{Line: uint32(line), Character: Character(text, column)}
+}
+
+// RunePosition returns the position of a 1-based line and column counted in
+// runes, the way gopkg.in/yaml.v3 reports the nodes it decodes
+func RunePosition(content []byte, line, column int) protocol.Position {
+	line, column = max(line-1, 0), max(column-1, 0)
+	text, _, ok := Line(content, uint32(line))
+	if !ok {
+		return protocol.Position{Line: uint32(line), Character: uint32(column)}
+	}
+	offset := 0
+	for ; column > 0 && offset < len(text); column-- {
+		_, size := utf8.DecodeRune(text[offset:])
+		offset += size
+	}
+	return protocol.Position{Line: uint32(line), Character: Character(text, offset)}
+}
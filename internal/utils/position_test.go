@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestByteOffsetCountsUTF16CodeUnits(t *testing.T) {
+	content := []byte("{# café #}\n{{ '😀' ~ x }}\n")
+
+	// é is 2 bytes but 1 code unit, 😀 is 4 bytes and 2 code units
+	assert.Equal(t, 9, ByteOffset(content, protocol.Position{Line: 0, Character: 8}))
+	assert.Equal(t, 12+8, ByteOffset(content, protocol.Position{Line: 1, Character: 6}))
+	// The middle of a surrogate pair is the start of its rune
+	assert.Equal(t, 12+4, ByteOffset(content, protocol.Position{Line: 1, Character: 5}))
+	// Past the end of the line is the end of the line
+	assert.Equal(t, 11, ByteOffset(content, protocol.Position{Line: 0, Character: 40}))
+	assert.Equal(t, len(content), ByteOffset(content, protocol.Position{Line: 2, Character: 0}))
+	assert.Equal(t, -1, ByteOffset(content, protocol.Position{Line: 3, Character: 0}))
+}
+
+func TestByteOffsetCountsBytesWithUTF8Positions(t *testing.T) {
+	SetUTF8Positions(true)
+	t.Cleanup(func() { SetUTF8Positions(false) })
+	content := []byte("{# café #}\n")
+
+	assert.Equal(t, 8, ByteOffset(content, protocol.Position{Line: 0, Character: 8}))
+	column, ok := ByteColumn([]byte("café"), 6)
+	assert.False(t, ok)
+	assert.Equal(t, 5, column)
+}
+
+func TestLine(t *testing.T) {
+	line, start, ok := Line([]byte("a\nbc\n"), 1)
+	assert.True(t, ok)
+	assert.Equal(t, "bc", string(line))
+	assert.Equal(t, 2, start)
+
+	_, _, ok = Line([]byte("a\nbc"), 2)
+	assert.False(t, ok)
+}
+
+func TestPositionCountsUTF16CodeUnits(t *testing.T) {
+	content := []byte("{# café #}\n{{ '😀' ~ x }}\n")
+
+	assert.Equal(t, protocol.Position{Line: 0, Character: 8}, Position(content, 0, 9))
+	assert.Equal(t, protocol.Position{Line: 1, Character: 6}, Position(content, 1, 8))
+	// Past the end of the line is the end of the line
+	assert.Equal(t, protocol.Position{Line: 0, Character: 10}, Position(content, 0, 40))
+	assert.Equal(t, uint32(2), Character([]byte("😀"), 4))
+	// yaml.v3 reports 1-based lines and columns counted in runes
+	assert.Equal(t, protocol.Position{Line: 1, Character: 9}, RunePosition(content, 2, 9))
+
+	SetUTF8Positions(true)
+	t.Cleanup(func() { SetUTF8Positions(false) })
+	assert.Equal(t, protocol.Position{Line: 0, Character: 9}, Position(content, 0, 9))
+}
@@ -0,0 +1,756 @@
+// Package yaml turns the tree-sitter-yaml syntax tree of a file into a tree
+// of positioned nodes. Documents that are being edited still produce the
+// structure around the caret: what the grammar could not parse is attached
+// by its indentation, which is what completion and definitions need.
+package yaml
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	tsyaml "github.com/alexaandru/go-sitter-forest/yaml"
+	sitter "github.com/alexaandru/go-tree-sitter-bare"
+	"github.com/shinyvision/vimfony/internal/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+type Kind int
+
+const (
+	Scalar Kind = iota
+	Mapping
+	Sequence
+)
+
+// Node is a value of a document. The entries of a mapping are the nodes of
+// their values, carrying the key; the items of a sequence have no key.
+type Node struct {
+	Kind     Kind
+	Parent   *Node
+	Children []*Node
+
+	Key      string
+	KeyRange protocol.Range
+	// Incomplete is set on keys that are typed without their colon yet
+	Incomplete bool
+
+	// Value is the text of a scalar, without quotes or escapes
+	Value string
+	Range protocol.Range
+
+	Tag         string
+	Anchor      string
+	AnchorRange protocol.Range
+	// Alias is the anchor name of an *alias value
+	Alias string
+
+	// Column of the key or item marker, -1 for documents
+	indent int
+	// Set on flow collections, whose children don't follow the indentation
+	flow bool
+}
+
+// IsEntry reports whether the node is the value of a mapping key
+func (n *Node) IsEntry() bool {
+	return n.Parent != nil && n.Parent.Kind == Mapping
+}
+
+// Get returns the entry of a mapping with the given key
+func (n *Node) Get(key string) *Node {
+	if n == nil || n.Kind != Mapping {
+		return nil
+	}
+	for _, child := range n.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+	return nil
+}
+
+// Path returns the keys from the document root to the node, with "-" for
+// the items of sequences.
+func (n *Node) Path() []string {
+	var path []string
+	for cur := n; cur.Parent != nil; cur = cur.Parent {
+		if cur.Parent.Kind == Sequence {
+			path = append(path, "-")
+		} else {
+			path = append(path, cur.Key)
+		}
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// Document returns the root of the document the node belongs to
+func (n *Node) Document() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// FindAnchor returns the node below root that declares the &name anchor
+func FindAnchor(root *Node, name string) *Node {
+	if root == nil {
+		return nil
+	}
+	if root.Anchor == name {
+		return root
+	}
+	for _, child := range root.Children {
+		if found := FindAnchor(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// NodeAt returns the innermost node whose key or scalar value contains pos,
+// and whether pos is on the key.
+func NodeAt(docs []*Node, pos protocol.Position) (*Node, bool) {
+	var found *Node
+	onKey := false
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.IsEntry() && contains(n.KeyRange, pos) {
+			found, onKey = n, true
+		} else if n.Kind == Scalar && n.Parent != nil && contains(n.Range, pos) {
+			found, onKey = n, false
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	for _, doc := range docs {
+		walk(doc)
+	}
+	return found, onKey
+}
+
+// ParentAt returns the node that a key starting at pos, on a line of its
+// own, belongs to: the last block node above it that is indented less.
+func ParentAt(docs []*Node, pos protocol.Position) *Node {
+	var parent *Node
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Parent != nil {
+			start := n.Range.Start
+			if n.IsEntry() {
+				start = n.KeyRange.Start
+			}
+			if start.Line >= pos.Line || n.Parent.flow {
+				return
+			}
+			if n.indent < int(pos.Character) {
+				parent = n
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	for _, doc := range docs {
+		if doc.Range.Start.Line > pos.Line {
+			break
+		}
+		parent = doc
+		walk(doc)
+	}
+	return parent
+}
+
+func contains(r protocol.Range, pos protocol.Position) bool {
+	before := func(a, b protocol.Position) bool {
+		return a.Line < b.Line || (a.Line == b.Line && a.Character <= b.Character)
+	}
+	return before(r.Start, pos) && before(pos, r.End)
+}
+
+// NewParser returns a parser for the YAML grammar, whose trees FromTree reads
+func NewParser() *sitter.Parser {
+	parser := sitter.NewParser()
+	_ = parser.SetLanguage(sitter.NewLanguage(tsyaml.GetLanguage()))
+	return parser
+}
+
+// Parse returns the root node of every document in content, being typed on
+// the caret line or -1.
+func Parse(content string, caretLine int) []*Node {
+	parser := NewParser()
+	defer parser.Close()
+	tree, err := parser.ParseString(context.Background(), nil, []byte(content))
+	if err != nil {
+		return []*Node{newDocument(0)}
+	}
+	defer tree.Close()
+	return FromTree(tree, []byte(content), caretLine)
+}
+
+// FromTree returns the root node of every document of a tree parsed from
+// content, being typed on the caret line or -1.
+func FromTree(tree *sitter.Tree, content []byte, caretLine int) []*Node {
+	b := &builder{content: content, lines: strings.Split(string(content), "\n"), caretLine: caretLine}
+	for i, line := range b.lines {
+		b.lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	var docs []*Node
+	root := tree.RootNode()
+	if root.Type() != "stream" {
+		// Nothing could be parsed
+		doc := newDocument(0)
+		b.recover(doc, root)
+		finishRange(doc)
+		return []*Node{doc}
+	}
+	for i := uint32(0); i < root.NamedChildCount(); i++ {
+		child := root.NamedChild(i)
+		switch child.Type() {
+		case "document":
+			line := 0
+			if len(docs) > 0 {
+				line = int(child.StartPoint().Row)
+			}
+			docs = append(docs, b.document(child, line))
+		case "ERROR":
+			if len(docs) == 0 {
+				docs = append(docs, newDocument(0))
+			}
+			doc := docs[len(docs)-1]
+			b.recover(doc, child)
+			finishRange(doc)
+		}
+	}
+	if len(docs) == 0 {
+		docs = append(docs, newDocument(0))
+	}
+	return docs
+}
+
+func newDocument(line int) *Node {
+	return &Node{indent: -1, Range: emptyRange(position(line, 0))}
+}
+
+type builder struct {
+	content []byte
+	lines   []string
+	// The line being typed, where an incomplete key is more likely than a
+	// value continued from the line above
+	caretLine int
+}
+
+func (b *builder) document(ts sitter.Node, line int) *Node {
+	doc := newDocument(line)
+	for i := uint32(0); i < ts.ChildCount(); i++ {
+		child := ts.Child(i)
+		switch child.Type() {
+		case "---":
+			// Documents start below their marker
+			doc.Range = emptyRange(position(int(child.StartPoint().Row)+1, 0))
+		case "block_node", "flow_node":
+			b.value(doc, child)
+		case "ERROR":
+			b.recover(doc, child)
+		}
+	}
+	finishRange(doc)
+	return doc
+}
+
+// Fills n with the value the syntax node holds
+func (b *builder) value(n *Node, ts sitter.Node) {
+	text := ts.Content(b.content)
+	switch ts.Type() {
+	case "block_node", "flow_node":
+		// The &anchor and !tag come before the value
+		empty := true
+		for i := uint32(0); i < ts.NamedChildCount(); i++ {
+			child := ts.NamedChild(i)
+			switch child.Type() {
+			case "anchor":
+				n.Anchor = strings.TrimPrefix(child.Content(b.content), "&")
+				n.AnchorRange = b.rangeOf(child)
+			case "tag":
+				n.Tag = child.Content(b.content)
+			case "comment":
+			default:
+				b.value(n, child)
+				empty = false
+			}
+		}
+		if empty {
+			n.Range = emptyRange(b.pastBlanks(ts.EndPoint()))
+		}
+	case "alias":
+		n.Alias = strings.TrimPrefix(text, "*")
+		n.Value = text
+		n.Range = b.rangeOf(ts)
+	case "plain_scalar":
+		n.Value = fold(text)
+		n.Range = b.rangeOf(ts)
+	case "single_quote_scalar", "double_quote_scalar":
+		n.Value = unquote(text)
+		n.Range = b.rangeOf(ts)
+	case "block_scalar":
+		b.blockScalar(n, ts)
+	case "block_mapping":
+		n.Kind = Mapping
+		for i := uint32(0); i < ts.NamedChildCount(); i++ {
+			switch child := ts.NamedChild(i); child.Type() {
+			case "block_mapping_pair":
+				b.pair(n, child)
+			case "ERROR":
+				b.recover(n, child)
+			}
+		}
+	case "block_sequence":
+		n.Kind = Sequence
+		for i := uint32(0); i < ts.NamedChildCount(); i++ {
+			switch child := ts.NamedChild(i); child.Type() {
+			case "block_sequence_item":
+				b.item(n, child)
+			case "ERROR":
+				b.recover(n, child)
+			}
+		}
+	case "flow_mapping", "flow_sequence":
+		b.flow(n, ts)
+	case "ERROR":
+		b.recover(n, ts)
+	default:
+		n.Value = strings.TrimSpace(text)
+		n.Range = b.rangeOf(ts)
+	}
+}
+
+// Adds the entry of a block_mapping_pair or flow_pair to the mapping
+func (b *builder) pair(mapping *Node, ts sitter.Node) *Node {
+	entry := &Node{Parent: mapping, indent: int(ts.StartPoint().Column)}
+	if mapping.flow {
+		entry.indent = mapping.indent
+	}
+	mapping.Children = append(mapping.Children, entry)
+
+	key := ts.ChildByFieldName("key")
+	if key.IsNull() {
+		entry.KeyRange = emptyRange(b.pointPosition(ts.StartPoint()))
+	} else {
+		entry.Key = b.scalarValue(key)
+		entry.KeyRange = b.rangeOf(key)
+	}
+
+	value := ts.ChildByFieldName("value")
+	if value.IsNull() {
+		colon := childOfType(ts, ":")
+		if colon.IsNull() {
+			entry.Range = emptyRange(entry.KeyRange.End)
+		} else if mapping.flow {
+			// An empty value spans the spaces in front of the delimiter
+			entry.Range = protocol.Range{Start: b.pointPosition(colon.EndPoint()), End: b.pointPosition(colon.EndPoint())}
+			if next := ts.NextSibling(); !next.IsNull() {
+				entry.Range.End = b.pointPosition(next.StartPoint())
+			}
+		} else {
+			entry.Range = emptyRange(b.pastBlanks(colon.EndPoint()))
+		}
+		return entry
+	}
+
+	if typed := plainScalar(value); !mapping.flow && !key.IsNull() && !typed.IsNull() &&
+		typed.StartPoint().Row > key.EndPoint().Row && typed.StartPoint().Row == typed.EndPoint().Row &&
+		(ts.HasError() || int(typed.StartPoint().Row) == b.caretLine) {
+		// A plain line below the key is valid YAML, but where the grammar gave
+		// up or the caret is, it is a key whose colon isn't typed yet
+		entry.Kind = Mapping
+		entry.Children = append(entry.Children, &Node{
+			Parent:     entry,
+			Key:        typed.Content(b.content),
+			KeyRange:   b.rangeOf(typed),
+			Incomplete: true,
+			Range:      emptyRange(b.pointPosition(typed.EndPoint())),
+			indent:     int(typed.StartPoint().Column),
+		})
+		return entry
+	}
+	b.value(entry, value)
+	return entry
+}
+
+// Adds a block_sequence_item to the sequence
+func (b *builder) item(sequence *Node, ts sitter.Node) *Node {
+	item := &Node{Parent: sequence, indent: int(ts.StartPoint().Column)}
+	sequence.Children = append(sequence.Children, item)
+	item.Range = emptyRange(b.pastBlanks(ts.Child(0).EndPoint()))
+	for i := uint32(0); i < ts.NamedChildCount(); i++ {
+		switch child := ts.NamedChild(i); child.Type() {
+		case "block_node", "flow_node":
+			b.value(item, child)
+		case "ERROR":
+			b.recover(item, child)
+		}
+	}
+	return item
+}
+
+func (b *builder) flow(n *Node, ts sitter.Node) {
+	n.Kind = Mapping
+	if ts.Type() == "flow_sequence" {
+		n.Kind = Sequence
+	}
+	n.flow = true
+	n.Range = b.rangeOf(ts)
+	for i := uint32(0); i < ts.NamedChildCount(); i++ {
+		child := ts.NamedChild(i)
+		switch {
+		case child.Type() == "ERROR":
+			b.recover(n, child)
+		case n.Kind == Sequence:
+			item := &Node{Parent: n, indent: n.indent}
+			n.Children = append(n.Children, item)
+			if child.Type() == "flow_pair" {
+				// [key: value] is a sequence of single entry mappings
+				item.Kind = Mapping
+				item.flow = true
+				item.Range = b.rangeOf(child)
+				b.pair(item, child)
+				continue
+			}
+			b.value(item, child)
+		case child.Type() == "flow_pair":
+			b.pair(n, child)
+		case child.Type() == "flow_node":
+			// A key without a value
+			n.Children = append(n.Children, &Node{
+				Parent:   n,
+				Key:      b.scalarValue(child),
+				KeyRange: b.rangeOf(child),
+				Range:    emptyRange(b.pointPosition(child.EndPoint())),
+				indent:   n.indent,
+			})
+		}
+	}
+}
+
+func (b *builder) blockScalar(n *Node, ts sitter.Node) {
+	header := int(ts.StartPoint().Row)
+	last := int(ts.EndPoint().Row)
+	if ts.EndPoint().Column == 0 {
+		last--
+	}
+	n.Range = emptyRange(position(header+1, 0))
+	var value strings.Builder
+	for line := header + 1; line <= last && line < len(b.lines); line++ {
+		text := b.lines[line]
+		if strings.TrimSpace(text) == "" && value.Len() == 0 {
+			n.Range = emptyRange(position(line+1, 0))
+			continue
+		}
+		if value.Len() == 0 {
+			n.Range.Start = b.position(line, indentOf(text))
+		}
+		value.WriteString(strings.TrimSpace(text) + "\n")
+		n.Range.End = b.position(line, len(text))
+	}
+	n.Value = value.String()
+}
+
+// Attaches what the grammar could not parse by its indentation, the way the
+// lines nest once the document is valid again. The syntax nodes inside the
+// error are still whole pairs, items and scalars.
+func (b *builder) recover(parent *Node, ts sitter.Node) {
+	// The nodes the next line can belong to: the ancestors of parent and the
+	// last nodes added below it
+	var stack []*Node
+	for cur := parent; cur != nil; cur = cur.Parent {
+		stack = append(stack, cur)
+	}
+	slices.Reverse(stack)
+	for cur := parent; len(cur.Children) > 0 && !cur.flow; {
+		cur = cur.Children[len(cur.Children)-1]
+		stack = append(stack, cur)
+	}
+	// Returns the node that owns the part starting at col
+	owner := func(col int, item bool) *Node {
+		for len(stack) > 1 {
+			top := stack[len(stack)-1]
+			if top.indent < col || item && top.indent == col && top.IsEntry() && (top.Kind == Sequence || top.empty()) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		return stack[len(stack)-1]
+	}
+
+	parts := b.errorParts(ts)
+	for i := 0; i < len(parts); i++ {
+		part := parts[i]
+		col := int(part.StartPoint().Column)
+		switch part.Type() {
+		case "block_mapping_pair", "flow_pair":
+			if mapping := asCollection(owner(col, false), Mapping); mapping != nil {
+				stack = append(stack, b.pair(mapping, part))
+			}
+		case "block_sequence_item":
+			if sequence := asCollection(owner(col, true), Sequence); sequence != nil {
+				stack = append(stack, b.item(sequence, part))
+			}
+		case "-":
+			sequence := asCollection(owner(col, true), Sequence)
+			if sequence == nil {
+				continue
+			}
+			item := &Node{Parent: sequence, indent: col, Range: emptyRange(b.pastBlanks(part.EndPoint()))}
+			sequence.Children = append(sequence.Children, item)
+			if i+1 < len(parts) && isValue(parts[i+1]) && parts[i+1].StartPoint().Row == part.EndPoint().Row {
+				i++
+				b.value(item, parts[i])
+			}
+			stack = append(stack, item)
+		case ":":
+		default:
+			if !isValue(part) {
+				continue
+			}
+			if i+1 < len(parts) && parts[i+1].Type() == ":" {
+				// A key the grammar did not pair with its value
+				mapping := asCollection(owner(col, false), Mapping)
+				colon := parts[i+1]
+				i++
+				if mapping == nil {
+					continue
+				}
+				entry := &Node{
+					Parent:   mapping,
+					Key:      b.scalarValue(part),
+					KeyRange: b.rangeOf(part),
+					Range:    emptyRange(b.pastBlanks(colon.EndPoint())),
+					indent:   col,
+				}
+				mapping.Children = append(mapping.Children, entry)
+				if i+1 < len(parts) && isValue(parts[i+1]) && parts[i+1].StartPoint().Row == colon.EndPoint().Row {
+					i++
+					b.value(entry, parts[i])
+				}
+				stack = append(stack, entry)
+				continue
+			}
+			if typed := plainScalar(part); !typed.IsNull() && b.startsLine(part) {
+				// A key whose colon isn't typed yet
+				if mapping := asCollection(owner(col, false), Mapping); mapping != nil {
+					mapping.Children = append(mapping.Children, &Node{
+						Parent:     mapping,
+						Key:        typed.Content(b.content),
+						KeyRange:   b.rangeOf(typed),
+						Incomplete: true,
+						Range:      emptyRange(b.pointPosition(typed.EndPoint())),
+						indent:     col,
+					})
+				}
+			}
+		}
+	}
+}
+
+// Flattens the children of an error down to pairs, items, values and the
+// : and - tokens between them
+func (b *builder) errorParts(ts sitter.Node) []sitter.Node {
+	var parts []sitter.Node
+	for i := uint32(0); i < ts.ChildCount(); i++ {
+		child := ts.Child(i)
+		switch child.Type() {
+		case "ERROR", "block_mapping", "block_sequence":
+			parts = append(parts, b.errorParts(child)...)
+		case "block_node":
+			if child.NamedChildCount() == 1 {
+				parts = append(parts, b.errorParts(child)...)
+			} else {
+				parts = append(parts, child)
+			}
+		case ":", "-":
+			parts = append(parts, child)
+		default:
+			if child.IsNamed() && child.Type() != "comment" {
+				parts = append(parts, child)
+			}
+		}
+	}
+	return parts
+}
+
+// Returns the text of a key, without its quotes
+func (b *builder) scalarValue(ts sitter.Node) string {
+	n := &Node{}
+	b.value(n, ts)
+	if n.Kind != Scalar {
+		return strings.TrimSpace(ts.Content(b.content))
+	}
+	return n.Value
+}
+
+// Returns the position past the blanks that follow p on its line
+func (b *builder) pastBlanks(p sitter.Point) protocol.Position {
+	line, col := int(p.Row), int(p.Column)
+	if line < len(b.lines) {
+		text := b.lines[line]
+		for col < len(text) && (text[col] == ' ' || text[col] == '\t') {
+			col++
+		}
+	}
+	return b.position(line, col)
+}
+
+func (b *builder) startsLine(ts sitter.Node) bool {
+	line := int(ts.StartPoint().Row)
+	if line >= len(b.lines) {
+		return false
+	}
+	col := min(int(ts.StartPoint().Column), len(b.lines[line]))
+	return strings.TrimSpace(b.lines[line][:col]) == ""
+}
+
+// Returns n, turned into a collection of the kind when it has no value yet,
+// or nil when it holds something else
+func asCollection(n *Node, kind Kind) *Node {
+	if n.empty() {
+		n.Kind = kind
+	}
+	if n.Kind != kind {
+		return nil
+	}
+	return n
+}
+
+func (n *Node) empty() bool {
+	return n.Kind == Scalar && n.Value == "" && n.Alias == "" && n.Tag == "" && len(n.Children) == 0
+}
+
+// Returns the plain_scalar a node holds, without properties
+func plainScalar(ts sitter.Node) sitter.Node {
+	for ts.Type() == "flow_node" && ts.NamedChildCount() == 1 {
+		ts = ts.NamedChild(0)
+	}
+	if ts.Type() != "plain_scalar" {
+		return sitter.Node{}
+	}
+	return ts
+}
+
+func isValue(ts sitter.Node) bool {
+	switch ts.Type() {
+	case "block_node", "flow_node", "plain_scalar", "single_quote_scalar", "double_quote_scalar", "block_scalar", "alias", "flow_mapping", "flow_sequence":
+		return true
+	}
+	return false
+}
+
+func childOfType(ts sitter.Node, kind string) sitter.Node {
+	for i := uint32(0); i < ts.ChildCount(); i++ {
+		if child := ts.Child(i); child.Type() == kind {
+			return child
+		}
+	}
+	return sitter.Node{}
+}
+
+// Computes the ranges of block collections from their children
+func finishRange(n *Node) {
+	for _, child := range n.Children {
+		finishRange(child)
+	}
+	if n.Kind == Scalar || len(n.Children) == 0 || n.flow {
+		return
+	}
+	first, last := n.Children[0], n.Children[len(n.Children)-1]
+	// Documents start at their --- marker
+	if n.Parent != nil {
+		n.Range.Start = first.Range.Start
+		if first.IsEntry() {
+			n.Range.Start = first.KeyRange.Start
+		}
+	}
+	n.Range.End = last.Range.End
+}
+
+// Joins the lines of a multi-line scalar with spaces
+func fold(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		if i > 0 {
+			lines[i] = strings.TrimLeft(lines[i], " \t")
+		}
+		if i < len(lines)-1 {
+			lines[i] = strings.TrimRight(lines[i], " \t\r")
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// Returns the value of a quoted scalar, which may lack its closing quote
+func unquote(text string) string {
+	if text == "" {
+		return ""
+	}
+	quote, body := text[0], text[1:]
+	closed := strings.HasSuffix(body, string(quote)) && !strings.HasSuffix(body, `\"`)
+	if quote == '\'' {
+		// Quotes are escaped by doubling them, so the closing one is odd
+		closed = (len(body)-len(strings.TrimRight(body, "'")))%2 == 1
+	}
+	if closed {
+		body = body[:len(body)-1]
+	}
+	body = fold(body)
+
+	if quote == '\'' {
+		return strings.ReplaceAll(body, "''", "'")
+	}
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' || i+1 == len(body) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(body[i])
+		}
+	}
+	return b.String()
+}
+
+func indentOf(text string) int {
+	return len(text) - len(strings.TrimLeft(text, " \t"))
+}
+
+func (b *builder) rangeOf(ts sitter.Node) protocol.Range {
+	return protocol.Range{Start: b.pointPosition(ts.StartPoint()), End: b.pointPosition(ts.EndPoint())}
+}
+
+func emptyRange(pos protocol.Position) protocol.Range {
+	return protocol.Range{Start: pos, End: pos}
+}
+
+func (b *builder) pointPosition(p sitter.Point) protocol.Position {
+	return b.position(int(p.Row), int(p.Column))
+}
+
+// Returns the position of a byte column, in the characters the client counts
+func (b *builder) position(line, col int) protocol.Position {
+	if line < len(b.lines) {
+		return protocol.Position{Line: uint32(line), Character: utils.Character([]byte(b.lines[line]), col)}
+	}
+	return position(line, col)
+}
+
+func position(line, col int) protocol.Position {
+	return protocol.Position{Line: uint32(line), Character: uint32(col)}
+}
@@ -0,0 +1,205 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func positionOf(t *testing.T, content, needle string, offset int) protocol.Position {
+	idx := strings.Index(content, needle)
+	require.NotEqualf(t, -1, idx, "needle %q not found", needle)
+	target := idx + offset
+	line := strings.Count(content[:target], "\n")
+	col := target - strings.LastIndex(content[:target], "\n") - 1
+	return protocol.Position{Line: uint32(line), Character: uint32(col)}
+}
+
+func TestParseBlockStructure(t *testing.T) {
+	content := `services:
+    _defaults:
+        autowire: true # comment
+    App\Service\Foo:
+        arguments:
+            - '@app.bar'
+            - "App\\Baz"
+        tags:
+        - { name: kernel.event_listener, event: kernel.request }
+        - name: app.tag
+          priority: 10
+`
+	docs := Parse(content, -1)
+	require.Len(t, docs, 1)
+
+	services := docs[0].Get("services")
+	require.NotNil(t, services)
+	assert.Equal(t, Mapping, services.Kind)
+	assert.Equal(t, "true", services.Get("_defaults").Get("autowire").Value)
+
+	foo := services.Get(`App\Service\Foo`)
+	require.NotNil(t, foo)
+	args := foo.Get("arguments")
+	require.Equal(t, Sequence, args.Kind)
+	require.Len(t, args.Children, 2)
+	assert.Equal(t, "@app.bar", args.Children[0].Value)
+	assert.Equal(t, `App\Baz`, args.Children[1].Value)
+
+	tags := foo.Get("tags")
+	require.Equal(t, Sequence, tags.Kind)
+	require.Len(t, tags.Children, 2)
+	assert.Equal(t, "kernel.request", tags.Children[0].Get("event").Value)
+	assert.Equal(t, "10", tags.Children[1].Get("priority").Value)
+	assert.Equal(t, []string{"services", `App\Service\Foo`, "tags", "-", "priority"}, tags.Children[1].Get("priority").Path())
+}
+
+func TestParseScalars(t *testing.T) {
+	content := `folded: >
+    first line
+    second line
+quoted: 'it''s
+    continued'
+plain: one
+    two
+after: value
+`
+	doc := Parse(content, -1)[0]
+	assert.Equal(t, "first line\nsecond line\n", doc.Get("folded").Value)
+	assert.Equal(t, "it's continued", doc.Get("quoted").Value)
+	assert.Equal(t, "one two", doc.Get("plain").Value)
+	assert.Equal(t, "value", doc.Get("after").Value)
+}
+
+func TestParseFlowAcrossLines(t *testing.T) {
+	content := `calls:
+    - [setLogger, ['@logger',
+        '@?cache']]
+next: { a: 1, b: [x, y] }
+`
+	doc := Parse(content, -1)[0]
+	call := doc.Get("calls").Children[0]
+	require.Equal(t, Sequence, call.Kind)
+	require.Len(t, call.Children, 2)
+	assert.Equal(t, "setLogger", call.Children[0].Value)
+	args := call.Children[1]
+	require.Len(t, args.Children, 2)
+	assert.Equal(t, "@?cache", args.Children[1].Value)
+
+	next := doc.Get("next")
+	assert.Equal(t, "1", next.Get("a").Value)
+	assert.Equal(t, "y", next.Get("b").Children[1].Value)
+}
+
+func TestParseAnchorsAndDocuments(t *testing.T) {
+	content := `base: &base
+    class: App\Foo
+other:
+    <<: *base
+---
+second: !tagged_iterator app.handler
+`
+	docs := Parse(content, -1)
+	require.Len(t, docs, 2)
+
+	base := FindAnchor(docs[0], "base")
+	require.NotNil(t, base)
+	assert.Equal(t, "base", base.Key)
+	assert.Equal(t, "base", docs[0].Get("other").Get("<<").Alias)
+
+	second := docs[1].Get("second")
+	assert.Equal(t, "!tagged_iterator", second.Tag)
+	assert.Equal(t, "app.handler", second.Value)
+}
+
+func TestParseIncompleteInput(t *testing.T) {
+	content := `services:
+    app.foo:
+        arguments: ['@app.bar']
+        cla
+    other: value
+next: value
+`
+	doc := Parse(content, -1)[0]
+	services := doc.Get("services")
+	foo := services.Get("app.foo")
+	require.NotNil(t, foo)
+	assert.Equal(t, "@app.bar", foo.Get("arguments").Children[0].Value)
+
+	typed := foo.Get("cla")
+	require.NotNil(t, typed)
+	assert.True(t, typed.Incomplete)
+
+	assert.Equal(t, "value", services.Get("other").Value)
+	assert.Equal(t, "value", doc.Get("next").Value)
+}
+
+func TestParseKeyBelowEmptyKey(t *testing.T) {
+	content := `services:
+    app.foo:
+        cla
+`
+	foo := Parse(content, 2)[0].Get("services").Get("app.foo")
+	require.NotNil(t, foo)
+	require.Equal(t, Mapping, foo.Kind)
+
+	typed := foo.Get("cla")
+	require.NotNil(t, typed)
+	assert.True(t, typed.Incomplete)
+	assert.Equal(t, positionOf(t, content, "cla", 0), typed.KeyRange.Start)
+}
+
+func TestParseScalarBelowKey(t *testing.T) {
+	content := `services:
+    app.foo:
+        class:
+            App\Foo
+`
+	class := Parse(content, -1)[0].Get("services").Get("app.foo").Get("class")
+	require.NotNil(t, class)
+	assert.Equal(t, Scalar, class.Kind)
+	assert.Equal(t, "App\\Foo", class.Value)
+	assert.Nil(t, class.Get("App\\Foo"))
+}
+
+func TestNodeAt(t *testing.T) {
+	content := `services:
+    app.foo:
+        class: App\Foo
+        arguments: { $bar: '@app.bar' }
+`
+	docs := Parse(content, -1)
+
+	node, onKey := NodeAt(docs, positionOf(t, content, "App\\Foo", 3))
+	require.NotNil(t, node)
+	assert.False(t, onKey)
+	assert.Equal(t, "class", node.Key)
+
+	node, onKey = NodeAt(docs, positionOf(t, content, "app.foo", 2))
+	require.NotNil(t, node)
+	assert.True(t, onKey)
+	assert.Equal(t, []string{"services", "app.foo"}, node.Path())
+
+	node, _ = NodeAt(docs, positionOf(t, content, "@app.bar", 4))
+	require.NotNil(t, node)
+	assert.Equal(t, "@app.bar", node.Value)
+	assert.Equal(t, "$bar", node.Key)
+}
+
+func TestParentAt(t *testing.T) {
+	content := `services:
+    app.foo:
+        class: App\Foo
+
+    app.bar: ~
+---
+parameters:
+`
+	docs := Parse(content, -1)
+
+	assert.Equal(t, []string{"services", "app.foo"}, ParentAt(docs, protocol.Position{Line: 3, Character: 8}).Path())
+	assert.Equal(t, []string{"services"}, ParentAt(docs, protocol.Position{Line: 3, Character: 4}).Path())
+	assert.Equal(t, []string{"services", "app.bar"}, ParentAt(docs, protocol.Position{Line: 5, Character: 8}).Path())
+	assert.Same(t, docs[1], ParentAt(docs, protocol.Position{Line: 7, Character: 0}))
+}
@@ -1,15 +1,38 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/shinyvision/vimfony/internal/server"
-	"github.com/tliron/commonlog"
 	_ "github.com/tliron/commonlog/simple"
 )
 
 func main() {
-	commonlog.Configure(1, nil)
+	listen := flag.String("listen", "", "serve on a TCP address, such as 0.0.0.0:9257, instead of stdio")
+	socket := flag.String("socket", "", "serve on a Unix socket at this path instead of stdio")
+	logLevel := flag.String("log-level", "", "none, critical, error, warning, notice, info or debug, info by default")
+	logFile := flag.String("log-file", "", "write the logs to this file, rotated at 10 MB, instead of stderr")
+	flag.Parse()
+
+	if err := server.ConfigureLogging(*logLevel, *logFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	s := server.NewServer()
-	s.Run()
+	var err error
+	switch {
+	case *listen != "":
+		err = s.RunTCP(*listen)
+	case *socket != "":
+		err = s.RunSocket(*socket)
+	default:
+		s.Run()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
-